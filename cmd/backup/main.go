@@ -0,0 +1,9 @@
+package main
+
+import (
+	"s1ntez/internal/run"
+)
+
+func main() {
+	run.Backup()
+}
@@ -0,0 +1,263 @@
+// Package abuse keeps spammers out of the bot: users an admin blocked,
+// users banned for a while for flooding, and suspicious newcomers until
+// they answer a simple question.
+package abuse
+
+import (
+	"context"
+	"math/rand/v2"
+	"regexp"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// CallbackPrefix is the prefix of the captcha answer buttons
+const CallbackPrefix = "captcha"
+
+// captchaTTL is how long a question may stay unanswered
+const captchaTTL = 10 * time.Minute
+
+// linkPattern finds links and channel mentions, what spam accounts
+// advertise in their names and first messages
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.|t\.me/|@\w{4,}`)
+
+// Service screens every update before it is handled
+type Service struct {
+	storage *postgres.PostgresStorage
+	redis   *redis.Storage
+	roles   *auth.Service
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, redisStorage *redis.Storage, roles *auth.Service, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		redis:   redisStorage,
+		roles:   roles,
+		botAPI:  botAPI,
+		logger:  logger.Named("abuse"),
+		cfg:     cfg,
+	}
+}
+
+// Screen reports whether the update was dealt with here and must not be
+// handled: the user is blocked, banned, flooding or owes a captcha. If a
+// check fails the update is let through; a spammer getting in is better
+// than everyone being locked out.
+func (s *Service) Screen(ctx context.Context, update tgbotapi.Update) (bool, error) {
+	user := update.SentFrom()
+	if user == nil {
+		return false, nil
+	}
+
+	standing, err := s.storage.GetStanding(ctx, user.ID)
+	if err != nil {
+		s.logger.Warn("Failed to get user standing", zap.Int64("user_id", user.ID), zap.Error(err))
+		return false, nil
+	}
+	// Blocked and banned users were told once; the rest is ignored
+	if standing.Blocked || time.Now().Before(standing.BannedUntil) {
+		return true, nil
+	}
+
+	c := s.cfg.Abuse
+	over, strikes, err := s.redis.CountUpdate(ctx, user.ID, c.FloodLimit, c.FloodWindow, c.StrikeWindow)
+	if err != nil {
+		s.logger.Warn("Failed to count update", zap.Int64("user_id", user.ID), zap.Error(err))
+	} else if over && !s.staff(ctx, user.ID) {
+		return true, s.strike(ctx, update, user.ID, strikes)
+	}
+
+	if query := update.CallbackQuery; query != nil && strings.HasPrefix(query.Data, CallbackPrefix+":") {
+		return true, s.answer(ctx, query)
+	}
+
+	// Questions are asked in private only, not to be spread over groups
+	if chat := update.FromChat(); !c.Captcha || standing.Known || standing.Verified || chat == nil || !chat.IsPrivate() {
+		return false, nil
+	}
+	pending, err := s.redis.GetCaptcha(ctx, user.ID)
+	if err != nil {
+		s.logger.Warn("Failed to get captcha", zap.Int64("user_id", user.ID), zap.Error(err))
+		return false, nil
+	}
+	if pending == nil && !suspicious(update) {
+		return false, nil
+	}
+	attempts := 0
+	if pending != nil {
+		attempts = pending.Attempts
+	}
+	return true, s.challenge(ctx, update, user.ID, attempts)
+}
+
+// staff are never throttled: a busy manager may well go over the limit
+func (s *Service) staff(ctx context.Context, userID int64) bool {
+	role, err := s.roles.Role(ctx, userID)
+	return err == nil && role.AtLeast(auth.Production)
+}
+
+// strike warns about the first strikes and bans on the last one. Updates
+// over the limit after the first one in a window go unanswered.
+func (s *Service) strike(ctx context.Context, update tgbotapi.Update, userID int64, strikes int64) error {
+	if strikes == 0 {
+		return nil
+	}
+	if strikes < s.cfg.Abuse.StrikesToBan {
+		s.notify(ctx, update, userID, "abuse.slow_down")
+		return nil
+	}
+	return s.ban(ctx, update, userID)
+}
+
+func (s *Service) ban(ctx context.Context, update tgbotapi.Update, userID int64) error {
+	until, err := s.storage.BanUser(ctx, userID, s.cfg.Abuse.BanDuration, s.cfg.Abuse.MaxBan)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.ResetStrikes(ctx, userID); err != nil {
+		s.logger.Warn("Failed to reset strikes", zap.Int64("user_id", userID), zap.Error(err))
+	}
+
+	s.logger.Info("User banned", zap.Int64("user_id", userID), zap.Time("until", until))
+	s.notify(ctx, update, userID, "abuse.banned", until.Format("02.01.2006 15:04"))
+	return nil
+}
+
+// challenge sends a new question, keeping the count of wrong answers
+func (s *Service) challenge(ctx context.Context, update tgbotapi.Update, userID int64, attempts int) error {
+	a, b := rand.IntN(9)+1, rand.IntN(9)+1
+	captcha := redis.Captcha{Answer: a + b, Attempts: attempts}
+	if err := s.redis.SetCaptcha(ctx, userID, captcha, captchaTTL); err != nil {
+		return err
+	}
+
+	// The answer and three wrong ones, all possible sums
+	options := []int{captcha.Answer}
+	for len(options) < 4 {
+		n := rand.IntN(17) + 2
+		if !slices.Contains(options, n) {
+			options = append(options, n)
+		}
+	}
+	rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(options))
+	for _, n := range options {
+		label := strconv.Itoa(n)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, CallbackPrefix+":"+label))
+	}
+
+	if query := update.CallbackQuery; query != nil {
+		_, _ = s.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	}
+	locale := s.locale(ctx, userID)
+	msg := tgbotapi.NewMessage(update.FromChat().ID, i18n.T(locale, "abuse.captcha", a, b))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	_, err := s.botAPI.Send(msg)
+	return err
+}
+
+// answer checks a pressed captcha button. Too many wrong answers ban the
+// user like flooding does.
+func (s *Service) answer(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	userID := query.From.ID
+	update := tgbotapi.Update{CallbackQuery: query}
+	// Questions are always sent as messages of a chat
+	if query.Message == nil {
+		_, _ = s.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return nil
+	}
+
+	pending, err := s.redis.GetCaptcha(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		// Answered already, or expired: a new question if one is still owed
+		standing, err := s.storage.GetStanding(ctx, userID)
+		if err != nil || standing.Known || standing.Verified {
+			_, _ = s.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+			return err
+		}
+		return s.challenge(ctx, update, userID, 0)
+	}
+
+	_, raw, _ := strings.Cut(query.Data, ":")
+	if n, err := strconv.Atoi(raw); err == nil && n == pending.Answer {
+		if err := s.storage.MarkHuman(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.redis.DropCaptcha(ctx, userID); err != nil {
+			s.logger.Warn("Failed to drop captcha", zap.Int64("user_id", userID), zap.Error(err))
+		}
+		_, _ = s.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		if query.Message != nil {
+			_, _ = s.botAPI.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+				i18n.T(s.locale(ctx, userID), "abuse.captcha_passed")))
+		}
+		return nil
+	}
+
+	attempts := pending.Attempts + 1
+	if attempts >= s.cfg.Abuse.CaptchaAttempts {
+		if err := s.redis.DropCaptcha(ctx, userID); err != nil {
+			s.logger.Warn("Failed to drop captcha", zap.Int64("user_id", userID), zap.Error(err))
+		}
+		return s.ban(ctx, update, userID)
+	}
+	return s.challenge(ctx, update, userID, attempts)
+}
+
+// notify tells the user why they are not being served
+func (s *Service) notify(ctx context.Context, update tgbotapi.Update, userID int64, key string, args ...any) {
+	text := i18n.T(s.locale(ctx, userID), key, args...)
+
+	if query := update.CallbackQuery; query != nil {
+		_, _ = s.botAPI.Request(tgbotapi.NewCallbackWithAlert(query.ID, text))
+		return
+	}
+	chat := update.FromChat()
+	if chat == nil {
+		return
+	}
+	if _, err := s.botAPI.Send(tgbotapi.NewMessage(chat.ID, text)); err != nil {
+		s.logger.Warn("Failed to send abuse notice", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+func (s *Service) locale(ctx context.Context, userID int64) i18n.Locale {
+	locale, err := s.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	return locale
+}
+
+// suspicious picks the first-time users worth a captcha: accounts that
+// look automated or advertise in their name, and first messages with links
+func suspicious(update tgbotapi.Update) bool {
+	user := update.SentFrom()
+	if user.IsBot || user.LanguageCode == "" {
+		return true
+	}
+	if linkPattern.MatchString(user.FirstName + " " + user.LastName) {
+		return true
+	}
+	if msg := update.Message; msg != nil {
+		return linkPattern.MatchString(msg.Text) || linkPattern.MatchString(msg.Caption)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Package analytics copies orders to a warehouse every night, flattened
+// with their texture, delivery and customer fields, so BI queries run
+// there instead of against the orders table
+package analytics
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const KindExport = "analytics_export"
+
+// settle is how recent a change may be to wait for the next run, see
+// ChangedOrdersForAnalytics
+const settle = 10 * time.Minute
+
+// Service exports the orders changed since its last run to the target
+// chosen by ANALYTICS_TARGET
+type Service struct {
+	storage *postgres.PostgresStorage
+	sink    Sink
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, logger *zap.Logger, cfg *config.Config) *Service {
+	c := cfg.Analytics
+	var sink Sink
+	switch c.Target {
+	case "clickhouse":
+		sink = newClickHouse(c.ClickHouseURL, c.ClickHouseDatabase, c.ClickHouseUser, c.ClickHousePassword, c.Timeout)
+	default:
+		sink = &postgresSink{storage: storage}
+	}
+
+	return &Service{
+		storage: storage,
+		sink:    sink,
+		logger:  logger.Named("analytics"),
+		cfg:     cfg,
+	}
+}
+
+// Export is the job handler: it sends batches of changed orders until
+// none are left. The cursor moves after every batch, so a retry resumes
+// where the failed run stopped.
+func (s *Service) Export(ctx context.Context, job *postgres.Job) error {
+	target := s.cfg.Analytics.Target
+	cursor, err := s.storage.AnalyticsCursor(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	total := 0
+	for {
+		orders, err := s.storage.ChangedOrdersForAnalytics(ctx, cursor, settle, s.cfg.Analytics.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			break
+		}
+
+		for i := range orders {
+			orders[i].ServiceType = orders[i].ServiceType.OrLeather()
+			orders[i].Customer = postgres.Pseudonymize(s.cfg.Export.AnonymizationKey, orders[i].UserID)
+		}
+		if err := s.sink.Write(ctx, orders); err != nil {
+			return err
+		}
+
+		last := orders[len(orders)-1]
+		cursor = postgres.AnalyticsCursor{UpdatedAt: last.UpdatedAt, OrderID: last.ID}
+		if err := s.storage.SaveAnalyticsCursor(ctx, target, cursor); err != nil {
+			return err
+		}
+		total += len(orders)
+
+		if len(orders) < s.cfg.Analytics.BatchSize {
+			break
+		}
+	}
+
+	s.logger.Info("Analytics export finished",
+		zap.String("target", target),
+		zap.Int("orders", total),
+		zap.Time("cursor", cursor.UpdatedAt),
+		zap.Duration("took", time.Since(started)))
+	return nil
+}
+
+// Watch queues the export for ANALYTICS_HOUR every night until ctx is
+// cancelled
+func (s *Service) Watch(ctx context.Context) {
+	for {
+		runAt := nextRun(time.Now(), s.cfg.Analytics.Hour)
+		queued, err := s.storage.ScheduleJob(ctx, KindExport, runAt, s.cfg.Jobs.MaxAttempts)
+		if err != nil {
+			s.logger.Error("Failed to schedule analytics export", zap.Error(err))
+		} else if queued {
+			s.logger.Info("Analytics export scheduled", zap.Time("run_at", runAt))
+		}
+
+		// try again after the run, or sooner after an error
+		wait := time.Until(runAt) + time.Minute
+		if err != nil {
+			wait = min(wait, 5*time.Minute)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// nextRun is the next time the clock shows the hour
+func nextRun(now time.Time, hour int) time.Time {
+	run := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !run.After(now) {
+		run = run.AddDate(0, 0, 1)
+	}
+	return run
+}
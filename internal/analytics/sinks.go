@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+)
+
+// Sink receives the exported orders. Writing an order again replaces the
+// earlier copy, so a retried batch does no harm.
+type Sink interface {
+	Write(ctx context.Context, orders []postgres.AnalyticsOrder) error
+}
+
+// postgresSink keeps the orders in the analytics schema of the same
+// database
+type postgresSink struct {
+	storage *postgres.PostgresStorage
+}
+
+func (p *postgresSink) Write(ctx context.Context, orders []postgres.AnalyticsOrder) error {
+	return p.storage.UpsertAnalyticsOrders(ctx, orders)
+}
+
+// clickhouseSink inserts over the ClickHouse HTTP interface. The table is a
+// ReplacingMergeTree, which keeps the latest version of every order.
+type clickhouseSink struct {
+	url      string
+	database string
+	user     string
+	password string
+	client   *http.Client
+
+	ready bool
+}
+
+const clickhouseTable = `
+CREATE TABLE IF NOT EXISTS %s.orders (
+    id                    Int64,
+    customer              String,
+    status                LowCardinality(String),
+    service_type          LowCardinality(String),
+    is_rush               Bool,
+    currency              LowCardinality(String),
+    price                 Decimal(12, 2),
+    discount              Decimal(12, 2),
+    shipping              Decimal(12, 2),
+    rush_surcharge        Decimal(12, 2),
+    leather_cost          Decimal(12, 2),
+    process_cost          Decimal(12, 2),
+    total_cost            Decimal(12, 2),
+    commission            Decimal(12, 2),
+    tax                   Decimal(12, 2),
+    net_revenue           Decimal(12, 2),
+    profit                Decimal(12, 2),
+    delivery_method       LowCardinality(String),
+    promo_code            String,
+    texture_id            UUID,
+    texture_name          String,
+    items                 UInt32,
+    quantity              UInt32,
+    material_dm2          Decimal(14, 2),
+    customer_locale       LowCardinality(String),
+    customer_since        Nullable(DateTime64(3, 'UTC')),
+    customer_order_number UInt32,
+    created_at            DateTime64(3, 'UTC'),
+    updated_at            DateTime64(6, 'UTC')
+)
+ENGINE = ReplacingMergeTree(updated_at)
+PARTITION BY toYYYYMM(created_at)
+ORDER BY id`
+
+func (c *clickhouseSink) Write(ctx context.Context, orders []postgres.AnalyticsOrder) error {
+	if !c.ready {
+		if err := c.exec(ctx, fmt.Sprintf(clickhouseTable, c.database), nil); err != nil {
+			return fmt.Errorf("failed to create ClickHouse table: %w", err)
+		}
+		c.ready = true
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, order := range orders {
+		order.CreatedAt = order.CreatedAt.UTC()
+		order.UpdatedAt = order.UpdatedAt.UTC()
+		if err := encoder.Encode(order); err != nil {
+			return fmt.Errorf("failed to encode order %d: %w", order.ID, err)
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s.orders FORMAT JSONEachRow", c.database)
+	if err := c.exec(ctx, query, &body); err != nil {
+		return fmt.Errorf("failed to insert into ClickHouse: %w", err)
+	}
+	return nil
+}
+
+// exec runs the query, with the rows of an INSERT as the request body
+func (c *clickhouseSink) exec(ctx context.Context, query string, rows io.Reader) error {
+	params := url.Values{}
+	params.Set("query", query)
+	// times are written in RFC 3339
+	params.Set("date_time_input_format", "best_effort")
+
+	if rows == nil {
+		rows = strings.NewReader("")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/?"+params.Encode(), rows)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-ClickHouse-User", c.user)
+	req.Header.Set("X-ClickHouse-Key", c.password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func newClickHouse(rawURL, database, user, password string, timeout time.Duration) *clickhouseSink {
+	return &clickhouseSink{
+		url:      strings.TrimSuffix(rawURL, "/"),
+		database: database,
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
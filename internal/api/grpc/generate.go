@@ -0,0 +1,9 @@
+// Package grpc serves the integration API defined in api/proto/adtime.proto.
+// The adtimev1 stubs are generated with protoc-gen-go and protoc-gen-go-grpc
+// and committed, so the service builds without protoc. Regenerate them after
+// changing the proto:
+//
+//	go generate ./internal/api/grpc
+package grpc
+
+//go:generate protoc -I ../../../api/proto --go_out=. --go_opt=module=s1ntez/internal/api/grpc --go-grpc_out=. --go-grpc_opt=module=s1ntez/internal/api/grpc adtime.proto
@@ -0,0 +1,241 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"s1ntez/internal/api/grpc/adtimev1"
+	"s1ntez/internal/config"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	apiKeyMetadata  = "x-api-key"
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+type Server struct {
+	adtimev1.UnimplementedAdtimeServiceServer
+
+	storage *postgres.PostgresStorage
+	orders  *orders.Service
+	logger  *zap.Logger
+	cfg     *config.Config
+
+	keys [][sha256.Size]byte
+}
+
+func New(storage *postgres.PostgresStorage, orderService *orders.Service, logger *zap.Logger, cfg *config.Config) *Server {
+	keys := make([][sha256.Size]byte, 0, len(cfg.GRPC.Keys))
+	for _, key := range cfg.GRPC.Keys {
+		if key != "" {
+			keys = append(keys, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return &Server{
+		storage: storage,
+		orders:  orderService,
+		logger:  logger.Named("grpc"),
+		cfg:     cfg,
+		keys:    keys,
+	}
+}
+
+// Start serves gRPC until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	if len(s.keys) == 0 {
+		return errors.New("grpc: no API keys configured")
+	}
+
+	lis, err := net.Listen("tcp", s.cfg.GRPC.Addr)
+	if err != nil {
+		return fmt.Errorf("grpc: failed to listen: %w", err)
+	}
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(s.recoverer, s.authenticate))
+	adtimev1.RegisterAdtimeServiceServer(srv, s)
+
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	s.logger.Info("gRPC server listening", zap.String("addr", s.cfg.GRPC.Addr))
+	if err := srv.Serve(lis); err != nil {
+		return fmt.Errorf("grpc: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *adtimev1.GetOrderRequest) (*adtimev1.Order, error) {
+	order, err := s.storage.GetOrderByID(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return toProtoOrder(order), nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *adtimev1.ListOrdersRequest) (*adtimev1.ListOrdersResponse, error) {
+	limit := int(req.GetPageSize())
+	if limit <= 0 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+
+	var status postgres.OrderStatus
+	if req.GetStatus() != "" {
+		var err error
+		if status, err = postgres.ParseOrderStatus(req.GetStatus()); err != nil {
+			return nil, s.toStatus(err)
+		}
+	}
+
+	filter := postgres.OrderFilter{
+		Status:  status,
+		AfterID: req.GetPageToken(),
+		Limit:   limit,
+	}
+	if req.GetUpdatedSince() != nil {
+		filter.UpdatedSince = req.GetUpdatedSince().AsTime()
+	}
+
+	list, err := s.storage.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &adtimev1.ListOrdersResponse{Orders: make([]*adtimev1.Order, 0, len(list))}
+	for i := range list {
+		resp.Orders = append(resp.Orders, toProtoOrder(&list[i]))
+	}
+	// A short page means the end of the list
+	if len(list) == limit {
+		resp.NextPageToken = list[len(list)-1].ID
+	}
+	return resp, nil
+}
+
+func (s *Server) UpdateOrderStatus(ctx context.Context, req *adtimev1.UpdateOrderStatusRequest) (*adtimev1.Order, error) {
+	status, err := postgres.ParseOrderStatus(req.GetStatus())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	if _, err := s.orders.ChangeStatus(ctx, req.GetId(), status); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	order, err := s.storage.GetOrderByID(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return toProtoOrder(order), nil
+}
+
+func (s *Server) ListTextures(ctx context.Context, _ *adtimev1.ListTexturesRequest) (*adtimev1.ListTexturesResponse, error) {
+	textures, err := s.storage.GetAvailableTextures(ctx)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &adtimev1.ListTexturesResponse{Textures: make([]*adtimev1.Texture, 0, len(textures))}
+	for _, t := range textures {
+		resp.Textures = append(resp.Textures, &adtimev1.Texture{
+			Id:          t.ID,
+			Name:        t.Name,
+			PricePerDm2: t.PricePerDM2,
+			InStock:     t.InStock,
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var key string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(apiKeyMetadata); len(values) > 0 {
+			key = values[0]
+		}
+	}
+
+	digest := sha256.Sum256([]byte(key))
+	valid := 0
+	for _, k := range s.keys {
+		valid |= subtle.ConstantTimeCompare(digest[:], k[:])
+	}
+	if valid != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) recoverer(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.logger.Error("gRPC handler panicked",
+				zap.String("method", info.FullMethod),
+				zap.Any("panic", p))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (s *Server) toStatus(err error) error {
+	switch {
+	case errors.Is(err, errs.ErrOrderNotFound):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, postgres.ErrInvalidOrderStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, orders.ErrPrepaymentDue), errors.Is(err, orders.ErrQuotePending):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+
+	s.logger.Error("gRPC request failed", zap.Error(err))
+	return status.Error(codes.Internal, "internal error")
+}
+
+func toProtoOrder(o *postgres.Order) *adtimev1.Order {
+	order := &adtimev1.Order{
+		Id:          o.ID,
+		UserId:      o.UserID,
+		WidthCm:     int32(o.WidthCM),
+		HeightCm:    int32(o.HeightCM),
+		TextureId:   o.TextureID,
+		TextureName: o.TextureName,
+		Price:       o.Price.Float(),
+		TotalCost:   o.TotalCost.Float(),
+		Status:      o.Status.String(),
+		Rush:        o.IsRush,
+		CreatedAt:   timestamp(o.CreatedAt),
+		UpdatedAt:   timestamp(o.UpdatedAt),
+	}
+	if o.ReadyBy != nil {
+		order.ReadyBy = timestamppb.New(*o.ReadyBy)
+	}
+	return order
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,233 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	stdhttp "net/http"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type orderResponse struct {
+	ID            int64                `json:"id"`
+	UserID        int64                `json:"user_id"`
+	WidthCM       int                  `json:"width_cm"`
+	HeightCM      int                  `json:"height_cm"`
+	TextureID     string               `json:"texture_id"`
+	Price         money.Amount         `json:"price"`
+	RushSurcharge money.Amount         `json:"rush_surcharge"`
+	Currency      money.Currency       `json:"currency"`
+	Rush          bool                 `json:"rush"`
+	Status        postgres.OrderStatus `json:"status"`
+	ReadyBy       *time.Time           `json:"ready_by,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Delivery      *deliveryJSON        `json:"delivery,omitempty"`
+}
+
+type deliveryJSON struct {
+	Method  string       `json:"method"`
+	Address string       `json:"address,omitempty"`
+	Cost    money.Amount `json:"cost,omitempty"`
+}
+
+func newOrderResponse(o *postgres.Order) orderResponse {
+	resp := orderResponse{
+		ID:            o.ID,
+		UserID:        o.UserID,
+		WidthCM:       o.WidthCM,
+		HeightCM:      o.HeightCM,
+		TextureID:     o.TextureID,
+		Price:         o.Price,
+		RushSurcharge: o.RushSurcharge,
+		Currency:      o.Currency,
+		Rush:          o.IsRush,
+		Status:        o.Status,
+		ReadyBy:       o.ReadyBy,
+		CreatedAt:     o.CreatedAt,
+	}
+	if o.Delivery != nil {
+		resp.Delivery = &deliveryJSON{
+			Method:  o.Delivery.Method,
+			Address: o.Delivery.Address,
+			Cost:    o.Delivery.Cost,
+		}
+	}
+	return resp
+}
+
+type textureResponse struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	PricePerDM2 float64 `json:"price_per_dm2"`
+	ImageURL    string  `json:"image_url,omitempty"`
+}
+
+type createOrderRequest struct {
+	UserID    int64  `json:"user_id"`
+	WidthCM   int    `json:"width_cm"`
+	HeightCM  int    `json:"height_cm"`
+	TextureID string `json:"texture_id"`
+	Contact   string `json:"contact"`
+	Rush      bool   `json:"rush"`
+	// Delivery is pickup when omitted; the cost is ignored
+	Delivery *deliveryJSON `json:"delivery,omitempty"`
+	// Requirements are the customer's own wishes; the order then waits
+	// for a manager to approve its price
+	Requirements string `json:"requirements,omitempty"`
+}
+
+func (s *Server) getOrder(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, stdhttp.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	order, err := s.storage.GetOrderByID(r.Context(), id)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		writeError(w, stdhttp.StatusNotFound, "order not found")
+		return
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, stdhttp.StatusOK, newOrderResponse(order))
+}
+
+func (s *Server) createOrder(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+	var req createOrderRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, stdhttp.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.UserID <= 0 {
+		writeError(w, stdhttp.StatusUnprocessableEntity, "user_id is required")
+		return
+	}
+
+	var idempotencyKey string
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		if len(key) > 100 {
+			writeError(w, stdhttp.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+		idempotencyKey = "api:" + key
+	}
+
+	var delivery orders.Delivery
+	if req.Delivery != nil {
+		delivery = orders.Delivery{
+			Method:  pricing.DeliveryMethod(req.Delivery.Method),
+			Address: req.Delivery.Address,
+		}
+	}
+
+	order, err := s.orders.Place(r.Context(), orders.Request{
+		UserID:         req.UserID,
+		WidthCM:        req.WidthCM,
+		HeightCM:       req.HeightCM,
+		TextureID:      req.TextureID,
+		Contact:        req.Contact,
+		Rush:           req.Rush,
+		Delivery:       delivery,
+		Requirements:   req.Requirements,
+		IdempotencyKey: idempotencyKey,
+		// Integrations dedupe with Idempotency-Key; there is nobody to ask
+		AllowDuplicate: true,
+	})
+	switch {
+	case errors.Is(err, orders.ErrInvalidDimensions),
+		errors.Is(err, orders.ErrTooLarge),
+		errors.Is(err, orders.ErrTextureUnavailable),
+		errors.Is(err, orders.ErrContactRequired),
+		errors.Is(err, orders.ErrInvalidContact),
+		errors.Is(err, orders.ErrInvalidDelivery),
+		errors.Is(err, orders.ErrAddressRequired):
+		writeError(w, stdhttp.StatusUnprocessableEntity, err.Error())
+		return
+	case errors.Is(err, postgres.ErrInsufficientStock):
+		writeError(w, stdhttp.StatusConflict, "not enough material in stock")
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	s.logger.Info("Order placed via API",
+		zap.Int64("order_id", order.ID),
+		zap.Int64("user_id", order.UserID))
+
+	writeJSON(w, stdhttp.StatusCreated, newOrderResponse(order))
+}
+
+func (s *Server) listTextures(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+	textures, err := s.storage.GetAvailableTextures(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	resp := make([]textureResponse, 0, len(textures))
+	for _, t := range textures {
+		resp = append(resp, textureResponse{
+			ID:          t.ID,
+			Name:        t.Name,
+			PricePerDM2: t.PricePerDM2,
+			ImageURL:    t.ImageURL,
+		})
+	}
+
+	writeJSON(w, stdhttp.StatusOK, resp)
+}
+
+func (s *Server) getStats(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+	stats, err := s.stats.Get(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, stdhttp.StatusOK, map[string]any{
+		"total_orders":   stats.TotalOrders,
+		"total_revenue":  stats.TotalRevenue,
+		"today_orders":   stats.TodayOrders,
+		"today_revenue":  stats.TodayRevenue,
+		"week_orders":    stats.WeekOrders,
+		"week_revenue":   stats.WeekRevenue,
+		"month_orders":   stats.MonthOrders,
+		"month_revenue":  stats.MonthRevenue,
+		"rush_orders":    stats.RushOrders,
+		"rush_revenue":   stats.RushRevenue,
+		"status_counts":  stats.StatusCounts,
+		"rush_surcharge": stats.RushSurcharge,
+	})
+}
+
+func (s *Server) internalError(w stdhttp.ResponseWriter, r *stdhttp.Request, err error) {
+	s.logger.Error("API request failed",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Error(err))
+	writeError(w, stdhttp.StatusInternalServerError, "internal error")
+}
+
+func writeJSON(w stdhttp.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w stdhttp.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
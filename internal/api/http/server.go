@@ -0,0 +1,131 @@
+// Package http exposes the storage layer as a JSON API for the web storefront.
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"s1ntez/internal/config"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/stats"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyHeader    = "X-API-Key"
+	maxBodyBytes    = 1 << 20
+	shutdownTimeout = 10 * time.Second
+)
+
+type Server struct {
+	storage *postgres.PostgresStorage
+	orders  *orders.Service
+	stats   *stats.Service
+	logger  *zap.Logger
+	cfg     *config.Config
+
+	// keys holds SHA-256 digests so comparison time doesn't depend on key length
+	keys [][sha256.Size]byte
+}
+
+func New(storage *postgres.PostgresStorage, orderService *orders.Service, statsService *stats.Service, logger *zap.Logger, cfg *config.Config) *Server {
+	keys := make([][sha256.Size]byte, 0, len(cfg.API.Keys))
+	for _, key := range cfg.API.Keys {
+		if key != "" {
+			keys = append(keys, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return &Server{
+		storage: storage,
+		orders:  orderService,
+		stats:   statsService,
+		logger:  logger.Named("api"),
+		cfg:     cfg,
+		keys:    keys,
+	}
+}
+
+// Start serves the API until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	if len(s.keys) == 0 {
+		return errors.New("api: no API keys configured")
+	}
+
+	srv := &stdhttp.Server{
+		Addr:              s.cfg.API.Addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       s.cfg.API.ReadTimeout,
+		WriteTimeout:      s.cfg.API.WriteTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("API server listening", zap.String("addr", srv.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("api: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("api: shutdown: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) routes() stdhttp.Handler {
+	mux := stdhttp.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", s.getOrder)
+	mux.HandleFunc("POST /orders", s.createOrder)
+	mux.HandleFunc("GET /textures", s.listTextures)
+	mux.HandleFunc("GET /stats", s.getStats)
+
+	return s.recoverer(s.authenticate(mux))
+}
+
+func (s *Server) authenticate(next stdhttp.Handler) stdhttp.Handler {
+	return stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		digest := sha256.Sum256([]byte(r.Header.Get(apiKeyHeader)))
+
+		valid := 0
+		for _, key := range s.keys {
+			valid |= subtle.ConstantTimeCompare(digest[:], key[:])
+		}
+		if valid != 1 {
+			writeError(w, stdhttp.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) recoverer(next stdhttp.Handler) stdhttp.Handler {
+	return stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				s.logger.Error("API handler panicked",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Any("panic", p))
+				writeError(w, stdhttp.StatusInternalServerError, "internal error")
+			}
+		}()
+
+		r.Body = stdhttp.MaxBytesReader(w, r.Body, maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
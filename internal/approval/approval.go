@@ -0,0 +1,128 @@
+// Package approval sends the quotes a manager prices to the admin chat:
+// orders from QUOTE_APPROVAL_FROM and orders with custom requirements are
+// placed pending a quote, a manager approves the calculated price or sets
+// another, and only then does the customer get it. The order goes on to
+// production, or to the deposit PREPAYMENT_PERCENT asks of the new price.
+package approval
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const CallbackPrefix = "quote"
+
+// Service asks managers for the price and tells customers the one approved
+type Service struct {
+	storage *postgres.PostgresStorage
+	orders  *orders.Service
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, orderService *orders.Service, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		orders:  orderService,
+		botAPI:  botAPI,
+		logger:  logger.Named("approval"),
+		cfg:     cfg,
+	}
+}
+
+// Register subscribes the service to the events it reacts to: a new order
+// pending its quote, and a held one released to wait for it
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "approval.review", s.OnPending)
+	bus.Subscribe(events.OrderStatusChanged, "approval.review_released", s.OnPending)
+}
+
+func (s *Service) OnPending(ctx context.Context, event events.Event) error {
+	if event.Status != postgres.StatusPendingQuote {
+		return nil
+	}
+	return s.RequestReview(ctx, event.OrderID)
+}
+
+// RequestReview posts the order to the admin chat with the buttons to
+// approve the calculated price or set another
+func (s *Service) RequestReview(ctx context.Context, orderID int64) error {
+	if s.cfg.Admin.ChatID == 0 {
+		s.logger.Warn("No admin chat configured, quote waits for /quote", zap.Int64("order_id", orderID))
+		return nil
+	}
+
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	review, err := s.storage.GetOpenQuoteReview(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	symbol := order.Currency.Symbol()
+	text := fmt.Sprintf("💬 Заказ #%d %s ждёт подтверждения цены\nПользователь: %d\nРасчёт: %.2f %s\nСебестоимость: %.2f %s\nПричина: %s",
+		order.ID, order.Code, order.UserID, order.Price, symbol, order.TotalCost, symbol, review.Reason)
+	if order.Requirements != "" {
+		text += "\nТребования: " + order.Requirements
+	}
+
+	msg := tgbotapi.NewMessage(s.cfg.Admin.ChatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Подтвердить %.2f", order.Price),
+			fmt.Sprintf("%s:approve:%d", CallbackPrefix, order.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить цену",
+			fmt.Sprintf("%s:adjust:%d", CallbackPrefix, order.ID)),
+	))
+	if _, err := s.botAPI.Send(msg); err != nil {
+		return fmt.Errorf("failed to send quote review of order %d: %w", orderID, err)
+	}
+	return nil
+}
+
+// Approve releases the order at the calculated price, or at price when it
+// is positive, and tells the customer. It returns the order as it was and
+// the new status.
+func (s *Service) Approve(ctx context.Context, orderID, managerID int64, price money.Amount) (*postgres.Order, postgres.OrderStatus, error) {
+	order, status, err := s.orders.ApproveQuote(ctx, orderID, managerID, price)
+	if err != nil {
+		return nil, "", err
+	}
+
+	locale, err := s.storage.GetUserLocale(ctx, order.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	approved := order.Price
+	if price > 0 {
+		approved = price
+	}
+	text := i18n.T(locale, "quote.approved", order.Code, approved)
+	if approved != order.Price {
+		text = i18n.T(locale, "quote.adjusted", order.Code, approved, order.Price)
+	}
+	if status == postgres.StatusNew {
+		text += "\n" + i18n.T(locale, "quote.in_production")
+	}
+
+	// The customer waits for this message whatever their notification
+	// setting; an invoice for the deposit follows from payments
+	if _, err := s.botAPI.Send(tgbotapi.NewMessage(order.UserID, text)); err != nil {
+		s.logger.Error("Failed to send approved quote",
+			zap.Int64("order_id", orderID),
+			zap.Error(err))
+	}
+	return order, status, nil
+}
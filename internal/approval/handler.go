@@ -0,0 +1,166 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const quoteUsage = "Использование: /quote &lt;номер заказа&gt; [цена]\nБез цены подтверждается расчётная"
+
+// Handler serves the buttons of the review message and the command for
+// the price a manager sets:
+//
+//	/quote <order>          — approve the calculated price
+//	/quote <order> <price>  — approve another price
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.Named("approval"),
+	}
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	botAPI := h.service.botAPI
+	if !auth.Has(ctx, auth.Manager) {
+		_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 || parts[0] != CallbackPrefix {
+		return fmt.Errorf("bad quote callback %q", query.Data)
+	}
+	orderID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad order id in callback %q", query.Data)
+	}
+
+	if parts[1] == "adjust" {
+		// A price can't be typed into a button: the manager sends it
+		_, err := botAPI.Request(tgbotapi.NewCallbackWithAlert(query.ID,
+			fmt.Sprintf("Отправьте новую цену командой:\n/quote %d <цена>", orderID)))
+		return err
+	}
+
+	prev, status, err := h.approve(ctx, orderID, query.From.ID, 0)
+	if err != nil {
+		text, ok := explain(err, orderID)
+		if !ok {
+			return err
+		}
+		_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, text))
+		return nil
+	}
+
+	_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("%s\n\nЦена %.2f подтверждена (%s), заказ %s", query.Message.Text, prev.Price, query.From.UserName, statusName(status)))
+	_, err = botAPI.Send(edit)
+	return err
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) == 0 || len(args) > 2 {
+		return h.reply(msg.Chat.ID, quoteUsage)
+	}
+	orderID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return h.reply(msg.Chat.ID, quoteUsage)
+	}
+	var price money.Amount
+	if len(args) == 2 {
+		price, err = money.Parse(args[1])
+		if err != nil || price <= 0 {
+			return h.reply(msg.Chat.ID, quoteUsage)
+		}
+	}
+
+	prev, status, err := h.approve(ctx, orderID, msg.From.ID, price)
+	if err != nil {
+		text, ok := explain(err, orderID)
+		if !ok {
+			return err
+		}
+		return h.reply(msg.Chat.ID, text)
+	}
+
+	if price == 0 {
+		price = prev.Price
+	}
+	text := fmt.Sprintf("✅ Заказ #%d: цена %.2f подтверждена, заказ %s", orderID, price, statusName(status))
+	if price != prev.Price {
+		text = fmt.Sprintf("✅ Заказ #%d: цена %.2f вместо %.2f, заказ %s", orderID, price, prev.Price, statusName(status))
+	}
+	return h.reply(msg.Chat.ID, text)
+}
+
+// approve releases the order and records it in the audit log
+func (h *Handler) approve(ctx context.Context, orderID, managerID int64, price money.Amount) (*postgres.Order, postgres.OrderStatus, error) {
+	prev, status, err := h.service.Approve(ctx, orderID, managerID, price)
+	if err != nil {
+		return nil, "", err
+	}
+
+	after := map[string]any{"status": status}
+	if price > 0 && price != prev.Price {
+		after["price"] = price
+	}
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+		map[string]any{"status": prev.Status, "price": prev.Price}, after)
+
+	h.logger.Info("Order quote resolved",
+		zap.Int64("order_id", orderID),
+		zap.Int64("manager_id", managerID),
+		zap.Stringer("status", status))
+	return prev, status, nil
+}
+
+// explain words the errors a manager can fix; ok is false for the others
+func explain(err error, orderID int64) (text string, ok bool) {
+	switch {
+	case errors.Is(err, errs.ErrOrderNotFound):
+		return fmt.Sprintf("Заказ #%d не найден", orderID), true
+	case errors.Is(err, postgres.ErrQuoteNotPending):
+		return fmt.Sprintf("Заказ #%d не ждёт подтверждения цены", orderID), true
+	case errors.Is(err, orders.ErrPriceBelowGiftCard):
+		return fmt.Sprintf("Цена заказа #%d не может быть меньше оплаченного сертификатом", orderID), true
+	}
+	return "", false
+}
+
+func statusName(status postgres.OrderStatus) string {
+	if status == postgres.StatusAwaitingPrepayment {
+		return "ждёт предоплату"
+	}
+	return "передан в работу"
+}
+
+func (h *Handler) reply(chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := h.service.botAPI.Send(msg)
+	return err
+}
@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Archiver moves old finished orders out of the orders table, so the
+// statistics and exports keep working on a small hot set
+type Archiver struct {
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, logger *zap.Logger, cfg *config.Config) *Archiver {
+	return &Archiver{
+		storage: storage,
+		logger:  logger.Named("archive"),
+		cfg:     cfg,
+	}
+}
+
+// Watch archives on every interval until ctx is cancelled
+func (a *Archiver) Watch(ctx context.Context) {
+	if a.cfg.Archive.After == 0 {
+		a.logger.Info("ARCHIVE_AFTER is not set, orders are not archived")
+		return
+	}
+
+	ticker := time.NewTicker(a.cfg.Archive.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run moves batches until nothing old enough is left. Each batch is its
+// own transaction, so a long backlog never holds locks for long.
+func (a *Archiver) run(ctx context.Context) {
+	before := time.Now().Add(-a.cfg.Archive.After)
+	total := 0
+	for ctx.Err() == nil {
+		n, err := a.storage.ArchiveOrders(ctx, before, a.cfg.Archive.BatchSize)
+		if err != nil {
+			a.logger.Error("Failed to archive orders", zap.Error(err))
+			break
+		}
+		total += n
+		if n < a.cfg.Archive.BatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		a.logger.Info("Archive run finished",
+			zap.Int("orders", total),
+			zap.Time("before", before))
+	}
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/storage/postgres"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// entry collects the changes made while one admin update is handled
+type entry struct {
+	mu      sync.Mutex
+	changes []change
+}
+
+type change struct {
+	target        string
+	before, after any
+}
+
+// Record notes a change made by the admin command being handled. target
+// names the record ("order:42"), before and after are its states and are
+// stored as JSON; pass nil for a record that did not exist. Outside of an
+// audited handler Record does nothing.
+func Record(ctx context.Context, target string, before, after any) {
+	e, ok := ctx.Value(ctxKey{}).(*entry)
+	if !ok {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.changes = append(e.changes, change{target: target, before: before, after: after})
+}
+
+// Log wraps admin handlers so the changes they Record are written to the
+// audit log with who made them and by which command. Changes are written
+// even when the handler fails afterwards: they have been applied.
+type Log struct {
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+}
+
+func New(storage *postgres.PostgresStorage, logger *zap.Logger) *Log {
+	return &Log{
+		storage: storage,
+		logger:  logger.Named("audit"),
+	}
+}
+
+// Command audits a command handler
+func (l *Log) Command(next bot.CommandHandler) bot.CommandHandler {
+	return commandFunc(func(ctx context.Context, update tgbotapi.Update) error {
+		e := &entry{}
+		err := next.Handle(context.WithValue(ctx, ctxKey{}, e), update)
+		if msg := update.Message; msg != nil && msg.From != nil {
+			l.write(ctx, msg.From.ID, msg.Text, e)
+		}
+		return err
+	})
+}
+
+// Callback audits a callback handler
+func (l *Log) Callback(next bot.CallbackHandler) bot.CallbackHandler {
+	return callbackFunc(func(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+		e := &entry{}
+		err := next.HandleCallback(context.WithValue(ctx, ctxKey{}, e), query)
+		l.write(ctx, query.From.ID, query.Data, e)
+		return err
+	})
+}
+
+func (l *Log) write(ctx context.Context, adminID int64, action string, e *entry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.changes) == 0 {
+		return
+	}
+
+	entries := make([]postgres.AuditEntry, 0, len(e.changes))
+	for _, c := range e.changes {
+		entries = append(entries, postgres.AuditEntry{
+			AdminID: adminID,
+			Action:  action,
+			Target:  c.target,
+			Before:  l.marshal(c.target, c.before),
+			After:   l.marshal(c.target, c.after),
+		})
+	}
+
+	// The update is done by now; don't lose the record to its cancellation
+	if err := l.storage.RecordAudit(context.WithoutCancel(ctx), entries); err != nil {
+		l.logger.Error("Failed to write audit log",
+			zap.Int64("admin_id", adminID),
+			zap.String("action", action),
+			zap.Error(err))
+	}
+}
+
+func (l *Log) marshal(target string, value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		l.logger.Warn("Failed to marshal audited value", zap.String("target", target), zap.Error(err))
+		return nil
+	}
+	return data
+}
+
+type commandFunc func(ctx context.Context, update tgbotapi.Update) error
+
+func (f commandFunc) Handle(ctx context.Context, update tgbotapi.Update) error {
+	return f(ctx, update)
+}
+
+type callbackFunc func(ctx context.Context, query *tgbotapi.CallbackQuery) error
+
+func (f callbackFunc) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	return f(ctx, query)
+}
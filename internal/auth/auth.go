@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Role is what a user may do in the bot. Roles are ordered: each one may
+// do everything the roles below it may.
+type Role string
+
+const (
+	Customer   Role = "customer"
+	Production Role = "production"
+	Manager    Role = "manager"
+	Admin      Role = "admin"
+)
+
+// Roles lists every role, lowest first
+var Roles = []Role{Customer, Production, Manager, Admin}
+
+var ErrUnknownRole = errors.New("unknown role")
+
+func ParseRole(raw string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(raw)))
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRole, raw)
+	}
+	return role, nil
+}
+
+// AtLeast reports whether r may do what min may
+func (r Role) AtLeast(min Role) bool {
+	return slices.Index(Roles, r) >= slices.Index(Roles, min)
+}
+
+func (r Role) String() string {
+	return string(r)
+}
+
+// Service resolves and grants roles. Roles are stored with the user;
+// ADMIN_IDS and PRODUCTION_IDS raise them, so an admin cannot lock
+// themselves out by a grant and a fresh install has someone to grant roles.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, cfg *config.Config) *Service {
+	return &Service{storage: storage, botAPI: botAPI, cfg: cfg}
+}
+
+// Role returns the effective role of a user
+func (s *Service) Role(ctx context.Context, userID int64) (Role, error) {
+	if s.cfg.IsAdmin(userID) {
+		return Admin, nil
+	}
+
+	stored, err := s.storage.GetUserRole(ctx, userID)
+	if err != nil {
+		return Customer, err
+	}
+	role, err := ParseRole(stored)
+	if err != nil {
+		role = Customer
+	}
+
+	if s.cfg.IsProduction(userID) && !role.AtLeast(Production) {
+		role = Production
+	}
+	return role, nil
+}
+
+// Grant stores the role of a user
+func (s *Service) Grant(ctx context.Context, userID int64, role Role) error {
+	if !slices.Contains(Roles, role) {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+	return s.storage.SetUserRole(ctx, userID, string(role))
+}
+
+type ctxKey struct{}
+
+// WithRole records the role the middleware resolved for the request
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, ctxKey{}, role)
+}
+
+// FromContext returns the role of the current user; Customer when the
+// handler is not behind the middleware
+func FromContext(ctx context.Context) Role {
+	if role, ok := ctx.Value(ctxKey{}).(Role); ok {
+		return role
+	}
+	return Customer
+}
+
+// Has reports whether the current user has at least the given role
+func Has(ctx context.Context, min Role) bool {
+	return FromContext(ctx).AtLeast(min)
+}
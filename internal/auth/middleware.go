@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Command lets only users with at least min through to next. Others are
+// ignored, as if the command did not exist.
+func (s *Service) Command(min Role, next bot.CommandHandler) bot.CommandHandler {
+	return commandFunc(func(ctx context.Context, update tgbotapi.Update) error {
+		msg := update.Message
+		if msg == nil || msg.From == nil {
+			return nil
+		}
+
+		role, err := s.Role(ctx, msg.From.ID)
+		if err != nil {
+			return err
+		}
+		if !role.AtLeast(min) {
+			return nil
+		}
+		return next.Handle(WithRole(ctx, role), update)
+	})
+}
+
+// Callback lets only users with at least min press the buttons of next
+func (s *Service) Callback(min Role, next bot.CallbackHandler) bot.CallbackHandler {
+	return callbackFunc(func(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+		role, err := s.Role(ctx, query.From.ID)
+		if err != nil {
+			return err
+		}
+		if !role.AtLeast(min) {
+			locale, _ := s.storage.GetUserLocale(ctx, query.From.ID)
+			_, _ = s.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "error.forbidden")))
+			return nil
+		}
+		return next.HandleCallback(WithRole(ctx, role), query)
+	})
+}
+
+type commandFunc func(ctx context.Context, update tgbotapi.Update) error
+
+func (f commandFunc) Handle(ctx context.Context, update tgbotapi.Update) error {
+	return f(ctx, update)
+}
+
+type callbackFunc func(ctx context.Context, query *tgbotapi.CallbackQuery) error
+
+func (f callbackFunc) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	return f(ctx, query)
+}
@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/objectstore"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// keyFormat names the backups by their UTC start time, so they sort by age
+const keyFormat = "20060102T150405Z"
+
+// Service dumps orders, textures and customers to gzipped JSON lines in the
+// object store and loads them back, for recovery without pg_dump
+type Service struct {
+	storage *postgres.PostgresStorage
+	store   *objectstore.Client
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, store *objectstore.Client, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		store:   store,
+		logger:  logger.Named("backup"),
+		cfg:     cfg,
+	}
+}
+
+// Run writes a new backup and returns its object key. The dump goes to a
+// temporary file first: the object store needs the size before the upload.
+func (s *Service) Run(ctx context.Context) (string, error) {
+	started := time.Now()
+	key := s.cfg.Backup.Prefix + started.UTC().Format(keyFormat) + ".jsonl.gz"
+
+	file, err := os.CreateTemp("", "adtime-backup-*.jsonl.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	zw := gzip.NewWriter(file)
+	counts, err := s.storage.DumpBackup(ctx, zw)
+	if err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("failed to size backup: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind backup: %w", err)
+	}
+	if err := s.store.Put(ctx, key, file, size, "application/gzip"); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("Backup written",
+		zap.String("key", key),
+		zap.Int64("bytes", size),
+		zap.Any("rows", counts),
+		zap.Duration("took", time.Since(started)))
+	return key, nil
+}
+
+// Restore loads the backup stored under key into a clean database
+func (s *Service) Restore(ctx context.Context, key string) error {
+	body, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("failed to open backup %s: %w", key, err)
+	}
+	defer zr.Close()
+
+	_, err = s.storage.RestoreBackup(ctx, zr)
+	return err
+}
+
+// Watch writes a backup on every interval until ctx is cancelled
+func (s *Service) Watch(ctx context.Context) {
+	if s.cfg.Backup.Interval == 0 {
+		s.logger.Info("BACKUP_INTERVAL is not set, scheduled backups are off")
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Backup.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.Run(ctx); err != nil {
+			s.logger.Error("Scheduled backup failed", zap.Error(err))
+		}
+	}
+}
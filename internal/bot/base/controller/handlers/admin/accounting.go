@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/jobs"
+	"s1ntez/internal/reports"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const accountingUsage = "Использование: /admin accounting 2025-01 [xml|csv]"
+
+// AccountingExportHandler serves /admin accounting YYYY-MM [xml|csv]: the
+// month's sales for the accountant's 1C, XML by default
+type AccountingExportHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewAccountingExportHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *AccountingExportHandler {
+	return &AccountingExportHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *AccountingExportHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 || len(args) > 3 {
+		return reply(h.botAPI, msg.Chat.ID, accountingUsage)
+	}
+	month, err := time.ParseInLocation("2006-01", args[1], time.Local)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, accountingUsage)
+	}
+	format := reports.AccountingXML
+	if len(args) == 3 {
+		format = strings.ToLower(args[2])
+	}
+	if format != reports.AccountingXML && format != reports.AccountingCSV {
+		return reply(h.botAPI, msg.Chat.ID, accountingUsage)
+	}
+
+	if _, end := postgres.MonthBounds(month); end.After(time.Now()) {
+		return reply(h.botAPI, msg.Chat.ID, "Месяц ещё не закончился")
+	}
+
+	payload := jobs.AccountingExportPayload{
+		ChatID:  msg.Chat.ID,
+		AdminID: msg.From.ID,
+		Month:   month,
+		Format:  format,
+	}
+
+	jobID, err := h.storage.EnqueueJob(ctx, jobs.KindAccountingExport, payload, msg.From.ID, h.cfg.Jobs.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue accounting export: %w", err)
+	}
+
+	h.logger.Info("Accounting export queued",
+		zap.Int64("job_id", jobID),
+		zap.String("month", month.Format("2006-01")),
+		zap.String("format", format),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Выгрузка для 1С за %s поставлена в очередь (задача #%d)",
+		month.Format("2006-01"), jobID))
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"context"
+	"maps"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/config"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// AdminCommands serves /admin <subcommand> ...: the update goes to the
+// handler registered for the subcommand ("audit", "reload", "note",
+// "grant", "due", "funnel", "experiments", "report", "accounting",
+// "stats", "reviews", "events").
+// Managers get through to the subcommands, which check their own role.
+type AdminCommands struct {
+	botAPI   *tgbotapi.BotAPI
+	cfg      *config.Config
+	handlers map[string]bot.CommandHandler
+}
+
+func NewAdminCommands(botAPI *tgbotapi.BotAPI, cfg *config.Config, handlers map[string]bot.CommandHandler) *AdminCommands {
+	return &AdminCommands{
+		botAPI:   botAPI,
+		cfg:      cfg,
+		handlers: handlers,
+	}
+}
+
+func (h *AdminCommands) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	name, _, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	handler, ok := h.handlers[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(h.handlers))
+		return reply(h.botAPI, msg.Chat.ID, "Использование: /admin "+strings.Join(names, " | "))
+	}
+	return handler.Handle(ctx, update)
+}
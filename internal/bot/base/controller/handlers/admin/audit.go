@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAuditEntries = 20
+	maxAuditEntries     = 100
+	// Long values (descriptions, export parameters) are cut in the chat
+	maxAuditValueLen = 120
+	// Telegram rejects messages over 4096 characters
+	maxAuditMessageLen = 4000
+)
+
+// AuditHandler serves /admin audit [count] [admin:<id>] [<target>]:
+// the latest admin changes, optionally only those of one admin or of
+// targets starting with the given prefix ("order:42", "texture:")
+type AuditHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewAuditHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *AuditHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	const usage = "Использование: /admin audit [кол-во] [admin:&lt;id&gt;] [order:&lt;id&gt; | texture:&lt;id&gt; | job:]"
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) == 0 || args[0] != "audit" {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	filter := postgres.AuditFilter{Limit: defaultAuditEntries}
+	for _, arg := range args[1:] {
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n <= 0 || n > maxAuditEntries {
+				return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Количество: от 1 до %d", maxAuditEntries))
+			}
+			filter.Limit = n
+			continue
+		}
+		if raw, ok := strings.CutPrefix(arg, "admin:"); ok {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return reply(h.botAPI, msg.Chat.ID, usage)
+			}
+			filter.AdminID = id
+			continue
+		}
+		filter.Target = arg
+	}
+
+	entries, err := h.storage.ListAuditLog(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return reply(h.botAPI, msg.Chat.ID, "Записей в журнале нет")
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Журнал действий</b>\n")
+	for i, e := range entries {
+		line := fmt.Sprintf("\n#%d %s · <code>%d</code> · %s\n%s: %s\n",
+			e.ID,
+			e.CreatedAt.Format("02.01 15:04"),
+			e.AdminID,
+			html.EscapeString(e.Action),
+			html.EscapeString(e.Target),
+			html.EscapeString(describeAuditChange(e.Before, e.After)))
+		if text.Len()+len(line) > maxAuditMessageLen {
+			fmt.Fprintf(&text, "\n… и ещё %d", len(entries)-i)
+			break
+		}
+		text.WriteString(line)
+	}
+
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
+
+// describeAuditChange lists the fields that differ between two JSON
+// objects; anything else is shown as a whole
+func describeAuditChange(before, after json.RawMessage) string {
+	var from, to map[string]json.RawMessage
+	if json.Unmarshal(before, &from) != nil || json.Unmarshal(after, &to) != nil {
+		return auditValue(before) + " → " + auditValue(after)
+	}
+
+	keys := make(map[string]struct{}, len(from)+len(to))
+	for k := range from {
+		keys[k] = struct{}{}
+	}
+	for k := range to {
+		keys[k] = struct{}{}
+	}
+
+	var changed []string
+	for k := range keys {
+		if !bytes.Equal(from[k], to[k]) {
+			changed = append(changed, fmt.Sprintf("%s: %s → %s", k, auditValue(from[k]), auditValue(to[k])))
+		}
+	}
+	if len(changed) == 0 {
+		return "без изменений"
+	}
+	sort.Strings(changed)
+	return strings.Join(changed, "; ")
+}
+
+func auditValue(value json.RawMessage) string {
+	if len(value) == 0 {
+		return "—"
+	}
+	s := string(value)
+	if r := []rune(s); len(r) > maxAuditValueLen {
+		s = string(r[:maxAuditValueLen]) + "…"
+	}
+	return s
+}
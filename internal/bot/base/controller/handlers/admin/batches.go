@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// TextureBatchHandler registers material deliveries and shows what is on the shelves:
+//
+//	/addbatch <texture_id> <dm²> <location> [supplier]
+//	/batches <texture_id>
+type TextureBatchHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewTextureBatchHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *TextureBatchHandler {
+	return &TextureBatchHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *TextureBatchHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.Command() == "batches" {
+		return h.list(ctx, msg.Chat.ID, strings.TrimSpace(msg.CommandArguments()))
+	}
+
+	fields := strings.Fields(msg.CommandArguments())
+	if len(fields) < 3 {
+		return reply(h.botAPI, msg.Chat.ID, "Использование: /addbatch <id> 500 <ячейка> [поставщик]")
+	}
+
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(fields[1], ",", "."), 64)
+	if err != nil || quantity <= 0 {
+		return reply(h.botAPI, msg.Chat.ID, "Количество должно быть положительным числом дм²")
+	}
+
+	batchID, err := h.storage.ReceiveTextureBatch(ctx, postgres.TextureBatch{
+		TextureID:   fields[0],
+		QuantityDM2: quantity,
+		Location:    fields[2],
+		Supplier:    strings.Join(fields[3:], " "),
+	})
+	if err != nil {
+		h.logger.Error("Failed to receive texture batch",
+			zap.String("texture_id", fields[0]),
+			zap.Error(err))
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err))
+	}
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Партия #%d принята в ячейку %s ✅",
+		batchID, html.EscapeString(fields[2])))
+}
+
+func (h *TextureBatchHandler) list(ctx context.Context, chatID int64, textureID string) error {
+	if textureID == "" {
+		return reply(h.botAPI, chatID, "Укажите ID текстуры")
+	}
+
+	batches, err := h.storage.GetTextureBatches(ctx, textureID)
+	if err != nil {
+		h.logger.Error("Failed to get texture batches",
+			zap.String("texture_id", textureID),
+			zap.Error(err))
+		return reply(h.botAPI, chatID, fmt.Sprintf("Ошибка: %v", err))
+	}
+	if len(batches) == 0 {
+		return reply(h.botAPI, chatID, "Партий на складе нет")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Партии на складе</b>\n")
+	for _, b := range batches {
+		fmt.Fprintf(&sb, "\n#%d · %s · %.2f/%.2f дм² · %s",
+			b.ID, html.EscapeString(b.Location), b.RemainingDM2, b.QuantityDM2,
+			b.ReceivedAt.Format("02.01.2006"))
+		if b.Supplier != "" {
+			fmt.Fprintf(&sb, " · %s", html.EscapeString(b.Supplier))
+		}
+	}
+
+	return reply(h.botAPI, chatID, sb.String())
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const blockUsage = "Использование: /block <user_id> [причина], /unblock <user_id>"
+
+// BlocklistHandler keeps users out of the bot:
+//
+//	/block <user_id> [reason]
+//	/unblock <user_id>   lifts a block or an automatic ban
+//	/blocked
+type BlocklistHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewBlocklistHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *BlocklistHandler {
+	return &BlocklistHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *BlocklistHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.Command() == "blocked" {
+		return h.list(ctx, msg.Chat.ID)
+	}
+
+	rawID, reason, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	userID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, blockUsage)
+	}
+
+	if msg.Command() == "unblock" {
+		lifted, err := h.storage.UnblockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !lifted {
+			return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Пользователь %d не заблокирован", userID))
+		}
+		h.logger.Info("User unblocked", zap.Int64("user_id", userID), zap.Int64("admin_id", msg.From.ID))
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Пользователь %d разблокирован", userID))
+	}
+
+	if h.cfg.IsAdmin(userID) {
+		return reply(h.botAPI, msg.Chat.ID, "Администратора заблокировать нельзя")
+	}
+	reason = strings.TrimSpace(reason)
+	if err := h.storage.BlockUser(ctx, userID, msg.From.ID, reason); err != nil {
+		return err
+	}
+	h.logger.Info("User blocked",
+		zap.Int64("user_id", userID),
+		zap.Int64("admin_id", msg.From.ID),
+		zap.String("reason", reason))
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Пользователь %d заблокирован", userID))
+}
+
+func (h *BlocklistHandler) list(ctx context.Context, chatID int64) error {
+	users, err := h.storage.ListBlockedUsers(ctx, 50)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return reply(h.botAPI, chatID, "Заблокированных нет")
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Заблокированные</b>\n\n")
+	for _, u := range users {
+		text.WriteString(describeBlocked(u) + "\n")
+	}
+	return reply(h.botAPI, chatID, text.String())
+}
+
+func describeBlocked(u postgres.BlockedUser) string {
+	if !u.BlockedAt.Valid {
+		return fmt.Sprintf("<code>%d</code> — бан до %s, всего банов: %d",
+			u.UserID, u.BannedUntil.Time.Format("02.01.2006 15:04"), u.Bans)
+	}
+	line := fmt.Sprintf("<code>%d</code> — заблокирован %s", u.UserID, u.BlockedAt.Time.Format("02.01.2006"))
+	if u.BlockedBy.Valid {
+		line += fmt.Sprintf(" (%d)", u.BlockedBy.Int64)
+	}
+	if u.Reason != "" {
+		line += ": " + html.EscapeString(u.Reason)
+	}
+	return line
+}
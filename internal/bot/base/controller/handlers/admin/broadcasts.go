@@ -0,0 +1,208 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/broadcast"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	broadcastCallbackPrefix = "bcast"
+	broadcastUsage          = "Использование: /broadcast all|active|status:&lt;статус&gt; ответом на сообщение для рассылки\n" +
+		"/broadcast stop &lt;id&gt;\n/broadcasts"
+	listedBroadcasts = 10
+)
+
+// buttonLine is a link button in the broadcast text: [Label](https://...)
+var buttonLine = regexp.MustCompile(`^\[(.+)\]\((https?://\S+)\)$`)
+
+// BroadcastHandler composes and tracks announcements:
+//
+//	/broadcast <all|active|status:<status>> (as a reply to the message to send)
+//	/broadcast stop <id>
+//	/broadcasts
+//
+// The text or photo caption is sent as HTML; its last lines of the form
+// [Label](https://...) become link buttons. The admin gets a preview and
+// confirms the send with a button.
+type BroadcastHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewBroadcastHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *BroadcastHandler {
+	return &BroadcastHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *BroadcastHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.Command() == "broadcasts" {
+		return h.list(ctx, msg.Chat.ID)
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	switch {
+	case len(args) == 2 && args[0] == "stop":
+		return h.stop(ctx, msg.Chat.ID, args[1])
+	case len(args) != 1 || msg.ReplyToMessage == nil:
+		return reply(h.botAPI, msg.Chat.ID, broadcastUsage)
+	}
+
+	b := composeBroadcast(msg.ReplyToMessage)
+	b.CreatedBy = msg.From.ID
+	b.Segment = args[0]
+	if b.Text == "" && b.PhotoFileID == "" {
+		return reply(h.botAPI, msg.Chat.ID, "Ответьте командой на текст или фото")
+	}
+
+	err := h.storage.CreateBroadcast(ctx, b)
+	if errors.Is(err, postgres.ErrInvalidSegment) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неизвестный сегмент: %s\n\n%s", html.EscapeString(b.Segment), broadcastUsage))
+	}
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, fmt.Sprintf("broadcast:%d", b.ID), nil, b)
+
+	// The preview is exactly what recipients get, so bad HTML shows up here
+	if _, err := h.botAPI.Send(broadcast.Message(b, msg.Chat.ID)); err != nil {
+		if cancelErr := h.storage.CancelBroadcast(ctx, b.ID); cancelErr != nil {
+			h.logger.Warn("Failed to cancel broadcast draft", zap.Error(cancelErr))
+		}
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Не удалось отправить предпросмотр: %s", html.EscapeString(err.Error())))
+	}
+
+	confirm := tgbotapi.NewMessage(msg.Chat.ID,
+		fmt.Sprintf("Рассылка #%d: %s, получателей: %d", b.ID, b.Segment, b.Total))
+	confirm.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📣 Отправить", fmt.Sprintf("%s:send:%d", broadcastCallbackPrefix, b.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("Отмена", fmt.Sprintf("%s:cancel:%d", broadcastCallbackPrefix, b.ID)),
+	))
+	_, err = h.botAPI.Send(confirm)
+	return err
+}
+
+func (h *BroadcastHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !isAdmin(ctx) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("bad broadcast callback %q", query.Data)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad broadcast id in callback %q", query.Data)
+	}
+
+	var text string
+	switch parts[1] {
+	case "send":
+		err = h.storage.StartBroadcast(ctx, id)
+		text = fmt.Sprintf("Рассылка #%d запущена. Статистика: /broadcasts", id)
+	case "cancel":
+		err = h.storage.CancelBroadcast(ctx, id)
+		text = fmt.Sprintf("Рассылка #%d отменена", id)
+	default:
+		return fmt.Errorf("bad broadcast callback %q", query.Data)
+	}
+	if errors.Is(err, postgres.ErrBroadcastNotFound) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Рассылка уже запущена или отменена"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("broadcast:%d", id), nil, map[string]any{"action": parts[1]})
+	h.logger.Info("Broadcast "+parts[1],
+		zap.Int64("broadcast_id", id),
+		zap.Int64("admin_id", query.From.ID))
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	_, err = h.botAPI.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text))
+	return err
+}
+
+func (h *BroadcastHandler) stop(ctx context.Context, chatID int64, rawID string) error {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return reply(h.botAPI, chatID, broadcastUsage)
+	}
+
+	err = h.storage.CancelBroadcast(ctx, id)
+	if errors.Is(err, postgres.ErrBroadcastNotFound) {
+		return reply(h.botAPI, chatID, fmt.Sprintf("Рассылка #%d не найдена или уже завершена", id))
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("broadcast:%d", id), nil, map[string]any{"action": "stop"})
+	return reply(h.botAPI, chatID, fmt.Sprintf("Рассылка #%d остановлена", id))
+}
+
+func (h *BroadcastHandler) list(ctx context.Context, chatID int64) error {
+	broadcasts, err := h.storage.ListBroadcasts(ctx, listedBroadcasts)
+	if err != nil {
+		return err
+	}
+	if len(broadcasts) == 0 {
+		return reply(h.botAPI, chatID, "Рассылок пока не было")
+	}
+
+	var text strings.Builder
+	for _, b := range broadcasts {
+		fmt.Fprintf(&text, "#%d %s · %s · %s\nдоставлено %d из %d, ошибок %d, заблокировали бота %d\n\n",
+			b.ID, b.CreatedAt.Format("02.01 15:04"), html.EscapeString(b.Segment), b.Status,
+			b.Sent, b.Total, b.Failed, b.Blocked)
+	}
+	return reply(h.botAPI, chatID, text.String())
+}
+
+// composeBroadcast takes the content of the message to send. Trailing
+// button lines are cut from the text.
+func composeBroadcast(msg *tgbotapi.Message) *postgres.Broadcast {
+	b := &postgres.Broadcast{Text: msg.Text}
+	if len(msg.Photo) > 0 {
+		// The last size is the largest one
+		b.PhotoFileID = msg.Photo[len(msg.Photo)-1].FileID
+		b.Text = msg.Caption
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.Text), "\n")
+	for len(lines) > 0 {
+		m := buttonLine.FindStringSubmatch(strings.TrimSpace(lines[len(lines)-1]))
+		if m == nil {
+			break
+		}
+		b.Buttons = append(postgres.BroadcastButtons{{Text: m[1], URL: m[2]}}, b.Buttons...)
+		lines = lines[:len(lines)-1]
+	}
+	b.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+	return b
+}
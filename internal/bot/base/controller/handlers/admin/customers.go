@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTopCustomers = 10
+	maxTopCustomers     = 50
+)
+
+// CustomersHandler serves /customers top [N]: the customers who spent the
+// most, with their order count, last order and Telegram username
+type CustomersHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewCustomersHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *CustomersHandler {
+	return &CustomersHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *CustomersHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	usage := fmt.Sprintf("Использование: /customers top [1-%d]", maxTopCustomers)
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) == 0 || args[0] != "top" || len(args) > 2 {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	limit := defaultTopCustomers
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > maxTopCustomers {
+			return reply(h.botAPI, msg.Chat.ID, usage)
+		}
+		limit = n
+	}
+
+	customers, err := h.storage.Customers().Top(ctx, limit)
+	if err != nil {
+		return err
+	}
+	if len(customers) == 0 {
+		return reply(h.botAPI, msg.Chat.ID, "Заказов пока нет")
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>Лучшие клиенты: %d</b>\n", len(customers))
+	for i, c := range customers {
+		name := fmt.Sprintf("<code>%d</code>", c.UserID)
+		if c.Username != "" {
+			name = "@" + html.EscapeString(c.Username) + " · " + name
+		}
+		fmt.Fprintf(&text, "\n%d. %s\n%.2f %s, заказов: %d", i+1, name, c.TotalSpend, h.cfg.Currency.Symbol(), c.Orders)
+		if c.LastOrderAt != nil {
+			fmt.Fprintf(&text, ", последний %s", c.LastOrderAt.Format("02.01.2006"))
+		}
+		fmt.Fprintf(&text, "\nс нами с %s\n", c.FirstSeenAt.Format("02.01.2006"))
+	}
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
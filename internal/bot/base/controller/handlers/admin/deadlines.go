@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// DueDateHandler serves /admin due <order_id> <deadline>: sets the
+// production deadline the reminders watch. "clear" falls back to the date
+// promised to the customer.
+type DueDateHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewDueDateHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *DueDateHandler {
+	return &DueDateHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *DueDateHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	const usage = "Использование: /admin due &lt;order_id&gt; &lt;ГГГГ-ММ-ДД ЧЧ:ММ | +48h | clear&gt;"
+
+	args, _ := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), "due")
+	rawID, rawDue, _ := strings.Cut(strings.TrimSpace(args), " ")
+	orderID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	dueAt, err := parseDueAt(strings.TrimSpace(rawDue), time.Now())
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	order, err := h.storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.storage.SetOrderDueAt(ctx, orderID, dueAt); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+		map[string]any{"due_at": order.DueAt}, map[string]any{"due_at": dueAt})
+
+	h.logger.Info("Order deadline set",
+		zap.Int64("order_id", orderID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	order.DueAt = dueAt
+	deadline := order.Deadline()
+	if deadline == nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d: срок снят", orderID))
+	}
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d: срок %s", orderID, deadline.Format("02.01.2006 15:04")))
+}
+
+// parseDueAt accepts a local date and time, a date (end of the working
+// day), an offset from now like +48h, or clear
+func parseDueAt(raw string, now time.Time) (*time.Time, error) {
+	if raw == "clear" {
+		return nil, nil
+	}
+	if offset, ok := strings.CutPrefix(raw, "+"); ok {
+		d, err := time.ParseDuration(offset)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("bad offset %q", raw)
+		}
+		due := now.Add(d)
+		return &due, nil
+	}
+	if due, err := time.ParseInLocation("2006-01-02 15:04", raw, time.Local); err == nil {
+		return &due, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	due := day.Add(18 * time.Hour)
+	return &due, nil
+}
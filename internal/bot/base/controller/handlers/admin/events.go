@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"maps"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// OrderEventsHandler serves /admin events <order_id>: the timeline of the
+// order from the order_events log. /admin events rebuild replays the whole
+// log into status counts and compares them with the orders table, to check
+// the log or restore the statistics from it.
+type OrderEventsHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewOrderEventsHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *OrderEventsHandler {
+	return &OrderEventsHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *OrderEventsHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	const usage = "Использование: /admin events &lt;order_id&gt; | rebuild"
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 2 || args[0] != "events" {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	if args[1] == "rebuild" {
+		if !isAdmin(ctx) {
+			return nil
+		}
+		return h.rebuild(ctx, msg.Chat.ID)
+	}
+
+	orderID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	events, err := h.storage.GetOrderEvents(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Событий заказа #%d нет", orderID))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>События заказа #%d</b>\n", orderID)
+	for _, event := range events {
+		fmt.Fprintf(&text, "\n%s <b>%s</b>", event.CreatedAt.Format("02.01.2006 15:04:05"), event.Type)
+		if payload := string(event.Payload); payload != "{}" {
+			fmt.Fprintf(&text, "\n<code>%s</code>", html.EscapeString(payload))
+		}
+	}
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
+
+// rebuild shows the status counts replayed from the log next to the ones
+// of the orders table; a difference means an order changed without an
+// event, or an event the orders table lost
+func (h *OrderEventsHandler) rebuild(ctx context.Context, chatID int64) error {
+	replayed, err := h.storage.RebuildOrderStatistics(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := h.storage.GetOrderStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	statuses := slices.Sorted(maps.Keys(replayed))
+	for status := range current.StatusCounts {
+		if _, ok := replayed[status]; !ok {
+			statuses = append(statuses, status)
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Статусы по журналу событий / по заказам</b>\n")
+	var drift int
+	for _, status := range statuses {
+		mark := ""
+		if replayed[status] != current.StatusCounts[status] {
+			mark = " ⚠️"
+			drift++
+		}
+		fmt.Fprintf(&text, "\n%s: %d / %d%s", status, replayed[status], current.StatusCounts[status], mark)
+	}
+	if drift == 0 {
+		text.WriteString("\n\nРасхождений нет")
+	}
+
+	h.logger.Info("Order statistics rebuilt from events", zap.Int("drifted_statuses", drift))
+	return reply(h.botAPI, chatID, text.String())
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// significantZ is the two-sided 95% bound of the z-test
+const significantZ = 1.96
+
+// ExperimentsHandler serves /admin experiments: for every experiment, how
+// many customers saw each variant, how many of them ordered, and how the
+// variants compare with the control
+type ExperimentsHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewExperimentsHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *ExperimentsHandler {
+	return &ExperimentsHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *ExperimentsHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	results, err := h.storage.GetExperimentResults(ctx)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Эксперименты</b>\n")
+	if len(results) == 0 {
+		text.WriteString("\nПоказов пока нет")
+		if len(h.cfg.Experiments.Running) == 0 {
+			text.WriteString(", а EXPERIMENTS не задан")
+		}
+		return reply(h.botAPI, msg.Chat.ID, text.String())
+	}
+
+	for start := 0; start < len(results); {
+		name := results[start].Experiment
+		end := start
+		for end < len(results) && results[end].Experiment == name {
+			end++
+		}
+		h.writeExperiment(&text, results[start:end])
+		start = end
+	}
+	text.WriteString("\nКонверсия — доля увидевших вариант, кто оформил заказ после показа")
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
+
+// writeExperiment adds the variants of one experiment; the lift and the
+// significance are against its control
+func (h *ExperimentsHandler) writeExperiment(text *strings.Builder, variants []postgres.ExperimentResult) {
+	name := variants[0].Experiment
+	running := slices.IndexFunc(h.cfg.Experiments.Running, func(e config.Experiment) bool { return e.Name == name })
+
+	since := variants[0].Since
+	for _, v := range variants {
+		if v.Since.Before(since) {
+			since = v.Since
+		}
+	}
+	state := "остановлен"
+	if running >= 0 {
+		state = "идёт"
+	}
+	fmt.Fprintf(text, "\n<b>%s</b> — %s, с %s\n", html.EscapeString(name), state, since.Format("02.01.2006"))
+
+	control := controlVariant(variants, h.cfg.Experiments.Running, running)
+	for _, v := range variants {
+		fmt.Fprintf(text, "%s: показов %d, заказов %d (%.1f%%)",
+			html.EscapeString(v.Variant), v.Exposed, v.Converted, percent(v.Converted, v.Exposed))
+		switch {
+		case v.Variant == control.Variant:
+			text.WriteString(" — контроль")
+		case control.Exposed > 0 && control.Converted > 0 && v.Exposed > 0:
+			base := float64(control.Converted) / float64(control.Exposed)
+			rate := float64(v.Converted) / float64(v.Exposed)
+			fmt.Fprintf(text, ", %+.0f%% к контролю", (rate/base-1)*100)
+			if math.Abs(zScore(control, v)) >= significantZ {
+				text.WriteString(", значимо")
+			}
+		}
+		text.WriteString("\n")
+	}
+}
+
+// controlVariant is the first variant of a running experiment; for a
+// stopped one it is "control" if shown, otherwise the first in the results
+func controlVariant(variants []postgres.ExperimentResult, running []config.Experiment, i int) postgres.ExperimentResult {
+	name := "control"
+	if i >= 0 {
+		name = running[i].Variants[0]
+	}
+	for _, v := range variants {
+		if v.Variant == name {
+			return v
+		}
+	}
+	return variants[0]
+}
+
+// zScore is the two-proportion z-test of the conversions of b against a
+func zScore(a, b postgres.ExperimentResult) float64 {
+	na, nb := float64(a.Exposed), float64(b.Exposed)
+	pa, pb := float64(a.Converted)/na, float64(b.Converted)/nb
+	pooled := float64(a.Converted+b.Converted) / (na + nb)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/na + 1/nb))
+	if se == 0 {
+		return 0
+	}
+	return (pb - pa) / se
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/jobs"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// ExportHandler serves /export [anon] [files] [template=<name>]: queues the orders spreadsheet
+// job, the file is sent to the admin once the worker has built it. The
+// reply to the command shows the progress of long exports.
+// "anon" produces the contractor-safe version; "files" adds the customer
+// files and texture photos of every order for the production team;
+// "template" lays the sheet out with a template saved by /admin report.
+// Production staff and managers may export too, without the cost columns.
+type ExportHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewExportHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *ExportHandler {
+	return &ExportHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *ExportHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Production) {
+		return nil
+	}
+
+	locale, err := h.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	var template string
+	for _, arg := range args {
+		if name, ok := strings.CutPrefix(arg, "template="); ok {
+			template = name
+		}
+	}
+	if template != "" {
+		if _, err := h.storage.GetReportTemplate(ctx, template); errors.Is(err, postgres.ErrReportTemplateNotFound) {
+			return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Шаблон %s не найден, шаблоны: /admin report", html.EscapeString(template)))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// The worker edits this message, so it is sent before the job exists
+	// and not touched afterwards: a quick job may have finished already
+	queued, err := h.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, "⏳ Экспорт поставлен в очередь"))
+	if err != nil {
+		return err
+	}
+
+	payload := jobs.ExportOrdersPayload{
+		ChatID:    msg.Chat.ID,
+		Locale:    locale,
+		Anonymize: slices.Contains(args, "anon"),
+		Files:     slices.Contains(args, "files"),
+		Policy:    exportPolicy(ctx),
+		Template:  template,
+		MessageID: queued.MessageID,
+	}
+
+	jobID, err := h.storage.EnqueueJob(ctx, jobs.KindExportOrders, payload, msg.From.ID, h.cfg.Jobs.MaxAttempts)
+	if err != nil {
+		_, _ = h.botAPI.Send(tgbotapi.NewEditMessageText(msg.Chat.ID, queued.MessageID, "Не удалось поставить экспорт в очередь"))
+		return fmt.Errorf("failed to enqueue export: %w", err)
+	}
+
+	audit.Record(ctx, fmt.Sprintf("job:%d", jobID), nil, payload)
+
+	h.logger.Info("Orders export queued",
+		zap.Int64("job_id", jobID),
+		zap.Int64("admin_id", msg.From.ID),
+		zap.Bool("anonymized", payload.Anonymize),
+		zap.Bool("files", payload.Files),
+		zap.String("template", payload.Template),
+		zap.Int("policy", int(payload.Policy)))
+
+	return nil
+}
+
+// exportPolicy shows costs and margins to admins only
+func exportPolicy(ctx context.Context) postgres.ColumnPolicy {
+	if isAdmin(ctx) {
+		return postgres.ColumnsAll
+	}
+	return postgres.ColumnsStaff
+}
+
+// RetryJobHandler serves /retryjob <id> for jobs that ran out of attempts
+type RetryJobHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewRetryJobHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *RetryJobHandler {
+	return &RetryJobHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *RetryJobHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	jobID, err := strconv.ParseInt(strings.TrimSpace(msg.CommandArguments()), 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, "Использование: /retryjob <id>")
+	}
+
+	if err := h.storage.RetryJob(ctx, jobID); err != nil {
+		if errors.Is(err, postgres.ErrJobNotFound) {
+			return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Задача #%d не найдена или не в статусе failed", jobID))
+		}
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("job:%d", jobID),
+		map[string]any{"status": postgres.JobFailed}, map[string]any{"status": postgres.JobPending})
+
+	h.logger.Info("Job requeued by admin",
+		zap.Int64("job_id", jobID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Задача #%d снова в очереди", jobID))
+}
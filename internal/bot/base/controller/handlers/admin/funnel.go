@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// defaultFunnelDays is the range shown without dates
+const defaultFunnelDays = 30
+
+// FunnelHandler serves /admin funnel [from] [to]: how many customers
+// reached each step of the order dialogs, and how many of them went on,
+// over the given days (YYYY-MM-DD, both included; the last 30 days by
+// default)
+type FunnelHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewFunnelHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *FunnelHandler {
+	return &FunnelHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *FunnelHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	const usage = "Использование: /admin funnel [YYYY-MM-DD] [YYYY-MM-DD]"
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) == 0 || args[0] != "funnel" || len(args) > 3 {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, to := today.AddDate(0, 0, -defaultFunnelDays+1), today
+	var err error
+	if len(args) > 1 {
+		if from, err = time.ParseInLocation("2006-01-02", args[1], time.Local); err != nil {
+			return reply(h.botAPI, msg.Chat.ID, usage)
+		}
+	}
+	if len(args) > 2 {
+		if to, err = time.ParseInLocation("2006-01-02", args[2], time.Local); err != nil {
+			return reply(h.botAPI, msg.Chat.ID, usage)
+		}
+	}
+	if to.Before(from) {
+		return reply(h.botAPI, msg.Chat.ID, "Начало периода позже конца")
+	}
+
+	steps, err := h.storage.GetFunnel(ctx, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>Воронка за %s – %s</b>\n", from.Format("02.01.2006"), to.Format("02.01.2006"))
+	if len(steps) == 0 {
+		text.WriteString("\nСобытий нет")
+		return reply(h.botAPI, msg.Chat.ID, text.String())
+	}
+
+	var flow string
+	var started, previous int
+	for _, step := range steps {
+		if step.Flow != flow {
+			flow, started, previous = step.Flow, funnelStarted(steps, step.Flow), 0
+			fmt.Fprintf(&text, "\n<b>%s</b>, начали: %d\n", html.EscapeString(flow), started)
+		}
+		if step.Step == postgres.FunnelStarted {
+			previous = step.Users
+			continue
+		}
+
+		line := fmt.Sprintf("%s — %d", html.EscapeString(step.Step), step.Users)
+		if started > 0 {
+			line += fmt.Sprintf(" (%.0f%%", percent(step.Users, started))
+			if previous > step.Users {
+				line += fmt.Sprintf(", ушли %d", previous-step.Users)
+			}
+			line += ")"
+		}
+		text.WriteString(line + "\n")
+		previous = step.Users
+	}
+
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
+
+// funnelStarted is how many customers started the flow
+func funnelStarted(steps []postgres.FunnelStep, flow string) int {
+	for _, step := range steps {
+		if step.Flow == flow && step.Step == postgres.FunnelStarted {
+			return step.Users
+		}
+	}
+	return 0
+}
+
+func percent(part, whole int) float64 {
+	return float64(part) * 100 / float64(whole)
+}
@@ -1 +1,20 @@
 package admin
+
+import (
+	"context"
+	"s1ntez/internal/auth"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isAdmin reports whether the auth middleware let an admin through
+func isAdmin(ctx context.Context) bool {
+	return auth.Has(ctx, auth.Admin)
+}
+
+func reply(botAPI *tgbotapi.BotAPI, chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := botAPI.Send(msg)
+	return err
+}
@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/fraud"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// HoldReviewHandler handles the approve/cancel buttons on held orders
+type HoldReviewHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	bus     *events.Bus
+	cfg     *config.Config
+}
+
+func NewHoldReviewHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, bus *events.Bus, cfg *config.Config) *HoldReviewHandler {
+	return &HoldReviewHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		bus:     bus,
+		cfg:     cfg,
+	}
+}
+
+func (h *HoldReviewHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !auth.Has(ctx, auth.Manager) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 || parts[0] != fraud.CallbackPrefix {
+		return fmt.Errorf("bad hold callback %q", query.Data)
+	}
+
+	orderID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad order id in callback %q", query.Data)
+	}
+	approve := parts[1] == "approve"
+
+	order, err := h.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.storage.ResolveHold(ctx, orderID, query.From.ID, approve)
+	if err != nil {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, err.Error()))
+		return nil
+	}
+
+	verdict := "отменён"
+	switch status {
+	case postgres.StatusNew:
+		verdict = "одобрен"
+	case postgres.StatusAwaitingPrepayment:
+		verdict = "одобрен, ждёт предоплату"
+	case postgres.StatusPendingQuote:
+		verdict = "одобрен, ждёт подтверждения цены"
+	}
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+		map[string]any{"status": order.Status}, map[string]any{"status": status})
+
+	h.logger.Info("Order hold resolved",
+		zap.Int64("order_id", orderID),
+		zap.Bool("approved", approve),
+		zap.Int64("admin_id", query.From.ID))
+
+	h.bus.Publish(ctx, events.Event{
+		Type:       events.OrderStatusChanged,
+		OrderID:    orderID,
+		UserID:     order.UserID,
+		Status:     status,
+		PrevStatus: order.Status,
+	})
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("%s\n\nЗаказ %s (%s)", query.Message.Text, verdict, query.From.UserName))
+	_, err = h.botAPI.Send(edit)
+	return err
+}
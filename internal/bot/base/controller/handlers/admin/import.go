@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Only the first errors are listed in the chat to stay under the message limit
+const maxReportedImportErrors = 30
+
+// ImportHandler serves /import as a reply to an .xlsx document with the
+// export layout, for migrating the old spreadsheet history
+type ImportHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewImportHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *ImportHandler {
+	return &ImportHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *ImportHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.Document == nil {
+		return reply(h.botAPI, msg.Chat.ID, "Отправьте /import ответом на .xlsx файл")
+	}
+
+	url, err := h.botAPI.GetFileDirectURL(msg.ReplyToMessage.Document.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	report, err := h.storage.ImportOrdersFromExcel(ctx, resp.Body)
+	if err != nil {
+		_ = reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Импорт не выполнен: %v", err))
+		return err
+	}
+
+	audit.Record(ctx, "orders:import", nil, map[string]any{
+		"file":     msg.ReplyToMessage.Document.FileName,
+		"total":    report.Total,
+		"imported": report.Imported,
+		"rejected": len(report.Errors),
+	})
+
+	h.logger.Info("Orders import finished",
+		zap.Int64("admin_id", msg.From.ID),
+		zap.Int("imported", report.Imported),
+		zap.Int("rejected", len(report.Errors)))
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Импортировано %d из %d строк\n", report.Imported, report.Total)
+	for i, rowErr := range report.Errors {
+		if i == maxReportedImportErrors {
+			fmt.Fprintf(&text, "… и ещё %d ошибок\n", len(report.Errors)-i)
+			break
+		}
+		fmt.Fprintf(&text, "Строка %d: %s\n", rowErr.Row, rowErr.Err)
+	}
+
+	_, err = h.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, text.String()))
+	return err
+}
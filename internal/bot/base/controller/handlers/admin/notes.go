@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Notes go to the customer in notifications, keep them a message long
+const maxNoteLen = 1000
+
+// OrderNoteHandler serves /admin note <order_id> [text]: with a text it
+// adds a production note to the order, without one it shows the notes.
+// The customer sees the notes on the order card.
+type OrderNoteHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewOrderNoteHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *OrderNoteHandler {
+	return &OrderNoteHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *OrderNoteHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	const usage = "Использование: /admin note &lt;order_id&gt; [текст]"
+
+	args, ok := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), "note")
+	if !ok {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	rawID, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	orderID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return h.list(ctx, msg.Chat.ID, orderID)
+	}
+	if utf8.RuneCountInString(text) > maxNoteLen {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заметка длиннее %d символов", maxNoteLen))
+	}
+
+	note, err := h.storage.AddOrderNote(ctx, orderID, msg.From.ID, text)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID), nil, note)
+
+	h.logger.Info("Order note added",
+		zap.Int64("order_id", orderID),
+		zap.Int64("note_id", note.ID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заметка к заказу #%d добавлена, клиент увидит её в карточке заказа", orderID))
+}
+
+func (h *OrderNoteHandler) list(ctx context.Context, chatID, orderID int64) error {
+	notes, err := h.storage.GetOrderNotes(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return reply(h.botAPI, chatID, fmt.Sprintf("У заказа #%d нет заметок", orderID))
+	}
+
+	// The newest notes are kept when the thread doesn't fit in a message
+	header := fmt.Sprintf("<b>Заметки к заказу #%d</b>\n", orderID)
+	size := len(header)
+	var entries []string
+	for i := len(notes) - 1; i >= 0; i-- {
+		entry := fmt.Sprintf("\n<i>%s, admin %d</i>\n%s\n",
+			notes[i].CreatedAt.Format("02.01.2006 15:04"), notes[i].AuthorID, html.EscapeString(notes[i].Text))
+		if size+len(entry) > maxAuditMessageLen {
+			break
+		}
+		size += len(entry)
+		entries = append(entries, entry)
+	}
+	slices.Reverse(entries)
+
+	return reply(h.botAPI, chatID, header+strings.Join(entries, ""))
+}
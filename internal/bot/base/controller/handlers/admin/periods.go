@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/jobs"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// PeriodCloseHandler runs the month close:
+//
+//	/closemonth [YYYY-MM]  (previous month by default)
+//	/unlockmonth YYYY-MM
+type PeriodCloseHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewPeriodCloseHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *PeriodCloseHandler {
+	return &PeriodCloseHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *PeriodCloseHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	arg := strings.TrimSpace(msg.CommandArguments())
+
+	if msg.Command() == "unlockmonth" {
+		month, err := time.ParseInLocation("2006-01", arg, time.Local)
+		if err != nil {
+			return reply(h.botAPI, msg.Chat.ID, "Использование: /unlockmonth 2025-01")
+		}
+		return h.unlock(ctx, msg, month)
+	}
+
+	month := time.Now().AddDate(0, -1, 0)
+	if arg != "" {
+		parsed, err := time.ParseInLocation("2006-01", arg, time.Local)
+		if err != nil {
+			return reply(h.botAPI, msg.Chat.ID, "Использование: /closemonth [2025-01]")
+		}
+		month = parsed
+	}
+
+	if h.cfg.Accounting.SigningKey == "" {
+		return reply(h.botAPI, msg.Chat.ID, "Не задан ACCOUNTING_SIGNING_KEY, закрытие невозможно")
+	}
+
+	if _, end := postgres.MonthBounds(month); end.After(time.Now()) {
+		return reply(h.botAPI, msg.Chat.ID, "Месяц ещё не закончился")
+	}
+
+	closing, err := h.storage.GetPeriodClose(ctx, month)
+	switch {
+	case err == nil:
+		valid := "подпись верна"
+		if !closing.VerifySignature(h.cfg.Accounting.SigningKey) {
+			valid = "⚠️ подпись не совпадает"
+		}
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf(
+			"Месяц %s уже закрыт %s (%s).\nДля исправлений: /unlockmonth %s",
+			month.Format("2006-01"), closing.ClosedAt.Format("02.01.2006 15:04"), valid, month.Format("2006-01")))
+	case !errors.Is(err, postgres.ErrPeriodNotClosed):
+		return err
+	}
+
+	locale, err := h.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	payload := jobs.CloseMonthPayload{
+		ChatID:  msg.Chat.ID,
+		AdminID: msg.From.ID,
+		Month:   month,
+		Locale:  locale,
+	}
+
+	jobID, err := h.storage.EnqueueJob(ctx, jobs.KindCloseMonth, payload, msg.From.ID, h.cfg.Jobs.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue month close: %w", err)
+	}
+
+	h.logger.Info("Month close queued",
+		zap.Int64("job_id", jobID),
+		zap.String("month", month.Format("2006-01")),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Закрытие %s поставлено в очередь (задача #%d)",
+		month.Format("2006-01"), jobID))
+}
+
+func (h *PeriodCloseHandler) unlock(ctx context.Context, msg *tgbotapi.Message, month time.Time) error {
+	err := h.storage.UnlockPeriod(ctx, month, msg.From.ID)
+	if errors.Is(err, postgres.ErrPeriodNotClosed) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Месяц %s не закрыт", month.Format("2006-01")))
+	}
+	if errors.Is(err, postgres.ErrPeriodArchived) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказы %s уже в архиве, месяц нельзя открыть", month.Format("2006-01")))
+	}
+	if err != nil {
+		return err
+	}
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf(
+		"Месяц %s открыт для правок 🔓\nПосле исправлений закройте его снова: /closemonth %s",
+		month.Format("2006-01"), month.Format("2006-01")))
+}
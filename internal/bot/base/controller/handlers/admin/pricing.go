@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const setRatesUsage = `Использование: /setrates 3 6 [usn_income] [2025-01-01]
+Режимы: usn_income — УСН «доходы», usn_profit — УСН «доходы минус расходы», npd — НПД, vat — НДС в цене`
+
+// regimeNames are the tax regimes as staff know them
+var regimeNames = map[string]string{
+	pricing.RegimeUSNIncome: "УСН доходы",
+	pricing.RegimeUSNProfit: "УСН доходы минус расходы",
+	pricing.RegimeNPD:       "НПД",
+	pricing.RegimeVAT:       "НДС",
+}
+
+// PricingRulesHandler updates commission/tax rates without a redeploy:
+//
+//	/setrates <commission %> <tax %> [regime] [YYYY-MM-DD]
+//	/rates
+//
+// The regime is one of pricing.Regimes, TAX_REGIME when left out.
+type PricingRulesHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewPricingRulesHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *PricingRulesHandler {
+	return &PricingRulesHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *PricingRulesHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.Command() == "rates" {
+		return h.list(ctx, msg.Chat.ID)
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 {
+		return reply(h.botAPI, msg.Chat.ID, setRatesUsage)
+	}
+
+	commission, err := parsePercent(args[0])
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неверная комиссия: %v", err))
+	}
+	tax, err := parsePercent(args[1])
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неверный налог: %v", err))
+	}
+
+	regime := h.cfg.CurrentPricing().TaxRegime
+	rest := args[2:]
+	if len(rest) > 0 && slices.Contains(pricing.Regimes, rest[0]) {
+		regime, rest = rest[0], rest[1:]
+	}
+
+	effectiveFrom := time.Now()
+	if len(rest) > 0 {
+		effectiveFrom, err = time.ParseInLocation("2006-01-02", rest[0], time.Local)
+		if err != nil {
+			return reply(h.botAPI, msg.Chat.ID, setRatesUsage)
+		}
+	}
+
+	id, err := h.storage.CreatePricingRule(ctx, postgres.PricingRule{
+		CommissionRate: commission,
+		TaxRate:        tax,
+		TaxRegime:      regime,
+		EffectiveFrom:  effectiveFrom,
+		CreatedBy:      msg.From.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Pricing rule created",
+		zap.Int64("rule_id", id),
+		zap.Int64("admin_id", msg.From.ID),
+		zap.Float64("commission_rate", commission),
+		zap.Float64("tax_rate", tax),
+		zap.String("tax_regime", regime),
+		zap.Time("effective_from", effectiveFrom))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf(
+		"Ставки #%d: комиссия %.2f%%, налог %.2f%% (%s) с %s",
+		id, commission*100, tax*100, regimeNames[regime], effectiveFrom.Format("2006-01-02 15:04"),
+	))
+}
+
+func (h *PricingRulesHandler) list(ctx context.Context, chatID int64) error {
+	rules, err := h.storage.ListPricingRules(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Ставки комиссии и налога</b>\n\n")
+	for _, r := range rules {
+		fmt.Fprintf(&text, "#%d с %s: комиссия %.2f%%, налог %.2f%% (%s)\n",
+			r.ID, r.EffectiveFrom.Format("2006-01-02"), r.CommissionRate*100, r.TaxRate*100, regimeNames[r.TaxRegime])
+	}
+
+	return reply(h.botAPI, chatID, text.String())
+}
+
+// parsePercent converts "6" or "6.5%" into a 0..1 rate
+func parsePercent(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.ReplaceAll(s, ",", "."), "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v >= 100 {
+		return 0, fmt.Errorf("value %.2f out of range", v)
+	}
+	return v / 100, nil
+}
@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/promo"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const promoUsage = "Использование: /addpromo CODE 10%|500 [uses=100] [peruser=1] [until=2025-12-31]"
+
+// PromoCodeHandler creates and lists promo codes:
+//
+//	/addpromo <code> <percent%|rubles> [uses=N] [peruser=N] [until=YYYY-MM-DD]
+//	/promos
+type PromoCodeHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	promos  *promo.Service
+	cfg     *config.Config
+}
+
+func NewPromoCodeHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, promos *promo.Service, cfg *config.Config) *PromoCodeHandler {
+	return &PromoCodeHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		promos:  promos,
+		cfg:     cfg,
+	}
+}
+
+func (h *PromoCodeHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	if msg.Command() == "promos" {
+		return h.list(ctx, msg.Chat.ID)
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 {
+		return reply(h.botAPI, msg.Chat.ID, promoUsage)
+	}
+
+	code := postgres.PromoCode{
+		Code:         args[0],
+		Kind:         postgres.PromoFixed,
+		PerUserLimit: 1,
+		CreatedBy:    msg.From.ID,
+	}
+
+	raw := strings.ReplaceAll(args[1], ",", ".")
+	if strings.HasSuffix(raw, "%") {
+		code.Kind = postgres.PromoPercent
+		raw = strings.TrimSuffix(raw, "%")
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, "Скидка должна быть числом: 10% или 500")
+	}
+	code.Value = value
+
+	for _, arg := range args[2:] {
+		key, val, _ := strings.Cut(arg, "=")
+		switch key {
+		case "uses":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return reply(h.botAPI, msg.Chat.ID, "uses должно быть целым числом")
+			}
+			code.MaxUses = &n
+		case "peruser":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return reply(h.botAPI, msg.Chat.ID, "peruser должно быть целым числом")
+			}
+			code.PerUserLimit = n
+		case "until":
+			day, err := time.ParseInLocation("2006-01-02", val, time.Local)
+			if err != nil {
+				return reply(h.botAPI, msg.Chat.ID, "Дата должна быть в формате YYYY-MM-DD")
+			}
+			// The code is valid through the whole last day
+			expires := day.AddDate(0, 0, 1)
+			code.ExpiresAt = &expires
+		default:
+			return reply(h.botAPI, msg.Chat.ID, promoUsage)
+		}
+	}
+
+	id, err := h.promos.Create(ctx, code)
+	switch {
+	case errors.Is(err, promo.ErrInvalidCode), errors.Is(err, promo.ErrInvalidValue):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неверный промокод: %v", err))
+	case errors.Is(err, postgres.ErrPromoExists):
+		return reply(h.botAPI, msg.Chat.ID, "Такой промокод уже есть")
+	case err != nil:
+		return err
+	}
+
+	h.logger.Info("Promo code created",
+		zap.Int64("promocode_id", id),
+		zap.Int64("admin_id", msg.From.ID),
+		zap.String("code", promo.Normalize(code.Code)),
+		zap.String("kind", string(code.Kind)),
+		zap.Float64("value", code.Value))
+
+	code.Code = promo.Normalize(code.Code)
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Промокод #%d создан: %s", id, describePromo(code)))
+}
+
+func (h *PromoCodeHandler) list(ctx context.Context, chatID int64) error {
+	codes, err := h.storage.ListPromoCodes(ctx, 20)
+	if err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return reply(h.botAPI, chatID, "Промокодов пока нет")
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Промокоды</b>\n\n")
+	for _, c := range codes {
+		fmt.Fprintf(&text, "#%d %s, использован %d раз\n", c.ID, describePromo(c), c.UsedCount)
+	}
+
+	return reply(h.botAPI, chatID, text.String())
+}
+
+func describePromo(c postgres.PromoCode) string {
+	discount := fmt.Sprintf("%.2f ₽", c.Value)
+	if c.Kind == postgres.PromoPercent {
+		discount = fmt.Sprintf("%.2f%%", c.Value)
+	}
+
+	text := fmt.Sprintf("<code>%s</code> −%s, на клиента %d", c.Code, discount, c.PerUserLimit)
+	if c.MaxUses != nil {
+		text += fmt.Sprintf(", всего %d", *c.MaxUses)
+	}
+	if c.ExpiresAt != nil {
+		text += ", до " + c.ExpiresAt.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	return text
+}
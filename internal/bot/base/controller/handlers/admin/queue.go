@@ -0,0 +1,219 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	queueCallbackPrefix = "queue"
+	// queueLimit keeps the message and its keyboard within Telegram limits
+	queueLimit = 30
+)
+
+// queueMoves are the transitions the workshop makes from the queue
+var queueMoves = map[string]struct {
+	from, to postgres.OrderStatus
+}{
+	"start": {postgres.StatusNew, postgres.StatusProcessing},
+	"done":  {postgres.StatusProcessing, postgres.StatusCompleted},
+}
+
+// ProductionQueueHandler serves /queue for workshop staff: open orders by
+// deadline, in two columns, with buttons that move an order to the next one
+type ProductionQueueHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	orders  *orders.Service
+	cfg     *config.Config
+}
+
+func NewProductionQueueHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, orderService *orders.Service, cfg *config.Config) *ProductionQueueHandler {
+	return &ProductionQueueHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		orders:  orderService,
+		cfg:     cfg,
+	}
+}
+
+func (h *ProductionQueueHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Production) {
+		return nil
+	}
+
+	text, keyboard, err := h.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeHTML
+	reply.ReplyMarkup = keyboard
+	_, err = h.botAPI.Send(reply)
+	return err
+}
+
+func (h *ProductionQueueHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !auth.Has(ctx, auth.Production) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	// queue:refresh or queue:<start|done>:<order_id>
+	parts := strings.Split(query.Data, ":")
+	answer := ""
+	switch {
+	case len(parts) == 2 && parts[1] == "refresh":
+	case len(parts) == 3:
+		move, ok := queueMoves[parts[1]]
+		orderID, err := strconv.ParseInt(parts[2], 10, 64)
+		if !ok || err != nil {
+			return fmt.Errorf("bad queue callback %q", query.Data)
+		}
+		if answer, err = h.move(ctx, query.From, orderID, move.from, move.to); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("bad queue callback %q", query.Data)
+	}
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, answer))
+
+	text, keyboard, err := h.render(ctx)
+	if err != nil {
+		return err
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := h.botAPI.Send(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// move changes the status unless someone already moved the order on; the
+// returned text is shown to the worker
+func (h *ProductionQueueHandler) move(ctx context.Context, from *tgbotapi.User, orderID int64, prev, next postgres.OrderStatus) (string, error) {
+	order, err := h.storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return fmt.Sprintf("Заказ #%d не найден", orderID), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if order.Status != prev {
+		return fmt.Sprintf("Заказ #%d уже %s", orderID, queueStatusName(order.Status)), nil
+	}
+
+	if _, err := h.orders.ChangeStatus(ctx, orderID, next); err != nil {
+		return "", err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+		map[string]any{"status": prev}, map[string]any{"status": next})
+
+	h.logger.Info("Order moved in production queue",
+		zap.Int64("order_id", orderID),
+		zap.Stringer("to", next),
+		zap.Int64("staff_id", from.ID))
+
+	return fmt.Sprintf("Заказ #%d: %s", orderID, queueStatusName(next)), nil
+}
+
+func (h *ProductionQueueHandler) render(ctx context.Context) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	queue, err := h.storage.GetProductionQueue(ctx, queueLimit)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	var waiting, inWork strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, order := range queue {
+		line := queueLine(order)
+		switch order.Status {
+		case postgres.StatusNew:
+			waiting.WriteString(line)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("▶️ В работу #%d", order.ID),
+				fmt.Sprintf("%s:start:%d", queueCallbackPrefix, order.ID))))
+		case postgres.StatusProcessing:
+			inWork.WriteString(line)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("✅ Готов #%d", order.ID),
+				fmt.Sprintf("%s:done:%d", queueCallbackPrefix, order.ID))))
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+		"🔄 Обновить", queueCallbackPrefix+":refresh")))
+
+	var text strings.Builder
+	text.WriteString("<b>Очередь производства</b>\n")
+	if len(queue) == 0 {
+		text.WriteString("\nЗаказов нет")
+	}
+	if waiting.Len() > 0 {
+		text.WriteString("\n<b>Ждут</b>\n" + waiting.String())
+	}
+	if inWork.Len() > 0 {
+		text.WriteString("\n<b>В работе</b>\n" + inWork.String())
+	}
+	if len(queue) == queueLimit {
+		fmt.Fprintf(&text, "\nПоказаны первые %d заказов", queueLimit)
+	}
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+func queueLine(order postgres.Order) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "#%d", order.ID)
+	if order.IsRush {
+		line.WriteString(" 🔥")
+	}
+	if deadline := order.Deadline(); deadline != nil {
+		fmt.Fprintf(&line, " · до %s", deadline.Format("02.01 15:04"))
+		if deadline.Before(time.Now()) {
+			line.WriteString(" ⚠️")
+		}
+	}
+	for _, item := range order.LineItems() {
+		line.WriteString("\n    " + item.String())
+	}
+	line.WriteString("\n")
+	return line.String()
+}
+
+func queueStatusName(status postgres.OrderStatus) string {
+	switch status {
+	case postgres.StatusNew:
+		return "ждёт"
+	case postgres.StatusProcessing:
+		return "в работе"
+	case postgres.StatusCompleted:
+		return "готов"
+	case postgres.StatusCancelled:
+		return "отменён"
+	case postgres.StatusOnHold:
+		return "на проверке"
+	case postgres.StatusAwaitingPrepayment:
+		return "ждёт предоплату"
+	case postgres.StatusPendingQuote:
+		return "ждёт цену"
+	}
+	return status.String()
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/config"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// ReloadHandler serves /admin reload: the same as sending the bot SIGHUP.
+// Admin and production IDs, pricing coefficients, order limits and the
+// statistics schedule are applied; other settings still need a restart.
+type ReloadHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	watcher *config.Watcher
+	cfg     *config.Config
+}
+
+func NewReloadHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, watcher *config.Watcher, cfg *config.Config) *ReloadHandler {
+	return &ReloadHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		watcher: watcher,
+		cfg:     cfg,
+	}
+}
+
+func (h *ReloadHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	changed, err := h.watcher.Reload()
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Конфигурация не применена, действует прежняя: %s", html.EscapeString(err.Error())))
+	}
+	if len(changed) == 0 {
+		return reply(h.botAPI, msg.Chat.ID, "Конфигурация перечитана, изменений нет")
+	}
+
+	audit.Record(ctx, "config", nil, changed)
+	return reply(h.botAPI, msg.Chat.ID, "Конфигурация обновлена: "+strings.Join(changed, ", "))
+}
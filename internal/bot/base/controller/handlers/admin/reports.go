@@ -0,0 +1,285 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// templateName keeps names usable as /export arguments and in file names
+var templateName = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+const reportTemplateUsage = `Использование:
+/admin report — шаблоны экспорта
+/admin report show &lt;name&gt;
+/admin report del &lt;name&gt;
+/admin report set &lt;name&gt;
+columns: id, created_at, price, status
+statuses: new, processing
+header: price=Сумма; status=Статус
+header.en: price=Amount
+
+Экспорт по шаблону: /export template=&lt;name&gt;`
+
+// ReportTemplateHandler serves /admin report: saves, shows and deletes the
+// templates of the orders export. A template lists the columns in sheet
+// order, may rename them, for all locales or for one, and may limit the
+// export to some statuses.
+type ReportTemplateHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewReportTemplateHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *ReportTemplateHandler {
+	return &ReportTemplateHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *ReportTemplateHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	args, ok := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), "report")
+	if !ok {
+		return reply(h.botAPI, msg.Chat.ID, reportTemplateUsage)
+	}
+	first, rest, _ := strings.Cut(strings.TrimSpace(args), "\n")
+	fields := strings.Fields(first)
+
+	switch {
+	case len(fields) == 0:
+		return h.list(ctx, msg.Chat.ID)
+	case len(fields) == 2 && fields[0] == "show":
+		return h.show(ctx, msg.Chat.ID, fields[1])
+	case len(fields) == 2 && fields[0] == "del":
+		return h.delete(ctx, msg, fields[1])
+	case len(fields) == 2 && fields[0] == "set":
+		return h.save(ctx, msg, fields[1], rest)
+	}
+	return reply(h.botAPI, msg.Chat.ID, reportTemplateUsage)
+}
+
+func (h *ReportTemplateHandler) list(ctx context.Context, chatID int64) error {
+	templates, err := h.storage.ListReportTemplates(ctx)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Шаблоны экспорта</b>\n")
+	if len(templates) == 0 {
+		text.WriteString("\nШаблонов пока нет\n")
+	}
+	for _, t := range templates {
+		fmt.Fprintf(&text, "\n<code>%s</code> — %d колонок", t.Name, len(t.Columns))
+		if len(t.Statuses) > 0 {
+			fmt.Fprintf(&text, ", статусы: %s", strings.Join(t.Statuses, ", "))
+		}
+	}
+	text.WriteString("\n\n" + reportTemplateUsage)
+	return reply(h.botAPI, chatID, text.String())
+}
+
+func (h *ReportTemplateHandler) show(ctx context.Context, chatID int64, name string) error {
+	t, err := h.storage.GetReportTemplate(ctx, name)
+	if errors.Is(err, postgres.ErrReportTemplateNotFound) {
+		return reply(h.botAPI, chatID, fmt.Sprintf("Шаблон %s не найден", html.EscapeString(name)))
+	}
+	if err != nil {
+		return err
+	}
+	return reply(h.botAPI, chatID, formatReportTemplate(t))
+}
+
+// formatReportTemplate renders the template in the form /admin report set
+// accepts, so it can be copied and edited
+func formatReportTemplate(t *postgres.ReportTemplate) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "<code>/admin report set %s\ncolumns: %s", t.Name, strings.Join(t.Columns, ", "))
+	if len(t.Statuses) > 0 {
+		fmt.Fprintf(&text, "\nstatuses: %s", strings.Join(t.Statuses, ", "))
+	}
+	locales := make([]i18n.Locale, 0, len(t.Headers))
+	for locale := range t.Headers {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	for _, locale := range locales {
+		key := "header"
+		if locale != "" {
+			key += "." + string(locale)
+		}
+		titles := make([]string, 0, len(t.Headers[locale]))
+		for _, column := range t.Columns {
+			if title, ok := t.Headers[locale][column]; ok {
+				titles = append(titles, column+"="+title)
+			}
+		}
+		fmt.Fprintf(&text, "\n%s: %s", key, html.EscapeString(strings.Join(titles, "; ")))
+	}
+	text.WriteString("</code>")
+	return text.String()
+}
+
+func (h *ReportTemplateHandler) save(ctx context.Context, msg *tgbotapi.Message, name, body string) error {
+	if !templateName.MatchString(name) {
+		return reply(h.botAPI, msg.Chat.ID, "Имя шаблона: латинские буквы в нижнем регистре, цифры, _ и -, до 50 символов")
+	}
+
+	t, problem := parseReportTemplate(body)
+	if problem != "" {
+		return reply(h.botAPI, msg.Chat.ID, html.EscapeString(problem)+"\n\n"+reportTemplateUsage)
+	}
+	t.Name = name
+	t.CreatedBy = msg.From.ID
+
+	// before stays nil for a new template, audited as created
+	var before any
+	existing, err := h.storage.GetReportTemplate(ctx, name)
+	switch {
+	case err == nil:
+		before = existing
+	case !errors.Is(err, postgres.ErrReportTemplateNotFound):
+		return err
+	}
+
+	saved, err := h.storage.SaveReportTemplate(ctx, t)
+	if errors.Is(err, postgres.ErrUnknownExportColumn) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("%s\nКолонки: %s",
+			html.EscapeString(err.Error()), strings.Join(postgres.ExportColumnNames(), ", ")))
+	}
+	if errors.Is(err, postgres.ErrInvalidOrderStatus) {
+		return reply(h.botAPI, msg.Chat.ID, html.EscapeString(err.Error()))
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "report_template:"+name, before, saved)
+
+	h.logger.Info("Report template saved",
+		zap.String("template", name),
+		zap.Int("columns", len(saved.Columns)),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Шаблон сохранён, экспорт: /export template=%s\n\n%s", name, formatReportTemplate(saved)))
+}
+
+// parseReportTemplate reads the "key: value" lines of /admin report set.
+// The problem is a message for the admin, empty when the body is fine.
+func parseReportTemplate(body string) (postgres.ReportTemplate, string) {
+	var t postgres.ReportTemplate
+	for line := range strings.Lines(body) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return t, "Непонятная строка: " + line
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch {
+		case key == "columns":
+			t.Columns = splitList(value)
+		case key == "statuses":
+			for _, raw := range splitList(value) {
+				status, err := postgres.ParseOrderStatus(raw)
+				if err != nil {
+					return t, "Неизвестный статус: " + raw
+				}
+				t.Statuses = append(t.Statuses, string(status))
+			}
+		case key == "header" || strings.HasPrefix(key, "header."):
+			var locale i18n.Locale
+			if code, ok := strings.CutPrefix(key, "header."); ok {
+				locale = i18n.Locale(code)
+				if !slices.Contains(i18n.Supported(), locale) {
+					return t, "Неизвестный язык: " + code
+				}
+			}
+			titles, problem := parseHeaders(value)
+			if problem != "" {
+				return t, problem
+			}
+			if t.Headers == nil {
+				t.Headers = postgres.ReportHeaders{}
+			}
+			t.Headers[locale] = titles
+		default:
+			return t, "Неизвестный параметр: " + key
+		}
+	}
+
+	if len(t.Columns) == 0 {
+		return t, "Укажите колонки: columns: id, price, ..."
+	}
+	return t, ""
+}
+
+// parseHeaders reads "column=Title; column=Title"
+func parseHeaders(value string) (map[string]string, string) {
+	titles := make(map[string]string)
+	for pair := range strings.SplitSeq(value, ";") {
+		column, title, ok := strings.Cut(pair, "=")
+		column, title = strings.TrimSpace(column), strings.TrimSpace(title)
+		if !ok || column == "" || title == "" {
+			return nil, "Заголовки задаются так: price=Сумма; status=Статус"
+		}
+		titles[column] = title
+	}
+	return titles, ""
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (h *ReportTemplateHandler) delete(ctx context.Context, msg *tgbotapi.Message, name string) error {
+	before, err := h.storage.GetReportTemplate(ctx, name)
+	if errors.Is(err, postgres.ErrReportTemplateNotFound) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Шаблон %s не найден", html.EscapeString(name)))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.storage.DeleteReportTemplate(ctx, name); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "report_template:"+name, before, nil)
+
+	h.logger.Info("Report template deleted",
+		zap.String("template", name),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Шаблон %s удалён", html.EscapeString(name)))
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const reviewModerationPrefix = "revmod"
+
+// ReviewModerationHandler serves /admin reviews, the queue of customer
+// comments waiting to be shown in /reviews: the oldest one comes with
+// publish and reject buttons, and the next one follows each verdict
+type ReviewModerationHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewReviewModerationHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *ReviewModerationHandler {
+	return &ReviewModerationHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *ReviewModerationHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+	return h.sendNext(ctx, msg.Chat.ID)
+}
+
+// HandleCallback takes revmod:approve:<id> and revmod:reject:<id>
+func (h *ReviewModerationHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !auth.Has(ctx, auth.Manager) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 || parts[0] != reviewModerationPrefix || (parts[1] != "approve" && parts[1] != "reject") {
+		return fmt.Errorf("bad review moderation callback %q", query.Data)
+	}
+	reviewID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad review id in callback %q", query.Data)
+	}
+	approve := parts[1] == "approve"
+
+	err = h.storage.ModerateReview(ctx, reviewID, query.From.ID, approve)
+	if errors.Is(err, postgres.ErrReviewModerated) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Отзыв уже проверен"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	verdict, after := "отклонён", postgres.ReviewRejected
+	if approve {
+		verdict, after = "опубликован", postgres.ReviewApproved
+	}
+	audit.Record(ctx, fmt.Sprintf("review:%d", reviewID),
+		map[string]any{"moderation": postgres.ReviewPending}, map[string]any{"moderation": after})
+
+	h.logger.Info("Review moderated",
+		zap.Int64("review_id", reviewID),
+		zap.Bool("approved", approve),
+		zap.Int64("admin_id", query.From.ID))
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("%s\n\nОтзыв %s (%s)", query.Message.Text, verdict, query.From.UserName))
+	if _, err := h.botAPI.Send(edit); err != nil {
+		return err
+	}
+	return h.sendNext(ctx, query.Message.Chat.ID)
+}
+
+// sendNext posts the oldest comment of the queue, or says it is empty
+func (h *ReviewModerationHandler) sendNext(ctx context.Context, chatID int64) error {
+	review, queued, err := h.storage.GetNextReviewToModerate(ctx)
+	if errors.Is(err, postgres.ErrReviewNotFound) {
+		return reply(h.botAPI, chatID, "Новых отзывов на проверке нет")
+	}
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("💬 Отзыв на проверке (в очереди: %d)\nЗаказ #%d, пользователь %d, %s\nОценка: %s\n\n%s",
+		queued, review.OrderID, review.UserID, review.CreatedAt.Format("02.01.2006 15:04"),
+		strings.Repeat("⭐", review.Rating), html.EscapeString(review.Comment))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Опубликовать",
+			fmt.Sprintf("%s:approve:%d", reviewModerationPrefix, review.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("🚫 Отклонить",
+			fmt.Sprintf("%s:reject:%d", reviewModerationPrefix, review.ID)),
+	))
+	_, err = h.botAPI.Send(msg)
+	return err
+}
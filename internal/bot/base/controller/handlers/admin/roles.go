@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// RoleGrantHandler serves /admin grant <user_id> <role> and lists the
+// staff for /admin grant without arguments
+type RoleGrantHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	auth    *auth.Service
+	cfg     *config.Config
+}
+
+func NewRoleGrantHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, authService *auth.Service, cfg *config.Config) *RoleGrantHandler {
+	return &RoleGrantHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		auth:    authService,
+		cfg:     cfg,
+	}
+}
+
+func (h *RoleGrantHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	roles := make([]string, len(auth.Roles))
+	for i, role := range auth.Roles {
+		roles[i] = role.String()
+	}
+	usage := "Использование: /admin grant &lt;user_id&gt; &lt;" + strings.Join(roles, "|") + "&gt;"
+
+	args, _ := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), "grant")
+	fields := strings.Fields(args)
+	switch len(fields) {
+	case 0:
+		return h.list(ctx, msg.Chat.ID, usage)
+	case 2:
+	default:
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+	role, err := auth.ParseRole(fields[1])
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, usage)
+	}
+
+	prev, err := h.auth.Role(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := h.auth.Grant(ctx, userID, role); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("user:%d", userID),
+		map[string]any{"role": prev}, map[string]any{"role": role})
+
+	h.logger.Info("Role granted",
+		zap.Int64("user_id", userID),
+		zap.Stringer("role", role),
+		zap.Int64("admin_id", msg.From.ID))
+
+	text := fmt.Sprintf("Пользователь %d: %s → %s", userID, prev, role)
+	// config IDs win over the stored role
+	if effective, err := h.auth.Role(ctx, userID); err == nil && effective != role {
+		text += fmt.Sprintf("\nДействует %s: пользователь указан в ADMIN_IDS или PRODUCTION_IDS", effective)
+	}
+	return reply(h.botAPI, msg.Chat.ID, text)
+}
+
+func (h *RoleGrantHandler) list(ctx context.Context, chatID int64, usage string) error {
+	staff, err := h.storage.ListStaff(ctx)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Сотрудники</b>\n")
+	if len(staff) == 0 {
+		text.WriteString("Ролей не выдано\n")
+	}
+	for _, member := range staff {
+		fmt.Fprintf(&text, "%d — %s\n", member.UserID, member.Role)
+	}
+	text.WriteString("\n" + usage)
+	return reply(h.botAPI, chatID, text.String())
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/routing"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// RoutingRulesHandler manages order routing rules:
+//
+//	/routes
+//	/addroute <name> <field> <op> <value> <chat_id> [assignee_id] [priority]
+//	/delroute <id>
+//
+// e.g. "/addroute big price >= 50000 -100123456 42" sends orders over 50k ₽
+// to the senior manager chat and assigns them to user 42.
+type RoutingRulesHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewRoutingRulesHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *RoutingRulesHandler {
+	return &RoutingRulesHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *RoutingRulesHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+
+	switch msg.Command() {
+	case "routes":
+		return h.list(ctx, msg.Chat.ID)
+
+	case "delroute":
+		if len(args) != 1 {
+			return reply(h.botAPI, msg.Chat.ID, "Использование: /delroute <id>")
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return reply(h.botAPI, msg.Chat.ID, "ID должен быть числом")
+		}
+		if err := h.storage.DeactivateRoutingRule(ctx, id); err != nil {
+			return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err))
+		}
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Правило #%d отключено", id))
+	}
+
+	if len(args) < 5 {
+		return reply(h.botAPI, msg.Chat.ID,
+			"Использование: /addroute <name> <field> <op> <value> <chat_id> [assignee_id] [priority]\n"+
+				"Поля: price, area_dm2, product, rush, quantity")
+	}
+
+	chatID, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, "chat_id должен быть числом")
+	}
+
+	rule := postgres.RoutingRule{
+		Name:         args[0],
+		Field:        args[1],
+		Operator:     args[2],
+		Value:        args[3],
+		TargetChatID: chatID,
+		Priority:     100,
+	}
+	if len(args) > 5 {
+		assignee, err := strconv.ParseInt(args[5], 10, 64)
+		if err != nil {
+			return reply(h.botAPI, msg.Chat.ID, "assignee_id должен быть числом")
+		}
+		rule.AssigneeID = &assignee
+	}
+	if len(args) > 6 {
+		if rule.Priority, err = strconv.Atoi(args[6]); err != nil {
+			return reply(h.botAPI, msg.Chat.ID, "priority должен быть числом")
+		}
+	}
+
+	if err := routing.ValidateRule(rule); err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неверное правило: %v", err))
+	}
+
+	id, err := h.storage.CreateRoutingRule(ctx, rule)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Routing rule created",
+		zap.Int64("rule_id", id),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Правило #%d создано", id))
+}
+
+func (h *RoutingRulesHandler) list(ctx context.Context, chatID int64) error {
+	rules, err := h.storage.GetRoutingRules(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return reply(h.botAPI, chatID, "Правил нет, все заказы идут в общий чат")
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Правила маршрутизации</b>\n\n")
+	for _, r := range rules {
+		fmt.Fprintf(&text, "#%d [%d] %s: %s %s %s → %d",
+			r.ID, r.Priority, r.Name, r.Field, r.Operator, r.Value, r.TargetChatID)
+		if r.AssigneeID != nil {
+			fmt.Fprintf(&text, " (исп. %d)", *r.AssigneeID)
+		}
+		text.WriteString("\n")
+	}
+
+	return reply(h.botAPI, chatID, text.String())
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/charts"
+	"s1ntez/internal/config"
+	"s1ntez/internal/stats"
+	"s1ntez/internal/storage/postgres"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// StatsHandler serves /stats: summary numbers plus revenue charts
+type StatsHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	stats   *stats.Service
+	cfg     *config.Config
+}
+
+func NewStatsHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, statsService *stats.Service, cfg *config.Config) *StatsHandler {
+	return &StatsHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		stats:   statsService,
+		cfg:     cfg,
+	}
+}
+
+func (h *StatsHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	summary, err := h.stats.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ratings, err := h.storage.GetReviewSummary(ctx, now.AddDate(0, 0, -30))
+	if err != nil {
+		return err
+	}
+
+	if err := reply(h.botAPI, msg.Chat.ID, formatStats(summary, ratings)); err != nil {
+		return err
+	}
+
+	daily, err := h.storage.GetRevenueSeries(ctx, postgres.BucketDay, now.AddDate(0, 0, -29))
+	if err != nil {
+		return err
+	}
+	weekly, err := h.storage.GetRevenueSeries(ctx, postgres.BucketWeek, now.AddDate(0, 0, -7*11))
+	if err != nil {
+		return err
+	}
+
+	var media []interface{}
+	for _, c := range []struct {
+		title, format string
+		points        []postgres.RevenuePoint
+	}{
+		{"Выручка по дням (30 дней)", "02.01", daily},
+		{"Выручка по неделям (12 недель)", "02.01", weekly},
+	} {
+		png, err := charts.RevenueBars(c.title, c.format, c.points)
+		if err != nil {
+			h.logger.Warn("Failed to render chart", zap.String("chart", c.title), zap.Error(err))
+			continue
+		}
+		photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: "chart.png", Bytes: png})
+		photo.Caption = c.title
+		media = append(media, photo)
+	}
+
+	if len(media) == 0 {
+		return nil
+	}
+
+	_, err = h.botAPI.SendMediaGroup(tgbotapi.NewMediaGroup(msg.Chat.ID, media))
+	return err
+}
+
+func formatStats(stats *postgres.OrderStatistics, ratings *postgres.ReviewSummary) string {
+	var text strings.Builder
+	text.WriteString("<b>📊 Статистика заказов</b>\n\n")
+	fmt.Fprintf(&text, "Сегодня: %d / %.2f ₽\n", stats.TodayOrders, stats.TodayRevenue)
+	fmt.Fprintf(&text, "7 дней: %d / %.2f ₽\n", stats.WeekOrders, stats.WeekRevenue)
+	fmt.Fprintf(&text, "30 дней: %d / %.2f ₽\n", stats.MonthOrders, stats.MonthRevenue)
+	fmt.Fprintf(&text, "Всего: %d / %.2f ₽\n", stats.TotalOrders, stats.TotalRevenue)
+	fmt.Fprintf(&text, "Срочные: %d / %.2f ₽ (наценка %.2f ₽)\n", stats.RushOrders, stats.RushRevenue, stats.RushSurcharge)
+
+	text.WriteString("\n<b>Оценки клиентов</b>\n")
+	if ratings.Count == 0 {
+		text.WriteString("Оценок пока нет\n")
+	} else {
+		fmt.Fprintf(&text, "Средняя: %.2f ⭐ (%d)\n", ratings.Average, ratings.Count)
+		fmt.Fprintf(&text, "30 дней: %.2f ⭐ (%d)\n", ratings.PeriodAverage, ratings.PeriodCount)
+	}
+
+	statuses := make([]string, 0, len(stats.StatusCounts))
+	for status := range stats.StatusCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	text.WriteString("\n<b>По статусам</b>\n")
+	for _, status := range statuses {
+		fmt.Fprintf(&text, "%s: %d\n", status, stats.StatusCounts[status])
+	}
+
+	return text.String()
+}
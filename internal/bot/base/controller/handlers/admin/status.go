@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxBulkStatus caps the orders of one /setstatus
+const maxBulkStatus = 100
+
+// OrderStatusHandler serves /setstatus <order_id> <status>. Several IDs,
+// separated by commas or spaces, are moved in one update.
+type OrderStatusHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	orders  *orders.Service
+	cfg     *config.Config
+}
+
+func NewOrderStatusHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, orderService *orders.Service, cfg *config.Config) *OrderStatusHandler {
+	return &OrderStatusHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		orders:  orderService,
+		cfg:     cfg,
+	}
+}
+
+func (h *OrderStatusHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 {
+		return reply(h.botAPI, msg.Chat.ID, "Использование: /setstatus <order_id>[,<order_id>...] <new|processing|completed|cancelled>")
+	}
+
+	orderIDs, err := parseOrderIDs(args[:len(args)-1])
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, "ID заказа должен быть числом")
+	}
+	if len(orderIDs) > maxBulkStatus {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Не больше %d заказов за раз", maxBulkStatus))
+	}
+	status, err := postgres.ParseOrderStatus(args[len(args)-1])
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Неизвестный статус: %s", html.EscapeString(args[len(args)-1])))
+	}
+
+	if len(orderIDs) > 1 {
+		return h.bulk(ctx, msg, orderIDs, status)
+	}
+	orderID := orderIDs[0]
+
+	prev, err := h.orders.ChangeStatus(ctx, orderID, status)
+	switch {
+	case errors.Is(err, postgres.ErrInvalidOrderStatus):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Статус %s нельзя установить вручную", status))
+	case errors.Is(err, errs.ErrOrderNotFound):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	case errors.Is(err, orders.ErrPrepaymentDue):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d ждёт предоплату: его можно только отменить", orderID))
+	case errors.Is(err, orders.ErrQuotePending):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Цена заказа #%d не подтверждена: /quote %d, или отмените заказ", orderID, orderID))
+	case err != nil:
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+		map[string]any{"status": prev.Status}, map[string]any{"status": status})
+
+	h.logger.Info("Order status changed by admin",
+		zap.Int64("order_id", orderID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d: %s → %s", orderID, prev.Status, status))
+}
+
+// bulk moves several orders at once and reports the ones left as they were
+func (h *OrderStatusHandler) bulk(ctx context.Context, msg *tgbotapi.Message, orderIDs []int64, status postgres.OrderStatus) error {
+	changes, err := h.orders.ChangeStatuses(ctx, orderIDs, status)
+	if errors.Is(err, postgres.ErrInvalidOrderStatus) {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Статус %s нельзя установить вручную", status))
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[int64]bool, len(changes))
+	for _, change := range changes {
+		changed[change.OrderID] = true
+		audit.Record(ctx, fmt.Sprintf("order:%d", change.OrderID),
+			map[string]any{"status": change.PrevStatus}, map[string]any{"status": status})
+	}
+
+	h.logger.Info("Order statuses changed by admin",
+		zap.Int("orders", len(changes)),
+		zap.Stringer("status", status),
+		zap.Int64("admin_id", msg.From.ID))
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s: %d из %d заказов", status, len(changes), len(orderIDs))
+	var skipped []string
+	for _, id := range orderIDs {
+		if !changed[id] {
+			skipped = append(skipped, fmt.Sprintf("#%d", id))
+		}
+	}
+	if len(skipped) > 0 {
+		text.WriteString("\nНе найдены или уже в этом статусе: " + strings.Join(skipped, ", "))
+	}
+	return reply(h.botAPI, msg.Chat.ID, text.String())
+}
+
+// parseOrderIDs reads IDs given as "12 13", "12,13" or a mix, dropping repeats
+func parseOrderIDs(args []string) ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, arg := range args {
+		for _, field := range strings.Split(arg, ",") {
+			if field == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, strconv.ErrSyntax
+	}
+	return ids, nil
+}
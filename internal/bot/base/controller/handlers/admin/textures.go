@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// TextureContentHandler lets admins manage texture galleries and descriptions:
+//
+//	/texturedesc <texture_id> <description> || <care instructions>
+//	/texturephoto <texture_id> (as a reply to a photo)
+//	/textureclear <texture_id>
+//	/textureprice <texture_id> <price per dm²> [currency]
+//	/texturestock <texture_id> on|off
+//	/texturearea <texture_id> <dm²|off>
+//	/textureattrs <texture_id> <name>: <value>; ... (none clears them)
+type TextureContentHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewTextureContentHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *TextureContentHandler {
+	return &TextureContentHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *TextureContentHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !isAdmin(ctx) {
+		return nil
+	}
+
+	textureID, rest, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	if textureID == "" {
+		return reply(h.botAPI, msg.Chat.ID, "Укажите ID текстуры")
+	}
+
+	// Snapshots for the audit log; a failed load only leaves a side empty
+	before, loadErr := h.storage.GetTextureDetails(ctx, textureID)
+	if loadErr != nil {
+		h.logger.Warn("Failed to load texture before update",
+			zap.String("texture_id", textureID),
+			zap.Error(loadErr))
+	}
+
+	var err error
+	switch msg.Command() {
+	case "texturedesc":
+		description, care, _ := strings.Cut(rest, "||")
+		err = h.storage.UpdateTextureContent(ctx, textureID,
+			strings.TrimSpace(description), strings.TrimSpace(care))
+
+	case "texturephoto":
+		if msg.ReplyToMessage == nil || len(msg.ReplyToMessage.Photo) == 0 {
+			return reply(h.botAPI, msg.Chat.ID, "Отправьте команду ответом на фото")
+		}
+		// The last size is the largest one
+		photo := msg.ReplyToMessage.Photo[len(msg.ReplyToMessage.Photo)-1]
+		err = h.storage.AddTexturePhoto(ctx, textureID, photo.FileID, msg.ReplyToMessage.Caption)
+
+	case "textureclear":
+		err = h.storage.ClearTexturePhotos(ctx, textureID)
+
+	case "textureprice":
+		// the currency is optional: imported leather is priced in the supplier's
+		amount, code, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		price, parseErr := strconv.ParseFloat(strings.ReplaceAll(amount, ",", "."), 64)
+		currency := money.Currency(strings.ToUpper(strings.TrimSpace(code)))
+		if parseErr != nil || (currency != "" && !currency.Valid()) {
+			return reply(h.botAPI, msg.Chat.ID, "Использование: /textureprice <id> 25.50 [EUR]")
+		}
+		_, err = h.storage.UpdateTexturePrice(ctx, textureID, price, currency)
+
+	case "texturestock":
+		_, err = h.storage.SetTextureInStock(ctx, textureID, strings.TrimSpace(rest) == "on")
+
+	case "texturearea":
+		var stock *float64
+		if raw := strings.TrimSpace(rest); raw != "off" {
+			v, parseErr := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+			if parseErr != nil || v < 0 {
+				return reply(h.botAPI, msg.Chat.ID, "Использование: /texturearea <id> 1200.5 | off")
+			}
+			stock = &v
+		}
+		err = h.storage.SetTextureStock(ctx, textureID, stock)
+
+	case "textureattrs":
+		attributes, ok := parseAttributes(rest)
+		if !ok {
+			return reply(h.botAPI, msg.Chat.ID, "Использование: /textureattrs <id> Толщина: 1.2 мм; Основа: хлопок")
+		}
+		err = h.storage.UpdateTextureAttributes(ctx, textureID, attributes)
+	}
+
+	switch {
+	case errors.Is(err, errs.ErrTextureNotFound):
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Текстура %s не найдена", html.EscapeString(textureID)))
+	case errors.Is(err, errs.ErrInvalidPrice):
+		return reply(h.botAPI, msg.Chat.ID, "Цена должна быть больше нуля")
+	case err != nil:
+		h.logger.Error("Failed to update texture content",
+			zap.String("texture_id", textureID),
+			zap.String("command", msg.Command()),
+			zap.Error(err))
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err))
+	}
+
+	after, loadErr := h.storage.GetTextureDetails(ctx, textureID)
+	if loadErr != nil {
+		h.logger.Warn("Failed to load texture after update",
+			zap.String("texture_id", textureID),
+			zap.Error(loadErr))
+	}
+	audit.Record(ctx, "texture:"+textureID, before, after)
+
+	return reply(h.botAPI, msg.Chat.ID, "Готово ✅")
+}
+
+// parseAttributes reads "name: value; name: value"
+func parseAttributes(raw string) (postgres.TextureAttributes, bool) {
+	attributes := make(postgres.TextureAttributes)
+	for _, pair := range strings.Split(raw, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, false
+		}
+		attributes[name] = value
+	}
+	return attributes, true
+}
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// TranscriptHandler serves /transcript <order_id>: sends the conversation
+// that led to an order as a text file, for customer disputes
+type TranscriptHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewTranscriptHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *TranscriptHandler {
+	return &TranscriptHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *TranscriptHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Manager) {
+		return nil
+	}
+
+	orderID, err := strconv.ParseInt(strings.TrimSpace(msg.CommandArguments()), 10, 64)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, "Использование: /transcript <order_id>")
+	}
+
+	order, err := h.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	}
+
+	messages, err := h.storage.GetOrderTranscript(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return reply(h.botAPI, msg.Chat.ID, fmt.Sprintf("Для заказа #%d переписка не сохранена", orderID))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Заказ #%d\nПользователь: %d\nСоздан: %s\nРазмер: %d × %d см\nЦена: %.2f ₽\n\n",
+		order.ID, order.UserID, order.CreatedAt.Format("2006-01-02 15:04:05"),
+		order.WidthCM, order.HeightCM, order.Price)
+
+	for _, m := range messages {
+		who := "Клиент"
+		if m.Direction == postgres.DirectionOut {
+			who = "Бот"
+		}
+		fmt.Fprintf(&text, "[%s] %s (%s): %s\n",
+			m.CreatedAt.Format("2006-01-02 15:04:05"), who, m.Step, m.Text)
+	}
+
+	h.logger.Info("Transcript exported",
+		zap.Int64("order_id", orderID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("order_%d_transcript.txt", orderID),
+		Bytes: []byte(text.String()),
+	})
+	_, err = h.botAPI.Send(doc)
+	return err
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/config"
+	"s1ntez/internal/fxrates"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/units"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+var errBadCalcArgs = errors.New("bad calc arguments")
+
+// CalcHandler serves /calc <width>x<height> <texture>: an instant quote
+// without starting the order dialog
+type CalcHandler struct {
+	logger     *zap.Logger
+	botAPI     *tgbotapi.BotAPI
+	storage    *postgres.PostgresStorage
+	calculator *pricing.Calculator
+	rates      *fxrates.Service
+	cfg        *config.Config
+}
+
+func NewCalcHandler(
+	logger *zap.Logger,
+	botAPI *tgbotapi.BotAPI,
+	storage *postgres.PostgresStorage,
+	calculator *pricing.Calculator,
+	rates *fxrates.Service,
+	cfg *config.Config,
+) *CalcHandler {
+	return &CalcHandler{
+		logger:     logger,
+		botAPI:     botAPI,
+		storage:    storage,
+		calculator: calculator,
+		rates:      rates,
+		cfg:        cfg,
+	}
+}
+
+func (h *CalcHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	chatID := update.Message.Chat.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, update.Message.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, update.Message.From.ID)
+
+	width, height, textureName, err := parseCalcArgs(update.Message.CommandArguments(), unit)
+	if err != nil {
+		return h.reply(chatID, i18n.T(locale, "calc.usage"))
+	}
+
+	if width > h.cfg.MaxDimensions.Width || height > h.cfg.MaxDimensions.Height {
+		return h.reply(chatID, i18n.T(locale, "calc.too_large",
+			i18n.Size(locale, unit, h.cfg.MaxDimensions.Width, h.cfg.MaxDimensions.Height)))
+	}
+
+	texture, err := h.storage.GetTextureByName(ctx, textureName)
+	if errors.Is(err, errs.ErrTextureNotFound) {
+		return h.reply(chatID, i18n.T(locale, "calc.unknown_texture", textureName))
+	}
+	if err != nil {
+		return err
+	}
+
+	pricePerDM2, _, err := h.rates.Convert(ctx, texture.PricePerDM2, texture.PriceCurrency)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b := h.calculator.Calculate(width, height, pricePerDM2, pricingOptions(ctx, h.storage, h.logger, now), now)
+
+	return h.reply(chatID, quoteText(locale, unit, width, height, texture.Name, b))
+}
+
+func (h *CalcHandler) reply(chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := h.botAPI.Send(msg)
+	return err
+}
+
+// parseCalcArgs parses "30x40 Натуральная кожа" into its parts, the size
+// in centimetres. Both latin "x" and cyrillic "х" / "×" separators are
+// accepted, and a unit like "300x400mm"; a bare size is in unit.
+func parseCalcArgs(args string, unit units.Unit) (int, int, string, error) {
+	size, texture, ok := strings.Cut(strings.TrimSpace(args), " ")
+	texture = strings.TrimSpace(texture)
+	if !ok || texture == "" {
+		return 0, 0, "", errBadCalcArgs
+	}
+
+	width, height, err := parseSize(size, unit)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	return width, height, texture, nil
+}
+
+// parseSize parses "30x40" or "0.3x0.4m" into centimetres
+func parseSize(size string, unit units.Unit) (int, int, error) {
+	width, height, ok := units.ParseSize(size, unit)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: size %q", errBadCalcArgs, size)
+	}
+	return width, height, nil
+}
+
+// pricingOptions applies the active pricing rule, falling back to the
+// rates from the config when there is none
+func pricingOptions(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, now time.Time) pricing.Options {
+	var opts pricing.Options
+	if rule, err := storage.GetActivePricingRule(ctx, now); err == nil {
+		opts.Rates = &pricing.Rates{
+			CommissionRate: rule.CommissionRate,
+			TaxRate:        rule.TaxRate,
+			TaxRegime:      rule.TaxRegime,
+		}
+	} else {
+		logger.Warn("Falling back to config pricing rates", zap.Error(err))
+	}
+	return opts
+}
+
+func quoteText(locale i18n.Locale, unit units.Unit, width, height int, textureName string, b pricing.Breakdown) string {
+	return i18n.T(locale, "calc.quote",
+		i18n.Size(locale, unit, width, height), html.EscapeString(textureName), b.AreaDM2,
+		b.LeatherCost, b.ProcessCost, b.Commission, b.Tax, b.Price,
+	)
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/imageproxy"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxCatalogCards keeps /catalog under Telegram's per-chat flood limit
+const maxCatalogCards = 20
+
+// CatalogHandler serves /catalog: a card per material in stock with its
+// preview, price and a button to see the gallery
+type CatalogHandler struct {
+	logger   *zap.Logger
+	botAPI   *tgbotapi.BotAPI
+	storage  *postgres.PostgresStorage
+	previews *imageproxy.Proxy
+	cfg      *config.Config
+}
+
+func NewCatalogHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, previews *imageproxy.Proxy, cfg *config.Config) *CatalogHandler {
+	return &CatalogHandler{
+		logger:   logger,
+		botAPI:   botAPI,
+		storage:  storage,
+		previews: previews,
+		cfg:      cfg,
+	}
+}
+
+func (h *CatalogHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	chatID := update.Message.Chat.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, update.Message.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	textures, err := h.storage.GetAvailableTextures(ctx)
+	if err != nil {
+		return err
+	}
+	if len(textures) == 0 {
+		_, err := h.botAPI.Send(tgbotapi.NewMessage(chatID, i18n.T(locale, "catalog.empty")))
+		return err
+	}
+	slices.SortFunc(textures, func(a, b postgres.Texture) int {
+		return cmp.Or(cmp.Compare(a.ServiceType, b.ServiceType), cmp.Compare(a.Name, b.Name))
+	})
+
+	for _, texture := range textures[:min(len(textures), maxCatalogCards)] {
+		if err := h.card(ctx, chatID, locale, texture); err != nil {
+			return err
+		}
+	}
+	if len(textures) > maxCatalogCards {
+		_, err := h.botAPI.Send(tgbotapi.NewMessage(chatID, i18n.T(locale, "catalog.more", len(textures)-maxCatalogCards)))
+		return err
+	}
+	return nil
+}
+
+// card sends the preview of the texture with its name and price as the
+// caption, or the caption alone when there is no preview to send. A
+// preview file ID Telegram no longer accepts is forgotten and the preview
+// uploaded again.
+func (h *CatalogHandler) card(ctx context.Context, chatID int64, locale i18n.Locale, texture postgres.Texture) error {
+	caption := fmt.Sprintf("%s\n%s", texture.Name,
+		i18n.T(locale, "texture.price", texture.PricePerDM2, cmp.Or(texture.PriceCurrency, h.cfg.Currency).Symbol()))
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(TextureInfoButton(locale, texture.ID)))
+
+	preview, err := h.previews.Photo(ctx, texture.ImageURL)
+	if err == nil {
+		msg, sendErr := h.sendPhoto(chatID, preview, caption, markup)
+		if _, cached := preview.(tgbotapi.FileID); sendErr != nil && cached {
+			h.logger.Warn("Cached texture preview refused, uploading it again",
+				zap.String("texture_id", texture.ID),
+				zap.Error(sendErr))
+			h.previews.Forget(ctx, texture.ImageURL)
+			if preview, err = h.previews.Photo(ctx, texture.ImageURL); err == nil {
+				msg, sendErr = h.sendPhoto(chatID, preview, caption, markup)
+			}
+		}
+		if err == nil && sendErr == nil {
+			if _, uploaded := preview.(tgbotapi.FileBytes); uploaded {
+				h.previews.Remember(ctx, texture.ImageURL, msg)
+			}
+			return nil
+		}
+		err = cmp.Or(err, sendErr)
+	}
+	if !errors.Is(err, imageproxy.ErrNoImage) {
+		// The card is still worth sending without the preview
+		h.logger.Warn("Failed to send texture preview",
+			zap.String("texture_id", texture.ID),
+			zap.Error(err))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, caption)
+	msg.ReplyMarkup = markup
+	_, err = h.botAPI.Send(msg)
+	return err
+}
+
+func (h *CatalogHandler) sendPhoto(chatID int64, preview tgbotapi.RequestFileData, caption string, markup tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+	photo := tgbotapi.NewPhoto(chatID, preview)
+	photo.Caption = caption
+	photo.ReplyMarkup = markup
+	return h.botAPI.Send(photo)
+}
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/templates"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	compareCallbackPrefix = "compare"
+	// maxCompared keeps the table readable on a phone
+	maxCompared = 3
+	// compareCellWidth truncates long names and values in the table
+	compareCellWidth = 16
+	selectedMark     = "✅ "
+)
+
+// CompareHandler serves /compare: the customer ticks two or three
+// materials and gets their prices, stock and characteristics side by side.
+// The selection lives in the buttons of the message itself.
+type CompareHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	cfg     *config.Config
+}
+
+func NewCompareHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, cfg *config.Config) *CompareHandler {
+	return &CompareHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+func (h *CompareHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	chatID := update.Message.Chat.ID
+	locale := h.locale(ctx, update.Message.From.ID)
+
+	textures, err := h.storage.GetAvailableTextures(ctx)
+	if err != nil {
+		return err
+	}
+	if len(textures) < 2 {
+		_, err := h.botAPI.Send(tgbotapi.NewMessage(chatID, i18n.T(locale, "compare.empty")))
+		return err
+	}
+	slices.SortFunc(textures, func(a, b postgres.Texture) int { return cmp.Compare(a.Name, b.Name) })
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(textures)+1)
+	for _, t := range textures {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.Name, compareCallbackPrefix+":t:"+t.ID),
+			TextureInfoButton(locale, t.ID)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "compare.button"), compareCallbackPrefix+":go")))
+
+	msg := tgbotapi.NewMessage(chatID, i18n.T(locale, "compare.choose"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err = h.botAPI.Send(msg)
+	return err
+}
+
+func (h *CompareHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil || query.Message.ReplyMarkup == nil {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return nil
+	}
+	locale := h.locale(ctx, query.From.ID)
+	markup := *query.Message.ReplyMarkup
+
+	if query.Data == compareCallbackPrefix+":go" {
+		selected := selectedTextures(markup)
+		if len(selected) < 2 {
+			_, _ = h.botAPI.Request(tgbotapi.NewCallbackWithAlert(query.ID, i18n.T(locale, "compare.too_few")))
+			return nil
+		}
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return h.compare(ctx, query.Message.Chat.ID, locale, selected)
+	}
+
+	textureID, ok := strings.CutPrefix(query.Data, compareCallbackPrefix+":t:")
+	if !ok {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return nil
+	}
+	if !toggleTexture(markup, textureID) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallbackWithAlert(query.ID, i18n.T(locale, "compare.too_many", maxCompared)))
+		return nil
+	}
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	_, err := h.botAPI.Request(tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, markup))
+	return err
+}
+
+// selectedTextures returns the IDs of the ticked buttons
+func selectedTextures(markup tgbotapi.InlineKeyboardMarkup) []string {
+	var ids []string
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == nil || !strings.HasPrefix(button.Text, selectedMark) {
+				continue
+			}
+			if id, ok := strings.CutPrefix(*button.CallbackData, compareCallbackPrefix+":t:"); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// toggleTexture ticks or unticks the button of the texture in place. It
+// refuses to tick more than maxCompared.
+func toggleTexture(markup tgbotapi.InlineKeyboardMarkup, textureID string) bool {
+	selected := len(selectedTextures(markup))
+	for _, row := range markup.InlineKeyboard {
+		for i := range row {
+			button := &row[i]
+			if button.CallbackData == nil || *button.CallbackData != compareCallbackPrefix+":t:"+textureID {
+				continue
+			}
+			if name, ok := strings.CutPrefix(button.Text, selectedMark); ok {
+				button.Text = name
+				return true
+			}
+			if selected >= maxCompared {
+				return false
+			}
+			button.Text = selectedMark + button.Text
+			return true
+		}
+	}
+	return true
+}
+
+func (h *CompareHandler) compare(ctx context.Context, chatID int64, locale i18n.Locale, ids []string) error {
+	textures := make([]*postgres.TextureDetails, 0, len(ids))
+	for _, id := range ids {
+		details, err := h.storage.GetTextureDetails(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to load texture %s: %w", id, err)
+		}
+		textures = append(textures, details)
+	}
+
+	msg, err := templates.Render(templates.TextureComparison{
+		Locale: locale,
+		Table:  comparisonTable(locale, textures, h.cfg),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = h.botAPI.Send(msg.To(chatID))
+	return err
+}
+
+// comparisonTable lays the textures out in columns: name, price, stock and
+// every characteristic any of them has, in alphabetical order
+func comparisonTable(locale i18n.Locale, textures []*postgres.TextureDetails, cfg *config.Config) string {
+	header := []string{""}
+	price := []string{i18n.T(locale, "compare.price")}
+	stock := []string{i18n.T(locale, "compare.stock")}
+	var names []string
+	for _, t := range textures {
+		header = append(header, t.Name)
+		price = append(price, fmt.Sprintf("%.2f %s", t.PricePerDM2, cmp.Or(t.PriceCurrency, cfg.Currency).Symbol()))
+		stock = append(stock, stockText(locale, t))
+		for name := range t.Attributes {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	rows := [][]string{header, price, stock}
+	for _, name := range names {
+		row := []string{name}
+		for _, t := range textures {
+			row = append(row, cmp.Or(t.Attributes[name], "—"))
+		}
+		rows = append(rows, row)
+	}
+	return formatTable(rows)
+}
+
+func stockText(locale i18n.Locale, t *postgres.TextureDetails) string {
+	switch {
+	case !t.InStock:
+		return i18n.T(locale, "compare.out_of_stock")
+	case t.StockDM2 != nil:
+		return i18n.T(locale, "compare.stock_left", *t.StockDM2)
+	}
+	return i18n.T(locale, "compare.in_stock")
+}
+
+// formatTable pads the cells into aligned columns; the result goes into
+// a <pre> block, so it is HTML-escaped by the template
+func formatTable(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = truncate(cell, compareCellWidth)
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString(" │ ")
+			}
+			line.WriteString(cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		lines[r] = strings.TrimRight(line.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func (h *CompareHandler) locale(ctx context.Context, userID int64) i18n.Locale {
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	return locale
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/config"
+	"s1ntez/internal/fxrates"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// inlineQuoteLimit keeps the result list short enough to scan
+	inlineQuoteLimit = 10
+	// inlineCacheTime lets Telegram answer a repeated query itself, in
+	// seconds. Answers are in the user's language and units, so Telegram
+	// keeps them per user.
+	inlineCacheTime = 300
+	// inlineStartParameter opens the bot from the "how to use" button
+	inlineStartParameter = "quote"
+)
+
+// InlineQuoteHandler answers inline queries such as "@adtimebot 30x40 nappa"
+// with price quotes the user can send into any chat. The texture is matched
+// by the beginning of its name or of any word in it; without one every
+// texture is offered.
+type InlineQuoteHandler struct {
+	logger     *zap.Logger
+	botAPI     *tgbotapi.BotAPI
+	storage    *postgres.PostgresStorage
+	calculator *pricing.Calculator
+	rates      *fxrates.Service
+	cfg        *config.Config
+}
+
+func NewInlineQuoteHandler(
+	logger *zap.Logger,
+	botAPI *tgbotapi.BotAPI,
+	storage *postgres.PostgresStorage,
+	calculator *pricing.Calculator,
+	rates *fxrates.Service,
+	cfg *config.Config,
+) *InlineQuoteHandler {
+	return &InlineQuoteHandler{
+		logger:     logger,
+		botAPI:     botAPI,
+		storage:    storage,
+		calculator: calculator,
+		rates:      rates,
+		cfg:        cfg,
+	}
+}
+
+func (h *InlineQuoteHandler) HandleInline(ctx context.Context, query *tgbotapi.InlineQuery) error {
+	locale, err := h.storage.GetUserLocale(ctx, query.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+		Results:       []any{},
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, query.From.ID)
+	size, search, _ := strings.Cut(strings.TrimSpace(query.Query), " ")
+	width, height, err := parseSize(size, unit)
+	switch {
+	case err != nil:
+		answer.SwitchPMText = i18n.T(locale, "inline.usage")
+		answer.SwitchPMParameter = inlineStartParameter
+	case width > h.cfg.MaxDimensions.Width || height > h.cfg.MaxDimensions.Height:
+		answer.SwitchPMText = i18n.T(locale, "calc.too_large",
+			i18n.Size(locale, unit, h.cfg.MaxDimensions.Width, h.cfg.MaxDimensions.Height))
+		answer.SwitchPMParameter = inlineStartParameter
+	default:
+		textures, err := h.storage.GetCachedMaterials(ctx, postgres.ServiceLeather)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		opts := pricingOptions(ctx, h.storage, h.logger, now)
+		for _, texture := range matchTextures(textures, search) {
+			pricePerDM2, _, err := h.rates.Convert(ctx, texture.PricePerDM2, texture.PriceCurrency)
+			if err != nil {
+				h.logger.Warn("Failed to convert texture price, leaving it out",
+					zap.String("texture_id", texture.ID),
+					zap.Error(err))
+				continue
+			}
+			b := h.calculator.Calculate(width, height, pricePerDM2, opts, now)
+			article := tgbotapi.NewInlineQueryResultArticleHTML(
+				fmt.Sprintf("%dx%d:%s", width, height, texture.ID),
+				i18n.T(locale, "inline.title", texture.Name, b.Price),
+				quoteText(locale, unit, width, height, texture.Name, b))
+			article.Description = i18n.T(locale, "inline.description", i18n.Size(locale, unit, width, height), b.AreaDM2)
+			answer.Results = append(answer.Results, article)
+		}
+		if len(answer.Results) == 0 {
+			answer.SwitchPMText = i18n.T(locale, "calc.unknown_texture", search)
+			answer.SwitchPMParameter = inlineStartParameter
+		}
+	}
+
+	_, err = h.botAPI.Request(answer)
+	return err
+}
+
+// matchTextures keeps the textures whose name, or a word of it, starts
+// with the search, exact matches first
+func matchTextures(textures []postgres.Texture, search string) []postgres.Texture {
+	search = strings.ToLower(strings.TrimSpace(search))
+
+	var exact, prefix []postgres.Texture
+	for _, texture := range textures {
+		name := strings.ToLower(texture.Name)
+		switch {
+		case search == "":
+			prefix = append(prefix, texture)
+		case name == search:
+			exact = append(exact, texture)
+		case strings.HasPrefix(name, search) || strings.Contains(name, " "+search):
+			prefix = append(prefix, texture)
+		}
+	}
+
+	matched := append(exact, prefix...)
+	if len(matched) > inlineQuoteLimit {
+		matched = matched[:inlineQuoteLimit]
+	}
+	return matched
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const languageCallbackPrefix = "lang"
+
+// LanguageHandler serves /language and the locale picker callbacks
+type LanguageHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+}
+
+func NewLanguageHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage) *LanguageHandler {
+	return &LanguageHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+	}
+}
+
+func (h *LanguageHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	chatID := update.Message.Chat.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, update.Message.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, l := range i18n.Supported() {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			l.Name(),
+			fmt.Sprintf("%s:%s", languageCallbackPrefix, l),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, i18n.T(locale, "language.choose"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+
+	_, err = h.botAPI.Send(msg)
+	return err
+}
+
+func (h *LanguageHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	code := strings.TrimPrefix(query.Data, languageCallbackPrefix+":")
+	locale := i18n.Parse(code)
+
+	if err := h.storage.SetUserLocale(ctx, query.From.ID, locale); err != nil {
+		return fmt.Errorf("failed to save locale: %w", err)
+	}
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	edit := tgbotapi.NewEditMessageText(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		i18n.T(locale, "language.changed", locale.Name()),
+	)
+	_, err := h.botAPI.Send(edit)
+	return err
+}
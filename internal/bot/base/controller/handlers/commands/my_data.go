@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// MyDataHandler serves /mydata: an archive with everything the bot keeps
+// about the user, as 152-FZ and GDPR require on request. data.json holds
+// the records, orders.xlsx the same orders as /myorders.
+type MyDataHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+	redis   *redis.Storage
+}
+
+func NewMyDataHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, redisStorage *redis.Storage) *MyDataHandler {
+	return &MyDataHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+		redis:   redisStorage,
+	}
+}
+
+// userDataArchive is data.json: the stored records plus the unfinished
+// dialog, which lives in Redis
+type userDataArchive struct {
+	ExportedAt  time.Time        `json:"exported_at"`
+	UserID      int64            `json:"user_id"`
+	DialogState *redis.UserState `json:"dialog_state"`
+	*postgres.UserData
+}
+
+func (h *MyDataHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	userID := msg.From.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	switch err := h.storage.CheckRateLimit(ctx, userID, "export_my_data", 3, 24*time.Hour); {
+	case errors.Is(err, errs.ErrRateLimited):
+		_, err := h.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, "error.rate_limited")))
+		return err
+	case err != nil:
+		h.logger.Warn("Rate limit check failed", zap.Error(err))
+	}
+
+	archive, err := h.buildArchive(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("User data exported", zap.Int64("user_id", userID))
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("mydata_%d_%s.zip", userID, time.Now().Format("20060102")),
+		Bytes: archive,
+	})
+	doc.Caption = i18n.T(locale, "mydata.caption")
+	_, err = h.botAPI.Send(doc)
+	return err
+}
+
+func (h *MyDataHandler) buildArchive(ctx context.Context, userID int64) ([]byte, error) {
+	data, err := h.storage.GetUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Private chats share the ID of the user
+	state, err := h.redis.GetUserDialogState(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dialog state: %w", err)
+	}
+	if state.Step == "" {
+		state = nil
+	}
+
+	records, err := json.MarshalIndent(userDataArchive{
+		ExportedAt:  time.Now(),
+		UserID:      userID,
+		DialogState: state,
+		UserData:    data,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal user data: %w", err)
+	}
+
+	ordersPath, err := h.storage.ExportUserOrdersToExcel(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.Remove(ordersPath); err != nil {
+			h.logger.Warn("Failed to remove user export", zap.String("path", ordersPath), zap.Error(err))
+		}
+	}()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create("data.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := w.Write(records); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := addFile(zw, "orders.xlsx", ordersPath); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
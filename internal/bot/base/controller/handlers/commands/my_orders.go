@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	myOrdersCallbackPrefix = "myorders"
+
+	// recentOrders are offered as order card buttons under /myorders
+	recentOrders = 5
+	// Telegram rejects messages over 4096 characters
+	maxCardLen = 4000
+)
+
+// MyOrdersHandler serves /myorders, the order cards of the recent orders
+// and the "Download my orders" button
+type MyOrdersHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+}
+
+func NewMyOrdersHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage) *MyOrdersHandler {
+	return &MyOrdersHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+	}
+}
+
+// MyOrdersButton lets other screens offer the history download
+func MyOrdersButton(locale i18n.Locale) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(
+		i18n.T(locale, "myorders.download"),
+		myOrdersCallbackPrefix+":export",
+	)
+}
+
+func (h *MyOrdersHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	userID := update.Message.From.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	orders, err := h.storage.GetUserOrders(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user orders", zap.Error(err))
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, recentOrders+1)
+	for _, order := range orders[:min(len(orders), recentOrders)] {
+		label := i18n.T(locale, "myorders.order_button",
+			order.Code, i18n.T(locale, "status."+order.Status.String()), order.Price)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			label, fmt.Sprintf("%s:card:%d", myOrdersCallbackPrefix, order.ID))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(MyOrdersButton(locale)))
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, i18n.T(locale, "myorders.prompt"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	_, err = h.botAPI.Send(msg)
+	return err
+}
+
+func (h *MyOrdersHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	userID := query.From.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	if raw, ok := strings.CutPrefix(query.Data, myOrdersCallbackPrefix+":card:"); ok {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		orderID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return h.showCard(ctx, query.Message.Chat.ID, userID, locale, orderID)
+	}
+
+	// Building a spreadsheet is not free, don't let the button be hammered
+	switch err := h.storage.CheckRateLimit(ctx, userID, "export_my_orders", 3, time.Hour); {
+	case errors.Is(err, errs.ErrRateLimited):
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "error.rate_limited")))
+		return nil
+	case err != nil:
+		h.logger.Warn("Rate limit check failed", zap.Error(err))
+	}
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	path, err := h.storage.ExportUserOrdersToExcel(ctx, userID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(path); err != nil {
+			h.logger.Warn("Failed to remove user export", zap.String("path", path), zap.Error(err))
+		}
+	}()
+
+	doc := tgbotapi.NewDocument(query.Message.Chat.ID, tgbotapi.FilePath(path))
+	doc.Caption = i18n.T(locale, "myorders.caption")
+	_, err = h.botAPI.Send(doc)
+	return err
+}
+
+// showCard sends the customer's order with its delivery and the notes
+// left by the workshop
+func (h *MyOrdersHandler) showCard(ctx context.Context, chatID, userID int64, locale i18n.Locale, orderID int64) error {
+	order, err := h.storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) || (err == nil && order.UserID != userID) {
+		return h.send(chatID, i18n.T(locale, "myorders.not_found"), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	notes, err := h.storage.GetOrderNotes(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	text := i18n.T(locale, "myorders.card", order.Code,
+		i18n.T(locale, "status."+order.Status.String()), order.Price, order.CreatedAt.Format("02.01.2006"))
+	if order.ReadyBy != nil && order.Status != postgres.StatusCompleted && order.Status != postgres.StatusCancelled {
+		text += "\n" + i18n.T(locale, "myorders.ready_by", order.ReadyBy.Format("02.01.2006"))
+	}
+
+	delivery := order.DeliveryOrPickup()
+	method := i18n.T(locale, "delivery."+delivery.Method)
+	if delivery.Address != "" {
+		method += ", " + html.EscapeString(delivery.Address)
+	}
+	text += "\n" + i18n.T(locale, "delivery.summary", method)
+
+	if len(notes) > 0 {
+		// The newest notes are kept when the thread doesn't fit in a message
+		header := "\n\n" + i18n.T(locale, "myorders.notes")
+		size := len(text) + len(header)
+		var entries []string
+		for i := len(notes) - 1; i >= 0; i-- {
+			entry := fmt.Sprintf("\n<i>%s</i> %s", notes[i].CreatedAt.Format("02.01 15:04"), html.EscapeString(notes[i].Text))
+			if size+len(entry) > maxCardLen {
+				break
+			}
+			size += len(entry)
+			entries = append(entries, entry)
+		}
+		slices.Reverse(entries)
+		text += header + strings.Join(entries, "")
+	}
+
+	var markup any
+	if button, ok := dialog.RepeatButton(locale, order); ok {
+		markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+	}
+	return h.send(chatID, text, markup)
+}
+
+func (h *MyOrdersHandler) send(chatID int64, text string, markup any) error {
+	return dialog.Send(h.botAPI, chatID, text, markup)
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// NotificationsHandler serves /notifications on|off
+type NotificationsHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+}
+
+func NewNotificationsHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage) *NotificationsHandler {
+	return &NotificationsHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+	}
+}
+
+func (h *NotificationsHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	userID := msg.From.ID
+
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(msg.CommandArguments())) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		_, err := h.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, "notifications.usage")))
+		return err
+	}
+
+	if err := h.storage.SetNotificationsEnabled(ctx, userID, enabled); err != nil {
+		return err
+	}
+
+	key := "notifications.off"
+	if enabled {
+		key = "notifications.on"
+	}
+	_, err = h.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, key)))
+	return err
+}
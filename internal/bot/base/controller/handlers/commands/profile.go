@@ -0,0 +1,308 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"html"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/verification"
+	"s1ntez/pkg/units"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	profileCallbackPrefix = "profile"
+
+	stepProfileAddress     = "profile_address"
+	stepProfileContact     = "profile_contact"
+	stepProfileContactCode = "profile_contact_code"
+
+	// maxFavoriteButtons keeps the favorites keyboard within Telegram's limits
+	maxFavoriteButtons = 60
+)
+
+// ProfileHandler serves /profile: the contact, default delivery and
+// favorite materials that the order dialogs fill in for the customer, and
+// the unit sizes are typed and shown in
+type ProfileHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	redis   *redis.Storage
+	storage *postgres.PostgresStorage
+	phones  *verification.Service
+}
+
+func NewProfileHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, redisStorage *redis.Storage, storage *postgres.PostgresStorage, phones *verification.Service) *ProfileHandler {
+	return &ProfileHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		redis:   redisStorage,
+		storage: storage,
+		phones:  phones,
+	}
+}
+
+func (h *ProfileHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	return h.show(ctx, msg.Chat.ID, msg.From.ID, h.locale(ctx, msg.From.ID))
+}
+
+func (h *ProfileHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	chatID, userID := query.Message.Chat.ID, query.From.ID
+	locale := h.locale(ctx, userID)
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) < 2 {
+		return nil
+	}
+	arg := ""
+	if len(parts) > 2 {
+		arg = parts[2]
+	}
+
+	switch parts[1] {
+	case "show":
+		if err := h.dropStep(ctx, chatID); err != nil {
+			return err
+		}
+		return h.show(ctx, chatID, userID, locale)
+
+	case "contact":
+		if err := h.setStep(ctx, chatID, stepProfileContact, nil); err != nil {
+			return err
+		}
+		return dialog.Send(h.botAPI, chatID, i18n.T(locale, "order.ask_contact"), dialog.ContactKeyboard(locale))
+
+	case "delivery":
+		text, markup := dialog.AskDelivery(locale, profileCallbackPrefix)
+		return dialog.Send(h.botAPI, chatID, text, markup)
+
+	case "dlv":
+		order := &redis.Order{}
+		needsAddress, ok := dialog.SetDeliveryMethod(order, arg)
+		if !ok {
+			return nil
+		}
+		if needsAddress {
+			if err := h.setStep(ctx, chatID, stepProfileAddress, order); err != nil {
+				return err
+			}
+			return dialog.Send(h.botAPI, chatID, i18n.T(locale, "delivery.ask_address"), nil)
+		}
+		if err := h.storage.SaveDeliveryPreference(ctx, userID, arg, ""); err != nil {
+			return err
+		}
+		return h.show(ctx, chatID, userID, locale)
+
+	case "favs":
+		markup, err := h.favoritesKeyboard(ctx, userID, locale)
+		if err != nil {
+			return err
+		}
+		return dialog.Send(h.botAPI, chatID, i18n.T(locale, "profile.favorites_prompt"), markup)
+
+	case "fav":
+		if _, err := h.storage.ToggleFavoriteTexture(ctx, userID, arg); err != nil {
+			return err
+		}
+		markup, err := h.favoritesKeyboard(ctx, userID, locale)
+		if err != nil {
+			return err
+		}
+		_, err = h.botAPI.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID, markup))
+		return err
+
+	case "units":
+		row := make([]tgbotapi.InlineKeyboardButton, 0, len(units.All))
+		for _, u := range units.All {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				i18n.T(locale, "unit."+string(u)), profileCallbackPrefix+":unit:"+string(u)))
+		}
+		return dialog.Send(h.botAPI, chatID, i18n.T(locale, "profile.units_prompt"), tgbotapi.NewInlineKeyboardMarkup(row))
+
+	case "unit":
+		unit := units.Unit(arg)
+		if !unit.Valid() {
+			return nil
+		}
+		if err := h.storage.SetUserUnit(ctx, userID, unit); err != nil {
+			return err
+		}
+		return h.show(ctx, chatID, userID, locale)
+
+	case "clear":
+		if err := h.storage.ClearPreferences(ctx, userID); err != nil {
+			return err
+		}
+		return h.show(ctx, chatID, userID, locale)
+	}
+
+	return nil
+}
+
+// HandleMessage takes the typed address and contact of a profile change
+func (h *ProfileHandler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if !msg.Chat.IsPrivate() {
+		return false, nil
+	}
+
+	state, err := h.redis.GetUserDialogState(ctx, msg.Chat.ID)
+	if err != nil || !strings.HasPrefix(state.Step, "profile_") {
+		return false, err
+	}
+
+	userID := msg.From.ID
+	locale := h.locale(ctx, userID)
+
+	switch state.Step {
+	case stepProfileAddress:
+		order := cmp.Or(state.Order, &redis.Order{})
+		if !dialog.SetDeliveryAddress(order, msg.Text) {
+			return true, dialog.Send(h.botAPI, msg.Chat.ID, i18n.T(locale, "delivery.bad_address"), nil)
+		}
+		d := dialog.Delivery(order)
+		if err := h.storage.SaveDeliveryPreference(ctx, userID, string(d.Method), d.Address); err != nil {
+			return true, err
+		}
+
+	case stepProfileContact, stepProfileContactCode:
+		answer, err := dialog.ReadContact(ctx, h.phones, msg, state.Step == stepProfileContactCode)
+		switch {
+		case err != nil:
+			return true, err
+		case answer.Problem != "":
+			return true, dialog.Send(h.botAPI, msg.Chat.ID, i18n.T(locale, answer.Problem), nil)
+		case answer.NeedsCode:
+			if err := h.setStep(ctx, msg.Chat.ID, stepProfileContactCode, nil); err != nil {
+				return true, err
+			}
+			return true, dialog.Send(h.botAPI, msg.Chat.ID, i18n.T(locale, "order.ask_code"), tgbotapi.NewRemoveKeyboard(true))
+		}
+
+	default:
+		return false, nil
+	}
+
+	if err := h.dropStep(ctx, msg.Chat.ID); err != nil {
+		return true, err
+	}
+	// The contact keyboard may still be open
+	if err := dialog.Send(h.botAPI, msg.Chat.ID, i18n.T(locale, "profile.saved"), tgbotapi.NewRemoveKeyboard(true)); err != nil {
+		return true, err
+	}
+	return true, h.show(ctx, msg.Chat.ID, userID, locale)
+}
+
+// show sends the profile card with its edit buttons
+func (h *ProfileHandler) show(ctx context.Context, chatID, userID int64, locale i18n.Locale) error {
+	prefs, err := h.storage.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	phone, err := h.phones.Known(ctx, userID)
+	if err != nil {
+		return err
+	}
+	unit, err := h.storage.GetUserUnit(ctx, userID)
+	if err != nil {
+		return err
+	}
+	textures, err := h.storage.GetAvailableTextures(ctx)
+	if err != nil {
+		return err
+	}
+
+	notSet := i18n.T(locale, "profile.not_set")
+
+	contact := cmp.Or(phone, notSet)
+
+	delivery := notSet
+	if method := pricing.DeliveryMethod(prefs.DeliveryMethod); method.Valid() {
+		delivery = i18n.T(locale, "delivery."+string(method))
+		if method.NeedsAddress() {
+			delivery += ", " + html.EscapeString(prefs.DeliveryAddress)
+		}
+	}
+
+	var names []string
+	for _, t := range textures {
+		if slices.Contains(prefs.FavoriteTextures, t.ID) {
+			names = append(names, html.EscapeString(t.Name))
+		}
+	}
+	favorites := notSet
+	if len(names) > 0 {
+		favorites = strings.Join(names, ", ")
+	}
+
+	button := func(key, action string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, key), profileCallbackPrefix+":"+action)
+	}
+	return dialog.Send(h.botAPI, chatID,
+		i18n.T(locale, "profile.card", contact, delivery, favorites, i18n.T(locale, "unit."+string(unit))),
+		tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(button("profile.edit_contact", "contact"), button("profile.edit_delivery", "delivery")),
+			tgbotapi.NewInlineKeyboardRow(button("profile.edit_favorites", "favs"), button("profile.edit_units", "units")),
+			tgbotapi.NewInlineKeyboardRow(button("profile.clear", "clear")),
+		))
+}
+
+// favoritesKeyboard lists the materials in stock with a star on the
+// favorites; a button adds or removes one
+func (h *ProfileHandler) favoritesKeyboard(ctx context.Context, userID int64, locale i18n.Locale) (tgbotapi.InlineKeyboardMarkup, error) {
+	prefs, err := h.storage.GetPreferences(ctx, userID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	textures, err := h.storage.GetAvailableTextures(ctx)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	slices.SortFunc(textures, func(a, b postgres.Texture) int {
+		return cmp.Or(cmp.Compare(a.ServiceType, b.ServiceType), cmp.Compare(a.Name, b.Name))
+	})
+	textures = dialog.FavoritesFirst(textures, prefs.FavoriteTextures)
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, min(len(textures), maxFavoriteButtons)+1)
+	for _, t := range textures[:min(len(textures), maxFavoriteButtons)] {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			dialog.MaterialLabel(t.Name, prefs.FavoriteTextures, t.ID),
+			profileCallbackPrefix+":fav:"+t.ID)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+		i18n.T(locale, "profile.done"), profileCallbackPrefix+":show")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+// setStep waits for a typed answer. It replaces an unfinished order
+// draft and its transcript, like starting a new order does.
+func (h *ProfileHandler) setStep(ctx context.Context, chatID int64, step string, order *redis.Order) error {
+	if err := h.redis.SetUserDialogState(ctx, chatID, &redis.UserState{Step: step, Order: order}); err != nil {
+		return err
+	}
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	return nil
+}
+
+// dropStep ends a profile change, leaving other dialogs alone
+func (h *ProfileHandler) dropStep(ctx context.Context, chatID int64) error {
+	state, err := h.redis.GetUserDialogState(ctx, chatID)
+	if err != nil || !strings.HasPrefix(state.Step, "profile_") {
+		return err
+	}
+	return h.redis.DropUserDialogState(ctx, chatID)
+}
+
+func (h *ProfileHandler) locale(ctx context.Context, userID int64) i18n.Locale {
+	return dialog.Locale(ctx, h.storage, h.logger, userID)
+}
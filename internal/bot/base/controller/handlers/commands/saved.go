@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const savedCallbackPrefix = "saved"
+
+// SavedQuotesHandler serves /saved, the quotes a customer kept for later
+// with "Save for later" on an order summary. "Order" resumes one in its
+// dialog, "Delete" drops it.
+type SavedQuotesHandler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	storage *postgres.PostgresStorage
+}
+
+func NewSavedQuotesHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage) *SavedQuotesHandler {
+	return &SavedQuotesHandler{
+		logger:  logger,
+		botAPI:  botAPI,
+		storage: storage,
+	}
+}
+
+func (h *SavedQuotesHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	userID := update.Message.From.ID
+	locale := h.locale(ctx, userID)
+
+	text, markup, err := h.list(ctx, userID, locale)
+	if err != nil {
+		return err
+	}
+	return dialog.Send(h.botAPI, update.Message.Chat.ID, text, markup)
+}
+
+func (h *SavedQuotesHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	userID := query.From.ID
+	locale := h.locale(ctx, userID)
+
+	raw, ok := strings.CutPrefix(query.Data, savedCallbackPrefix+":del:")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if !ok || err != nil || query.Message == nil {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return nil
+	}
+
+	err = h.storage.DeleteSavedQuote(ctx, userID, id)
+	if err != nil && !errors.Is(err, postgres.ErrSavedQuoteNotFound) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "error.generic")))
+		return err
+	}
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "saved.deleted")))
+
+	text, markup, err := h.list(ctx, userID, locale)
+	if err != nil {
+		return err
+	}
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if markup != nil {
+		edit.ReplyMarkup = markup
+	}
+	_, err = h.botAPI.Request(edit)
+	return err
+}
+
+// list renders the saved quotes, each with its "Order" and "Delete"
+// buttons; markup is nil when there are none
+func (h *SavedQuotesHandler) list(ctx context.Context, userID int64, locale i18n.Locale) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	quotes, err := h.storage.ListSavedQuotes(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(quotes) == 0 {
+		return i18n.T(locale, "saved.empty"), nil, nil
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "saved.title"))
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(quotes))
+	for i, q := range quotes {
+		n := i + 1
+		text.WriteString("\n\n" + i18n.T(locale, "saved.item", n, html.EscapeString(q.Title),
+			q.Price, q.Currency.Symbol(), q.CreatedAt.Format("02.01.2006"), q.ExpiresAt.Format("02.01.2006")))
+
+		row := tgbotapi.NewInlineKeyboardRow()
+		if button, ok := dialog.ResumeButton(locale, q, n); ok {
+			row = append(row, button)
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "saved.delete_button", n),
+			fmt.Sprintf("%s:del:%d", savedCallbackPrefix, q.ID)))
+		rows = append(rows, row)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return text.String(), &markup, nil
+}
+
+func (h *SavedQuotesHandler) locale(ctx context.Context, userID int64) i18n.Locale {
+	locale, err := h.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	return locale
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/imageproxy"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/templates"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const textureInfoCallbackPrefix = "texinfo"
+
+// TextureInfoButton builds the "More info" button shown next to a texture
+// wherever materials are listed: /compare and the material steps of the
+// sticker and print dialogs
+func TextureInfoButton(locale i18n.Locale, textureID string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(
+		i18n.T(locale, "texture.more_info"),
+		fmt.Sprintf("%s:%s", textureInfoCallbackPrefix, textureID),
+	)
+}
+
+// TextureInfoHandler sends the texture preview and gallery as a media group
+// followed by the description and care instructions
+type TextureInfoHandler struct {
+	logger   *zap.Logger
+	botAPI   *tgbotapi.BotAPI
+	storage  *postgres.PostgresStorage
+	previews *imageproxy.Proxy
+	cfg      *config.Config
+}
+
+func NewTextureInfoHandler(logger *zap.Logger, botAPI *tgbotapi.BotAPI, storage *postgres.PostgresStorage, previews *imageproxy.Proxy, cfg *config.Config) *TextureInfoHandler {
+	return &TextureInfoHandler{
+		logger:   logger,
+		botAPI:   botAPI,
+		storage:  storage,
+		previews: previews,
+		cfg:      cfg,
+	}
+}
+
+func (h *TextureInfoHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	textureID := strings.TrimPrefix(query.Data, textureInfoCallbackPrefix+":")
+	chatID := query.Message.Chat.ID
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	locale, err := h.storage.GetUserLocale(ctx, query.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	details, err := h.storage.GetTextureDetails(ctx, textureID)
+	if err != nil {
+		_, _ = h.botAPI.Send(tgbotapi.NewMessage(chatID, i18n.T(locale, "error.generic")))
+		return fmt.Errorf("failed to load texture %s: %w", textureID, err)
+	}
+
+	h.sendGallery(ctx, chatID, details)
+
+	card, err := templates.Render(templates.TextureCard{
+		Locale:           locale,
+		Name:             details.Name,
+		PricePerDM2:      details.PricePerDM2,
+		CurrencySymbol:   cmp.Or(details.PriceCurrency, h.cfg.Currency).Symbol(),
+		Description:      details.Description,
+		CareInstructions: details.CareInstructions,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = h.botAPI.Send(card.To(chatID))
+	return err
+}
+
+// sendGallery sends the preview of the texture image first, then the
+// gallery photos. A preview file ID Telegram no longer accepts is
+// forgotten and the preview uploaded again.
+func (h *TextureInfoHandler) sendGallery(ctx context.Context, chatID int64, details *postgres.TextureDetails) {
+	preview, err := h.previews.Photo(ctx, details.ImageURL)
+	if err != nil && !errors.Is(err, imageproxy.ErrNoImage) {
+		// The gallery is still worth sending without the preview
+		h.logger.Warn("Failed to get texture preview",
+			zap.String("texture_id", details.ID),
+			zap.Error(err))
+	}
+
+	messages, err := h.sendMedia(chatID, preview, details.Photos)
+	if _, cached := preview.(tgbotapi.FileID); err != nil && cached {
+		h.logger.Warn("Cached texture preview refused, uploading it again",
+			zap.String("texture_id", details.ID),
+			zap.Error(err))
+		h.previews.Forget(ctx, details.ImageURL)
+		if preview, err = h.previews.Photo(ctx, details.ImageURL); err == nil {
+			messages, err = h.sendMedia(chatID, preview, details.Photos)
+		}
+	}
+	if err != nil {
+		h.logger.Warn("Failed to send texture gallery",
+			zap.String("texture_id", details.ID),
+			zap.Error(err))
+		return
+	}
+
+	if _, uploaded := preview.(tgbotapi.FileBytes); uploaded && len(messages) > 0 {
+		h.previews.Remember(ctx, details.ImageURL, messages[0])
+	}
+}
+
+// sendMedia sends a single photo on its own, since a media group needs at
+// least two
+func (h *TextureInfoHandler) sendMedia(chatID int64, preview tgbotapi.RequestFileData, photos []postgres.TexturePhoto) ([]tgbotapi.Message, error) {
+	media := make([]interface{}, 0, len(photos)+1)
+	if preview != nil {
+		media = append(media, tgbotapi.NewInputMediaPhoto(preview))
+	}
+	for _, photo := range photos {
+		item := tgbotapi.NewInputMediaPhoto(tgbotapi.FileID(photo.FileID))
+		item.Caption = photo.Caption
+		media = append(media, item)
+	}
+
+	switch len(media) {
+	case 0:
+		return nil, nil
+	case 1:
+		item := media[0].(tgbotapi.InputMediaPhoto)
+		photo := tgbotapi.NewPhoto(chatID, item.Media)
+		photo.Caption = item.Caption
+		msg, err := h.botAPI.Send(photo)
+		if err != nil {
+			return nil, err
+		}
+		return []tgbotapi.Message{msg}, nil
+	}
+	return h.botAPI.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media))
+}
@@ -0,0 +1,321 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/tracing"
+	redisclient "s1ntez/pkg/redis"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// CommandHandler handles a single slash command ("/start", "/language", ...)
+type CommandHandler interface {
+	Handle(ctx context.Context, update tgbotapi.Update) error
+}
+
+// CallbackHandler handles inline keyboard callbacks. Callback data has the
+// form "<prefix>:<payload>" and handlers are registered by prefix.
+type CallbackHandler interface {
+	HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error
+}
+
+// InlineHandler answers inline queries ("@bot <query>" typed in any chat)
+type InlineHandler interface {
+	HandleInline(ctx context.Context, query *tgbotapi.InlineQuery) error
+}
+
+// MessageHandler gets plain (non-command) messages. Handlers are tried in
+// registration order until one reports the message as handled.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error)
+}
+
+// PreCheckoutHandler confirms or declines a payment before Telegram
+// charges the customer. It must answer within ten seconds.
+type PreCheckoutHandler interface {
+	HandlePreCheckout(ctx context.Context, query *tgbotapi.PreCheckoutQuery) error
+}
+
+// Guard screens updates before any handler sees them. An update it
+// reports as handled goes no further.
+type Guard interface {
+	Screen(ctx context.Context, update tgbotapi.Update) (bool, error)
+}
+
+type Bot struct {
+	api     *tgbotapi.BotAPI
+	redis   *redis.Storage
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+	cfg     *config.Config
+
+	commandHandlers  map[string]CommandHandler
+	callbackHandlers map[string]CallbackHandler
+	messageHandlers  []MessageHandler
+	inlineHandler    InlineHandler
+	preCheckout      PreCheckoutHandler
+	guard            Guard
+}
+
+func New(
+	redisStorage *redis.Storage,
+	pgStorage *postgres.PostgresStorage,
+	logger *zap.Logger,
+	cfg *config.Config,
+	commandHandlers map[string]CommandHandler,
+	callbackHandlers map[string]CallbackHandler,
+) (*Bot, error) {
+	api, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bot API: %w", err)
+	}
+
+	if callbackHandlers == nil {
+		callbackHandlers = make(map[string]CallbackHandler)
+	}
+
+	return &Bot{
+		api:              api,
+		redis:            redisStorage,
+		storage:          pgStorage,
+		logger:           logger,
+		cfg:              cfg,
+		commandHandlers:  commandHandlers,
+		callbackHandlers: callbackHandlers,
+	}, nil
+}
+
+// AddMessageHandler registers a handler for plain messages. It must be called before Start.
+func (b *Bot) AddMessageHandler(handler MessageHandler) {
+	b.messageHandlers = append(b.messageHandlers, handler)
+}
+
+// SetInlineHandler registers the handler of inline queries. Inline mode must
+// also be enabled for the bot in @BotFather. It must be called before Start.
+func (b *Bot) SetInlineHandler(handler InlineHandler) {
+	b.inlineHandler = handler
+}
+
+// SetPreCheckoutHandler registers the handler of payment confirmations.
+// It must be called before Start.
+func (b *Bot) SetPreCheckoutHandler(handler PreCheckoutHandler) {
+	b.preCheckout = handler
+}
+
+// SetGuard registers the screen run before every update. It must be
+// called before Start.
+func (b *Bot) SetGuard(guard Guard) {
+	b.guard = guard
+}
+
+// Start polls Telegram for updates until ctx is cancelled. Updates are
+// handled concurrently across chats, in order within a chat. On shutdown
+// the updates already received are still handled before Start returns:
+// Telegram considers them delivered.
+func (b *Bot) Start(ctx context.Context) error {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	updates := b.api.GetUpdatesChan(u)
+
+	handleCtx := context.WithoutCancel(ctx)
+	workers := newDispatcher(b.cfg.Telegram.Workers, b.cfg.Telegram.QueueSize, func(update tgbotapi.Update) {
+		b.handleUpdate(handleCtx, update)
+	})
+	defer workers.stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.api.StopReceivingUpdates()
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if !workers.dispatch(ctx, update) {
+				b.api.StopReceivingUpdates()
+				return nil
+			}
+		}
+	}
+}
+
+func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	ctx, span := tracing.Start(ctx, "bot.handleUpdate",
+		tracing.UpdateIDKey.Int(update.UpdateID))
+	defer span.End()
+
+	if user := update.SentFrom(); user != nil {
+		span.SetAttributes(tracing.UserIDKey.Int64(user.ID))
+	}
+	if chat := update.FromChat(); chat != nil {
+		span.SetAttributes(tracing.ChatIDKey.Int64(chat.ID))
+	}
+
+	// Updates are handled at most once: after a restart Telegram may send
+	// the last batch again, and a repeated confirmation must not place a
+	// second order. If Redis is down we'd rather process than drop.
+	claimed, err := b.redis.ClaimUpdate(ctx, update.UpdateID, b.cfg.Redis.UpdateDedupTTL)
+	if err != nil {
+		b.logger.Warn("Failed to deduplicate update",
+			zap.Int("update_id", update.UpdateID),
+			zap.Error(err))
+	} else if !claimed {
+		b.logger.Info("Skipping redelivered update", zap.Int("update_id", update.UpdateID))
+		return
+	}
+
+	if b.guard != nil {
+		screened, err := b.guard.Screen(ctx, update)
+		if err != nil {
+			b.logger.Error("Failed to screen update",
+				zap.Int("update_id", update.UpdateID),
+				zap.Error(err))
+		}
+		if screened {
+			return
+		}
+	}
+
+	b.recordDialogInput(ctx, update)
+	b.recordCustomer(ctx, update)
+
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		handler, ok := b.commandHandlers[update.Message.Command()]
+		if !ok {
+			return
+		}
+		span.SetName("bot.command." + update.Message.Command())
+		err = handler.Handle(ctx, update)
+
+	case update.CallbackQuery != nil:
+		prefix, _, _ := strings.Cut(update.CallbackQuery.Data, ":")
+		handler, ok := b.callbackHandlers[prefix]
+		if !ok {
+			b.logger.Warn("Unknown callback",
+				zap.String("data", update.CallbackQuery.Data))
+			return
+		}
+		span.SetName("bot.callback." + prefix)
+		err = handler.HandleCallback(ctx, update.CallbackQuery)
+
+	case update.InlineQuery != nil:
+		if b.inlineHandler == nil {
+			return
+		}
+		span.SetName("bot.inline")
+		err = b.inlineHandler.HandleInline(ctx, update.InlineQuery)
+
+	case update.PreCheckoutQuery != nil:
+		if b.preCheckout == nil {
+			return
+		}
+		span.SetName("bot.pre_checkout")
+		err = b.preCheckout.HandlePreCheckout(ctx, update.PreCheckoutQuery)
+
+	case update.Message != nil:
+		for _, handler := range b.messageHandlers {
+			var handled bool
+			if handled, err = handler.HandleMessage(ctx, update.Message); handled || err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		b.logger.Error("Failed to handle update",
+			zap.Int("update_id", update.UpdateID),
+			zap.Error(err))
+
+		// Dialogs keep their state in Redis; everything else works from
+		// Postgres while it is down
+		if errors.Is(err, redisclient.ErrUnavailable) {
+			b.replyUnavailable(ctx, update)
+		}
+	}
+}
+
+func (b *Bot) replyUnavailable(ctx context.Context, update tgbotapi.Update) {
+	chat := update.FromChat()
+	if chat == nil {
+		return
+	}
+	locale, err := b.storage.GetUserLocale(ctx, chat.ID)
+	if err != nil {
+		b.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(chat.ID, i18n.T(locale, "error.unavailable"))); err != nil {
+		b.logger.Warn("Failed to send unavailable notice", zap.Error(err))
+	}
+}
+
+// recordDialogInput keeps user inputs made during an order dialog so the
+// transcript can be exported if the customer later disputes the order. The
+// dialogs record their own replies, see dialog.Record.
+func (b *Bot) recordDialogInput(ctx context.Context, update tgbotapi.Update) {
+	var userID int64
+	var text string
+
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		userID = update.Message.From.ID
+		text = update.Message.Text
+		if update.Message.Contact != nil {
+			text = "[contact] " + update.Message.Contact.PhoneNumber
+		}
+	case update.CallbackQuery != nil:
+		userID = update.CallbackQuery.From.ID
+		text = "[button] " + update.CallbackQuery.Data
+	default:
+		return
+	}
+
+	if text == "" {
+		return
+	}
+
+	state, err := b.redis.GetUserDialogState(ctx, userID)
+	if err != nil || state.Step == "" {
+		return
+	}
+
+	if err := b.storage.RecordDialogMessage(ctx, userID, postgres.DirectionIn, state.Step, text); err != nil {
+		b.logger.Warn("Failed to record dialog message",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// recordCustomer keeps the Telegram username of the customer writing in a
+// private chat, so staff can reach them outside the bot
+func (b *Bot) recordCustomer(ctx context.Context, update tgbotapi.Update) {
+	var from *tgbotapi.User
+	switch {
+	case update.Message != nil && update.Message.Chat != nil && update.Message.Chat.IsPrivate():
+		from = update.Message.From
+	case update.CallbackQuery != nil:
+		from = update.CallbackQuery.From
+	}
+	if from == nil || from.IsBot {
+		return
+	}
+
+	if err := b.storage.Customers().Touch(ctx, from.ID, from.UserName); err != nil {
+		b.logger.Warn("Failed to record customer",
+			zap.Int64("user_id", from.ID),
+			zap.Error(err))
+	}
+}
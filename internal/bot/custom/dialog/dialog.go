@@ -0,0 +1,273 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"html"
+	"s1ntez/internal/bot/validate"
+	"s1ntez/internal/giftcards"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/promo"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/verification"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/phone"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Helpers shared by the product order dialogs
+
+// ContactKeyboard offers to share the phone number of the Telegram account
+func ContactKeyboard(locale i18n.Locale) tgbotapi.ReplyKeyboardMarkup {
+	keyboard := tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(
+		tgbotapi.NewKeyboardButtonContact(i18n.T(locale, "order.share_contact"))))
+	keyboard.OneTimeKeyboard = true
+	keyboard.ResizeKeyboard = true
+	return keyboard
+}
+
+// ContactAnswer is the outcome of a message on the contact step
+type ContactAnswer struct {
+	// Phone is set once the number can be used for the order
+	Phone string
+	// NeedsCode means a verification code was sent and should be asked for
+	NeedsCode bool
+	// Problem is the message key explaining a rejected answer
+	Problem string
+}
+
+// ReadContact takes a reply on the contact step: a shared contact, a typed
+// number or, while awaitingCode, the verification code. A number typed
+// instead of the code starts over with it.
+func ReadContact(ctx context.Context, phones *verification.Service, msg *tgbotapi.Message, awaitingCode bool) (ContactAnswer, error) {
+	userID := msg.From.ID
+
+	if msg.Contact != nil {
+		// Only the account's own contact proves the number
+		if msg.Contact.UserID != userID {
+			return ContactAnswer{Problem: "order.foreign_contact"}, nil
+		}
+		number, err := phones.Shared(ctx, userID, msg.Contact.PhoneNumber)
+		if errors.Is(err, phone.ErrInvalid) {
+			return ContactAnswer{Problem: "order.bad_contact"}, nil
+		}
+		return ContactAnswer{Phone: number}, err
+	}
+
+	text := strings.TrimSpace(msg.Text)
+
+	if awaitingCode && validate.IsCode(text) {
+		number, err := phones.Confirm(ctx, userID, text)
+		switch {
+		case errors.Is(err, verification.ErrWrongCode):
+			return ContactAnswer{Problem: "order.wrong_code"}, nil
+		case errors.Is(err, verification.ErrNoCode), errors.Is(err, verification.ErrTooManyAttempts):
+			return ContactAnswer{Problem: "order.code_expired"}, nil
+		}
+		return ContactAnswer{Phone: number}, err
+	}
+
+	typed, err := validate.Phone(text)
+	if err != nil {
+		return ContactAnswer{Problem: "order.bad_contact"}, nil
+	}
+	number, needsCode, err := phones.Submit(ctx, userID, typed)
+	switch {
+	case errors.Is(err, verification.ErrTooManyCodes):
+		return ContactAnswer{Problem: "order.too_many_codes"}, nil
+	case err != nil:
+		return ContactAnswer{}, err
+	}
+	if needsCode {
+		return ContactAnswer{NeedsCode: true}, nil
+	}
+	return ContactAnswer{Phone: number}, nil
+}
+
+// Locale returns the user's language, the default one if it can't be loaded
+func Locale(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64) i18n.Locale {
+	locale, err := storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	return locale
+}
+
+// Send posts an HTML message with an optional keyboard
+func Send(botAPI *tgbotapi.BotAPI, chatID int64, text string, markup any) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	_, err := botAPI.Send(msg)
+	return err
+}
+
+// PromoCode returns the promo code entered in the draft, if any
+func PromoCode(order *redis.Order) string {
+	if order == nil || order.PromoCode == nil {
+		return ""
+	}
+	return *order.PromoCode
+}
+
+// GiftCard returns the gift card code entered in the draft, if any
+func GiftCard(order *redis.Order) string {
+	if order == nil || order.GiftCard == nil {
+		return ""
+	}
+	return *order.GiftCard
+}
+
+// SetCode keeps a code typed at the summary: a gift card code pays for the
+// order, anything else is taken for a promo code
+func SetCode(order *redis.Order, text string) {
+	if giftcards.IsCode(text) {
+		code := giftcards.Normalize(text)
+		order.GiftCard = &code
+		return
+	}
+	code := promo.Normalize(text)
+	order.PromoCode = &code
+}
+
+// CodeRejection returns the message key explaining why the promo code or
+// the gift card was not accepted; ok is false for any other error
+func CodeRejection(err error) (key string, ok bool) {
+	if key, ok := giftcards.Rejection(err); ok {
+		return key, true
+	}
+	switch {
+	case errors.Is(err, postgres.ErrPromoNotFound):
+		return "order.promo_not_found", true
+	case errors.Is(err, postgres.ErrPromoExpired):
+		return "order.promo_expired", true
+	case errors.Is(err, postgres.ErrPromoExhausted):
+		return "order.promo_exhausted", true
+	case errors.Is(err, postgres.ErrPromoUserLimit):
+		return "order.promo_used", true
+	}
+	return "", false
+}
+
+// DropRejected removes the code CodeRejection explained from the draft
+func DropRejected(order *redis.Order, err error) {
+	if _, ok := giftcards.Rejection(err); ok {
+		order.GiftCard = nil
+		return
+	}
+	order.PromoCode = nil
+}
+
+// GiftCardSummary is the summary line of what the gift card pays, "" when
+// it pays nothing
+func GiftCardSummary(locale i18n.Locale, order *redis.Order, b pricing.Breakdown) string {
+	code := GiftCard(order)
+	if b.GiftCard <= 0 || code == "" {
+		return ""
+	}
+	return i18n.T(locale, "order.gift_card_applied", giftcards.Hint(code), b.GiftCard, b.Price-b.GiftCard)
+}
+
+// RushSummary is the summary line of the rush surcharge, "" for an order
+// that isn't rushed. The "rush" button of the summary toggles it.
+func RushSummary(locale i18n.Locale, b pricing.Breakdown) string {
+	if b.RushSurcharge <= 0 {
+		return ""
+	}
+	return i18n.T(locale, "order.rush_surcharge", b.RushSurcharge)
+}
+
+// RepeatedOrder asks whether the customer really wants the same order
+// again: prefix+":again" places it anyway. ok is false for other errors.
+func RepeatedOrder(err error, locale i18n.Locale, prefix string) (text string, markup tgbotapi.InlineKeyboardMarkup, ok bool) {
+	var duplicate *orders.DuplicateOrderError
+	if !errors.As(err, &duplicate) {
+		return "", markup, false
+	}
+
+	text = i18n.T(locale, "order.repeated", duplicate.Previous.Code, duplicate.Previous.CreatedAt.Format("15:04"))
+	markup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.repeat_anyway"), prefix+":again")),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.cancel"), prefix+":cancel")),
+	)
+	return text, markup, true
+}
+
+// AskDelivery offers the delivery methods; a button sends
+// prefix+":dlv:<method>"
+func AskDelivery(locale i18n.Locale, prefix string) (string, tgbotapi.InlineKeyboardMarkup) {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(pricing.DeliveryMethods))
+	for _, method := range pricing.DeliveryMethods {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(locale, "delivery."+string(method)),
+			prefix+":dlv:"+string(method)))
+	}
+	return i18n.T(locale, "delivery.ask"), tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// SetDeliveryMethod records the chosen method in the draft and reports
+// whether an address should be asked for next
+func SetDeliveryMethod(order *redis.Order, raw string) (needsAddress, ok bool) {
+	method := pricing.DeliveryMethod(raw)
+	if !method.Valid() {
+		return false, false
+	}
+
+	value := string(method)
+	order.Delivery = &redis.Delivery{Method: &value}
+	return method.NeedsAddress(), true
+}
+
+// SetDeliveryAddress records a typed address; ok is false when it can't be one
+func SetDeliveryAddress(order *redis.Order, raw string) bool {
+	address, err := validate.Address(raw)
+	if err != nil || order.Delivery == nil {
+		return false
+	}
+	order.Delivery.Address = &address
+	return true
+}
+
+// Delivery returns the delivery chosen in the draft, pickup when none
+func Delivery(order *redis.Order) orders.Delivery {
+	var d orders.Delivery
+	if order == nil || order.Delivery == nil {
+		return d
+	}
+	if order.Delivery.Method != nil {
+		d.Method = pricing.DeliveryMethod(*order.Delivery.Method)
+	}
+	if order.Delivery.Address != nil {
+		d.Address = *order.Delivery.Address
+	}
+	return d
+}
+
+// DeliverySummary describes the delivery of a quoted draft for the order
+// summary: the method, the address and the shipping charge
+func DeliverySummary(locale i18n.Locale, d orders.Delivery, shipping money.Amount) string {
+	method := d.OrPickup()
+	text := i18n.T(locale, "delivery."+string(method))
+	if method.NeedsAddress() {
+		text += ", " + html.EscapeString(d.Address)
+	}
+	text = i18n.T(locale, "delivery.summary", text)
+
+	switch {
+	case shipping > 0:
+		text += "\n" + i18n.T(locale, "delivery.shipping", shipping)
+	case method.NeedsAddress():
+		text += "\n" + i18n.T(locale, "delivery.free")
+	}
+	return text
+}
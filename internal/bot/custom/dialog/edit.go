@@ -0,0 +1,74 @@
+package dialog
+
+import (
+	"context"
+	"html"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/verification"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Fields that can be changed from the order summary; every dialog offers
+// the ones its product has
+const (
+	FieldMaterial   = "material"
+	FieldSize       = "size"
+	FieldFormat     = "format"
+	FieldSides      = "sides"
+	FieldQuantity   = "quantity"
+	FieldLamination = "lamination"
+	FieldLayout     = "layout"
+	FieldDelivery   = "delivery"
+	FieldContact    = "contact"
+)
+
+// EditKeyboard lists the fields of the draft, two per row; a button sends
+// prefix+":edit:<field>". The last one returns to the summary unchanged
+// with prefix+":summary".
+func EditKeyboard(locale i18n.Locale, prefix string, fields []string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(fields)/2+2)
+	for i := 0; i < len(fields); i += 2 {
+		row := make([]tgbotapi.InlineKeyboardButton, 0, 2)
+		for _, field := range fields[i:min(i+2, len(fields))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				i18n.T(locale, "edit."+field), prefix+":edit:"+field))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "edit.back"), prefix+":summary")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// StartEdit marks the draft as edited from the summary: the step answered
+// next returns to it instead of going on through the dialog
+func StartEdit(order *redis.Order) {
+	editing := true
+	order.Editing = &editing
+}
+
+// Editing reports whether a field is being changed from the summary
+func Editing(order *redis.Order) bool {
+	return order != nil && order.Editing != nil && *order.Editing
+}
+
+// EndEdit clears the mark once the summary is shown again
+func EndEdit(order *redis.Order) {
+	order.Editing = nil
+}
+
+// ContactSummary is the summary line with the number the order will be
+// placed with; "" while the customer has none
+func ContactSummary(ctx context.Context, phones *verification.Service, logger *zap.Logger, locale i18n.Locale, userID int64) string {
+	phone, err := phones.Known(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to get user contact", zap.Error(err))
+	}
+	if phone == "" {
+		return ""
+	}
+	return i18n.T(locale, "order.contact", html.EscapeString(phone))
+}
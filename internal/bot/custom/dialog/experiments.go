@@ -0,0 +1,25 @@
+package dialog
+
+import (
+	"context"
+	"s1ntez/internal/experiments"
+	"s1ntez/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SummaryKeyboard puts the confirm button to the other summary buttons as
+// the customer's variant of the summary_confirm experiment has it: on top
+// by default, last for the "bottom" variant
+func SummaryKeyboard(ctx context.Context, exp *experiments.Service, locale i18n.Locale, userID int64, prefix string, rows ...[]tgbotapi.InlineKeyboardButton) tgbotapi.InlineKeyboardMarkup {
+	variant := exp.Variant(ctx, experiments.SummaryConfirm, userID)
+	confirm := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(experiments.T(locale, variant, "order.confirm"), prefix+":confirm"))
+
+	if variant == experiments.VariantBottom {
+		rows = append(rows, confirm)
+	} else {
+		rows = append([][]tgbotapi.InlineKeyboardButton{confirm}, rows...)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
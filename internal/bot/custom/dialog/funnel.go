@@ -0,0 +1,22 @@
+package dialog
+
+import (
+	"context"
+	"s1ntez/internal/storage/postgres"
+
+	"go.uber.org/zap"
+)
+
+// Track records a step reached in an order flow for the funnel. A failure
+// is only logged: the dialog goes on without it.
+func Track(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64, flow, step string) {
+	if step == "" {
+		return
+	}
+	if err := storage.RecordDialogStep(ctx, userID, flow, step); err != nil {
+		logger.Warn("Failed to record dialog step",
+			zap.Int64("user_id", userID),
+			zap.String("step", step),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,70 @@
+package dialog
+
+import (
+	"context"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// Preferences returns the defaults the customer saved under /profile, none
+// if they can't be loaded
+func Preferences(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64) postgres.Preferences {
+	prefs, err := storage.GetPreferences(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to get user preferences", zap.Error(err))
+	}
+	return prefs
+}
+
+// Prefill puts the saved delivery into a new draft, so the delivery steps
+// are skipped; the summary still offers to change it
+func Prefill(order *redis.Order, prefs postgres.Preferences) {
+	method := pricing.DeliveryMethod(prefs.DeliveryMethod)
+	if !method.Valid() || (method.NeedsAddress() && prefs.DeliveryAddress == "") {
+		return
+	}
+
+	value, address := string(method), prefs.DeliveryAddress
+	order.Delivery = &redis.Delivery{Method: &value}
+	if method.NeedsAddress() {
+		order.Delivery.Address = &address
+	}
+}
+
+// HasDelivery reports whether the draft already has a delivery, chosen or
+// prefilled
+func HasDelivery(order *redis.Order) bool {
+	return order != nil && order.Delivery != nil && order.Delivery.Method != nil
+}
+
+// FavoritesFirst moves the favorite materials to the top of the list,
+// keeping the order within both groups
+func FavoritesFirst(materials []postgres.Texture, favorites []string) []postgres.Texture {
+	if len(favorites) == 0 {
+		return materials
+	}
+	sorted := slices.Clone(materials)
+	slices.SortStableFunc(sorted, func(a, b postgres.Texture) int {
+		fa, fb := slices.Contains(favorites, a.ID), slices.Contains(favorites, b.ID)
+		switch {
+		case fa && !fb:
+			return -1
+		case fb && !fa:
+			return 1
+		}
+		return 0
+	})
+	return sorted
+}
+
+// MaterialLabel stars a favorite material on its button
+func MaterialLabel(label string, favorites []string, id string) string {
+	if slices.Contains(favorites, id) {
+		return "⭐ " + label
+	}
+	return label
+}
@@ -0,0 +1,72 @@
+package dialog
+
+import (
+	"errors"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+)
+
+// HoldQuote records the quote of the summary shown, so the order is placed
+// at that price while it holds. A nil quote, when holding it failed, lets
+// the order be priced on confirmation.
+func HoldQuote(draft *redis.Order, q *postgres.PriceQuote) {
+	if q == nil {
+		draft.QuoteID = nil
+		return
+	}
+	draft.QuoteID = &q.ID
+}
+
+// QuoteID returns the quote of the last summary, 0 when none is held
+func QuoteID(draft *redis.Order) int64 {
+	if draft == nil || draft.QuoteID == nil {
+		return 0
+	}
+	return *draft.QuoteID
+}
+
+// QuoteSummary tells until when the price of the summary holds; "" when
+// no quote is held
+func QuoteSummary(locale i18n.Locale, q *postgres.PriceQuote) string {
+	if q == nil {
+		return ""
+	}
+	return i18n.T(locale, "order.quote_valid", q.ExpiresAt.Format("02.01.2006 15:04"))
+}
+
+// ApprovalSummary warns that the price of the summary is an estimate a
+// manager checks before the order goes ahead; "" when it is final
+func ApprovalSummary(locale i18n.Locale, cfg *config.Config, b pricing.Breakdown) string {
+	if len(orders.ApprovalReasons(cfg, b.Price, "")) == 0 {
+		return ""
+	}
+	return i18n.T(locale, "order.approval_note")
+}
+
+// Placed tells the customer the order is placed: with its total, or with
+// the promise of one while a manager approves the price. key takes the
+// order code and total.
+func Placed(locale i18n.Locale, key string, order *postgres.Order) string {
+	if order.Status == postgres.StatusPendingQuote {
+		return i18n.T(locale, "order.pending_quote", order.Code)
+	}
+	return i18n.T(locale, key, order.Code, order.Price)
+}
+
+// QuoteChanged explains a *orders.QuoteChangedError: how the price moved
+// since the summary. ok is false for other errors.
+func QuoteChanged(err error, locale i18n.Locale) (text string, ok bool) {
+	var changed *orders.QuoteChangedError
+	if !errors.As(err, &changed) {
+		return "", false
+	}
+	was, now := changed.Quote.Price, changed.Current.Price
+	if now > was {
+		return i18n.T(locale, "order.quote_up", was, now-was), true
+	}
+	return i18n.T(locale, "order.quote_down", was, was-now), true
+}
@@ -0,0 +1,118 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrNotRepeatable means the past order can't be started over in a dialog
+var ErrNotRepeatable = errors.New("order can't be repeated")
+
+// repeatPrefixes are the callback prefixes of the dialogs that can repeat
+// an order of their product
+var repeatPrefixes = map[postgres.ServiceType]string{
+	postgres.ServiceSticker:    "sticker",
+	postgres.ServiceTypography: "print",
+}
+
+// RepeatButton offers to repeat a past order; ok is false for orders no
+// dialog can repeat. The button sends prefix+":repeat:<order id>".
+func RepeatButton(locale i18n.Locale, order *postgres.Order) (button tgbotapi.InlineKeyboardButton, ok bool) {
+	items := order.LineItems()
+	if len(items) != 1 {
+		return button, false
+	}
+	prefix, ok := repeatPrefixes[items[0].ServiceType]
+	if !ok {
+		return button, false
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.repeat_button"),
+		fmt.Sprintf("%s:repeat:%d", prefix, order.ID)), true
+}
+
+// RepeatSource loads the customer's past order behind a repeat button and
+// returns its only item. Orders of other customers, of another product or
+// with several items are ErrNotRepeatable.
+func RepeatSource(ctx context.Context, storage *postgres.PostgresStorage, userID int64, rawID string, service postgres.ServiceType) (*postgres.Order, postgres.OrderItem, error) {
+	orderID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return nil, postgres.OrderItem{}, ErrNotRepeatable
+	}
+
+	order, err := storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return nil, postgres.OrderItem{}, ErrNotRepeatable
+	}
+	if err != nil {
+		return nil, postgres.OrderItem{}, err
+	}
+
+	items := order.LineItems()
+	if order.UserID != userID || len(items) != 1 || items[0].ServiceType != service {
+		return nil, postgres.OrderItem{}, ErrNotRepeatable
+	}
+	return order, items[0], nil
+}
+
+// Repeat fills a new draft with what the past order shares with every
+// product: the delivery and rush. Its price without the promo discount is
+// kept for RepeatDifference; the promo code itself is not reused.
+func Repeat(draft *redis.Order, past *postgres.Order) {
+	d := past.DeliveryOrPickup()
+	method, address := d.Method, d.Address
+	draft.Delivery = &redis.Delivery{Method: &method}
+	if address != "" {
+		draft.Delivery.Address = &address
+	}
+
+	rush := past.IsRush
+	draft.Rush = &rush
+
+	id, code, price := past.ID, past.Code, past.Price+past.Discount
+	draft.RepeatOf = &id
+	draft.RepeatCode = &code
+	draft.RepeatPrice = &price
+}
+
+// RepeatOf returns the order the draft repeats, 0 for a new one
+func RepeatOf(draft *redis.Order) int64 {
+	if draft == nil || draft.RepeatOf == nil {
+		return 0
+	}
+	return *draft.RepeatOf
+}
+
+// RepeatCode returns the code of the order the draft repeats, as the
+// customer knows it
+func RepeatCode(draft *redis.Order) string {
+	if draft == nil || draft.RepeatCode == nil {
+		return ""
+	}
+	return *draft.RepeatCode
+}
+
+// RepeatDifference compares the quote of a repeat with the price of the
+// past order, both without the promo discount; "" for other drafts
+func RepeatDifference(locale i18n.Locale, draft *redis.Order, b pricing.Breakdown) string {
+	if draft == nil || draft.RepeatCode == nil || draft.RepeatPrice == nil {
+		return ""
+	}
+
+	was, now := *draft.RepeatPrice, b.Price+b.Discount
+	switch {
+	case now > was:
+		return i18n.T(locale, "order.repeat_up", *draft.RepeatCode, was, now-was)
+	case now < was:
+		return i18n.T(locale, "order.repeat_down", *draft.RepeatCode, was, was-now)
+	}
+	return i18n.T(locale, "order.repeat_same", *draft.RepeatCode, was)
+}
@@ -0,0 +1,146 @@
+package dialog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/pkg/money"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// SaveQuote keeps the draft and its price under /saved and returns what to
+// tell the customer. A full list is told to them, not returned as an error.
+func SaveQuote(ctx context.Context, storage *postgres.PostgresStorage, cfg *config.Config, locale i18n.Locale, userID int64, service postgres.ServiceType, title string, draft *redis.Order, price money.Amount) (string, error) {
+	// A resumed quote is saved anew, at the price of today
+	kept := *draft
+	kept.Editing, kept.SavedQuote, kept.PricedAt, kept.QuoteID = nil, nil, nil, nil
+	// gift card codes are stored hashed only
+	kept.GiftCard = nil
+	data, err := json.Marshal(&kept)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode draft: %w", err)
+	}
+
+	_, err = storage.SaveQuote(ctx, postgres.SavedQuote{
+		UserID:      userID,
+		ServiceType: service,
+		Title:       title,
+		Draft:       data,
+		Price:       price,
+		Currency:    cfg.Currency,
+	}, cfg.SavedQuotes.TTL, cfg.SavedQuotes.Max)
+	if errors.Is(err, postgres.ErrTooManySavedQuotes) {
+		return i18n.T(locale, "saved.full", cfg.SavedQuotes.Max), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return i18n.T(locale, "saved.done",
+		now.Add(cfg.SavedQuotes.Validity).Format("02.01.2006 15:04"),
+		now.Add(cfg.SavedQuotes.TTL).Format("02.01.2006")), nil
+}
+
+// ResumeButton offers to order the saved quote numbered n in the list; ok
+// is false for products no dialog can resume. The button sends
+// prefix+":saved:<quote id>".
+func ResumeButton(locale i18n.Locale, q postgres.SavedQuote, n int) (button tgbotapi.InlineKeyboardButton, ok bool) {
+	prefix, ok := repeatPrefixes[q.ServiceType]
+	if !ok {
+		return button, false
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "saved.order_button", n),
+		fmt.Sprintf("%s:saved:%d", prefix, q.ID)), true
+}
+
+// ResumeQuote loads a saved quote of the customer into a new draft. While
+// the quote is within SAVED_QUOTE_VALIDITY the order keeps the prices of
+// the day it was saved. Expired quotes and quotes of another product are
+// postgres.ErrSavedQuoteNotFound.
+func ResumeQuote(ctx context.Context, storage *postgres.PostgresStorage, cfg *config.Config, userID int64, rawID string, service postgres.ServiceType) (*redis.Order, error) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return nil, postgres.ErrSavedQuoteNotFound
+	}
+
+	q, err := storage.GetSavedQuote(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.ServiceType != service {
+		return nil, postgres.ErrSavedQuoteNotFound
+	}
+
+	var draft redis.Order
+	if err := json.Unmarshal(q.Draft, &draft); err != nil {
+		return nil, fmt.Errorf("failed to decode saved quote %d: %w", id, err)
+	}
+	draft.SavedQuote = &q.ID
+	if time.Since(q.CreatedAt) < cfg.SavedQuotes.Validity {
+		pricedAt := q.CreatedAt
+		draft.PricedAt = &pricedAt
+	}
+	return &draft, nil
+}
+
+// SavedQuote returns the saved quote the draft was resumed from, 0 for
+// other drafts
+func SavedQuote(draft *redis.Order) int64 {
+	if draft == nil || draft.SavedQuote == nil {
+		return 0
+	}
+	return *draft.SavedQuote
+}
+
+// PricedAt returns the moment whose prices the draft is quoted at, zero for
+// today's
+func PricedAt(draft *redis.Order) time.Time {
+	if draft == nil || draft.PricedAt == nil {
+		return time.Time{}
+	}
+	return *draft.PricedAt
+}
+
+// PriceLapsed drops the held price of a resumed quote once its validity
+// window is over and reports whether it did, so the customer sees the new
+// price before confirming
+func PriceLapsed(draft *redis.Order, cfg *config.Config) bool {
+	if draft == nil || draft.PricedAt == nil || time.Since(*draft.PricedAt) < cfg.SavedQuotes.Validity {
+		return false
+	}
+	draft.PricedAt = nil
+	return true
+}
+
+// SavedQuoteSummary tells whether a resumed quote keeps its price; "" for
+// other drafts
+func SavedQuoteSummary(locale i18n.Locale, draft *redis.Order, cfg *config.Config) string {
+	if SavedQuote(draft) == 0 {
+		return ""
+	}
+	if pricedAt := PricedAt(draft); !pricedAt.IsZero() {
+		return i18n.T(locale, "saved.price_held", pricedAt.Add(cfg.SavedQuotes.Validity).Format("02.01.2006 15:04"))
+	}
+	return i18n.T(locale, "saved.price_current")
+}
+
+// ForgetQuote removes the saved quote an order was placed from
+func ForgetQuote(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64, draft *redis.Order) {
+	id := SavedQuote(draft)
+	if id == 0 {
+		return
+	}
+	if err := storage.DeleteSavedQuote(ctx, userID, id); err != nil && !errors.Is(err, postgres.ErrSavedQuoteNotFound) {
+		logger.Warn("Failed to remove ordered saved quote", zap.Int64("quote_id", id), zap.Error(err))
+	}
+}
@@ -0,0 +1,34 @@
+package dialog
+
+import (
+	"context"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+
+	"go.uber.org/zap"
+)
+
+// Record keeps a message the bot sent during an order dialog in the
+// transcript of the draft, next to the customer's inputs the bot records
+// itself. Messages sent outside a dialog are not kept.
+func Record(ctx context.Context, redisStorage *redis.Storage, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64, text string) {
+	state, err := redisStorage.GetUserDialogState(ctx, userID)
+	if err != nil || state.Step == "" {
+		return
+	}
+	if err := storage.RecordDialogMessage(ctx, userID, postgres.DirectionOut, state.Step, text); err != nil {
+		logger.Warn("Failed to record dialog message",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// Discard drops the transcript of a draft that was cancelled or replaced
+// by a new one, so it isn't linked to the next order placed
+func Discard(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64) {
+	if err := storage.DiscardDialogMessages(ctx, userID); err != nil {
+		logger.Warn("Failed to discard dialog messages",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,19 @@
+package dialog
+
+import (
+	"context"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/units"
+
+	"go.uber.org/zap"
+)
+
+// Unit returns the unit the user sees sizes in, the default one if it
+// can't be loaded
+func Unit(ctx context.Context, storage *postgres.PostgresStorage, logger *zap.Logger, userID int64) units.Unit {
+	unit, err := storage.GetUserUnit(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to get user unit", zap.Error(err))
+	}
+	return unit
+}
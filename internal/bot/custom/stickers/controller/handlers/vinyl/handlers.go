@@ -1 +1,926 @@
 package vinyl
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/bot/base/controller/handlers/commands"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/bot/custom/stickers/entity"
+	"s1ntez/internal/bot/custom/stickers/usecase"
+	"s1ntez/internal/bot/validate"
+	"s1ntez/internal/config"
+	"s1ntez/internal/experiments"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/sizing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/uploads"
+	"s1ntez/internal/verification"
+	"s1ntez/pkg/units"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	callbackPrefix = "sticker"
+	product        = "sticker"
+
+	stepMaterial    = "sticker_material"
+	stepSize        = "sticker_size"
+	stepSizePhoto   = "sticker_size_photo"
+	stepQuantity    = "sticker_quantity"
+	stepLamination  = "sticker_lamination"
+	stepPreview     = "sticker_preview"
+	stepConfirm     = "sticker_confirm"
+	stepContact     = "sticker_contact"
+	stepContactCode = "sticker_contact_code"
+	stepPromo       = "sticker_promo"
+	stepDelivery    = "sticker_delivery"
+	stepAddress     = "sticker_address"
+)
+
+var (
+	// sizePresets are in centimetres whatever unit the customer uses
+	sizePresets     = []string{"5x5", "7x7", "10x10", "10x15"}
+	quantityPresets = []int{50, 100, 250, 500, 1000}
+
+	// editFields can be changed from the summary
+	editFields = []string{
+		dialog.FieldMaterial, dialog.FieldSize, dialog.FieldQuantity, dialog.FieldLamination,
+		dialog.FieldLayout, dialog.FieldDelivery, dialog.FieldContact,
+	}
+)
+
+// Handler walks the customer through a sticker order:
+// material → size → quantity → lamination → layout → delivery →
+// confirmation.
+// The size may be measured from a photo when a measuring service is
+// configured; the customer confirms the suggestion or types the size.
+// The draft lives in the Redis dialog state. A delivery saved under
+// /profile is prefilled and skips its step. "Repeat this order" under
+// /myorders fills in a whole past order and goes to the confirmation.
+// "Edit" on the summary goes back to any one step and returns to it.
+// "Save for later" keeps the quote under /saved, whose "Order" button
+// resumes it at the confirmation.
+type Handler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	redis   *redis.Storage
+	storage *postgres.PostgresStorage
+	usecase *usecase.Usecase
+	phones  *verification.Service
+	// sizer suggests the size from a photo
+	sizer *sizing.Service
+	// experiments decide the summary keyboard
+	experiments *experiments.Service
+	cfg         *config.Config
+}
+
+func New(
+	logger *zap.Logger,
+	botAPI *tgbotapi.BotAPI,
+	redis *redis.Storage,
+	storage *postgres.PostgresStorage,
+	usecase *usecase.Usecase,
+	phones *verification.Service,
+	sizer *sizing.Service,
+	experiments *experiments.Service,
+	cfg *config.Config,
+) *Handler {
+	return &Handler{
+		logger:      logger,
+		botAPI:      botAPI,
+		redis:       redis,
+		storage:     storage,
+		usecase:     usecase,
+		phones:      phones,
+		sizer:       sizer,
+		experiments: experiments,
+		cfg:         cfg,
+	}
+}
+
+// Handle starts a new sticker order with /stickers
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	locale := h.locale(ctx, msg.From.ID)
+
+	selected := product
+	state := &redis.UserState{
+		Step:  stepMaterial,
+		Order: &redis.Order{SelectedProduct: &selected, Sticker: &redis.Stickers{}},
+	}
+	prefs := dialog.Preferences(ctx, h.storage, h.logger, msg.From.ID)
+	dialog.Prefill(state.Order, prefs)
+	dialog.Discard(ctx, h.storage, h.logger, msg.Chat.ID)
+	dialog.Track(ctx, h.storage, h.logger, msg.Chat.ID, callbackPrefix, postgres.FunnelStarted)
+	if err := h.save(ctx, msg.Chat.ID, state); err != nil {
+		return err
+	}
+
+	return h.askMaterial(ctx, msg.Chat.ID, locale, prefs.FavoriteTextures)
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	chatID := query.Message.Chat.ID
+	locale := h.locale(ctx, query.From.ID)
+
+	// A repeat from /myorders starts a draft of its own
+	if rawID, ok := strings.CutPrefix(query.Data, callbackPrefix+":repeat:"); ok {
+		return h.repeat(ctx, chatID, query.From.ID, locale, rawID)
+	}
+	// So does a quote saved under /saved
+	if rawID, ok := strings.CutPrefix(query.Data, callbackPrefix+":saved:"); ok {
+		return h.resume(ctx, chatID, query.From.ID, locale, rawID)
+	}
+
+	state, ok, err := h.draft(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return h.send(ctx, chatID, i18n.T(locale, "sticker.expired"), nil)
+	}
+	sticker := state.Order.Sticker
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) < 2 {
+		return nil
+	}
+	arg := ""
+	if len(parts) > 2 {
+		arg = parts[2]
+	}
+
+	switch parts[1] {
+	case "mat":
+		if state.Step != stepMaterial {
+			return nil
+		}
+		sticker.MaterialID = &arg
+		return h.proceed(ctx, chatID, locale, state, stepSize, func() error {
+			return h.askSize(ctx, chatID, locale)
+		})
+
+	case "size":
+		if state.Step != stepSize {
+			return nil
+		}
+		return h.setSize(ctx, chatID, locale, state, arg, units.CM)
+
+	case "photo":
+		if state.Step != stepSize || !h.sizer.Enabled() {
+			return nil
+		}
+		state.Step = stepSizePhoto
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, i18n.T(locale, "sticker.ask_size_photo"), nil)
+
+	case "qty":
+		if state.Step != stepQuantity {
+			return nil
+		}
+		return h.setQuantity(ctx, chatID, locale, state, arg)
+
+	case "lam":
+		lamination := pricing.Lamination(arg)
+		if state.Step != stepLamination || !lamination.Valid() {
+			return nil
+		}
+		value := string(lamination)
+		sticker.Lamination = &value
+		return h.proceed(ctx, chatID, locale, state, stepPreview, func() error {
+			return h.askPreview(ctx, chatID, locale)
+		})
+
+	case "skip":
+		if state.Step != stepPreview {
+			return nil
+		}
+		// Skipping while editing drops the layout sent before
+		sticker.PreviewID = nil
+		return h.afterPreview(ctx, chatID, locale, state)
+
+	case "dlv":
+		if state.Step != stepDelivery {
+			return nil
+		}
+		needsAddress, ok := dialog.SetDeliveryMethod(state.Order, arg)
+		if !ok {
+			return nil
+		}
+		if needsAddress {
+			state.Step = stepAddress
+			if err := h.save(ctx, chatID, state); err != nil {
+				return err
+			}
+			return h.send(ctx, chatID, i18n.T(locale, "delivery.ask_address"), nil)
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "delivery":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		return h.askDelivery(ctx, chatID, locale, state)
+
+	case "edit":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		if arg == "" {
+			return h.send(ctx, chatID, i18n.T(locale, "edit.prompt"), dialog.EditKeyboard(locale, callbackPrefix, editFields))
+		}
+		return h.edit(ctx, chatID, query.From.ID, locale, state, arg)
+
+	case "summary":
+		if state.Step != stepConfirm && !dialog.Editing(state.Order) {
+			return nil
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "rush":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		rush := !isRush(state)
+		state.Order.Rush = &rush
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "savequote":
+		if state.Step != stepConfirm || dialog.SavedQuote(state.Order) != 0 {
+			return nil
+		}
+		return h.saveQuote(ctx, chatID, query.From.ID, locale, state)
+
+	case "promo":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		state.Step = stepPromo
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, i18n.T(locale, "order.ask_promo"), tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.promo_skip"), callbackPrefix+":nopromo"))))
+
+	case "nopromo":
+		if state.Step != stepPromo {
+			return nil
+		}
+		state.Order.PromoCode, state.Order.GiftCard = nil, nil
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "confirm", "again":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		phone, err := h.phones.Known(ctx, query.From.ID)
+		if err != nil {
+			h.logger.Warn("Failed to get user contact", zap.Error(err))
+		}
+		if phone == "" {
+			state.Step = stepContact
+			if err := h.save(ctx, chatID, state); err != nil {
+				return err
+			}
+			return h.send(ctx, chatID, i18n.T(locale, "order.ask_contact"), dialog.ContactKeyboard(locale))
+		}
+		// One confirmation per summary message, however often it's pressed;
+		// "again" comes from the repeated order prompt, a message of its own
+		key := fmt.Sprintf("tg:sticker:%d:%d", chatID, query.Message.MessageID)
+		return h.place(ctx, chatID, query.From.ID, locale, state, phone, key, parts[1] == "again")
+
+	case "cancel":
+		if err := h.redis.DropUserDialogState(ctx, chatID); err != nil {
+			return err
+		}
+		dialog.Discard(ctx, h.storage, h.logger, chatID)
+		return h.send(ctx, chatID, i18n.T(locale, "sticker.cancelled"), nil)
+	}
+
+	return nil
+}
+
+// HandleMessage takes typed answers and the layout upload of an active draft
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if !msg.Chat.IsPrivate() {
+		return false, nil
+	}
+
+	state, ok, err := h.draft(ctx, msg.Chat.ID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	locale := h.locale(ctx, msg.From.ID)
+	text := strings.TrimSpace(msg.Text)
+
+	switch state.Step {
+	case stepSize:
+		unit := dialog.Unit(ctx, h.storage, h.logger, msg.From.ID)
+		return true, h.setSize(ctx, msg.Chat.ID, locale, state, text, unit)
+
+	case stepSizePhoto:
+		// A size typed instead of the photo is taken as is
+		if text != "" {
+			unit := dialog.Unit(ctx, h.storage, h.logger, msg.From.ID)
+			return true, h.setSize(ctx, msg.Chat.ID, locale, state, text, unit)
+		}
+		return true, h.measureSize(ctx, msg, locale, state)
+
+	case stepQuantity:
+		return true, h.setQuantity(ctx, msg.Chat.ID, locale, state, text)
+
+	case stepPreview:
+		var id int64
+		preview, err := uploads.Download(ctx, h.botAPI, msg, h.cfg.Stickers.MaxPreviewBytes)
+		if err == nil {
+			id, err = h.usecase.SavePreview(ctx, msg.From.ID, *preview)
+		}
+
+		switch {
+		case errors.Is(err, uploads.ErrNoFile):
+			return true, h.askPreview(ctx, msg.Chat.ID, locale)
+		case errors.Is(err, uploads.ErrTooLarge):
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "sticker.preview_too_large",
+				h.cfg.Stickers.MaxPreviewBytes>>20), nil)
+		case errors.Is(err, uploads.ErrFileFormat):
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "sticker.preview_format"), nil)
+		case err != nil:
+			return true, err
+		}
+
+		state.Order.Sticker.PreviewID = &id
+		return true, h.afterPreview(ctx, msg.Chat.ID, locale, state)
+
+	case stepAddress:
+		if !dialog.SetDeliveryAddress(state.Order, text) {
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "delivery.bad_address"), nil)
+		}
+		return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+
+	case stepPromo:
+		dialog.SetCode(state.Order, text)
+		return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+
+	case stepContact, stepContactCode:
+		answer, err := dialog.ReadContact(ctx, h.phones, msg, state.Step == stepContactCode)
+		switch {
+		case err != nil:
+			return true, err
+		case answer.Problem != "":
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, answer.Problem), nil)
+		case answer.NeedsCode:
+			state.Step = stepContactCode
+			if err := h.save(ctx, msg.Chat.ID, state); err != nil {
+				return true, err
+			}
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "order.ask_code"), tgbotapi.NewRemoveKeyboard(true))
+		}
+		if dialog.Editing(state.Order) {
+			// The contact keyboard may still be open
+			if err := h.send(ctx, msg.Chat.ID, i18n.T(locale, "order.contact_saved"), tgbotapi.NewRemoveKeyboard(true)); err != nil {
+				return true, err
+			}
+			return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+		}
+		key := fmt.Sprintf("tg:sticker:%d:%d", msg.Chat.ID, msg.MessageID)
+		return true, h.place(ctx, msg.Chat.ID, msg.From.ID, locale, state, answer.Phone, key, false)
+	}
+
+	// Buttons are expected on the other steps
+	return false, nil
+}
+
+// setSize takes a size typed or picked; a number without a unit is in unit
+func (h *Handler) setSize(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, raw string, unit units.Unit) error {
+	width, height, err := validate.Size(raw, unit, validate.StickerSize(h.cfg))
+	if text, invalid := validate.Message(err, locale); invalid {
+		return h.send(ctx, chatID, text, nil)
+	}
+
+	state.Order.Sticker.WidthCM = &width
+	state.Order.Sticker.HeightCM = &height
+	return h.proceed(ctx, chatID, locale, state, stepQuantity, func() error {
+		return h.askQuantity(ctx, chatID, locale)
+	})
+}
+
+// measureSize suggests the size read off a photo. The draft goes back to
+// the size step either way: the suggestion is confirmed with a size button,
+// and a typed size replaces it.
+func (h *Handler) measureSize(ctx context.Context, msg *tgbotapi.Message, locale i18n.Locale, state *redis.UserState) error {
+	width, height, err := h.sizer.Measure(ctx, msg)
+	switch {
+	case errors.Is(err, uploads.ErrNoFile):
+		return h.send(ctx, msg.Chat.ID, i18n.T(locale, "sticker.ask_size_photo"), nil)
+	case errors.Is(err, uploads.ErrTooLarge), errors.Is(err, uploads.ErrFileFormat), errors.Is(err, sizing.ErrNotMeasured):
+		return h.send(ctx, msg.Chat.ID, i18n.T(locale, "sticker.size_not_measured"), nil)
+	case err != nil:
+		return err
+	}
+
+	state.Step = stepSize
+	if err := h.save(ctx, msg.Chat.ID, state); err != nil {
+		return err
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, msg.From.ID)
+	size := i18n.Size(locale, unit, width, height)
+	text := i18n.T(locale, "sticker.size_measured", size)
+	// A size the plotter can't cut is shown with the limits, not offered
+	raw := fmt.Sprintf("%dx%d", width, height)
+	if _, _, err := validate.Size(raw, units.CM, validate.StickerSize(h.cfg)); err != nil {
+		problem, _ := validate.Message(err, locale)
+		return h.send(ctx, msg.Chat.ID, text+"\n\n"+problem, nil)
+	}
+	return h.send(ctx, msg.Chat.ID, text, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "sticker.size_confirm", size), callbackPrefix+":size:"+raw))))
+}
+
+func (h *Handler) setQuantity(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, raw string) error {
+	quantity, err := validate.Quantity(raw, h.cfg.Stickers.MaxQuantity)
+	if text, invalid := validate.Message(err, locale); invalid {
+		return h.send(ctx, chatID, text, nil)
+	}
+
+	state.Order.Sticker.Quantity = &quantity
+	return h.proceed(ctx, chatID, locale, state, stepLamination, func() error {
+		return h.askLamination(ctx, chatID, locale)
+	})
+}
+
+// proceed moves the draft on to the next step, or back to the summary when
+// the answer was a change made from it
+func (h *Handler) proceed(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, next string, ask func() error) error {
+	if dialog.Editing(state.Order) {
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	state.Step = next
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+	return ask()
+}
+
+// edit goes back to the step of one field; once it is answered the
+// summary is shown again with a new quote
+func (h *Handler) edit(ctx context.Context, chatID, userID int64, locale i18n.Locale, state *redis.UserState, field string) error {
+	var step string
+	var ask func() error
+	switch field {
+	case dialog.FieldMaterial:
+		step = stepMaterial
+		ask = func() error {
+			favorites := dialog.Preferences(ctx, h.storage, h.logger, userID).FavoriteTextures
+			return h.askMaterial(ctx, chatID, locale, favorites)
+		}
+	case dialog.FieldSize:
+		step, ask = stepSize, func() error { return h.askSize(ctx, chatID, locale) }
+	case dialog.FieldQuantity:
+		step, ask = stepQuantity, func() error { return h.askQuantity(ctx, chatID, locale) }
+	case dialog.FieldLamination:
+		step, ask = stepLamination, func() error { return h.askLamination(ctx, chatID, locale) }
+	case dialog.FieldLayout:
+		step, ask = stepPreview, func() error { return h.askPreview(ctx, chatID, locale) }
+	case dialog.FieldDelivery:
+		// The delivery steps end on the summary anyway
+		return h.askDelivery(ctx, chatID, locale, state)
+	case dialog.FieldContact:
+		step = stepContact
+		ask = func() error {
+			return h.send(ctx, chatID, i18n.T(locale, "order.ask_contact"), dialog.ContactKeyboard(locale))
+		}
+	default:
+		return nil
+	}
+
+	dialog.StartEdit(state.Order)
+	state.Step = step
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+	return ask()
+}
+
+func (h *Handler) askQuantity(ctx context.Context, chatID int64, locale i18n.Locale) error {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(quantityPresets))
+	for _, q := range quantityPresets {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(q), fmt.Sprintf("%s:qty:%d", callbackPrefix, q)))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "sticker.ask_quantity", h.cfg.Stickers.MaxQuantity), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+func (h *Handler) askLamination(ctx context.Context, chatID int64, locale i18n.Locale) error {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(pricing.Laminations))
+	for _, l := range pricing.Laminations {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(locale, "sticker.lamination."+string(l)),
+			callbackPrefix+":lam:"+string(l)))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "sticker.ask_lamination"), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+// askMaterial lists the sticker films, the customer's favorites first,
+// each with a button to see its photos
+func (h *Handler) askMaterial(ctx context.Context, chatID int64, locale i18n.Locale, favorites []string) error {
+	materials, err := h.usecase.Materials(ctx)
+	if err != nil {
+		return err
+	}
+	if len(materials) == 0 {
+		return h.send(ctx, chatID, i18n.T(locale, "sticker.no_materials"), nil)
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(materials))
+	for _, m := range dialog.FavoritesFirst(materials, favorites) {
+		label := fmt.Sprintf("%s — %s", m.Name, i18n.T(locale, "texture.price", m.PricePerDM2, cmp.Or(m.PriceCurrency, h.cfg.Currency).Symbol()))
+		label = dialog.MaterialLabel(label, favorites, m.ID)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, callbackPrefix+":mat:"+m.ID),
+			commands.TextureInfoButton(locale, m.ID)))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "sticker.choose_material"), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) askSize(ctx context.Context, chatID int64, locale i18n.Locale) error {
+	c := h.cfg.Stickers
+	// Dialogs run in private chats, where the chat is the customer
+	unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(sizePresets))
+	for _, size := range sizePresets {
+		width, height, _ := units.ParseSize(size, units.CM)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.Size(locale, unit, width, height), callbackPrefix+":size:"+size))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{row}
+	if h.sizer.Enabled() {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "sticker.size_by_photo"), callbackPrefix+":photo")))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "sticker.ask_size",
+		unit.Example(5, 5), i18n.T(locale, "unit."+string(unit)),
+		i18n.Length(locale, unit, c.MinSizeCM), i18n.Size(locale, unit, c.MaxWidthCM, c.MaxHeightCM)),
+		tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) askPreview(ctx context.Context, chatID int64, locale i18n.Locale) error {
+	return h.send(ctx, chatID, i18n.T(locale, "sticker.ask_preview"), tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "sticker.skip_preview"), callbackPrefix+":skip"))))
+}
+
+// repeat copies the size, vinyl and run of a past order into a new draft
+// and quotes it at today's prices
+func (h *Handler) repeat(ctx context.Context, chatID, userID int64, locale i18n.Locale, rawID string) error {
+	past, item, err := dialog.RepeatSource(ctx, h.storage, userID, rawID, postgres.ServiceSticker)
+	if errors.Is(err, dialog.ErrNotRepeatable) {
+		return h.send(ctx, chatID, i18n.T(locale, "order.repeat_gone"), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	selected := product
+	lamination := cmp.Or(item.Options[orders.OptionLamination], string(pricing.LaminationNone))
+	order := &redis.Order{
+		SelectedProduct: &selected,
+		Sticker: &redis.Stickers{
+			MaterialID: &item.TextureID,
+			WidthCM:    &item.WidthCM,
+			HeightCM:   &item.HeightCM,
+			Quantity:   &item.Quantity,
+			Lamination: &lamination,
+		},
+	}
+	dialog.Repeat(order, past)
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelStarted)
+	return h.showSummary(ctx, chatID, locale, &redis.UserState{Order: order})
+}
+
+// resume starts a new draft from a quote saved under /saved
+func (h *Handler) resume(ctx context.Context, chatID, userID int64, locale i18n.Locale, rawID string) error {
+	order, err := dialog.ResumeQuote(ctx, h.storage, h.cfg, userID, rawID, postgres.ServiceSticker)
+	if errors.Is(err, postgres.ErrSavedQuoteNotFound) {
+		return h.send(ctx, chatID, i18n.T(locale, "saved.gone"), nil)
+	}
+	if err != nil {
+		return err
+	}
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelStarted)
+	return h.showSummary(ctx, chatID, locale, &redis.UserState{Order: order})
+}
+
+// saveQuote keeps the draft under /saved at today's quote; the dialog
+// stays where it is
+func (h *Handler) saveQuote(ctx context.Context, chatID, userID int64, locale i18n.Locale, state *redis.UserState) error {
+	sticker := toEntity(state.Order)
+	// Dialogs run in private chats, where the chat is the customer
+	material, b, err := h.usecase.Quote(ctx, chatID, sticker, isRush(state), dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), dialog.Delivery(state.Order))
+	if _, rejected := dialog.CodeRejection(err); rejected {
+		// The summary drops the code and tells why
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+	title := i18n.T(locale, "saved.title_sticker",
+		material.Name, i18n.Size(locale, unit, sticker.WidthCM, sticker.HeightCM), sticker.Quantity)
+	text, err := dialog.SaveQuote(ctx, h.storage, h.cfg, locale, userID, postgres.ServiceSticker, title, state.Order, b.Price)
+	if err != nil {
+		return err
+	}
+	return h.send(ctx, chatID, text, nil)
+}
+
+// afterPreview goes on to the delivery, or straight to the summary when
+// the saved one was prefilled or the layout was changed from it
+func (h *Handler) afterPreview(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	if dialog.HasDelivery(state.Order) || dialog.Editing(state.Order) {
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	return h.askDelivery(ctx, chatID, locale, state)
+}
+
+// askDelivery offers the delivery methods
+func (h *Handler) askDelivery(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepDelivery
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+	text, markup := dialog.AskDelivery(locale, callbackPrefix)
+	return h.send(ctx, chatID, text, markup)
+}
+
+// showSummary quotes the draft and asks for confirmation
+func (h *Handler) showSummary(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepConfirm
+	dialog.EndEdit(state.Order)
+	dialog.PriceLapsed(state.Order, h.cfg)
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	sticker := toEntity(state.Order)
+	rush := isRush(state)
+	delivery := dialog.Delivery(state.Order)
+	// Dialogs run in private chats, where the chat is the customer
+	material, b, err := h.usecase.Quote(ctx, chatID, sticker, rush, dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), delivery)
+	// Quote without the rejected code rather than ending the dialog; the
+	// other code may be rejected too
+	for key, rejected := dialog.CodeRejection(err); rejected; key, rejected = dialog.CodeRejection(err) {
+		dialog.DropRejected(state.Order, err)
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		if err := h.send(ctx, chatID, i18n.T(locale, key), nil); err != nil {
+			return err
+		}
+		material, b, err = h.usecase.Quote(ctx, chatID, sticker, rush, dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), delivery)
+	}
+	if errors.Is(err, orders.ErrInvalidDelivery) || errors.Is(err, orders.ErrAddressRequired) {
+		return h.askDelivery(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	// The order is placed at this price while it holds
+	quote, err := h.usecase.HoldPrice(ctx, chatID, sticker, b)
+	if err != nil {
+		h.logger.Warn("Failed to hold quoted price", zap.Error(err))
+	}
+	dialog.HoldQuote(state.Order, quote)
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	preview := i18n.T(locale, "sticker.preview_none")
+	switch {
+	case sticker.PreviewID != 0:
+		preview = i18n.T(locale, "sticker.preview_attached")
+	case sticker.RepeatOf != 0:
+		preview = i18n.T(locale, "order.repeat_layout", dialog.RepeatCode(state.Order))
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+	text := i18n.T(locale, "sticker.summary",
+		material.Name, i18n.Size(locale, unit, sticker.WidthCM, sticker.HeightCM), sticker.Quantity,
+		i18n.T(locale, "sticker.lamination."+string(sticker.Lamination)), preview,
+		b.AreaDM2, b.Price, b.ReadyBy.Format("02.01.2006"))
+	if discount := pricing.BulkDiscount(sticker.Quantity); discount > 0 {
+		text += "\n" + i18n.T(locale, "order.discount", discount*100)
+	}
+	if promo := b.Discount - b.LoyaltyDiscount; promo > 0 {
+		text += "\n" + i18n.T(locale, "order.promo_applied", dialog.PromoCode(state.Order), promo)
+	}
+	if b.LoyaltyDiscount > 0 {
+		text += "\n" + i18n.T(locale, "order.loyalty_applied", html.EscapeString(b.LoyaltyTier), b.LoyaltyDiscount)
+	}
+	if line := dialog.RushSummary(locale, b); line != "" {
+		text += "\n" + line
+	}
+	if line := dialog.GiftCardSummary(locale, state.Order, b); line != "" {
+		text += "\n" + line
+	}
+	text += "\n" + dialog.DeliverySummary(locale, delivery, b.Shipping)
+	if line := dialog.ContactSummary(ctx, h.phones, h.logger, locale, chatID); line != "" {
+		text += "\n" + line
+	}
+	if diff := dialog.RepeatDifference(locale, state.Order, b); diff != "" {
+		text += "\n\n" + diff
+	}
+	if note := dialog.SavedQuoteSummary(locale, state.Order, h.cfg); note != "" {
+		text += "\n\n" + note
+	}
+	if note := dialog.QuoteSummary(locale, quote); note != "" {
+		text += "\n" + note
+	}
+	if note := dialog.ApprovalSummary(locale, h.cfg, b); note != "" {
+		text += "\n" + note
+	}
+
+	rushLabel := i18n.T(locale, "order.rush_off")
+	if rush {
+		rushLabel = i18n.T(locale, "order.rush_on")
+	}
+
+	last := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.cancel"), callbackPrefix+":cancel"))
+	// A resumed quote is already saved
+	if dialog.SavedQuote(state.Order) == 0 {
+		last = append(last, tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "saved.save_button"), callbackPrefix+":savequote"))
+	}
+
+	// Dialogs run in private chats, where the chat is the customer
+	return h.send(ctx, chatID, text, dialog.SummaryKeyboard(ctx, h.experiments, locale, chatID, callbackPrefix,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(rushLabel, callbackPrefix+":rush"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.promo_button"), callbackPrefix+":promo")),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.edit"), callbackPrefix+":edit"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "delivery.change"), callbackPrefix+":delivery")),
+		last,
+	))
+}
+
+func (h *Handler) place(ctx context.Context, chatID, userID int64, locale i18n.Locale, state *redis.UserState, contact, key string, allowDuplicate bool) error {
+	if dialog.PriceLapsed(state.Order, h.cfg) {
+		// The saved price ran out since the summary: show today's first
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+
+	order, err := h.usecase.Place(ctx, userID, toEntity(state.Order), contact, isRush(state),
+		dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), dialog.Delivery(state.Order), key, allowDuplicate)
+	if _, rejected := dialog.CodeRejection(err); rejected {
+		// A code ran out after the quote: show the price without it
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if text, ok := dialog.QuoteChanged(err, locale); ok {
+		// The summary's price ran out and today's differs: show it first
+		if err := h.send(ctx, chatID, text, nil); err != nil {
+			return err
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if text, markup, ok := dialog.RepeatedOrder(err, locale, callbackPrefix); ok {
+		state.Step = stepConfirm
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, text, markup)
+	}
+	if errors.Is(err, orders.ErrInvalidDelivery) || errors.Is(err, orders.ErrAddressRequired) {
+		return h.askDelivery(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	if err := h.redis.DropUserDialogState(ctx, chatID); err != nil {
+		h.logger.Warn("Failed to drop sticker draft", zap.Error(err))
+	}
+
+	dialog.ForgetQuote(ctx, h.storage, h.logger, userID, state.Order)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelPlaced)
+	h.logger.Info("Sticker order placed",
+		zap.Int64("order_id", order.ID),
+		zap.Int64("user_id", userID),
+		zap.Int("quantity", order.Quantity))
+
+	// The contact keyboard may still be open
+	return h.send(ctx, chatID, dialog.Placed(locale, "sticker.placed", order), tgbotapi.NewRemoveKeyboard(true))
+}
+
+// orderError explains a rejected quote; unexpected errors go to the bot log
+func (h *Handler) orderError(ctx context.Context, chatID int64, locale i18n.Locale, err error) error {
+	var key string
+	switch {
+	case errors.Is(err, orders.ErrTextureUnavailable):
+		key = "sticker.unavailable"
+		if dropErr := h.redis.DropUserDialogState(ctx, chatID); dropErr != nil {
+			h.logger.Warn("Failed to drop sticker draft", zap.Error(dropErr))
+		}
+		dialog.Discard(ctx, h.storage, h.logger, chatID)
+	case errors.Is(err, orders.ErrTooLarge), errors.Is(err, orders.ErrInvalidDimensions),
+		errors.Is(err, orders.ErrInvalidQuantity), errors.Is(err, orders.ErrInvalidOptions):
+		key = "sticker.invalid"
+	default:
+		_ = h.send(ctx, chatID, i18n.T(locale, "error.generic"), nil)
+		return err
+	}
+	return h.send(ctx, chatID, i18n.T(locale, key), nil)
+}
+
+// draft returns the dialog state when a sticker order is in progress
+func (h *Handler) draft(ctx context.Context, chatID int64) (*redis.UserState, bool, error) {
+	state, err := h.redis.GetUserDialogState(ctx, chatID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	order := state.Order
+	if order == nil || order.SelectedProduct == nil || *order.SelectedProduct != product ||
+		order.Sticker == nil || !strings.HasPrefix(state.Step, "sticker_") {
+		return nil, false, nil
+	}
+	return state, true, nil
+}
+
+// save keeps the draft and counts the step it is at in the funnel. Dialogs
+// run in private chats, so the chat is the customer.
+func (h *Handler) save(ctx context.Context, chatID int64, state *redis.UserState) error {
+	if err := h.redis.SetUserDialogState(ctx, chatID, state); err != nil {
+		return err
+	}
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, state.Step)
+	return nil
+}
+
+func (h *Handler) locale(ctx context.Context, userID int64) i18n.Locale {
+	return dialog.Locale(ctx, h.storage, h.logger, userID)
+}
+
+// send posts the message and keeps it in the transcript of the draft
+func (h *Handler) send(ctx context.Context, chatID int64, text string, markup any) error {
+	if err := dialog.Send(h.botAPI, chatID, text, markup); err != nil {
+		return err
+	}
+	dialog.Record(ctx, h.redis, h.storage, h.logger, chatID, text)
+	return nil
+}
+
+func toEntity(order *redis.Order) entity.Sticker {
+	s := order.Sticker
+	sticker := entity.Sticker{
+		RepeatOf:   dialog.RepeatOf(order),
+		PricedAt:   dialog.PricedAt(order),
+		QuoteID:    dialog.QuoteID(order),
+		VoiceNotes: order.VoiceNotes,
+	}
+	if s.MaterialID != nil {
+		sticker.MaterialID = *s.MaterialID
+	}
+	if s.WidthCM != nil {
+		sticker.WidthCM = *s.WidthCM
+	}
+	if s.HeightCM != nil {
+		sticker.HeightCM = *s.HeightCM
+	}
+	if s.Quantity != nil {
+		sticker.Quantity = *s.Quantity
+	}
+	if s.Lamination != nil {
+		sticker.Lamination = pricing.Lamination(*s.Lamination)
+	}
+	if s.PreviewID != nil {
+		sticker.PreviewID = *s.PreviewID
+	}
+	return sticker
+}
+
+func isRush(state *redis.UserState) bool {
+	return state.Order.Rush != nil && *state.Order.Rush
+}
@@ -1,7 +1,28 @@
 package entity
 
+import (
+	"s1ntez/internal/pricing"
+	"time"
+)
+
+// Sticker is a run of identical stickers as configured in the dialog
 type Sticker struct {
-	Id    int
-	Name  string
-	Order string
+	MaterialID string
+	WidthCM    int
+	HeightCM   int
+	Quantity   int
+	Lamination pricing.Lamination
+	// PreviewID is the uploaded layout, 0 when the customer skipped it
+	PreviewID int64
+	// RepeatOf is the past order this one repeats, whose layout is reused
+	RepeatOf int64
+	// PricedAt is the moment of a saved quote whose price holds, zero to
+	// price at today's rates
+	PricedAt time.Time
+	// QuoteID is the price quote of the summary the customer confirms,
+	// 0 to price the order on confirmation
+	QuoteID int64
+	// VoiceNotes are the customer's voice messages (attachment IDs) sent
+	// along the dialog
+	VoiceNotes []int64
 }
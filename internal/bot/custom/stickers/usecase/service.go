@@ -1,8 +1,102 @@
 package usecase
 
-type ISticker interface {
-	CreateSticker()
-	ReadSticker()
-	UpdateSticker()
-	DeleteSticker()
+import (
+	"context"
+	"s1ntez/internal/bot/custom/stickers/entity"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/uploads"
+	"s1ntez/pkg/objectstore"
+	"strconv"
+	"time"
+)
+
+// PreviewContentTypes are the accepted layout formats
+var PreviewContentTypes = []string{"image/png", "image/jpeg", "image/webp", "application/pdf"}
+
+// Usecase runs sticker orders through the common order pipeline
+type Usecase struct {
+	orders  *orders.Service
+	storage *postgres.PostgresStorage
+	// files is nil when no object store is configured
+	files *objectstore.Client
+}
+
+func New(orderService *orders.Service, storage *postgres.PostgresStorage, files *objectstore.Client) *Usecase {
+	return &Usecase{
+		orders:  orderService,
+		storage: storage,
+		files:   files,
+	}
+}
+
+// Materials lists the vinyl films that can be ordered
+func (u *Usecase) Materials(ctx context.Context) ([]postgres.Texture, error) {
+	return u.storage.GetMaterials(ctx, postgres.ServiceSticker)
+}
+
+// Quote prices the run without saving it. userID is needed for the
+// per-customer limit of the promo code.
+func (u *Usecase) Quote(ctx context.Context, userID int64, sticker entity.Sticker, rush bool, promoCode, giftCard string, delivery orders.Delivery) (*postgres.Texture, pricing.Breakdown, error) {
+	req := request(userID, sticker)
+	req.Rush = rush
+	req.PromoCode = promoCode
+	req.GiftCard = giftCard
+	req.Delivery = delivery
+	return u.orders.Quote(ctx, req, time.Now())
+}
+
+// HoldPrice keeps the quoted price of the run for the customer; the order is
+// placed at it while it holds
+func (u *Usecase) HoldPrice(ctx context.Context, userID int64, sticker entity.Sticker, b pricing.Breakdown) (*postgres.PriceQuote, error) {
+	return u.orders.HoldPrice(ctx, userID, postgres.ServiceSticker, sticker.PricedAt, b)
+}
+
+// SavePreview stores an uploaded layout and returns its attachment ID
+func (u *Usecase) SavePreview(ctx context.Context, userID int64, preview uploads.File) (int64, error) {
+	return uploads.Save(ctx, u.storage, u.files, userID, preview, PreviewContentTypes)
+}
+
+// Place creates the order. idempotencyKey identifies the confirmation;
+// allowDuplicate is set once the customer confirmed a repeated order.
+func (u *Usecase) Place(ctx context.Context, userID int64, sticker entity.Sticker, contact string, rush bool, promoCode, giftCard string, delivery orders.Delivery, idempotencyKey string, allowDuplicate bool) (*postgres.Order, error) {
+	req := request(userID, sticker)
+	req.Contact = contact
+	req.Rush = rush
+	req.PromoCode = promoCode
+	req.GiftCard = giftCard
+	req.Delivery = delivery
+	req.IdempotencyKey = idempotencyKey
+	req.AllowDuplicate = allowDuplicate
+	if sticker.PreviewID != 0 {
+		req.AttachmentIDs = []int64{sticker.PreviewID}
+	}
+	req.AttachmentIDs = append(req.AttachmentIDs, sticker.VoiceNotes...)
+	return u.orders.Place(ctx, req)
+}
+
+func request(userID int64, sticker entity.Sticker) orders.Request {
+	lamination := sticker.Lamination
+	if lamination == "" {
+		lamination = pricing.LaminationNone
+	}
+
+	req := orders.Request{
+		UserID:      userID,
+		WidthCM:     sticker.WidthCM,
+		HeightCM:    sticker.HeightCM,
+		TextureID:   sticker.MaterialID,
+		ServiceType: postgres.ServiceSticker,
+		Quantity:    sticker.Quantity,
+		Options: postgres.ProductOptions{
+			orders.OptionLamination: string(lamination),
+		},
+	}
+	if sticker.RepeatOf != 0 {
+		req.Options[orders.OptionRepeatOf] = strconv.FormatInt(sticker.RepeatOf, 10)
+	}
+	req.PricedAt = sticker.PricedAt
+	req.QuoteID = sticker.QuoteID
+	return req
 }
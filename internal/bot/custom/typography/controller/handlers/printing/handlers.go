@@ -1 +1,937 @@
-package admin
+package printing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/bot/base/controller/handlers/commands"
+	"s1ntez/internal/bot/custom/dialog"
+	"s1ntez/internal/bot/custom/typography/entity"
+	"s1ntez/internal/bot/custom/typography/usecase"
+	"s1ntez/internal/bot/validate"
+	"s1ntez/internal/config"
+	"s1ntez/internal/experiments"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/uploads"
+	"s1ntez/internal/verification"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	callbackPrefix = "print"
+	product        = "typography"
+
+	stepProduct     = "print_product"
+	stepFormat      = "print_format"
+	stepSize        = "print_size"
+	stepMaterial    = "print_material"
+	stepSides       = "print_sides"
+	stepQuantity    = "print_quantity"
+	stepLayout      = "print_layout"
+	stepConfirm     = "print_confirm"
+	stepContact     = "print_contact"
+	stepContactCode = "print_contact_code"
+	stepPromo       = "print_promo"
+	stepDelivery    = "print_delivery"
+	stepAddress     = "print_address"
+)
+
+// Handler walks the customer through a print order:
+// product → format → paper → sides → quantity → print file → delivery →
+// confirmation.
+// The draft lives in the Redis dialog state. A delivery saved under
+// /profile is prefilled and skips its step. "Repeat this order" under
+// /myorders fills in a whole past order and goes to the confirmation.
+// "Edit" on the summary goes back to any one step and returns to it.
+// "Save for later" keeps the quote under /saved, whose "Order" button
+// resumes it at the confirmation.
+type Handler struct {
+	logger  *zap.Logger
+	botAPI  *tgbotapi.BotAPI
+	redis   *redis.Storage
+	storage *postgres.PostgresStorage
+	usecase *usecase.Usecase
+	phones  *verification.Service
+	// experiments decide the summary keyboard
+	experiments *experiments.Service
+	cfg         *config.Config
+}
+
+func New(
+	logger *zap.Logger,
+	botAPI *tgbotapi.BotAPI,
+	redis *redis.Storage,
+	storage *postgres.PostgresStorage,
+	usecase *usecase.Usecase,
+	phones *verification.Service,
+	experiments *experiments.Service,
+	cfg *config.Config,
+) *Handler {
+	return &Handler{
+		logger:      logger,
+		botAPI:      botAPI,
+		redis:       redis,
+		storage:     storage,
+		usecase:     usecase,
+		phones:      phones,
+		experiments: experiments,
+		cfg:         cfg,
+	}
+}
+
+// Handle starts a new print order with /print
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	locale := h.locale(ctx, msg.From.ID)
+
+	selected := product
+	state := &redis.UserState{
+		Step:  stepProduct,
+		Order: &redis.Order{SelectedProduct: &selected, Typography: &redis.Typography{}},
+	}
+	dialog.Prefill(state.Order, dialog.Preferences(ctx, h.storage, h.logger, msg.From.ID))
+	dialog.Discard(ctx, h.storage, h.logger, msg.Chat.ID)
+	dialog.Track(ctx, h.storage, h.logger, msg.Chat.ID, callbackPrefix, postgres.FunnelStarted)
+	if err := h.save(ctx, msg.Chat.ID, state); err != nil {
+		return err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(usecase.Products))
+	for _, p := range usecase.Products {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(locale, "print.product."+string(p)), callbackPrefix+":prod:"+string(p))))
+	}
+	return h.send(ctx, msg.Chat.ID, i18n.T(locale, "print.choose_product"), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	chatID := query.Message.Chat.ID
+	locale := h.locale(ctx, query.From.ID)
+
+	// A repeat from /myorders starts a draft of its own
+	if rawID, ok := strings.CutPrefix(query.Data, callbackPrefix+":repeat:"); ok {
+		return h.repeat(ctx, chatID, query.From.ID, locale, rawID)
+	}
+	// So does a quote saved under /saved
+	if rawID, ok := strings.CutPrefix(query.Data, callbackPrefix+":saved:"); ok {
+		return h.resume(ctx, chatID, query.From.ID, locale, rawID)
+	}
+
+	state, ok, err := h.draft(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return h.send(ctx, chatID, i18n.T(locale, "print.expired"), nil)
+	}
+	draft := state.Order.Typography
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) < 2 {
+		return nil
+	}
+	arg := ""
+	if len(parts) > 2 {
+		arg = parts[2]
+	}
+
+	switch parts[1] {
+	case "prod":
+		if _, known := usecase.Catalog[entity.Product(arg)]; state.Step != stepProduct || !known {
+			return nil
+		}
+		draft.Product = &arg
+		state.Step = stepFormat
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.askFormat(ctx, chatID, locale, entity.Product(arg))
+
+	case "fmt":
+		if state.Step != stepFormat {
+			return nil
+		}
+		spec := usecase.Catalog[entity.Product(*draft.Product)]
+		if arg == usecase.FormatCustom && spec.CustomSize {
+			draft.Format = &arg
+			state.Step = stepSize
+			if err := h.save(ctx, chatID, state); err != nil {
+				return err
+			}
+			// Dialogs run in private chats, where the chat is the customer
+			unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+			return h.send(ctx, chatID, i18n.T(locale, "print.ask_size",
+				unit.Example(70, 50), i18n.T(locale, "unit."+string(unit)),
+				i18n.Size(locale, unit, h.cfg.MaxDimensions.Width, h.cfg.MaxDimensions.Height)), nil)
+		}
+		if _, known := spec.Format(arg); !known {
+			return nil
+		}
+		draft.Format = &arg
+		return h.next(ctx, chatID, locale, state, func() error {
+			return h.askMaterial(ctx, chatID, locale, state)
+		})
+
+	case "mat":
+		if state.Step != stepMaterial {
+			return nil
+		}
+		draft.MaterialID = &arg
+		if !usecase.Catalog[entity.Product(*draft.Product)].DoubleSided {
+			one := 1
+			draft.Sides = &one
+			return h.next(ctx, chatID, locale, state, func() error {
+				return h.askQuantity(ctx, chatID, locale, state)
+			})
+		}
+		return h.next(ctx, chatID, locale, state, func() error {
+			return h.askSides(ctx, chatID, locale, state)
+		})
+
+	case "sides":
+		sides, err := strconv.Atoi(arg)
+		if state.Step != stepSides || err != nil || sides < 1 || sides > 2 {
+			return nil
+		}
+		draft.Sides = &sides
+		return h.next(ctx, chatID, locale, state, func() error {
+			return h.askQuantity(ctx, chatID, locale, state)
+		})
+
+	case "qty":
+		if state.Step != stepQuantity {
+			return nil
+		}
+		return h.setQuantity(ctx, chatID, locale, state, arg)
+
+	case "skip":
+		if state.Step != stepLayout {
+			return nil
+		}
+		// Skipping while editing drops the file sent before
+		draft.LayoutID = nil
+		return h.afterLayout(ctx, chatID, locale, state)
+
+	case "dlv":
+		if state.Step != stepDelivery {
+			return nil
+		}
+		needsAddress, ok := dialog.SetDeliveryMethod(state.Order, arg)
+		if !ok {
+			return nil
+		}
+		if needsAddress {
+			state.Step = stepAddress
+			if err := h.save(ctx, chatID, state); err != nil {
+				return err
+			}
+			return h.send(ctx, chatID, i18n.T(locale, "delivery.ask_address"), nil)
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "delivery":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		return h.askDelivery(ctx, chatID, locale, state)
+
+	case "edit":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		if arg == "" {
+			return h.send(ctx, chatID, i18n.T(locale, "edit.prompt"),
+				dialog.EditKeyboard(locale, callbackPrefix, editFields(entity.Product(*draft.Product))))
+		}
+		return h.edit(ctx, chatID, locale, state, arg)
+
+	case "summary":
+		if state.Step != stepConfirm && !dialog.Editing(state.Order) {
+			return nil
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "rush":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		rush := !isRush(state)
+		state.Order.Rush = &rush
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "savequote":
+		if state.Step != stepConfirm || dialog.SavedQuote(state.Order) != 0 {
+			return nil
+		}
+		return h.saveQuote(ctx, chatID, query.From.ID, locale, state)
+
+	case "promo":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		state.Step = stepPromo
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, i18n.T(locale, "order.ask_promo"), tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.promo_skip"), callbackPrefix+":nopromo"))))
+
+	case "nopromo":
+		if state.Step != stepPromo {
+			return nil
+		}
+		state.Order.PromoCode, state.Order.GiftCard = nil, nil
+		return h.showSummary(ctx, chatID, locale, state)
+
+	case "confirm", "again":
+		if state.Step != stepConfirm {
+			return nil
+		}
+		phone, err := h.phones.Known(ctx, query.From.ID)
+		if err != nil {
+			h.logger.Warn("Failed to get user contact", zap.Error(err))
+		}
+		if phone == "" {
+			state.Step = stepContact
+			if err := h.save(ctx, chatID, state); err != nil {
+				return err
+			}
+			return h.send(ctx, chatID, i18n.T(locale, "order.ask_contact"), dialog.ContactKeyboard(locale))
+		}
+		// One confirmation per summary message, however often it's pressed;
+		// "again" comes from the repeated order prompt, a message of its own
+		key := fmt.Sprintf("tg:print:%d:%d", chatID, query.Message.MessageID)
+		return h.place(ctx, chatID, query.From.ID, locale, state, phone, key, parts[1] == "again")
+
+	case "cancel":
+		if err := h.redis.DropUserDialogState(ctx, chatID); err != nil {
+			return err
+		}
+		dialog.Discard(ctx, h.storage, h.logger, chatID)
+		return h.send(ctx, chatID, i18n.T(locale, "print.cancelled"), nil)
+	}
+
+	return nil
+}
+
+// HandleMessage takes typed answers and the print file of an active draft
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if !msg.Chat.IsPrivate() {
+		return false, nil
+	}
+
+	state, ok, err := h.draft(ctx, msg.Chat.ID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	locale := h.locale(ctx, msg.From.ID)
+	text := strings.TrimSpace(msg.Text)
+
+	switch state.Step {
+	case stepSize:
+		unit := dialog.Unit(ctx, h.storage, h.logger, msg.From.ID)
+		width, height, err := validate.Size(text, unit, validate.PrintSize(h.cfg))
+		if problem, invalid := validate.Message(err, locale); invalid {
+			return true, h.send(ctx, msg.Chat.ID, problem, nil)
+		}
+		state.Order.Typography.WidthCM = &width
+		state.Order.Typography.HeightCM = &height
+		return true, h.next(ctx, msg.Chat.ID, locale, state, func() error {
+			return h.askMaterial(ctx, msg.Chat.ID, locale, state)
+		})
+
+	case stepQuantity:
+		return true, h.setQuantity(ctx, msg.Chat.ID, locale, state, text)
+
+	case stepLayout:
+		var id int64
+		layout, err := uploads.Download(ctx, h.botAPI, msg, h.cfg.Typography.MaxLayoutBytes)
+		if err == nil {
+			id, err = h.usecase.SaveLayout(ctx, msg.From.ID, *layout)
+		}
+
+		switch {
+		case errors.Is(err, uploads.ErrNoFile):
+			return true, h.askLayout(ctx, msg.Chat.ID, locale)
+		case errors.Is(err, uploads.ErrTooLarge):
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "print.layout_too_large",
+				h.cfg.Typography.MaxLayoutBytes>>20), nil)
+		case errors.Is(err, uploads.ErrFileFormat):
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "print.layout_format"), nil)
+		case err != nil:
+			return true, err
+		}
+
+		state.Order.Typography.LayoutID = &id
+		return true, h.afterLayout(ctx, msg.Chat.ID, locale, state)
+
+	case stepAddress:
+		if !dialog.SetDeliveryAddress(state.Order, text) {
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "delivery.bad_address"), nil)
+		}
+		return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+
+	case stepPromo:
+		dialog.SetCode(state.Order, text)
+		return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+
+	case stepContact, stepContactCode:
+		answer, err := dialog.ReadContact(ctx, h.phones, msg, state.Step == stepContactCode)
+		switch {
+		case err != nil:
+			return true, err
+		case answer.Problem != "":
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, answer.Problem), nil)
+		case answer.NeedsCode:
+			state.Step = stepContactCode
+			if err := h.save(ctx, msg.Chat.ID, state); err != nil {
+				return true, err
+			}
+			return true, h.send(ctx, msg.Chat.ID, i18n.T(locale, "order.ask_code"), tgbotapi.NewRemoveKeyboard(true))
+		}
+		if dialog.Editing(state.Order) {
+			// The contact keyboard may still be open
+			if err := h.send(ctx, msg.Chat.ID, i18n.T(locale, "order.contact_saved"), tgbotapi.NewRemoveKeyboard(true)); err != nil {
+				return true, err
+			}
+			return true, h.showSummary(ctx, msg.Chat.ID, locale, state)
+		}
+		key := fmt.Sprintf("tg:print:%d:%d", msg.Chat.ID, msg.MessageID)
+		return true, h.place(ctx, msg.Chat.ID, msg.From.ID, locale, state, answer.Phone, key, false)
+	}
+
+	// Buttons are expected on the other steps
+	return false, nil
+}
+
+func (h *Handler) askFormat(ctx context.Context, chatID int64, locale i18n.Locale, p entity.Product) error {
+	spec := usecase.Catalog[p]
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(spec.Formats)+1)
+	for _, f := range spec.Formats {
+		label := fmt.Sprintf("%s (%d×%d)", f.Code, f.WidthCM, f.HeightCM)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, callbackPrefix+":fmt:"+f.Code))
+	}
+	if spec.CustomSize {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(locale, "print.format.custom"), callbackPrefix+":fmt:"+usecase.FormatCustom))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "print.choose_format"), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+func (h *Handler) askMaterial(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	materials, err := h.usecase.Materials(ctx, entity.Product(*state.Order.Typography.Product))
+	if err != nil {
+		return err
+	}
+	if len(materials) == 0 {
+		if err := h.redis.DropUserDialogState(ctx, chatID); err != nil {
+			h.logger.Warn("Failed to drop print draft", zap.Error(err))
+		}
+		dialog.Discard(ctx, h.storage, h.logger, chatID)
+		return h.send(ctx, chatID, i18n.T(locale, "print.no_materials"), nil)
+	}
+
+	state.Step = stepMaterial
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	// Dialogs run in private chats, where the chat is the customer
+	favorites := dialog.Preferences(ctx, h.storage, h.logger, chatID).FavoriteTextures
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(materials))
+	for _, m := range dialog.FavoritesFirst(materials, favorites) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(dialog.MaterialLabel(m.Name, favorites, m.ID), callbackPrefix+":mat:"+m.ID),
+			commands.TextureInfoButton(locale, m.ID)))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "print.choose_material"), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (h *Handler) askQuantity(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepQuantity
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	spec := usecase.Catalog[entity.Product(*state.Order.Typography.Product)]
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(spec.Quantities))
+	for _, q := range spec.Quantities {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(q), fmt.Sprintf("%s:qty:%d", callbackPrefix, q)))
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "print.ask_quantity", h.cfg.Typography.MaxQuantity), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+func (h *Handler) setQuantity(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, raw string) error {
+	quantity, err := validate.Quantity(raw, h.cfg.Typography.MaxQuantity)
+	if text, invalid := validate.Message(err, locale); invalid {
+		return h.send(ctx, chatID, text, nil)
+	}
+
+	state.Order.Typography.Quantity = &quantity
+	return h.next(ctx, chatID, locale, state, func() error {
+		state.Step = stepLayout
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.askLayout(ctx, chatID, locale)
+	})
+}
+
+func (h *Handler) askSides(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepSides
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+	return h.send(ctx, chatID, i18n.T(locale, "print.ask_sides"), tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "print.sides.1"), callbackPrefix+":sides:1"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "print.sides.2"), callbackPrefix+":sides:2"))))
+}
+
+// next asks the following question, or shows the summary again when the
+// answer was a change made from it
+func (h *Handler) next(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, ask func() error) error {
+	if dialog.Editing(state.Order) {
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	return ask()
+}
+
+// editFields can be changed from the summary; the product itself can't, a
+// different one is a new order
+func editFields(p entity.Product) []string {
+	fields := []string{dialog.FieldFormat, dialog.FieldMaterial}
+	if usecase.Catalog[p].DoubleSided {
+		fields = append(fields, dialog.FieldSides)
+	}
+	return append(fields, dialog.FieldQuantity, dialog.FieldLayout, dialog.FieldDelivery, dialog.FieldContact)
+}
+
+// edit goes back to the step of one field; once it is answered the
+// summary is shown again with a new quote. A custom format asks for the
+// size before returning.
+func (h *Handler) edit(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState, field string) error {
+	if !slices.Contains(editFields(entity.Product(*state.Order.Typography.Product)), field) {
+		return nil
+	}
+	dialog.StartEdit(state.Order)
+
+	switch field {
+	case dialog.FieldFormat:
+		state.Step = stepFormat
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.askFormat(ctx, chatID, locale, entity.Product(*state.Order.Typography.Product))
+	case dialog.FieldMaterial:
+		return h.askMaterial(ctx, chatID, locale, state)
+	case dialog.FieldSides:
+		return h.askSides(ctx, chatID, locale, state)
+	case dialog.FieldQuantity:
+		return h.askQuantity(ctx, chatID, locale, state)
+	case dialog.FieldLayout:
+		state.Step = stepLayout
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.askLayout(ctx, chatID, locale)
+	case dialog.FieldDelivery:
+		// The delivery steps end on the summary anyway
+		return h.askDelivery(ctx, chatID, locale, state)
+	default:
+		state.Step = stepContact
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, i18n.T(locale, "order.ask_contact"), dialog.ContactKeyboard(locale))
+	}
+}
+
+func (h *Handler) askLayout(ctx context.Context, chatID int64, locale i18n.Locale) error {
+	return h.send(ctx, chatID, i18n.T(locale, "print.ask_layout"), tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "print.skip_layout"), callbackPrefix+":skip"))))
+}
+
+// repeat copies the product, format, paper and run of a past order into a
+// new draft and quotes it at today's prices
+func (h *Handler) repeat(ctx context.Context, chatID, userID int64, locale i18n.Locale, rawID string) error {
+	past, item, err := dialog.RepeatSource(ctx, h.storage, userID, rawID, postgres.ServiceTypography)
+	if errors.Is(err, dialog.ErrNotRepeatable) {
+		return h.send(ctx, chatID, i18n.T(locale, "order.repeat_gone"), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	selected := product
+	printProduct, format := item.Options[orders.OptionProduct], item.Options[usecase.OptionFormat]
+	sides, err := strconv.Atoi(item.Options[orders.OptionSides])
+	if err != nil {
+		sides = 1
+	}
+	order := &redis.Order{
+		SelectedProduct: &selected,
+		Typography: &redis.Typography{
+			Product:    &printProduct,
+			Format:     &format,
+			WidthCM:    &item.WidthCM,
+			HeightCM:   &item.HeightCM,
+			MaterialID: &item.TextureID,
+			Sides:      &sides,
+			Quantity:   &item.Quantity,
+		},
+	}
+	dialog.Repeat(order, past)
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelStarted)
+	return h.showSummary(ctx, chatID, locale, &redis.UserState{Order: order})
+}
+
+// resume starts a new draft from a quote saved under /saved
+func (h *Handler) resume(ctx context.Context, chatID, userID int64, locale i18n.Locale, rawID string) error {
+	order, err := dialog.ResumeQuote(ctx, h.storage, h.cfg, userID, rawID, postgres.ServiceTypography)
+	if errors.Is(err, postgres.ErrSavedQuoteNotFound) {
+		return h.send(ctx, chatID, i18n.T(locale, "saved.gone"), nil)
+	}
+	if err != nil {
+		return err
+	}
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelStarted)
+	return h.showSummary(ctx, chatID, locale, &redis.UserState{Order: order})
+}
+
+// saveQuote keeps the draft under /saved at today's quote; the dialog
+// stays where it is
+func (h *Handler) saveQuote(ctx context.Context, chatID, userID int64, locale i18n.Locale, state *redis.UserState) error {
+	spec := toEntity(state.Order)
+	// Dialogs run in private chats, where the chat is the customer
+	material, b, err := h.usecase.Quote(ctx, chatID, spec, isRush(state), dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), dialog.Delivery(state.Order))
+	if _, rejected := dialog.CodeRejection(err); rejected {
+		// The summary drops the code and tells why
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	width, height := spec.WidthCM, spec.HeightCM
+	if format, ok := usecase.Catalog[spec.Product].Format(spec.Format); ok {
+		width, height = format.WidthCM, format.HeightCM
+	}
+	unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+	title := i18n.T(locale, "saved.title_print", i18n.T(locale, "print.product."+string(spec.Product)),
+		i18n.Size(locale, unit, width, height), material.Name, spec.Quantity)
+	text, err := dialog.SaveQuote(ctx, h.storage, h.cfg, locale, userID, postgres.ServiceTypography, title, state.Order, b.Price)
+	if err != nil {
+		return err
+	}
+	return h.send(ctx, chatID, text, nil)
+}
+
+// afterLayout goes on to the delivery, or straight to the summary when
+// the saved one was prefilled or the layout was changed from it
+func (h *Handler) afterLayout(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	if dialog.HasDelivery(state.Order) || dialog.Editing(state.Order) {
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	return h.askDelivery(ctx, chatID, locale, state)
+}
+
+// askDelivery offers the delivery methods
+func (h *Handler) askDelivery(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepDelivery
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+	text, markup := dialog.AskDelivery(locale, callbackPrefix)
+	return h.send(ctx, chatID, text, markup)
+}
+
+// showSummary quotes the draft and asks for confirmation
+func (h *Handler) showSummary(ctx context.Context, chatID int64, locale i18n.Locale, state *redis.UserState) error {
+	state.Step = stepConfirm
+	dialog.EndEdit(state.Order)
+	dialog.PriceLapsed(state.Order, h.cfg)
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	spec := toEntity(state.Order)
+	rush := isRush(state)
+	delivery := dialog.Delivery(state.Order)
+	// Dialogs run in private chats, where the chat is the customer
+	material, b, err := h.usecase.Quote(ctx, chatID, spec, rush, dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), delivery)
+	// Quote without the rejected code rather than ending the dialog; the
+	// other code may be rejected too
+	for key, rejected := dialog.CodeRejection(err); rejected; key, rejected = dialog.CodeRejection(err) {
+		dialog.DropRejected(state.Order, err)
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		if err := h.send(ctx, chatID, i18n.T(locale, key), nil); err != nil {
+			return err
+		}
+		material, b, err = h.usecase.Quote(ctx, chatID, spec, rush, dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), delivery)
+	}
+	if errors.Is(err, orders.ErrInvalidDelivery) || errors.Is(err, orders.ErrAddressRequired) {
+		return h.askDelivery(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	// The order is placed at this price while it holds
+	quote, err := h.usecase.HoldPrice(ctx, chatID, spec, b)
+	if err != nil {
+		h.logger.Warn("Failed to hold quoted price", zap.Error(err))
+	}
+	dialog.HoldQuote(state.Order, quote)
+	if err := h.save(ctx, chatID, state); err != nil {
+		return err
+	}
+
+	width, height := spec.WidthCM, spec.HeightCM
+	if format, ok := usecase.Catalog[spec.Product].Format(spec.Format); ok {
+		width, height = format.WidthCM, format.HeightCM
+	}
+
+	layout := i18n.T(locale, "print.layout_needed")
+	switch {
+	case spec.LayoutID != 0:
+		layout = i18n.T(locale, "print.layout_attached")
+	case spec.RepeatOf != 0:
+		layout = i18n.T(locale, "order.repeat_layout", dialog.RepeatCode(state.Order))
+	}
+
+	unit := dialog.Unit(ctx, h.storage, h.logger, chatID)
+	text := i18n.T(locale, "print.summary",
+		i18n.T(locale, "print.product."+string(spec.Product)), i18n.Size(locale, unit, width, height), material.Name,
+		i18n.T(locale, "print.sides."+strconv.Itoa(max(spec.Sides, 1))), spec.Quantity, layout,
+		b.Price, b.ReadyBy.Format("02.01.2006"))
+	if discount := pricing.BulkDiscount(spec.Quantity); discount > 0 {
+		text += "\n" + i18n.T(locale, "order.discount", discount*100)
+	}
+	if promo := b.Discount - b.LoyaltyDiscount; promo > 0 {
+		text += "\n" + i18n.T(locale, "order.promo_applied", dialog.PromoCode(state.Order), promo)
+	}
+	if b.LoyaltyDiscount > 0 {
+		text += "\n" + i18n.T(locale, "order.loyalty_applied", html.EscapeString(b.LoyaltyTier), b.LoyaltyDiscount)
+	}
+	if line := dialog.RushSummary(locale, b); line != "" {
+		text += "\n" + line
+	}
+	if line := dialog.GiftCardSummary(locale, state.Order, b); line != "" {
+		text += "\n" + line
+	}
+	text += "\n" + dialog.DeliverySummary(locale, delivery, b.Shipping)
+	if line := dialog.ContactSummary(ctx, h.phones, h.logger, locale, chatID); line != "" {
+		text += "\n" + line
+	}
+	if diff := dialog.RepeatDifference(locale, state.Order, b); diff != "" {
+		text += "\n\n" + diff
+	}
+	if note := dialog.SavedQuoteSummary(locale, state.Order, h.cfg); note != "" {
+		text += "\n\n" + note
+	}
+	if note := dialog.QuoteSummary(locale, quote); note != "" {
+		text += "\n" + note
+	}
+	if note := dialog.ApprovalSummary(locale, h.cfg, b); note != "" {
+		text += "\n" + note
+	}
+
+	rushLabel := i18n.T(locale, "order.rush_off")
+	if rush {
+		rushLabel = i18n.T(locale, "order.rush_on")
+	}
+
+	last := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.cancel"), callbackPrefix+":cancel"))
+	// A resumed quote is already saved
+	if dialog.SavedQuote(state.Order) == 0 {
+		last = append(last, tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "saved.save_button"), callbackPrefix+":savequote"))
+	}
+
+	// Dialogs run in private chats, where the chat is the customer
+	return h.send(ctx, chatID, text, dialog.SummaryKeyboard(ctx, h.experiments, locale, chatID, callbackPrefix,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(rushLabel, callbackPrefix+":rush"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.promo_button"), callbackPrefix+":promo")),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "order.edit"), callbackPrefix+":edit"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "delivery.change"), callbackPrefix+":delivery")),
+		last,
+	))
+}
+
+func (h *Handler) place(ctx context.Context, chatID, userID int64, locale i18n.Locale, state *redis.UserState, contact, key string, allowDuplicate bool) error {
+	if dialog.PriceLapsed(state.Order, h.cfg) {
+		// The saved price ran out since the summary: show today's first
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+
+	order, err := h.usecase.Place(ctx, userID, toEntity(state.Order), contact, isRush(state),
+		dialog.PromoCode(state.Order), dialog.GiftCard(state.Order), dialog.Delivery(state.Order), key, allowDuplicate)
+	if _, rejected := dialog.CodeRejection(err); rejected {
+		// A code ran out after the quote: show the price without it
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if text, ok := dialog.QuoteChanged(err, locale); ok {
+		// The summary's price ran out and today's differs: show it first
+		if err := h.send(ctx, chatID, text, nil); err != nil {
+			return err
+		}
+		return h.showSummary(ctx, chatID, locale, state)
+	}
+	if text, markup, ok := dialog.RepeatedOrder(err, locale, callbackPrefix); ok {
+		state.Step = stepConfirm
+		if err := h.save(ctx, chatID, state); err != nil {
+			return err
+		}
+		return h.send(ctx, chatID, text, markup)
+	}
+	if errors.Is(err, orders.ErrInvalidDelivery) || errors.Is(err, orders.ErrAddressRequired) {
+		return h.askDelivery(ctx, chatID, locale, state)
+	}
+	if err != nil {
+		return h.orderError(ctx, chatID, locale, err)
+	}
+
+	if err := h.redis.DropUserDialogState(ctx, chatID); err != nil {
+		h.logger.Warn("Failed to drop print draft", zap.Error(err))
+	}
+
+	dialog.ForgetQuote(ctx, h.storage, h.logger, userID, state.Order)
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, postgres.FunnelPlaced)
+	h.logger.Info("Print order placed",
+		zap.Int64("order_id", order.ID),
+		zap.Int64("user_id", userID),
+		zap.String("product", order.Options[orders.OptionProduct]),
+		zap.Int("quantity", order.Quantity))
+
+	// The contact keyboard may still be open
+	return h.send(ctx, chatID, dialog.Placed(locale, "print.placed", order), tgbotapi.NewRemoveKeyboard(true))
+}
+
+// orderError explains a rejected quote; unexpected errors go to the bot log
+func (h *Handler) orderError(ctx context.Context, chatID int64, locale i18n.Locale, err error) error {
+	var key string
+	switch {
+	case errors.Is(err, orders.ErrTextureUnavailable):
+		key = "print.unavailable"
+	case errors.Is(err, orders.ErrTooLarge), errors.Is(err, orders.ErrInvalidDimensions),
+		errors.Is(err, orders.ErrInvalidQuantity), errors.Is(err, orders.ErrInvalidOptions),
+		errors.Is(err, usecase.ErrUnknownProduct), errors.Is(err, usecase.ErrUnknownFormat):
+		key = "print.invalid"
+	default:
+		_ = h.send(ctx, chatID, i18n.T(locale, "error.generic"), nil)
+		return err
+	}
+
+	if dropErr := h.redis.DropUserDialogState(ctx, chatID); dropErr != nil {
+		h.logger.Warn("Failed to drop print draft", zap.Error(dropErr))
+	}
+	dialog.Discard(ctx, h.storage, h.logger, chatID)
+	return h.send(ctx, chatID, i18n.T(locale, key), nil)
+}
+
+// draft returns the dialog state when a print order is in progress
+func (h *Handler) draft(ctx context.Context, chatID int64) (*redis.UserState, bool, error) {
+	state, err := h.redis.GetUserDialogState(ctx, chatID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	order := state.Order
+	if order == nil || order.SelectedProduct == nil || *order.SelectedProduct != product ||
+		order.Typography == nil || !strings.HasPrefix(state.Step, "print_") {
+		return nil, false, nil
+	}
+	// Every step after the first one needs the product
+	if state.Step != stepProduct && order.Typography.Product == nil {
+		return nil, false, nil
+	}
+	return state, true, nil
+}
+
+// save keeps the draft and counts the step it is at in the funnel. Dialogs
+// run in private chats, so the chat is the customer.
+func (h *Handler) save(ctx context.Context, chatID int64, state *redis.UserState) error {
+	if err := h.redis.SetUserDialogState(ctx, chatID, state); err != nil {
+		return err
+	}
+	dialog.Track(ctx, h.storage, h.logger, chatID, callbackPrefix, state.Step)
+	return nil
+}
+
+func (h *Handler) locale(ctx context.Context, userID int64) i18n.Locale {
+	return dialog.Locale(ctx, h.storage, h.logger, userID)
+}
+
+// send posts the message and keeps it in the transcript of the draft
+func (h *Handler) send(ctx context.Context, chatID int64, text string, markup any) error {
+	if err := dialog.Send(h.botAPI, chatID, text, markup); err != nil {
+		return err
+	}
+	dialog.Record(ctx, h.redis, h.storage, h.logger, chatID, text)
+	return nil
+}
+
+func toEntity(order *redis.Order) entity.Typography {
+	t := order.Typography
+	spec := entity.Typography{
+		RepeatOf:   dialog.RepeatOf(order),
+		PricedAt:   dialog.PricedAt(order),
+		QuoteID:    dialog.QuoteID(order),
+		VoiceNotes: order.VoiceNotes,
+	}
+	if t.Product != nil {
+		spec.Product = entity.Product(*t.Product)
+	}
+	if t.Format != nil {
+		spec.Format = *t.Format
+	}
+	if t.WidthCM != nil {
+		spec.WidthCM = *t.WidthCM
+	}
+	if t.HeightCM != nil {
+		spec.HeightCM = *t.HeightCM
+	}
+	if t.MaterialID != nil {
+		spec.MaterialID = *t.MaterialID
+	}
+	if t.Sides != nil {
+		spec.Sides = *t.Sides
+	}
+	if t.Quantity != nil {
+		spec.Quantity = *t.Quantity
+	}
+	if t.LayoutID != nil {
+		spec.LayoutID = *t.LayoutID
+	}
+	return spec
+}
+
+func isRush(state *redis.UserState) bool {
+	return state.Order.Rush != nil && *state.Order.Rush
+}
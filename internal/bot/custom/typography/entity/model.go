@@ -1,7 +1,37 @@
 package entity
 
+import "time"
+
+// Product is a kind of printed matter
+type Product string
+
+const (
+	ProductBusinessCard Product = "business_card"
+	ProductFlyer        Product = "flyer"
+	ProductBanner       Product = "banner"
+)
+
+// Typography is a print run as configured in the dialog
 type Typography struct {
-	Id    int
-	Name  string
-	Order string
+	Product Product
+	// Format is the code of a preset format, or "custom" for banners
+	Format     string
+	WidthCM    int
+	HeightCM   int
+	MaterialID string
+	Sides      int
+	Quantity   int
+	// LayoutID is the uploaded print file, 0 when a layout has to be made
+	LayoutID int64
+	// RepeatOf is the past order this one repeats, whose layout is reused
+	RepeatOf int64
+	// PricedAt is the moment of a saved quote whose price holds, zero to
+	// price at today's rates
+	PricedAt time.Time
+	// QuoteID is the price quote of the summary the customer confirms,
+	// 0 to price the order on confirmation
+	QuoteID int64
+	// VoiceNotes are the customer's voice messages (attachment IDs) sent
+	// along the dialog
+	VoiceNotes []int64
 }
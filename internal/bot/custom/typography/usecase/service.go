@@ -1,12 +1,217 @@
 package usecase
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/bot/custom/typography/entity"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/uploads"
+	"s1ntez/pkg/objectstore"
+	"strconv"
+	"time"
+)
+
+// FormatCustom lets the customer type the size (banners only)
+const FormatCustom = "custom"
+
+// OptionFormat and OptionLayout are typography keys of orders.Request.Options
+const (
+	OptionFormat = "format"
+	// OptionLayout is "attached", "repeat" when the layout of the repeated
+	// order is used again, or "needed" when it is still to be made
+	OptionLayout = "layout"
+)
+
+var (
+	ErrUnknownProduct = errors.New("unknown print product")
+	ErrUnknownFormat  = errors.New("unknown print format")
+)
+
+// LayoutContentTypes are the accepted print file formats
+var LayoutContentTypes = []string{
+	"application/pdf",
+	"application/postscript",
+	"application/illustrator",
+	"image/tiff",
+	"image/png",
+	"image/jpeg",
+}
+
+type Format struct {
+	Code     string
+	WidthCM  int
+	HeightCM int
+}
+
+// ProductSpec describes what can be ordered for a product
+type ProductSpec struct {
+	Formats     []Format
+	CustomSize  bool
+	DoubleSided bool
+	// Quantities are the suggested run sizes
+	Quantities []int
+}
+
+// Products in the order they are offered
+var Products = []entity.Product{entity.ProductBusinessCard, entity.ProductFlyer, entity.ProductBanner}
+
+var Catalog = map[entity.Product]ProductSpec{
+	entity.ProductBusinessCard: {
+		Formats:     []Format{{"standard", 9, 5}, {"vertical", 5, 9}},
+		DoubleSided: true,
+		Quantities:  []int{100, 200, 500, 1000},
+	},
+	entity.ProductFlyer: {
+		Formats:     []Format{{"A6", 10, 15}, {"A5", 15, 21}, {"A4", 21, 30}},
+		DoubleSided: true,
+		Quantities:  []int{100, 500, 1000, 5000},
+	},
+	entity.ProductBanner: {
+		Formats:    []Format{{"60x40", 60, 40}, {"80x50", 80, 50}},
+		CustomSize: true,
+		Quantities: []int{1, 2, 5, 10},
+	},
+}
+
+// Format returns a preset format of the product
+func (p ProductSpec) Format(code string) (Format, bool) {
+	for _, f := range p.Formats {
+		if f.Code == code {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Usecase runs print orders through the common order pipeline
 type Usecase struct {
-	typorgaphy Typography
+	orders  *orders.Service
+	storage *postgres.PostgresStorage
+	// files is nil when no object store is configured
+	files *objectstore.Client
+}
+
+func New(orderService *orders.Service, storage *postgres.PostgresStorage, files *objectstore.Client) *Usecase {
+	return &Usecase{
+		orders:  orderService,
+		storage: storage,
+		files:   files,
+	}
+}
+
+// Materials lists the papers suitable for the product
+func (u *Usecase) Materials(ctx context.Context, product entity.Product) ([]postgres.Texture, error) {
+	materials, err := u.storage.GetMaterials(ctx, postgres.ServiceTypography)
+	if err != nil {
+		return nil, err
+	}
+
+	suitable := materials[:0]
+	for _, m := range materials {
+		if m.Suits(string(product)) {
+			suitable = append(suitable, m)
+		}
+	}
+	return suitable, nil
+}
+
+// Quote prices the run without saving it. userID is needed for the
+// per-customer limit of the promo code.
+func (u *Usecase) Quote(ctx context.Context, userID int64, spec entity.Typography, rush bool, promoCode, giftCard string, delivery orders.Delivery) (*postgres.Texture, pricing.Breakdown, error) {
+	req, err := request(userID, spec)
+	if err != nil {
+		return nil, pricing.Breakdown{}, err
+	}
+	req.Rush = rush
+	req.PromoCode = promoCode
+	req.GiftCard = giftCard
+	req.Delivery = delivery
+	return u.orders.Quote(ctx, req, time.Now())
+}
+
+// HoldPrice keeps the quoted price of the run for the customer; the order is
+// placed at it while it holds
+func (u *Usecase) HoldPrice(ctx context.Context, userID int64, spec entity.Typography, b pricing.Breakdown) (*postgres.PriceQuote, error) {
+	return u.orders.HoldPrice(ctx, userID, postgres.ServiceTypography, spec.PricedAt, b)
 }
 
-type ITypography interface {
-	CreateSticker()
-	ReadSticker()
-	UpdateSticker()
-	DeleteSticker()
+// SaveLayout stores an uploaded print file and returns its attachment ID
+func (u *Usecase) SaveLayout(ctx context.Context, userID int64, layout uploads.File) (int64, error) {
+	return uploads.Save(ctx, u.storage, u.files, userID, layout, LayoutContentTypes)
+}
+
+// Place creates the order. idempotencyKey identifies the confirmation;
+// allowDuplicate is set once the customer confirmed a repeated order.
+func (u *Usecase) Place(ctx context.Context, userID int64, spec entity.Typography, contact string, rush bool, promoCode, giftCard string, delivery orders.Delivery, idempotencyKey string, allowDuplicate bool) (*postgres.Order, error) {
+	req, err := request(userID, spec)
+	if err != nil {
+		return nil, err
+	}
+	req.Contact = contact
+	req.Rush = rush
+	req.PromoCode = promoCode
+	req.GiftCard = giftCard
+	req.Delivery = delivery
+	req.IdempotencyKey = idempotencyKey
+	req.AllowDuplicate = allowDuplicate
+	if spec.LayoutID != 0 {
+		req.AttachmentIDs = []int64{spec.LayoutID}
+	}
+	req.AttachmentIDs = append(req.AttachmentIDs, spec.VoiceNotes...)
+	return u.orders.Place(ctx, req)
+}
+
+// request checks the run against the catalog; size and quantity limits are
+// left to the order service
+func request(userID int64, spec entity.Typography) (orders.Request, error) {
+	product, ok := Catalog[spec.Product]
+	if !ok {
+		return orders.Request{}, fmt.Errorf("%w: %q", ErrUnknownProduct, spec.Product)
+	}
+
+	width, height := spec.WidthCM, spec.HeightCM
+	if spec.Format != FormatCustom || !product.CustomSize {
+		format, ok := product.Format(spec.Format)
+		if !ok {
+			return orders.Request{}, fmt.Errorf("%w: %q", ErrUnknownFormat, spec.Format)
+		}
+		width, height = format.WidthCM, format.HeightCM
+	}
+
+	sides := max(spec.Sides, 1)
+	if sides > 1 && !product.DoubleSided {
+		return orders.Request{}, fmt.Errorf("%w: %s is one-sided", orders.ErrInvalidOptions, spec.Product)
+	}
+
+	layout := "needed"
+	switch {
+	case spec.LayoutID != 0:
+		layout = "attached"
+	case spec.RepeatOf != 0:
+		layout = "repeat"
+	}
+
+	req := orders.Request{
+		UserID:      userID,
+		WidthCM:     width,
+		HeightCM:    height,
+		TextureID:   spec.MaterialID,
+		ServiceType: postgres.ServiceTypography,
+		Quantity:    spec.Quantity,
+		Options: postgres.ProductOptions{
+			orders.OptionProduct: string(spec.Product),
+			OptionFormat:         spec.Format,
+			orders.OptionSides:   strconv.Itoa(sides),
+			OptionLayout:         layout,
+		},
+	}
+	if spec.RepeatOf != 0 {
+		req.Options[orders.OptionRepeatOf] = strconv.FormatInt(spec.RepeatOf, 10)
+	}
+	req.PricedAt = spec.PricedAt
+	req.QuoteID = spec.QuoteID
+	return req, nil
 }
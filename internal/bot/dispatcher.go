@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"context"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dispatcher spreads updates over a fixed set of workers. All updates of
+// a chat go to the same worker, so a dialog sees its messages in order
+// while other chats are served in parallel. A slow update delays only the
+// chats that share its worker.
+type dispatcher struct {
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+func newDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *dispatcher {
+	d := &dispatcher{queues: make([]chan tgbotapi.Update, max(workers, 1))}
+	for i := range d.queues {
+		queue := make(chan tgbotapi.Update, queueSize)
+		d.queues[i] = queue
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for update := range queue {
+				handle(update)
+			}
+		}()
+	}
+	return d
+}
+
+// dispatch queues the update, waiting while its worker's queue is full
+// unless ctx ends first
+func (d *dispatcher) dispatch(ctx context.Context, update tgbotapi.Update) bool {
+	queue := d.queues[uint64(orderKey(update))%uint64(len(d.queues))]
+	select {
+	case queue <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stop lets the workers finish the queued updates and waits for them
+func (d *dispatcher) stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+// orderKey is what updates are serialized by: the chat, or the user for
+// updates outside a chat (inline queries)
+func orderKey(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return int64(update.UpdateID)
+}
@@ -0,0 +1,134 @@
+// Package validate checks the answers typed in the order dialogs. A
+// rejected answer is an *Error that explains itself in the customer's
+// language, so every flow words the same mistake the same way.
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"s1ntez/internal/config"
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/phone"
+	"s1ntez/pkg/units"
+	"strconv"
+	"strings"
+)
+
+// MaxAddressLength keeps typed addresses within a Telegram message
+const MaxAddressLength = 500
+
+var codePattern = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+// Error is an answer a rule rejected
+type Error struct {
+	key  string
+	args func(locale i18n.Locale) []any
+}
+
+func (e *Error) Error() string {
+	return "invalid answer: " + e.key
+}
+
+// Message explains what was wrong and what is expected
+func (e *Error) Message(locale i18n.Locale) string {
+	if e.args == nil {
+		return i18n.T(locale, e.key)
+	}
+	return i18n.T(locale, e.key, e.args(locale)...)
+}
+
+// Message returns the explanation of a rejected answer; ok is false for
+// errors that didn't come from a rule
+func Message(err error, locale i18n.Locale) (text string, ok bool) {
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		return "", false
+	}
+	return invalid.Message(locale), true
+}
+
+func reject(key string, args ...any) *Error {
+	return &Error{key: key, args: func(i18n.Locale) []any { return args }}
+}
+
+// SizeLimits bound a size in centimetres. Example is the size suggested
+// when one is rejected.
+type SizeLimits struct {
+	MinCM                   int
+	MaxWidthCM, MaxHeightCM int
+	Example                 [2]int
+}
+
+// StickerSize are the limits of the plotter
+func StickerSize(cfg *config.Config) SizeLimits {
+	c := cfg.Stickers
+	return SizeLimits{MinCM: c.MinSizeCM, MaxWidthCM: c.MaxWidthCM, MaxHeightCM: c.MaxHeightCM, Example: [2]int{7, 5}}
+}
+
+// PrintSize are the limits of the printer
+func PrintSize(cfg *config.Config) SizeLimits {
+	return SizeLimits{MinCM: 1, MaxWidthCM: cfg.MaxDimensions.Width, MaxHeightCM: cfg.MaxDimensions.Height, Example: [2]int{70, 50}}
+}
+
+// Size reads a typed "WxH"; a number without a unit is in unit. The size
+// is returned in whole centimetres.
+func Size(raw string, unit units.Unit, limits SizeLimits) (widthCM, heightCM int, err error) {
+	widthCM, heightCM, ok := units.ParseSize(raw, unit)
+	if ok && widthCM >= limits.MinCM && heightCM >= limits.MinCM &&
+		widthCM <= limits.MaxWidthCM && heightCM <= limits.MaxHeightCM {
+		return widthCM, heightCM, nil
+	}
+
+	example := unit.Example(limits.Example[0], limits.Example[1])
+	if limits.MinCM <= 1 {
+		return 0, 0, &Error{key: "validate.size_max", args: func(locale i18n.Locale) []any {
+			return []any{example, i18n.Size(locale, unit, limits.MaxWidthCM, limits.MaxHeightCM)}
+		}}
+	}
+	return 0, 0, &Error{key: "validate.size", args: func(locale i18n.Locale) []any {
+		return []any{example, i18n.Length(locale, unit, limits.MinCM), i18n.Size(locale, unit, limits.MaxWidthCM, limits.MaxHeightCM)}
+	}}
+}
+
+// Quantity reads a run size from 1 to max
+func Quantity(raw string, max int) (int, error) {
+	quantity, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || quantity < 1 || quantity > max {
+		return 0, reject("validate.quantity", max)
+	}
+	return quantity, nil
+}
+
+// Phone normalizes a typed phone number to E.164
+func Phone(raw string) (string, error) {
+	number, err := phone.Normalize(raw)
+	if err != nil {
+		return "", reject("order.bad_contact")
+	}
+	return number, nil
+}
+
+// IsCode reports whether the answer looks like a verification code rather
+// than a phone number
+func IsCode(raw string) bool {
+	return codePattern.MatchString(strings.TrimSpace(raw))
+}
+
+// Text collapses the whitespace of a typed answer and checks its length
+// in characters
+func Text(raw string, min, max int) (string, error) {
+	text := strings.Join(strings.Fields(raw), " ")
+	if n := len([]rune(text)); n < min || n > max {
+		return "", reject("validate.text", min, max)
+	}
+	return text, nil
+}
+
+// Address reads a typed delivery address
+func Address(raw string) (string, error) {
+	address, err := Text(raw, 5, MaxAddressLength)
+	if err != nil {
+		return "", reject("delivery.bad_address")
+	}
+	return address, nil
+}
@@ -0,0 +1,203 @@
+package validate
+
+import (
+	"errors"
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/units"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	limits := SizeLimits{MinCM: 2, MaxWidthCM: 50, MaxHeightCM: 30, Example: [2]int{7, 5}}
+
+	tests := []struct {
+		name       string
+		raw        string
+		unit       units.Unit
+		limits     SizeLimits
+		wantWidth  int
+		wantHeight int
+		wantMsg    string
+	}{
+		{name: "within limits", raw: "10x20", unit: units.CM, limits: limits, wantWidth: 10, wantHeight: 20},
+		{name: "at the limits", raw: "50x30", unit: units.CM, limits: limits, wantWidth: 50, wantHeight: 30},
+		{name: "in millimetres", raw: "100x200", unit: units.MM, limits: limits, wantWidth: 10, wantHeight: 20},
+		{name: "too small", raw: "1x20", unit: units.CM, limits: limits,
+			wantMsg: "Couldn't read the size. Example: <code>7x5</code>, from 2 cm, at most 50 × 30 cm"},
+		{name: "too wide", raw: "60x10", unit: units.CM, limits: limits,
+			wantMsg: "Couldn't read the size. Example: <code>7x5</code>, from 2 cm, at most 50 × 30 cm"},
+		{name: "example in the unit", raw: "abc", unit: units.MM, limits: limits,
+			wantMsg: "Couldn't read the size. Example: <code>70x50</code>, from 20 mm, at most 500 × 300 mm"},
+		{name: "no minimum", raw: "0x10", unit: units.CM, limits: SizeLimits{MinCM: 1, MaxWidthCM: 100, MaxHeightCM: 100, Example: [2]int{70, 50}},
+			wantMsg: "Couldn't read the size. Example: <code>70x50</code>, at most 100 × 100 cm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := Size(tt.raw, tt.unit, tt.limits)
+			if tt.wantMsg != "" {
+				msg, ok := Message(err, i18n.EN)
+				if !ok {
+					t.Fatalf("Size(%q) error = %v, want a rule error", tt.raw, err)
+				}
+				if msg != tt.wantMsg {
+					t.Errorf("message = %q, want %q", msg, tt.wantMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Size(%q) unexpected error: %v", tt.raw, err)
+			}
+			if width != tt.wantWidth || height != tt.wantHeight {
+				t.Errorf("Size(%q) = %dx%d, want %dx%d", tt.raw, width, height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestQuantity(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "5", want: 5},
+		{raw: " 10 ", want: 10},
+		{raw: "1", want: 1},
+		{raw: "0", wantErr: true},
+		{raw: "11", wantErr: true},
+		{raw: "-3", wantErr: true},
+		{raw: "2.5", wantErr: true},
+		{raw: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Quantity(tt.raw, 10)
+			if tt.wantErr {
+				if msg, ok := Message(err, i18n.EN); !ok || msg != "Quantity must be a whole number from 1 to 10" {
+					t.Fatalf("Quantity(%q) = %d, %v, want the quantity rule", tt.raw, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Quantity(%q) = %d, %v, want %d", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhone(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "8 (999) 123-45-67", want: "+79991234567"},
+		{raw: "+44 20 7946 0958", want: "+442079460958"},
+		{raw: "12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Phone(tt.raw)
+			if tt.wantErr {
+				var invalid *Error
+				if !errors.As(err, &invalid) {
+					t.Fatalf("Phone(%q) error = %v, want a rule error", tt.raw, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Phone(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCode(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "1234", want: true},
+		{raw: "12345678", want: true},
+		{raw: " 4321 ", want: true},
+		{raw: "123", want: false},
+		{raw: "123456789", want: false},
+		{raw: "12a4", want: false},
+		{raw: "+79991234567", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := IsCode(tt.raw); got != tt.want {
+				t.Errorf("IsCode(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		min, max int
+		want     string
+		wantErr  bool
+	}{
+		{name: "collapses whitespace", raw: "  hello \n  world ", min: 1, max: 20, want: "hello world"},
+		{name: "counts characters", raw: "привет", min: 1, max: 6, want: "привет"},
+		{name: "too short", raw: "ab", min: 3, max: 10, wantErr: true},
+		{name: "too long", raw: "привет!", min: 1, max: 6, wantErr: true},
+		{name: "blank", raw: "   ", min: 1, max: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Text(tt.raw, tt.min, tt.max)
+			if tt.wantErr {
+				if _, ok := Message(err, i18n.EN); !ok {
+					t.Fatalf("Text(%q) = %q, %v, want a rule error", tt.raw, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Text(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "address", raw: " Москва,  Тверская 1 ", want: "Москва, Тверская 1"},
+		{name: "too short", raw: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Address(tt.raw)
+			if tt.wantErr {
+				if msg, ok := Message(err, i18n.EN); !ok || msg != i18n.T(i18n.EN, "delivery.bad_address") {
+					t.Fatalf("Address(%q) = %q, %v, want the address rule", tt.raw, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Address(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageOfOtherErrors(t *testing.T) {
+	if msg, ok := Message(errors.New("boom"), i18n.EN); ok {
+		t.Errorf("Message() = %q, true for an error not made by a rule", msg)
+	}
+}
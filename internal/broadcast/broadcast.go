@@ -0,0 +1,179 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Recipients are loaded in batches; cancellation is checked between them
+const batchSize = 100
+
+// Service sends broadcasts at BROADCAST_RATE messages per second, below
+// Telegram's limit of about 30 so the bot stays responsive meanwhile.
+// Progress is kept per recipient, so a restart resumes where it stopped.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("broadcast"),
+		cfg:     cfg,
+	}
+}
+
+// Message is what a recipient gets; admins see it as the preview
+func Message(b *postgres.Broadcast, chatID int64) tgbotapi.Chattable {
+	var markup any
+	if len(b.Buttons) > 0 {
+		rows := make([][]tgbotapi.InlineKeyboardButton, len(b.Buttons))
+		for i, button := range b.Buttons {
+			rows[i] = tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(button.Text, button.URL))
+		}
+		markup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	if b.PhotoFileID != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(b.PhotoFileID))
+		photo.Caption = b.Text
+		photo.ParseMode = tgbotapi.ModeHTML
+		photo.ReplyMarkup = markup
+		return photo
+	}
+
+	msg := tgbotapi.NewMessage(chatID, b.Text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = markup
+	return msg
+}
+
+// Watch sends broadcasts queued by admins, one at a time
+func (s *Service) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Broadcast.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			b, err := s.storage.NextSendingBroadcast(ctx)
+			if err != nil {
+				s.logger.Error("Failed to get broadcast", zap.Error(err))
+				break
+			}
+			if b == nil {
+				break
+			}
+			if err := s.send(ctx, b); err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("Broadcast interrupted", zap.Int64("broadcast_id", b.ID), zap.Error(err))
+				}
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) send(ctx context.Context, b *postgres.Broadcast) error {
+	limiter := time.NewTicker(time.Second / time.Duration(max(s.cfg.Broadcast.Rate, 1)))
+	defer limiter.Stop()
+
+	s.logger.Info("Sending broadcast", zap.Int64("broadcast_id", b.ID), zap.Int("pending", b.Total-b.Sent-b.Failed-b.Blocked))
+
+	for {
+		// An admin may have cancelled it
+		current, err := s.storage.GetBroadcast(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != postgres.BroadcastSending {
+			s.logger.Info("Broadcast stopped", zap.Int64("broadcast_id", b.ID), zap.String("status", string(current.Status)))
+			return nil
+		}
+
+		userIDs, err := s.storage.PendingBroadcastRecipients(ctx, b.ID, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-limiter.C:
+			}
+
+			status, deliveryErr, err := s.deliver(ctx, b, userID)
+			if err != nil {
+				return err
+			}
+			if err := s.storage.MarkBroadcastRecipient(ctx, b.ID, userID, status, deliveryErr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.storage.FinishBroadcast(ctx, b.ID); err != nil && !errors.Is(err, postgres.ErrBroadcastNotFound) {
+		return err
+	}
+
+	done, err := s.storage.GetBroadcast(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Broadcast finished",
+		zap.Int64("broadcast_id", b.ID),
+		zap.Int("sent", done.Sent),
+		zap.Int("failed", done.Failed),
+		zap.Int("blocked", done.Blocked))
+	return nil
+}
+
+// deliver sends the broadcast to one user, waiting out flood limits. err
+// is set only when ctx ends; the recipient then stays pending.
+func (s *Service) deliver(ctx context.Context, b *postgres.Broadcast, userID int64) (status, deliveryErr string, err error) {
+	for {
+		_, sendErr := s.botAPI.Send(Message(b, userID))
+		if sendErr == nil {
+			return postgres.RecipientSent, "", nil
+		}
+
+		var apiErr *tgbotapi.Error
+		if !errors.As(sendErr, &apiErr) {
+			return postgres.RecipientFailed, sendErr.Error(), nil
+		}
+
+		switch {
+		case apiErr.RetryAfter > 0:
+			s.logger.Warn("Broadcast hit the flood limit", zap.Int("retry_after", apiErr.RetryAfter))
+			select {
+			case <-ctx.Done():
+				return "", "", ctx.Err()
+			case <-time.After(time.Duration(apiErr.RetryAfter) * time.Second):
+			}
+		case apiErr.Code == 403:
+			// Blocked the bot or deactivated the account
+			return postgres.RecipientBlocked, apiErr.Message, nil
+		default:
+			return postgres.RecipientFailed, apiErr.Message, nil
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package broker publishes order events to NATS or Kafka for analytics
+// pipelines. Messages follow api/events/order_event.schema.json.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// Message is one event on its way to the broker
+type Message struct {
+	// Type names the event, e.g. order.created
+	Type string
+	// Key keeps the events of one order in order: the Kafka partition key
+	Key string
+	// ID is unique per event, so consumers and JetStream can drop
+	// redeliveries
+	ID   string
+	Body []byte
+}
+
+// Publisher sends messages to the configured broker. Publish returns once
+// the broker has the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New connects to the broker chosen by BROKER; nil when none is
+func New(cfg *config.Config) (Publisher, error) {
+	c := cfg.Broker
+	switch c.Kind {
+	case "nats":
+		return newNATS(c.URLs, c.Topic, c.NATSCredentials, c.Timeout)
+	case "kafka":
+		return newKafka(c.URLs, c.Topic, c.Timeout), nil
+	}
+	return nil, nil
+}
+
+// natsPublisher sends every event type to its own subject,
+// <topic>.created and <topic>.status_changed
+type natsPublisher struct {
+	conn  *nats.Conn
+	topic string
+}
+
+func newNATS(urls []string, topic, credentials string, timeout time.Duration) (*natsPublisher, error) {
+	options := []nats.Option{
+		nats.Name("adtime-bot"),
+		nats.Timeout(timeout),
+		// keep reconnecting: the outbox retries whatever fails meanwhile
+		nats.MaxReconnects(-1),
+	}
+	if credentials != "" {
+		options = append(options, nats.UserCredentials(credentials))
+	}
+
+	conn, err := nats.Connect(strings.Join(urls, ","), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &natsPublisher{conn: conn, topic: topic}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, msg Message) error {
+	m := nats.NewMsg(p.topic + "." + strings.TrimPrefix(msg.Type, "order."))
+	m.Data = msg.Body
+	m.Header.Set(nats.MsgIdHdr, msg.ID)
+	m.Header.Set("Event-Type", msg.Type)
+
+	if err := p.conn.PublishMsg(m); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	// core NATS doesn't acknowledge messages; a flush at least confirms
+	// the server has read them
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// kafkaPublisher writes every event to one topic, keyed by order
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafka(brokers []string, topic string, timeout time.Duration) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// messages are written one at a time by the outbox
+		BatchTimeout: 10 * time.Millisecond,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	err := p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key),
+		Value: msg.Body,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(msg.ID)},
+			{Key: "event-type", Value: []byte(msg.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write to Kafka: %w", err)
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,88 @@
+package broker
+
+import (
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"time"
+)
+
+// Schema names the version of OrderEvent. Fields may be added within a
+// version; renaming or removing one starts a new version.
+const Schema = "adtime.order_event.v1"
+
+// OrderEvent is the JSON body of every published message. Customers are
+// pseudonymous, as in the anonymized exports, and contacts are left out.
+type OrderEvent struct {
+	Schema string `json:"schema"`
+	// ID is the same for redeliveries of one event
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Order      Order     `json:"order"`
+}
+
+type Order struct {
+	ID       int64  `json:"id"`
+	Customer string `json:"customer"`
+	Status   string `json:"status"`
+	// PrevStatus is set for status changes
+	PrevStatus  string         `json:"prev_status,omitempty"`
+	ServiceType string         `json:"service_type"`
+	Rush        bool           `json:"rush"`
+	Currency    money.Currency `json:"currency"`
+	// Price is what the customer pays: after the discount, shipping included
+	Price     money.Amount `json:"price"`
+	Discount  money.Amount `json:"discount"`
+	Shipping  money.Amount `json:"shipping"`
+	Delivery  string       `json:"delivery"`
+	CreatedAt time.Time    `json:"created_at"`
+	Items     []Item       `json:"items"`
+}
+
+type Item struct {
+	ServiceType string       `json:"service_type"`
+	TextureID   string       `json:"texture_id"`
+	WidthCM     int          `json:"width_cm"`
+	HeightCM    int          `json:"height_cm"`
+	Quantity    int          `json:"quantity"`
+	Price       money.Amount `json:"price"`
+}
+
+// NewOrderEvent describes the order as it is now. id identifies the event
+// and customer is the pseudonym of the order's user.
+func NewOrderEvent(id int64, eventType string, occurredAt time.Time, order *postgres.Order, prevStatus postgres.OrderStatus, customer string) OrderEvent {
+	delivery := order.DeliveryOrPickup()
+	event := OrderEvent{
+		Schema:     Schema,
+		ID:         strconv.FormatInt(id, 10),
+		Type:       eventType,
+		OccurredAt: occurredAt.UTC(),
+		Order: Order{
+			ID:          order.ID,
+			Customer:    customer,
+			Status:      order.Status.String(),
+			PrevStatus:  prevStatus.String(),
+			ServiceType: string(order.ServiceType.OrLeather()),
+			Rush:        order.IsRush,
+			Currency:    order.Currency,
+			Price:       order.Price,
+			Discount:    order.Discount,
+			Shipping:    delivery.Cost,
+			Delivery:    delivery.Method,
+			CreatedAt:   order.CreatedAt.UTC(),
+		},
+	}
+
+	for _, item := range order.LineItems() {
+		event.Order.Items = append(event.Order.Items, Item{
+			ServiceType: string(item.ServiceType.OrLeather()),
+			TextureID:   item.TextureID,
+			WidthCM:     item.WidthCM,
+			HeightCM:    item.HeightCM,
+			Quantity:    max(item.Quantity, 1),
+			Price:       item.Price,
+		})
+	}
+	return event
+}
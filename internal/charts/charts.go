@@ -0,0 +1,49 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"s1ntez/internal/storage/postgres"
+
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// RevenueBars renders a revenue series as a PNG bar chart
+func RevenueBars(title, dateFormat string, points []postgres.RevenuePoint) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data to plot")
+	}
+
+	bars := make([]chart.Value, 0, len(points))
+	for _, p := range points {
+		bars = append(bars, chart.Value{
+			Label: p.Period.Format(dateFormat),
+			Value: p.Revenue,
+		})
+	}
+
+	graph := chart.BarChart{
+		Title:    title,
+		Width:    1200,
+		Height:   600,
+		BarWidth: max(8, 1000/len(points)-4),
+		Background: chart.Style{
+			Padding: chart.Box{Top: 50, Left: 20, Right: 20, Bottom: 20},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				if f, ok := v.(float64); ok {
+					return fmt.Sprintf("%.0f ₽", f)
+				}
+				return ""
+			},
+		},
+		Bars: bars,
+	}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -3,78 +3,936 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/units"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Config is read from the environment and CONFIG_FILE at startup. The
+// admin and production IDs and the Pricing, Limits and Stats sections can
+// be reloaded while the bot runs (see Reload); read them through the
+// accessors in reload.go.
 type Config struct {
+	// Fields tagged secret may hold a reference instead of the value:
+	// "env:NAME" reads another variable, "vault:<path>#<key>" a Vault KV
+	// secret (see secrets.go). CONFIG_FILE may only hold references.
+	Vault struct {
+		Addr      string `env:"VAULT_ADDR"`
+		Token     string `env:"VAULT_TOKEN"`
+		Namespace string `env:"VAULT_NAMESPACE"`
+	}
+
 	Telegram struct {
-		Token string `env:"TELEGRAM_TOKEN,required"`
+		// required; checked by Validate with the rest so all problems are reported together
+		Token string `env:"TELEGRAM_TOKEN" secret:"true"`
+
+		// Updates of different chats are handled by Workers in parallel;
+		// those of one chat always in order. QueueSize updates may wait per
+		// worker before polling pauses.
+		Workers   int `env:"TELEGRAM_WORKERS" envDefault:"8"`
+		QueueSize int `env:"TELEGRAM_QUEUE_SIZE" envDefault:"64"`
 	}
 
 	Redis struct {
 		Addr     string        `env:"REDIS_ADDR" envDefault:"localhost:6379"`
-		Password string        `env:"REDIS_PASSWORD" envDefault:""`
+		Password string        `env:"REDIS_PASSWORD" envDefault:"" secret:"true"`
 		DB       int           `env:"REDIS_DB" envDefault:"0"`
 		TTL      time.Duration `env:"REDIS_TTL" envDefault:"24h"`
+
+		TextureCheckInterval time.Duration `env:"REDIS_TEXTURE_CHECK_INTERVAL" envDefault:"5m"`
+		// Telegram keeps undelivered updates for 24 hours
+		UpdateDedupTTL time.Duration `env:"REDIS_UPDATE_DEDUP_TTL" envDefault:"25h"`
+
+		// Idempotent calls are retried; after BreakerThreshold failures in a
+		// row the bot stops calling Redis and works from Postgres alone,
+		// probing every BreakerCooldown
+		Retries          int           `env:"REDIS_RETRIES" envDefault:"2"`
+		RetryBackoff     time.Duration `env:"REDIS_RETRY_BACKOFF" envDefault:"50ms"`
+		BreakerThreshold int           `env:"REDIS_BREAKER_THRESHOLD" envDefault:"5"`
+		BreakerCooldown  time.Duration `env:"REDIS_BREAKER_COOLDOWN" envDefault:"10s"`
 	}
 
 	Database struct {
 		Host            string        `env:"DB_HOST" envDefault:"localhost"`
 		Port            int           `env:"DB_PORT" envDefault:"5432"`
 		User            string        `env:"DB_USER" envDefault:"postgres"`
-		Password        string        `env:"DB_PASSWORD" envDefault:"postgres"`
+		Password        string        `env:"DB_PASSWORD" envDefault:"postgres" secret:"true"`
 		Name            string        `env:"DB_NAME" envDefault:"adtime"`
 		MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
 		MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" envDefault:"5"`
 		ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"5m"`
 		ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"2m"`
+		// prepared statements kept per connection; 0 behind PgBouncer in transaction mode
+		StatementCacheSize int `env:"DB_STATEMENT_CACHE_SIZE" envDefault:"512"`
+		// QueryTimeout bounds every storage call; exports and imports run
+		// detached from the caller under ExportTimeout instead. 0 disables it.
+		QueryTimeout  time.Duration `env:"DB_QUERY_TIMEOUT" envDefault:"10s"`
+		ExportTimeout time.Duration `env:"DB_EXPORT_TIMEOUT" envDefault:"10m"`
+
+		// ReplicaDSN points heavy reads (statistics, exports, order lists) at
+		// a read replica; reads go to the primary while it is unreachable
+		ReplicaDSN           string        `env:"DB_REPLICA_DSN" secret:"true"`
+		ReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"30s"`
+	}
+
+	Admin Admin
+
+	// Currency of the prices below and of every new order; changing it
+	// doesn't convert orders already placed
+	Currency money.Currency `env:"CURRENCY" envDefault:"RUB"`
+	// Unit is how sizes typed without a unit are read and how they are
+	// shown to customers who haven't picked one; orders keep centimetres
+	Unit    units.Unit `env:"DEFAULT_UNIT" envDefault:"cm"`
+	Pricing Pricing
+
+	// ExchangeRates convert material prices set in another currency
+	ExchangeRates struct {
+		// cbr (Bank of Russia) or ecb (European Central Bank); the ECB
+		// publishes no rouble rate
+		Source string `env:"FX_SOURCE" envDefault:"cbr"`
+		// URL replaces the source address, e.g. with a mirror
+		URL string `env:"FX_URL"`
+		// TTL is how long fetched rates are used before asking again;
+		// MaxAge is how long they stand in while the source is down
+		TTL     time.Duration `env:"FX_TTL" envDefault:"1h"`
+		MaxAge  time.Duration `env:"FX_MAX_AGE" envDefault:"96h"`
+		Timeout time.Duration `env:"FX_TIMEOUT" envDefault:"10s"`
+	}
+
+	Stickers struct {
+		MinSizeCM   int `env:"STICKER_MIN_SIZE_CM" envDefault:"2"`
+		MaxWidthCM  int `env:"STICKER_MAX_WIDTH_CM" envDefault:"30"`
+		MaxHeightCM int `env:"STICKER_MAX_HEIGHT_CM" envDefault:"30"`
+		MaxQuantity int `env:"STICKER_MAX_QUANTITY" envDefault:"5000"`
+
+		// plotter cutting, per piece
+		CutCostPerPiece  float64 `env:"STICKER_CUT_COST_PER_PIECE" envDefault:"1.5"`
+		LaminationPerDM2 float64 `env:"STICKER_LAMINATION_PER_DM2" envDefault:"2.0"`
+		MinPrice         float64 `env:"STICKER_MIN_PRICE" envDefault:"350"`
+		// preview images larger than this are rejected
+		MaxPreviewBytes int64 `env:"STICKER_MAX_PREVIEW_BYTES" envDefault:"10485760"`
+	}
+
+	Typography struct {
+		MaxQuantity int `env:"TYPOGRAPHY_MAX_QUANTITY" envDefault:"10000"`
+
+		// ink per printed side
+		PrintCostPerDM2 float64 `env:"TYPOGRAPHY_PRINT_COST_PER_DM2" envDefault:"0.8"`
+		// prepress and plate setup, once per order
+		SetupCost float64 `env:"TYPOGRAPHY_SETUP_COST" envDefault:"250"`
+		MinPrice  float64 `env:"TYPOGRAPHY_MIN_PRICE" envDefault:"500"`
+		// Telegram bots can't download files over 20 MB
+		MaxLayoutBytes int64 `env:"TYPOGRAPHY_MAX_LAYOUT_BYTES" envDefault:"20971520"`
+	}
+
+	// ObjectStore keeps customer files; without an endpoint they stay on
+	// Telegram servers and only their file IDs are stored
+	ObjectStore struct {
+		Endpoint  string `env:"OBJECT_STORE_ENDPOINT"`
+		Region    string `env:"OBJECT_STORE_REGION" envDefault:"us-east-1"`
+		Bucket    string `env:"OBJECT_STORE_BUCKET"`
+		AccessKey string `env:"OBJECT_STORE_ACCESS_KEY"`
+		SecretKey string `env:"OBJECT_STORE_SECRET_KEY" secret:"true"`
+		// lifetime of the download links sent to production
+		LinkTTL time.Duration `env:"OBJECT_STORE_LINK_TTL" envDefault:"168h"`
+	}
+
+	Export struct {
+		AnonymizationKey string `env:"EXPORT_ANONYMIZATION_KEY" secret:"true"`
+	}
+
+	API struct {
+		Enabled      bool          `env:"API_ENABLED" envDefault:"false"`
+		Addr         string        `env:"API_ADDR" envDefault:":8080"`
+		Keys         []string      `env:"API_KEYS" secret:"true"`
+		ReadTimeout  time.Duration `env:"API_READ_TIMEOUT" envDefault:"10s"`
+		WriteTimeout time.Duration `env:"API_WRITE_TIMEOUT" envDefault:"30s"`
+	}
+
+	GRPC struct {
+		Enabled bool     `env:"GRPC_ENABLED" envDefault:"false"`
+		Addr    string   `env:"GRPC_ADDR" envDefault:":9090"`
+		Keys    []string `env:"GRPC_API_KEYS" secret:"true"`
+	}
+
+	Outbox struct {
+		PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+		BatchSize    int           `env:"OUTBOX_BATCH_SIZE" envDefault:"20"`
+		Lease        time.Duration `env:"OUTBOX_LEASE" envDefault:"2m"`
+		MaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"12"`
+
+		// CRMWebhookURLs each receive every enabled event, e.g. a Bitrix24
+		// and an amoCRM endpoint side by side
+		CRMWebhookURLs   []string `env:"CRM_WEBHOOK_URL"`
+		CRMWebhookSecret string   `env:"CRM_WEBHOOK_SECRET" secret:"true"`
+		CRMWebhookEvents []string `env:"CRM_WEBHOOK_EVENTS" envDefault:"order.created,order.status_changed"`
+
+		SMTPAddr     string   `env:"SMTP_ADDR"`
+		SMTPUser     string   `env:"SMTP_USER"`
+		SMTPPassword string   `env:"SMTP_PASSWORD" secret:"true"`
+		EmailFrom    string   `env:"EMAIL_FROM"`
+		EmailTo      []string `env:"ORDER_EMAIL_TO"`
+
+		// SheetsCredentials is the JSON key of a service account the
+		// spreadsheet is shared with
+		SheetsSpreadsheetID string `env:"SHEETS_SPREADSHEET_ID"`
+		SheetsSheet         string `env:"SHEETS_SHEET" envDefault:"Orders"`
+		SheetsCredentials   string `env:"SHEETS_CREDENTIALS" secret:"true"`
+	}
+
+	// Broker publishes order events for analytics pipelines, see
+	// api/events/order_event.schema.json
+	Broker struct {
+		// nats, kafka or empty for none
+		Kind string `env:"BROKER"`
+		// NATS servers (nats://host:4222) or Kafka brokers (host:9092)
+		URLs []string `env:"BROKER_URLS"`
+		// Topic is the Kafka topic; NATS gets <topic>.created and
+		// <topic>.status_changed subjects
+		Topic string `env:"BROKER_TOPIC" envDefault:"adtime.orders"`
+		// NATSCredentials is the path of a .creds file, for servers that
+		// require one
+		NATSCredentials string        `env:"BROKER_NATS_CREDS"`
+		Timeout         time.Duration `env:"BROKER_TIMEOUT" envDefault:"10s"`
+	}
+
+	// Analytics copies orders every night to a warehouse for BI queries
+	Analytics struct {
+		// postgres (the analytics schema), clickhouse or empty for none
+		Target             string        `env:"ANALYTICS_TARGET"`
+		ClickHouseURL      string        `env:"ANALYTICS_CLICKHOUSE_URL"`
+		ClickHouseDatabase string        `env:"ANALYTICS_CLICKHOUSE_DATABASE" envDefault:"default"`
+		ClickHouseUser     string        `env:"ANALYTICS_CLICKHOUSE_USER" envDefault:"default"`
+		ClickHousePassword string        `env:"ANALYTICS_CLICKHOUSE_PASSWORD" secret:"true"`
+		Timeout            time.Duration `env:"ANALYTICS_TIMEOUT" envDefault:"1m"`
+		// Hour of the night the export runs, local time
+		Hour      int `env:"ANALYTICS_HOUR" envDefault:"3"`
+		BatchSize int `env:"ANALYTICS_BATCH_SIZE" envDefault:"1000"`
+	}
+
+	Support struct {
+		// GroupID is a forum supergroup; every ticket gets its own topic
+		GroupID          int64         `env:"SUPPORT_GROUP_ID"`
+		ResponseSLA      time.Duration `env:"SUPPORT_RESPONSE_SLA" envDefault:"4h"`
+		SLACheckInterval time.Duration `env:"SUPPORT_SLA_CHECK_INTERVAL" envDefault:"1m"`
+	}
+
+	// Transcription turns voice messages of customers, in support tickets
+	// and order dialogs, into text for staff
+	Transcription struct {
+		// whisper (an OpenAI-compatible /audio/transcriptions endpoint) or
+		// empty to keep voice messages as audio only
+		Provider string `env:"TRANSCRIPTION_PROVIDER"`
+		// URL replaces the OpenAI endpoint, e.g. with a self-hosted Whisper
+		URL    string `env:"TRANSCRIPTION_URL" envDefault:"https://api.openai.com/v1/audio/transcriptions"`
+		APIKey string `env:"TRANSCRIPTION_API_KEY" secret:"true"`
+		Model  string `env:"TRANSCRIPTION_MODEL" envDefault:"whisper-1"`
+		// Language of the speech, ISO 639-1; empty lets the provider guess
+		Language string `env:"TRANSCRIPTION_LANGUAGE" envDefault:"ru"`
+		// longer voice messages are kept as audio only
+		MaxDuration time.Duration `env:"TRANSCRIPTION_MAX_DURATION" envDefault:"5m"`
+		Timeout     time.Duration `env:"TRANSCRIPTION_TIMEOUT" envDefault:"1m"`
+	}
+
+	// SizeEstimation suggests the size of a sticker from a photo of the
+	// object next to a bank card, which the customer confirms or corrects
+	SizeEstimation struct {
+		// http (a service measuring the photo against the card) or empty to
+		// ask for the size in numbers only
+		Provider string `env:"SIZE_ESTIMATION_PROVIDER"`
+		URL      string `env:"SIZE_ESTIMATION_URL"`
+		APIKey   string `env:"SIZE_ESTIMATION_API_KEY" secret:"true"`
+		// estimates the service is less sure of are not offered
+		MinConfidence float64       `env:"SIZE_ESTIMATION_MIN_CONFIDENCE" envDefault:"0.5"`
+		MaxPhotoBytes int64         `env:"SIZE_ESTIMATION_MAX_PHOTO_BYTES" envDefault:"10485760"`
+		Timeout       time.Duration `env:"SIZE_ESTIMATION_TIMEOUT" envDefault:"30s"`
+	}
+
+	// TexturePreviews are the texture images of the catalog, downloaded
+	// from their image_url, shrunk and cached
+	TexturePreviews struct {
+		// longest side of a preview in pixels; Telegram shows photos at
+		// up to 1280
+		MaxSide int `env:"TEXTURE_PREVIEW_MAX_SIDE" envDefault:"1280"`
+		// JPEG quality of the previews, 1 to 100
+		Quality int `env:"TEXTURE_PREVIEW_QUALITY" envDefault:"80"`
+		// larger source images are not downloaded
+		MaxBytes int64         `env:"TEXTURE_PREVIEW_MAX_BYTES" envDefault:"20971520"`
+		Timeout  time.Duration `env:"TEXTURE_PREVIEW_TIMEOUT" envDefault:"15s"`
+		// how long a preview stays in Redis; the object store keeps it
+		// for good
+		CacheTTL time.Duration `env:"TEXTURE_PREVIEW_CACHE_TTL" envDefault:"168h"`
+	}
+
+	// Stock alerts tell the admin chat about tracked textures running out
+	Stock struct {
+		// LowDM2 is the remaining area under which a texture is reported;
+		// 0 turns the alerts off
+		LowDM2        float64       `env:"STOCK_LOW_DM2" envDefault:"100"`
+		CheckInterval time.Duration `env:"STOCK_CHECK_INTERVAL" envDefault:"15m"`
+	}
+
+	Deadlines struct {
+		// ChatID is the production chat reminded about deadlines;
+		// ADMIN_CHAT_ID when unset
+		ChatID        int64         `env:"PRODUCTION_CHAT_ID"`
+		Warning       time.Duration `env:"DEADLINE_WARNING" envDefault:"24h"`
+		CheckInterval time.Duration `env:"DEADLINE_CHECK_INTERVAL" envDefault:"5m"`
+	}
+
+	Archive struct {
+		// After is how old a finished order of a closed month must be to
+		// leave the orders table for orders_archive; 0 keeps every order
+		After     time.Duration `env:"ARCHIVE_AFTER" envDefault:"0"`
+		Interval  time.Duration `env:"ARCHIVE_INTERVAL" envDefault:"24h"`
+		BatchSize int           `env:"ARCHIVE_BATCH_SIZE" envDefault:"500"`
 	}
 
-	Admin struct {
-		ChatID    int64   `env:"ADMIN_CHAT_ID"`
-		ChannelID int64   `env:"CHANNEL_ID"`
-		IDs       []int64 `env:"ADMIN_IDS"`
+	Backup struct {
+		// Interval between scheduled dumps to the object store; 0 leaves
+		// backups to cmd/backup
+		Interval time.Duration `env:"BACKUP_INTERVAL" envDefault:"0"`
+		Prefix   string        `env:"BACKUP_PREFIX" envDefault:"backups/"`
 	}
 
-	Pricing struct {
-		LeatherPricePerDM2    float64 `env:"LEATHER_PRICE_PER_DM2" envDefault:"25.0"`
-		ProcessingCostPerDM2  float64 `env:"PROCESSING_COST_PER_DM2" envDefault:"31.25"`
-		PaymentCommissionRate float64 `env:"PAYMENT_COMMISSION_RATE" envDefault:"0.03"`
-		SalesTaxRate          float64 `env:"SALES_TAX_RATE" envDefault:"0.06"`
-		MarkupMultiplier      float64 `env:"MARKUP_MULTIPLIER" envDefault:"2.5"`
+	Pickup struct {
+		// Secret signs the pickup QR codes sent with ready orders; without
+		// it no codes are sent
+		Secret string `env:"PICKUP_SECRET" secret:"true"`
 	}
 
+	Customers struct {
+		// SyncInterval between full rebuilds of the customers table; order
+		// events keep it current in between
+		SyncInterval time.Duration `env:"CUSTOMERS_SYNC_INTERVAL" envDefault:"24h"`
+	}
+
+	Loyalty struct {
+		// PointsRate is the points a completed order earns per unit of its
+		// price without delivery: 0.01 is a point per 100 ₽; 0 turns the
+		// loyalty program off
+		PointsRate float64       `env:"LOYALTY_POINTS_RATE" envDefault:"0.01"`
+		PointsTTL  time.Duration `env:"LOYALTY_POINTS_TTL" envDefault:"8760h"`
+		// Tiers by the points a customer holds, each taking its percentage
+		// off the products of their orders
+		Tiers []LoyaltyTier `env:"LOYALTY_TIERS" envDefault:"Silver:100:3,Gold:500:5,Platinum:1500:10"`
+		// ExpiryHour is when the nightly job expires the points earned
+		// LOYALTY_POINTS_TTL ago
+		ExpiryHour int `env:"LOYALTY_EXPIRY_HOUR" envDefault:"3"`
+	}
+
+	GiftCards struct {
+		// Denominations customers can buy with /giftcard; empty stops the
+		// sales, cards issued before still work
+		Denominations []float64 `env:"GIFT_CARD_DENOMINATIONS" envDefault:"1000,3000,5000"`
+		// TTL is how long an issued card can be spent
+		TTL time.Duration `env:"GIFT_CARD_TTL" envDefault:"8760h"`
+	}
+
+	Experiments struct {
+		// Running are the experiments on the bot copy, comma separated
+		// NAME:VARIANT|VARIANT entries; the first variant is the control.
+		// A customer always gets the same variant of an experiment.
+		Running []Experiment `env:"EXPERIMENTS"`
+	}
+
+	Referral struct {
+		// credited to the referrer when the invited customer's first order is completed; 0 disables it
+		Bonus float64 `env:"REFERRAL_BONUS" envDefault:"300"`
+	}
+
+	// Phone verification of typed numbers. Numbers shared with the
+	// Telegram contact button belong to the account and need no code.
+	Phone struct {
+		// off, sms (SMS.ru) or telegram (Telegram Gateway)
+		Verification string        `env:"PHONE_VERIFICATION" envDefault:"off"`
+		SMSRuAPIID   string        `env:"SMSRU_API_ID" secret:"true"`
+		GatewayToken string        `env:"TELEGRAM_GATEWAY_TOKEN" secret:"true"`
+		CodeTTL      time.Duration `env:"PHONE_CODE_TTL" envDefault:"10m"`
+		MaxAttempts  int           `env:"PHONE_CODE_MAX_ATTEMPTS" envDefault:"5"`
+		CodesPerHour int64         `env:"PHONE_CODES_PER_HOUR" envDefault:"3"`
+	}
+
+	Abuse struct {
+		// updates a user may send within FloodWindow; going over is a strike
+		FloodLimit  int64         `env:"ABUSE_FLOOD_LIMIT" envDefault:"30"`
+		FloodWindow time.Duration `env:"ABUSE_FLOOD_WINDOW" envDefault:"1m"`
+		// StrikesToBan strikes within StrikeWindow ban the user for
+		// BanDuration, doubled for every earlier ban up to MaxBan
+		StrikesToBan int64         `env:"ABUSE_STRIKES_TO_BAN" envDefault:"3"`
+		StrikeWindow time.Duration `env:"ABUSE_STRIKE_WINDOW" envDefault:"1h"`
+		BanDuration  time.Duration `env:"ABUSE_BAN_DURATION" envDefault:"15m"`
+		MaxBan       time.Duration `env:"ABUSE_MAX_BAN" envDefault:"168h"`
+		// suspicious first-time users answer a question before they are served
+		Captcha         bool `env:"ABUSE_CAPTCHA" envDefault:"true"`
+		CaptchaAttempts int  `env:"ABUSE_CAPTCHA_ATTEMPTS" envDefault:"3"`
+	}
+
+	// Quotes are the prices shown on order summaries
+	Quotes struct {
+		// Validity is how long the price of a summary holds; confirming
+		// later prices the order again and shows the difference first
+		Validity time.Duration `env:"QUOTE_VALIDITY" envDefault:"30m"`
+	}
+
+	// Payments take the deposit for orders through Telegram invoices
+	Payments struct {
+		// PrepaymentPercent is the share of the order total paid before
+		// production starts; 0 sends orders straight to production
+		PrepaymentPercent int `env:"PREPAYMENT_PERCENT" envDefault:"0"`
+		// ProviderToken is the payment provider token from @BotFather
+		ProviderToken string `env:"PAYMENT_PROVIDER_TOKEN" secret:"true"`
+		// ReportInterval is how often the admin chat gets the payments
+		// reconciliation; 0 leaves it to /payments
+		ReportInterval time.Duration `env:"PAYMENTS_REPORT_INTERVAL" envDefault:"168h"`
+	}
+
+	// SavedQuotes are quotes customers keep for later under /saved
+	SavedQuotes struct {
+		// TTL is how long a saved quote is kept
+		TTL time.Duration `env:"SAVED_QUOTE_TTL" envDefault:"720h"`
+		// Validity is how long its price holds; after that the order is
+		// priced at the current rates
+		Validity time.Duration `env:"SAVED_QUOTE_VALIDITY" envDefault:"72h"`
+		// Max is how many quotes one customer may keep
+		Max int `env:"SAVED_QUOTE_MAX" envDefault:"10"`
+	}
+
+	// QuoteApproval sends quotes to a manager before the customer gets
+	// the price: orders from From, and orders with custom requirements
+	QuoteApproval struct {
+		// From is the order total that needs approval; 0 leaves only the
+		// orders with requirements
+		From float64 `env:"QUOTE_APPROVAL_FROM" envDefault:"0"`
+	}
+
+	// Reviews asks customers to rate completed orders
+	Reviews struct {
+		// Delay after completion before the survey is sent; 0 turns the
+		// survey off
+		Delay time.Duration `env:"REVIEW_DELAY" envDefault:"24h"`
+	}
+
+	Accounting struct {
+		// SigningKey signs month-close snapshots; closing is refused without it
+		SigningKey string `env:"ACCOUNTING_SIGNING_KEY" secret:"true"`
+		// VATRate is the VAT percentage included in order totals, for the
+		// 1C export; 0 exports sales without VAT
+		VATRate int `env:"ACCOUNTING_VAT_RATE" envDefault:"0"`
+	}
+
+	Stats Stats
+
+	Broadcast struct {
+		// messages per second; Telegram allows about 30 for the whole bot
+		Rate         int           `env:"BROADCAST_RATE" envDefault:"25"`
+		PollInterval time.Duration `env:"BROADCAST_POLL_INTERVAL" envDefault:"10s"`
+	}
+
+	Jobs struct {
+		MaxAttempts int `env:"JOB_MAX_ATTEMPTS" envDefault:"3"`
+	}
+
+	Reports struct {
+		// Debounce is how long order changes must settle before
+		// reports/current_orders.xlsx is rebuilt
+		Debounce time.Duration `env:"REPORT_DEBOUNCE" envDefault:"30s"`
+	}
+
+	Tracing struct {
+		Enabled     bool    `env:"OTEL_ENABLED" envDefault:"false"`
+		Endpoint    string  `env:"OTEL_EXPORTER_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+		Insecure    bool    `env:"OTEL_EXPORTER_OTLP_INSECURE" envDefault:"true"`
+		ServiceName string  `env:"OTEL_SERVICE_NAME" envDefault:"adtime-bot"`
+		SampleRatio float64 `env:"OTEL_SAMPLE_RATIO" envDefault:"1.0"`
+	}
+
+	Limits Limits
+
 	MaxDimensions struct {
 		Width  int `env:"MAX_WIDTH" envDefault:"80"`
 		Height int `env:"MAX_HEIGHT" envDefault:"50"`
 	}
+
+	// file is CONFIG_FILE; mu guards the reloadable sections
+	file string
+	mu   *sync.RWMutex
 }
 
 func Load() (*Config, error) {
+	cfg, err := parse(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.mu = new(sync.RWMutex)
+	return cfg, nil
+}
+
+// parse reads the environment with the KEY=VALUE lines of file, if any, on
+// top: the file is what Reload reads again. Secret references are resolved
+// before validation.
+func parse(file string) (*Config, error) {
+	environment := env.ToMap(os.Environ())
+	var fromFile map[string]string
+	if file != "" {
+		var err error
+		if fromFile, err = readEnvFile(file); err != nil {
+			return nil, err
+		}
+		maps.Copy(environment, fromFile)
+	}
+
 	var cfg Config
-	if err := env.Parse(&cfg); err != nil {
+	if err := env.ParseWithOptions(&cfg, env.Options{Environment: environment}); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	if err := resolveSecrets(&cfg, fromFile); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets:\n%w", err)
+	}
+
 	if err := cfg.Validate(); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid config:\n%w", err)
 	}
 
+	cfg.file = file
 	return &cfg, nil
 }
 
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := make(map[string]string)
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("config file %s, line %d: expected KEY=VALUE", path, n+1)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, nil
+}
+
+// Validate checks the whole config and reports every problem at once, one
+// per line, so a misconfigured deployment is fixed in a single pass
 func (c *Config) Validate() error {
-	if c.Telegram.Token == "" {
-		return errors.New("telegram token is required")
+	var p problems
+
+	p.require(c.Telegram.Token, "TELEGRAM_TOKEN")
+	p.require(c.Database.Host, "DB_HOST")
+	p.require(c.Database.Name, "DB_NAME")
+	p.require(c.Database.User, "DB_USER")
+	p.require(c.Redis.Addr, "REDIS_ADDR")
+
+	positive(&p, "TELEGRAM_WORKERS", c.Telegram.Workers)
+	notNegative(&p, "TELEGRAM_QUEUE_SIZE", c.Telegram.QueueSize)
+
+	p.between("DB_PORT", c.Database.Port, 1, 65535)
+	positive(&p, "DB_MAX_OPEN_CONNS", c.Database.MaxOpenConns)
+	p.between("DB_MAX_IDLE_CONNS", c.Database.MaxIdleConns, 0, c.Database.MaxOpenConns)
+	notNegative(&p, "DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime)
+	notNegative(&p, "DB_CONN_MAX_IDLE_TIME", c.Database.ConnMaxIdleTime)
+	notNegative(&p, "DB_STATEMENT_CACHE_SIZE", c.Database.StatementCacheSize)
+	notNegative(&p, "DB_QUERY_TIMEOUT", c.Database.QueryTimeout)
+	positive(&p, "DB_EXPORT_TIMEOUT", c.Database.ExportTimeout)
+	positive(&p, "DB_REPLICA_CHECK_INTERVAL", c.Database.ReplicaCheckInterval)
+
+	// Redis has 16 databases unless reconfigured
+	p.between("REDIS_DB", c.Redis.DB, 0, 15)
+	positive(&p, "REDIS_TTL", c.Redis.TTL)
+	positive(&p, "REDIS_TEXTURE_CHECK_INTERVAL", c.Redis.TextureCheckInterval)
+	positive(&p, "REDIS_UPDATE_DEDUP_TTL", c.Redis.UpdateDedupTTL)
+	notNegative(&p, "REDIS_RETRIES", c.Redis.Retries)
+	notNegative(&p, "REDIS_RETRY_BACKOFF", c.Redis.RetryBackoff)
+	positive(&p, "REDIS_BREAKER_THRESHOLD", c.Redis.BreakerThreshold)
+	positive(&p, "REDIS_BREAKER_COOLDOWN", c.Redis.BreakerCooldown)
+
+	if !c.Currency.Valid() {
+		p.add("CURRENCY must be an ISO 4217 code like RUB, got %q", c.Currency)
+	}
+	if !c.Unit.Valid() {
+		p.add("DEFAULT_UNIT must be mm, cm or m, got %q", c.Unit)
+	}
+	if c.ExchangeRates.Source != "cbr" && c.ExchangeRates.Source != "ecb" {
+		p.add("FX_SOURCE must be cbr or ecb, got %q", c.ExchangeRates.Source)
+	}
+	positive(&p, "FX_TTL", c.ExchangeRates.TTL)
+	positive(&p, "FX_TIMEOUT", c.ExchangeRates.Timeout)
+	if c.ExchangeRates.MaxAge < c.ExchangeRates.TTL {
+		p.add("FX_MAX_AGE must be at least FX_TTL, got %s", c.ExchangeRates.MaxAge)
+	}
+	positive(&p, "LEATHER_PRICE_PER_DM2", c.Pricing.LeatherPricePerDM2)
+	notNegative(&p, "PROCESSING_COST_PER_DM2", c.Pricing.ProcessingCostPerDM2)
+	p.rate("PAYMENT_COMMISSION_RATE", c.Pricing.PaymentCommissionRate)
+	p.rate("SALES_TAX_RATE", c.Pricing.SalesTaxRate)
+	if !slices.Contains([]string{"usn_income", "usn_profit", "npd", "vat"}, c.Pricing.TaxRegime) {
+		p.add("TAX_REGIME must be usn_income, usn_profit, npd or vat, got %q", c.Pricing.TaxRegime)
+	}
+	positive(&p, "MARKUP_MULTIPLIER", c.Pricing.MarkupMultiplier)
+	positive(&p, "STANDARD_LEAD_TIME", c.Pricing.StandardLeadTime)
+	notNegative(&p, "RUSH_SURCHARGE_RATE", c.Pricing.RushSurchargeRate)
+	positive(&p, "RUSH_LEAD_TIME", c.Pricing.RushLeadTime)
+	notNegative(&p, "DELIVERY_COURIER_COST", c.Pricing.CourierCost)
+	notNegative(&p, "DELIVERY_POST_COST", c.Pricing.PostCost)
+	notNegative(&p, "DELIVERY_FREE_FROM", c.Pricing.DeliveryFreeFrom)
+
+	positive(&p, "STICKER_MIN_SIZE_CM", c.Stickers.MinSizeCM)
+	p.between("STICKER_MAX_WIDTH_CM", c.Stickers.MaxWidthCM, c.Stickers.MinSizeCM, 80)
+	p.between("STICKER_MAX_HEIGHT_CM", c.Stickers.MaxHeightCM, c.Stickers.MinSizeCM, 50)
+	positive(&p, "STICKER_MAX_QUANTITY", c.Stickers.MaxQuantity)
+	positive(&p, "STICKER_MAX_PREVIEW_BYTES", c.Stickers.MaxPreviewBytes)
+	positive(&p, "TYPOGRAPHY_MAX_QUANTITY", c.Typography.MaxQuantity)
+	positive(&p, "TYPOGRAPHY_MAX_LAYOUT_BYTES", c.Typography.MaxLayoutBytes)
+
+	if c.ObjectStore.Endpoint != "" {
+		p.require(c.ObjectStore.Bucket, "OBJECT_STORE_BUCKET (with OBJECT_STORE_ENDPOINT)")
+		positive(&p, "OBJECT_STORE_LINK_TTL", c.ObjectStore.LinkTTL)
+	}
+
+	if c.API.Enabled && len(c.API.Keys) == 0 {
+		p.add("API_KEYS are required when the API is enabled")
+	}
+	if c.GRPC.Enabled && len(c.GRPC.Keys) == 0 {
+		p.add("GRPC_API_KEYS are required when gRPC is enabled")
+	}
+
+	positive(&p, "OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+	positive(&p, "OUTBOX_BATCH_SIZE", c.Outbox.BatchSize)
+	positive(&p, "OUTBOX_LEASE", c.Outbox.Lease)
+	positive(&p, "OUTBOX_MAX_ATTEMPTS", c.Outbox.MaxAttempts)
+	for _, event := range c.Outbox.CRMWebhookEvents {
+		if event != "order.created" && event != "order.status_changed" {
+			p.add("CRM_WEBHOOK_EVENTS must list order.created and/or order.status_changed, got %q", event)
+		}
+	}
+	if c.Outbox.SheetsSpreadsheetID != "" {
+		p.require(c.Outbox.SheetsCredentials, "SHEETS_CREDENTIALS (for SHEETS_SPREADSHEET_ID)")
+		p.require(c.Outbox.SheetsSheet, "SHEETS_SHEET (for SHEETS_SPREADSHEET_ID)")
 	}
 
-	if c.Database.Host == "" {
-		return errors.New("database host is required")
+	switch c.Broker.Kind {
+	case "":
+	case "nats", "kafka":
+		if len(c.Broker.URLs) == 0 {
+			p.add("BROKER_URLS are required when BROKER is set")
+		}
+		p.require(c.Broker.Topic, "BROKER_TOPIC (for BROKER)")
+		positive(&p, "BROKER_TIMEOUT", c.Broker.Timeout)
+		// customers are published under the pseudonyms of the anonymized exports
+		p.require(c.Export.AnonymizationKey, "EXPORT_ANONYMIZATION_KEY (for BROKER)")
+	default:
+		p.add("BROKER must be nats, kafka or empty, got %q", c.Broker.Kind)
 	}
 
-	if c.Database.Name == "" {
-		return errors.New("database name is required")
+	switch c.Analytics.Target {
+	case "":
+	case "postgres", "clickhouse":
+		if c.Analytics.Target == "clickhouse" {
+			p.require(c.Analytics.ClickHouseURL, "ANALYTICS_CLICKHOUSE_URL (for ANALYTICS_TARGET=clickhouse)")
+			p.require(c.Analytics.ClickHouseDatabase, "ANALYTICS_CLICKHOUSE_DATABASE (for ANALYTICS_TARGET=clickhouse)")
+			positive(&p, "ANALYTICS_TIMEOUT", c.Analytics.Timeout)
+		}
+		p.between("ANALYTICS_HOUR", c.Analytics.Hour, 0, 23)
+		positive(&p, "ANALYTICS_BATCH_SIZE", c.Analytics.BatchSize)
+		// customers are exported under the pseudonyms of the anonymized exports
+		p.require(c.Export.AnonymizationKey, "EXPORT_ANONYMIZATION_KEY (for ANALYTICS_TARGET)")
+	default:
+		p.add("ANALYTICS_TARGET must be postgres, clickhouse or empty, got %q", c.Analytics.Target)
 	}
 
+	positive(&p, "SUPPORT_RESPONSE_SLA", c.Support.ResponseSLA)
+	positive(&p, "SUPPORT_SLA_CHECK_INTERVAL", c.Support.SLACheckInterval)
+	switch c.Transcription.Provider {
+	case "":
+	case "whisper":
+		p.require(c.Transcription.URL, "TRANSCRIPTION_URL (for TRANSCRIPTION_PROVIDER)")
+		p.require(c.Transcription.Model, "TRANSCRIPTION_MODEL (for TRANSCRIPTION_PROVIDER)")
+		positive(&p, "TRANSCRIPTION_MAX_DURATION", c.Transcription.MaxDuration)
+		positive(&p, "TRANSCRIPTION_TIMEOUT", c.Transcription.Timeout)
+	default:
+		p.add("TRANSCRIPTION_PROVIDER must be whisper or empty, got %q", c.Transcription.Provider)
+	}
+	switch c.SizeEstimation.Provider {
+	case "":
+	case "http":
+		p.require(c.SizeEstimation.URL, "SIZE_ESTIMATION_URL (for SIZE_ESTIMATION_PROVIDER)")
+		if c.SizeEstimation.MinConfidence < 0 || c.SizeEstimation.MinConfidence > 1 {
+			p.add("SIZE_ESTIMATION_MIN_CONFIDENCE must be between 0 and 1, got %v", c.SizeEstimation.MinConfidence)
+		}
+		positive(&p, "SIZE_ESTIMATION_MAX_PHOTO_BYTES", c.SizeEstimation.MaxPhotoBytes)
+		positive(&p, "SIZE_ESTIMATION_TIMEOUT", c.SizeEstimation.Timeout)
+	default:
+		p.add("SIZE_ESTIMATION_PROVIDER must be http or empty, got %q", c.SizeEstimation.Provider)
+	}
+	notNegative(&p, "STOCK_LOW_DM2", c.Stock.LowDM2)
+	positive(&p, "STOCK_CHECK_INTERVAL", c.Stock.CheckInterval)
+	positive(&p, "TEXTURE_PREVIEW_MAX_SIDE", c.TexturePreviews.MaxSide)
+	p.between("TEXTURE_PREVIEW_QUALITY", c.TexturePreviews.Quality, 1, 100)
+	positive(&p, "TEXTURE_PREVIEW_MAX_BYTES", c.TexturePreviews.MaxBytes)
+	positive(&p, "TEXTURE_PREVIEW_TIMEOUT", c.TexturePreviews.Timeout)
+	positive(&p, "TEXTURE_PREVIEW_CACHE_TTL", c.TexturePreviews.CacheTTL)
+	positive(&p, "DEADLINE_WARNING", c.Deadlines.Warning)
+	positive(&p, "DEADLINE_CHECK_INTERVAL", c.Deadlines.CheckInterval)
+	positive(&p, "REPORT_DEBOUNCE", c.Reports.Debounce)
+	notNegative(&p, "ARCHIVE_AFTER", c.Archive.After)
+	positive(&p, "ARCHIVE_INTERVAL", c.Archive.Interval)
+	positive(&p, "ARCHIVE_BATCH_SIZE", c.Archive.BatchSize)
+	// the statistics count the last 30 days from the orders table alone
+	if c.Archive.After > 0 && c.Archive.After < 31*24*time.Hour {
+		p.add("ARCHIVE_AFTER must be at least 744h, got %s", c.Archive.After)
+	}
+	notNegative(&p, "BACKUP_INTERVAL", c.Backup.Interval)
+	if c.Backup.Interval > 0 {
+		p.require(c.ObjectStore.Endpoint, "OBJECT_STORE_ENDPOINT (for BACKUP_INTERVAL)")
+	}
+	positive(&p, "CUSTOMERS_SYNC_INTERVAL", c.Customers.SyncInterval)
+	notNegative(&p, "LOYALTY_POINTS_RATE", c.Loyalty.PointsRate)
+	positive(&p, "LOYALTY_POINTS_TTL", c.Loyalty.PointsTTL)
+	p.between("LOYALTY_EXPIRY_HOUR", c.Loyalty.ExpiryHour, 0, 23)
+	for i, tier := range c.Loyalty.Tiers {
+		if tier.Discount <= 0 || tier.Discount >= 100 {
+			p.add("LOYALTY_TIERS: discount of %s must be within 0..100, got %v", tier.Name, tier.Discount)
+		}
+		if i > 0 && tier.MinPoints <= c.Loyalty.Tiers[i-1].MinPoints {
+			p.add("LOYALTY_TIERS: tiers must go from the fewest points up, %s comes after %s", tier.Name, c.Loyalty.Tiers[i-1].Name)
+		}
+	}
+	for _, amount := range c.GiftCards.Denominations {
+		positive(&p, "GIFT_CARD_DENOMINATIONS", amount)
+	}
+	positive(&p, "GIFT_CARD_TTL", c.GiftCards.TTL)
+	seen := make(map[string]bool)
+	for _, e := range c.Experiments.Running {
+		if seen[e.Name] {
+			p.add("EXPERIMENTS: %s is listed twice", e.Name)
+		}
+		seen[e.Name] = true
+	}
+	notNegative(&p, "REFERRAL_BONUS", c.Referral.Bonus)
+
+	switch c.Phone.Verification {
+	case "off":
+	case "sms":
+		p.require(c.Phone.SMSRuAPIID, "SMSRU_API_ID (for PHONE_VERIFICATION=sms)")
+	case "telegram":
+		p.require(c.Phone.GatewayToken, "TELEGRAM_GATEWAY_TOKEN (for PHONE_VERIFICATION=telegram)")
+	default:
+		p.add("PHONE_VERIFICATION must be off, sms or telegram, got %q", c.Phone.Verification)
+	}
+	positive(&p, "PHONE_CODE_TTL", c.Phone.CodeTTL)
+	positive(&p, "PHONE_CODE_MAX_ATTEMPTS", c.Phone.MaxAttempts)
+	positive(&p, "PHONE_CODES_PER_HOUR", c.Phone.CodesPerHour)
+
+	positive(&p, "ABUSE_FLOOD_LIMIT", c.Abuse.FloodLimit)
+	positive(&p, "ABUSE_FLOOD_WINDOW", c.Abuse.FloodWindow)
+	positive(&p, "ABUSE_STRIKES_TO_BAN", c.Abuse.StrikesToBan)
+	positive(&p, "ABUSE_STRIKE_WINDOW", c.Abuse.StrikeWindow)
+	positive(&p, "ABUSE_BAN_DURATION", c.Abuse.BanDuration)
+	if c.Abuse.MaxBan < c.Abuse.BanDuration {
+		p.add("ABUSE_MAX_BAN must be at least ABUSE_BAN_DURATION, got %v", c.Abuse.MaxBan)
+	}
+	positive(&p, "ABUSE_CAPTCHA_ATTEMPTS", c.Abuse.CaptchaAttempts)
+
+	positive(&p, "QUOTE_VALIDITY", c.Quotes.Validity)
+	if !slices.Contains([]int{0, 5, 7, 10, 20}, c.Accounting.VATRate) {
+		p.add("ACCOUNTING_VAT_RATE must be 0, 5, 7, 10 or 20, got %d", c.Accounting.VATRate)
+	}
+	p.between("PREPAYMENT_PERCENT", c.Payments.PrepaymentPercent, 0, 100)
+	if c.Payments.PrepaymentPercent > 0 && c.Payments.ProviderToken == "" {
+		p.add("PAYMENT_PROVIDER_TOKEN is required when PREPAYMENT_PERCENT is set")
+	}
+	notNegative(&p, "PAYMENTS_REPORT_INTERVAL", c.Payments.ReportInterval)
+	positive(&p, "SAVED_QUOTE_TTL", c.SavedQuotes.TTL)
+	notNegative(&p, "SAVED_QUOTE_VALIDITY", c.SavedQuotes.Validity)
+	if c.SavedQuotes.Validity > c.SavedQuotes.TTL {
+		p.add("SAVED_QUOTE_VALIDITY must not exceed SAVED_QUOTE_TTL, got %v", c.SavedQuotes.Validity)
+	}
+	positive(&p, "SAVED_QUOTE_MAX", c.SavedQuotes.Max)
+	notNegative(&p, "QUOTE_APPROVAL_FROM", c.QuoteApproval.From)
+	notNegative(&p, "REVIEW_DELAY", c.Reviews.Delay)
+
+	positive(&p, "STATS_RECONCILE_INTERVAL", c.Stats.ReconcileInterval)
+	// Telegram allows about 30 messages per second for the whole bot
+	p.between("BROADCAST_RATE", c.Broadcast.Rate, 1, 30)
+	positive(&p, "BROADCAST_POLL_INTERVAL", c.Broadcast.PollInterval)
+	positive(&p, "JOB_MAX_ATTEMPTS", c.Jobs.MaxAttempts)
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.add("OTEL_SAMPLE_RATIO must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	notNegative(&p, "LIMIT_MAX_OPEN_ORDERS", c.Limits.MaxOpenOrders)
+	notNegative(&p, "LIMIT_FIRST_ORDER_MAX_VALUE", c.Limits.FirstOrderMaxValue)
+	positive(&p, "LIMIT_VELOCITY_WINDOW", c.Limits.VelocityWindow)
+	notNegative(&p, "LIMIT_VELOCITY_MAX_ORDERS", c.Limits.VelocityMaxOrders)
+	notNegative(&p, "LIMIT_DUPLICATE_WINDOW", c.Limits.DuplicateWindow)
+
+	// orders_width_cm_check / orders_height_cm_check cap every product at 80x50
+	p.between("MAX_WIDTH", c.MaxDimensions.Width, 1, 80)
+	p.between("MAX_HEIGHT", c.MaxDimensions.Height, 1, 50)
+
+	return errors.Join(p...)
+}
+
+// problems collects what Validate finds wrong
+type problems []error
+
+func (p *problems) add(format string, args ...any) {
+	*p = append(*p, fmt.Errorf(format, args...))
+}
+
+func (p *problems) require(value, name string) {
+	if value == "" {
+		p.add("%s is required", name)
+	}
+}
+
+func (p *problems) between(name string, value, lo, hi int) {
+	if value < lo || value > hi {
+		p.add("%s must be between %d and %d, got %d", name, lo, hi, value)
+	}
+}
+
+// rate is a share of the price, e.g. 0.06 for 6%
+func (p *problems) rate(name string, value float64) {
+	if value < 0 || value >= 1 {
+		p.add("%s must be a fraction from 0 to 1, got %v", name, value)
+	}
+}
+
+type number interface {
+	~int | ~int64 | ~float64
+}
+
+func positive[T number](p *problems, name string, value T) {
+	if value <= 0 {
+		p.add("%s must be positive, got %v", name, value)
+	}
+}
+
+func notNegative[T number](p *problems, name string, value T) {
+	if value < 0 {
+		p.add("%s must not be negative, got %v", name, value)
+	}
+}
+
+type Admin struct {
+	ChatID    int64   `env:"ADMIN_CHAT_ID"`
+	ChannelID int64   `env:"CHANNEL_ID"`
+	IDs       []int64 `env:"ADMIN_IDS"`
+	// ProductionIDs are workshop staff: they work the /queue, nothing else
+	ProductionIDs []int64 `env:"PRODUCTION_IDS"`
+}
+
+type Pricing struct {
+	LeatherPricePerDM2    float64 `env:"LEATHER_PRICE_PER_DM2" envDefault:"25.0"`
+	ProcessingCostPerDM2  float64 `env:"PROCESSING_COST_PER_DM2" envDefault:"31.25"`
+	PaymentCommissionRate float64 `env:"PAYMENT_COMMISSION_RATE" envDefault:"0.03"`
+	SalesTaxRate          float64 `env:"SALES_TAX_RATE" envDefault:"0.06"`
+	// TaxRegime is how SALES_TAX_RATE applies: usn_income and npd tax the
+	// price, usn_profit the price less costs and commission, vat is
+	// included in the price. Pricing rules carry their own regime.
+	TaxRegime        string  `env:"TAX_REGIME" envDefault:"usn_income"`
+	MarkupMultiplier float64 `env:"MARKUP_MULTIPLIER" envDefault:"2.5"`
+
+	StandardLeadTime  time.Duration `env:"STANDARD_LEAD_TIME" envDefault:"168h"`
+	RushSurchargeRate float64       `env:"RUSH_SURCHARGE_RATE" envDefault:"0.3"`
+	RushLeadTime      time.Duration `env:"RUSH_LEAD_TIME" envDefault:"48h"`
+
+	// shipping charged to the customer; orders from DeliveryFreeFrom ship
+	// free, 0 disables free shipping
+	CourierCost      float64 `env:"DELIVERY_COURIER_COST" envDefault:"400"`
+	PostCost         float64 `env:"DELIVERY_POST_COST" envDefault:"350"`
+	DeliveryFreeFrom float64 `env:"DELIVERY_FREE_FROM" envDefault:"10000"`
+}
+
+type Limits struct {
+	MaxOpenOrders      int           `env:"LIMIT_MAX_OPEN_ORDERS" envDefault:"5"`
+	FirstOrderMaxValue float64       `env:"LIMIT_FIRST_ORDER_MAX_VALUE" envDefault:"30000"`
+	VelocityWindow     time.Duration `env:"LIMIT_VELOCITY_WINDOW" envDefault:"10m"`
+	VelocityMaxOrders  int           `env:"LIMIT_VELOCITY_MAX_ORDERS" envDefault:"3"`
+	VelocityMinValue   float64       `env:"LIMIT_VELOCITY_MIN_VALUE" envDefault:"10000"`
+	// the same order placed again within this window needs a confirmation; 0 disables the check
+	DuplicateWindow time.Duration `env:"LIMIT_DUPLICATE_WINDOW" envDefault:"10m"`
+}
+
+type Stats struct {
+	// live counters are checked against Postgres this often
+	ReconcileInterval time.Duration `env:"STATS_RECONCILE_INTERVAL" envDefault:"15m"`
+}
+
+// LoyaltyTier is one NAME:POINTS:PERCENT entry of LOYALTY_TIERS: customers
+// holding at least MinPoints get Discount percent off their orders
+type LoyaltyTier struct {
+	Name      string
+	MinPoints int
+	Discount  float64
+}
+
+func (t *LoyaltyTier) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("loyalty tier %q: expected NAME:POINTS:PERCENT", text)
+	}
+	minPoints, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || minPoints < 0 {
+		return fmt.Errorf("loyalty tier %q: bad points", text)
+	}
+	discount, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+	if err != nil {
+		return fmt.Errorf("loyalty tier %q: bad percent", text)
+	}
+
+	*t = LoyaltyTier{Name: strings.TrimSpace(parts[0]), MinPoints: minPoints, Discount: discount}
 	return nil
 }
+
+// Experiment is one NAME:VARIANT|VARIANT entry of EXPERIMENTS
+type Experiment struct {
+	Name     string
+	Variants []string
+}
+
+func (e *Experiment) UnmarshalText(text []byte) error {
+	name, list, ok := strings.Cut(string(text), ":")
+	name = strings.TrimSpace(name)
+	if !ok || !experimentName.MatchString(name) {
+		return fmt.Errorf("experiment %q: expected NAME:VARIANT|VARIANT", text)
+	}
+
+	var variants []string
+	for _, v := range strings.Split(list, "|") {
+		v = strings.TrimSpace(v)
+		if !experimentName.MatchString(v) || slices.Contains(variants, v) {
+			return fmt.Errorf("experiment %q: bad variant %q", text, v)
+		}
+		variants = append(variants, v)
+	}
+	if len(variants) < 2 {
+		return fmt.Errorf("experiment %q: needs at least two variants", text)
+	}
+
+	*e = Experiment{Name: name, Variants: variants}
+	return nil
+}
+
+// experimentName keeps names fit for message keys and callback data
+var experimentName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Reload reads the environment and CONFIG_FILE again and applies the
+// reloadable settings: admin and production IDs, pricing coefficients,
+// order limits and the statistics schedule. Other settings need a restart.
+// changed names the sections that differ; the config is left as is when
+// the new one is invalid.
+func (c *Config) Reload() (changed []string, err error) {
+	fresh, err := parse(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := c.lock()
+	defer unlock()
+
+	if !slices.Equal(c.Admin.IDs, fresh.Admin.IDs) {
+		changed = append(changed, "ADMIN_IDS")
+		c.Admin.IDs = fresh.Admin.IDs
+	}
+	if !slices.Equal(c.Admin.ProductionIDs, fresh.Admin.ProductionIDs) {
+		changed = append(changed, "PRODUCTION_IDS")
+		c.Admin.ProductionIDs = fresh.Admin.ProductionIDs
+	}
+	if c.Pricing != fresh.Pricing {
+		changed = append(changed, "pricing")
+		c.Pricing = fresh.Pricing
+	}
+	if c.Limits != fresh.Limits {
+		changed = append(changed, "limits")
+		c.Limits = fresh.Limits
+	}
+	if c.Stats != fresh.Stats {
+		changed = append(changed, "stats")
+		c.Stats = fresh.Stats
+	}
+	return changed, nil
+}
+
+// IsAdmin reports whether the user is listed in ADMIN_IDS
+func (c *Config) IsAdmin(userID int64) bool {
+	unlock := c.rlock()
+	defer unlock()
+	return slices.Contains(c.Admin.IDs, userID)
+}
+
+// IsProduction reports whether the user may work the production queue:
+// listed in PRODUCTION_IDS or an admin
+func (c *Config) IsProduction(userID int64) bool {
+	unlock := c.rlock()
+	defer unlock()
+	return slices.Contains(c.Admin.ProductionIDs, userID) || slices.Contains(c.Admin.IDs, userID)
+}
+
+func (c *Config) CurrentPricing() Pricing {
+	unlock := c.rlock()
+	defer unlock()
+	return c.Pricing
+}
+
+func (c *Config) CurrentLimits() Limits {
+	unlock := c.rlock()
+	defer unlock()
+	return c.Limits
+}
+
+func (c *Config) CurrentStats() Stats {
+	unlock := c.rlock()
+	defer unlock()
+	return c.Stats
+}
+
+// A Config not made by Load (e.g. in a one-off tool) is never reloaded
+// and needs no locking
+
+func (c *Config) lock() func() {
+	if c.mu == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	return c.mu.Unlock
+}
+
+func (c *Config) rlock() func() {
+	if c.mu == nil {
+		return func() {}
+	}
+	c.mu.RLock()
+	return c.mu.RUnlock
+}
+
+// Watcher reloads the config on SIGHUP and on request from admins
+type Watcher struct {
+	cfg    *Config
+	logger *zap.Logger
+}
+
+func NewWatcher(cfg *Config, logger *zap.Logger) *Watcher {
+	return &Watcher{
+		cfg:    cfg,
+		logger: logger.Named("config"),
+	}
+}
+
+// Reload applies the current settings and logs what changed
+func (w *Watcher) Reload() ([]string, error) {
+	changed, err := w.cfg.Reload()
+	if err != nil {
+		w.logger.Error("Config reload failed, keeping the current one", zap.Error(err))
+		return nil, err
+	}
+	w.logger.Info("Config reloaded", zap.Strings("changed", changed))
+	return changed, nil
+}
+
+// Watch reloads the config on every SIGHUP until ctx ends
+func (w *Watcher) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			_, _ = w.Reload()
+		}
+	}
+}
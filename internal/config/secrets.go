@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretsTimeout bounds resolving all secrets at load or reload
+const secretsTimeout = 15 * time.Second
+
+// SecretProvider resolves a secret reference. ref is what follows the
+// scheme, e.g. "secret/data/adtime#db_password" for "vault:".
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SecretProvider{"env": EnvProvider{}}
+)
+
+// RegisterSecretProvider makes references "<scheme>:<ref>" resolve through
+// p. It must be called before Load.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = p
+}
+
+// EnvProvider reads the secret from another environment variable, so a
+// deployment can keep its own names: TELEGRAM_TOKEN=env:BOT_TOKEN
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// VaultProvider reads secrets from HashiCorp Vault KV engines (v1 or v2)
+// with a token: "vault:secret/data/adtime#db_password". Each path is read
+// once per load.
+type VaultProvider struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+
+	mu    sync.Mutex
+	paths map[string]map[string]any
+}
+
+func NewVaultProvider(addr, token, namespace string) *VaultProvider {
+	return &VaultProvider{
+		addr:      strings.TrimRight(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		paths:     make(map[string]map[string]any),
+	}
+}
+
+func (v *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference %q must be <path>#<key>", ref)
+	}
+
+	data, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %q", path, key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s#%s is not a string", path, key)
+	}
+	return s, nil
+}
+
+func (v *VaultProvider) read(ctx context.Context, path string) (map[string]any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if data, ok := v.paths[path]; ok {
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		v.addr+"/v1/"+(&url.URL{Path: strings.TrimLeft(path, "/")}).EscapedPath(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read vault secret %s: %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault secret %s: %w", path, err)
+	}
+
+	// KV v2 nests the values under data.data next to data.metadata
+	data := body.Data
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = inner
+		}
+	}
+
+	v.paths[path] = data
+	return data, nil
+}
+
+// resolveSecrets replaces the references in the fields tagged secret with
+// their values. fromFile holds the variables set by CONFIG_FILE: a secret
+// written there in plain text is an error.
+func resolveSecrets(cfg *Config, fromFile map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	providersMu.RLock()
+	schemes := make(map[string]SecretProvider, len(providers)+1)
+	for scheme, p := range providers {
+		schemes[scheme] = p
+	}
+	providersMu.RUnlock()
+	if cfg.Vault.Addr != "" {
+		schemes["vault"] = NewVaultProvider(cfg.Vault.Addr, cfg.Vault.Token, cfg.Vault.Namespace)
+	}
+
+	var p problems
+	if _, ok := fromFile["VAULT_TOKEN"]; ok {
+		p.add("VAULT_TOKEN must come from the environment, not CONFIG_FILE")
+	}
+
+	resolve := func(name, value string) string {
+		scheme, ref, _ := strings.Cut(value, ":")
+		provider, ok := schemes[scheme]
+		if !ok {
+			if _, inFile := fromFile[name]; inFile && value != "" {
+				p.add("%s is a secret: CONFIG_FILE may only reference it (env:NAME or vault:<path>#<key>)", name)
+			}
+			return value
+		}
+		if scheme == "vault" && cfg.Vault.Addr == "" {
+			p.add("%s references Vault, but VAULT_ADDR is not set", name)
+			return ""
+		}
+
+		secret, err := provider.Resolve(ctx, ref)
+		if err != nil {
+			p.add("%s: %w", name, err)
+			return ""
+		}
+		return secret
+	}
+
+	forEachSecret(reflect.ValueOf(cfg).Elem(), func(name string, field reflect.Value) {
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(resolve(name, field.String()))
+		case reflect.Slice:
+			for i := range field.Len() {
+				item := field.Index(i)
+				item.SetString(resolve(name, item.String()))
+			}
+		}
+	})
+
+	return errors.Join(p...)
+}
+
+// forEachSecret calls fn for every settable field tagged secret:"true",
+// named by its environment variable
+func forEachSecret(v reflect.Value, fn func(name string, field reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field, sf := v.Field(i), t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if field.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Time{}) {
+			forEachSecret(field, fn)
+			continue
+		}
+		if sf.Tag.Get("secret") != "true" {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("env"), ",")
+		fn(name, field)
+	}
+}
@@ -0,0 +1,65 @@
+package customers
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service keeps the customers table in step with the orders: each order
+// event refreshes its customer, and a periodic sync rebuilds everyone
+type Service struct {
+	customers *postgres.CustomersRepository
+	logger    *zap.Logger
+	cfg       *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		customers: storage.Customers(),
+		logger:    logger.Named("customers"),
+		cfg:       cfg,
+	}
+}
+
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "customers.refresh", s.OnOrderChanged)
+	bus.Subscribe(events.OrderStatusChanged, "customers.refresh", s.OnOrderChanged)
+}
+
+// OnOrderChanged recomputes the customer of the order
+func (s *Service) OnOrderChanged(ctx context.Context, event events.Event) error {
+	if event.UserID == 0 {
+		return nil
+	}
+	return s.customers.Refresh(ctx, event.UserID)
+}
+
+// Watch syncs the whole table at start and on every interval until ctx is
+// cancelled
+func (s *Service) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Customers.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		n, err := s.customers.Sync(ctx)
+		if err != nil {
+			s.logger.Error("Failed to sync customers", zap.Error(err))
+		} else {
+			s.logger.Info("Customers synced",
+				zap.Int64("customers", n),
+				zap.Duration("took", time.Since(start)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package deadlines
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/templates"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Watcher reminds the production chat about orders close to their
+// deadline and about overdue ones
+type Watcher struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Watcher {
+	return &Watcher{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("deadlines"),
+		cfg:     cfg,
+	}
+}
+
+// Watch checks deadlines until ctx is cancelled
+func (w *Watcher) Watch(ctx context.Context) {
+	chatID := w.cfg.Deadlines.ChatID
+	if chatID == 0 {
+		chatID = w.cfg.Admin.ChatID
+	}
+	if chatID == 0 {
+		w.logger.Warn("No production chat configured, deadline reminders are off")
+		return
+	}
+
+	ticker := time.NewTicker(w.cfg.Deadlines.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reminders, err := w.storage.ClaimDeadlineReminders(ctx, w.cfg.Deadlines.Warning)
+		if err != nil {
+			w.logger.Error("Failed to check order deadlines", zap.Error(err))
+			continue
+		}
+
+		now := time.Now()
+		for _, r := range reminders {
+			text, err := reminderText(r, now)
+			if err != nil {
+				w.logger.Error("Failed to render deadline reminder", zap.Int64("order_id", r.OrderID), zap.Error(err))
+				continue
+			}
+			if _, err := w.botAPI.Send(text.To(chatID)); err != nil {
+				w.logger.Error("Failed to send deadline reminder",
+					zap.Int64("order_id", r.OrderID),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+func reminderText(r postgres.DeadlineReminder, now time.Time) (templates.Message, error) {
+	left := r.DueAt.Sub(now)
+	if r.Kind == postgres.ReminderOverdue {
+		left = now.Sub(r.DueAt)
+	}
+	return templates.Render(templates.DeadlineReminder{
+		OrderID: r.OrderID,
+		Rush:    r.IsRush,
+		Overdue: r.Kind == postgres.ReminderOverdue,
+		DueAt:   r.DueAt,
+		Left:    left,
+		Started: r.Status == postgres.StatusProcessing,
+	})
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"s1ntez/internal/storage/postgres"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Type string
+
+const (
+	OrderCreated       Type = "order.created"
+	OrderStatusChanged Type = "order.status_changed"
+)
+
+// Event is an in-process domain notification. It is published after the
+// corresponding change is committed to Postgres.
+type Event struct {
+	Type       Type                 `json:"type"`
+	OrderID    int64                `json:"order_id"`
+	UserID     int64                `json:"user_id"`
+	Status     postgres.OrderStatus `json:"status,omitempty"`
+	PrevStatus postgres.OrderStatus `json:"prev_status,omitempty"`
+	OccurredAt time.Time            `json:"occurred_at"`
+}
+
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus fans events out to subscribers. Each subscriber runs in its own
+// goroutine so a slow one (e.g. Telegram retries) never blocks the publisher.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[Type][]subscriber
+	logger      *zap.Logger
+	wg          sync.WaitGroup
+}
+
+func NewBus(logger *zap.Logger) *Bus {
+	return &Bus{
+		subscribers: make(map[Type][]subscriber),
+		logger:      logger,
+	}
+}
+
+func (b *Bus) Subscribe(eventType Type, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[eventType] = append(b.subscribers[eventType], subscriber{name: name, handler: handler})
+}
+
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	// Subscribers must outlive the update that triggered the event
+	ctx = context.WithoutCancel(ctx)
+
+	for _, sub := range subs {
+		b.wg.Add(1)
+		go func(sub subscriber) {
+			defer b.wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					b.logger.Error("Event subscriber panicked",
+						zap.String("subscriber", sub.name),
+						zap.String("event", string(event.Type)),
+						zap.Any("panic", p))
+				}
+			}()
+
+			if err := sub.handler(ctx, event); err != nil {
+				b.logger.Error("Event subscriber failed",
+					zap.String("subscriber", sub.name),
+					zap.String("event", string(event.Type)),
+					zap.Int64("order_id", event.OrderID),
+					zap.Error(err))
+			}
+		}(sub)
+	}
+}
+
+// Wait blocks until in-flight subscribers finish, used on shutdown
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
@@ -0,0 +1,118 @@
+// Package experiments runs A/B tests on the bot copy. EXPERIMENTS lists
+// the running experiments and their variants; every customer is put in one
+// variant of each by a hash of their ID, so they see the same copy every
+// time. The first time a customer is shown a variant is recorded as the
+// exposure, their first order after it as the conversion, and
+// /admin experiments compares the variants.
+package experiments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Experiments the code knows how to show. Each is off until EXPERIMENTS
+// lists it; variants without special handling get the control's layout
+// and their own copy where the catalog has it.
+const (
+	// SummaryConfirm tries the confirm button of the order summary. Its
+	// text is order.confirm@<variant> where the catalog has one, e.g.
+	// order.confirm@cta; the "bottom" variant moves it under the other
+	// buttons. Run it with summary_confirm:control|cta|bottom.
+	SummaryConfirm = "summary_confirm"
+)
+
+// VariantBottom is the SummaryConfirm variant with the button last
+const VariantBottom = "bottom"
+
+// Service assigns variants and records what customers were shown and
+// whether they ordered
+type Service struct {
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		logger:  logger.Named("experiments"),
+		cfg:     cfg,
+	}
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "experiments.convert", s.OnOrderCreated)
+}
+
+// Running returns the experiment if EXPERIMENTS lists it
+func (s *Service) Running(name string) (config.Experiment, bool) {
+	for _, e := range s.cfg.Experiments.Running {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return config.Experiment{}, false
+}
+
+// Variant returns the customer's variant of the experiment and records
+// that they are shown it. It is "" when the experiment isn't running,
+// which callers show as the control.
+func (s *Service) Variant(ctx context.Context, name string, userID int64) string {
+	e, ok := s.Running(name)
+	if !ok || userID == 0 {
+		return ""
+	}
+
+	variant := Assign(e, userID)
+	// A lost exposure skews the numbers a little but must not break the dialog
+	if err := s.storage.RecordExposure(ctx, e.Name, userID, variant); err != nil {
+		s.logger.Warn("Failed to record exposure",
+			zap.String("experiment", e.Name),
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+	return variant
+}
+
+// Assign picks the customer's variant. The same customer always gets the
+// same variant while the list of variants stays the same.
+func Assign(e config.Experiment, userID int64) string {
+	sum := sha256.Sum256([]byte(e.Name + ":" + strconv.FormatInt(userID, 10)))
+	return e.Variants[binary.BigEndian.Uint64(sum[:8])%uint64(len(e.Variants))]
+}
+
+// T is i18n.T with the variant's copy: key@variant when the catalog has
+// it, the key itself otherwise
+func T(locale i18n.Locale, variant, key string, args ...any) string {
+	if variant != "" && i18n.Has(locale, key+"@"+variant) {
+		key += "@" + variant
+	}
+	return i18n.T(locale, key, args...)
+}
+
+// OnOrderCreated counts the order as the conversion of every experiment
+// the customer was shown and hadn't ordered in yet
+func (s *Service) OnOrderCreated(ctx context.Context, event events.Event) error {
+	if event.UserID == 0 {
+		return nil
+	}
+	n, err := s.storage.RecordConversion(ctx, event.UserID, event.OrderID)
+	if err != nil || n == 0 {
+		return err
+	}
+	s.logger.Debug("Experiment conversion recorded",
+		zap.Int64("user_id", event.UserID),
+		zap.Int64("order_id", event.OrderID),
+		zap.Int64("experiments", n))
+	return nil
+}
@@ -0,0 +1,95 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const CallbackPrefix = "hold"
+
+// Verdict of the pre-acceptance checks. Orders are never rejected outright:
+// suspicious ones are held until an admin approves or cancels them.
+type Verdict struct {
+	Hold    bool
+	Reasons []string
+}
+
+type Guard struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Guard {
+	return &Guard{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger,
+		cfg:     cfg,
+	}
+}
+
+// Check evaluates caps and velocity for a new order of the given value.
+// It must run before the order is saved so the order itself isn't counted.
+func (g *Guard) Check(ctx context.Context, userID int64, price float64) (Verdict, error) {
+	limits := g.cfg.CurrentLimits()
+
+	counters, err := g.storage.GetUserOrderCounters(ctx, userID,
+		time.Now().Add(-limits.VelocityWindow), limits.VelocityMinValue)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	var v Verdict
+	if limits.MaxOpenOrders > 0 && counters.Open >= limits.MaxOpenOrders {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("открытых заказов: %d (лимит %d)", counters.Open, limits.MaxOpenOrders))
+	}
+	if counters.Total == 0 && limits.FirstOrderMaxValue > 0 && price > limits.FirstOrderMaxValue {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("первый заказ на %.2f ₽ (лимит %.2f ₽)", price, limits.FirstOrderMaxValue))
+	}
+	if limits.VelocityMaxOrders > 0 && price >= limits.VelocityMinValue &&
+		counters.RecentHighValue+1 > limits.VelocityMaxOrders {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("%d дорогих заказов за %s",
+			counters.RecentHighValue+1, limits.VelocityWindow))
+	}
+
+	v.Hold = len(v.Reasons) > 0
+	return v, nil
+}
+
+// Reason is what the checks found, as the hold records it
+func (v Verdict) Reason() string {
+	return strings.Join(v.Reasons, "; ")
+}
+
+// RequestReview asks admins to decide on an order saved on hold
+func (g *Guard) RequestReview(ctx context.Context, order postgres.Order) error {
+	g.logger.Warn("Order held for review",
+		zap.Int64("order_id", order.ID),
+		zap.Int64("user_id", order.UserID),
+		zap.String("reason", order.HoldReason))
+
+	if g.cfg.Admin.ChatID == 0 {
+		return nil
+	}
+
+	msg := tgbotapi.NewMessage(g.cfg.Admin.ChatID, fmt.Sprintf(
+		"🛑 Заказ #%d на проверке\nПользователь: %d\nСумма: %.2f ₽\nПричина: %s",
+		order.ID, order.UserID, order.Price, order.HoldReason,
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Одобрить", fmt.Sprintf("%s:approve:%d", CallbackPrefix, order.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("❌ Отменить", fmt.Sprintf("%s:reject:%d", CallbackPrefix, order.ID)),
+	))
+
+	_, err := g.botAPI.Send(msg)
+	return err
+}
@@ -0,0 +1,196 @@
+// Package fxrates converts material prices set in a foreign currency, e.g.
+// imported leather priced in euros, to the order currency
+package fxrates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"s1ntez/internal/config"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/redis"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnavailable means no rates could be fetched and none recent
+	// enough are cached
+	ErrUnavailable = errors.New("exchange rates are unavailable")
+	// ErrUnknownCurrency means the source publishes no rate for the currency
+	ErrUnknownCurrency = errors.New("no exchange rate for currency")
+)
+
+// Table is one publication of rates: Rates[c] is the price of one unit of c
+// in Base
+type Table struct {
+	Base  money.Currency             `json:"base"`
+	Date  time.Time                  `json:"date"`
+	Rates map[money.Currency]float64 `json:"rates"`
+	// FetchedAt is when the table was downloaded
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// in re-expresses the rates in another base currency, e.g. the euro
+// rates of the ECB in dollars
+func (t Table) in(base money.Currency) (Table, error) {
+	if base == t.Base {
+		return t, nil
+	}
+	unit, ok := t.Rates[base]
+	if !ok {
+		return Table{}, fmt.Errorf("%w: %s in %s rates", ErrUnknownCurrency, base, t.Base)
+	}
+
+	rebased := Table{Base: base, Date: t.Date, Rates: make(map[money.Currency]float64, len(t.Rates)), FetchedAt: t.FetchedAt}
+	for currency, rate := range t.Rates {
+		rebased.Rates[currency] = rate / unit
+	}
+	return rebased, nil
+}
+
+// Service keeps the latest rates in memory and in Redis, so the bot and the
+// API share one download per FX_TTL. While the source is down the last
+// rates are used for up to FX_MAX_AGE.
+type Service struct {
+	source Source
+	redis  *redis.Client
+	logger *zap.Logger
+	cfg    *config.Config
+
+	mu      sync.Mutex
+	current *Table
+}
+
+func New(redisClient *redis.Client, logger *zap.Logger, cfg *config.Config) *Service {
+	client := &http.Client{Timeout: cfg.ExchangeRates.Timeout}
+
+	url := cfg.ExchangeRates.URL
+	var source Source
+	switch cfg.ExchangeRates.Source {
+	case "ecb":
+		if url == "" {
+			url = ecbURL
+		}
+		source = &ecb{url: url, client: client}
+	default:
+		if url == "" {
+			url = cbrURL
+		}
+		source = &cbr{url: url, client: client}
+	}
+
+	return &Service{
+		source: source,
+		redis:  redisClient,
+		logger: logger.Named("fxrates"),
+		cfg:    cfg,
+	}
+}
+
+// Rate returns the price of one unit of the currency in the order
+// currency; 1 for the order currency itself and for an empty one
+func (s *Service) Rate(ctx context.Context, currency money.Currency) (float64, error) {
+	if currency == "" || currency == s.cfg.Currency {
+		return 1, nil
+	}
+
+	table, err := s.table(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rate, ok := table.Rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return rate, nil
+}
+
+// Convert prices a material in the order currency, rounded to the minor
+// unit, and returns the rate it applied
+func (s *Service) Convert(ctx context.Context, price float64, currency money.Currency) (float64, float64, error) {
+	rate, err := s.Rate(ctx, currency)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rate == 1 {
+		return price, 1, nil
+	}
+	return money.FromFloat(price).MulRate(rate).Float(), rate, nil
+}
+
+func (s *Service) cacheKey() string {
+	return fmt.Sprintf("fxrates:%s:%s", s.cfg.ExchangeRates.Source, s.cfg.Currency)
+}
+
+// table returns rates fresher than FX_TTL, downloading them when neither
+// memory nor Redis has them
+func (s *Service) table(ctx context.Context) (Table, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	fresh := func(t *Table) bool { return t != nil && now.Sub(t.FetchedAt) < s.cfg.ExchangeRates.TTL }
+
+	if fresh(s.current) {
+		return *s.current, nil
+	}
+	if cached := s.cached(ctx); cached != nil && (s.current == nil || cached.FetchedAt.After(s.current.FetchedAt)) {
+		s.current = cached
+		if fresh(cached) {
+			return *cached, nil
+		}
+	}
+
+	table, err := s.source.Fetch(ctx)
+	if err == nil {
+		table, err = table.in(s.cfg.Currency)
+	}
+	if err != nil {
+		if s.current != nil && now.Sub(s.current.FetchedAt) < s.cfg.ExchangeRates.MaxAge {
+			s.logger.Warn("Failed to fetch exchange rates, using the last ones",
+				zap.Time("fetched_at", s.current.FetchedAt),
+				zap.Error(err))
+			return *s.current, nil
+		}
+		return Table{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	table.FetchedAt = now
+	s.current = &table
+	s.store(ctx, table)
+
+	s.logger.Info("Exchange rates updated",
+		zap.String("base", string(table.Base)),
+		zap.Time("date", table.Date),
+		zap.Int("currencies", len(table.Rates)))
+	return table, nil
+}
+
+func (s *Service) cached(ctx context.Context) *Table {
+	data, err := s.redis.Get(ctx, s.cacheKey())
+	if err != nil {
+		return nil
+	}
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil || table.Base != s.cfg.Currency {
+		return nil
+	}
+	return &table
+}
+
+// store keeps the rates in Redis for as long as they may stand in for a
+// failed download
+func (s *Service) store(ctx context.Context, table Table) {
+	data, err := json.Marshal(table)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, s.cacheKey(), data, s.cfg.ExchangeRates.MaxAge); err != nil {
+		s.logger.Warn("Failed to cache exchange rates", zap.Error(err))
+	}
+}
@@ -0,0 +1,136 @@
+package fxrates
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Source downloads the latest published rates
+type Source interface {
+	Fetch(ctx context.Context) (Table, error)
+}
+
+const (
+	cbrURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+	ecbURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+)
+
+// cbr reads the official daily rates of the Bank of Russia, in roubles
+type cbr struct {
+	url    string
+	client *http.Client
+}
+
+func (c *cbr) Fetch(ctx context.Context) (Table, error) {
+	var doc struct {
+		Date    string `xml:"Date,attr"`
+		Valutes []struct {
+			CharCode string `xml:"CharCode"`
+			Nominal  string `xml:"Nominal"`
+			Value    string `xml:"Value"`
+		} `xml:"Valute"`
+	}
+	if err := fetchXML(ctx, c.client, c.url, &doc); err != nil {
+		return Table{}, fmt.Errorf("cbr: %w", err)
+	}
+
+	date, err := time.Parse("02.01.2006", doc.Date)
+	if err != nil {
+		return Table{}, fmt.Errorf("cbr: bad date %q", doc.Date)
+	}
+
+	table := Table{Base: money.RUB, Date: date, Rates: map[money.Currency]float64{money.RUB: 1}}
+	for _, v := range doc.Valutes {
+		// rates are quoted per Nominal units with a decimal comma: 10 CNY = 112,3456
+		nominal, err := strconv.Atoi(strings.TrimSpace(v.Nominal))
+		if err != nil || nominal <= 0 {
+			return Table{}, fmt.Errorf("cbr: bad nominal %q of %s", v.Nominal, v.CharCode)
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v.Value), ",", "."), 64)
+		if err != nil || value <= 0 {
+			return Table{}, fmt.Errorf("cbr: bad rate %q of %s", v.Value, v.CharCode)
+		}
+		table.Rates[money.Currency(v.CharCode)] = value / float64(nominal)
+	}
+	return table, nil
+}
+
+// ecb reads the euro reference rates of the European Central Bank
+type ecb struct {
+	url    string
+	client *http.Client
+}
+
+func (e *ecb) Fetch(ctx context.Context) (Table, error) {
+	var doc struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}
+	if err := fetchXML(ctx, e.client, e.url, &doc); err != nil {
+		return Table{}, fmt.Errorf("ecb: %w", err)
+	}
+	if len(doc.Days) == 0 {
+		return Table{}, fmt.Errorf("ecb: no rates published")
+	}
+
+	day := doc.Days[0]
+	date, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return Table{}, fmt.Errorf("ecb: bad date %q", day.Time)
+	}
+
+	const eur money.Currency = "EUR"
+	table := Table{Base: eur, Date: date, Rates: map[money.Currency]float64{eur: 1}}
+	for _, r := range day.Rates {
+		if r.Rate <= 0 {
+			return Table{}, fmt.Errorf("ecb: bad rate %v of %s", r.Rate, r.Currency)
+		}
+		// the ECB quotes units of the currency per euro
+		table.Rates[money.Currency(r.Currency)] = 1 / r.Rate
+	}
+	return table, nil
+}
+
+func fetchXML(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	decoder := xml.NewDecoder(resp.Body)
+	// the Bank of Russia still serves windows-1251
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if strings.EqualFold(label, "windows-1251") {
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("bad response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Package giftcards sells and redeems gift cards. A customer asks for a
+// card of one of GIFT_CARD_DENOMINATIONS, staff issue it once it is paid
+// and the code goes to the customer; only its hash is stored. The code pays
+// for orders at checkout and what an order leaves on the card stays there
+// for the next one.
+package giftcards
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// codePrefix starts every code, so the checkout tells a card from a promo
+// code
+const codePrefix = "GIFT"
+
+// codeLength is the random part of a code: 16 base32 characters, 80 bits
+const codeLength = 16
+
+var ErrInvalidDenomination = errors.New("gift card amount is not on sale")
+
+// Service issues, checks and refunds gift cards
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("giftcards"),
+		cfg:     cfg,
+	}
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "giftcards.refund", s.OnStatusChanged)
+}
+
+// OnSale reports whether customers can buy cards
+func (s *Service) OnSale() bool {
+	return len(s.cfg.GiftCards.Denominations) > 0
+}
+
+// Normalize makes a typed code comparable: upper case, dashes in their
+// places whatever the customer typed. Anything that isn't a code is
+// returned as typed.
+func Normalize(raw string) string {
+	code := strings.ToUpper(raw)
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	random, ok := strings.CutPrefix(code, codePrefix)
+	if !ok || len(random) != codeLength {
+		return strings.TrimSpace(raw)
+	}
+	if _, err := base32.StdEncoding.DecodeString(random); err != nil {
+		return strings.TrimSpace(raw)
+	}
+
+	parts := []string{codePrefix}
+	for i := 0; i < codeLength; i += 4 {
+		parts = append(parts, random[i:i+4])
+	}
+	return strings.Join(parts, "-")
+}
+
+// IsCode reports whether the text is a gift card code rather than a
+// promo code
+func IsCode(raw string) bool {
+	return strings.HasPrefix(Normalize(raw), codePrefix+"-")
+}
+
+// Hash is what is stored of a code
+func Hash(code string) string {
+	sum := sha256.Sum256([]byte(Normalize(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Hint is the end of a code, shown to tell cards apart
+func Hint(code string) string {
+	code = Normalize(code)
+	return code[len(code)-4:]
+}
+
+// Check returns the card of the code if it can pay for an order now
+func (s *Service) Check(ctx context.Context, code string, now time.Time) (*postgres.GiftCard, error) {
+	card, err := s.storage.GetGiftCardByHash(ctx, Hash(code))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case card.Status == postgres.GiftCardVoid:
+		return nil, postgres.ErrGiftCardVoid
+	case card.Status != postgres.GiftCardActive:
+		return nil, postgres.ErrGiftCardNotFound
+	case card.ExpiresAt != nil && !now.Before(*card.ExpiresAt):
+		return nil, postgres.ErrGiftCardExpired
+	case card.Balance <= 0:
+		return nil, postgres.ErrGiftCardEmpty
+	}
+	return card, nil
+}
+
+// Request records a customer's order for a card and tells the admin chat,
+// where it is issued once paid
+func (s *Service) Request(ctx context.Context, userID int64, amount money.Amount) (int64, error) {
+	if !slices.ContainsFunc(s.cfg.GiftCards.Denominations, func(d float64) bool {
+		return money.FromFloat(d) == amount
+	}) {
+		return 0, fmt.Errorf("%w: %.2f", ErrInvalidDenomination, amount)
+	}
+
+	id, err := s.storage.RequestGiftCard(ctx, userID, amount, s.cfg.Currency)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("Gift card requested",
+		zap.Int64("request_id", id),
+		zap.Int64("user_id", userID),
+		zap.Stringer("amount", amount))
+
+	if s.cfg.Admin.ChatID != 0 {
+		msg := tgbotapi.NewMessage(s.cfg.Admin.ChatID, fmt.Sprintf(
+			"🎁 Заявка на подарочную карту #%d: %.2f %s, клиент <code>%d</code>\nПосле оплаты: /issuegift %d",
+			id, amount, s.cfg.Currency.Symbol(), userID, id))
+		msg.ParseMode = tgbotapi.ModeHTML
+		if _, err := s.botAPI.Send(msg); err != nil {
+			// the request is in /giftcards either way
+			s.logger.Warn("Failed to notify admins of gift card request", zap.Int64("request_id", id), zap.Error(err))
+		}
+	}
+	return id, nil
+}
+
+// Issue activates a paid request and sends the code to its buyer. The code
+// is returned for staff to pass on if the message doesn't get through.
+func (s *Service) Issue(ctx context.Context, requestID, adminID int64) (*postgres.GiftCard, string, error) {
+	code, err := newCode()
+	if err != nil {
+		return nil, "", err
+	}
+	card, err := s.storage.IssueGiftCard(ctx, requestID, Hash(code), Hint(code), adminID, time.Now().Add(s.cfg.GiftCards.TTL))
+	if err != nil {
+		return nil, "", err
+	}
+	s.logger.Info("Gift card issued",
+		zap.Int64("gift_card_id", card.ID),
+		zap.Int64("admin_id", adminID))
+
+	if card.BuyerID != nil {
+		locale, err := s.storage.GetUserLocale(ctx, *card.BuyerID)
+		if err != nil {
+			s.logger.Warn("Failed to get user locale", zap.Error(err))
+		}
+		msg := tgbotapi.NewMessage(*card.BuyerID, i18n.T(locale, "giftcard.issued",
+			card.Amount, code, card.ExpiresAt.Format("02.01.2006")))
+		msg.ParseMode = tgbotapi.ModeHTML
+		if _, err := s.botAPI.Send(msg); err != nil {
+			s.logger.Warn("Failed to send gift card to buyer", zap.Int64("gift_card_id", card.ID), zap.Error(err))
+		}
+	}
+	return card, code, nil
+}
+
+// IssueNew makes an active card nobody requested, e.g. sold in person,
+// and returns it with its code
+func (s *Service) IssueNew(ctx context.Context, amount money.Amount, adminID int64) (*postgres.GiftCard, string, error) {
+	if amount <= 0 {
+		return nil, "", fmt.Errorf("%w: %.2f", ErrInvalidDenomination, amount)
+	}
+	code, err := newCode()
+	if err != nil {
+		return nil, "", err
+	}
+	card, err := s.storage.CreateGiftCard(ctx, amount, s.cfg.Currency, Hash(code), Hint(code), adminID, time.Now().Add(s.cfg.GiftCards.TTL))
+	if err != nil {
+		return nil, "", err
+	}
+	s.logger.Info("Gift card issued",
+		zap.Int64("gift_card_id", card.ID),
+		zap.Int64("admin_id", adminID))
+	return card, code, nil
+}
+
+// OnStatusChanged tells the customer what a cancelled order put back on
+// their gift card. The refund itself is made with the status change.
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if event.Status != postgres.StatusCancelled {
+		return nil
+	}
+
+	amount, err := s.storage.GetGiftCardRefund(ctx, event.OrderID)
+	if err != nil || amount == 0 {
+		return err
+	}
+
+	locale, err := s.storage.GetUserLocale(ctx, event.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	msg := tgbotapi.NewMessage(event.UserID, i18n.T(locale, "giftcard.refunded", amount))
+	msg.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.botAPI.Send(msg); err != nil {
+		// the balance is back either way
+		s.logger.Warn("Failed to notify customer", zap.Int64("user_id", event.UserID), zap.Error(err))
+	}
+	return nil
+}
+
+// newCode returns a code like GIFT-ABCD-EFGH-IJKL-MNOP
+func newCode() (string, error) {
+	buf := make([]byte, codeLength*5/8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate gift card code: %w", err)
+	}
+	return Normalize(codePrefix + base32.StdEncoding.EncodeToString(buf)), nil
+}
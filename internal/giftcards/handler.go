@@ -0,0 +1,282 @@
+package giftcards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const callbackPrefix = "giftcard"
+
+// listLimit caps /giftcards
+const listLimit = 30
+
+// Handler wires the service to Telegram:
+//
+//	/giftcard [code]            — the cards on sale, or the balance of a card
+//	/issuegift <request>        — staff issue a paid request, the code goes to the buyer
+//	/issuegift new <amount>     — staff issue a card of their own, e.g. sold in person
+//	/voidgift <id | code>       — staff cancel a card or a request
+//	/giftcards                  — staff see the pending requests and recent cards
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.Named("giftcards"),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if msg.Command() == "giftcard" {
+		if !msg.Chat.IsPrivate() {
+			return nil
+		}
+		return h.customer(ctx, msg)
+	}
+
+	if !auth.Has(ctx, auth.Admin) {
+		return nil
+	}
+	args := strings.Fields(msg.CommandArguments())
+	switch msg.Command() {
+	case "issuegift":
+		return h.issue(ctx, msg, args)
+	case "voidgift":
+		return h.void(ctx, msg, args)
+	default:
+		return h.list(ctx, msg.Chat.ID)
+	}
+}
+
+// customer shows the balance of the card in the arguments, or offers the
+// cards on sale
+func (h *Handler) customer(ctx context.Context, msg *tgbotapi.Message) error {
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	if code := strings.TrimSpace(msg.CommandArguments()); code != "" {
+		card, err := h.service.Check(ctx, code, time.Now())
+		if key, rejected := Rejection(err); rejected {
+			return h.send(msg.Chat.ID, i18n.T(locale, key), nil)
+		}
+		if err != nil {
+			return err
+		}
+		return h.send(msg.Chat.ID, i18n.T(locale, "giftcard.balance",
+			card.CodeHint, card.Balance, card.Amount, card.ExpiresAt.Format("02.01.2006")), nil)
+	}
+
+	if !h.service.OnSale() {
+		return h.send(msg.Chat.ID, i18n.T(locale, "giftcard.off"), nil)
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, d := range h.service.cfg.GiftCards.Denominations {
+		amount := money.FromFloat(d)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(locale, "giftcard.buy_button", amount),
+			fmt.Sprintf("%s:buy:%s", callbackPrefix, amount))))
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return h.send(msg.Chat.ID, i18n.T(locale, "giftcard.offer", int(h.service.cfg.GiftCards.TTL/(24*time.Hour))), &markup)
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	locale, err := h.service.storage.GetUserLocale(ctx, query.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	// giftcard:buy:<amount>
+	raw, ok := strings.CutPrefix(query.Data, callbackPrefix+":buy:")
+	amount, err := money.Parse(raw)
+	if !ok || err != nil {
+		return fmt.Errorf("bad gift card callback %q", query.Data)
+	}
+
+	id, err := h.service.Request(ctx, query.From.ID, amount)
+	if errors.Is(err, ErrInvalidDenomination) {
+		// the denominations changed since the offer
+		_, _ = h.service.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "giftcard.off")))
+		return nil
+	}
+	if err != nil {
+		_, _ = h.service.botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "error.generic")))
+		return err
+	}
+	_, _ = h.service.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	return h.send(query.Message.Chat.ID, i18n.T(locale, "giftcard.requested", id, amount), nil)
+}
+
+func (h *Handler) issue(ctx context.Context, msg *tgbotapi.Message, args []string) error {
+	usage := "Использование: /issuegift <номер заявки> или /issuegift new <сумма>"
+
+	var (
+		card   *postgres.GiftCard
+		code   string
+		before any
+		err    error
+	)
+	switch {
+	case len(args) == 2 && args[0] == "new":
+		amount, perr := money.Parse(args[1])
+		if perr != nil || amount <= 0 {
+			return h.reply(msg.Chat.ID, usage)
+		}
+		card, code, err = h.service.IssueNew(ctx, amount, msg.From.ID)
+	case len(args) == 1:
+		id, perr := strconv.ParseInt(args[0], 10, 64)
+		if perr != nil {
+			return h.reply(msg.Chat.ID, usage)
+		}
+		card, code, err = h.service.Issue(ctx, id, msg.From.ID)
+		before = map[string]any{"status": postgres.GiftCardRequested}
+	default:
+		return h.reply(msg.Chat.ID, usage)
+	}
+	if errors.Is(err, postgres.ErrGiftCardNotRequested) {
+		return h.reply(msg.Chat.ID, fmt.Sprintf("Заявки #%s нет или она уже обработана. /giftcards", args[0]))
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("gift_card:%d", card.ID),
+		before, map[string]any{"status": card.Status, "amount": card.Amount})
+
+	text := fmt.Sprintf("✅ Подарочная карта #%d на %.2f %s до %s\nКод: <code>%s</code>",
+		card.ID, card.Amount, h.service.cfg.Currency.Symbol(), card.ExpiresAt.Format("02.01.2006"), code)
+	if card.BuyerID != nil {
+		text += fmt.Sprintf("\nКод отправлен покупателю <code>%d</code>", *card.BuyerID)
+	} else {
+		text += "\nКод показан только здесь: передайте его покупателю"
+	}
+	return h.reply(msg.Chat.ID, text)
+}
+
+func (h *Handler) void(ctx context.Context, msg *tgbotapi.Message, args []string) error {
+	usage := "Использование: /voidgift <номер карты или код>"
+	if len(args) == 0 {
+		return h.reply(msg.Chat.ID, usage)
+	}
+
+	var (
+		card *postgres.GiftCard
+		err  error
+	)
+	raw := strings.Join(args, "")
+	if IsCode(raw) {
+		card, err = h.service.storage.GetGiftCardByHash(ctx, Hash(raw))
+	} else {
+		id, perr := strconv.ParseInt(raw, 10, 64)
+		if perr != nil {
+			return h.reply(msg.Chat.ID, usage)
+		}
+		card, err = h.service.storage.GetGiftCard(ctx, id)
+	}
+	if errors.Is(err, postgres.ErrGiftCardNotFound) {
+		return h.reply(msg.Chat.ID, "Такой карты нет")
+	}
+	if err != nil {
+		return err
+	}
+
+	voided, err := h.service.storage.VoidGiftCard(ctx, card.ID)
+	if errors.Is(err, postgres.ErrGiftCardNotFound) {
+		return h.reply(msg.Chat.ID, fmt.Sprintf("Карта #%d уже аннулирована", card.ID))
+	}
+	if err != nil {
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("gift_card:%d", card.ID),
+		map[string]any{"status": card.Status, "balance": card.Balance},
+		map[string]any{"status": voided.Status, "balance": voided.Balance})
+	h.logger.Info("Gift card voided",
+		zap.Int64("gift_card_id", card.ID),
+		zap.Int64("admin_id", msg.From.ID))
+
+	if card.Status == postgres.GiftCardRequested {
+		return h.reply(msg.Chat.ID, fmt.Sprintf("🚫 Заявка #%d отменена", card.ID))
+	}
+	return h.reply(msg.Chat.ID, fmt.Sprintf("🚫 Карта #%d аннулирована, остаток %.2f %s больше не принимается",
+		card.ID, voided.Balance, h.service.cfg.Currency.Symbol()))
+}
+
+func (h *Handler) list(ctx context.Context, chatID int64) error {
+	cards, err := h.service.storage.ListGiftCards(ctx, listLimit)
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		return h.reply(chatID, "Подарочных карт пока нет")
+	}
+
+	symbol := h.service.cfg.Currency.Symbol()
+	var text strings.Builder
+	text.WriteString("<b>Подарочные карты</b>\n")
+	for _, c := range cards {
+		switch c.Status {
+		case postgres.GiftCardRequested:
+			fmt.Fprintf(&text, "\n⏳ Заявка #%d: %.2f %s, клиент <code>%d</code>, %s",
+				c.ID, c.Amount, symbol, *c.BuyerID, c.CreatedAt.Format("02.01.2006 15:04"))
+		case postgres.GiftCardVoid:
+			fmt.Fprintf(&text, "\n🚫 #%d …%s: %.2f %s, аннулирована", c.ID, c.CodeHint, c.Amount, symbol)
+		default:
+			fmt.Fprintf(&text, "\n🎁 #%d …%s: %.2f из %.2f %s", c.ID, c.CodeHint, c.Balance, c.Amount, symbol)
+			if c.ExpiresAt != nil {
+				fmt.Fprintf(&text, ", до %s", c.ExpiresAt.Format("02.01.2006"))
+			}
+		}
+	}
+	return h.reply(chatID, text.String())
+}
+
+func (h *Handler) send(chatID int64, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	_, err := h.service.botAPI.Send(msg)
+	return err
+}
+
+func (h *Handler) reply(chatID int64, text string) error {
+	return h.send(chatID, text, nil)
+}
+
+// Rejection returns the message key explaining why the card can't pay;
+// ok is false for any other error
+func Rejection(err error) (key string, ok bool) {
+	switch {
+	case errors.Is(err, postgres.ErrGiftCardNotFound):
+		return "giftcard.not_found", true
+	case errors.Is(err, postgres.ErrGiftCardExpired):
+		return "giftcard.expired", true
+	case errors.Is(err, postgres.ErrGiftCardVoid):
+		return "giftcard.void", true
+	case errors.Is(err, postgres.ErrGiftCardEmpty):
+		return "giftcard.empty", true
+	case errors.Is(err, postgres.ErrGiftCardBalance):
+		return "giftcard.balance_changed", true
+	}
+	return "", false
+}
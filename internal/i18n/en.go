@@ -0,0 +1,327 @@
+package i18n
+
+var en = map[string]string{
+	"language.name":    "English",
+	"language.choose":  "Choose your language:",
+	"language.changed": "Language changed: %s",
+
+	"error.generic":      "Something went wrong, please try again later",
+	"error.rate_limited": "Too many requests, please wait a moment",
+	"error.unavailable":  "Order forms are temporarily unavailable, please try again in a few minutes",
+	"error.forbidden":    "You don't have access to this",
+
+	"abuse.slow_down":      "You're sending messages too fast. Please slow down, or the bot will stop answering for a while",
+	"abuse.banned":         "Too many messages. The bot will answer you again after %s",
+	"abuse.captcha":        "Please confirm you're a person: how much is %d + %d?",
+	"abuse.captcha_passed": "Thank you! Please send your request again",
+
+	"calc.usage":           "Usage: <code>/calc 30x40 Nappa</code>",
+	"calc.too_large":       "Maximum size is %s",
+	"calc.unknown_texture": "Texture %q not found",
+	"calc.quote":           "<b>Price quote</b>\n\nSize: %s\nTexture: %s\nArea: %.1f dm²\n\nLeather: %.2f ₽\nProcessing: %.2f ₽\nCommission: %.2f ₽\nTax: %.2f ₽\n\n<b>Total: %.2f ₽</b>",
+
+	"inline.usage":       "Type a size and a texture, e.g. 30x40 Nappa",
+	"inline.title":       "%s — %.2f ₽",
+	"inline.description": "%s, %.1f dm². Tap to send the quote",
+
+	"unit.mm":     "mm",
+	"unit.cm":     "cm",
+	"unit.m":      "m",
+	"unit.length": "%s %s",
+	"unit.size":   "%s × %s %s",
+
+	"validate.size":     "Couldn't read the size. Example: <code>%s</code>, from %s, at most %s",
+	"validate.size_max": "Couldn't read the size. Example: <code>%s</code>, at most %s",
+	"validate.quantity": "Quantity must be a whole number from 1 to %d",
+	"validate.text":     "Please send from %d to %d characters",
+
+	"texture.more_info": "More info",
+	"texture.price":     "Price: %.2f %s/dm²",
+	"texture.care":      "Care",
+
+	"catalog.empty": "No materials in stock right now",
+	"catalog.more":  "And %d more — compare every material in /compare",
+
+	"compare.choose":       "Choose two or three materials to compare",
+	"compare.button":       "Compare",
+	"compare.too_many":     "At most %d materials can be compared",
+	"compare.too_few":      "Choose at least two materials",
+	"compare.empty":        "No materials to compare right now",
+	"compare.title":        "Material comparison",
+	"compare.price":        "Price/dm²",
+	"compare.stock":        "Stock",
+	"compare.in_stock":     "in stock",
+	"compare.out_of_stock": "out of stock",
+	"compare.stock_left":   "%.0f dm²",
+
+	"notify.status_changed":      "Your order %s is now: %s",
+	"notify.opt_out_hint":        "Turn off notifications: /notifications off",
+	"notify.delivery":            "Delivery: %s",
+	"notify.note":                "📝 Note: %s",
+	"notifications.on":           "Order notifications are on",
+	"notifications.off":          "Order notifications are off",
+	"notifications.usage":        "Usage: /notifications on|off",
+	"status.new":                 "new",
+	"status.processing":          "in production",
+	"status.completed":           "ready",
+	"status.cancelled":           "cancelled",
+	"status.on_hold":             "under review",
+	"status.awaiting_prepayment": "awaiting prepayment",
+	"status.pending_quote":       "awaiting price approval",
+
+	"myorders.prompt":       "Tap an order to open its card. Your whole history is available as a spreadsheet",
+	"myorders.download":     "📥 Download my orders",
+	"myorders.caption":      "Your orders",
+	"myorders.order_button": "%s · %s · %.2f ₽",
+	"myorders.card":         "<b>Order %s</b>\nStatus: %s\nTotal: %.2f ₽\nPlaced: %s",
+	"myorders.ready_by":     "Ready by: %s",
+	"myorders.notes":        "<b>Notes from the workshop</b>",
+	"myorders.not_found":    "Order not found",
+
+	"saved.save_button":   "💾 Save for later",
+	"saved.done":          "💾 Quote saved. The price holds until %s, the quote is kept until %s. Get back to it with /saved",
+	"saved.full":          "You can keep at most %d quotes. Delete some under /saved",
+	"saved.title":         "<b>Saved quotes</b>",
+	"saved.item":          "%d. %s\n%.2f %s, saved %s, kept until %s",
+	"saved.empty":         "No saved quotes. Save one with \"Save for later\" on an order summary",
+	"saved.order_button":  "🛒 Order #%d",
+	"saved.delete_button": "🗑 Delete #%d",
+	"saved.deleted":       "Quote deleted",
+	"saved.gone":          "This quote has expired or was deleted, please place a new order",
+	"saved.price_held":    "💾 The price of your saved quote holds until %s",
+	"saved.price_current": "💾 The price of your saved quote has expired, it is recalculated at today's rates",
+	"saved.title_sticker": "Stickers: %s, %s, %d pcs",
+	"saved.title_print":   "%s %s, %s, %d pcs",
+
+	"support.unavailable":     "Support is unavailable right now, please try later",
+	"support.opened":          "🎫 Ticket #%d opened. Write your question — we will answer right here. Close it with /support close",
+	"support.already_open":    "You already have an open ticket — just send a message. Close it with /support close",
+	"support.none":            "You have no open tickets",
+	"support.closed":          "✅ Ticket #%d closed. Thank you!",
+	"support.closed_by_staff": "✅ Ticket #%d was closed by support. Still need help? /support",
+
+	"voice.noted":      "🎙 Voice note attached to the order, the workshop will get it. We heard:\n<i>%s</i>\n\nLet's go on from the same step.",
+	"voice.noted_file": "🎙 Voice note attached to the order, the workshop will get it. Let's go on from the same step.",
+
+	"sticker.choose_material":   "🏷 <b>Stickers</b>\n\nChoose the vinyl:",
+	"sticker.no_materials":      "No vinyl is available right now, please try later",
+	"sticker.ask_size":          "Size of one sticker, e.g. <code>%s</code>; a number without a unit is in %s.\nFrom %s, at most %s",
+	"sticker.size_by_photo":     "📷 Measure from a photo",
+	"sticker.ask_size_photo":    "Take a photo of the object the sticker goes on with a bank card next to it, the card serves as a ruler. Shoot straight on, with the whole object and card in the frame.\nOr just type the size",
+	"sticker.size_measured":     "📐 The photo gives about %s. Confirm it or type the exact size",
+	"sticker.size_confirm":      "✅ %s",
+	"sticker.size_not_measured": "We couldn't measure the photo: the card or the object isn't visible. Send another shot or type the size",
+	"sticker.ask_quantity":      "How many stickers? Pick one or type a number (up to %d)",
+	"sticker.ask_lamination":    "Lamination protects from scratches and water:",
+	"sticker.lamination.none":   "No lamination",
+	"sticker.lamination.gloss":  "Gloss",
+	"sticker.lamination.matte":  "Matte",
+	"sticker.ask_preview":       "Send your layout as an image or a file (PNG, JPEG, WebP, PDF). You can skip this and discuss it with a manager",
+	"sticker.skip_preview":      "Skip",
+	"sticker.preview_too_large": "The file is too large, maximum is %d MB",
+	"sticker.preview_format":    "PNG, JPEG, WebP and PDF are supported",
+	"sticker.preview_none":      "none, to discuss with a manager",
+	"sticker.preview_attached":  "attached",
+	"sticker.summary":           "<b>Your order</b>\n\nVinyl: %s\nSize: %s\nQuantity: %d pcs\nLamination: %s\nLayout: %s\nArea: %.1f dm²\n\n<b>Total: %.2f ₽</b>\nReady by: %s",
+	"sticker.cancelled":         "Sticker order cancelled",
+	"sticker.expired":           "This draft has expired, please start again: /stickers",
+	"sticker.unavailable":       "This vinyl has run out, please start again: /stickers",
+	"sticker.invalid":           "The order is outside the allowed limits, please start again: /stickers",
+	"sticker.placed":            "🎉 Order %s placed! Total: %.2f ₽. A manager will contact you",
+
+	"order.discount":          "Bulk discount: %.0f%%",
+	"order.rush_off":          "🔥 Rush",
+	"order.rush_on":           "✅ Rush",
+	"order.rush_surcharge":    "Rush production: +%.2f ₽",
+	"order.confirm":           "✅ Place order",
+	"order.confirm@cta":       "🚀 Order now",
+	"order.cancel":            "Cancel",
+	"order.ask_contact":       "Share your number with the button below or type it, like <code>+79991234567</code>",
+	"order.bad_contact":       "Phone number like <code>+79991234567</code> or <code>8 999 123-45-67</code>",
+	"order.share_contact":     "📱 Share my number",
+	"order.foreign_contact":   "Send your own contact with the button below or type the number",
+	"order.ask_code":          "We have sent a confirmation code to this number. Send it as a message",
+	"order.wrong_code":        "Wrong code, try again",
+	"order.code_expired":      "The code is no longer valid. Send the number again",
+	"order.too_many_codes":    "Too many code requests. Try later or share your number with the button",
+	"order.promo_button":      "🎟 Promo code / gift card",
+	"order.ask_promo":         "Send your promo code or gift card code",
+	"order.promo_skip":        "Without codes",
+	"order.promo_applied":     "Promo code %s: −%.2f ₽",
+	"order.loyalty_applied":   "%s tier discount: −%.2f ₽",
+	"order.gift_card_applied": "Gift card …%s: −%.2f ₽, to pay %.2f ₽",
+	"order.promo_not_found":   "No such promo code",
+	"order.promo_expired":     "This promo code has expired",
+	"order.promo_exhausted":   "This promo code has run out",
+	"order.promo_used":        "You have already used this promo code",
+	"order.repeated":          "You already placed the same order %s at %s. Place another one?",
+	"order.repeat_anyway":     "Yes, place another one",
+	"order.repeat_button":     "🔁 Repeat this order",
+	"order.repeat_up":         "📈 Order %s cost %.2f ₽, now it is %.2f ₽ more",
+	"order.repeat_down":       "📉 Order %s cost %.2f ₽, now it is %.2f ₽ less",
+	"order.repeat_same":       "Same price as order %s: %.2f ₽",
+	"order.quote_up":          "📈 The price has changed: it was %.2f ₽, now it is %.2f ₽ more. Please check the order and confirm again",
+	"order.quote_down":        "📉 The price has changed: it was %.2f ₽, now it is %.2f ₽ less. Please check the order and confirm again",
+	"order.quote_valid":       "⏱ This price holds until %s",
+	"order.approval_note":     "ℹ️ This is an estimate: a manager will check it and send you the final price",
+	"order.pending_quote":     "🎉 Order %s placed! A manager will check the estimate and send you the final price",
+	"order.repeat_layout":     "from order %s",
+	"order.repeat_gone":       "This order can't be repeated, please place a new one",
+	"order.edit":              "✏️ Edit",
+	"order.contact":           "Contact: %s",
+	"order.contact_saved":     "Contact updated",
+
+	"edit.prompt":     "What would you like to change?",
+	"edit.material":   "Material",
+	"edit.size":       "Size",
+	"edit.format":     "Format",
+	"edit.sides":      "Print sides",
+	"edit.quantity":   "Quantity",
+	"edit.lamination": "Lamination",
+	"edit.layout":     "Layout",
+	"edit.delivery":   "Delivery",
+	"edit.contact":    "Contact",
+	"edit.back":       "↩️ Back to the order",
+
+	"print.choose_product":        "🖨 <b>Printing</b>\n\nWhat shall we print?",
+	"print.product.business_card": "Business cards",
+	"print.product.flyer":         "Flyers",
+	"print.product.banner":        "Banner",
+	"print.choose_format":         "Choose the format:",
+	"print.format.custom":         "Custom size",
+	"print.ask_size":              "Banner size, e.g. <code>%s</code>; a number without a unit is in %s. At most %s",
+	"print.choose_material":       "Choose the paper:",
+	"print.no_materials":          "No suitable materials are available right now, please try later",
+	"print.ask_sides":             "One-sided or double-sided print?",
+	"print.sides.1":               "One-sided",
+	"print.sides.2":               "Double-sided",
+	"print.ask_quantity":          "How many? Pick one or type a number (up to %d)",
+	"print.ask_layout":            "Send the print file (PDF, AI, EPS, TIFF, PNG, JPEG). No layout yet? Skip and our designer will help",
+	"print.skip_layout":           "No layout",
+	"print.layout_too_large":      "The file is too large, maximum is %d MB. Send a link to support: /support",
+	"print.layout_format":         "PDF, AI, EPS, TIFF, PNG and JPEG are supported",
+	"print.layout_needed":         "design needed",
+	"print.layout_attached":       "attached",
+	"print.summary":               "<b>Your order</b>\n\nProduct: %s\nFormat: %s\nPaper: %s\nPrint: %s\nQuantity: %d pcs\nLayout: %s\n\n<b>Total: %.2f ₽</b>\nReady by: %s",
+	"print.cancelled":             "Print order cancelled",
+	"print.expired":               "This draft has expired, please start again: /print",
+	"print.unavailable":           "This material has run out, please start again: /print",
+	"print.invalid":               "The order is outside the allowed limits, please start again: /print",
+	"print.placed":                "🎉 Order %s placed! Total: %.2f ₽. A manager will contact you",
+
+	"delivery.ask":         "How would you like to get the order?",
+	"delivery.pickup":      "Pickup",
+	"delivery.courier":     "Courier",
+	"delivery.post":        "Post",
+	"delivery.ask_address": "Send the delivery address: city, street, house, apartment. For post, add the postcode",
+	"delivery.bad_address": "Please send the address as text, e.g. <code>Moscow, Tverskaya 1, apt. 5</code>",
+	"delivery.summary":     "Delivery: %s",
+	"delivery.shipping":    "Shipping: %.2f ₽",
+	"delivery.free":        "Shipping: free",
+	"delivery.change":      "🚚 Delivery",
+
+	"pickup.ready": "✅ Order %s is ready! Show this QR code when you pick it up.\nPickup code: <code>%s</code>",
+
+	"referral.info":     "🤝 <b>Invite friends</b>\n\nShare your link: %s\nYou get %.0f ₽ when a friend's first order is completed.\n\nInvited: %d\nOrdered: %d\nBonus balance: %.2f ₽",
+	"referral.credited": "🎉 Your friend's first order is completed: +%.2f ₽ to your bonus balance. /referral",
+
+	"giftcard.offer":           "🎁 <b>Gift cards</b>\n\nA gift card pays for orders in full or in part, whatever is left stays on it for the next order. It is valid for %d days.\n\nChoose the amount. We will contact you about the payment and send the code once it is paid.",
+	"giftcard.buy_button":      "🎁 %.0f ₽",
+	"giftcard.off":             "Gift cards are not on sale at the moment",
+	"giftcard.requested":       "Request #%d for a %.2f ₽ gift card is received. We will contact you about the payment.",
+	"giftcard.issued":          "🎁 Your %.2f ₽ gift card is ready!\n\nCode: <code>%s</code>\nValid until %s. Enter the code in the order summary with the promo code button.",
+	"giftcard.balance":         "🎁 Gift card …%s: %.2f ₽ left of %.2f ₽, valid until %s",
+	"giftcard.refunded":        "🎁 The order was cancelled: %.2f ₽ are back on your gift card",
+	"giftcard.not_found":       "No such gift card",
+	"giftcard.expired":         "This gift card has expired",
+	"giftcard.void":            "This gift card has been cancelled",
+	"giftcard.empty":           "There is nothing left on this gift card",
+	"giftcard.balance_changed": "The gift card balance has changed, please check the new price",
+
+	"payment.invoice_title":       "Prepayment for order %s",
+	"payment.invoice_description": "Prepayment of %d%% of the order total of %.2f ₽. The order goes to production as soon as it is paid.",
+	"payment.invoice_label":       "Prepayment",
+	"payment.declined":            "This invoice is no longer valid",
+	"payment.received":            "💳 Payment of %.2f ₽ for order %s received, thank you!",
+	"payment.cleared":             "Your order has gone to production.",
+	"payment.remaining":           "Left to pay: %.2f ₽",
+	"payment.refunded":            "💳 A refund of %.2f ₽ for order %s has been issued. The money will reach your card within a few days.",
+
+	"quote.approved":      "✅ The price of order %s is confirmed: %.2f ₽",
+	"quote.adjusted":      "✏️ A manager has repriced order %s: %.2f ₽ instead of the estimated %.2f ₽",
+	"quote.in_production": "Your order has gone to production.",
+
+	"review.ask":           "🙏 How was order %s? Please rate it from 1 to 5",
+	"review.ask_comment":   "Thanks for the %d! Tell us in a few words what you liked and what to improve, or tap “No comment”",
+	"review.skip_comment":  "No comment",
+	"review.thanks":        "Thank you for your feedback!",
+	"review.already_rated": "This order has been rated already",
+	"review.not_available": "This order can't be rated",
+
+	"reviews.title":   "💬 <b>What our customers say</b>",
+	"reviews.average": "Average rating: %.1f ⭐ (%d ratings)",
+	"reviews.none":    "No reviews yet. You can leave yours once your order is completed.",
+	"reviews.newer":   "◀️ Newer",
+	"reviews.older":   "Older ▶️",
+
+	"loyalty.accrued":   "⭐ Your order is completed: +%d bonus points, valid until %s. /bonus",
+	"loyalty.expired":   "⏳ %d bonus points have expired. /bonus",
+	"loyalty.off":       "The bonus program is not running at the moment",
+	"loyalty.balance":   "⭐ <b>Bonus points</b>\n\nBalance: %d",
+	"loyalty.tier":      "Tier: <b>%s</b>, %.0f%% off every order",
+	"loyalty.no_tier":   "No tier yet",
+	"loyalty.next_tier": "%d more points to %s, %.0f%% off",
+	"loyalty.expiring":  "%d points expire on %s",
+	"loyalty.how":       "Completed orders earn a point for every %.0f ₽ (delivery excluded). Points are valid for %d days.",
+
+	"mydata.caption": "Everything the bot stores about you: data.json has the records, orders.xlsx the orders. To have your data deleted, contact /support",
+
+	"profile.card":             "👤 <b>Profile</b>\n\nContact: %s\nDelivery: %s\nFavorite materials: %s\nUnits: %s\n\nSaved details are filled in when you order with /stickers or /print",
+	"profile.not_set":          "not set",
+	"profile.edit_contact":     "📱 Contact",
+	"profile.edit_delivery":    "🚚 Delivery",
+	"profile.edit_favorites":   "⭐ Favorite materials",
+	"profile.edit_units":       "📏 Units",
+	"profile.units_prompt":     "Which unit do you type and see sizes in? A size can always name its own, like <code>50x70mm</code> or <code>0.5x0.7m</code>",
+	"profile.clear":            "Clear delivery and favorites",
+	"profile.favorites_prompt": "Tap a material to add it to your favorites or remove it. Favorites come first when you order",
+	"profile.done":             "Done",
+	"profile.saved":            "Saved",
+
+	"export.id":             "ID",
+	"export.user_id":        "User ID",
+	"export.user_pseudonym": "User Pseudonym",
+	"export.width":          "Width (cm)",
+	"export.height":         "Height (cm)",
+	"export.width_mm":       "Width (mm)",
+	"export.height_mm":      "Height (mm)",
+	"export.width_m":        "Width (m)",
+	"export.height_m":       "Height (m)",
+	"export.texture_id":     "Texture ID",
+	"export.texture_name":   "Texture Name",
+	"export.price":          "Price",
+	"export.leather_cost":   "Leather Cost",
+	"export.process_cost":   "Process Cost",
+	"export.total_cost":     "Total Cost",
+	"export.commission":     "Commission",
+	"export.tax":            "Tax",
+	"export.net_revenue":    "Net Revenue",
+	"export.profit":         "Profit",
+	"export.contact":        "Contact",
+	"export.status":         "Status",
+	"export.created_at":     "Created At",
+	"export.rush":           "Rush",
+	"export.items":          "Items",
+	"export.delivery":       "Delivery",
+	"export.address":        "Address",
+	"export.shipping":       "Shipping",
+	"export.currency":       "Currency",
+	"export.code":           "Order code",
+	"export.gift_card":      "Paid by gift card",
+	"export.prepayment":     "Prepayment",
+	"export.paid":           "Paid",
+	"export.files":          "Files",
+	"export.file_telegram":  "%s, in the order chat",
+	"export.preview":        "Preview",
+	"export.texture_photo":  "Texture photo",
+}
@@ -0,0 +1,69 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Locale string
+
+const (
+	RU Locale = "ru"
+	EN Locale = "en"
+
+	Default = RU
+)
+
+var catalogs = map[Locale]map[string]string{
+	RU: ru,
+	EN: en,
+}
+
+// Supported returns the locales that have a message catalog
+func Supported() []Locale {
+	return []Locale{RU, EN}
+}
+
+// Parse maps a Telegram language_code ("ru", "en-US", ...) to a supported locale
+func Parse(code string) Locale {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+
+	if _, ok := catalogs[Locale(code)]; ok {
+		return Locale(code)
+	}
+	return Default
+}
+
+// T returns the message for key in the given locale, formatted with args.
+// Missing keys fall back to the default locale and then to the key itself.
+func T(locale Locale, key string, args ...any) string {
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		if msg, ok = catalogs[Default][key]; !ok {
+			msg = key
+		}
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has reports whether the key has a message in the locale or the default
+// one
+func Has(locale Locale, key string) bool {
+	if _, ok := catalogs[locale][key]; ok {
+		return true
+	}
+	_, ok := catalogs[Default][key]
+	return ok
+}
+
+// Name returns the human readable name of the locale in that locale
+func (l Locale) Name() string {
+	return T(l, "language.name")
+}
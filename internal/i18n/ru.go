@@ -0,0 +1,327 @@
+package i18n
+
+var ru = map[string]string{
+	"language.name":    "Русский",
+	"language.choose":  "Выберите язык:",
+	"language.changed": "Язык изменён: %s",
+
+	"error.generic":      "Произошла ошибка, попробуйте позже",
+	"error.rate_limited": "Слишком много запросов, подождите немного",
+	"error.unavailable":  "Оформление заказов временно недоступно, попробуйте через несколько минут",
+	"error.forbidden":    "Недостаточно прав",
+
+	"abuse.slow_down":      "Вы пишете слишком часто. Пожалуйста, помедленнее, иначе бот перестанет отвечать на время",
+	"abuse.banned":         "Слишком много сообщений. Бот снова ответит вам после %s",
+	"abuse.captcha":        "Подтвердите, что вы человек: сколько будет %d + %d?",
+	"abuse.captcha_passed": "Спасибо! Повторите, пожалуйста, ваш запрос",
+
+	"calc.usage":           "Использование: <code>/calc 30x40 Натуральная кожа</code>",
+	"calc.too_large":       "Максимальный размер: %s",
+	"calc.unknown_texture": "Текстура «%s» не найдена",
+	"calc.quote":           "<b>Расчёт стоимости</b>\n\nРазмер: %s\nТекстура: %s\nПлощадь: %.1f дм²\n\nКожа: %.2f ₽\nОбработка: %.2f ₽\nКомиссия: %.2f ₽\nНалог: %.2f ₽\n\n<b>Итого: %.2f ₽</b>",
+
+	"inline.usage":       "Введите размер и текстуру, например 30x40 Наппа",
+	"inline.title":       "%s — %.2f ₽",
+	"inline.description": "%s, %.1f дм². Нажмите, чтобы отправить расчёт",
+
+	"unit.mm":     "мм",
+	"unit.cm":     "см",
+	"unit.m":      "м",
+	"unit.length": "%s %s",
+	"unit.size":   "%s × %s %s",
+
+	"validate.size":     "Не получилось разобрать размер. Пример: <code>%s</code>, от %s, не больше %s",
+	"validate.size_max": "Не получилось разобрать размер. Пример: <code>%s</code>, не больше %s",
+	"validate.quantity": "Тираж — целое число от 1 до %d",
+	"validate.text":     "Пришлите от %d до %d символов",
+
+	"texture.more_info": "Подробнее",
+	"texture.price":     "Цена: %.2f %s/дм²",
+	"texture.care":      "Уход",
+
+	"catalog.empty": "Сейчас нет материалов в наличии",
+	"catalog.more":  "И ещё %d — сравнить все материалы можно в /compare",
+
+	"compare.choose":       "Выберите два или три материала для сравнения",
+	"compare.button":       "Сравнить",
+	"compare.too_many":     "Сравнить можно не больше %d материалов",
+	"compare.too_few":      "Выберите хотя бы два материала",
+	"compare.empty":        "Сейчас нечего сравнивать",
+	"compare.title":        "Сравнение материалов",
+	"compare.price":        "Цена/дм²",
+	"compare.stock":        "Наличие",
+	"compare.in_stock":     "в наличии",
+	"compare.out_of_stock": "нет в наличии",
+	"compare.stock_left":   "%.0f дм²",
+
+	"notify.status_changed":      "Ваш заказ %s теперь: %s",
+	"notify.opt_out_hint":        "Отключить уведомления: /notifications off",
+	"notify.delivery":            "Доставка: %s",
+	"notify.note":                "📝 Заметка: %s",
+	"notifications.on":           "Уведомления о заказах включены",
+	"notifications.off":          "Уведомления о заказах отключены",
+	"notifications.usage":        "Использование: /notifications on|off",
+	"status.new":                 "новый",
+	"status.processing":          "в производстве",
+	"status.completed":           "готов",
+	"status.cancelled":           "отменён",
+	"status.on_hold":             "на проверке",
+	"status.awaiting_prepayment": "ждёт предоплату",
+	"status.pending_quote":       "ждёт подтверждения цены",
+
+	"myorders.prompt":       "Нажмите на заказ, чтобы открыть его карточку. Вся история доступна в виде таблицы",
+	"myorders.download":     "📥 Скачать мои заказы",
+	"myorders.caption":      "Ваши заказы",
+	"myorders.order_button": "%s · %s · %.2f ₽",
+	"myorders.card":         "<b>Заказ %s</b>\nСтатус: %s\nСумма: %.2f ₽\nОформлен: %s",
+	"myorders.ready_by":     "Будет готов: %s",
+	"myorders.notes":        "<b>Заметки мастерской</b>",
+	"myorders.not_found":    "Заказ не найден",
+
+	"saved.save_button":   "💾 Сохранить на потом",
+	"saved.done":          "💾 Расчёт сохранён. Цена действует до %s, расчёт хранится до %s. Вернуться к нему — /saved",
+	"saved.full":          "Можно хранить не больше %d расчётов. Удалите лишние в /saved",
+	"saved.title":         "<b>Сохранённые расчёты</b>",
+	"saved.item":          "%d. %s\n%.2f %s, сохранён %s, хранится до %s",
+	"saved.empty":         "Сохранённых расчётов нет. Сохраните расчёт кнопкой «Сохранить на потом» в сводке заказа",
+	"saved.order_button":  "🛒 Заказать №%d",
+	"saved.delete_button": "🗑 Удалить №%d",
+	"saved.deleted":       "Расчёт удалён",
+	"saved.gone":          "Этот расчёт истёк или уже удалён, оформите заказ заново",
+	"saved.price_held":    "💾 Цена из сохранённого расчёта действует до %s",
+	"saved.price_current": "💾 Срок цены сохранённого расчёта истёк, цена пересчитана по текущим тарифам",
+	"saved.title_sticker": "Наклейки: %s, %s, %d шт.",
+	"saved.title_print":   "%s %s, %s, %d шт.",
+
+	"support.unavailable":     "Поддержка сейчас недоступна, попробуйте позже",
+	"support.opened":          "🎫 Обращение #%d создано. Напишите свой вопрос — мы ответим здесь же. Закрыть: /support close",
+	"support.already_open":    "У вас уже есть открытое обращение — просто напишите сообщение. Закрыть: /support close",
+	"support.none":            "У вас нет открытых обращений",
+	"support.closed":          "✅ Обращение #%d закрыто. Спасибо!",
+	"support.closed_by_staff": "✅ Обращение #%d закрыто поддержкой. Если вопрос остался — /support",
+
+	"voice.noted":      "🎙 Голосовое приложено к заказу, мастер его получит. Мы расслышали:\n<i>%s</i>\n\nПродолжим с того же шага.",
+	"voice.noted_file": "🎙 Голосовое приложено к заказу, мастер его получит. Продолжим с того же шага.",
+
+	"sticker.choose_material":   "🏷 <b>Наклейки</b>\n\nВыберите плёнку:",
+	"sticker.no_materials":      "Сейчас нет доступных плёнок, попробуйте позже",
+	"sticker.ask_size":          "Размер одной наклейки, например <code>%s</code>; число без единиц — в %s.\nОт %s, не больше %s",
+	"sticker.size_by_photo":     "📷 Измерить по фото",
+	"sticker.ask_size_photo":    "Сфотографируйте предмет, на который будет наклейка, положив рядом банковскую карту — она служит линейкой. Снимайте прямо, чтобы предмет и карта целиком попали в кадр.\nИли просто напишите размер",
+	"sticker.size_measured":     "📐 По фото получилось около %s. Подтвердите или напишите точный размер",
+	"sticker.size_confirm":      "✅ %s",
+	"sticker.size_not_measured": "Не получилось измерить по фото: не видно карты или предмета. Пришлите другой снимок или напишите размер",
+	"sticker.ask_quantity":      "Сколько наклеек напечатать? Выберите или напишите число (до %d)",
+	"sticker.ask_lamination":    "Ламинация защищает от царапин и воды:",
+	"sticker.lamination.none":   "Без ламинации",
+	"sticker.lamination.gloss":  "Глянцевая",
+	"sticker.lamination.matte":  "Матовая",
+	"sticker.ask_preview":       "Пришлите макет — картинкой или файлом (PNG, JPEG, WebP, PDF). Можно пропустить и обсудить макет с менеджером",
+	"sticker.skip_preview":      "Пропустить",
+	"sticker.preview_too_large": "Файл слишком большой, максимум %d МБ",
+	"sticker.preview_format":    "Поддерживаются PNG, JPEG, WebP и PDF",
+	"sticker.preview_none":      "нет, обсудим с менеджером",
+	"sticker.preview_attached":  "приложен",
+	"sticker.summary":           "<b>Ваш заказ</b>\n\nПлёнка: %s\nРазмер: %s\nТираж: %d шт.\nЛаминация: %s\nМакет: %s\nПлощадь: %.1f дм²\n\n<b>Итого: %.2f ₽</b>\nГотовность: %s",
+	"sticker.cancelled":         "Заказ наклеек отменён",
+	"sticker.expired":           "Черновик заказа устарел, начните заново: /stickers",
+	"sticker.unavailable":       "Эта плёнка закончилась, начните заново: /stickers",
+	"sticker.invalid":           "Параметры заказа вне допустимых пределов, начните заново: /stickers",
+	"sticker.placed":            "🎉 Заказ %s оформлен! Сумма: %.2f ₽. Менеджер свяжется с вами",
+
+	"order.discount":          "Скидка за тираж: %.0f%%",
+	"order.rush_off":          "🔥 Срочно",
+	"order.rush_on":           "✅ Срочно",
+	"order.rush_surcharge":    "Срочное изготовление: +%.2f ₽",
+	"order.confirm":           "✅ Оформить",
+	"order.confirm@cta":       "🚀 Заказать сейчас",
+	"order.cancel":            "Отмена",
+	"order.ask_contact":       "Поделитесь номером кнопкой ниже или напишите его, например <code>+79991234567</code>",
+	"order.bad_contact":       "Номер в формате <code>+79991234567</code> или <code>8 999 123-45-67</code>",
+	"order.share_contact":     "📱 Поделиться номером",
+	"order.foreign_contact":   "Отправьте свой контакт кнопкой ниже или напишите номер",
+	"order.ask_code":          "Мы отправили код подтверждения на этот номер. Введите его сообщением",
+	"order.wrong_code":        "Неверный код, попробуйте ещё раз",
+	"order.code_expired":      "Код больше не действует. Отправьте номер ещё раз",
+	"order.too_many_codes":    "Слишком много запросов кода. Попробуйте позже или поделитесь номером кнопкой",
+	"order.promo_button":      "🎟 Промокод / подарочная карта",
+	"order.ask_promo":         "Отправьте промокод или код подарочной карты",
+	"order.promo_skip":        "Без кодов",
+	"order.promo_applied":     "Промокод %s: −%.2f ₽",
+	"order.loyalty_applied":   "Скидка уровня %s: −%.2f ₽",
+	"order.gift_card_applied": "Подарочная карта …%s: −%.2f ₽, к оплате %.2f ₽",
+	"order.promo_not_found":   "Такого промокода нет",
+	"order.promo_expired":     "Срок действия промокода истёк",
+	"order.promo_exhausted":   "Промокод закончился",
+	"order.promo_used":        "Вы уже использовали этот промокод",
+	"order.repeated":          "Вы уже оформили такой же заказ %s в %s. Оформить ещё один?",
+	"order.repeat_anyway":     "Да, оформить ещё один",
+	"order.repeat_button":     "🔁 Повторить заказ",
+	"order.repeat_up":         "📈 Заказ %s стоил %.2f ₽, сейчас дороже на %.2f ₽",
+	"order.repeat_down":       "📉 Заказ %s стоил %.2f ₽, сейчас дешевле на %.2f ₽",
+	"order.repeat_same":       "Цена как у заказа %s: %.2f ₽",
+	"order.quote_up":          "📈 Цена изменилась: было %.2f ₽, сейчас дороже на %.2f ₽. Проверьте заказ и подтвердите ещё раз",
+	"order.quote_down":        "📉 Цена изменилась: было %.2f ₽, сейчас дешевле на %.2f ₽. Проверьте заказ и подтвердите ещё раз",
+	"order.quote_valid":       "⏱ Цена действует до %s",
+	"order.approval_note":     "ℹ️ Цена предварительная: менеджер проверит расчёт и пришлёт окончательную сумму",
+	"order.pending_quote":     "🎉 Заказ %s оформлен! Менеджер проверит расчёт и пришлёт окончательную цену",
+	"order.repeat_layout":     "из заказа %s",
+	"order.repeat_gone":       "Этот заказ нельзя повторить, оформите новый",
+	"order.edit":              "✏️ Изменить",
+	"order.contact":           "Контакт: %s",
+	"order.contact_saved":     "Контакт обновлён",
+
+	"edit.prompt":     "Что хотите изменить?",
+	"edit.material":   "Материал",
+	"edit.size":       "Размер",
+	"edit.format":     "Формат",
+	"edit.sides":      "Печать",
+	"edit.quantity":   "Тираж",
+	"edit.lamination": "Ламинация",
+	"edit.layout":     "Макет",
+	"edit.delivery":   "Доставка",
+	"edit.contact":    "Контакт",
+	"edit.back":       "↩️ Вернуться к заказу",
+
+	"print.choose_product":        "🖨 <b>Полиграфия</b>\n\nЧто печатаем?",
+	"print.product.business_card": "Визитки",
+	"print.product.flyer":         "Листовки",
+	"print.product.banner":        "Баннер",
+	"print.choose_format":         "Выберите формат:",
+	"print.format.custom":         "Свой размер",
+	"print.ask_size":              "Размер баннера, например <code>%s</code>; число без единиц — в %s. Не больше %s",
+	"print.choose_material":       "Выберите бумагу:",
+	"print.no_materials":          "Сейчас нет подходящих материалов, попробуйте позже",
+	"print.ask_sides":             "Печать с одной стороны или с двух?",
+	"print.sides.1":               "Односторонняя",
+	"print.sides.2":               "Двусторонняя",
+	"print.ask_quantity":          "Какой тираж? Выберите или напишите число (до %d)",
+	"print.ask_layout":            "Пришлите файл для печати (PDF, AI, EPS, TIFF, PNG, JPEG). Если макета нет — пропустите, дизайнер поможет",
+	"print.skip_layout":           "Макета нет",
+	"print.layout_too_large":      "Файл слишком большой, максимум %d МБ. Пришлите ссылку в поддержку: /support",
+	"print.layout_format":         "Поддерживаются PDF, AI, EPS, TIFF, PNG и JPEG",
+	"print.layout_needed":         "нужен дизайн",
+	"print.layout_attached":       "приложен",
+	"print.summary":               "<b>Ваш заказ</b>\n\nПродукт: %s\nФормат: %s\nБумага: %s\nПечать: %s\nТираж: %d шт.\nМакет: %s\n\n<b>Итого: %.2f ₽</b>\nГотовность: %s",
+	"print.cancelled":             "Заказ печати отменён",
+	"print.expired":               "Черновик заказа устарел, начните заново: /print",
+	"print.unavailable":           "Этот материал закончился, начните заново: /print",
+	"print.invalid":               "Параметры заказа вне допустимых пределов, начните заново: /print",
+	"print.placed":                "🎉 Заказ %s оформлен! Сумма: %.2f ₽. Менеджер свяжется с вами",
+
+	"delivery.ask":         "Как вы хотите получить заказ?",
+	"delivery.pickup":      "Самовывоз",
+	"delivery.courier":     "Курьер",
+	"delivery.post":        "Почта",
+	"delivery.ask_address": "Напишите адрес доставки: город, улица, дом, квартира. Для почты добавьте индекс",
+	"delivery.bad_address": "Пришлите адрес текстом, например: <code>Москва, Тверская 1, кв. 5</code>",
+	"delivery.summary":     "Доставка: %s",
+	"delivery.shipping":    "Доставка: %.2f ₽",
+	"delivery.free":        "Доставка: бесплатно",
+	"delivery.change":      "🚚 Доставка",
+
+	"pickup.ready": "✅ Заказ %s готов! Покажите этот QR-код при получении.\nКод выдачи: <code>%s</code>",
+
+	"referral.info":     "🤝 <b>Приглашайте друзей</b>\n\nВаша ссылка: %s\nЗа первый выполненный заказ друга вы получите %.0f ₽.\n\nПриглашено: %d\nСделали заказ: %d\nБонусный баланс: %.2f ₽",
+	"referral.credited": "🎉 Первый заказ вашего друга выполнен: +%.2f ₽ на бонусный баланс. /referral",
+
+	"giftcard.offer":           "🎁 <b>Подарочные карты</b>\n\nКартой можно оплатить заказ целиком или частично, остаток сохраняется для следующих заказов. Карта действует %d дней.\n\nВыберите номинал. Мы свяжемся с вами насчёт оплаты и пришлём код, как только она пройдёт.",
+	"giftcard.buy_button":      "🎁 %.0f ₽",
+	"giftcard.off":             "Подарочные карты сейчас не продаются",
+	"giftcard.requested":       "Заявка #%d на подарочную карту %.2f ₽ принята. Мы свяжемся с вами насчёт оплаты.",
+	"giftcard.issued":          "🎁 Ваша подарочная карта на %.2f ₽ готова!\n\nКод: <code>%s</code>\nДействует до %s. Введите код в сводке заказа кнопкой промокода.",
+	"giftcard.balance":         "🎁 Подарочная карта …%s: осталось %.2f ₽ из %.2f ₽, действует до %s",
+	"giftcard.refunded":        "🎁 Заказ отменён: %.2f ₽ вернулись на вашу подарочную карту",
+	"giftcard.not_found":       "Такой подарочной карты нет",
+	"giftcard.expired":         "Срок действия подарочной карты истёк",
+	"giftcard.void":            "Подарочная карта аннулирована",
+	"giftcard.empty":           "На подарочной карте ничего не осталось",
+	"giftcard.balance_changed": "Остаток на подарочной карте изменился, проверьте новую сумму",
+
+	"payment.invoice_title":       "Предоплата заказа %s",
+	"payment.invoice_description": "Предоплата %d%% от суммы заказа %.2f ₽. Заказ уйдёт в производство, как только она пройдёт.",
+	"payment.invoice_label":       "Предоплата",
+	"payment.declined":            "Этот счёт больше не действует",
+	"payment.received":            "💳 Оплата %.2f ₽ по заказу %s получена, спасибо!",
+	"payment.cleared":             "Заказ передан в производство.",
+	"payment.remaining":           "Осталось оплатить: %.2f ₽",
+	"payment.refunded":            "💳 Возврат %.2f ₽ по заказу %s оформлен. Деньги придут на карту в течение нескольких дней.",
+
+	"quote.approved":      "✅ Цена заказа %s подтверждена: %.2f ₽",
+	"quote.adjusted":      "✏️ Менеджер пересчитал заказ %s: %.2f ₽ вместо предварительных %.2f ₽",
+	"quote.in_production": "Заказ передан в производство.",
+
+	"review.ask":           "🙏 Как вам заказ %s? Оцените его от 1 до 5",
+	"review.ask_comment":   "Спасибо за оценку %d! Напишите пару слов, что понравилось и что улучшить, или нажмите «Без комментария»",
+	"review.skip_comment":  "Без комментария",
+	"review.thanks":        "Спасибо за отзыв!",
+	"review.already_rated": "Этот заказ уже оценён",
+	"review.not_available": "Этот заказ нельзя оценить",
+
+	"reviews.title":   "💬 <b>Отзывы наших клиентов</b>",
+	"reviews.average": "Средняя оценка: %.1f ⭐ (оценок: %d)",
+	"reviews.none":    "Отзывов пока нет. Оставить свой можно после выполнения заказа.",
+	"reviews.newer":   "◀️ Новее",
+	"reviews.older":   "Раньше ▶️",
+
+	"loyalty.accrued":   "⭐ Заказ выполнен, начислено бонусных баллов: %d. Они действуют до %s. /bonus",
+	"loyalty.expired":   "⏳ Сгорело бонусных баллов: %d. /bonus",
+	"loyalty.off":       "Бонусная программа сейчас не действует",
+	"loyalty.balance":   "⭐ <b>Бонусные баллы</b>\n\nБаланс: %d",
+	"loyalty.tier":      "Уровень: <b>%s</b>, скидка %.0f%% на каждый заказ",
+	"loyalty.no_tier":   "Уровня пока нет",
+	"loyalty.next_tier": "Осталось баллов: %d до уровня %s со скидкой %.0f%%",
+	"loyalty.expiring":  "Сгорит баллов: %d, %s",
+	"loyalty.how":       "За выполненный заказ начисляется балл за каждые %.0f ₽ (без доставки). Баллы действуют %d дней.",
+
+	"mydata.caption": "Все данные, которые бот хранит о вас: data.json — записи, orders.xlsx — заказы. Удалить данные можно через поддержку: /support",
+
+	"profile.card":             "👤 <b>Профиль</b>\n\nКонтакт: %s\nДоставка: %s\nИзбранные материалы: %s\nЕдиницы: %s\n\nСохранённые данные подставляются в заказ через /stickers и /print",
+	"profile.not_set":          "не указано",
+	"profile.edit_contact":     "📱 Контакт",
+	"profile.edit_delivery":    "🚚 Доставка",
+	"profile.edit_favorites":   "⭐ Избранные материалы",
+	"profile.edit_units":       "📏 Единицы",
+	"profile.units_prompt":     "В каких единицах вводить и показывать размеры? Единицы всегда можно указать в самом размере: <code>50x70мм</code> или <code>0.5x0.7м</code>",
+	"profile.clear":            "Очистить доставку и избранное",
+	"profile.favorites_prompt": "Нажмите на материал, чтобы добавить его в избранное или убрать. Избранные показываются первыми при заказе",
+	"profile.done":             "Готово",
+	"profile.saved":            "Сохранено",
+
+	"export.id":             "ID",
+	"export.user_id":        "ID пользователя",
+	"export.user_pseudonym": "Псевдоним пользователя",
+	"export.width":          "Ширина (см)",
+	"export.height":         "Высота (см)",
+	"export.width_mm":       "Ширина (мм)",
+	"export.height_mm":      "Высота (мм)",
+	"export.width_m":        "Ширина (м)",
+	"export.height_m":       "Высота (м)",
+	"export.texture_id":     "ID текстуры",
+	"export.texture_name":   "Текстура",
+	"export.price":          "Цена",
+	"export.leather_cost":   "Стоимость кожи",
+	"export.process_cost":   "Стоимость обработки",
+	"export.total_cost":     "Себестоимость",
+	"export.commission":     "Комиссия",
+	"export.tax":            "Налог",
+	"export.net_revenue":    "Чистая выручка",
+	"export.profit":         "Прибыль",
+	"export.contact":        "Контакт",
+	"export.status":         "Статус",
+	"export.created_at":     "Создан",
+	"export.rush":           "Срочный",
+	"export.items":          "Позиции",
+	"export.delivery":       "Доставка",
+	"export.address":        "Адрес",
+	"export.shipping":       "Стоимость доставки",
+	"export.currency":       "Валюта",
+	"export.code":           "Код заказа",
+	"export.gift_card":      "Оплачено подарочной картой",
+	"export.prepayment":     "Предоплата",
+	"export.paid":           "Оплачено",
+	"export.files":          "Файлы",
+	"export.file_telegram":  "%s, в чате заказа",
+	"export.preview":        "Превью",
+	"export.texture_photo":  "Фото текстуры",
+}
@@ -0,0 +1,13 @@
+package i18n
+
+import "s1ntez/pkg/units"
+
+// Length shows centimetres in the unit, e.g. "50 mm"
+func Length(locale Locale, unit units.Unit, cm int) string {
+	return T(locale, "unit.length", unit.Number(cm), T(locale, "unit."+string(unit)))
+}
+
+// Size shows a size in centimetres in the unit, e.g. "50 × 70 mm"
+func Size(locale Locale, unit units.Unit, widthCM, heightCM int) string {
+	return T(locale, "unit.size", unit.Number(widthCM), unit.Number(heightCM), T(locale, "unit."+string(unit)))
+}
@@ -0,0 +1,200 @@
+// Package imageproxy serves the texture images of the catalog to Telegram.
+// The image_url of a texture is downloaded once, shrunk to
+// TEXTURE_PREVIEW_MAX_SIDE and recompressed as JPEG, and the preview is
+// kept in Redis and in the object store. Once Telegram has it, its file ID
+// is sent instead of the bytes. A source URL that breaks later still has
+// its preview served from the cache.
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"s1ntez/internal/config"
+	"s1ntez/pkg/objectstore"
+	"s1ntez/pkg/redis"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// fileIDTTL is how long an uploaded preview's file ID is reused; Telegram
+// keeps files for much longer, a refused one is forgotten sooner
+const fileIDTTL = 30 * 24 * time.Hour
+
+// ErrNoImage means the texture has no image URL
+var ErrNoImage = errors.New("texture has no image")
+
+// Proxy fetches, shrinks and caches the texture previews
+type Proxy struct {
+	redis *redis.Client
+	// files is nil without an object store: previews are then cached in
+	// Redis only
+	files  *objectstore.Client
+	client *http.Client
+	logger *zap.Logger
+	cfg    *config.Config
+}
+
+func New(redisClient *redis.Client, files *objectstore.Client, logger *zap.Logger, cfg *config.Config) *Proxy {
+	return &Proxy{
+		redis:  redisClient,
+		files:  files,
+		client: &http.Client{Timeout: cfg.TexturePreviews.Timeout},
+		logger: logger.Named("imageproxy"),
+		cfg:    cfg,
+	}
+}
+
+// Photo returns the preview of the image at url to send: the file ID of
+// the preview uploaded before, or else the preview itself from the cache
+// or the source
+func (p *Proxy) Photo(ctx context.Context, url string) (tgbotapi.RequestFileData, error) {
+	if url == "" {
+		return nil, ErrNoImage
+	}
+	key := cacheKey(url)
+
+	if fileID, err := p.redis.Get(ctx, fileIDKey(key)); err == nil && len(fileID) > 0 {
+		return tgbotapi.FileID(string(fileID)), nil
+	}
+
+	preview, err := p.preview(ctx, key, url)
+	if err != nil {
+		return nil, err
+	}
+	return tgbotapi.FileBytes{Name: key + ".jpg", Bytes: preview}, nil
+}
+
+// Remember keeps the file ID Telegram gave the preview sent in msg, so the
+// next sends don't upload it again
+func (p *Proxy) Remember(ctx context.Context, url string, msg tgbotapi.Message) {
+	if url == "" || len(msg.Photo) == 0 {
+		return
+	}
+	// The last size is the largest one
+	fileID := msg.Photo[len(msg.Photo)-1].FileID
+	if err := p.redis.Set(ctx, fileIDKey(cacheKey(url)), fileID, fileIDTTL); err != nil {
+		p.logger.Warn("Failed to cache preview file ID", zap.String("url", url), zap.Error(err))
+	}
+}
+
+// Forget drops the file ID of a preview Telegram refused; it is uploaded
+// again next time
+func (p *Proxy) Forget(ctx context.Context, url string) {
+	if url == "" {
+		return
+	}
+	if err := p.redis.Del(ctx, fileIDKey(cacheKey(url))); err != nil {
+		p.logger.Warn("Failed to drop preview file ID", zap.String("url", url), zap.Error(err))
+	}
+}
+
+// preview reads the preview from Redis, then the object store, and makes
+// it from the source when neither has it
+func (p *Proxy) preview(ctx context.Context, key, url string) ([]byte, error) {
+	if data, err := p.redis.Get(ctx, bytesKey(key)); err == nil && len(data) > 0 {
+		return data, nil
+	}
+
+	if p.files != nil {
+		data, err := p.stored(ctx, key)
+		if err == nil {
+			p.cache(ctx, key, data)
+			return data, nil
+		}
+		if !errors.Is(err, objectstore.ErrNotFound) {
+			p.logger.Warn("Failed to read stored preview", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	source, err := p.download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	c := p.cfg.TexturePreviews
+	data, err := Shrink(source, c.MaxSide, c.Quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to shrink %s: %w", url, err)
+	}
+	p.logger.Info("Texture preview made",
+		zap.String("url", url),
+		zap.Int("source_bytes", len(source)),
+		zap.Int("preview_bytes", len(data)))
+
+	p.cache(ctx, key, data)
+	if p.files != nil {
+		if err := p.files.Put(ctx, objectKey(key), bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			p.logger.Warn("Failed to store preview", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return data, nil
+}
+
+func (p *Proxy) stored(ctx context.Context, key string) ([]byte, error) {
+	body, err := p.files.Get(ctx, objectKey(key))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (p *Proxy) cache(ctx context.Context, key string, data []byte) {
+	if err := p.redis.Set(ctx, bytesKey(key), data, p.cfg.TexturePreviews.CacheTTL); err != nil {
+		p.logger.Warn("Failed to cache preview", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (p *Proxy) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bad image URL %q: %w", url, err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	limit := p.cfg.TexturePreviews.MaxBytes
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("image %s is too large: %d bytes", url, resp.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("image %s is larger than %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// cacheKey names the preview after its source URL, so a texture given a
+// new image gets a new preview
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
+
+func fileIDKey(key string) string {
+	return "texture_preview:file_id:" + key
+}
+
+func bytesKey(key string) string {
+	return "texture_preview:bytes:" + key
+}
+
+func objectKey(key string) string {
+	return "texture_previews/" + key + ".jpg"
+}
@@ -0,0 +1,45 @@
+package imageproxy
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Shrink decodes a JPEG, PNG, GIF or WebP image, scales it down so its
+// longest side is at most maxSide pixels and encodes it as JPEG. Smaller
+// images keep their size and are only recompressed. Transparent areas
+// turn white, as the catalog shows them.
+func Shrink(data []byte, maxSide, quality int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	if longest := max(width, height); longest > maxSide {
+		width = max(width*maxSide/longest, 1)
+		height = max(height*maxSide/longest, 1)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode preview: %w", err)
+	}
+	return out.Bytes(), nil
+}
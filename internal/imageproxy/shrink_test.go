@@ -0,0 +1,81 @@
+package imageproxy
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode source: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestShrink(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	transparent := color.NRGBA{}
+
+	tests := []struct {
+		name       string
+		source     []byte
+		maxSide    int
+		wantWidth  int
+		wantHeight int
+		// wantWhite checks the center of the preview is white
+		wantWhite bool
+		wantErr   bool
+	}{
+		{name: "wide image is scaled down", source: encodePNG(t, 2000, 1000, red), maxSide: 500, wantWidth: 500, wantHeight: 250},
+		{name: "tall image is scaled down", source: encodePNG(t, 300, 1200, red), maxSide: 400, wantWidth: 100, wantHeight: 400},
+		{name: "small image keeps its size", source: encodePNG(t, 120, 80, red), maxSide: 500, wantWidth: 120, wantHeight: 80},
+		{name: "image at the limit keeps its size", source: encodePNG(t, 500, 500, red), maxSide: 500, wantWidth: 500, wantHeight: 500},
+		{name: "transparency turns white", source: encodePNG(t, 64, 64, transparent), maxSide: 500, wantWidth: 64, wantHeight: 64, wantWhite: true},
+		{name: "undecodable input", source: []byte("not an image"), maxSide: 500, wantErr: true},
+		{name: "empty input", source: nil, maxSide: 500, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Shrink(tt.source, tt.maxSide, 80)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			preview, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("preview is not a JPEG: %v", err)
+			}
+			bounds := preview.Bounds()
+			if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+
+			if tt.wantWhite {
+				r, g, b, _ := preview.At(bounds.Dx()/2, bounds.Dy()/2).RGBA()
+				// JPEG may shift the white slightly
+				if r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+					t.Errorf("center = (%d, %d, %d), want white", r>>8, g>>8, b>>8)
+				}
+			}
+		})
+	}
+}
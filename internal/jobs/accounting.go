@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"s1ntez/internal/reports"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const KindAccountingExport = "accounting_export"
+
+type AccountingExportPayload struct {
+	ChatID  int64     `json:"chat_id"`
+	AdminID int64     `json:"admin_id"`
+	Month   time.Time `json:"month"`
+	// Format is reports.AccountingCSV or reports.AccountingXML
+	Format string `json:"format"`
+}
+
+// AccountingExport writes the month's sales in the format the accountant
+// loads into 1C and sends the file to the admin chat
+func (r *Runner) AccountingExport(ctx context.Context, job *postgres.Job) error {
+	var payload AccountingExportPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+
+	entries, err := r.storage.GetAccountingEntries(ctx, payload.Month)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("reports/accounting_%s.%s", payload.Month.Format("2006_01"), payload.Format)
+	totals, err := reports.WriteAccounting(path, payload.Format, payload.Month, entries, r.cfg.Accounting.VATRate)
+	if err != nil {
+		return err
+	}
+
+	doc := tgbotapi.NewDocument(payload.ChatID, tgbotapi.FilePath(path))
+	doc.Caption = fmt.Sprintf("Реализация за %s: заказов %d, сумма %.2f %s, НДС %.2f",
+		payload.Month.Format("2006-01"), totals.Sales, totals.Amount, r.cfg.Currency.Symbol(), totals.VAT)
+	if _, err := r.botAPI.Send(doc); err != nil {
+		return fmt.Errorf("failed to send %s: %w", path, err)
+	}
+	return nil
+}
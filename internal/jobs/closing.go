@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/reports"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const KindCloseMonth = "close_month"
+
+type CloseMonthPayload struct {
+	ChatID  int64       `json:"chat_id"`
+	AdminID int64       `json:"admin_id"`
+	Month   time.Time   `json:"month"`
+	Locale  i18n.Locale `json:"locale"`
+}
+
+// CloseMonth freezes the month and sends the closing package (orders
+// spreadsheet, PDF summary and the signed snapshot) to the admin chat
+func (r *Runner) CloseMonth(ctx context.Context, job *postgres.Job) error {
+	var payload CloseMonthPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+
+	// A retry after a failed upload must not close the month twice
+	closing, err := r.storage.ClosePeriod(ctx, payload.Month, payload.AdminID, r.cfg.Accounting.SigningKey)
+	if errors.Is(err, postgres.ErrPeriodClosed) {
+		closing, err = r.storage.GetPeriodClose(ctx, payload.Month)
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot postgres.PeriodSnapshot
+	if err := json.Unmarshal(closing.Snapshot, &snapshot); err != nil {
+		return fmt.Errorf("bad snapshot: %w", err)
+	}
+
+	name := fmt.Sprintf("closing_%s", closing.PeriodStart.Format("2006_01"))
+
+	err = r.storage.ExportAllOrdersToExcel(ctx, name, postgres.ExportOptions{
+		Locale: payload.Locale,
+		Since:  closing.PeriodStart,
+		Until:  closing.PeriodEnd,
+	})
+	if err != nil {
+		return err
+	}
+
+	pdfPath := fmt.Sprintf("reports/%s.pdf", name)
+	if err := reports.ClosingPDF(pdfPath, closing, snapshot); err != nil {
+		return err
+	}
+
+	snapshotPath := fmt.Sprintf("reports/%s.json", name)
+	if err := os.WriteFile(snapshotPath, closing.Snapshot, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	files := []string{fmt.Sprintf("reports/%s.xlsx", name), pdfPath, snapshotPath}
+	for _, path := range files {
+		doc := tgbotapi.NewDocument(payload.ChatID, tgbotapi.FilePath(path))
+		if path == snapshotPath {
+			doc.Caption = fmt.Sprintf("HMAC-SHA256: %s", closing.Signature)
+		}
+		if _, err := r.botAPI.Send(doc); err != nil {
+			return fmt.Errorf("failed to send %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
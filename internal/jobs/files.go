@@ -0,0 +1,124 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/objectstore"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxPictureBytes skips originals too heavy to embed in a spreadsheet
+const maxPictureBytes = 5 << 20
+
+// pictureExtensions are the image types a spreadsheet can embed
+var pictureExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// exportFiles serves the file columns of the orders export: links to the
+// originals in the object store, pictures from it or from Telegram
+type exportFiles struct {
+	botAPI *tgbotapi.BotAPI
+	// store is nil when customer files stay on Telegram only
+	store   *objectstore.Client
+	linkTTL time.Duration
+	logger  *zap.Logger
+}
+
+func (r *Runner) exportFiles() *exportFiles {
+	return &exportFiles{
+		botAPI:  r.botAPI,
+		store:   r.files,
+		linkTTL: r.cfg.ObjectStore.LinkTTL,
+		logger:  r.logger,
+	}
+}
+
+func (e *exportFiles) Link(a postgres.Attachment) string {
+	if a.TelegramKind() != "" || e.store == nil {
+		return ""
+	}
+	return e.store.PresignGet(a.ObjectKey, e.linkTTL)
+}
+
+func (e *exportFiles) Picture(ctx context.Context, a postgres.Attachment) ([]byte, string) {
+	ext, ok := pictureExtensions[a.ContentType]
+	if !ok || a.SizeBytes > maxPictureBytes {
+		return nil, ""
+	}
+
+	var data []byte
+	var err error
+	if a.TelegramKind() == "" && e.store != nil {
+		data, err = e.fromStore(ctx, a.ObjectKey)
+	} else {
+		data, err = e.fromTelegram(ctx, a.FileID)
+	}
+	if err != nil {
+		e.logger.Warn("Failed to fetch attachment for export", zap.Int64("attachment_id", a.ID), zap.Error(err))
+		return nil, ""
+	}
+	return data, ext
+}
+
+// TexturePhoto fetches a gallery photo; Telegram keeps photos as JPEG
+func (e *exportFiles) TexturePhoto(ctx context.Context, fileID string) ([]byte, string) {
+	data, err := e.fromTelegram(ctx, fileID)
+	if err != nil {
+		e.logger.Warn("Failed to fetch texture photo for export", zap.String("file_id", fileID), zap.Error(err))
+		return nil, ""
+	}
+	return data, ".jpg"
+}
+
+func (e *exportFiles) fromStore(ctx context.Context, key string) ([]byte, error) {
+	body, err := e.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return readPicture(body)
+}
+
+func (e *exportFiles) fromTelegram(ctx context.Context, fileID string) ([]byte, error) {
+	url, err := e.botAPI.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build download request: %w", err)
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+	return readPicture(resp.Body)
+}
+
+func readPicture(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPictureBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > maxPictureBytes {
+		return nil, fmt.Errorf("file is over %d bytes", maxPictureBytes)
+	}
+	return data, nil
+}
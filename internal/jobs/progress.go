@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// progressEvery keeps short jobs quiet and long ones within Telegram's
+// edit limits
+const progressEvery = 10 * time.Second
+
+// progress edits the "queued" message of a job as it runs. A zero message
+// ID, as in payloads queued before progress existed, turns it off.
+type progress struct {
+	botAPI    *tgbotapi.BotAPI
+	chatID    int64
+	messageID int
+	logger    *zap.Logger
+	last      time.Time
+}
+
+func (r *Runner) progress(chatID int64, messageID int, logger *zap.Logger) *progress {
+	return &progress{
+		botAPI:    r.botAPI,
+		chatID:    chatID,
+		messageID: messageID,
+		logger:    logger,
+		last:      time.Now(),
+	}
+}
+
+// Tick reports the counts once progressEvery has passed since the last edit
+func (p *progress) Tick(text string) {
+	if time.Since(p.last) < progressEvery {
+		return
+	}
+	p.Set(text)
+}
+
+// Set replaces the message text right away
+func (p *progress) Set(text string) {
+	if p.messageID == 0 {
+		return
+	}
+	p.last = time.Now()
+	edit := tgbotapi.NewEditMessageText(p.chatID, p.messageID, text)
+	if _, err := p.botAPI.Send(edit); err != nil {
+		p.logger.Warn("Failed to update job progress", zap.Error(err))
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const KindExportOrders = "export_orders"
+
+type ExportOrdersPayload struct {
+	ChatID    int64       `json:"chat_id"`
+	Locale    i18n.Locale `json:"locale"`
+	Anonymize bool        `json:"anonymize"`
+	// Files adds links to the customer files and pictures of the designs
+	// and textures, the package the production team works from
+	Files bool `json:"files"`
+	// Policy is what the requester may see; staff don't get costs
+	Policy postgres.ColumnPolicy `json:"policy,omitempty"`
+	// Template names the report template to lay the sheet out with
+	Template string `json:"template,omitempty"`
+	// MessageID is the "queued" reply the worker edits with its progress
+	MessageID int `json:"message_id,omitempty"`
+}
+
+// ExportOrders builds the orders spreadsheet and sends it to the requesting chat
+func (r *Runner) ExportOrders(ctx context.Context, job *postgres.Job) error {
+	var payload ExportOrdersPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+
+	filename := fmt.Sprintf("orders_%d_%s", job.ID, time.Now().Format("20060102_1504"))
+	if payload.Anonymize {
+		filename += "_anon"
+	}
+
+	opts := postgres.ExportOptions{
+		Locale:           payload.Locale,
+		Anonymize:        payload.Anonymize,
+		AnonymizationKey: r.cfg.Export.AnonymizationKey,
+		Policy:           payload.Policy,
+	}
+	if payload.Files {
+		opts.Files = r.exportFiles()
+	}
+	if payload.Template != "" {
+		template, err := r.storage.GetReportTemplate(ctx, payload.Template)
+		if err != nil {
+			return err
+		}
+		opts.Template = template
+		filename += "_" + template.Name
+	}
+
+	progress := r.progress(payload.ChatID, payload.MessageID, r.logger.With(zap.Int64("job_id", job.ID)))
+	if job.Attempts > 1 {
+		progress.Set(fmt.Sprintf("🔁 Экспорт (задача #%d): попытка %d из %d", job.ID, job.Attempts, job.MaxAttempts))
+	}
+	opts.Progress = func(done, total int) {
+		progress.Tick(fmt.Sprintf("⏳ Экспорт (задача #%d): %d из %d заказов", job.ID, done, total))
+	}
+
+	err := r.storage.ExportAllOrdersToExcel(ctx, filename, opts)
+	if err != nil {
+		if job.Attempts < job.MaxAttempts {
+			progress.Set(fmt.Sprintf("⚠️ Экспорт (задача #%d) не удался, повторим позже", job.ID))
+		} else {
+			progress.Set(fmt.Sprintf("❌ Экспорт (задача #%d) не удался: /retryjob %d", job.ID, job.ID))
+		}
+		return err
+	}
+
+	doc := tgbotapi.NewDocument(payload.ChatID, tgbotapi.FilePath(fmt.Sprintf("reports/%s.xlsx", filename)))
+	doc.ReplyToMessageID = payload.MessageID
+	if _, err := r.botAPI.Send(doc); err != nil {
+		return fmt.Errorf("failed to send report: %w", err)
+	}
+	progress.Set(fmt.Sprintf("✅ Экспорт (задача #%d) готов", job.ID))
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/templates"
+	"s1ntez/pkg/objectstore"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 30 * time.Minute
+	stuckAfter   = time.Hour
+)
+
+// HandlerFunc executes a single job. Returning an error schedules a retry
+// until the job runs out of attempts.
+type HandlerFunc func(ctx context.Context, job *postgres.Job) error
+
+type Runner struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	// files is nil when customer files stay on Telegram only
+	files    *objectstore.Client
+	logger   *zap.Logger
+	cfg      *config.Config
+	handlers map[string]HandlerFunc
+}
+
+func NewRunner(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, files *objectstore.Client, logger *zap.Logger, cfg *config.Config) *Runner {
+	return &Runner{
+		storage:  storage,
+		botAPI:   botAPI,
+		files:    files,
+		logger:   logger,
+		cfg:      cfg,
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// Register binds a job kind to its handler. It must be called before Start.
+func (r *Runner) Register(kind string, handler HandlerFunc) {
+	r.handlers[kind] = handler
+}
+
+// Start processes queued jobs until ctx is cancelled
+func (r *Runner) Start(ctx context.Context) {
+	if n, err := r.storage.ResetStuckJobs(ctx, stuckAfter); err != nil {
+		r.logger.Error("Failed to reset stuck jobs", zap.Error(err))
+	} else if n > 0 {
+		r.logger.Warn("Requeued stuck jobs", zap.Int64("count", n))
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain everything that is due before sleeping again
+		for r.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	job, err := r.storage.ClaimNextJob(ctx)
+	if err != nil {
+		r.logger.Error("Failed to claim job", zap.Error(err))
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	logger := r.logger.With(
+		zap.Int64("job_id", job.ID),
+		zap.String("kind", job.Kind),
+		zap.Int("attempt", job.Attempts))
+
+	err = r.execute(ctx, job)
+	if err == nil {
+		if err := r.storage.CompleteJob(ctx, job.ID); err != nil {
+			logger.Error("Failed to mark job done", zap.Error(err))
+		}
+		logger.Info("Job completed")
+		return true
+	}
+
+	if job.Attempts < job.MaxAttempts {
+		retryAt := time.Now().Add(backoffFor(job.Attempts))
+		logger.Warn("Job failed, will retry", zap.Error(err), zap.Time("retry_at", retryAt))
+		if err := r.storage.FailJob(ctx, job.ID, err, &retryAt); err != nil {
+			logger.Error("Failed to reschedule job", zap.Error(err))
+		}
+		return true
+	}
+
+	logger.Error("Job failed permanently", zap.Error(err))
+	if err := r.storage.FailJob(ctx, job.ID, err, nil); err != nil {
+		logger.Error("Failed to mark job failed", zap.Error(err))
+	}
+	r.alertAdmins(job, err)
+	return true
+}
+
+func (r *Runner) execute(ctx context.Context, job *postgres.Job) (err error) {
+	handler, ok := r.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func (r *Runner) alertAdmins(job *postgres.Job, jobErr error) {
+	if r.cfg.Admin.ChatID == 0 {
+		return
+	}
+
+	text, err := templates.Render(templates.JobFailed{
+		JobID:    job.ID,
+		Kind:     job.Kind,
+		Attempts: job.Attempts,
+		Error:    errorSummary(jobErr),
+	})
+	if err != nil {
+		r.logger.Error("Failed to render job failure alert", zap.Int64("job_id", job.ID), zap.Error(err))
+		return
+	}
+
+	msg := text.To(r.cfg.Admin.ChatID)
+	if _, err := r.botAPI.Send(msg); err != nil {
+		r.logger.Error("Failed to alert admins about job failure",
+			zap.Int64("job_id", job.ID),
+			zap.Error(err))
+	}
+}
+
+// backoffFor returns the exponential delay before the given retry
+func backoffFor(attempt int) time.Duration {
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempt-1)))
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+// errorSummary is the innermost error, cut to 500 characters for the job row
+func errorSummary(err error) string {
+	msg := err.Error()
+	for unwrapped := errors.Unwrap(err); unwrapped != nil; unwrapped = errors.Unwrap(unwrapped) {
+		msg = unwrapped.Error()
+	}
+	if runes := []rune(msg); len(runes) > 500 {
+		msg = string(runes[:500]) + "…"
+	}
+	return msg
+}
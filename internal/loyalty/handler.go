@@ -0,0 +1,67 @@
+package loyalty
+
+import (
+	"context"
+	"html"
+	"s1ntez/internal/i18n"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler serves /bonus: the customer's points, their tier and the next
+// one, and when the earliest points expire
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.Named("loyalty"),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !msg.Chat.IsPrivate() {
+		return nil
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	cfg := h.service.cfg.Loyalty
+	if !h.service.Enabled() {
+		_, err := h.service.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, "loyalty.off")))
+		return err
+	}
+
+	balance, err := h.service.storage.GetLoyaltyBalance(ctx, msg.From.ID)
+	if err != nil {
+		return err
+	}
+
+	text := i18n.T(locale, "loyalty.balance", balance.Points)
+	if tier := h.service.Tier(balance.Points); tier != nil {
+		text += "\n" + i18n.T(locale, "loyalty.tier", html.EscapeString(tier.Name), tier.Discount)
+	} else {
+		text += "\n" + i18n.T(locale, "loyalty.no_tier")
+	}
+	if next := h.service.NextTier(balance.Points); next != nil {
+		text += "\n" + i18n.T(locale, "loyalty.next_tier", next.MinPoints-balance.Points, html.EscapeString(next.Name), next.Discount)
+	}
+	if balance.NextExpiry != nil {
+		text += "\n" + i18n.T(locale, "loyalty.expiring", balance.ExpiringPoints, balance.NextExpiry.Format("02.01.2006"))
+	}
+	text += "\n\n" + i18n.T(locale, "loyalty.how", 1/cfg.PointsRate, int(cfg.PointsTTL/(24*time.Hour)))
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeHTML
+	_, err = h.service.botAPI.Send(reply)
+	return err
+}
@@ -0,0 +1,183 @@
+// Package loyalty runs the points program: completed orders earn points,
+// the points a customer holds put them in a tier whose discount the
+// pricing applies to their orders, and points expire after
+// LOYALTY_POINTS_TTL
+package loyalty
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const KindExpire = "loyalty_expire"
+
+// Service accrues and expires points and tells which tier a customer is
+// in. There is no online payment, so completion is when an order counts
+// as paid.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("loyalty"),
+		cfg:     cfg,
+	}
+}
+
+// Enabled reports whether orders earn points
+func (s *Service) Enabled() bool {
+	return s.cfg.Loyalty.PointsRate > 0
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "loyalty.accrue", s.OnStatusChanged)
+}
+
+// OnStatusChanged credits the points of a completed order and takes them
+// back when a completed order is cancelled
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	switch {
+	case event.Status == postgres.StatusCompleted:
+		expiresAt := time.Now().Add(s.cfg.Loyalty.PointsTTL)
+		userID, points, err := s.storage.AccrueLoyaltyPoints(ctx, event.OrderID, s.cfg.Loyalty.PointsRate, expiresAt)
+		if err != nil || points == 0 {
+			return err
+		}
+		s.logger.Info("Loyalty points accrued",
+			zap.Int64("user_id", userID),
+			zap.Int64("order_id", event.OrderID),
+			zap.Int("points", points))
+		s.notify(ctx, userID, "loyalty.accrued", points, expiresAt.Format("02.01.2006"))
+
+	case event.Status == postgres.StatusCancelled && event.PrevStatus == postgres.StatusCompleted:
+		points, err := s.storage.RevokeLoyaltyPoints(ctx, event.OrderID)
+		if err != nil || points == 0 {
+			return err
+		}
+		s.logger.Info("Loyalty points revoked",
+			zap.Int64("user_id", event.UserID),
+			zap.Int64("order_id", event.OrderID),
+			zap.Int("points", points))
+	}
+	return nil
+}
+
+// Tier returns the tier of the points held, nil below the first one
+func (s *Service) Tier(points int) *config.LoyaltyTier {
+	var tier *config.LoyaltyTier
+	for i, t := range s.cfg.Loyalty.Tiers {
+		if points >= t.MinPoints {
+			tier = &s.cfg.Loyalty.Tiers[i]
+		}
+	}
+	return tier
+}
+
+// NextTier returns the tier after the one of the points held, nil at the top
+func (s *Service) NextTier(points int) *config.LoyaltyTier {
+	for i, t := range s.cfg.Loyalty.Tiers {
+		if points < t.MinPoints {
+			return &s.cfg.Loyalty.Tiers[i]
+		}
+	}
+	return nil
+}
+
+// CustomerTier is the tier whose discount the customer's orders get; nil
+// for unknown customers, when the program is off or the balance can't be
+// read, so a failure never blocks an order
+func (s *Service) CustomerTier(ctx context.Context, userID int64) *config.LoyaltyTier {
+	if userID == 0 || !s.Enabled() {
+		return nil
+	}
+	balance, err := s.storage.GetLoyaltyBalance(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Pricing without the loyalty discount", zap.Int64("user_id", userID), zap.Error(err))
+		return nil
+	}
+	return s.Tier(balance.Points)
+}
+
+// Expire is the job handler: it expires the points due and tells their
+// owners
+func (s *Service) Expire(ctx context.Context, job *postgres.Job) error {
+	expired, err := s.storage.ExpireLoyaltyPoints(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, e := range expired {
+		total += e.Points
+		s.notify(ctx, e.UserID, "loyalty.expired", e.Points)
+	}
+	s.logger.Info("Loyalty points expired",
+		zap.Int("customers", len(expired)),
+		zap.Int("points", total))
+	return nil
+}
+
+// Watch queues the expiry for LOYALTY_EXPIRY_HOUR every night until ctx is
+// cancelled
+func (s *Service) Watch(ctx context.Context) {
+	for {
+		runAt := nextRun(time.Now(), s.cfg.Loyalty.ExpiryHour)
+		queued, err := s.storage.ScheduleJob(ctx, KindExpire, runAt, s.cfg.Jobs.MaxAttempts)
+		if err != nil {
+			s.logger.Error("Failed to schedule loyalty expiry", zap.Error(err))
+		} else if queued {
+			s.logger.Info("Loyalty expiry scheduled", zap.Time("run_at", runAt))
+		}
+
+		// try again after the run, or sooner after an error
+		wait := time.Until(runAt) + time.Minute
+		if err != nil {
+			wait = min(wait, 5*time.Minute)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (s *Service) notify(ctx context.Context, userID int64, key string, args ...any) {
+	locale, err := s.storage.GetUserLocale(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	msg := tgbotapi.NewMessage(userID, i18n.T(locale, key, args...))
+	msg.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.botAPI.Send(msg); err != nil {
+		// the balance has changed either way
+		s.logger.Warn("Failed to notify customer", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// nextRun is the next time the clock shows the hour
+func nextRun(now time.Time, hour int) time.Time {
+	run := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !run.After(now) {
+		run = run.AddDate(0, 0, 1)
+	}
+	return run
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/tg"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Notifier tells customers about changes to their orders
+type Notifier struct {
+	sender  *tg.Sender
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+}
+
+func New(sender *tg.Sender, storage *postgres.PostgresStorage, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		sender:  sender,
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// Register subscribes the notifier to the events it reacts to
+func (n *Notifier) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "notify.status_changed", n.OnStatusChanged)
+}
+
+func (n *Notifier) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if event.PrevStatus == postgres.StatusPendingQuote && event.Status != postgres.StatusCancelled {
+		// the customer got the approved price instead, see approval
+		return nil
+	}
+
+	enabled, err := n.storage.NotificationsEnabled(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check notification settings: %w", err)
+	}
+	if !enabled {
+		return nil
+	}
+
+	orderCode, err := n.storage.GetOrderCode(ctx, event.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order code: %w", err)
+	}
+
+	locale, err := n.storage.GetUserLocale(ctx, event.UserID)
+	if err != nil {
+		n.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	text := i18n.T(locale, "notify.status_changed",
+		orderCode, i18n.T(locale, "status."+event.Status.String()))
+	if event.Status != postgres.StatusCancelled {
+		if delivery, err := n.storage.GetOrderDelivery(ctx, event.OrderID); err != nil {
+			n.logger.Warn("Failed to get order delivery", zap.Int64("order_id", event.OrderID), zap.Error(err))
+		} else {
+			text += "\n" + i18n.T(locale, "notify.delivery", describeDelivery(locale, delivery.Method, delivery.Address))
+		}
+	}
+	if note, err := n.storage.LatestOrderNote(ctx, event.OrderID); err != nil {
+		n.logger.Warn("Failed to get order note", zap.Int64("order_id", event.OrderID), zap.Error(err))
+	} else if note != nil {
+		text += "\n\n" + i18n.T(locale, "notify.note", note.Text)
+	}
+	text += "\n\n" + i18n.T(locale, "notify.opt_out_hint")
+
+	return n.Send(ctx, tgbotapi.NewMessage(event.UserID, text))
+}
+
+// describeDelivery names the delivery method in the customer's language,
+// with the address it goes to
+func describeDelivery(locale i18n.Locale, method, address string) string {
+	text := i18n.T(locale, "delivery."+method)
+	if address != "" {
+		text += ", " + address
+	}
+	return text
+}
+
+// Send delivers a message; the sender retries rate limits and transient
+// API errors
+func (n *Notifier) Send(ctx context.Context, msg tgbotapi.MessageConfig) error {
+	_, err := n.sender.Send(ctx, msg)
+	return err
+}
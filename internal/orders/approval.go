@@ -0,0 +1,94 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrPriceBelowGiftCard refuses an approved price lower than what the gift
+// card already paid of the order
+var ErrPriceBelowGiftCard = errors.New("price is below the gift card amount")
+
+// ApprovalReasons tells why a manager approves the price before the
+// customer gets it: the total is from QUOTE_APPROVAL_FROM, or the customer
+// asked for something of their own. None when the order goes ahead.
+func ApprovalReasons(cfg *config.Config, price money.Amount, requirements string) []string {
+	var reasons []string
+	if from := cfg.QuoteApproval.From; from > 0 && price >= money.FromFloat(from) {
+		reasons = append(reasons, fmt.Sprintf("сумма от %.2f %s", from, cfg.Currency.Symbol()))
+	}
+	if strings.TrimSpace(requirements) != "" {
+		reasons = append(reasons, "особые требования")
+	}
+	return reasons
+}
+
+// ApproveQuote releases an order pending its quote, at the calculated
+// price or, when price is positive, at the one the manager set. The
+// commission, tax and deposit follow the new price, with the rates of the
+// moment the order was placed. Returns the order as it was and its new
+// status: new, or awaiting prepayment.
+func (s *Service) ApproveQuote(ctx context.Context, orderID, managerID int64, price money.Amount) (*postgres.Order, postgres.OrderStatus, error) {
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, "", err
+	}
+	if order.Status != postgres.StatusPendingQuote {
+		return nil, "", fmt.Errorf("%w: order %d is %s", postgres.ErrQuoteNotPending, orderID, order.Status)
+	}
+
+	b := pricing.Breakdown{
+		TotalCost:  order.TotalCost,
+		Price:      order.Price,
+		GiftCard:   order.GiftCardAmount,
+		Commission: order.Commission,
+		Tax:        order.Tax,
+		NetRevenue: order.NetRevenue,
+		Profit:     order.Profit,
+	}
+	if price > 0 && price != order.Price {
+		if price < order.GiftCardAmount {
+			return nil, "", fmt.Errorf("%w: %s", ErrPriceBelowGiftCard, order.GiftCardAmount)
+		}
+		s.calculator.Reprice(&b, price, s.pricingOptions(ctx, order.IsRush, order.CreatedAt))
+	}
+
+	status, err := s.storage.ApproveQuote(ctx, orderID, managerID, postgres.QuotePrice{
+		Price:      b.Price,
+		Commission: b.Commission,
+		Tax:        b.Tax,
+		NetRevenue: b.NetRevenue,
+		Profit:     b.Profit,
+		Prepayment: s.prepayment(b),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("Quote approved",
+		zap.Int64("order_id", orderID),
+		zap.Int64("manager_id", managerID),
+		zap.Stringer("calculated", order.Price),
+		zap.Stringer("price", b.Price))
+
+	s.bus.Publish(ctx, events.Event{
+		Type:       events.OrderStatusChanged,
+		OrderID:    orderID,
+		UserID:     order.UserID,
+		Status:     status,
+		PrevStatus: order.Status,
+		OccurredAt: time.Now(),
+	})
+
+	return order, status, nil
+}
@@ -0,0 +1,743 @@
+package orders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/fraud"
+	"s1ntez/internal/fxrates"
+	"s1ntez/internal/giftcards"
+	"s1ntez/internal/loyalty"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/promo"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/phone"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTooLarge           = errors.New("dimensions exceed the maximum size")
+	ErrInvalidDimensions  = errors.New("dimensions must be positive")
+	ErrTextureUnavailable = errors.New("texture is not available")
+	ErrContactRequired    = errors.New("contact is required")
+	ErrInvalidContact     = errors.New("contact must be a phone number")
+	ErrInvalidQuantity    = errors.New("quantity is out of range")
+	ErrInvalidOptions     = errors.New("invalid product options")
+	ErrTooManyItems       = errors.New("too many items in one order")
+	ErrInvalidDelivery    = errors.New("invalid delivery method")
+	ErrAddressRequired    = errors.New("delivery address is required")
+	// ErrPrepaymentDue and ErrQuotePending are checked by the storage in
+	// the status update transaction
+	ErrPrepaymentDue = postgres.ErrPrepaymentDue
+	ErrQuotePending  = postgres.ErrQuotePending
+)
+
+// DuplicateOrderError means the customer placed the same products with the
+// same contact within LIMIT_DUPLICATE_WINDOW, typically by confirming twice.
+// The caller asks whether another order is wanted and, if so, places it
+// again with Request.AllowDuplicate.
+type DuplicateOrderError struct {
+	Previous *postgres.Order
+}
+
+func (e *DuplicateOrderError) Error() string {
+	return fmt.Sprintf("same order #%d placed at %s", e.Previous.ID, e.Previous.CreatedAt.Format(time.RFC3339))
+}
+
+// maxItems caps the products of one order
+const maxItems = 10
+
+// Keys of Request.Options
+const (
+	// OptionProduct narrows the product line, e.g. business cards in
+	// typography; materials may be limited to some products
+	OptionProduct = "product"
+	// OptionLamination is the sticker lamination, one of pricing.Laminations
+	OptionLamination = "lamination"
+	// OptionSides is "1" or "2" for one- or double-sided print
+	OptionSides = "sides"
+	// OptionRepeatOf is the ID of the order a repeat was made from; its
+	// layout is used again
+	OptionRepeatOf = "repeat_of"
+)
+
+// Statuses an order can be moved to by staff or integrations.
+// On hold is reserved for the fraud checks, awaiting prepayment for
+// orders placed with PREPAYMENT_PERCENT, pending quote for the orders
+// a manager prices, see ApproveQuote.
+var Statuses = []postgres.OrderStatus{
+	postgres.StatusNew,
+	postgres.StatusProcessing,
+	postgres.StatusCompleted,
+	postgres.StatusCancelled,
+}
+
+// Request is a confirmed order, whichever channel it came from
+type Request struct {
+	UserID    int64
+	WidthCM   int
+	HeightCM  int
+	TextureID string
+	Contact   string
+	Rush      bool
+
+	// ServiceType is the product line, leather when empty. TextureID must be
+	// a material of the same line.
+	ServiceType postgres.ServiceType
+	Quantity    int
+	Options     postgres.ProductOptions
+	// AttachmentIDs are the customer's uploads that belong to the order
+	AttachmentIDs []int64
+
+	// PromoCode is checked against its limits and discounts the order total
+	PromoCode string
+	// GiftCard is a gift card code; its balance pays for as much of the
+	// order as it covers
+	GiftCard string
+
+	// Items make an order of several products. When empty the order is the
+	// single product described by the fields above.
+	Items []Item
+
+	// IdempotencyKey identifies the confirmation (Telegram update, API
+	// request) so a retried one returns the order that was already placed
+	IdempotencyKey string
+
+	// AllowDuplicate skips the check for a repeat of a recent order: the
+	// customer confirmed they want it again, or nobody can be asked
+	AllowDuplicate bool
+
+	// Delivery is how the order reaches the customer, pickup when empty
+	Delivery Delivery
+
+	// PricedAt prices the materials and rates as they were at that moment,
+	// for a saved quote whose price still holds. Zero prices them now.
+	PricedAt time.Time
+
+	// QuoteID is the quote the customer confirms, see HoldPrice. While it
+	// holds the order is priced at its moment; when the price differs from
+	// the quoted one Place returns a *QuoteChangedError.
+	QuoteID int64
+
+	// Requirements are the customer's own wishes for the order; with them
+	// a manager approves the price first, see ApprovalReasons
+	Requirements string
+}
+
+// Delivery is the customer's choice of delivery. Courier and post need an
+// address; their charge is added to the order total.
+type Delivery struct {
+	Method  pricing.DeliveryMethod
+	Address string
+}
+
+// OrPickup returns the method, pickup when none was chosen
+func (d Delivery) OrPickup() pricing.DeliveryMethod {
+	if d.Method == "" {
+		return pricing.DeliveryPickup
+	}
+	return d.Method
+}
+
+func (d Delivery) validate() error {
+	method := d.OrPickup()
+	if !method.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidDelivery, d.Method)
+	}
+	if method.NeedsAddress() && strings.TrimSpace(d.Address) == "" {
+		return ErrAddressRequired
+	}
+	return nil
+}
+
+// Item is one product of a multi-product order
+type Item struct {
+	ServiceType postgres.ServiceType
+	TextureID   string
+	WidthCM     int
+	HeightCM    int
+	Quantity    int
+	Options     postgres.ProductOptions
+}
+
+// QuotedItem is an item priced with its material
+type QuotedItem struct {
+	Item
+	Texture   *postgres.Texture
+	Breakdown pricing.Breakdown
+	// ExchangeRate converted the material price from Texture.PriceCurrency,
+	// 0 when it was already in the order currency
+	ExchangeRate float64
+}
+
+func (r Request) items() []Item {
+	if len(r.Items) > 0 {
+		return r.Items
+	}
+	return []Item{{
+		ServiceType: r.ServiceType,
+		TextureID:   r.TextureID,
+		WidthCM:     r.WidthCM,
+		HeightCM:    r.HeightCM,
+		Quantity:    r.Quantity,
+		Options:     r.Options,
+	}}
+}
+
+// Service places orders: prices them with the active rule, saves them with
+// stock reservation and runs the fraud checks. Staff notifications go out
+// through the outbox written by SaveOrder.
+type Service struct {
+	storage    *postgres.PostgresStorage
+	calculator *pricing.Calculator
+	promos     *promo.Service
+	loyalty    *loyalty.Service
+	giftCards  *giftcards.Service
+	rates      *fxrates.Service
+	guard      *fraud.Guard
+	bus        *events.Bus
+	logger     *zap.Logger
+	cfg        *config.Config
+}
+
+func New(
+	storage *postgres.PostgresStorage,
+	calculator *pricing.Calculator,
+	promos *promo.Service,
+	loyalty *loyalty.Service,
+	giftCards *giftcards.Service,
+	rates *fxrates.Service,
+	guard *fraud.Guard,
+	bus *events.Bus,
+	logger *zap.Logger,
+	cfg *config.Config,
+) *Service {
+	return &Service{
+		storage:    storage,
+		calculator: calculator,
+		promos:     promos,
+		loyalty:    loyalty,
+		giftCards:  giftCards,
+		rates:      rates,
+		guard:      guard,
+		bus:        bus,
+		logger:     logger,
+		cfg:        cfg,
+	}
+}
+
+// Quote prices an order without saving it. For a multi-product order it
+// returns the material of the first item and the order totals.
+func (s *Service) Quote(ctx context.Context, req Request, now time.Time) (*postgres.Texture, pricing.Breakdown, error) {
+	quoted, total, err := s.QuoteItems(ctx, req, now)
+	if err != nil {
+		return nil, pricing.Breakdown{}, err
+	}
+	return quoted[0].Texture, total, nil
+}
+
+// QuoteItems prices every item of the order and returns them with the totals
+func (s *Service) QuoteItems(ctx context.Context, req Request, now time.Time) ([]QuotedItem, pricing.Breakdown, error) {
+	quoted, total, _, _, err := s.quote(ctx, req, now)
+	return quoted, total, err
+}
+
+// quote prices the items and applies the promo code and the gift card,
+// which it returns for the order to redeem
+func (s *Service) quote(ctx context.Context, req Request, now time.Time) ([]QuotedItem, pricing.Breakdown, *postgres.PromoCode, *postgres.GiftCard, error) {
+	items := req.items()
+	if len(items) > maxItems {
+		return nil, pricing.Breakdown{}, nil, nil, ErrTooManyItems
+	}
+	if err := req.Delivery.validate(); err != nil {
+		return nil, pricing.Breakdown{}, nil, nil, err
+	}
+
+	pricedAt := now
+	if !req.PricedAt.IsZero() {
+		pricedAt = req.PricedAt
+	}
+
+	opts := s.pricingOptions(ctx, req.Rush, pricedAt)
+
+	quoted := make([]QuotedItem, 0, len(items))
+	parts := make([]pricing.Breakdown, 0, len(items))
+	for _, item := range items {
+		q, err := s.quoteItem(ctx, item, opts, now, pricedAt)
+		if err != nil {
+			return nil, pricing.Breakdown{}, nil, nil, err
+		}
+		quoted = append(quoted, q)
+		parts = append(parts, q.Breakdown)
+	}
+	total := pricing.Sum(parts...)
+
+	var code *postgres.PromoCode
+	if req.PromoCode != "" {
+		var err error
+		if code, err = s.promos.Check(ctx, req.PromoCode, req.UserID, now); err != nil {
+			return nil, pricing.Breakdown{}, nil, nil, err
+		}
+		s.calculator.ApplyDiscount(&total, promo.Discount(*code, total.Price), opts)
+	}
+
+	if tier := s.loyalty.CustomerTier(ctx, req.UserID); tier != nil {
+		s.calculator.ApplyLoyalty(&total, tier.Name, tier.Discount, opts)
+	}
+
+	// Promo codes and loyalty tiers discount the products, not the delivery
+	s.calculator.ApplyShipping(&total, req.Delivery.OrPickup(), opts)
+
+	// A gift card pays, it doesn't discount: the price and the revenue
+	// figures stay whole
+	var card *postgres.GiftCard
+	if req.GiftCard != "" {
+		var err error
+		if card, err = s.giftCards.Check(ctx, req.GiftCard, now); err != nil {
+			return nil, pricing.Breakdown{}, nil, nil, err
+		}
+		total.GiftCard = min(card.Balance, total.Price)
+	}
+
+	return quoted, total, code, card, nil
+}
+
+// pricingOptions applies the pricing rule in effect at the moment, falling
+// back to the config rates when there is none
+func (s *Service) pricingOptions(ctx context.Context, rush bool, at time.Time) pricing.Options {
+	opts := pricing.Options{Rush: rush}
+	if rule, err := s.storage.GetActivePricingRule(ctx, at); err == nil {
+		opts.Rates = &pricing.Rates{
+			CommissionRate: rule.CommissionRate,
+			TaxRate:        rule.TaxRate,
+			TaxRegime:      rule.TaxRegime,
+		}
+	} else if !errors.Is(err, postgres.ErrNoPricingRule) {
+		s.logger.Warn("Falling back to config pricing rates", zap.Error(err))
+	}
+	return opts
+}
+
+// quoteItem prices the item with the material price of pricedAt; now sets
+// the production dates
+func (s *Service) quoteItem(ctx context.Context, item Item, opts pricing.Options, now, pricedAt time.Time) (QuotedItem, error) {
+	if err := s.validate(item); err != nil {
+		return QuotedItem{}, err
+	}
+
+	texture, err := s.storage.GetTextureByID(ctx, item.TextureID)
+	if err != nil || texture == nil || !texture.InStock ||
+		texture.ServiceType.OrLeather() != item.ServiceType.OrLeather() ||
+		(item.Options[OptionProduct] != "" && !texture.Suits(item.Options[OptionProduct])) {
+		return QuotedItem{}, ErrTextureUnavailable
+	}
+
+	price, currency := texture.PricePerDM2, texture.PriceCurrency
+	if pricedAt.Before(now) {
+		if past, err := s.storage.GetTexturePriceAt(ctx, texture.ID, pricedAt); err == nil {
+			price, currency = past.PricePerDM2, past.PriceCurrency
+		} else if !errors.Is(err, postgres.ErrNoTexturePrice) {
+			s.logger.Warn("Pricing at the current material price", zap.String("texture_id", texture.ID), zap.Error(err))
+		}
+	}
+
+	// imported materials may be priced in the supplier's currency
+	pricePerDM2, rate, err := s.rates.Convert(ctx, price, currency)
+	if err != nil {
+		return QuotedItem{}, fmt.Errorf("failed to convert the price of %s: %w", texture.Name, err)
+	}
+
+	q := QuotedItem{Item: item, Texture: texture}
+	if rate != 1 {
+		q.ExchangeRate = rate
+	}
+	switch item.ServiceType {
+	case postgres.ServiceSticker:
+		spec := pricing.StickerSpec{
+			WidthCM:    item.WidthCM,
+			HeightCM:   item.HeightCM,
+			Quantity:   item.Quantity,
+			Lamination: pricing.Lamination(item.Options[OptionLamination]),
+		}
+		q.Breakdown = s.calculator.CalculateStickers(spec, pricePerDM2, opts, now)
+
+	case postgres.ServiceTypography:
+		spec := pricing.PrintSpec{
+			WidthCM:  item.WidthCM,
+			HeightCM: item.HeightCM,
+			Quantity: item.Quantity,
+			Sides:    1,
+		}
+		if item.Options[OptionSides] == "2" {
+			spec.Sides = 2
+		}
+		q.Breakdown = s.calculator.CalculatePrint(spec, pricePerDM2, opts, now)
+
+	default:
+		q.Breakdown = s.calculator.Calculate(item.WidthCM, item.HeightCM, pricePerDM2, opts, now)
+	}
+
+	return q, nil
+}
+
+// validate checks the size, run and options against the limits of the product line
+func (s *Service) validate(item Item) error {
+	if item.WidthCM <= 0 || item.HeightCM <= 0 {
+		return ErrInvalidDimensions
+	}
+
+	switch item.ServiceType.OrLeather() {
+	case postgres.ServiceLeather:
+		if item.WidthCM > s.cfg.MaxDimensions.Width || item.HeightCM > s.cfg.MaxDimensions.Height {
+			return ErrTooLarge
+		}
+		if item.Quantity > 1 {
+			return ErrInvalidQuantity
+		}
+
+	case postgres.ServiceSticker:
+		c := s.cfg.Stickers
+		if item.WidthCM < c.MinSizeCM || item.HeightCM < c.MinSizeCM {
+			return ErrInvalidDimensions
+		}
+		if item.WidthCM > c.MaxWidthCM || item.HeightCM > c.MaxHeightCM {
+			return ErrTooLarge
+		}
+		if item.Quantity < 1 || item.Quantity > c.MaxQuantity {
+			return ErrInvalidQuantity
+		}
+		if lamination, ok := item.Options[OptionLamination]; ok && !pricing.Lamination(lamination).Valid() {
+			return fmt.Errorf("%w: lamination %q", ErrInvalidOptions, lamination)
+		}
+
+	case postgres.ServiceTypography:
+		if item.WidthCM > s.cfg.MaxDimensions.Width || item.HeightCM > s.cfg.MaxDimensions.Height {
+			return ErrTooLarge
+		}
+		if item.Quantity < 1 || item.Quantity > s.cfg.Typography.MaxQuantity {
+			return ErrInvalidQuantity
+		}
+		if sides, ok := item.Options[OptionSides]; ok && sides != "1" && sides != "2" {
+			return fmt.Errorf("%w: sides %q", ErrInvalidOptions, sides)
+		}
+
+	default:
+		return fmt.Errorf("%w: %q", postgres.ErrInvalidServiceType, item.ServiceType)
+	}
+
+	return nil
+}
+
+// Place saves the order and returns it with its final status: new, on
+// hold when the fraud checks flagged it, pending quote when a manager is
+// to approve the price, or awaiting prepayment when PREPAYMENT_PERCENT
+// asks for a deposit first
+func (s *Service) Place(ctx context.Context, req Request) (*postgres.Order, error) {
+	if req.Contact == "" {
+		return nil, ErrContactRequired
+	}
+	contact, err := phone.Normalize(req.Contact)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidContact, req.Contact)
+	}
+
+	now := time.Now()
+	shown := s.heldQuote(ctx, req)
+	if shown != nil && !shown.Expired(now) {
+		req.PricedAt = shown.PricedAt
+	}
+	quoted, b, code, card, err := s.quote(ctx, req, now)
+	if err != nil {
+		return nil, err
+	}
+	if shown != nil && b.Price != shown.Price {
+		return nil, &QuoteChangedError{Quote: shown, Current: b}
+	}
+	first := quoted[0]
+
+	fingerprint := Fingerprint(req.items(), contact)
+	if window := s.cfg.CurrentLimits().DuplicateWindow; window > 0 && !req.AllowDuplicate {
+		previous, err := s.storage.RecentOrderByFingerprint(ctx, req.UserID, fingerprint, now.Add(-window), req.IdempotencyKey)
+		if err != nil {
+			s.logger.Warn("Repeated order check failed, accepting order", zap.Error(err))
+		} else if previous != nil {
+			return nil, &DuplicateOrderError{Previous: previous}
+		}
+	}
+
+	// Checked before saving so the new order doesn't count against itself
+	verdict, err := s.guard.Check(ctx, req.UserID, b.Price.Float())
+	if err != nil {
+		s.logger.Error("Fraud checks failed, accepting order", zap.Error(err))
+	}
+
+	status := postgres.StatusNew
+	prepayment := s.prepayment(b)
+	reasons := ApprovalReasons(s.cfg, b.Price, req.Requirements)
+	switch {
+	case verdict.Hold:
+		// Saved as held right away, with its hold, so the outbox doesn't
+		// announce it
+		status = postgres.StatusOnHold
+	case len(reasons) > 0:
+		// The deposit is asked once the price is approved
+		status = postgres.StatusPendingQuote
+	case prepayment > 0:
+		status = postgres.StatusAwaitingPrepayment
+	}
+
+	readyBy := b.ReadyBy
+	order := postgres.Order{
+		UserID:        req.UserID,
+		WidthCM:       first.WidthCM,
+		HeightCM:      first.HeightCM,
+		TextureID:     first.Texture.ID,
+		TextureName:   first.Texture.Name,
+		Price:         b.Price,
+		LeatherCost:   b.LeatherCost,
+		ProcessCost:   b.ProcessCost,
+		TotalCost:     b.TotalCost,
+		Commission:    b.Commission,
+		Tax:           b.Tax,
+		NetRevenue:    b.NetRevenue,
+		Profit:        b.Profit,
+		Contact:       contact,
+		Status:        status,
+		CreatedAt:     now,
+		IsRush:        req.Rush,
+		RushSurcharge: b.RushSurcharge,
+		ReadyBy:       &readyBy,
+		ServiceType:   first.ServiceType.OrLeather(),
+		Currency:      s.cfg.Currency,
+		ExchangeRates: exchangeRates(quoted),
+		Quantity:      max(first.Quantity, 1),
+		Options:       first.Options,
+		AttachmentIDs: req.AttachmentIDs,
+		Items:         orderItems(quoted),
+		Discount:      b.Discount,
+		Prepayment:    prepayment,
+		Requirements:  strings.TrimSpace(req.Requirements),
+	}
+	if verdict.Hold {
+		order.HoldReason = verdict.Reason()
+	}
+	// A held order waits for its price once the hold is released
+	if len(reasons) > 0 {
+		order.QuoteReason = strings.Join(reasons, "; ")
+	}
+	if code != nil {
+		order.PromoCodeID = &code.ID
+	}
+	if card != nil {
+		order.GiftCardID = &card.ID
+		order.GiftCardAmount = b.GiftCard
+	}
+
+	order.Delivery = &postgres.OrderDelivery{
+		Method:  string(req.Delivery.OrPickup()),
+		Address: strings.TrimSpace(req.Delivery.Address),
+		Cost:    b.Shipping,
+	}
+	if !req.Delivery.OrPickup().NeedsAddress() {
+		order.Delivery.Address = ""
+	}
+
+	order.Fingerprint = &fingerprint
+	if req.IdempotencyKey != "" {
+		order.IdempotencyKey = &req.IdempotencyKey
+	}
+
+	err = s.storage.SaveOrder(ctx, &order)
+	if errors.Is(err, postgres.ErrDuplicateOrder) {
+		s.logger.Info("Duplicate order confirmation ignored",
+			zap.Int64("order_id", order.ID),
+			zap.String("idempotency_key", req.IdempotencyKey))
+		return s.storage.GetOrderByID(ctx, order.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if verdict.Hold {
+		// The hold is saved with the order; only the notice can be lost
+		if err := s.guard.RequestReview(ctx, order); err != nil {
+			s.logger.Error("Failed to ask admins to review held order",
+				zap.Int64("order_id", order.ID),
+				zap.Error(err))
+		}
+	}
+
+	s.forgetQuote(ctx, shown)
+
+	// Held orders are published too, with their on_hold status
+	s.bus.Publish(ctx, events.Event{
+		Type:       events.OrderCreated,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     order.Status,
+		OccurredAt: order.CreatedAt,
+	})
+	return &order, nil
+}
+
+// prepayment is the deposit PREPAYMENT_PERCENT asks of what the customer
+// pays, the part covered by a gift card left out
+func (s *Service) prepayment(b pricing.Breakdown) money.Amount {
+	percent := s.cfg.Payments.PrepaymentPercent
+	if percent <= 0 {
+		return 0
+	}
+	return (b.Price - b.GiftCard).MulRate(float64(percent) / 100)
+}
+
+// Fingerprint identifies an order by its products and contact. Rush and
+// the codes are left out: changing them doesn't make a different order.
+func Fingerprint(items []Item, contact string) string {
+	h := sha256.New()
+	for _, item := range items {
+		fmt.Fprintf(h, "%s|%s|%dx%d|%d", item.ServiceType.OrLeather(), item.TextureID,
+			item.WidthCM, item.HeightCM, max(item.Quantity, 1))
+		for _, key := range slices.Sorted(maps.Keys(item.Options)) {
+			fmt.Fprintf(h, "|%s=%s", key, item.Options[key])
+		}
+		h.Write([]byte{'\n'})
+	}
+	h.Write([]byte(contact))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChangeStatus moves an order to a new status, returns the material of
+// cancelled orders to stock and notifies subscribers. It returns the order
+// as it was before the change.
+func (s *Service) ChangeStatus(ctx context.Context, orderID int64, status postgres.OrderStatus) (*postgres.Order, error) {
+	if !slices.Contains(Statuses, status) {
+		return nil, fmt.Errorf("%w: %q", postgres.ErrInvalidOrderStatus, status)
+	}
+
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := s.storage.UpdateOrderStatus(ctx, orderID, status)
+	if errors.Is(err, ErrPrepaymentDue) || errors.Is(err, ErrQuotePending) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order %d status: %w", orderID, err)
+	}
+	order.Status = prev
+
+	if status == postgres.StatusCancelled {
+		if err := s.storage.ReleaseOrderStock(ctx, orderID); err != nil {
+			s.logger.Error("Failed to release stock of cancelled order",
+				zap.Int64("order_id", orderID),
+				zap.Error(err))
+		}
+	}
+
+	s.logger.Info("Order status changed",
+		zap.Int64("order_id", orderID),
+		zap.Stringer("from", order.Status),
+		zap.Stringer("to", status))
+
+	s.bus.Publish(ctx, events.Event{
+		Type:       events.OrderStatusChanged,
+		OrderID:    orderID,
+		UserID:     order.UserID,
+		Status:     status,
+		PrevStatus: order.Status,
+		OccurredAt: time.Now(),
+	})
+
+	return order, nil
+}
+
+// ChangeStatuses moves a batch of orders to the status in one update and
+// publishes an event for every order that changed. Orders not found,
+// already in the status or still awaiting prepayment or their price are
+// left out of the result.
+func (s *Service) ChangeStatuses(ctx context.Context, orderIDs []int64, status postgres.OrderStatus) ([]postgres.StatusChange, error) {
+	if !slices.Contains(Statuses, status) {
+		return nil, fmt.Errorf("%w: %q", postgres.ErrInvalidOrderStatus, status)
+	}
+
+	changes, err := s.storage.UpdateOrdersStatus(ctx, orderIDs, status)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, change := range changes {
+		if status == postgres.StatusCancelled {
+			if err := s.storage.ReleaseOrderStock(ctx, change.OrderID); err != nil {
+				s.logger.Error("Failed to release stock of cancelled order",
+					zap.Int64("order_id", change.OrderID),
+					zap.Error(err))
+			}
+		}
+
+		s.bus.Publish(ctx, events.Event{
+			Type:       events.OrderStatusChanged,
+			OrderID:    change.OrderID,
+			UserID:     change.UserID,
+			Status:     status,
+			PrevStatus: change.PrevStatus,
+			OccurredAt: now,
+		})
+	}
+
+	s.logger.Info("Order statuses changed",
+		zap.Int("requested", len(orderIDs)),
+		zap.Int("changed", len(changes)),
+		zap.Stringer("to", status))
+
+	return changes, nil
+}
+
+// exchangeRates collects the rates the material prices were converted with
+func exchangeRates(quoted []QuotedItem) postgres.ExchangeRates {
+	var rates postgres.ExchangeRates
+	for _, q := range quoted {
+		if q.ExchangeRate == 0 {
+			continue
+		}
+		if rates == nil {
+			rates = make(postgres.ExchangeRates)
+		}
+		rates[q.Texture.PriceCurrency] = q.ExchangeRate
+	}
+	return rates
+}
+
+func orderItems(quoted []QuotedItem) []postgres.OrderItem {
+	items := make([]postgres.OrderItem, len(quoted))
+	for i, q := range quoted {
+		items[i] = postgres.OrderItem{
+			Position:      i + 1,
+			ServiceType:   q.ServiceType.OrLeather(),
+			TextureID:     q.Texture.ID,
+			TextureName:   q.Texture.Name,
+			WidthCM:       q.WidthCM,
+			HeightCM:      q.HeightCM,
+			Quantity:      max(q.Quantity, 1),
+			Options:       q.Options,
+			Price:         q.Breakdown.Price,
+			LeatherCost:   q.Breakdown.LeatherCost,
+			ProcessCost:   q.Breakdown.ProcessCost,
+			TotalCost:     q.Breakdown.TotalCost,
+			RushSurcharge: q.Breakdown.RushSurcharge,
+		}
+	}
+	return items
+}
@@ -0,0 +1,83 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// QuoteChangedError means the order no longer costs what the summary
+// showed: the quote expired and the prices moved, or a discount ran out.
+// Nothing is placed; the caller shows the difference and a new summary.
+type QuoteChangedError struct {
+	Quote   *postgres.PriceQuote
+	Current pricing.Breakdown
+}
+
+func (e *QuoteChangedError) Error() string {
+	return fmt.Sprintf("quoted price %s changed to %s", e.Quote.Price, e.Current.Price)
+}
+
+// HoldPrice keeps the quoted price for QUOTE_VALIDITY and returns the
+// quote to place the order with, see Request.QuoteID. pricedAt is the
+// moment the breakdown was priced at, zero for now.
+func (s *Service) HoldPrice(ctx context.Context, userID int64, service postgres.ServiceType, pricedAt time.Time, b pricing.Breakdown) (*postgres.PriceQuote, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode breakdown: %w", err)
+	}
+
+	now := time.Now()
+	if pricedAt.IsZero() {
+		pricedAt = now
+	}
+	q := postgres.PriceQuote{
+		UserID:      userID,
+		ServiceType: service.OrLeather(),
+		Breakdown:   data,
+		Price:       b.Price,
+		Currency:    s.cfg.Currency,
+		PricedAt:    pricedAt,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.Quotes.Validity),
+	}
+	if q.ID, err = s.storage.SavePriceQuote(ctx, q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// heldQuote returns the quote the order is confirmed from, nil when there
+// is none. A quote that is gone is treated as none: the order is priced
+// now, as before quotes were kept.
+func (s *Service) heldQuote(ctx context.Context, req Request) *postgres.PriceQuote {
+	if req.QuoteID == 0 {
+		return nil
+	}
+	q, err := s.storage.GetPriceQuote(ctx, req.UserID, req.QuoteID)
+	if err != nil {
+		if !errors.Is(err, postgres.ErrPriceQuoteNotFound) {
+			s.logger.Warn("Failed to load price quote, pricing order now",
+				zap.Int64("quote_id", req.QuoteID),
+				zap.Error(err))
+		}
+		return nil
+	}
+	return q
+}
+
+// forgetQuote removes the quote an order was placed from
+func (s *Service) forgetQuote(ctx context.Context, q *postgres.PriceQuote) {
+	if q == nil {
+		return
+	}
+	if err := s.storage.DeletePriceQuote(ctx, q.UserID, q.ID); err != nil {
+		s.logger.Warn("Failed to remove used price quote", zap.Int64("quote_id", q.ID), zap.Error(err))
+	}
+}
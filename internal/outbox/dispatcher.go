@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Sender delivers one message to an external system. It may be called more
+// than once for the same message, so receivers must tolerate duplicates.
+type Sender func(ctx context.Context, msg postgres.OutboxMessage) error
+
+// Dispatcher delivers outbox messages written by business transactions
+type Dispatcher struct {
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+	cfg     *config.Config
+
+	senders map[string]Sender
+	fanOut  map[string][]string
+}
+
+func NewDispatcher(storage *postgres.PostgresStorage, logger *zap.Logger, cfg *config.Config) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		logger:  logger.Named("outbox"),
+		cfg:     cfg,
+		senders: make(map[string]Sender),
+		fanOut:  make(map[string][]string),
+	}
+}
+
+// Register binds a message kind to its sender. It must be called before Start.
+func (d *Dispatcher) Register(kind string, sender Sender) {
+	d.senders[kind] = sender
+}
+
+// FanOut splits messages of a kind into one message per destination kind.
+// Without destinations the messages are just marked delivered.
+func (d *Dispatcher) FanOut(kind string, destinations ...string) {
+	d.fanOut[kind] = append(d.fanOut[kind], destinations...)
+}
+
+// Start delivers due messages until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Outbox.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for d.dispatchBatch(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchBatch reports whether a full batch was claimed, i.e. more may be due
+func (d *Dispatcher) dispatchBatch(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	messages, err := d.storage.ClaimOutbox(ctx, d.cfg.Outbox.BatchSize, d.cfg.Outbox.Lease)
+	if err != nil {
+		d.logger.Error("Failed to claim outbox messages", zap.Error(err))
+		return false
+	}
+
+	for _, msg := range messages {
+		d.deliver(ctx, msg)
+	}
+	return len(messages) == d.cfg.Outbox.BatchSize
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, msg postgres.OutboxMessage) {
+	logger := d.logger.With(
+		zap.Int64("message_id", msg.ID),
+		zap.String("kind", msg.Kind),
+		zap.Int("attempt", msg.Attempts))
+
+	err := d.send(ctx, msg)
+	if err == nil {
+		return
+	}
+
+	if msg.Attempts >= d.cfg.Outbox.MaxAttempts {
+		// the payload is logged so the message can be replayed by hand
+		logger.Error("Outbox message dropped after max attempts",
+			zap.Error(err), zap.ByteString("payload", msg.Payload))
+		if err := d.storage.RetryOutbox(ctx, msg.ID, err, nil); err != nil {
+			logger.Error("Failed to mark outbox message failed", zap.Error(err))
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(backoffFor(msg.Attempts))
+	logger.Warn("Outbox delivery failed, will retry", zap.Error(err), zap.Time("retry_at", retryAt))
+	if err := d.storage.RetryOutbox(ctx, msg.ID, err, &retryAt); err != nil {
+		logger.Error("Failed to reschedule outbox message", zap.Error(err))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, msg postgres.OutboxMessage) (err error) {
+	if destinations, ok := d.fanOut[msg.Kind]; ok {
+		return d.storage.FanOutOutbox(ctx, msg, destinations)
+	}
+
+	sender, ok := d.senders[msg.Kind]
+	if !ok {
+		return fmt.Errorf("no sender for outbox kind %q", msg.Kind)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("sender panicked: %v", p)
+		}
+	}()
+
+	if err := sender(ctx, msg); err != nil {
+		return err
+	}
+	return d.storage.MarkOutboxDelivered(ctx, msg.ID)
+}
+
+// backoffFor returns the exponential delay before the given retry, with
+// jitter so messages failing together don't retry together
+func backoffFor(attempt int) time.Duration {
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempt-1)))
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,280 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"s1ntez/internal/broker"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/routing"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Destination kinds an order.created message fans out to
+const (
+	KindRouteOrder    = "order.route"
+	KindCRMSync       = "crm.sync"
+	KindOrderEmail    = "email.order_created"
+	KindBrokerCreated = "broker.order_created"
+)
+
+// Kinds of status change messages
+const (
+	KindCRMStatus    = "crm.status_changed"
+	KindBrokerStatus = "broker.status_changed"
+)
+
+// announced reports whether staff should hear about the order yet.
+// Held orders are announced again when an admin approves them, orders
+// awaiting prepayment when the deposit is paid, pending quotes when a
+// manager approves the price.
+func announced(order *postgres.Order) bool {
+	switch order.Status {
+	case postgres.StatusOnHold, postgres.StatusAwaitingPrepayment, postgres.StatusPendingQuote, postgres.StatusCancelled:
+		return false
+	}
+	return true
+}
+
+func loadOrder(ctx context.Context, storage *postgres.PostgresStorage, msg postgres.OutboxMessage) (*postgres.Order, error) {
+	var payload postgres.OrderOutboxPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("bad payload: %w", err)
+	}
+	return storage.GetOrderByID(ctx, payload.OrderID)
+}
+
+// RouteOrder posts the new order to the production chat chosen by the routing rules
+func RouteOrder(storage *postgres.PostgresStorage, router *routing.Router) Sender {
+	return func(ctx context.Context, msg postgres.OutboxMessage) error {
+		order, err := loadOrder(ctx, storage, msg)
+		if err != nil {
+			return err
+		}
+		if !announced(order) {
+			return nil
+		}
+
+		return router.OnOrderCreated(ctx, *order, routing.Facts{
+			Price:    order.Price.Float(),
+			AreaDM2:  order.MaterialDM2(),
+			Product:  order.ServiceType.OrLeather().String(),
+			Rush:     order.IsRush,
+			Quantity: max(order.Quantity, 1),
+		})
+	}
+}
+
+// CRMWebhook pushes the order to every CRM endpoint: as order.created for
+// KindCRMSync messages once the order is announced, and as
+// order.status_changed for KindCRMStatus. The body is signed with
+// HMAC-SHA256 and the message ID is sent as the idempotency key, so
+// endpoints that already got it on an earlier attempt can ignore the retry.
+func CRMWebhook(storage *postgres.PostgresStorage, cfg *config.Config) Sender {
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	return func(ctx context.Context, msg postgres.OutboxMessage) error {
+		var payload postgres.OrderOutboxPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("bad payload: %w", err)
+		}
+		order, err := storage.GetOrderByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
+
+		event := postgres.OutboxOrderCreated
+		if msg.Kind == KindCRMStatus {
+			event = string(events.OrderStatusChanged)
+		} else if !announced(order) {
+			return nil
+		}
+
+		body, err := json.Marshal(map[string]any{
+			"event":       event,
+			"prev_status": payload.PrevStatus,
+			"order":       crmOrder(order),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal CRM payload: %w", err)
+		}
+
+		var signature string
+		if cfg.Outbox.CRMWebhookSecret != "" {
+			mac := hmac.New(sha256.New, []byte(cfg.Outbox.CRMWebhookSecret))
+			mac.Write(body)
+			signature = hex.EncodeToString(mac.Sum(nil))
+		}
+
+		var errs []error
+		for _, endpoint := range cfg.Outbox.CRMWebhookURLs {
+			if err := postCRM(ctx, client, endpoint, body, event, signature, msg.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func crmOrder(order *postgres.Order) map[string]any {
+	items := make([]map[string]any, 0, len(order.LineItems()))
+	for _, item := range order.LineItems() {
+		items = append(items, map[string]any{
+			"service_type": item.ServiceType.OrLeather(),
+			"texture_id":   item.TextureID,
+			"width_cm":     item.WidthCM,
+			"height_cm":    item.HeightCM,
+			"quantity":     max(item.Quantity, 1),
+			"options":      item.Options,
+			"price":        item.Price,
+		})
+	}
+
+	return map[string]any{
+		"id":           order.ID,
+		"user_id":      order.UserID,
+		"service_type": order.ServiceType.OrLeather(),
+		"width_cm":     order.WidthCM,
+		"height_cm":    order.HeightCM,
+		"quantity":     max(order.Quantity, 1),
+		"options":      order.Options,
+		"texture_id":   order.TextureID,
+		"price":        order.Price,
+		"contact":      order.Contact,
+		"status":       order.Status,
+		"rush":         order.IsRush,
+		"created_at":   order.CreatedAt,
+		"items":        items,
+		"delivery": map[string]any{
+			"method":  order.DeliveryOrPickup().Method,
+			"address": order.DeliveryOrPickup().Address,
+			"cost":    order.DeliveryOrPickup().Cost,
+		},
+	}
+}
+
+func postCRM(ctx context.Context, client *http.Client, endpoint string, body []byte, event, signature string, id int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build CRM request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", strconv.FormatInt(id, 10))
+	req.Header.Set("X-Event", event)
+	if signature != "" {
+		req.Header.Set("X-Signature", signature)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("CRM request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("CRM %s responded %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// OrderEmail notifies the sales mailbox about a new order
+func OrderEmail(storage *postgres.PostgresStorage, cfg *config.Config) Sender {
+	return func(ctx context.Context, msg postgres.OutboxMessage) error {
+		order, err := loadOrder(ctx, storage, msg)
+		if err != nil {
+			return err
+		}
+		if !announced(order) {
+			return nil
+		}
+
+		c := cfg.Outbox
+		if len(c.EmailTo) == 0 {
+			return errors.New("no email recipients configured")
+		}
+
+		var body strings.Builder
+		fmt.Fprintf(&body, "From: %s\r\n", c.EmailFrom)
+		fmt.Fprintf(&body, "To: %s\r\n", strings.Join(c.EmailTo, ", "))
+		fmt.Fprintf(&body, "Subject: New order #%d\r\n", order.ID)
+		fmt.Fprintf(&body, "Message-ID: <outbox-%d@adtime>\r\n", msg.ID)
+		body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		fmt.Fprintf(&body, "Order #%d (%s)\r\nSize: %d x %d cm\r\nQuantity: %d\r\nPrice: %.2f %s\r\nContact: %s\r\n",
+			order.ID, order.ServiceType.OrLeather(), order.WidthCM, order.HeightCM, max(order.Quantity, 1), order.Price, order.Currency, order.Contact)
+		for key, value := range order.Options {
+			fmt.Fprintf(&body, "%s: %s\r\n", key, value)
+		}
+		delivery := order.DeliveryOrPickup()
+		fmt.Fprintf(&body, "Delivery: %s\r\n", delivery)
+		if delivery.Cost > 0 {
+			fmt.Fprintf(&body, "Shipping: %.2f %s\r\n", delivery.Cost, order.Currency)
+		}
+		if order.IsRush {
+			body.WriteString("RUSH ORDER\r\n")
+		}
+
+		host, _, _ := strings.Cut(c.SMTPAddr, ":")
+		var auth smtp.Auth
+		if c.SMTPUser != "" {
+			auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, host)
+		}
+
+		if err := smtp.SendMail(c.SMTPAddr, auth, c.EmailFrom, c.EmailTo, []byte(body.String())); err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	}
+}
+
+// Broker publishes order.created for KindBrokerCreated messages and
+// order.status_changed for KindBrokerStatus. The outbox message ID is the
+// event ID, so consumers can drop redeliveries. Held orders are published
+// too: analytics counts every order.
+func Broker(storage *postgres.PostgresStorage, publisher broker.Publisher, cfg *config.Config) Sender {
+	return func(ctx context.Context, msg postgres.OutboxMessage) error {
+		var payload postgres.OrderOutboxPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("bad payload: %w", err)
+		}
+		order, err := storage.GetOrderByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
+
+		eventType := postgres.OutboxOrderCreated
+		if msg.Kind == KindBrokerStatus {
+			eventType = string(events.OrderStatusChanged)
+		}
+
+		customer := postgres.Pseudonymize(cfg.Export.AnonymizationKey, order.UserID)
+		event := broker.NewOrderEvent(msg.ID, eventType, msg.CreatedAt, order, payload.PrevStatus, customer)
+		// the order may have moved on since; the event carries its status then
+		if payload.Status != "" {
+			event.Order.Status = payload.Status.String()
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal order event: %w", err)
+		}
+
+		return publisher.Publish(ctx, broker.Message{
+			Type: eventType,
+			Key:  strconv.FormatInt(order.ID, 10),
+			ID:   event.ID,
+			Body: body,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/sheets"
+	"strconv"
+	"strings"
+)
+
+// KindSheetsSync writes the current state of an order to the live spreadsheet
+const KindSheetsSync = "sheets.sync"
+
+// SheetsSync keeps one row per order in the configured sheet: a new order
+// is appended, a known one is overwritten in place. Looking the row up by
+// order ID makes redelivery harmless and lets status changes reuse it.
+func SheetsSync(storage *postgres.PostgresStorage, client *sheets.Client, sheet string) Sender {
+	// 'Orders 2024'!A:A — quoting keeps names with spaces valid
+	prefix := "'" + strings.ReplaceAll(sheet, "'", "''") + "'!"
+
+	return func(ctx context.Context, msg postgres.OutboxMessage) error {
+		order, err := loadOrder(ctx, storage, msg)
+		if err != nil {
+			return err
+		}
+
+		ids, err := client.Get(ctx, prefix+"A:A")
+		if err != nil {
+			return err
+		}
+
+		row := postgres.OrderExportRow(*order)
+		if len(ids) == 0 {
+			// empty sheet: start it with the header row
+			header := make([]any, 0, len(row))
+			for _, title := range postgres.OrderExportHeaders(i18n.Default) {
+				header = append(header, title)
+			}
+			if err := client.Update(ctx, prefix+"A1", [][]any{header}); err != nil {
+				return err
+			}
+		}
+
+		id := strconv.FormatInt(order.ID, 10)
+		for i, cells := range ids {
+			if len(cells) > 0 && cells[0] == id {
+				return client.Update(ctx, fmt.Sprintf("%sA%d", prefix, i+1), [][]any{row})
+			}
+		}
+
+		_, err = client.Append(ctx, prefix+"A1", [][]any{row})
+		return err
+	}
+}
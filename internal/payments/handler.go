@@ -0,0 +1,235 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler wires the service to Telegram: it confirms payments before they
+// are charged and records them once they went through. Staff commands:
+//
+//	/payments                              — the reconciliation report
+//	/payments <order>                      — the ledger of an order
+//	/refund <order> <amount> [ref] [note]  — a refund made at the provider
+//	/chargeback <order> <amount> <ref>     — a chargeback the bank reported
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.Named("payments"),
+	}
+}
+
+// HandlePreCheckout lets the payment through only while the order still
+// awaits the amount invoiced: the invoice may be paid after the order was
+// cancelled or paid from another invoice
+func (h *Handler) HandlePreCheckout(ctx context.Context, query *tgbotapi.PreCheckoutQuery) error {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+
+	_, err := h.service.Check(ctx, query.From.ID, query.InvoicePayload, query.Currency, money.Amount(query.TotalAmount))
+	if err != nil {
+		h.logger.Info("Payment declined",
+			zap.String("payload", query.InvoicePayload),
+			zap.Int64("user_id", query.From.ID),
+			zap.Error(err))
+
+		locale, lerr := h.service.storage.GetUserLocale(ctx, query.From.ID)
+		if lerr != nil {
+			h.logger.Warn("Failed to get user locale", zap.Error(lerr))
+		}
+		answer = tgbotapi.PreCheckoutConfig{
+			PreCheckoutQueryID: query.ID,
+			ErrorMessage:       i18n.T(locale, "payment.declined"),
+		}
+	}
+
+	_, aerr := h.service.botAPI.Request(answer)
+	if errors.Is(err, ErrNotDue) {
+		// a stale invoice, nothing is wrong on our side
+		err = nil
+	}
+	return errors.Join(err, aerr)
+}
+
+// HandleMessage records successful payments; other messages are left to
+// the next handlers
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if msg.SuccessfulPayment == nil || msg.From == nil {
+		return false, nil
+	}
+
+	order, cleared, err := h.service.Record(ctx, msg.From.ID, msg.SuccessfulPayment)
+	if errors.Is(err, postgres.ErrPaymentRecorded) {
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	paid := money.Amount(msg.SuccessfulPayment.TotalAmount)
+	text := i18n.T(locale, "payment.received", paid, order.Code)
+	switch {
+	case cleared:
+		text += "\n" + i18n.T(locale, "payment.cleared")
+	case order.Status == postgres.StatusAwaitingPrepayment:
+		text += "\n" + i18n.T(locale, "payment.remaining", order.Prepayment-order.PaidAmount)
+	}
+
+	_, err = h.service.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+	return true, err
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if !auth.Has(ctx, auth.Admin) {
+		return nil
+	}
+	args := strings.Fields(msg.CommandArguments())
+	switch msg.Command() {
+	case "refund":
+		return h.reverse(ctx, msg, postgres.PaymentRefund, args)
+	case "chargeback":
+		return h.reverse(ctx, msg, postgres.PaymentChargeback, args)
+	}
+
+	if len(args) == 0 {
+		// the period of the scheduled report, a week when it is off
+		period := h.service.cfg.Payments.ReportInterval
+		if period == 0 {
+			period = 7 * 24 * time.Hour
+		}
+		now := time.Now()
+		text, err := h.service.Report(ctx, now.Add(-period), now)
+		if err != nil {
+			return err
+		}
+		return h.reply(msg.Chat.ID, text)
+	}
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return h.reply(msg.Chat.ID, "Использование: /payments [номер заказа]")
+	}
+	return h.ledger(ctx, msg.Chat.ID, orderID)
+}
+
+func (h *Handler) ledger(ctx context.Context, chatID, orderID int64) error {
+	order, err := h.service.storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return h.reply(chatID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	}
+	if err != nil {
+		return err
+	}
+	payments, err := h.service.storage.GetOrderPayments(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	symbol := order.Currency.Symbol()
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>Заказ #%d %s</b> (%s)\nК оплате %.2f %s, предоплата %.2f, оплачено %.2f\n",
+		order.ID, order.Code, order.Status, order.Payable(), symbol, order.Prepayment, order.PaidAmount)
+	if len(payments) == 0 {
+		text.WriteString("\nПлатежей нет")
+	}
+	for _, p := range payments {
+		sign := "+"
+		if p.Kind != postgres.PaymentCharge {
+			sign = "−"
+		}
+		fmt.Fprintf(&text, "\n%s %s%.2f %s, %s", p.CreatedAt.Format("02.01.2006 15:04"), sign, p.Amount, p.Currency.Symbol(), entryNames[p.Kind])
+		if p.ProviderChargeID != "" {
+			fmt.Fprintf(&text, " <code>%s</code>", html.EscapeString(p.ProviderChargeID))
+		}
+		if p.Note != "" {
+			fmt.Fprintf(&text, " — %s", html.EscapeString(p.Note))
+		}
+	}
+	return h.reply(chatID, text.String())
+}
+
+func (h *Handler) reverse(ctx context.Context, msg *tgbotapi.Message, kind postgres.PaymentKind, args []string) error {
+	usage := "Использование: /refund &lt;номер заказа&gt; &lt;сумма&gt; [номер возврата у провайдера] [комментарий]"
+	if kind == postgres.PaymentChargeback {
+		usage = "Использование: /chargeback &lt;номер заказа&gt; &lt;сумма&gt; &lt;номер спора у провайдера&gt; [комментарий]"
+	}
+	if len(args) < 2 || (kind == postgres.PaymentChargeback && len(args) < 3) {
+		return h.reply(msg.Chat.ID, usage)
+	}
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return h.reply(msg.Chat.ID, usage)
+	}
+	amount, err := money.Parse(args[1])
+	if err != nil || amount <= 0 {
+		return h.reply(msg.Chat.ID, usage)
+	}
+	p := postgres.Payment{
+		OrderID:    orderID,
+		Kind:       kind,
+		Amount:     amount,
+		RecordedBy: &msg.From.ID,
+	}
+	if len(args) > 2 {
+		p.ProviderChargeID = args[2]
+		p.Note = strings.Join(args[3:], " ")
+	}
+
+	saved, err := h.service.Reverse(ctx, p)
+	switch {
+	case errors.Is(err, errs.ErrOrderNotFound):
+		return h.reply(msg.Chat.ID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	case errors.Is(err, postgres.ErrRefundExceedsPaid):
+		return h.reply(msg.Chat.ID, fmt.Sprintf("По заказу #%d оплачено меньше %.2f", orderID, amount))
+	case errors.Is(err, postgres.ErrPaymentRecorded):
+		return h.reply(msg.Chat.ID, fmt.Sprintf("Этот %s уже записан: <code>%s</code>", entryNames[kind], html.EscapeString(p.ProviderChargeID)))
+	case errors.Is(err, postgres.ErrPeriodClosed):
+		order, err := h.service.storage.GetOrderByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		month := order.CreatedAt.Format("2006-01")
+		return h.reply(msg.Chat.ID, fmt.Sprintf("Месяц %s заказа #%d закрыт, %s не записан.\nДля исправлений: /unlockmonth %s",
+			month, orderID, entryNames[kind], month))
+	case err != nil:
+		return err
+	}
+
+	audit.Record(ctx, fmt.Sprintf("order:%d", orderID), nil, map[string]any{
+		string(kind): saved.Amount,
+		"reference":  saved.ProviderChargeID,
+	})
+
+	return h.reply(msg.Chat.ID, fmt.Sprintf("✅ Записан %s по заказу #%d: %.2f %s. /payments %d",
+		entryNames[kind], orderID, saved.Amount, saved.Currency.Symbol(), orderID))
+}
+
+func (h *Handler) reply(chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := h.service.botAPI.Send(msg)
+	return err
+}
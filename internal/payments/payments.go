@@ -0,0 +1,227 @@
+// Package payments takes the deposit PREPAYMENT_PERCENT asks before an
+// order goes to production. The order is placed awaiting prepayment, the
+// customer gets a Telegram invoice for the deposit, and once the payments
+// cover it the order becomes new and is announced to staff. Refunds and
+// chargebacks staff enter go to the same ledger, which is reconciled
+// against the orders every PAYMENTS_REPORT_INTERVAL.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// payloadPrefix starts the payload of every invoice, followed by the order ID
+const payloadPrefix = "order:"
+
+var ErrNotDue = errors.New("order has no prepayment due")
+
+// Service sends invoices and records what the customers paid
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	bus     *events.Bus
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, bus *events.Bus, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		bus:     bus,
+		logger:  logger.Named("payments"),
+		cfg:     cfg,
+	}
+}
+
+// Register subscribes the service to the events it reacts to: a new order
+// awaiting prepayment, and a held one released to await it
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "payments.invoice", s.OnAwaiting)
+	bus.Subscribe(events.OrderStatusChanged, "payments.invoice_released", s.OnAwaiting)
+}
+
+func (s *Service) OnAwaiting(ctx context.Context, event events.Event) error {
+	if event.Status != postgres.StatusAwaitingPrepayment {
+		return nil
+	}
+	return s.SendInvoice(ctx, event.OrderID)
+}
+
+// SendInvoice sends the customer an invoice for what is left of the deposit
+func (s *Service) SendInvoice(ctx context.Context, orderID int64) error {
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	due, err := Due(order)
+	if err != nil {
+		return err
+	}
+
+	locale, err := s.storage.GetUserLocale(ctx, order.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	invoice := tgbotapi.NewInvoice(order.UserID,
+		i18n.T(locale, "payment.invoice_title", order.Code),
+		i18n.T(locale, "payment.invoice_description", s.cfg.Payments.PrepaymentPercent, order.Price),
+		payloadPrefix+strconv.FormatInt(order.ID, 10),
+		s.cfg.Payments.ProviderToken,
+		"",
+		string(order.Currency),
+		[]tgbotapi.LabeledPrice{{Label: i18n.T(locale, "payment.invoice_label"), Amount: int(due)}})
+	// A nil slice goes out as null, which Telegram rejects
+	invoice.SuggestedTipAmounts = []int{}
+	if _, err := s.botAPI.Send(invoice); err != nil {
+		return fmt.Errorf("failed to send invoice for order %d: %w", order.ID, err)
+	}
+
+	s.logger.Info("Prepayment invoice sent",
+		zap.Int64("order_id", order.ID),
+		zap.Stringer("amount", due))
+	return nil
+}
+
+// Due is what is left of the deposit of an order awaiting prepayment
+func Due(order *postgres.Order) (money.Amount, error) {
+	due := order.Prepayment - order.PaidAmount
+	if order.Status != postgres.StatusAwaitingPrepayment || due <= 0 {
+		return 0, fmt.Errorf("%w: order %d is %s", ErrNotDue, order.ID, order.Status)
+	}
+	return due, nil
+}
+
+// Check tells whether an invoice payment can go through: the order is the
+// payer's, still awaits the deposit, its period is not closed, and the
+// amount is what is due
+func (s *Service) Check(ctx context.Context, userID int64, payload, currency string, amount money.Amount) (*postgres.Order, error) {
+	orderID, err := parsePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, fmt.Errorf("order %d belongs to another customer", orderID)
+	}
+	due, err := Due(order)
+	if err != nil {
+		return nil, err
+	}
+	// The payment could not be recorded once charged
+	if _, err := s.storage.GetPeriodClose(ctx, order.CreatedAt); err == nil {
+		return nil, fmt.Errorf("%w: order %d", postgres.ErrPeriodClosed, orderID)
+	} else if !errors.Is(err, postgres.ErrPeriodNotClosed) {
+		return nil, err
+	}
+	if amount != due || !strings.EqualFold(currency, string(order.Currency)) {
+		return nil, fmt.Errorf("order %d: %s %s paid, %s %s due", orderID, amount, currency, due, order.Currency)
+	}
+	return order, nil
+}
+
+// Record counts a successful payment against its order. When it clears
+// the deposit the order goes to production and subscribers hear about the
+// change, as for any other status change.
+func (s *Service) Record(ctx context.Context, userID int64, payment *tgbotapi.SuccessfulPayment) (*postgres.Order, bool, error) {
+	orderID, err := parsePayload(payment.InvoicePayload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cleared, err := s.storage.RecordPayment(ctx, postgres.Payment{
+		OrderID:          orderID,
+		UserID:           userID,
+		Amount:           money.Amount(payment.TotalAmount),
+		Currency:         money.Currency(strings.ToUpper(payment.Currency)),
+		TelegramChargeID: &payment.TelegramPaymentChargeID,
+		ProviderChargeID: payment.ProviderPaymentChargeID,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.logger.Info("Payment recorded",
+		zap.Int64("order_id", orderID),
+		zap.Int("amount", payment.TotalAmount),
+		zap.Bool("cleared", cleared))
+
+	if cleared {
+		s.bus.Publish(ctx, events.Event{
+			Type:       events.OrderStatusChanged,
+			OrderID:    orderID,
+			UserID:     userID,
+			Status:     postgres.StatusNew,
+			PrevStatus: postgres.StatusAwaitingPrepayment,
+			OccurredAt: time.Now(),
+		})
+	}
+
+	order, err := s.storage.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, cleared, err
+	}
+	return order, cleared, nil
+}
+
+// Reverse enters a refund or chargeback. The customer is told about
+// refunds; a chargeback is their own doing.
+func (s *Service) Reverse(ctx context.Context, p postgres.Payment) (*postgres.Payment, error) {
+	saved, err := s.storage.RecordReversal(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Payment reversed",
+		zap.Int64("order_id", saved.OrderID),
+		zap.String("kind", string(saved.Kind)),
+		zap.Stringer("amount", saved.Amount),
+		zap.String("reference", saved.ProviderChargeID))
+
+	if saved.Kind != postgres.PaymentRefund {
+		return saved, nil
+	}
+	code, err := s.storage.GetOrderCode(ctx, saved.OrderID)
+	if err != nil {
+		s.logger.Warn("Failed to get order code", zap.Int64("order_id", saved.OrderID), zap.Error(err))
+		return saved, nil
+	}
+	locale, err := s.storage.GetUserLocale(ctx, saved.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	if _, err := s.botAPI.Send(tgbotapi.NewMessage(saved.UserID, i18n.T(locale, "payment.refunded", saved.Amount, code))); err != nil {
+		// the refund is recorded either way
+		s.logger.Warn("Failed to notify customer", zap.Int64("user_id", saved.UserID), zap.Error(err))
+	}
+	return saved, nil
+}
+
+func parsePayload(payload string) (int64, error) {
+	raw, ok := strings.CutPrefix(payload, payloadPrefix)
+	if !ok {
+		return 0, fmt.Errorf("unknown invoice payload %q", payload)
+	}
+	orderID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad order id in invoice payload %q", payload)
+	}
+	return orderID, nil
+}
@@ -0,0 +1,117 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// reportLimit caps the discrepancies listed in one message
+const reportLimit = 30
+
+// kindNames head the totals of the report
+var kindNames = map[postgres.PaymentKind]string{
+	postgres.PaymentCharge:     "Платежи",
+	postgres.PaymentRefund:     "Возвраты",
+	postgres.PaymentChargeback: "Чарджбэки",
+}
+
+// entryNames name a single row of the ledger
+var entryNames = map[postgres.PaymentKind]string{
+	postgres.PaymentCharge:     "платёж",
+	postgres.PaymentRefund:     "возврат",
+	postgres.PaymentChargeback: "чарджбэк",
+}
+
+var problemNames = map[string]string{
+	postgres.DiscrepancyLedger:       "оплачено по заказу не сходится с платежами",
+	postgres.DiscrepancyOverpaid:     "оплачено больше суммы заказа",
+	postgres.DiscrepancyOverRefunded: "возвращено больше, чем оплачено",
+	postgres.DiscrepancyRefundDue:    "заказ отменён, деньги не возвращены",
+}
+
+// Watch sends the reconciliation to the admin chat every
+// PAYMENTS_REPORT_INTERVAL until ctx is cancelled
+func (s *Service) Watch(ctx context.Context) {
+	interval := s.cfg.Payments.ReportInterval
+	if interval == 0 {
+		s.logger.Info("PAYMENTS_REPORT_INTERVAL is not set, scheduled payment reports are off")
+		return
+	}
+	if s.cfg.Admin.ChatID == 0 {
+		s.logger.Warn("No admin chat configured, payment reports are off")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		text, err := s.Report(ctx, now.Add(-interval), now)
+		if err != nil {
+			s.logger.Error("Failed to build payments report", zap.Error(err))
+			continue
+		}
+		msg := tgbotapi.NewMessage(s.cfg.Admin.ChatID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		if _, err := s.botAPI.Send(msg); err != nil {
+			s.logger.Error("Failed to send payments report", zap.Error(err))
+		}
+	}
+}
+
+// Report sums the ledger over [since, until) and lists the orders whose
+// payments don't add up, whenever they were paid
+func (s *Service) Report(ctx context.Context, since, until time.Time) (string, error) {
+	totals, err := s.storage.GetPaymentTotals(ctx, since, until)
+	if err != nil {
+		return "", err
+	}
+	discrepancies, err := s.storage.GetPaymentDiscrepancies(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	symbol := s.cfg.Currency.Symbol()
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>Платежи с %s по %s</b>\n",
+		since.Format("02.01.2006 15:04"), until.Format("02.01.2006 15:04"))
+	if len(totals) == 0 {
+		text.WriteString("\nДвижений нет\n")
+	}
+	for _, t := range totals {
+		fmt.Fprintf(&text, "\n%s: %d на %.2f %s", kindNames[t.Kind], t.Count, t.Amount, symbol)
+	}
+
+	if len(discrepancies) == 0 {
+		text.WriteString("\n\n✅ Расхождений нет")
+		return text.String(), nil
+	}
+
+	fmt.Fprintf(&text, "\n\n⚠️ <b>Расхождения: %d</b>", len(discrepancies))
+	for i, d := range discrepancies {
+		if i == reportLimit {
+			fmt.Fprintf(&text, "\n… и ещё %d", len(discrepancies)-reportLimit)
+			break
+		}
+		problems := make([]string, 0, 4)
+		for _, p := range d.Problems() {
+			problems = append(problems, problemNames[p])
+		}
+		fmt.Fprintf(&text, "\n\n#%d %s (%s): к оплате %.2f, оплачено %.2f, по платежам %.2f %s\n%s",
+			d.OrderID, d.Code, d.Status, d.Payable, d.PaidAmount, d.Net(), symbol, strings.Join(problems, "; "))
+	}
+	return text.String(), nil
+}
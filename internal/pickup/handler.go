@@ -0,0 +1,158 @@
+package pickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const callbackPrefix = "pickup"
+
+// Handler wires the service to Telegram:
+//
+//	/start pk_<code>  — staff who scanned a customer's QR code get the order
+//	                    with a button to hand it over; others get the regular /start
+//	/scan <code>      — the same for a code typed by hand
+type Handler struct {
+	service *Service
+	auth    *auth.Service
+	start   bot.CommandHandler
+	logger  *zap.Logger
+}
+
+// NewHandler wraps the regular /start handler so scanned codes open the
+// order for staff
+func NewHandler(service *Service, authService *auth.Service, start bot.CommandHandler, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		auth:    authService,
+		start:   start,
+		logger:  logger.Named("pickup"),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if msg.Command() == "start" {
+		code, ok := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), StartPrefix)
+		if !ok || !h.service.Enabled() {
+			return h.start.Handle(ctx, update)
+		}
+		// the start command is open to everyone, so the role is checked here
+		role, err := h.auth.Role(ctx, msg.From.ID)
+		if err != nil {
+			return err
+		}
+		if !role.AtLeast(auth.Production) {
+			return h.start.Handle(ctx, update)
+		}
+		return h.scan(ctx, msg.Chat.ID, code)
+	}
+
+	if !auth.Has(ctx, auth.Production) {
+		return nil
+	}
+	if !h.service.Enabled() {
+		return h.reply(msg.Chat.ID, "Коды выдачи выключены: не задан PICKUP_SECRET")
+	}
+	code := strings.TrimSpace(msg.CommandArguments())
+	if code == "" {
+		return h.reply(msg.Chat.ID, "Использование: /scan <код выдачи>")
+	}
+	return h.scan(ctx, msg.Chat.ID, code)
+}
+
+// scan shows the order of a code with a button to hand it over
+func (h *Handler) scan(ctx context.Context, chatID int64, code string) error {
+	orderID, err := h.service.Resolve(ctx, code)
+	if errors.Is(err, ErrInvalidCode) {
+		return h.reply(chatID, "❌ Код выдачи недействителен")
+	}
+	if err != nil {
+		return err
+	}
+
+	order, err := h.service.storage.GetOrderByID(ctx, orderID)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return h.reply(chatID, fmt.Sprintf("Заказ #%d не найден", orderID))
+	}
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "<b>Заказ #%d · %s</b>\nСтатус: %s\nСумма: %.2f ₽", order.ID, order.Code, order.Status, order.Price)
+	for _, item := range order.LineItems() {
+		text.WriteString("\n• " + item.String())
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "")
+	switch {
+	case order.PickedUpAt != nil:
+		fmt.Fprintf(&text, "\n\n⚠️ Уже выдан %s", order.PickedUpAt.Format("02.01.2006 15:04"))
+	case order.Status != postgres.StatusCompleted:
+		text.WriteString("\n\n⚠️ Заказ ещё не готов")
+	default:
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Выдать", fmt.Sprintf("%s:%d", callbackPrefix, order.ID))))
+	}
+	msg.Text = text.String()
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err = h.service.botAPI.Send(msg)
+	return err
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !auth.Has(ctx, auth.Production) {
+		return nil
+	}
+
+	// pickup:<order_id>
+	_, raw, _ := strings.Cut(query.Data, ":")
+	orderID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad pickup callback %q", query.Data)
+	}
+
+	answer := fmt.Sprintf("Заказ #%d выдан", orderID)
+	err = h.service.storage.MarkPickedUp(ctx, orderID, query.From.ID)
+	switch {
+	case errors.Is(err, postgres.ErrAlreadyPickedUp):
+		answer = fmt.Sprintf("Заказ #%d уже выдан", orderID)
+	case errors.Is(err, postgres.ErrOrderNotReady):
+		answer = fmt.Sprintf("Заказ #%d ещё не готов", orderID)
+	case errors.Is(err, errs.ErrOrderNotFound):
+		answer = fmt.Sprintf("Заказ #%d не найден", orderID)
+	case err != nil:
+		return err
+	default:
+		audit.Record(ctx, fmt.Sprintf("order:%d", orderID),
+			map[string]any{"picked_up": false}, map[string]any{"picked_up": true})
+		h.logger.Info("Order picked up",
+			zap.Int64("order_id", orderID),
+			zap.Int64("staff_id", query.From.ID))
+	}
+	_, _ = h.service.botAPI.Request(tgbotapi.NewCallback(query.ID, answer))
+
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		query.Message.Text+"\n\n"+answer)
+	_, err = h.service.botAPI.Send(edit)
+	return err
+}
+
+func (h *Handler) reply(chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := h.service.botAPI.Send(msg)
+	return err
+}
@@ -0,0 +1,149 @@
+package pickup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+// StartPrefix marks a pickup deep link: /start pk_<code>
+const StartPrefix = "pk_"
+
+const (
+	// signatureBytes keeps the code short enough for a deep link and a
+	// sparse QR code, while still impossible to guess
+	signatureBytes = 12
+	qrSize         = 512
+)
+
+var ErrInvalidCode = errors.New("invalid pickup code")
+
+// Service issues pickup codes: when an order for pickup is ready the
+// customer gets a QR code, staff scan it at the counter and hand the order
+// over. The pickup code is the order code signed with PICKUP_SECRET, so it
+// can't be made up for someone else's order. Codes sent before orders had
+// codes carry the order ID and still work.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("pickup"),
+		cfg:     cfg,
+	}
+}
+
+// Enabled reports whether a secret to sign codes with is configured
+func (s *Service) Enabled() bool {
+	return s.cfg.Pickup.Secret != ""
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "pickup.code", s.OnStatusChanged)
+}
+
+// Code is the signed pickup code of an order, e.g. "AT-2024-00123_x3Jd..."
+func (s *Service) Code(orderCode string) string {
+	return orderCode + "_" + s.sign(orderCode)
+}
+
+// Link is the deep link the QR code holds: scanning it with the phone
+// camera opens the bot with the code
+func (s *Service) Link(orderCode string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", s.botAPI.Self.UserName, StartPrefix, s.Code(orderCode))
+}
+
+// Resolve returns the order of a code. The code may come with the deep
+// link prefix or as the whole link, as typed by staff after a failed scan.
+func (s *Service) Resolve(ctx context.Context, code string) (int64, error) {
+	code = strings.TrimSpace(code)
+	if _, rest, ok := strings.Cut(code, "start="); ok {
+		code = rest
+	}
+	code = strings.TrimPrefix(code, StartPrefix)
+
+	orderCode, signature, ok := strings.Cut(code, "_")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(orderCode))) {
+		return 0, ErrInvalidCode
+	}
+	// a code sent before orders had codes
+	if orderID, err := strconv.ParseInt(orderCode, 10, 64); err == nil {
+		return orderID, nil
+	}
+
+	orderID, err := s.storage.GetOrderIDByCode(ctx, orderCode)
+	if errors.Is(err, errs.ErrOrderNotFound) {
+		return 0, ErrInvalidCode
+	}
+	return orderID, err
+}
+
+func (s *Service) sign(id string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.Pickup.Secret))
+	mac.Write([]byte("pickup:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureBytes])
+}
+
+// OnStatusChanged sends the pickup code when an order to be picked up is
+// ready. It is sent regardless of the notification setting: the customer
+// needs it to get the order.
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if event.Status != postgres.StatusCompleted || !s.Enabled() {
+		return nil
+	}
+
+	delivery, err := s.storage.GetOrderDelivery(ctx, event.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order delivery: %w", err)
+	}
+	if delivery.Method != postgres.DeliveryPickup {
+		return nil
+	}
+
+	orderCode, err := s.storage.GetOrderCode(ctx, event.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order code: %w", err)
+	}
+
+	locale, err := s.storage.GetUserLocale(ctx, event.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	png, err := qrcode.Encode(s.Link(orderCode), qrcode.Medium, qrSize)
+	if err != nil {
+		return fmt.Errorf("failed to render pickup QR code: %w", err)
+	}
+
+	photo := tgbotapi.NewPhoto(event.UserID, tgbotapi.FileBytes{
+		Name:  "pickup_" + orderCode + ".png",
+		Bytes: png,
+	})
+	photo.Caption = i18n.T(locale, "pickup.ready", orderCode, s.Code(orderCode))
+	photo.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.botAPI.Send(photo); err != nil {
+		return fmt.Errorf("failed to send pickup code: %w", err)
+	}
+	return nil
+}
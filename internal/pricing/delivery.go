@@ -0,0 +1,54 @@
+package pricing
+
+import (
+	"s1ntez/pkg/money"
+)
+
+// DeliveryMethod is how the finished order reaches the customer
+type DeliveryMethod string
+
+const (
+	DeliveryPickup  DeliveryMethod = "pickup"
+	DeliveryCourier DeliveryMethod = "courier"
+	DeliveryPost    DeliveryMethod = "post"
+)
+
+var DeliveryMethods = []DeliveryMethod{DeliveryPickup, DeliveryCourier, DeliveryPost}
+
+func (m DeliveryMethod) Valid() bool {
+	switch m {
+	case DeliveryPickup, DeliveryCourier, DeliveryPost:
+		return true
+	}
+	return false
+}
+
+// NeedsAddress reports whether the order is sent somewhere
+func (m DeliveryMethod) NeedsAddress() bool {
+	return m == DeliveryCourier || m == DeliveryPost
+}
+
+// ApplyShipping adds the delivery charge to the final price. What the
+// delivery costs us goes to the costs even when the order ships free, so
+// free shipping comes out of the profit. Pickup changes nothing.
+func (c *Calculator) ApplyShipping(b *Breakdown, method DeliveryMethod, opts Options) {
+	p := c.cfg.CurrentPricing()
+
+	var cost money.Amount
+	switch method {
+	case DeliveryCourier:
+		cost = money.FromFloat(p.CourierCost)
+	case DeliveryPost:
+		cost = money.FromFloat(p.PostCost)
+	}
+	if cost <= 0 {
+		return
+	}
+
+	b.TotalCost += cost
+	if p.DeliveryFreeFrom <= 0 || b.Price < money.FromFloat(p.DeliveryFreeFrom) {
+		b.Shipping = cost
+		b.Price += b.Shipping
+	}
+	c.applyRates(b, opts)
+}
@@ -0,0 +1,182 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"s1ntez/internal/config"
+	"s1ntez/pkg/money"
+)
+
+// Options are the customer choices that affect the final price
+type Options struct {
+	Rush bool
+
+	// Rates overrides the commission and tax rates from config,
+	// normally with the pricing rule in effect at quote time
+	Rates *Rates
+}
+
+type Rates struct {
+	CommissionRate float64
+	TaxRate        float64
+	// TaxRegime names the regime TaxRate applies under, see Regime
+	TaxRegime string
+}
+
+// Breakdown mirrors the financial columns stored on an order. Amounts are
+// in minor units, so the columns add up to the kopeck.
+type Breakdown struct {
+	AreaDM2       float64
+	LeatherCost   money.Amount
+	ProcessCost   money.Amount
+	TotalCost     money.Amount
+	RushSurcharge money.Amount
+	Discount      money.Amount
+	// LoyaltyDiscount is the part of Discount the customer's loyalty tier
+	// LoyaltyTier gave
+	LoyaltyDiscount money.Amount
+	LoyaltyTier     string
+	Shipping        money.Amount
+	Price           money.Amount
+	// GiftCard is the part of Price a gift card pays, the customer pays
+	// the rest
+	GiftCard   money.Amount
+	Commission money.Amount
+	Tax        money.Amount
+	NetRevenue money.Amount
+	Profit     money.Amount
+	ReadyBy    time.Time
+}
+
+type Calculator struct {
+	cfg *config.Config
+}
+
+// New makes a calculator; coefficients are read from cfg on every quote,
+// so a config reload applies to the next one
+func New(cfg *config.Config) *Calculator {
+	return &Calculator{cfg: cfg}
+}
+
+// Calculate quotes a leather piece of the given size for a texture priced per dm²
+func (c *Calculator) Calculate(widthCM, heightCM int, pricePerDM2 float64, opts Options, now time.Time) Breakdown {
+	p := c.cfg.CurrentPricing()
+
+	var b Breakdown
+	b.AreaDM2 = float64(widthCM*heightCM) / 100
+	b.LeatherCost = money.FromFloat(pricePerDM2).MulRate(b.AreaDM2)
+	b.ProcessCost = money.FromFloat(p.ProcessingCostPerDM2).MulRate(b.AreaDM2)
+	b.TotalCost = b.LeatherCost + b.ProcessCost
+
+	base := b.TotalCost.MulRate(p.MarkupMultiplier)
+	leadTime := p.StandardLeadTime
+	if opts.Rush {
+		b.RushSurcharge = base.MulRate(p.RushSurchargeRate)
+		leadTime = p.RushLeadTime
+	}
+	b.Price = base + b.RushSurcharge
+
+	c.applyRates(&b, opts)
+	b.ReadyBy = now.Add(leadTime)
+
+	return b
+}
+
+// applyRates derives the commission from the final price and leaves tax
+// and the net figures to the tax regime
+func (c *Calculator) applyRates(b *Breakdown, opts Options) {
+	p := c.cfg.CurrentPricing()
+	rates := Rates{
+		CommissionRate: p.PaymentCommissionRate,
+		TaxRate:        p.SalesTaxRate,
+		TaxRegime:      p.TaxRegime,
+	}
+	if opts.Rates != nil {
+		rates = *opts.Rates
+	}
+
+	b.Commission = b.Price.MulRate(rates.CommissionRate)
+	Regime(rates.TaxRegime, rates.TaxRate).Settle(b)
+}
+
+// ApplyDiscount takes a promo discount off the final price and recomputes
+// the figures derived from it. Discounts never take the price below cost.
+func (c *Calculator) ApplyDiscount(b *Breakdown, discount money.Amount, opts Options) {
+	discount = min(discount, b.Price-b.TotalCost)
+	if discount <= 0 {
+		return
+	}
+
+	b.Discount += discount
+	b.Price -= discount
+	c.applyRates(b, opts)
+}
+
+// Reprice sets the price a manager agreed on and recomputes the figures
+// derived from it. Costs and discounts stay as calculated.
+func (c *Calculator) Reprice(b *Breakdown, price money.Amount, opts Options) {
+	b.Price = price
+	c.applyRates(b, opts)
+}
+
+// ApplyLoyalty takes the percentage of the customer's loyalty tier off
+// the final price, after any promo code
+func (c *Calculator) ApplyLoyalty(b *Breakdown, tier string, percent float64, opts Options) {
+	before := b.Discount
+	c.ApplyDiscount(b, b.Price.MulRate(percent/100), opts)
+	if b.Discount > before {
+		b.LoyaltyDiscount += b.Discount - before
+		b.LoyaltyTier = tier
+	}
+}
+
+// Sum adds up the breakdowns of the items of one order. The order is ready
+// when its slowest item is.
+func Sum(parts ...Breakdown) Breakdown {
+	var total Breakdown
+	for _, b := range parts {
+		total.AreaDM2 += b.AreaDM2
+		total.LeatherCost += b.LeatherCost
+		total.ProcessCost += b.ProcessCost
+		total.TotalCost += b.TotalCost
+		total.RushSurcharge += b.RushSurcharge
+		total.Discount += b.Discount
+		total.LoyaltyDiscount += b.LoyaltyDiscount
+		total.Shipping += b.Shipping
+		total.Price += b.Price
+		total.Commission += b.Commission
+		total.Tax += b.Tax
+		total.NetRevenue += b.NetRevenue
+		total.Profit += b.Profit
+		if b.ReadyBy.After(total.ReadyBy) {
+			total.ReadyBy = b.ReadyBy
+		}
+	}
+	total.AreaDM2 = round(total.AreaDM2)
+	return total
+}
+
+// bulkDiscounts apply to the marked-up price of printed runs, largest run first
+var bulkDiscounts = []struct {
+	MinQuantity int
+	Rate        float64
+}{
+	{1000, 0.25},
+	{500, 0.15},
+	{100, 0.10},
+}
+
+// BulkDiscount returns the discount rate for a run size
+func BulkDiscount(quantity int) float64 {
+	for _, d := range bulkDiscounts {
+		if quantity >= d.MinQuantity {
+			return d.Rate
+		}
+	}
+	return 0
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,243 @@
+package pricing
+
+import (
+	"s1ntez/internal/config"
+	"s1ntez/pkg/money"
+	"testing"
+	"time"
+)
+
+func testConfig() *config.Config {
+	cfg := &config.Config{Pricing: config.Pricing{
+		ProcessingCostPerDM2:  31.25,
+		PaymentCommissionRate: 0.03,
+		SalesTaxRate:          0.06,
+		TaxRegime:             RegimeUSNIncome,
+		MarkupMultiplier:      2.5,
+		StandardLeadTime:      168 * time.Hour,
+		RushSurchargeRate:     0.3,
+		RushLeadTime:          48 * time.Hour,
+		CourierCost:           400,
+		PostCost:              350,
+		DeliveryFreeFrom:      10000,
+	}}
+	cfg.Stickers.CutCostPerPiece = 2
+	cfg.Stickers.LaminationPerDM2 = 10
+	cfg.Stickers.MinPrice = 500
+	return cfg
+}
+
+func TestCalculate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts Options
+		want Breakdown
+	}{
+		{
+			name: "standard",
+			want: Breakdown{
+				AreaDM2: 10, LeatherCost: 25000, ProcessCost: 31250, TotalCost: 56250,
+				Price: 140625, Commission: 4219, Tax: 8438, NetRevenue: 127968, Profit: 71718,
+				ReadyBy: now.Add(168 * time.Hour),
+			},
+		},
+		{
+			name: "rush",
+			opts: Options{Rush: true},
+			want: Breakdown{
+				AreaDM2: 10, LeatherCost: 25000, ProcessCost: 31250, TotalCost: 56250,
+				RushSurcharge: 42188, Price: 182813, Commission: 5484, Tax: 10969, NetRevenue: 166360, Profit: 110110,
+				ReadyBy: now.Add(48 * time.Hour),
+			},
+		},
+		{
+			name: "rates of a pricing rule",
+			opts: Options{Rates: &Rates{CommissionRate: 0, TaxRate: 0.2, TaxRegime: RegimeVAT}},
+			want: Breakdown{
+				AreaDM2: 10, LeatherCost: 25000, ProcessCost: 31250, TotalCost: 56250,
+				Price: 140625, Commission: 0, Tax: 23438, NetRevenue: 117187, Profit: 60937,
+				ReadyBy: now.Add(168 * time.Hour),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(testConfig()).Calculate(20, 50, 25, tt.opts, now)
+			if got != tt.want {
+				t.Errorf("Calculate() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateStickers(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		spec      StickerSpec
+		wantCost  money.Amount
+		wantPrice money.Amount
+	}{
+		{
+			name:      "laminated run with a bulk discount",
+			spec:      StickerSpec{WidthCM: 5, HeightCM: 5, Quantity: 100, Lamination: LaminationGloss},
+			wantCost:  95000,
+			wantPrice: 213750,
+		},
+		{
+			name:      "small run at the minimum price",
+			spec:      StickerSpec{WidthCM: 5, HeightCM: 5, Quantity: 1, Lamination: LaminationNone},
+			wantCost:  700,
+			wantPrice: 50000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(testConfig()).CalculateStickers(tt.spec, 20, Options{}, now)
+			if got.TotalCost != tt.wantCost || got.Price != tt.wantPrice {
+				t.Errorf("cost, price = %v, %v, want %v, %v", got.TotalCost, got.Price, tt.wantCost, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestApplyDiscount(t *testing.T) {
+	tests := []struct {
+		name           string
+		discount       money.Amount
+		wantDiscount   money.Amount
+		wantPrice      money.Amount
+		wantCommission money.Amount
+	}{
+		{name: "discount", discount: 10000, wantDiscount: 10000, wantPrice: 130625, wantCommission: 3919},
+		{name: "never below cost", discount: 200000, wantDiscount: 84375, wantPrice: 56250, wantCommission: 1688},
+		{name: "nothing", discount: 0, wantDiscount: 0, wantPrice: 140625, wantCommission: 4219},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(testConfig())
+			b := c.Calculate(20, 50, 25, Options{}, time.Now())
+			c.ApplyDiscount(&b, tt.discount, Options{})
+			if b.Discount != tt.wantDiscount || b.Price != tt.wantPrice || b.Commission != tt.wantCommission {
+				t.Errorf("discount, price, commission = %v, %v, %v, want %v, %v, %v",
+					b.Discount, b.Price, b.Commission, tt.wantDiscount, tt.wantPrice, tt.wantCommission)
+			}
+			if b.Price != b.NetRevenue+b.Commission+b.Tax {
+				t.Errorf("price %v != net %v + commission %v + tax %v", b.Price, b.NetRevenue, b.Commission, b.Tax)
+			}
+		})
+	}
+}
+
+func TestApplyLoyalty(t *testing.T) {
+	c := New(testConfig())
+	b := c.Calculate(20, 50, 25, Options{}, time.Now())
+	c.ApplyDiscount(&b, 10000, Options{})
+	c.ApplyLoyalty(&b, "gold", 10, Options{})
+
+	// 10% of the price after the promo code
+	if b.LoyaltyDiscount != 13063 || b.Discount != 23063 || b.LoyaltyTier != "gold" {
+		t.Errorf("loyalty, discount, tier = %v, %v, %q, want 130.63, 230.63, gold", b.LoyaltyDiscount, b.Discount, b.LoyaltyTier)
+	}
+}
+
+func TestApplyShipping(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       DeliveryMethod
+		freeFrom     float64
+		wantShipping money.Amount
+		wantPrice    money.Amount
+		wantCost     money.Amount
+	}{
+		{name: "pickup", method: DeliveryPickup, freeFrom: 10000, wantShipping: 0, wantPrice: 140625, wantCost: 56250},
+		{name: "courier", method: DeliveryCourier, freeFrom: 10000, wantShipping: 40000, wantPrice: 180625, wantCost: 96250},
+		{name: "post", method: DeliveryPost, freeFrom: 0, wantShipping: 35000, wantPrice: 175625, wantCost: 91250},
+		{name: "free from the threshold", method: DeliveryCourier, freeFrom: 1000, wantShipping: 0, wantPrice: 140625, wantCost: 96250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Pricing.DeliveryFreeFrom = tt.freeFrom
+			c := New(cfg)
+			b := c.Calculate(20, 50, 25, Options{}, time.Now())
+			c.ApplyShipping(&b, tt.method, Options{})
+			if b.Shipping != tt.wantShipping || b.Price != tt.wantPrice || b.TotalCost != tt.wantCost {
+				t.Errorf("shipping, price, cost = %v, %v, %v, want %v, %v, %v",
+					b.Shipping, b.Price, b.TotalCost, tt.wantShipping, tt.wantPrice, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestRegime(t *testing.T) {
+	tests := []struct {
+		name       string
+		regime     string
+		rate       float64
+		totalCost  money.Amount
+		wantTax    money.Amount
+		wantNet    money.Amount
+		wantProfit money.Amount
+	}{
+		{name: "income", regime: RegimeUSNIncome, rate: 0.06, totalCost: 4000, wantTax: 600, wantNet: 9100, wantProfit: 5100},
+		{name: "self-employed", regime: RegimeNPD, rate: 0.04, totalCost: 4000, wantTax: 400, wantNet: 9300, wantProfit: 5300},
+		{name: "profit", regime: RegimeUSNProfit, rate: 0.15, totalCost: 4000, wantTax: 855, wantNet: 8845, wantProfit: 4845},
+		{name: "profit at a loss", regime: RegimeUSNProfit, rate: 0.15, totalCost: 12000, wantTax: 0, wantNet: 9700, wantProfit: -2300},
+		{name: "included VAT", regime: RegimeVAT, rate: 0.2, totalCost: 4000, wantTax: 1667, wantNet: 8033, wantProfit: 4033},
+		{name: "unknown taxes income", regime: "flat", rate: 0.06, totalCost: 4000, wantTax: 600, wantNet: 9100, wantProfit: 5100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Breakdown{Price: 10000, Commission: 300, TotalCost: tt.totalCost}
+			Regime(tt.regime, tt.rate).Settle(&b)
+			if b.Tax != tt.wantTax || b.NetRevenue != tt.wantNet || b.Profit != tt.wantProfit {
+				t.Errorf("tax, net, profit = %d, %d, %d, want %d, %d, %d",
+					b.Tax, b.NetRevenue, b.Profit, tt.wantTax, tt.wantNet, tt.wantProfit)
+			}
+		})
+	}
+}
+
+func TestBulkDiscount(t *testing.T) {
+	tests := []struct {
+		quantity int
+		want     float64
+	}{
+		{quantity: 1, want: 0},
+		{quantity: 99, want: 0},
+		{quantity: 100, want: 0.10},
+		{quantity: 499, want: 0.10},
+		{quantity: 500, want: 0.15},
+		{quantity: 1000, want: 0.25},
+		{quantity: 5000, want: 0.25},
+	}
+
+	for _, tt := range tests {
+		if got := BulkDiscount(tt.quantity); got != tt.want {
+			t.Errorf("BulkDiscount(%d) = %v, want %v", tt.quantity, got, tt.want)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	early := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	late := early.Add(72 * time.Hour)
+
+	got := Sum(
+		Breakdown{AreaDM2: 0.1, TotalCost: 100, Price: 300, Tax: 18, ReadyBy: late},
+		Breakdown{AreaDM2: 0.2, TotalCost: 200, Price: 500, Tax: 30, ReadyBy: early},
+	)
+	want := Breakdown{AreaDM2: 0.3, TotalCost: 300, Price: 800, Tax: 48, ReadyBy: late}
+	if got != want {
+		t.Errorf("Sum() = %+v, want %+v", got, want)
+	}
+}
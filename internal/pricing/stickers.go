@@ -0,0 +1,69 @@
+package pricing
+
+import (
+	"time"
+
+	"s1ntez/pkg/money"
+)
+
+// Lamination protects a sticker print; gloss and matte cost the same
+type Lamination string
+
+const (
+	LaminationNone  Lamination = "none"
+	LaminationGloss Lamination = "gloss"
+	LaminationMatte Lamination = "matte"
+)
+
+var Laminations = []Lamination{LaminationNone, LaminationGloss, LaminationMatte}
+
+func (l Lamination) Valid() bool {
+	switch l {
+	case LaminationNone, LaminationGloss, LaminationMatte:
+		return true
+	}
+	return false
+}
+
+// StickerSpec is a run of identical stickers
+type StickerSpec struct {
+	WidthCM    int
+	HeightCM   int
+	Quantity   int
+	Lamination Lamination
+}
+
+// CalculateStickers quotes a sticker run printed on a vinyl priced per dm².
+// The material column holds the vinyl, the process column lamination and
+// cutting. Small runs are brought up to the minimum order price.
+func (c *Calculator) CalculateStickers(spec StickerSpec, pricePerDM2 float64, opts Options, now time.Time) Breakdown {
+	p := c.cfg.CurrentPricing()
+	sc := c.cfg.Stickers
+
+	quantity := max(spec.Quantity, 1)
+
+	var b Breakdown
+	b.AreaDM2 = float64(spec.WidthCM*spec.HeightCM) / 100 * float64(quantity)
+	b.LeatherCost = money.FromFloat(pricePerDM2).MulRate(b.AreaDM2)
+
+	b.ProcessCost = money.FromFloat(sc.CutCostPerPiece).MulRate(float64(quantity))
+	if spec.Lamination != "" && spec.Lamination != LaminationNone {
+		b.ProcessCost += money.FromFloat(sc.LaminationPerDM2).MulRate(b.AreaDM2)
+	}
+	b.TotalCost = b.LeatherCost + b.ProcessCost
+
+	base := b.TotalCost.MulRate(p.MarkupMultiplier * (1 - BulkDiscount(quantity)))
+	base = max(base, money.FromFloat(sc.MinPrice))
+
+	leadTime := p.StandardLeadTime
+	if opts.Rush {
+		b.RushSurcharge = base.MulRate(p.RushSurchargeRate)
+		leadTime = p.RushLeadTime
+	}
+	b.Price = base + b.RushSurcharge
+
+	c.applyRates(&b, opts)
+	b.ReadyBy = now.Add(leadTime)
+
+	return b
+}
@@ -0,0 +1,72 @@
+package pricing
+
+import "s1ntez/pkg/money"
+
+// Tax regimes; the rate comes with the pricing rule or SALES_TAX_RATE
+const (
+	// RegimeUSNIncome is the simplified system on income, 6%
+	RegimeUSNIncome = "usn_income"
+	// RegimeUSNProfit is the simplified system on income less expenses,
+	// 15%: materials, processing and the payment commission count as
+	// expenses
+	RegimeUSNProfit = "usn_profit"
+	// RegimeNPD is the professional income tax of the self-employed: 4% of
+	// sales to individuals, 6% to companies
+	RegimeNPD = "npd"
+	// RegimeVAT is the general system, VAT at 20% included in the price
+	RegimeVAT = "vat"
+)
+
+// Regimes lists the regime names in the order staff see them
+var Regimes = []string{RegimeUSNIncome, RegimeUSNProfit, RegimeNPD, RegimeVAT}
+
+// TaxRegime settles a breakdown: it sets Tax, NetRevenue and Profit from
+// the price, the commission and the costs
+type TaxRegime interface {
+	Settle(b *Breakdown)
+}
+
+// Regime returns the strategy for a regime name taxing at rate; unknown
+// names fall back to the tax on income, which is how orders were taxed
+// before regimes were configurable
+func Regime(name string, rate float64) TaxRegime {
+	switch name {
+	case RegimeUSNProfit:
+		return profitTax{rate: rate}
+	case RegimeVAT:
+		return includedTax{rate: rate}
+	default:
+		return incomeTax{rate: rate}
+	}
+}
+
+// incomeTax takes the rate of the price: УСН «доходы» and НПД
+type incomeTax struct{ rate float64 }
+
+func (t incomeTax) Settle(b *Breakdown) {
+	settle(b, b.Price.MulRate(t.rate))
+}
+
+// profitTax takes the rate of what is left after expenses, nothing at a loss
+type profitTax struct{ rate float64 }
+
+func (t profitTax) Settle(b *Breakdown) {
+	base := b.Price - b.Commission - b.TotalCost
+	settle(b, max(base, 0).MulRate(t.rate))
+}
+
+// includedTax is VAT: the price already contains it, so it is
+// rate/(1+rate) of the price rather than rate
+type includedTax struct{ rate float64 }
+
+func (t includedTax) Settle(b *Breakdown) {
+	settle(b, b.Price.MulRate(t.rate/(1+t.rate)))
+}
+
+// settle derives the net figures as exact differences, so
+// price = net + commission + tax holds in every export
+func settle(b *Breakdown, tax money.Amount) {
+	b.Tax = tax
+	b.NetRevenue = b.Price - b.Commission - b.Tax
+	b.Profit = b.NetRevenue - b.TotalCost
+}
@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	"time"
+
+	"s1ntez/pkg/money"
+)
+
+// PrintSpec is a run of printed sheets: cards, flyers or a banner
+type PrintSpec struct {
+	WidthCM  int
+	HeightCM int
+	Quantity int
+	// Sides is 1 for one-sided, 2 for double-sided print
+	Sides int
+}
+
+// CalculatePrint quotes a print run on a paper priced per dm². The material
+// column holds the paper, the process column ink and the prepress setup.
+func (c *Calculator) CalculatePrint(spec PrintSpec, pricePerDM2 float64, opts Options, now time.Time) Breakdown {
+	p := c.cfg.CurrentPricing()
+	tc := c.cfg.Typography
+
+	quantity := max(spec.Quantity, 1)
+	sides := min(max(spec.Sides, 1), 2)
+
+	var b Breakdown
+	b.AreaDM2 = float64(spec.WidthCM*spec.HeightCM) / 100 * float64(quantity)
+	b.LeatherCost = money.FromFloat(pricePerDM2).MulRate(b.AreaDM2)
+	b.ProcessCost = money.FromFloat(tc.PrintCostPerDM2).MulRate(b.AreaDM2*float64(sides)) + money.FromFloat(tc.SetupCost)
+	b.TotalCost = b.LeatherCost + b.ProcessCost
+
+	base := b.TotalCost.MulRate(p.MarkupMultiplier * (1 - BulkDiscount(quantity)))
+	base = max(base, money.FromFloat(tc.MinPrice))
+
+	leadTime := p.StandardLeadTime
+	if opts.Rush {
+		b.RushSurcharge = base.MulRate(p.RushSurchargeRate)
+		leadTime = p.RushLeadTime
+	}
+	b.Price = base + b.RushSurcharge
+
+	c.applyRates(&b, opts)
+	b.ReadyBy = now.Add(leadTime)
+
+	return b
+}
@@ -0,0 +1,103 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCode  = errors.New("promo code must be 3-32 letters, digits, dashes or underscores")
+	ErrInvalidValue = errors.New("invalid promo discount")
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// Service checks promo codes against their expiry and usage limits.
+// The limits are enforced again when the order is saved.
+type Service struct {
+	storage *postgres.PostgresStorage
+}
+
+func New(storage *postgres.PostgresStorage) *Service {
+	return &Service{storage: storage}
+}
+
+// Normalize makes codes case-insensitive: they are stored upper-case
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Create validates and saves a new code
+func (s *Service) Create(ctx context.Context, code postgres.PromoCode) (int64, error) {
+	code.Code = Normalize(code.Code)
+	if !codePattern.MatchString(code.Code) {
+		return 0, ErrInvalidCode
+	}
+
+	switch code.Kind {
+	case postgres.PromoPercent:
+		if code.Value <= 0 || code.Value >= 100 {
+			return 0, fmt.Errorf("%w: percent must be within 0..100", ErrInvalidValue)
+		}
+	case postgres.PromoFixed:
+		if code.Value <= 0 {
+			return 0, fmt.Errorf("%w: amount must be positive", ErrInvalidValue)
+		}
+	default:
+		return 0, fmt.Errorf("%w: kind %q", ErrInvalidValue, code.Kind)
+	}
+
+	if code.MaxUses != nil && *code.MaxUses <= 0 {
+		return 0, fmt.Errorf("%w: usage limit must be positive", ErrInvalidValue)
+	}
+	if code.PerUserLimit <= 0 {
+		code.PerUserLimit = 1
+	}
+
+	return s.storage.CreatePromoCode(ctx, code)
+}
+
+// Check returns the code if the customer may apply it at the given moment
+func (s *Service) Check(ctx context.Context, raw string, userID int64, now time.Time) (*postgres.PromoCode, error) {
+	code, err := s.storage.GetPromoCode(ctx, Normalize(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	if code.ExpiresAt != nil && !now.Before(*code.ExpiresAt) {
+		return nil, postgres.ErrPromoExpired
+	}
+	if code.MaxUses != nil && code.UsedCount >= *code.MaxUses {
+		return nil, postgres.ErrPromoExhausted
+	}
+
+	// Quotes shown before the customer is known skip the per-customer limit
+	if userID != 0 {
+		used, err := s.storage.CountPromoRedemptions(ctx, code.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= code.PerUserLimit {
+			return nil, postgres.ErrPromoUserLimit
+		}
+	}
+
+	return code, nil
+}
+
+// Discount is the amount the code takes off a price
+func Discount(code postgres.PromoCode, price money.Amount) money.Amount {
+	switch code.Kind {
+	case postgres.PromoPercent:
+		return price.MulRate(code.Value / 100)
+	case postgres.PromoFixed:
+		return min(money.FromFloat(code.Value), price)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package referral
+
+import (
+	"context"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/i18n"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler wires the service to Telegram:
+//
+//	/start ref_<code>  — records the referral, then runs the regular /start
+//	/referral          — shows the user's link and bonus balance
+type Handler struct {
+	service *Service
+	start   bot.CommandHandler
+	logger  *zap.Logger
+}
+
+// NewHandler wraps the regular /start handler so deep links are recorded
+// before the usual greeting
+func NewHandler(service *Service, start bot.CommandHandler, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		start:   start,
+		logger:  logger.Named("referral"),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if msg.Command() == "start" {
+		if code, ok := strings.CutPrefix(strings.TrimSpace(msg.CommandArguments()), StartPrefix); ok && code != "" {
+			// A broken link must not keep the customer from starting
+			if _, err := h.service.Record(ctx, msg.From.ID, code); err != nil {
+				h.logger.Error("Failed to record referral", zap.Int64("user_id", msg.From.ID), zap.Error(err))
+			}
+		}
+		return h.start.Handle(ctx, update)
+	}
+
+	if !msg.Chat.IsPrivate() {
+		return nil
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	code, err := h.service.Code(ctx, msg.From.ID)
+	if err != nil {
+		return err
+	}
+	stats, err := h.service.storage.GetReferralStats(ctx, msg.From.ID)
+	if err != nil {
+		return err
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, "referral.info",
+		h.service.Link(code), h.service.cfg.Referral.Bonus, stats.Invited, stats.Converted, stats.Balance))
+	reply.ParseMode = tgbotapi.ModeHTML
+	reply.DisableWebPagePreview = true
+	_, err = h.service.botAPI.Send(reply)
+	return err
+}
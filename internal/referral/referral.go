@@ -0,0 +1,135 @@
+package referral
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// StartPrefix marks a referral deep link: /start ref_<code>
+const StartPrefix = "ref_"
+
+const codeAttempts = 3
+
+// Service tracks who invited whom and credits the referrer once the
+// invited customer's first order is completed. There is no online payment,
+// so completion is when an order counts as paid.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("referral"),
+		cfg:     cfg,
+	}
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "referral.credit", s.OnStatusChanged)
+}
+
+// Code returns the user's referral code, issuing one on first use
+func (s *Service) Code(ctx context.Context, userID int64) (string, error) {
+	for range codeAttempts {
+		candidate, err := newCode()
+		if err != nil {
+			return "", err
+		}
+
+		code, err := s.storage.EnsureReferralCode(ctx, userID, candidate)
+		if errors.Is(err, postgres.ErrReferralCodeTaken) {
+			continue
+		}
+		return code, err
+	}
+	return "", postgres.ErrReferralCodeTaken
+}
+
+// Link is the deep link that opens the bot with the code
+func (s *Service) Link(code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", s.botAPI.Self.UserName, StartPrefix, code)
+}
+
+// Record links a customer who opened a referral link to its owner.
+// Self-referrals, unknown codes and existing customers are ignored.
+func (s *Service) Record(ctx context.Context, refereeID int64, code string) (bool, error) {
+	referrerID, err := s.storage.GetReferrerByCode(ctx, strings.ToUpper(code))
+	if errors.Is(err, postgres.ErrReferralCodeNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if referrerID == refereeID {
+		return false, nil
+	}
+
+	recorded, err := s.storage.RecordReferral(ctx, refereeID, referrerID)
+	if err != nil {
+		return false, err
+	}
+	if recorded {
+		s.logger.Info("Referral recorded",
+			zap.Int64("referrer_id", referrerID),
+			zap.Int64("referee_id", refereeID))
+	}
+	return recorded, nil
+}
+
+// OnStatusChanged credits the referrer when a referred customer's first
+// order is completed
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	bonus := s.cfg.Referral.Bonus
+	if event.Status != postgres.StatusCompleted || bonus <= 0 {
+		return nil
+	}
+
+	referrerID, credited, err := s.storage.CreditReferral(ctx, event.UserID, event.OrderID, bonus)
+	if err != nil || !credited {
+		return err
+	}
+
+	s.logger.Info("Referral bonus credited",
+		zap.Int64("referrer_id", referrerID),
+		zap.Int64("referee_id", event.UserID),
+		zap.Int64("order_id", event.OrderID),
+		zap.Float64("bonus", bonus))
+
+	locale, err := s.storage.GetUserLocale(ctx, referrerID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	msg := tgbotapi.NewMessage(referrerID, i18n.T(locale, "referral.credited", bonus))
+	msg.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.botAPI.Send(msg); err != nil {
+		// The bonus is already on the balance
+		s.logger.Warn("Failed to notify referrer", zap.Int64("referrer_id", referrerID), zap.Error(err))
+	}
+	return nil
+}
+
+// newCode returns 8 random base32 characters
+func newCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate referral code: %w", err)
+	}
+	return base32.StdEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,191 @@
+package reports
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Accounting export formats, as the accountant's 1C data processing
+// loads them
+const (
+	AccountingCSV = "csv"
+	AccountingXML = "xml"
+)
+
+// AccountingTotals sum an accounting export for the message it is sent with
+type AccountingTotals struct {
+	Sales  int
+	Amount money.Amount
+	VAT    money.Amount
+}
+
+// VAT is the tax included in the amount at the rate in percent, rounded
+// half up to the kopeck
+func VAT(amount money.Amount, rate int) money.Amount {
+	if rate <= 0 || amount <= 0 {
+		return 0
+	}
+	n, d := int64(amount)*int64(rate), int64(100+rate)
+	return money.Amount((2*n + d) / (2 * d))
+}
+
+// vatRate is how 1C names the rate
+func vatRate(rate int) string {
+	if rate <= 0 {
+		return "Без НДС"
+	}
+	return strconv.Itoa(rate) + "%"
+}
+
+// counterparty names the customer; 1C matches counterparties by the code,
+// the Telegram ID
+func counterparty(e postgres.AccountingEntry) string {
+	if e.Username != "" {
+		return "@" + e.Username
+	}
+	return fmt.Sprintf("Покупатель %d", e.UserID)
+}
+
+// WriteAccounting writes the month's sales in the format to path
+func WriteAccounting(path, format string, month time.Time, entries []postgres.AccountingEntry, vatRate int) (AccountingTotals, error) {
+	var totals AccountingTotals
+	for _, e := range entries {
+		totals.Sales++
+		totals.Amount += e.Amount
+		totals.VAT += VAT(e.Amount, vatRate)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return totals, fmt.Errorf("failed to create accounting export: %w", err)
+	}
+	defer f.Close()
+
+	switch format {
+	case AccountingCSV:
+		err = writeAccountingCSV(f, entries, vatRate)
+	case AccountingXML:
+		err = writeAccountingXML(f, month, entries, vatRate, totals)
+	default:
+		err = fmt.Errorf("unknown accounting format %q", format)
+	}
+	if err != nil {
+		return totals, err
+	}
+	if err := f.Close(); err != nil {
+		return totals, fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	return totals, nil
+}
+
+// writeAccountingCSV writes one sale per row, separated by semicolons with
+// decimal commas, as 1C reads spreadsheets in the Russian locale. The BOM
+// keeps the Cyrillic readable when the file is opened in Excel first.
+func writeAccountingCSV(f *os.File, entries []postgres.AccountingEntry, rate int) error {
+	if _, err := f.WriteString("\ufeff"); err != nil {
+		return fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+	w.UseCRLF = true
+
+	decimal := func(a money.Amount) string {
+		return strings.Replace(a.String(), ".", ",", 1)
+	}
+
+	rows := [][]string{{
+		"Дата", "Номер", "Контрагент", "Код контрагента", "Телефон",
+		"Сумма", "Ставка НДС", "Сумма НДС", "Оплачено сертификатом", "Валюта",
+	}}
+	for _, e := range entries {
+		rows = append(rows, []string{
+			e.Date.Format("02.01.2006"),
+			e.Code,
+			counterparty(e),
+			strconv.FormatInt(e.UserID, 10),
+			e.Contact,
+			decimal(e.Amount),
+			vatRate(rate),
+			decimal(VAT(e.Amount, rate)),
+			decimal(e.GiftCard),
+			string(e.Currency),
+		})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	return nil
+}
+
+type accountingDocument struct {
+	XMLName xml.Name         `xml:"Реализации"`
+	Period  string           `xml:"Период,attr"`
+	Created string           `xml:"ДатаФормирования,attr"`
+	Count   int              `xml:"Количество,attr"`
+	Amount  string           `xml:"Сумма,attr"`
+	VAT     string           `xml:"СуммаНДС,attr"`
+	Sales   []accountingSale `xml:"Документ"`
+}
+
+type accountingSale struct {
+	Number       string                 `xml:"Номер,attr"`
+	Date         string                 `xml:"Дата,attr"`
+	Counterparty accountingCounterparty `xml:"Контрагент"`
+	Amount       string                 `xml:"Сумма"`
+	VATRate      string                 `xml:"СтавкаНДС"`
+	VAT          string                 `xml:"СуммаНДС"`
+	GiftCard     string                 `xml:"ОплаченоСертификатом"`
+	Currency     string                 `xml:"Валюта"`
+}
+
+type accountingCounterparty struct {
+	Code  string `xml:"Код,attr"`
+	Name  string `xml:"Наименование,attr"`
+	Phone string `xml:"Телефон,attr,omitempty"`
+}
+
+// writeAccountingXML writes the sales as Документ elements of one
+// Реализации root, amounts with a decimal point and dates in ISO 8601
+func writeAccountingXML(f *os.File, month time.Time, entries []postgres.AccountingEntry, rate int, totals AccountingTotals) error {
+	doc := accountingDocument{
+		Period:  month.Format("2006-01"),
+		Created: time.Now().Format(time.RFC3339),
+		Count:   totals.Sales,
+		Amount:  totals.Amount.String(),
+		VAT:     totals.VAT.String(),
+		Sales:   make([]accountingSale, 0, len(entries)),
+	}
+	for _, e := range entries {
+		doc.Sales = append(doc.Sales, accountingSale{
+			Number: e.Code,
+			Date:   e.Date.Format("2006-01-02T15:04:05"),
+			Counterparty: accountingCounterparty{
+				Code:  strconv.FormatInt(e.UserID, 10),
+				Name:  counterparty(e),
+				Phone: e.Contact,
+			},
+			Amount:   e.Amount.String(),
+			VATRate:  vatRate(rate),
+			VAT:      VAT(e.Amount, rate).String(),
+			GiftCard: e.GiftCard.String(),
+			Currency: string(e.Currency),
+		})
+	}
+
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"fmt"
+	"s1ntez/internal/storage/postgres"
+	"sort"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ClosingPDF renders the month-close summary for the accountant.
+// The core PDF fonts have no Cyrillic, so the document is in English.
+func ClosingPDF(path string, closing *postgres.PeriodClose, snapshot postgres.PeriodSnapshot) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Closing report %s", snapshot.PeriodStart.Format("2006-01")), true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.Cell(0, 10, fmt.Sprintf("Closing report: %s", snapshot.PeriodStart.Format("January 2006")))
+	pdf.Ln(12)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Period: %s - %s",
+		snapshot.PeriodStart.Format("2006-01-02"),
+		snapshot.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Closed at: %s by admin %d",
+		closing.ClosedAt.Format("2006-01-02 15:04 MST"), closing.ClosedBy))
+	pdf.Ln(10)
+
+	rows := []struct {
+		label string
+		value float64
+	}{
+		{"Revenue", snapshot.Revenue},
+		{"  incl. rush surcharge", snapshot.RushSurcharge},
+		{"Leather cost", snapshot.LeatherCost},
+		{"Processing cost", snapshot.ProcessCost},
+		{"Total cost", snapshot.TotalCost},
+		{"Payment commission", snapshot.Commission},
+		{"Tax", snapshot.Tax},
+		{"Net revenue", snapshot.NetRevenue},
+		{"Profit", snapshot.Profit},
+	}
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(100, 8, "Figure", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(60, 8, "Amount, RUB", "1", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(100, 8, "Orders (excl. cancelled)", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(60, 8, fmt.Sprintf("%d", snapshot.Orders), "1", 1, "R", false, 0, "")
+	for _, row := range rows {
+		pdf.CellFormat(100, 8, row.label, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(60, 8, fmt.Sprintf("%.2f", row.value), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	statuses := make([]string, 0, len(snapshot.ByStatus))
+	for status := range snapshot.ByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.Cell(0, 8, "Orders by status")
+	pdf.Ln(8)
+	pdf.SetFont("Helvetica", "", 11)
+	for _, status := range statuses {
+		pdf.CellFormat(100, 7, status, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(60, 7, fmt.Sprintf("%d", snapshot.ByStatus[status]), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(10)
+
+	pdf.SetFont("Courier", "", 8)
+	pdf.MultiCell(0, 4, fmt.Sprintf("Snapshot #%d, HMAC-SHA256:\n%s", closing.ID, closing.Signature), "", "L", false)
+
+	if err := pdf.OutputFileAndClose(path); err != nil {
+		return fmt.Errorf("failed to write closing pdf: %w", err)
+	}
+	return nil
+}
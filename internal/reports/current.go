@@ -0,0 +1,69 @@
+package reports
+
+import (
+	"context"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// currentOrdersFile is the report name, saved as reports/current_orders.xlsx
+const currentOrdersFile = "current_orders"
+
+// CurrentOrders keeps reports/current_orders.xlsx in step with the orders.
+// Order events only mark the report stale; it is rebuilt once things have
+// been quiet for the debounce delay, so a burst of changes (a bulk status
+// update, a busy hour) costs one export instead of one per order.
+type CurrentOrders struct {
+	storage *postgres.PostgresStorage
+	logger  *zap.Logger
+	delay   time.Duration
+	stale   chan struct{}
+}
+
+func NewCurrentOrders(storage *postgres.PostgresStorage, logger *zap.Logger, delay time.Duration) *CurrentOrders {
+	return &CurrentOrders{
+		storage: storage,
+		logger:  logger.Named("reports"),
+		delay:   delay,
+		stale:   make(chan struct{}, 1),
+	}
+}
+
+func (r *CurrentOrders) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "reports.order_created", r.Invalidate)
+	bus.Subscribe(events.OrderStatusChanged, "reports.status_changed", r.Invalidate)
+}
+
+// Invalidate marks the report stale; it never blocks the publisher
+func (r *CurrentOrders) Invalidate(ctx context.Context, event events.Event) error {
+	select {
+	case r.stale <- struct{}{}:
+	default:
+		// a rebuild is already pending
+	}
+	return nil
+}
+
+// Watch rebuilds the report after changes until ctx is cancelled
+func (r *CurrentOrders) Watch(ctx context.Context) {
+	timer := time.NewTimer(r.delay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stale:
+			timer.Reset(r.delay)
+		case <-timer.C:
+			if err := r.storage.ExportAllOrdersToExcel(ctx, currentOrdersFile, postgres.ExportOptions{Locale: i18n.Default}); err != nil {
+				r.logger.Error("Failed to rebuild current orders report", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// commentWindow is how long after the rating a message is taken for its
+// comment
+const commentWindow = time.Hour
+
+// Handler takes the answers to a survey: the rating buttons, the button
+// that skips the comment, and the comment typed after the rating.
+type Handler struct {
+	service *Service
+	redis   *redis.Storage
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, redis *redis.Storage, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		redis:   redis,
+		logger:  logger.Named("reviews"),
+	}
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	botAPI := h.service.botAPI
+	locale, err := h.service.storage.GetUserLocale(ctx, query.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	// review:rate:<order>:<rating> or review:skip:<order>
+	parts := strings.Split(query.Data, ":")
+	if len(parts) < 3 || parts[0] != CallbackPrefix {
+		return fmt.Errorf("bad review callback %q", query.Data)
+	}
+	orderID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad order id in callback %q", query.Data)
+	}
+
+	switch {
+	case parts[1] == "skip" && len(parts) == 3:
+		err := h.service.storage.SetReviewComment(ctx, orderID, query.From.ID, "")
+		if err != nil && !errors.Is(err, postgres.ErrReviewNotFound) {
+			return err
+		}
+		_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		return h.edit(query, i18n.T(locale, "review.thanks"))
+
+	case parts[1] == "rate" && len(parts) == 4:
+		rating, err := strconv.Atoi(parts[3])
+		if err != nil || rating < 1 || rating > 5 {
+			return fmt.Errorf("bad rating in callback %q", query.Data)
+		}
+
+		_, err = h.service.storage.SaveRating(ctx, orderID, query.From.ID, rating)
+		switch {
+		case errors.Is(err, postgres.ErrReviewExists):
+			_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "review.already_rated")))
+			return nil
+		case errors.Is(err, postgres.ErrReviewNotFound):
+			_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, i18n.T(locale, "review.not_available")))
+			return nil
+		case err != nil:
+			return err
+		}
+
+		h.logger.Info("Order rated",
+			zap.Int64("order_id", orderID),
+			zap.Int64("user_id", query.From.ID),
+			zap.Int("rating", rating))
+
+		_, _ = botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+		edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID,
+			i18n.T(locale, "review.ask_comment", rating),
+			tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "review.skip_comment"),
+					fmt.Sprintf("%s:skip:%d", CallbackPrefix, orderID)),
+			)))
+		_, err = botAPI.Send(edit)
+		return err
+	}
+
+	return fmt.Errorf("bad review callback %q", query.Data)
+}
+
+// HandleMessage takes a plain message sent soon after a rating for its
+// comment
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if msg.From == nil || !msg.Chat.IsPrivate() || strings.TrimSpace(msg.Text) == "" {
+		return false, nil
+	}
+
+	// Messages typed during an order dialog belong to the dialog
+	state, err := h.redis.GetUserDialogState(ctx, msg.Chat.ID)
+	if err != nil {
+		return false, err
+	}
+	if state.Step != "" {
+		return false, nil
+	}
+
+	review, err := h.service.storage.GetReviewAwaitingComment(ctx, msg.From.ID, time.Now().Add(-commentWindow))
+	if errors.Is(err, postgres.ErrReviewNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := h.service.storage.SetReviewComment(ctx, review.OrderID, msg.From.ID, strings.TrimSpace(msg.Text)); err != nil {
+		if errors.Is(err, postgres.ErrReviewNotFound) {
+			return false, nil
+		}
+		return true, err
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	_, err = h.service.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, i18n.T(locale, "review.thanks")))
+	return true, err
+}
+
+// edit replaces the survey message, dropping its buttons
+func (h *Handler) edit(query *tgbotapi.CallbackQuery, text string) error {
+	if query.Message == nil {
+		return nil
+	}
+	_, err := h.service.botAPI.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text))
+	return err
+}
@@ -0,0 +1,123 @@
+// Package reviews asks customers how their order went: REVIEW_DELAY after
+// an order is completed the customer gets a survey to rate it from 1 to 5,
+// and may add a comment to the rating. The averages are shown in /stats;
+// the comments a manager approves are shown to everyone in /reviews.
+package reviews
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	KindSurvey     = "review_survey"
+	CallbackPrefix = "review"
+)
+
+// SurveyPayload is the job payload of a survey
+type SurveyPayload struct {
+	OrderID int64 `json:"order_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+// Service sends the surveys and records the answers
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("reviews"),
+		cfg:     cfg,
+	}
+}
+
+// Enabled reports whether completed orders get a survey
+func (s *Service) Enabled() bool {
+	return s.cfg.Reviews.Delay > 0
+}
+
+// Register subscribes the service to the events it reacts to
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderStatusChanged, "reviews.survey", s.OnStatusChanged)
+}
+
+// OnStatusChanged queues the survey of a completed order
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if !s.Enabled() || event.Status != postgres.StatusCompleted || event.PrevStatus == postgres.StatusCompleted {
+		return nil
+	}
+
+	runAt := time.Now().Add(s.cfg.Reviews.Delay)
+	id, err := s.storage.EnqueueJobAt(ctx, KindSurvey, SurveyPayload{
+		OrderID: event.OrderID,
+		UserID:  event.UserID,
+	}, runAt, s.cfg.Jobs.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Review survey scheduled",
+		zap.Int64("job_id", id),
+		zap.Int64("order_id", event.OrderID),
+		zap.Time("run_at", runAt))
+	return nil
+}
+
+// Survey is the job handler: it asks for the rating unless the order was
+// reopened or cancelled meanwhile, was rated already, or the customer
+// turned notifications off
+func (s *Service) Survey(ctx context.Context, job *postgres.Job) error {
+	var payload SurveyPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+
+	order, err := s.storage.GetOrderByID(ctx, payload.OrderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != postgres.StatusCompleted {
+		return nil
+	}
+	rated, err := s.storage.HasReview(ctx, order.ID)
+	if err != nil || rated {
+		return err
+	}
+	enabled, err := s.storage.NotificationsEnabled(ctx, order.UserID)
+	if err != nil || !enabled {
+		return err
+	}
+
+	locale, err := s.storage.GetUserLocale(ctx, order.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, 5)
+	for rating := 1; rating <= 5; rating++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			strconv.Itoa(rating)+"⭐",
+			fmt.Sprintf("%s:rate:%d:%d", CallbackPrefix, order.ID, rating)))
+	}
+	msg := tgbotapi.NewMessage(order.UserID, i18n.T(locale, "review.ask", order.Code))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	if _, err := s.botAPI.Send(msg); err != nil {
+		return fmt.Errorf("failed to send review survey of order %d: %w", order.ID, err)
+	}
+	return nil
+}
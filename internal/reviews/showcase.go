@@ -0,0 +1,128 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/i18n"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	ShowcaseCallbackPrefix = "reviews"
+
+	// pageSize reviews fit in one message with room to spare
+	pageSize = 5
+)
+
+// ShowcaseHandler serves /reviews: the comments managers approved, newest
+// first, a page at a time, under the average rating of all orders
+type ShowcaseHandler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewShowcaseHandler(service *Service, logger *zap.Logger) *ShowcaseHandler {
+	return &ShowcaseHandler{
+		service: service,
+		logger:  logger.Named("reviews"),
+	}
+}
+
+func (h *ShowcaseHandler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	text, markup, err := h.page(ctx, locale, 0)
+	if err != nil {
+		return err
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeHTML
+	reply.DisableWebPagePreview = true
+	if markup != nil {
+		reply.ReplyMarkup = *markup
+	}
+	_, err = h.service.botAPI.Send(reply)
+	return err
+}
+
+// HandleCallback turns the pages: reviews:page:<n>
+func (h *ShowcaseHandler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	raw, ok := strings.CutPrefix(query.Data, ShowcaseCallbackPrefix+":page:")
+	page, err := strconv.Atoi(raw)
+	if !ok || err != nil || page < 0 {
+		return fmt.Errorf("bad reviews callback %q", query.Data)
+	}
+	_, _ = h.service.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	if query.Message == nil {
+		return nil
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, query.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	text, markup, err := h.page(ctx, locale, page)
+	if err != nil {
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = markup
+	_, err = h.service.botAPI.Send(edit)
+	return err
+}
+
+// page renders the reviews of a page and the buttons to its neighbours;
+// markup is nil when there is a single page
+func (h *ShowcaseHandler) page(ctx context.Context, locale i18n.Locale, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	summary, err := h.service.storage.GetReviewSummary(ctx, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	reviews, err := h.service.storage.GetPublishedReviews(ctx, page*pageSize, pageSize+1)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(reviews) == 0 && page == 0 {
+		return i18n.T(locale, "reviews.none"), nil, nil
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "reviews.title"))
+	if summary.Count > 0 {
+		text.WriteString("\n" + i18n.T(locale, "reviews.average", summary.Average, summary.Count))
+	}
+	for _, review := range reviews[:min(len(reviews), pageSize)] {
+		fmt.Fprintf(&text, "\n\n%s <i>%s</i>\n%s",
+			strings.Repeat("⭐", review.Rating), review.CreatedAt.Format("02.01.2006"), html.EscapeString(review.Comment))
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "reviews.newer"),
+			fmt.Sprintf("%s:page:%d", ShowcaseCallbackPrefix, page-1)))
+	}
+	if len(reviews) > pageSize {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(locale, "reviews.older"),
+			fmt.Sprintf("%s:page:%d", ShowcaseCallbackPrefix, page+1)))
+	}
+	if len(row) == 0 {
+		return text.String(), nil, nil
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return text.String(), &markup, nil
+}
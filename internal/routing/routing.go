@@ -0,0 +1,293 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/templates"
+	"s1ntez/internal/uploads"
+	"s1ntez/pkg/objectstore"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Facts are the order attributes rules can match on
+type Facts struct {
+	Price    float64
+	AreaDM2  float64
+	Product  string
+	Rush     bool
+	Quantity int
+}
+
+// Decision says where the new-order notification goes
+type Decision struct {
+	ChatID     int64
+	AssigneeID *int64
+	RuleID     int64
+	RuleName   string
+}
+
+// voiceCaptionLength leaves room for the order line within the 1024
+// characters of a caption
+const voiceCaptionLength = 1000
+
+type Router struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	// files is nil when customer files stay on Telegram only
+	files       *objectstore.Client
+	linkTTL     time.Duration
+	logger      *zap.Logger
+	defaultChat int64
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, files *objectstore.Client, linkTTL time.Duration, logger *zap.Logger, defaultChat int64) *Router {
+	return &Router{
+		storage:     storage,
+		botAPI:      botAPI,
+		files:       files,
+		linkTTL:     linkTTL,
+		logger:      logger,
+		defaultChat: defaultChat,
+	}
+}
+
+// OnOrderCreated routes a freshly saved order: assigns it and notifies the
+// chat chosen by the rules
+func (r *Router) OnOrderCreated(ctx context.Context, order postgres.Order, facts Facts) error {
+	decision := r.Route(ctx, facts)
+
+	if decision.AssigneeID != nil {
+		if err := r.storage.AssignOrder(ctx, order.ID, *decision.AssigneeID); err != nil {
+			r.logger.Error("Failed to assign order",
+				zap.Int64("order_id", order.ID),
+				zap.Error(err))
+		}
+	}
+
+	if decision.ChatID == 0 {
+		return nil
+	}
+
+	delivery := order.DeliveryOrPickup()
+	card, err := templates.Render(templates.OrderCard{
+		OrderID:      order.ID,
+		Code:         order.Code,
+		Rush:         order.IsRush,
+		Product:      facts.Product,
+		Price:        order.Price,
+		GiftCard:     order.GiftCardAmount,
+		ToPay:        order.Payable(),
+		Contact:      order.Contact,
+		Quantity:     facts.Quantity,
+		WidthCM:      order.WidthCM,
+		HeightCM:     order.HeightCM,
+		Options:      order.Options,
+		Delivery:     i18n.T(i18n.RU, "delivery."+delivery.Method),
+		DeliveryCost: delivery.Cost,
+		Address:      delivery.Address,
+		Rule:         decision.RuleName,
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := card.To(decision.ChatID)
+	if _, err := r.botAPI.Send(msg); err != nil {
+		return fmt.Errorf("failed to notify chat %d: %w", decision.ChatID, err)
+	}
+
+	r.sendAttachments(ctx, decision.ChatID, order.ID)
+
+	r.logger.Info("Order routed",
+		zap.Int64("order_id", order.ID),
+		zap.Int64("chat_id", decision.ChatID),
+		zap.Int64("rule_id", decision.RuleID))
+	return nil
+}
+
+// sendAttachments forwards the customer's files (layouts, previews, photos
+// of the item, voice notes) so production doesn't have to ask for them.
+// Photos go as albums, the way the customer sent them.
+func (r *Router) sendAttachments(ctx context.Context, chatID, orderID int64) {
+	attachments, err := r.storage.GetOrderAttachments(ctx, orderID)
+	if err != nil {
+		r.logger.Warn("Failed to load order attachments",
+			zap.Int64("order_id", orderID),
+			zap.Error(err))
+		return
+	}
+
+	caption := fmt.Sprintf("Заказ #%d", orderID)
+	var photos []postgres.Attachment
+	for _, a := range attachments {
+		var msg tgbotapi.Chattable
+		switch a.TelegramKind() {
+		case postgres.TelegramPhoto:
+			photos = append(photos, a)
+			continue
+		case postgres.TelegramVoice:
+			voice := tgbotapi.NewVoice(chatID, tgbotapi.FileID(a.FileID))
+			voice.Caption = caption
+			if a.Transcript != nil {
+				voice.Caption += "\n🎙 " + truncate(*a.Transcript, voiceCaptionLength)
+			}
+			msg = voice
+		default:
+			doc := tgbotapi.NewDocument(chatID, tgbotapi.FileID(a.FileID))
+			doc.Caption = caption
+			// Telegram links expire with the bot token; the stored original doesn't
+			if a.TelegramKind() == "" && r.files != nil {
+				doc.Caption += "\nОригинал: " + r.files.PresignGet(a.ObjectKey, r.linkTTL)
+			}
+			msg = doc
+		}
+
+		if _, err := r.botAPI.Send(msg); err != nil {
+			r.logger.Warn("Failed to send order attachment",
+				zap.Int64("order_id", orderID),
+				zap.Int64("attachment_id", a.ID),
+				zap.Error(err))
+		}
+	}
+
+	for chunk := range slices.Chunk(photos, uploads.MaxAlbum) {
+		if err := r.sendPhotos(chatID, caption, chunk); err != nil {
+			r.logger.Warn("Failed to send order photos",
+				zap.Int64("order_id", orderID),
+				zap.Int("photos", len(chunk)),
+				zap.Error(err))
+		}
+	}
+}
+
+// sendPhotos sends up to uploads.MaxAlbum photos, captioned on the first;
+// an album takes at least two
+func (r *Router) sendPhotos(chatID int64, caption string, photos []postgres.Attachment) error {
+	if len(photos) == 1 {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(photos[0].FileID))
+		photo.Caption = caption
+		_, err := r.botAPI.Send(photo)
+		return err
+	}
+
+	media := make([]interface{}, 0, len(photos))
+	for i, a := range photos {
+		item := tgbotapi.NewInputMediaPhoto(tgbotapi.FileID(a.FileID))
+		if i == 0 {
+			item.Caption = caption
+		}
+		media = append(media, item)
+	}
+	_, err := r.botAPI.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media))
+	return err
+}
+
+// truncate cuts s to n characters, marking the cut
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// Route evaluates active rules by priority; the first match wins.
+// Without a match the order goes to the default admin chat.
+func (r *Router) Route(ctx context.Context, facts Facts) Decision {
+	fallback := Decision{ChatID: r.defaultChat}
+
+	rules, err := r.storage.GetRoutingRules(ctx)
+	if err != nil {
+		r.logger.Error("Failed to load routing rules, using default chat", zap.Error(err))
+		return fallback
+	}
+
+	for _, rule := range rules {
+		ok, err := Match(rule, facts)
+		if err != nil {
+			r.logger.Warn("Skipping invalid routing rule",
+				zap.Int64("rule_id", rule.ID),
+				zap.Error(err))
+			continue
+		}
+		if ok {
+			return Decision{
+				ChatID:     rule.TargetChatID,
+				AssigneeID: rule.AssigneeID,
+				RuleID:     rule.ID,
+				RuleName:   rule.Name,
+			}
+		}
+	}
+
+	return fallback
+}
+
+// Match reports whether a single rule matches the facts
+func Match(rule postgres.RoutingRule, facts Facts) (bool, error) {
+	switch rule.Field {
+	case "price":
+		return compareNumber(facts.Price, rule.Operator, rule.Value)
+	case "area_dm2":
+		return compareNumber(facts.AreaDM2, rule.Operator, rule.Value)
+	case "quantity":
+		return compareNumber(float64(facts.Quantity), rule.Operator, rule.Value)
+	case "product":
+		return compareString(facts.Product, rule.Operator, rule.Value)
+	case "rush":
+		want, err := strconv.ParseBool(rule.Value)
+		if err != nil {
+			return false, fmt.Errorf("rush expects true/false: %w", err)
+		}
+		return compareString(strconv.FormatBool(facts.Rush), rule.Operator, strconv.FormatBool(want))
+	default:
+		return false, fmt.Errorf("unknown field %q", rule.Field)
+	}
+}
+
+// ValidateRule checks a rule before it is stored
+func ValidateRule(rule postgres.RoutingRule) error {
+	_, err := Match(rule, Facts{})
+	return err
+}
+
+func compareNumber(actual float64, op, raw string) (bool, error) {
+	expected, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+	if err != nil {
+		return false, fmt.Errorf("value %q is not a number", raw)
+	}
+
+	switch op {
+	case "=":
+		return actual == expected, nil
+	case "!=":
+		return actual != expected, nil
+	case ">":
+		return actual > expected, nil
+	case ">=":
+		return actual >= expected, nil
+	case "<":
+		return actual < expected, nil
+	case "<=":
+		return actual <= expected, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+func compareString(actual, op, expected string) (bool, error) {
+	switch op {
+	case "=":
+		return strings.EqualFold(actual, expected), nil
+	case "!=":
+		return !strings.EqualFold(actual, expected), nil
+	}
+	return false, fmt.Errorf("operator %q is not supported for text fields", op)
+}
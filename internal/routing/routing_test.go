@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"s1ntez/internal/storage/postgres"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	facts := Facts{Price: 1500, AreaDM2: 12.5, Product: "sticker", Rush: true, Quantity: 100}
+
+	tests := []struct {
+		name     string
+		field    string
+		operator string
+		value    string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "price above", field: "price", operator: ">", value: "1000", want: true},
+		{name: "price not below", field: "price", operator: "<", value: "1000", want: false},
+		{name: "price with a comma", field: "price", operator: "<=", value: "1500,0", want: true},
+		{name: "price equal", field: "price", operator: "=", value: "1500", want: true},
+		{name: "price not equal", field: "price", operator: "!=", value: "1500", want: false},
+		{name: "area at least", field: "area_dm2", operator: ">=", value: "12.5", want: true},
+		{name: "quantity", field: "quantity", operator: ">", value: "99", want: true},
+		{name: "product ignores case", field: "product", operator: "=", value: "Sticker", want: true},
+		{name: "other product", field: "product", operator: "!=", value: "leather", want: true},
+		{name: "rush", field: "rush", operator: "=", value: "true", want: true},
+		{name: "not rush", field: "rush", operator: "=", value: "0", want: false},
+		{name: "rush not a bool", field: "rush", operator: "=", value: "yes", wantErr: true},
+		{name: "number not a number", field: "price", operator: ">", value: "lots", wantErr: true},
+		{name: "unknown operator", field: "price", operator: "~", value: "1", wantErr: true},
+		{name: "ordering text", field: "product", operator: ">", value: "a", wantErr: true},
+		{name: "unknown field", field: "color", operator: "=", value: "red", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := postgres.RoutingRule{Field: tt.field, Operator: tt.operator, Value: tt.value}
+			got, err := Match(rule, facts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Match(%s %s %s) = %v, want an error", tt.field, tt.operator, tt.value, got)
+				}
+				if ValidateRule(rule) == nil {
+					t.Errorf("ValidateRule(%s %s %s) accepted the rule", tt.field, tt.operator, tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match(%s %s %s) unexpected error: %v", tt.field, tt.operator, tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Match(%s %s %s) = %v, want %v", tt.field, tt.operator, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{name: "short", s: "hello", n: 10, want: "hello"},
+		{name: "exact", s: "hello", n: 5, want: "hello"},
+		{name: "cut", s: "hello world", n: 6, want: "hello…"},
+		{name: "cut by characters", s: "привет мир", n: 4, want: "при…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncate(tt.s, tt.n); got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"s1ntez/internal/backup"
+	"s1ntez/internal/config"
+	storage "s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/objectstore"
+	redisclient "s1ntez/pkg/redis"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Backup writes one backup to the object store and exits, for cron or a
+// manual run before maintenance
+func Backup() {
+	withBackup(func(ctx context.Context, service *backup.Service, logger *zap.Logger) {
+		key, err := service.Run(ctx)
+		if err != nil {
+			logger.Fatal("Backup failed", zap.Error(err))
+		}
+		fmt.Println(key)
+	})
+}
+
+// Restore loads the backup under key into a clean, migrated database
+func Restore(key string) {
+	withBackup(func(ctx context.Context, service *backup.Service, logger *zap.Logger) {
+		if err := service.Restore(ctx, key); err != nil {
+			logger.Fatal("Restore failed", zap.String("key", key), zap.Error(err))
+		}
+		logger.Info("Restore finished", zap.String("key", key))
+	})
+}
+
+// withBackup connects what the backup service needs, without the bot
+func withBackup(fn func(ctx context.Context, service *backup.Service, logger *zap.Logger)) {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	fileStore, err := newObjectStore(cfg)
+	if err != nil {
+		logger.Fatal("Failed to init object store", zap.Error(err))
+	}
+	if fileStore == nil {
+		logger.Fatal("OBJECT_STORE_ENDPOINT is required for backups")
+	}
+
+	redisClient := redisclient.New(redisclient.Config{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	pgStorage, err := storage.NewPostgresStorage(ctx, *cfg, redisClient, logger)
+	if err != nil {
+		logger.Fatal("Failed to init PostgreSQL storage", zap.Error(err))
+	}
+	defer pgStorage.Close()
+
+	fn(ctx, backup.New(pgStorage, fileStore, logger, cfg), logger)
+}
+
+// newObjectStore returns nil when no object store is configured
+func newObjectStore(cfg *config.Config) (*objectstore.Client, error) {
+	if cfg.ObjectStore.Endpoint == "" {
+		return nil, nil
+	}
+	return objectstore.New(objectstore.Config{
+		Endpoint:  cfg.ObjectStore.Endpoint,
+		Region:    cfg.ObjectStore.Region,
+		Bucket:    cfg.ObjectStore.Bucket,
+		AccessKey: cfg.ObjectStore.AccessKey,
+		SecretKey: cfg.ObjectStore.SecretKey,
+	})
+}
@@ -5,8 +5,57 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"s1ntez/internal/abuse"
+	"s1ntez/internal/analytics"
+	apigrpc "s1ntez/internal/api/grpc"
+	apihttp "s1ntez/internal/api/http"
+	"s1ntez/internal/approval"
+	"s1ntez/internal/archive"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/backup"
+	"s1ntez/internal/bot"
+	"s1ntez/internal/bot/base/controller/handlers/admin"
+	"s1ntez/internal/bot/base/controller/handlers/commands"
+	"s1ntez/internal/bot/custom/stickers/controller/handlers/vinyl"
+	stickers "s1ntez/internal/bot/custom/stickers/usecase"
+	"s1ntez/internal/bot/custom/typography/controller/handlers/printing"
+	typography "s1ntez/internal/bot/custom/typography/usecase"
+	"s1ntez/internal/broadcast"
+	"s1ntez/internal/broker"
 	"s1ntez/internal/config"
+	"s1ntez/internal/customers"
+	"s1ntez/internal/deadlines"
+	"s1ntez/internal/events"
+	"s1ntez/internal/experiments"
+	"s1ntez/internal/fraud"
+	"s1ntez/internal/fxrates"
+	"s1ntez/internal/giftcards"
+	"s1ntez/internal/imageproxy"
+	"s1ntez/internal/jobs"
+	"s1ntez/internal/loyalty"
+	"s1ntez/internal/notify"
+	"s1ntez/internal/orders"
+	"s1ntez/internal/outbox"
+	"s1ntez/internal/payments"
+	"s1ntez/internal/pickup"
+	"s1ntez/internal/pricing"
+	"s1ntez/internal/promo"
+	"s1ntez/internal/referral"
+	"s1ntez/internal/reports"
+	"s1ntez/internal/reviews"
+	"s1ntez/internal/routing"
+	"s1ntez/internal/sizing"
+	"s1ntez/internal/stats"
+	"s1ntez/internal/stock"
 	"s1ntez/internal/storage/redis"
+	"s1ntez/internal/support"
+	"s1ntez/internal/tracing"
+	"s1ntez/internal/transcribe"
+	"s1ntez/internal/verification"
+	redisclient "s1ntez/pkg/redis"
+	"s1ntez/pkg/sheets"
+	"s1ntez/pkg/tg"
 	"syscall"
 )
 
@@ -33,21 +82,50 @@ func Run() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// SIGHUP and /admin reload apply changed settings without a restart
+	configWatcher := config.NewWatcher(cfg, logger)
+	go configWatcher.Watch(ctx)
+
+	shutdownTracing, err := tracing.Init(ctx, *cfg)
+	if err != nil {
+		logger.Fatal("Failed to init tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	// Initialize Redis client (используем pkg/redis)
-	redisStorage := redis.New(
-		cfg.Redis.Addr,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-	)
-	defer redisStorage.Close()
+	redisClient := redisclient.New(redisclient.Config{
+		Addr:             cfg.Redis.Addr,
+		Password:         cfg.Redis.Password,
+		DB:               cfg.Redis.DB,
+		Retries:          cfg.Redis.Retries,
+		RetryBackoff:     cfg.Redis.RetryBackoff,
+		BreakerThreshold: cfg.Redis.BreakerThreshold,
+		BreakerCooldown:  cfg.Redis.BreakerCooldown,
+		OnStateChange: func(degraded bool) {
+			if degraded {
+				logger.Error("Redis is unavailable, working without cache and dialog state")
+			} else {
+				logger.Info("Redis is back")
+			}
+		},
+	})
+	defer redisClient.Close()
+	redisStorage := redis.New(redisClient)
 
 	// Initialize PostgreSQL storage
-	pgStorage, err := storage.NewPostgresStorage(ctx, *cfg, redisStorage, logger)
+	pgStorage, err := storage.NewPostgresStorage(ctx, *cfg, redisClient, logger)
 	if err != nil {
 		logger.Fatal("Failed to init PostgreSQL storage", zap.Error(err))
 	}
 	defer pgStorage.Close()
 
+	go pgStorage.WatchTextureCache(ctx, cfg.Redis.TextureCheckInterval)
+	go pgStorage.WatchReplica(ctx, cfg.Database.ReplicaCheckInterval)
+
 	botAPI, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
 	if err != nil {
 		logger.Fatal("failed to create bot API", zap.Error(err))
@@ -62,10 +140,237 @@ func Run() {
 
 	userDialogStateManager := state_manager.New(redisStorage)
 
+	// customer files stay on Telegram unless an object store is configured
+	fileStore, err := newObjectStore(cfg)
+	if err != nil {
+		logger.Fatal("Failed to init object store", zap.Error(err))
+	}
+
+	// domain events
+	eventBus := events.NewBus(logger)
+	defer eventBus.Wait()
+
+	// customer messages that must get through: retried, split, and
+	// blocked chats flagged on the user
+	tgSender := tg.New(botAPI, logger, tg.Options{OnBlocked: pgStorage.MarkBotBlocked})
+	notifier := notify.New(tgSender, pgStorage, logger)
+	notifier.Register(eventBus)
+
+	statsService := stats.New(pgStorage, redisStorage, logger, cfg)
+	statsService.Register(eventBus)
+	go statsService.Watch(ctx)
+
+	currentOrdersReport := reports.NewCurrentOrders(pgStorage, logger, cfg.Reports.Debounce)
+	currentOrdersReport.Register(eventBus)
+	go currentOrdersReport.Watch(ctx)
+
 	startCmdHandler := start.New(logger, botAPI, userDialogStateManager, pgStorage)
+	languageHandler := commands.NewLanguageHandler(logger, botAPI, pgStorage)
+
+	priceCalculator := pricing.New(cfg)
+	exchangeRates := fxrates.New(redisClient, logger, cfg)
+
+	// order placement shared by the bot and the integrations
+	fraudGuard := fraud.New(pgStorage, botAPI, logger, cfg)
+	orderRouter := routing.New(pgStorage, botAPI, fileStore, cfg.ObjectStore.LinkTTL, logger, cfg.Admin.ChatID)
+	promoService := promo.New(pgStorage)
+	loyaltyService := loyalty.New(pgStorage, botAPI, logger, cfg)
+	loyaltyService.Register(eventBus)
+	reviewService := reviews.New(pgStorage, botAPI, logger, cfg)
+	reviewService.Register(eventBus)
+	giftCardService := giftcards.New(pgStorage, botAPI, logger, cfg)
+	giftCardService.Register(eventBus)
+	// deposits of orders placed with PREPAYMENT_PERCENT
+	paymentService := payments.New(pgStorage, botAPI, eventBus, logger, cfg)
+	paymentService.Register(eventBus)
+	go paymentService.Watch(ctx)
+	paymentHandler := payments.NewHandler(paymentService, logger)
+	orderService := orders.New(pgStorage, priceCalculator, promoService, loyaltyService, giftCardService, exchangeRates, fraudGuard, eventBus, logger, cfg)
+	// quotes a manager prices before the customer gets them
+	approvalService := approval.New(pgStorage, orderService, botAPI, logger, cfg)
+	approvalService.Register(eventBus)
+	approvalHandler := approval.NewHandler(approvalService, logger)
+
+	// product flows
+	phoneVerifier := verification.New(pgStorage, redisStorage, logger, cfg)
+	experimentService := experiments.New(pgStorage, logger, cfg)
+	experimentService.Register(eventBus)
+	// suggests sticker sizes from photos when SIZE_ESTIMATION_PROVIDER is set
+	sizeEstimator := sizing.New(botAPI, logger, cfg)
+	stickerHandler := vinyl.New(logger, botAPI, redisStorage, pgStorage, stickers.New(orderService, pgStorage, fileStore), phoneVerifier, sizeEstimator, experimentService, cfg)
+	printHandler := printing.New(logger, botAPI, redisStorage, pgStorage, typography.New(orderService, pgStorage, fileStore), phoneVerifier, experimentService, cfg)
+
+	calcHandler := commands.NewCalcHandler(logger, botAPI, pgStorage, priceCalculator, exchangeRates, cfg)
+	// texture previews are shrunk and cached so the catalog loads fast
+	texturePreviews := imageproxy.New(redisClient, fileStore, logger, cfg)
+	textureInfoHandler := commands.NewTextureInfoHandler(logger, botAPI, pgStorage, texturePreviews, cfg)
+	catalogHandler := commands.NewCatalogHandler(logger, botAPI, pgStorage, texturePreviews, cfg)
+	compareHandler := commands.NewCompareHandler(logger, botAPI, pgStorage, cfg)
+	savedQuotesHandler := commands.NewSavedQuotesHandler(logger, botAPI, pgStorage)
+	// admin changes are recorded in the audit log
+	auditLog := audit.New(pgStorage, logger)
+	// staff commands are gated by role; handlers check it again
+	authService := auth.New(pgStorage, botAPI, cfg)
+
+	textureContentHandler := auditLog.Command(admin.NewTextureContentHandler(logger, botAPI, pgStorage, cfg))
+	pricingRulesHandler := admin.NewPricingRulesHandler(logger, botAPI, pgStorage, cfg)
+	exportHandler := auditLog.Command(admin.NewExportHandler(logger, botAPI, pgStorage, cfg))
+	retryJobHandler := auditLog.Command(admin.NewRetryJobHandler(logger, botAPI, pgStorage, cfg))
+	importHandler := auditLog.Command(admin.NewImportHandler(logger, botAPI, pgStorage, cfg))
+	routingRulesHandler := admin.NewRoutingRulesHandler(logger, botAPI, pgStorage, cfg)
+	transcriptHandler := admin.NewTranscriptHandler(logger, botAPI, pgStorage, cfg)
+	notificationsHandler := commands.NewNotificationsHandler(logger, botAPI, pgStorage)
+	myOrdersHandler := commands.NewMyOrdersHandler(logger, botAPI, pgStorage)
+	myDataHandler := commands.NewMyDataHandler(logger, botAPI, pgStorage, redisStorage)
+	profileHandler := commands.NewProfileHandler(logger, botAPI, redisStorage, pgStorage, phoneVerifier)
+	orderStatusHandler := auditLog.Command(admin.NewOrderStatusHandler(logger, botAPI, pgStorage, orderService, cfg))
+	holdReviewHandler := auditLog.Callback(admin.NewHoldReviewHandler(logger, botAPI, pgStorage, eventBus, cfg))
+	statsHandler := admin.NewStatsHandler(logger, botAPI, pgStorage, statsService, cfg)
+	textureBatchHandler := admin.NewTextureBatchHandler(logger, botAPI, pgStorage, cfg)
+	periodCloseHandler := admin.NewPeriodCloseHandler(logger, botAPI, pgStorage, cfg)
+	promoCodeHandler := admin.NewPromoCodeHandler(logger, botAPI, pgStorage, promoService, cfg)
+	auditHandler := admin.NewAuditHandler(logger, botAPI, pgStorage, cfg)
+	customersHandler := admin.NewCustomersHandler(logger, botAPI, pgStorage, cfg)
+	reloadHandler := auditLog.Command(admin.NewReloadHandler(logger, botAPI, configWatcher, cfg))
+	orderNoteHandler := auditLog.Command(admin.NewOrderNoteHandler(logger, botAPI, pgStorage, cfg))
+	productionQueueHandler := admin.NewProductionQueueHandler(logger, botAPI, pgStorage, orderService, cfg)
+	reviewModerationHandler := admin.NewReviewModerationHandler(logger, botAPI, pgStorage, cfg)
+	adminCommands := admin.NewAdminCommands(botAPI, cfg, map[string]bot.CommandHandler{
+		"audit":       auditHandler,
+		"reload":      reloadHandler,
+		"note":        orderNoteHandler,
+		"grant":       auditLog.Command(admin.NewRoleGrantHandler(logger, botAPI, pgStorage, authService, cfg)),
+		"due":         auditLog.Command(admin.NewDueDateHandler(logger, botAPI, pgStorage, cfg)),
+		"funnel":      admin.NewFunnelHandler(logger, botAPI, pgStorage, cfg),
+		"experiments": admin.NewExperimentsHandler(logger, botAPI, pgStorage, cfg),
+		"report":      auditLog.Command(admin.NewReportTemplateHandler(logger, botAPI, pgStorage, cfg)),
+		"accounting":  auditLog.Command(admin.NewAccountingExportHandler(logger, botAPI, pgStorage, cfg)),
+		"stats":       statsHandler,
+		"reviews":     reviewModerationHandler,
+		"events":      admin.NewOrderEventsHandler(logger, botAPI, pgStorage, cfg),
+	})
+	broadcastHandler := admin.NewBroadcastHandler(logger, botAPI, pgStorage, cfg)
+	blocklistHandler := admin.NewBlocklistHandler(logger, botAPI, pgStorage, cfg)
+	// blocklist, flood bans and the newcomer captcha
+	abuseGuard := abuse.New(pgStorage, redisStorage, authService, botAPI, logger, cfg)
+
+	broadcastService := broadcast.New(pgStorage, botAPI, logger, cfg)
+	go broadcastService.Watch(ctx)
+
+	deadlineWatcher := deadlines.New(pgStorage, botAPI, logger, cfg)
+	go deadlineWatcher.Watch(ctx)
+
+	stockWatcher := stock.New(pgStorage, botAPI, logger, cfg)
+	go stockWatcher.Watch(ctx)
+
+	archiver := archive.New(pgStorage, logger, cfg)
+	go archiver.Watch(ctx)
+
+	customerService := customers.New(pgStorage, logger, cfg)
+	customerService.Register(eventBus)
+	go customerService.Watch(ctx)
+
+	backupService := backup.New(pgStorage, fileStore, logger, cfg)
+	go backupService.Watch(ctx)
+
+	voiceNotes := transcribe.New(pgStorage, botAPI, logger, cfg)
+	supportService := support.New(pgStorage, botAPI, voiceNotes, logger, cfg)
+	supportHandler := support.NewHandler(supportService, redisStorage, logger)
+	reviewHandler := reviews.NewHandler(reviewService, redisStorage, logger)
+	reviewShowcaseHandler := reviews.NewShowcaseHandler(reviewService, logger)
+
+	referralService := referral.New(pgStorage, botAPI, logger, cfg)
+	referralService.Register(eventBus)
+	referralHandler := referral.NewHandler(referralService, startCmdHandler, logger)
+
+	loyaltyHandler := loyalty.NewHandler(loyaltyService, logger)
+	giftCardHandler := giftcards.NewHandler(giftCardService, logger)
+
+	pickupService := pickup.New(pgStorage, botAPI, logger, cfg)
+	pickupService.Register(eventBus)
+	pickupHandler := pickup.NewHandler(pickupService, authService, referralHandler, logger)
 
 	commandHandlersMap := map[string]bot.CommandHandler{
-		"start": startCmdHandler,
+		"start":         pickupHandler,
+		"language":      languageHandler,
+		"calc":          calcHandler,
+		"catalog":       catalogHandler,
+		"compare":       compareHandler,
+		"notifications": notificationsHandler,
+		"myorders":      myOrdersHandler,
+		"saved":         savedQuotesHandler,
+		"mydata":        myDataHandler,
+		"profile":       profileHandler,
+		"stickers":      stickerHandler,
+		"print":         printHandler,
+		"support":       supportHandler,
+		"closeticket":   supportHandler,
+		"referral":      referralHandler,
+		"bonus":         loyaltyHandler,
+		"reviews":       reviewShowcaseHandler,
+		"giftcard":      giftCardHandler,
+
+		"texturedesc":  authService.Command(auth.Admin, textureContentHandler),
+		"texturephoto": authService.Command(auth.Admin, textureContentHandler),
+		"textureclear": authService.Command(auth.Admin, textureContentHandler),
+		"textureprice": authService.Command(auth.Admin, textureContentHandler),
+		"texturestock": authService.Command(auth.Admin, textureContentHandler),
+		"texturearea":  authService.Command(auth.Admin, textureContentHandler),
+		"textureattrs": authService.Command(auth.Admin, textureContentHandler),
+		"addbatch":     authService.Command(auth.Admin, textureBatchHandler),
+		"batches":      authService.Command(auth.Admin, textureBatchHandler),
+		"setrates":     authService.Command(auth.Admin, pricingRulesHandler),
+		"rates":        authService.Command(auth.Admin, pricingRulesHandler),
+		"export":       authService.Command(auth.Production, exportHandler),
+		"retryjob":     authService.Command(auth.Admin, retryJobHandler),
+		"import":       authService.Command(auth.Admin, importHandler),
+		"routes":       authService.Command(auth.Admin, routingRulesHandler),
+		"addroute":     authService.Command(auth.Admin, routingRulesHandler),
+		"delroute":     authService.Command(auth.Admin, routingRulesHandler),
+		"transcript":   authService.Command(auth.Manager, transcriptHandler),
+		"setstatus":    authService.Command(auth.Manager, orderStatusHandler),
+		"quote":        authService.Command(auth.Manager, auditLog.Command(approvalHandler)),
+		"stats":        authService.Command(auth.Admin, statsHandler),
+		"closemonth":   authService.Command(auth.Admin, periodCloseHandler),
+		"unlockmonth":  authService.Command(auth.Admin, periodCloseHandler),
+		"addpromo":     authService.Command(auth.Admin, promoCodeHandler),
+		"promos":       authService.Command(auth.Admin, promoCodeHandler),
+		"customers":    authService.Command(auth.Admin, customersHandler),
+		"issuegift":    authService.Command(auth.Admin, auditLog.Command(giftCardHandler)),
+		"voidgift":     authService.Command(auth.Admin, auditLog.Command(giftCardHandler)),
+		"giftcards":    authService.Command(auth.Admin, giftCardHandler),
+		"payments":     authService.Command(auth.Admin, paymentHandler),
+		"refund":       authService.Command(auth.Admin, auditLog.Command(paymentHandler)),
+		"chargeback":   authService.Command(auth.Admin, auditLog.Command(paymentHandler)),
+		"admin":        authService.Command(auth.Manager, adminCommands),
+		"broadcast":    authService.Command(auth.Admin, auditLog.Command(broadcastHandler)),
+		"broadcasts":   authService.Command(auth.Admin, broadcastHandler),
+		"block":        authService.Command(auth.Admin, auditLog.Command(blocklistHandler)),
+		"unblock":      authService.Command(auth.Admin, auditLog.Command(blocklistHandler)),
+		"blocked":      authService.Command(auth.Admin, blocklistHandler),
+		"queue":        authService.Command(auth.Production, productionQueueHandler),
+		"scan":         authService.Command(auth.Production, pickupHandler),
+	}
+
+	callbackHandlersMap := map[string]bot.CallbackHandler{
+		"lang":     languageHandler,
+		"texinfo":  textureInfoHandler,
+		"compare":  compareHandler,
+		"hold":     authService.Callback(auth.Manager, holdReviewHandler),
+		"quote":    authService.Callback(auth.Manager, auditLog.Callback(approvalHandler)),
+		"review":   reviewHandler,
+		"reviews":  reviewShowcaseHandler,
+		"revmod":   authService.Callback(auth.Manager, auditLog.Callback(reviewModerationHandler)),
+		"myorders": myOrdersHandler,
+		"saved":    savedQuotesHandler,
+		"giftcard": giftCardHandler,
+		"profile":  profileHandler,
+		"sticker":  stickerHandler,
+		"print":    printHandler,
+		"bcast":    authService.Callback(auth.Admin, auditLog.Callback(broadcastHandler)),
+		"queue":    authService.Callback(auth.Production, auditLog.Callback(productionQueueHandler)),
+		"pickup":   authService.Callback(auth.Production, auditLog.Callback(pickupHandler)),
+		"stock":    authService.Callback(auth.Admin, auditLog.Callback(stock.NewHandler(pgStorage, botAPI, logger))),
 	}
 
 	// Infrastructure
@@ -73,6 +378,91 @@ func Run() {
 
 	// TTL proxy
 
+	if cfg.API.Enabled {
+		apiServer := apihttp.New(pgStorage, orderService, statsService, logger, cfg)
+		go func() {
+			if err := apiServer.Start(ctx); err != nil {
+				logger.Error("API server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	if cfg.GRPC.Enabled {
+		grpcServer := apigrpc.New(pgStorage, orderService, logger, cfg)
+		go func() {
+			if err := grpcServer.Start(ctx); err != nil {
+				logger.Error("gRPC server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// external side effects written with the orders
+	outboxDispatcher := outbox.NewDispatcher(pgStorage, logger, cfg)
+	orderDestinations := []string{outbox.KindRouteOrder}
+	// status changes are queued with the change itself, see UpdateOrderStatus
+	var statusDestinations []string
+	outboxDispatcher.Register(outbox.KindRouteOrder, outbox.RouteOrder(pgStorage, orderRouter))
+	if len(cfg.Outbox.CRMWebhookURLs) > 0 {
+		crmWebhook := outbox.CRMWebhook(pgStorage, cfg)
+		for _, event := range cfg.Outbox.CRMWebhookEvents {
+			switch event {
+			case storage.OutboxOrderCreated:
+				orderDestinations = append(orderDestinations, outbox.KindCRMSync)
+				outboxDispatcher.Register(outbox.KindCRMSync, crmWebhook)
+			case string(events.OrderStatusChanged):
+				statusDestinations = append(statusDestinations, outbox.KindCRMStatus)
+				outboxDispatcher.Register(outbox.KindCRMStatus, crmWebhook)
+			}
+		}
+	}
+	if cfg.Outbox.SMTPAddr != "" {
+		orderDestinations = append(orderDestinations, outbox.KindOrderEmail)
+		outboxDispatcher.Register(outbox.KindOrderEmail, outbox.OrderEmail(pgStorage, cfg))
+	}
+	if cfg.Outbox.SheetsSpreadsheetID != "" {
+		sheetsClient, err := sheets.New(sheets.Config{
+			Credentials:   []byte(cfg.Outbox.SheetsCredentials),
+			SpreadsheetID: cfg.Outbox.SheetsSpreadsheetID,
+		})
+		if err != nil {
+			logger.Fatal("Failed to init Google Sheets client", zap.Error(err))
+		}
+		orderDestinations = append(orderDestinations, outbox.KindSheetsSync)
+		statusDestinations = append(statusDestinations, outbox.KindSheetsSync)
+		outboxDispatcher.Register(outbox.KindSheetsSync, outbox.SheetsSync(pgStorage, sheetsClient, cfg.Outbox.SheetsSheet))
+	}
+	if cfg.Broker.Kind != "" {
+		publisher, err := broker.New(cfg)
+		if err != nil {
+			logger.Fatal("Failed to connect to the message broker", zap.Error(err))
+		}
+		defer publisher.Close()
+
+		brokerSender := outbox.Broker(pgStorage, publisher, cfg)
+		orderDestinations = append(orderDestinations, outbox.KindBrokerCreated)
+		statusDestinations = append(statusDestinations, outbox.KindBrokerStatus)
+		outboxDispatcher.Register(outbox.KindBrokerCreated, brokerSender)
+		outboxDispatcher.Register(outbox.KindBrokerStatus, brokerSender)
+	}
+	outboxDispatcher.FanOut(storage.OutboxOrderCreated, orderDestinations...)
+	outboxDispatcher.FanOut(storage.OutboxStatusChanged, statusDestinations...)
+	go outboxDispatcher.Start(ctx)
+
+	// background jobs
+	jobRunner := jobs.NewRunner(pgStorage, botAPI, fileStore, logger, cfg)
+	jobRunner.Register(jobs.KindExportOrders, jobRunner.ExportOrders)
+	jobRunner.Register(jobs.KindCloseMonth, jobRunner.CloseMonth)
+	jobRunner.Register(jobs.KindAccountingExport, jobRunner.AccountingExport)
+	jobRunner.Register(loyalty.KindExpire, loyaltyService.Expire)
+	jobRunner.Register(reviews.KindSurvey, reviewService.Survey)
+	go loyaltyService.Watch(ctx)
+	if cfg.Analytics.Target != "" {
+		analyticsService := analytics.New(pgStorage, logger, cfg)
+		jobRunner.Register(analytics.KindExport, analyticsService.Export)
+		go analyticsService.Watch(ctx)
+	}
+	go jobRunner.Start(ctx)
+
 	// use cases
 
 	// controller
@@ -84,12 +474,33 @@ func Run() {
 		logger,
 		cfg,
 		commandHandlersMap,
-		nil,
+		callbackHandlersMap,
 	)
 	if err != nil {
 		logger.Fatal("Failed to create bot", zap.Error(err))
 	}
 
+	tgBot.SetGuard(abuseGuard)
+
+	// payments come as messages too and must not be taken for dialog answers
+	tgBot.SetPreCheckoutHandler(paymentHandler)
+	tgBot.AddMessageHandler(paymentHandler)
+
+	// order dialogs first: while one is active, messages are its answers;
+	// voice messages are notes to the order instead
+	tgBot.AddMessageHandler(transcribe.NewHandler(voiceNotes, redisStorage, logger))
+	tgBot.AddMessageHandler(stickerHandler)
+	tgBot.AddMessageHandler(printHandler)
+	tgBot.AddMessageHandler(profileHandler)
+	// a comment to a rating just given comes before an open ticket
+	tgBot.AddMessageHandler(reviewHandler)
+	// customer messages go to an open ticket, staff answers come back
+	tgBot.AddMessageHandler(supportHandler)
+	tgBot.SetInlineHandler(commands.NewInlineQuoteHandler(logger, botAPI, pgStorage, priceCalculator, exchangeRates, cfg))
+	if supportService.Enabled() {
+		go supportService.WatchSLA(ctx)
+	}
+
 	// Start the bot
 	logger.Info("Starting bot")
 	if err := tgBot.Start(ctx); err != nil {
@@ -0,0 +1,105 @@
+package sizing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// Reference is the card customers put next to the object: ISO/IEC 7810
+// ID-1, the size of every bank card
+const Reference = "id1_card"
+
+// Estimator measures the object in a photo against the reference card
+type Estimator interface {
+	Estimate(ctx context.Context, photo []byte, contentType string) (Estimate, error)
+}
+
+// httpEstimator posts the photo to a measuring service:
+//
+//	POST <url>  multipart: photo, reference=id1_card
+//	200 {"width_cm": 42.5, "height_cm": 30.1, "confidence": 0.87}
+//	4xx/5xx {"error": "no reference card found"}
+type httpEstimator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPEstimator(url, apiKey string, timeout time.Duration) *httpEstimator {
+	return &httpEstimator{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (e *httpEstimator) Estimate(ctx context.Context, photo []byte, contentType string) (Estimate, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("photo", "photo"+extension(contentType))
+	if err != nil {
+		return Estimate{}, err
+	}
+	if _, err := part.Write(photo); err != nil {
+		return Estimate{}, err
+	}
+	if err := form.WriteField("reference", Reference); err != nil {
+		return Estimate{}, err
+	}
+	if err := form.Close(); err != nil {
+		return Estimate{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, &body)
+	if err != nil {
+		return Estimate{}, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return Estimate{}, fmt.Errorf("size estimation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		WidthCM    float64 `json:"width_cm"`
+		HeightCM   float64 `json:"height_cm"`
+		Confidence float64 `json:"confidence"`
+		Error      string  `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return Estimate{}, fmt.Errorf("bad size estimation response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return Estimate{}, fmt.Errorf("size estimation: %s (%s)", result.Error, resp.Status)
+		}
+		return Estimate{}, fmt.Errorf("size estimation: %s", resp.Status)
+	}
+	return Estimate{
+		WidthCM:    result.WidthCM,
+		HeightCM:   result.HeightCM,
+		Confidence: result.Confidence,
+	}, nil
+}
+
+// extension tells the service the image format by the file name
+func extension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	}
+	return ".jpg"
+}
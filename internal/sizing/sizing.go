@@ -0,0 +1,105 @@
+// Package sizing suggests the size of a sticker from a photo: the customer
+// photographs the object next to a bank card, whose size is standard, and
+// a measuring service works out the object's width and height from it.
+// The customer confirms the suggestion or types the size as usual. Without
+// a service the size is asked in numbers only.
+package sizing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"s1ntez/internal/config"
+	"s1ntez/internal/uploads"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// ErrNotMeasured means the service found no card or no object in the
+// photo, or wasn't sure enough of what it found
+var ErrNotMeasured = errors.New("photo could not be measured")
+
+// PhotoTypes are the image formats the service takes
+var PhotoTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// Estimate is the size the service read off a photo
+type Estimate struct {
+	WidthCM  float64
+	HeightCM float64
+	// Confidence is from 0 to 1
+	Confidence float64
+}
+
+// Service downloads the photos and has them measured
+type Service struct {
+	botAPI *tgbotapi.BotAPI
+	// estimator is nil when the service is off
+	estimator Estimator
+	logger    *zap.Logger
+	cfg       *config.Config
+}
+
+func New(botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	s := &Service{
+		botAPI: botAPI,
+		logger: logger.Named("sizing"),
+		cfg:    cfg,
+	}
+
+	c := cfg.SizeEstimation
+	switch c.Provider {
+	case "http":
+		s.estimator = newHTTPEstimator(c.URL, c.APIKey, c.Timeout)
+	}
+	return s
+}
+
+// Enabled reports whether photos are measured
+func (s *Service) Enabled() bool {
+	return s.estimator != nil
+}
+
+// Measure estimates the size of the object in the photo of msg in whole
+// centimetres, at least 1. It returns uploads.ErrNoFile for a message
+// without a photo, ErrNotMeasured when the size can't be read off it.
+func (s *Service) Measure(ctx context.Context, msg *tgbotapi.Message) (width, height int, err error) {
+	if !s.Enabled() {
+		return 0, 0, ErrNotMeasured
+	}
+
+	photo, err := uploads.Download(ctx, s.botAPI, msg, s.cfg.SizeEstimation.MaxPhotoBytes)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !slices.Contains(PhotoTypes, photo.ContentType) {
+		return 0, 0, fmt.Errorf("%w: %s", uploads.ErrFileFormat, photo.ContentType)
+	}
+
+	estimate, err := s.estimator.Estimate(ctx, photo.Data, photo.ContentType)
+	if err != nil {
+		s.logger.Warn("Failed to measure photo",
+			zap.String("file_id", photo.FileID),
+			zap.Error(err))
+		return 0, 0, ErrNotMeasured
+	}
+	if estimate.Confidence < s.cfg.SizeEstimation.MinConfidence || estimate.WidthCM <= 0 || estimate.HeightCM <= 0 {
+		s.logger.Info("Photo measured with low confidence",
+			zap.String("file_id", photo.FileID),
+			zap.Float64("confidence", estimate.Confidence))
+		return 0, 0, ErrNotMeasured
+	}
+
+	s.logger.Info("Photo measured",
+		zap.Float64("width_cm", estimate.WidthCM),
+		zap.Float64("height_cm", estimate.HeightCM),
+		zap.Float64("confidence", estimate.Confidence))
+	return wholeCM(estimate.WidthCM), wholeCM(estimate.HeightCM), nil
+}
+
+// wholeCM rounds to the centimetre sizes are ordered in
+func wholeCM(cm float64) int {
+	return max(int(math.Round(cm)), 1)
+}
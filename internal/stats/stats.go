@@ -0,0 +1,189 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/config"
+	"s1ntez/internal/events"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Windows of the statistics in days before today, as GetOrderStatistics
+// counts them: "7 days" is today plus the seven days before
+const (
+	weekDays  = 7
+	monthDays = 30
+)
+
+// Service keeps order statistics as Redis counters updated on order events,
+// so reading them needs no scan of the orders table. Orders that change
+// without an event (imports, direct SQL) and events lost on restart are
+// corrected by the periodic reconciliation against Postgres.
+type Service struct {
+	storage  *postgres.PostgresStorage
+	counters *redis.Storage
+	logger   *zap.Logger
+	cfg      *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, counters *redis.Storage, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage:  storage,
+		counters: counters,
+		logger:   logger.Named("stats"),
+		cfg:      cfg,
+	}
+}
+
+// Register subscribes the counters to order events
+func (s *Service) Register(bus *events.Bus) {
+	bus.Subscribe(events.OrderCreated, "stats.order_created", s.OnOrderCreated)
+	bus.Subscribe(events.OrderStatusChanged, "stats.status_changed", s.OnStatusChanged)
+}
+
+func (s *Service) OnOrderCreated(ctx context.Context, event events.Event) error {
+	order, err := s.storage.GetOrderByID(ctx, event.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load order for stats: %w", err)
+	}
+
+	return s.counters.CountOrder(ctx, redis.OrderTally{
+		CreatedAt:     order.CreatedAt,
+		Status:        order.Status.String(),
+		Price:         order.Price.Float(),
+		Rush:          order.IsRush,
+		RushSurcharge: order.RushSurcharge.Float(),
+	})
+}
+
+func (s *Service) OnStatusChanged(ctx context.Context, event events.Event) error {
+	if event.PrevStatus == event.Status {
+		return nil
+	}
+	return s.counters.MoveOrderStatus(ctx, event.PrevStatus.String(), event.Status.String())
+}
+
+// Get returns the live statistics. Until the counters are filled it falls
+// back to computing them in Postgres.
+func (s *Service) Get(ctx context.Context) (*postgres.OrderStatistics, error) {
+	today := startOfDay(time.Now())
+
+	days := make([]time.Time, monthDays+1)
+	for i := range days {
+		days[i] = today.AddDate(0, 0, -i)
+	}
+
+	live, ok, err := s.counters.GetOrderStats(ctx, days)
+	if err != nil {
+		s.logger.Warn("Failed to read stats counters, computing from Postgres", zap.Error(err))
+	}
+	if err != nil || !ok {
+		return s.storage.GetOrderStatistics(ctx)
+	}
+
+	stats := &postgres.OrderStatistics{
+		TotalOrders:   int(live.Orders),
+		TotalRevenue:  live.Revenue,
+		StatusCounts:  make(map[string]int, len(live.Statuses)),
+		RushOrders:    int(live.RushOrders),
+		RushRevenue:   live.RushRevenue,
+		RushSurcharge: live.RushSurcharge,
+	}
+	for status, count := range live.Statuses {
+		if count != 0 {
+			stats.StatusCounts[status] = int(count)
+		}
+	}
+
+	for i, day := range days {
+		totals := live.Days[redis.DayKey(day)]
+		if i == 0 {
+			stats.TodayOrders += int(totals.Orders)
+			stats.TodayRevenue += totals.Revenue
+		}
+		if i <= weekDays {
+			stats.WeekOrders += int(totals.Orders)
+			stats.WeekRevenue += totals.Revenue
+		}
+		stats.MonthOrders += int(totals.Orders)
+		stats.MonthRevenue += totals.Revenue
+	}
+
+	return stats, nil
+}
+
+// Reconcile recomputes the counters from Postgres and replaces them. An
+// event handled while it runs may be counted twice or not at all; the next
+// run corrects that.
+func (s *Service) Reconcile(ctx context.Context) error {
+	totals, err := s.storage.GetOrderStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(time.Now())
+	since := today.AddDate(0, 0, -monthDays)
+	daily, err := s.storage.GetDailyOrderTotals(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	fresh := redis.OrderStats{
+		Orders:        int64(totals.TotalOrders),
+		Revenue:       totals.TotalRevenue,
+		RushOrders:    int64(totals.RushOrders),
+		RushRevenue:   totals.RushRevenue,
+		RushSurcharge: totals.RushSurcharge,
+		Statuses:      make(map[string]int64, len(totals.StatusCounts)),
+		Days:          make(map[string]redis.DayTotals, monthDays+1),
+	}
+	for status, count := range totals.StatusCounts {
+		fresh.Statuses[status] = int64(count)
+	}
+	// Empty days are written too so stale buckets are reset
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		fresh.Days[redis.DayKey(day)] = redis.DayTotals{}
+	}
+	for _, p := range daily {
+		fresh.Days[redis.DayKey(p.Period)] = redis.DayTotals{
+			Orders:  int64(p.Orders),
+			Revenue: p.Revenue,
+		}
+	}
+
+	return s.counters.ReplaceOrderStats(ctx, fresh)
+}
+
+// Watch reconciles the counters at startup and then periodically
+func (s *Service) Watch(ctx context.Context) {
+	interval := s.cfg.CurrentStats().ReconcileInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reconcile(ctx); err != nil {
+			s.logger.Error("Failed to reconcile stats counters", zap.Error(err))
+		}
+
+		// The interval may have been changed by a config reload
+		if current := s.cfg.CurrentStats().ReconcileInterval; current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
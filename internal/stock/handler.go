@@ -0,0 +1,80 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/audit"
+	"s1ntez/internal/auth"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler answers the "restocked" button of a low stock alert
+type Handler struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+}
+
+func NewHandler(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger) *Handler {
+	return &Handler{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("stock"),
+	}
+}
+
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if !auth.Has(ctx, auth.Admin) {
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Недостаточно прав"))
+		return nil
+	}
+
+	// stock:restock:<texture_id>
+	textureID, ok := strings.CutPrefix(query.Data, callbackPrefix+":restock:")
+	if !ok || textureID == "" {
+		return fmt.Errorf("bad stock callback %q", query.Data)
+	}
+
+	batch, err := h.storage.RestockTexture(ctx, textureID)
+	switch {
+	case errors.Is(err, postgres.ErrNoLowStockAlert):
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Уже пополнено"))
+		return h.dropButton(query)
+	case errors.Is(err, postgres.ErrNoTextureBatch):
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Поставок не было, примите партию через /addbatch"))
+		return nil
+	case err != nil:
+		_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, "Ошибка, попробуйте ещё раз"))
+		return err
+	}
+
+	audit.Record(ctx, "texture:"+textureID, nil,
+		map[string]any{"batch_id": batch.ID, "quantity_dm2": batch.QuantityDM2, "location": batch.Location})
+	h.logger.Info("Low stock alert answered",
+		zap.String("texture_id", textureID),
+		zap.Int64("batch_id", batch.ID),
+		zap.Int64("admin_id", query.From.ID))
+
+	_, _ = h.botAPI.Request(tgbotapi.NewCallback(query.ID, ""))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("%s\n\n✅ Партия #%d: +%.2f дм² в ячейку %s (%s)",
+			html.EscapeString(query.Message.Text), batch.ID, batch.QuantityDM2,
+			html.EscapeString(batch.Location), html.EscapeString(query.From.UserName)))
+	edit.ParseMode = tgbotapi.ModeHTML
+	_, err = h.botAPI.Send(edit)
+	return err
+}
+
+// dropButton removes the keyboard from an alert answered already
+func (h *Handler) dropButton(query *tgbotapi.CallbackQuery) error {
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	_, err := h.botAPI.Send(edit)
+	return err
+}
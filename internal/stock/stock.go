@@ -0,0 +1,98 @@
+// Package stock tells admins when a tracked texture runs low. Every
+// STOCK_CHECK_INTERVAL the textures with less than STOCK_LOW_DM2 left are
+// posted to the admin chat, each once until its stock is back up, with a
+// button that receives the last delivery of the texture again.
+package stock
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const callbackPrefix = "stock"
+
+// Watcher checks the stock of tracked textures
+type Watcher struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	logger  *zap.Logger
+	cfg     *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Watcher {
+	return &Watcher{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("stock"),
+		cfg:     cfg,
+	}
+}
+
+// Watch checks the stock until ctx is cancelled
+func (w *Watcher) Watch(ctx context.Context) {
+	if w.cfg.Stock.LowDM2 == 0 {
+		return
+	}
+	if w.cfg.Admin.ChatID == 0 {
+		w.logger.Warn("No admin chat configured, low stock alerts are off")
+		return
+	}
+
+	ticker := time.NewTicker(w.cfg.Stock.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) check(ctx context.Context) {
+	textures, err := w.storage.ClaimLowStockAlerts(ctx, w.cfg.Stock.LowDM2)
+	if err != nil {
+		w.logger.Error("Failed to check texture stock", zap.Error(err))
+		return
+	}
+
+	for _, t := range textures {
+		msg := tgbotapi.NewMessage(w.cfg.Admin.ChatID, alertText(t))
+		msg.ParseMode = tgbotapi.ModeHTML
+		if t.LastBatchDM2 != nil {
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					fmt.Sprintf("✅ Пополнено: +%.0f дм²", *t.LastBatchDM2),
+					fmt.Sprintf("%s:restock:%s", callbackPrefix, t.TextureID))))
+		}
+		if _, err := w.botAPI.Send(msg); err != nil {
+			w.logger.Error("Failed to send low stock alert",
+				zap.String("texture_id", t.TextureID),
+				zap.Error(err))
+		}
+	}
+}
+
+func alertText(t postgres.LowStock) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "📉 <b>Заканчивается %s</b> (<code>%s</code>)\nОсталось %.2f дм²",
+		html.EscapeString(t.Name), t.TextureID, t.StockDM2)
+	if t.LastBatchDM2 != nil {
+		fmt.Fprintf(&text, "\n\nПоследняя поставка: %.2f дм² от %s. Кнопка ниже принимает такую же партию в ту же ячейку.",
+			*t.LastBatchDM2, t.LastBatchAt.Format("02.01.2006"))
+	} else {
+		text.WriteString("\n\nПоставок по этой текстуре не было: примите партию командой /addbatch")
+	}
+	return text.String()
+}
@@ -0,0 +1,15 @@
+// Package errs holds the kinds of errors storage returns, so callers can
+// branch on them with errors.Is instead of matching messages
+package errs
+
+import (
+	"errors"
+)
+
+var (
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrTextureNotFound = errors.New("texture not found")
+	ErrInvalidPrice    = errors.New("invalid price")
+	// ErrRateLimited means the user has used up the action for this window
+	ErrRateLimited = errors.New("rate limited")
+)
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Standing is read on every update, so it is cached in Redis
+const standingCacheTTL = 10 * time.Minute
+
+// Standing is what the abuse checks know about a user
+type Standing struct {
+	// Known users have a row or an order, i.e. used the bot before
+	Known       bool      `json:"known"`
+	Blocked     bool      `json:"blocked"`
+	BannedUntil time.Time `json:"banned_until"`
+	Bans        int       `json:"bans"`
+	// Verified users passed the captcha
+	Verified bool `json:"verified"`
+}
+
+// BlockedUser is a user kept out of the bot, by an admin or by a ban
+type BlockedUser struct {
+	UserID      int64         `db:"user_id"`
+	BlockedAt   sql.NullTime  `db:"blocked_at"`
+	BlockedBy   sql.NullInt64 `db:"blocked_by"`
+	Reason      string        `db:"block_reason"`
+	BannedUntil sql.NullTime  `db:"banned_until"`
+	Bans        int           `db:"ban_count"`
+}
+
+func standingCacheKey(userID int64) string {
+	return fmt.Sprintf("user_standing:%d", userID)
+}
+
+// GetStanding returns the block, ban and captcha state of a user
+func (s *PostgresStorage) GetStanding(ctx context.Context, userID int64) (Standing, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var st Standing
+	if cached, err := s.redis.Get(ctx, standingCacheKey(userID)); err == nil && json.Unmarshal(cached, &st) == nil {
+		return st, nil
+	}
+
+	const query = `
+        SELECT
+            u.user_id IS NOT NULL OR EXISTS (SELECT 1 FROM orders WHERE user_id = $1),
+            u.blocked_at IS NOT NULL,
+            u.banned_until,
+            COALESCE(u.ban_count, 0),
+            u.human_verified_at IS NOT NULL
+        FROM (SELECT 1) AS one
+        LEFT JOIN users u ON u.user_id = $1
+    `
+	var bannedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&st.Known, &st.Blocked, &bannedUntil, &st.Bans, &st.Verified)
+	if err != nil {
+		return Standing{}, fmt.Errorf("failed to get user standing: %w", err)
+	}
+	st.BannedUntil = bannedUntil.Time
+
+	if data, err := json.Marshal(st); err == nil {
+		s.redis.Set(ctx, standingCacheKey(userID), data, standingCacheTTL)
+	}
+	return st, nil
+}
+
+// BlockUser keeps the user out of the bot until UnblockUser
+func (s *PostgresStorage) BlockUser(ctx context.Context, userID, adminID int64, reason string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, blocked_at, blocked_by, block_reason)
+        VALUES ($1, NOW(), $2, $3)
+        ON CONFLICT (user_id)
+        DO UPDATE SET blocked_at = NOW(), blocked_by = $2, block_reason = $3, updated_at = NOW()
+    `
+	if _, err := s.db.ExecContext(ctx, query, userID, adminID, reason); err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+
+	s.redis.Del(ctx, standingCacheKey(userID))
+	return nil
+}
+
+// UnblockUser lifts the block and any ban. It reports false for users who
+// were neither blocked nor banned.
+func (s *PostgresStorage) UnblockUser(ctx context.Context, userID int64) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE users
+        SET blocked_at = NULL, blocked_by = NULL, block_reason = NULL,
+            banned_until = NULL, updated_at = NOW()
+        WHERE user_id = $1 AND (blocked_at IS NOT NULL OR banned_until > NOW())
+    `
+	res, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	s.redis.Del(ctx, standingCacheKey(userID))
+	return n > 0, nil
+}
+
+// BanUser keeps the user out for duration, doubled for every earlier ban
+// and capped at max, and returns when the ban ends
+func (s *PostgresStorage) BanUser(ctx context.Context, userID int64, duration, max time.Duration) (time.Time, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// The exponent is capped so the power can't overflow the interval
+	const query = `
+        INSERT INTO users (user_id, banned_until, ban_count)
+        VALUES ($1, NOW() + make_interval(secs => $2), 1)
+        ON CONFLICT (user_id)
+        DO UPDATE SET
+            banned_until = NOW() + make_interval(secs => LEAST($2 * power(2, LEAST(users.ban_count, 20)), $3)),
+            ban_count = users.ban_count + 1,
+            updated_at = NOW()
+        RETURNING banned_until
+    `
+	var until time.Time
+	err := s.db.QueryRowContext(ctx, query, userID, duration.Seconds(), max.Seconds()).Scan(&until)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to ban user: %w", err)
+	}
+
+	s.redis.Del(ctx, standingCacheKey(userID))
+	return until, nil
+}
+
+// MarkHuman records that the user passed the captcha
+func (s *PostgresStorage) MarkHuman(ctx context.Context, userID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, human_verified_at)
+        VALUES ($1, NOW())
+        ON CONFLICT (user_id)
+        DO UPDATE SET human_verified_at = NOW(), updated_at = NOW()
+    `
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to mark user verified: %w", err)
+	}
+
+	s.redis.Del(ctx, standingCacheKey(userID))
+	return nil
+}
+
+// ListBlockedUsers returns the blocked and currently banned users, most
+// recent first
+func (s *PostgresStorage) ListBlockedUsers(ctx context.Context, limit int) ([]BlockedUser, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT user_id, blocked_at, blocked_by, COALESCE(block_reason, '') AS block_reason,
+               banned_until, ban_count
+        FROM users
+        WHERE blocked_at IS NOT NULL OR banned_until > NOW()
+        ORDER BY GREATEST(blocked_at, banned_until) DESC
+        LIMIT $1
+    `
+	var users []BlockedUser
+	if err := s.db.SelectContext(ctx, &users, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AccountingEntry is an order as the bookkeeping sees it: a sale to a
+// counterparty on a date
+type AccountingEntry struct {
+	OrderID int64     `db:"order_id"`
+	Code    string    `db:"code"`
+	Date    time.Time `db:"created_at"`
+	// UserID identifies the counterparty; Username and Contact help the
+	// accountant tell customers apart
+	UserID   int64  `db:"user_id"`
+	Username string `db:"username"`
+	Contact  string `db:"contact"`
+	// Amount is the order total, VAT included; GiftCard the part of it
+	// paid with a gift card sold earlier
+	Amount   money.Amount   `db:"amount"`
+	GiftCard money.Amount   `db:"gift_card_amount"`
+	Currency money.Currency `db:"currency"`
+}
+
+// GetAccountingEntries returns the orders of the month the way the month
+// close counts them: by creation date, cancelled ones left out. Archived
+// orders are included.
+func (s *PostgresStorage) GetAccountingEntries(ctx context.Context, month time.Time) ([]AccountingEntry, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start, end := MonthBounds(month)
+
+	const query = `
+        WITH sales AS (
+            SELECT id, code, created_at, user_id, contact, price, gift_card_amount, currency, status
+            FROM orders
+            WHERE created_at >= $1 AND created_at < $2
+            UNION ALL
+            SELECT o.id, o.code, o.created_at, o.user_id, o.contact, o.price,
+                   COALESCE(o.gift_card_amount, 0), COALESCE(o.currency, $3), o.status
+            FROM orders_archive a
+            CROSS JOIN LATERAL jsonb_populate_record(NULL::orders, a.data->'order') o
+            WHERE a.created_at >= $1 AND a.created_at < $2
+        )
+        SELECT s.id AS order_id, s.code, s.created_at, s.user_id,
+               COALESCE(c.username, '') AS username, s.contact,
+               s.price AS amount, s.gift_card_amount, s.currency
+        FROM sales s
+        LEFT JOIN customers c ON c.user_id = s.user_id
+        WHERE s.status <> 'cancelled'
+        ORDER BY s.created_at, s.id
+    `
+
+	var entries []AccountingEntry
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &entries, query, start, end, s.currency)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounting entries: %w", err)
+	}
+	return entries, nil
+}
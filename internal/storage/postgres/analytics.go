@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AnalyticsOrder is one order flattened for BI queries: the texture,
+// delivery, promo code and customer fields are copied in, so reports need
+// no joins and never touch the orders table
+type AnalyticsOrder struct {
+	ID int64 `db:"id" json:"id"`
+	// UserID is replaced by Customer, a pseudonym, before the row leaves
+	UserID         int64          `db:"user_id" json:"-"`
+	Customer       string         `db:"customer" json:"customer"`
+	Status         OrderStatus    `db:"status" json:"status"`
+	ServiceType    ServiceType    `db:"service_type" json:"service_type"`
+	IsRush         bool           `db:"is_rush" json:"is_rush"`
+	Currency       money.Currency `db:"currency" json:"currency"`
+	Price          money.Amount   `db:"price" json:"price"`
+	Discount       money.Amount   `db:"discount" json:"discount"`
+	Shipping       money.Amount   `db:"shipping" json:"shipping"`
+	RushSurcharge  money.Amount   `db:"rush_surcharge" json:"rush_surcharge"`
+	LeatherCost    money.Amount   `db:"leather_cost" json:"leather_cost"`
+	ProcessCost    money.Amount   `db:"process_cost" json:"process_cost"`
+	TotalCost      money.Amount   `db:"total_cost" json:"total_cost"`
+	Commission     money.Amount   `db:"commission" json:"commission"`
+	Tax            money.Amount   `db:"tax" json:"tax"`
+	NetRevenue     money.Amount   `db:"net_revenue" json:"net_revenue"`
+	Profit         money.Amount   `db:"profit" json:"profit"`
+	DeliveryMethod string         `db:"delivery_method" json:"delivery_method"`
+	PromoCode      string         `db:"promo_code" json:"promo_code"`
+	TextureID      string         `db:"texture_id" json:"texture_id"`
+	TextureName    string         `db:"texture_name" json:"texture_name"`
+	Items          int            `db:"items" json:"items"`
+	Quantity       int            `db:"quantity" json:"quantity"`
+	MaterialDM2    float64        `db:"material_dm2" json:"material_dm2"`
+	CustomerLocale string         `db:"customer_locale" json:"customer_locale"`
+	CustomerSince  *time.Time     `db:"customer_since" json:"customer_since"`
+	// CustomerOrderNumber is 1 for the customer's first order
+	CustomerOrderNumber int       `db:"customer_order_number" json:"customer_order_number"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// AnalyticsCursor is the last order an export target has received, by
+// (updated_at, id)
+type AnalyticsCursor struct {
+	UpdatedAt time.Time `db:"updated_at"`
+	OrderID   int64     `db:"order_id"`
+}
+
+// AnalyticsCursor returns how far the target has been exported; the zero
+// cursor when it never was
+func (s *PostgresStorage) AnalyticsCursor(ctx context.Context, target string) (AnalyticsCursor, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var cursor AnalyticsCursor
+	err := s.db.GetContext(ctx, &cursor,
+		`SELECT updated_at, order_id FROM analytics_exports WHERE target = $1`, target)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AnalyticsCursor{}, nil
+	}
+	if err != nil {
+		return AnalyticsCursor{}, fmt.Errorf("failed to get analytics cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (s *PostgresStorage) SaveAnalyticsCursor(ctx context.Context, target string, cursor AnalyticsCursor) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO analytics_exports (target, updated_at, order_id)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (target) DO UPDATE
+        SET updated_at = EXCLUDED.updated_at, order_id = EXCLUDED.order_id, exported_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, target, cursor.UpdatedAt, cursor.OrderID); err != nil {
+		return fmt.Errorf("failed to save analytics cursor: %w", err)
+	}
+	return nil
+}
+
+// ChangedOrdersForAnalytics returns up to limit orders changed after the
+// cursor, oldest change first. Changes of the last settle are left for the
+// next run: a transaction that started earlier may still commit rows
+// stamped before them.
+func (s *PostgresStorage) ChangedOrdersForAnalytics(ctx context.Context, after AnalyticsCursor, settle time.Duration, limit int) ([]AnalyticsOrder, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT o.id, o.user_id, '' AS customer, o.status, o.service_type, o.is_rush, o.currency,
+               o.price, o.discount, COALESCE(d.cost, 0) AS shipping, o.rush_surcharge,
+               o.leather_cost, o.process_cost, o.total_cost, o.commission, o.tax,
+               o.net_revenue, o.profit,
+               COALESCE(d.method, 'pickup') AS delivery_method,
+               COALESCE(p.code, '') AS promo_code,
+               o.texture_id, COALESCE(t.name, '') AS texture_name,
+               COALESCE(i.items, 0) AS items,
+               COALESCE(i.quantity, 0) AS quantity,
+               COALESCE(i.material_dm2, 0) AS material_dm2,
+               COALESCE(u.locale, '') AS customer_locale,
+               u.created_at AS customer_since,
+               (SELECT COUNT(*) FROM orders e
+                WHERE e.user_id = o.user_id AND (e.created_at, e.id) <= (o.created_at, o.id)
+               ) AS customer_order_number,
+               o.created_at, o.updated_at
+        FROM orders o
+        LEFT JOIN textures t ON t.id = o.texture_id
+        LEFT JOIN users u ON u.user_id = o.user_id
+        LEFT JOIN promocodes p ON p.id = o.promocode_id
+        LEFT JOIN delivery d ON d.order_id = o.id
+        LEFT JOIN LATERAL (
+            SELECT COUNT(*) AS items,
+                   SUM(quantity) AS quantity,
+                   SUM(width_cm * height_cm * quantity) / 100.0 AS material_dm2
+            FROM order_items
+            WHERE order_id = o.id
+        ) i ON TRUE
+        WHERE (o.updated_at, o.id) > ($1, $2)
+          AND o.updated_at < LOCALTIMESTAMP - make_interval(secs => $3)
+        ORDER BY o.updated_at, o.id
+        LIMIT $4
+    `
+
+	var orders []AnalyticsOrder
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &orders, query, after.UpdatedAt, after.OrderID, settle.Seconds(), limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed orders: %w", err)
+	}
+	return orders, nil
+}
+
+// UpsertAnalyticsOrders writes the orders to analytics.orders, replacing
+// the earlier copies of changed ones
+func (s *PostgresStorage) UpsertAnalyticsOrders(ctx context.Context, orders []AnalyticsOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO analytics.orders (
+            id, customer, status, service_type, is_rush, currency,
+            price, discount, shipping, rush_surcharge,
+            leather_cost, process_cost, total_cost, commission, tax, net_revenue, profit,
+            delivery_method, promo_code, texture_id, texture_name,
+            items, quantity, material_dm2,
+            customer_locale, customer_since, customer_order_number,
+            created_at, updated_at
+        ) VALUES (
+            :id, :customer, :status, :service_type, :is_rush, :currency,
+            :price, :discount, :shipping, :rush_surcharge,
+            :leather_cost, :process_cost, :total_cost, :commission, :tax, :net_revenue, :profit,
+            :delivery_method, :promo_code, :texture_id, :texture_name,
+            :items, :quantity, :material_dm2,
+            :customer_locale, :customer_since, :customer_order_number,
+            :created_at, :updated_at
+        )
+        ON CONFLICT (id) DO UPDATE SET
+            customer = EXCLUDED.customer,
+            status = EXCLUDED.status,
+            service_type = EXCLUDED.service_type,
+            is_rush = EXCLUDED.is_rush,
+            currency = EXCLUDED.currency,
+            price = EXCLUDED.price,
+            discount = EXCLUDED.discount,
+            shipping = EXCLUDED.shipping,
+            rush_surcharge = EXCLUDED.rush_surcharge,
+            leather_cost = EXCLUDED.leather_cost,
+            process_cost = EXCLUDED.process_cost,
+            total_cost = EXCLUDED.total_cost,
+            commission = EXCLUDED.commission,
+            tax = EXCLUDED.tax,
+            net_revenue = EXCLUDED.net_revenue,
+            profit = EXCLUDED.profit,
+            delivery_method = EXCLUDED.delivery_method,
+            promo_code = EXCLUDED.promo_code,
+            texture_id = EXCLUDED.texture_id,
+            texture_name = EXCLUDED.texture_name,
+            items = EXCLUDED.items,
+            quantity = EXCLUDED.quantity,
+            material_dm2 = EXCLUDED.material_dm2,
+            customer_locale = EXCLUDED.customer_locale,
+            customer_since = EXCLUDED.customer_since,
+            customer_order_number = EXCLUDED.customer_order_number,
+            created_at = EXCLUDED.created_at,
+            updated_at = EXCLUDED.updated_at,
+            exported_at = NOW()
+    `
+
+	if _, err := s.db.NamedExecContext(ctx, query, orders); err != nil {
+		return fmt.Errorf("failed to write analytics orders: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ErrPeriodArchived is returned when a month to unlock has orders in the
+// archive: they can no longer be corrected, so the lock stays
+var ErrPeriodArchived = errors.New("period has archived orders")
+
+// ArchiveOrders moves up to limit finished orders created before the
+// cutoff out of the orders table. Only orders of closed months qualify,
+// their figures can no longer change. Returns the number of orders moved.
+func (s *PostgresStorage) ArchiveOrders(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const operation = "storage.ArchiveOrders"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	// lets the period lock trigger pass our deletes, for this transaction only
+	if _, err := tx.ExecContext(ctx, `SET LOCAL adtime.archiving = 'on'`); err != nil {
+		return 0, fmt.Errorf("%s: failed to mark transaction: %w", operation, err)
+	}
+
+	var ids []int64
+	err = tx.SelectContext(ctx, &ids, `
+        WITH picked AS (
+            SELECT o.*
+            FROM orders o
+            WHERE o.status IN ('completed', 'cancelled')
+              AND o.created_at < $1
+              AND EXISTS (
+                  SELECT 1 FROM period_closes p
+                  WHERE p.unlocked_at IS NULL
+                    AND o.created_at >= p.period_start AND o.created_at < p.period_end
+              )
+            ORDER BY o.created_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        INSERT INTO orders_archive (id, user_id, status, created_at, data)
+        SELECT o.id, o.user_id, o.status, o.created_at, jsonb_build_object(
+            'order', to_jsonb(o),
+            'items', COALESCE((SELECT jsonb_agg(to_jsonb(i) ORDER BY i.position)
+                               FROM order_items i WHERE i.order_id = o.id), '[]'::jsonb),
+            'delivery', (SELECT to_jsonb(d) FROM delivery d WHERE d.order_id = o.id),
+            'notes', COALESCE((SELECT jsonb_agg(to_jsonb(n) ORDER BY n.id)
+                               FROM order_notes n WHERE n.order_id = o.id), '[]'::jsonb)
+        )
+        FROM picked o
+        RETURNING id
+    `, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to copy orders: %w", operation, err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO orders_archive_totals (status, orders, revenue, rush_orders, rush_revenue, rush_surcharge)
+        SELECT status,
+               COUNT(*),
+               COALESCE(SUM(price), 0),
+               COUNT(*) FILTER (WHERE is_rush),
+               COALESCE(SUM(price) FILTER (WHERE is_rush), 0),
+               COALESCE(SUM(rush_surcharge) FILTER (WHERE is_rush), 0)
+        FROM orders
+        WHERE id = ANY($1)
+        GROUP BY status
+        ON CONFLICT (status) DO UPDATE SET
+            orders = orders_archive_totals.orders + EXCLUDED.orders,
+            revenue = orders_archive_totals.revenue + EXCLUDED.revenue,
+            rush_orders = orders_archive_totals.rush_orders + EXCLUDED.rush_orders,
+            rush_revenue = orders_archive_totals.rush_revenue + EXCLUDED.rush_revenue,
+            rush_surcharge = orders_archive_totals.rush_surcharge + EXCLUDED.rush_surcharge
+    `, ids)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update totals: %w", operation, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM orders WHERE id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("%s: failed to delete orders: %w", operation, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit: %w", operation, err)
+	}
+
+	s.logger.Info("Orders archived",
+		zap.Int("orders", len(ids)),
+		zap.Time("before", before))
+
+	return len(ids), nil
+}
+
+// addArchiveTotals folds the archived orders into all-time statistics.
+// Today, week and month figures never reach that far back.
+func addArchiveTotals(ctx context.Context, db sqlx.QueryerContext, stats *OrderStatistics) error {
+	var totals []struct {
+		Status        string  `db:"status"`
+		Orders        int     `db:"orders"`
+		Revenue       float64 `db:"revenue"`
+		RushOrders    int     `db:"rush_orders"`
+		RushRevenue   float64 `db:"rush_revenue"`
+		RushSurcharge float64 `db:"rush_surcharge"`
+	}
+	err := sqlx.SelectContext(ctx, db, &totals, `
+        SELECT status, orders, revenue, rush_orders, rush_revenue, rush_surcharge
+        FROM orders_archive_totals
+    `)
+	if err != nil {
+		return fmt.Errorf("failed to get archive totals: %w", err)
+	}
+
+	for _, t := range totals {
+		stats.TotalOrders += t.Orders
+		stats.TotalRevenue += t.Revenue
+		stats.StatusCounts[t.Status] += t.Orders
+		stats.RushOrders += t.RushOrders
+		stats.RushRevenue += t.RushRevenue
+		stats.RushSurcharge += t.RushSurcharge
+	}
+	return nil
+}
+
+// getArchivedOrders returns the user's archived orders rebuilt from their
+// stored rows, with items and delivery, newest first
+func getArchivedOrders(ctx context.Context, db sqlx.QueryerContext, userID int64) ([]Order, error) {
+	var orders []Order
+	err := sqlx.SelectContext(ctx, db, &orders, `
+        SELECT o.*, COALESCE(t.name, '') AS texture_name
+        FROM orders_archive a
+        CROSS JOIN LATERAL jsonb_populate_record(NULL::orders, a.data->'order') o
+        LEFT JOIN textures t ON t.id = o.texture_id
+        WHERE a.user_id = $1
+        ORDER BY a.created_at DESC
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived orders: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	var items []OrderItem
+	err = sqlx.SelectContext(ctx, db, &items, `
+        SELECT i.id, i.order_id, i.position, i.service_type, i.texture_id::text,
+               COALESCE(t.name, '') AS texture_name, i.width_cm, i.height_cm, i.quantity,
+               i.options, i.price, i.leather_cost, i.process_cost, i.total_cost,
+               i.rush_surcharge, i.reserved_dm2
+        FROM orders_archive a
+        CROSS JOIN LATERAL jsonb_array_elements(a.data->'items') e
+        CROSS JOIN LATERAL jsonb_populate_record(NULL::order_items, e) i
+        LEFT JOIN textures t ON t.id = i.texture_id
+        WHERE a.user_id = $1
+        ORDER BY i.order_id, i.position
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived order items: %w", err)
+	}
+
+	var deliveries []OrderDelivery
+	err = sqlx.SelectContext(ctx, db, &deliveries, `
+        SELECT d.order_id, d.method, d.address, d.cost
+        FROM orders_archive a
+        CROSS JOIN LATERAL jsonb_populate_record(NULL::delivery, a.data->'delivery') d
+        WHERE a.user_id = $1 AND jsonb_typeof(a.data->'delivery') = 'object'
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived deliveries: %w", err)
+	}
+
+	byID := make(map[int64]*Order, len(orders))
+	for i := range orders {
+		byID[orders[i].ID] = &orders[i]
+	}
+	for _, item := range items {
+		if order := byID[item.OrderID]; order != nil {
+			order.Items = append(order.Items, item)
+		}
+	}
+	for i := range deliveries {
+		if order := byID[deliveries[i].OrderID]; order != nil {
+			order.Delivery = &deliveries[i]
+		}
+	}
+	return orders, nil
+}
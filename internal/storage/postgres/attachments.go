@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Attachment struct {
+	ID          int64     `db:"id"`
+	UserID      int64     `db:"user_id"`
+	OrderID     *int64    `db:"order_id"`
+	FileID      string    `db:"tg_file_id"`
+	UniqueID    string    `db:"tg_unique_id"`
+	ObjectKey   string    `db:"object_key"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	SHA256      string    `db:"sha256"`
+	CreatedAt   time.Time `db:"created_at"`
+
+	// Transcript is the text of a voice message, nil when there is none
+	Transcript *string `db:"transcript"`
+}
+
+// Kinds of files kept by Telegram itself; a file ID can only be re-sent
+// with the method of its kind
+const (
+	TelegramPhoto    = "photo"
+	TelegramDocument = "document"
+	TelegramVoice    = "voice"
+)
+
+// TelegramObjectKey names a file that stays on Telegram servers
+func TelegramObjectKey(kind, uniqueID string) string {
+	return "telegram/" + kind + "/" + uniqueID
+}
+
+// TelegramKind returns the kind of a file kept by Telegram, or "" for files
+// in our own storage
+func (a Attachment) TelegramKind() string {
+	rest, ok := strings.CutPrefix(a.ObjectKey, "telegram/")
+	if !ok {
+		return ""
+	}
+	kind, _, _ := strings.Cut(rest, "/")
+	return kind
+}
+
+// SaveAttachment records a stored file. Re-sending the same Telegram file
+// returns the existing attachment instead of creating a duplicate.
+func (s *PostgresStorage) SaveAttachment(ctx context.Context, a Attachment) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO attachments (
+            user_id, order_id, tg_file_id, tg_unique_id, object_key,
+            content_type, size_bytes, sha256, transcript
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (user_id, tg_unique_id)
+        DO UPDATE SET tg_file_id = EXCLUDED.tg_file_id,
+                      transcript = COALESCE(EXCLUDED.transcript, attachments.transcript)
+        RETURNING id
+    `
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		a.UserID,
+		a.OrderID,
+		a.FileID,
+		a.UniqueID,
+		a.ObjectKey,
+		a.ContentType,
+		a.SizeBytes,
+		a.SHA256,
+		a.Transcript,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *PostgresStorage) GetOrderAttachments(ctx context.Context, orderID int64) ([]Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, user_id, order_id, tg_file_id, tg_unique_id, object_key,
+               content_type, size_bytes, sha256, transcript, created_at
+        FROM attachments
+        WHERE order_id = $1
+        ORDER BY id
+    `
+
+	var attachments []Attachment
+	if err := s.db.SelectContext(ctx, &attachments, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+func (s *PostgresStorage) GetAttachment(ctx context.Context, id int64) (*Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, user_id, order_id, tg_file_id, tg_unique_id, object_key,
+               content_type, size_bytes, sha256, transcript, created_at
+        FROM attachments
+        WHERE id = $1
+    `
+
+	var a Attachment
+	if err := s.db.GetContext(ctx, &a, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// linkAttachments assigns the user's loose uploads to a new order. Files of
+// other users or already linked to an order are left alone.
+func linkAttachments(ctx context.Context, tx *sqlx.Tx, orderID, userID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE attachments SET order_id = $1
+        WHERE id = ANY($3) AND user_id = $2 AND order_id IS NULL
+    `, orderID, userID, ids); err != nil {
+		return fmt.Errorf("failed to link attachments: %w", err)
+	}
+	return nil
+}
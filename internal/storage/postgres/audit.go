@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditEntry is one change made by an admin. Before and After are the
+// changed record as JSON, nil when it did not exist.
+type AuditEntry struct {
+	ID        int64           `db:"id"`
+	AdminID   int64           `db:"admin_id"`
+	Action    string          `db:"action"`
+	Target    string          `db:"target"`
+	Before    json.RawMessage `db:"before"`
+	After     json.RawMessage `db:"after"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// AuditFilter narrows ListAuditLog; zero fields match everything
+type AuditFilter struct {
+	AdminID int64
+	// Target matches by prefix: "order:" lists all order changes
+	Target string
+	Limit  int
+}
+
+// RecordAudit writes the changes of one admin action together
+func (s *PostgresStorage) RecordAudit(ctx context.Context, entries []AuditEntry) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const query = `
+        INSERT INTO audit_log (admin_id, action, target, before, after)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	for _, e := range entries {
+		if _, err := tx.ExecContext(ctx, query, e.AdminID, e.Action, e.Target, nullJSON(e.Before), nullJSON(e.After)); err != nil {
+			return fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit entries: %w", err)
+	}
+	return nil
+}
+
+// ListAuditLog returns the latest entries first
+func (s *PostgresStorage) ListAuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, admin_id, action, target, before, after, created_at
+        FROM audit_log
+        WHERE ($1::bigint = 0 OR admin_id = $1)
+          AND ($2::text = '' OR target LIKE $2 || '%')
+        ORDER BY created_at DESC, id DESC
+        LIMIT $3
+    `
+
+	var entries []AuditEntry
+	if err := s.db.SelectContext(ctx, &entries, query, filter.AdminID, filter.Target, filter.Limit); err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// nullJSON stores a missing value as SQL NULL rather than JSON null
+func nullJSON(data json.RawMessage) any {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return []byte(data)
+}
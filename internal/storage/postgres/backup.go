@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// backupBatchSize is how many rows of a table one INSERT restores
+const backupBatchSize = 500
+
+// ErrDatabaseNotEmpty is returned when a backup is restored over a database
+// that already has customers or orders
+var ErrDatabaseNotEmpty = errors.New("database is not empty")
+
+// BackupTables are dumped and restored in this order, referenced tables
+// first. Orders come before period_closes so the period lock doesn't
+// reject them on restore.
+var BackupTables = []string{
+	"textures",
+	"texture_photos",
+	"texture_batches",
+	"texture_price_history",
+	"users",
+	"promocodes",
+	"orders",
+	"order_items",
+	"delivery",
+	"order_notes",
+	"order_events",
+	"order_holds",
+	"quote_reviews",
+	"promocode_redemptions",
+	"gift_cards",
+	"gift_card_redemptions",
+	"payments",
+	"order_batch_allocations",
+	"referrals",
+	"loyalty_points",
+	"reviews",
+	"orders_archive",
+	"orders_archive_totals",
+	"period_closes",
+}
+
+// backupLine is one row of a backup: a JSON object per line
+type backupLine struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// DumpBackup writes every row of BackupTables to w as JSON lines, all
+// read from one snapshot. Returns the number of rows per table.
+func (s *PostgresStorage) DumpBackup(ctx context.Context, w io.Writer) (map[string]int, error) {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	const operation = "storage.DumpBackup"
+
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	counts := make(map[string]int, len(BackupTables))
+	for _, table := range BackupTables {
+		n, err := dumpTable(ctx, tx, table, w)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", operation, err)
+		}
+		counts[table] = n
+	}
+	return counts, nil
+}
+
+func dumpTable(ctx context.Context, tx *sqlx.Tx, table string, w io.Writer) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+        SELECT jsonb_build_object('table', $1::text, 'row', to_jsonb(t))::text
+        FROM %s t
+    `, table), table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return n, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return n, fmt.Errorf("failed to write %s: %w", table, err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// RestoreBackup loads a backup written by DumpBackup in one transaction.
+// The database must have the current schema and no customers or orders;
+// textures seeded by the migrations are replaced. Returns the number of
+// rows per table.
+func (s *PostgresStorage) RestoreBackup(ctx context.Context, r io.Reader) (map[string]int, error) {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	const operation = "storage.RestoreBackup"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	var used bool
+	err = tx.GetContext(ctx, &used, `
+        SELECT EXISTS (SELECT 1 FROM orders)
+            OR EXISTS (SELECT 1 FROM orders_archive)
+            OR EXISTS (SELECT 1 FROM users)
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to check database: %w", operation, err)
+	}
+	if used {
+		return nil, ErrDatabaseNotEmpty
+	}
+
+	if _, err := tx.ExecContext(ctx, `TRUNCATE `+strings.Join(BackupTables, ", ")+` RESTART IDENTITY CASCADE`); err != nil {
+		return nil, fmt.Errorf("%s: failed to clear tables: %w", operation, err)
+	}
+
+	counts := make(map[string]int, len(BackupTables))
+	current := -1
+	var batch []json.RawMessage
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := restoreRows(ctx, tx, BackupTables[current], batch); err != nil {
+			return err
+		}
+		counts[BackupTables[current]] += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			var line backupLine
+			if err := json.Unmarshal(data, &line); err != nil {
+				return nil, fmt.Errorf("%s: bad line: %w", operation, err)
+			}
+
+			idx := slices.Index(BackupTables, line.Table)
+			switch {
+			case idx < 0:
+				return nil, fmt.Errorf("%s: unknown table %q", operation, line.Table)
+			case idx < current:
+				return nil, fmt.Errorf("%s: table %q is out of order", operation, line.Table)
+			case idx > current:
+				if err := flush(); err != nil {
+					return nil, fmt.Errorf("%s: %w", operation, err)
+				}
+				current = idx
+				// triggers on earlier tables may have filled it (texture prices)
+				if _, err := tx.ExecContext(ctx, `DELETE FROM `+line.Table); err != nil {
+					return nil, fmt.Errorf("%s: failed to clear %s: %w", operation, line.Table, err)
+				}
+			}
+
+			batch = append(batch, line.Row)
+			if len(batch) == backupBatchSize {
+				if err := flush(); err != nil {
+					return nil, fmt.Errorf("%s: %w", operation, err)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read backup: %w", operation, err)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	for _, table := range BackupTables {
+		if err := resetSequences(ctx, tx, table); err != nil {
+			return nil, fmt.Errorf("%s: %w", operation, err)
+		}
+	}
+	// archived orders keep their numbers, new ones must not reuse them
+	_, err = tx.ExecContext(ctx, `
+        SELECT setval(pg_get_serial_sequence('orders', 'id'), GREATEST(
+            (SELECT COALESCE(MAX(id), 0) FROM orders),
+            (SELECT COALESCE(MAX(id), 0) FROM orders_archive)
+        ) + 1, false)
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to reset order numbers: %w", operation, err)
+	}
+	// the same for the yearly counters behind the order codes
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO order_code_counters AS c (year, last)
+        SELECT split_part(code, '-', 2)::int, MAX(split_part(code, '-', 3)::int)
+        FROM (
+            SELECT code FROM orders
+            UNION ALL
+            SELECT data->'order'->>'code' FROM orders_archive
+        ) codes
+        WHERE code ~ '^AT-[0-9]+-[0-9]+$'
+        GROUP BY 1
+        ON CONFLICT (year) DO UPDATE SET last = GREATEST(c.last, EXCLUDED.last)
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to reset order codes: %w", operation, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", operation, err)
+	}
+
+	s.logger.Info("Backup restored", zap.Any("rows", counts))
+	return counts, nil
+}
+
+// restoreRows inserts rows of one table; keys of columns the table no
+// longer has are ignored
+func restoreRows(ctx context.Context, tx *sqlx.Tx, table string, rows []json.RawMessage) error {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s rows: %w", table, err)
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+        INSERT INTO %[1]s
+        SELECT (jsonb_populate_record(NULL::%[1]s, r)).*
+        FROM jsonb_array_elements($1::jsonb) r
+    `, table), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", table, err)
+	}
+	return nil
+}
+
+// resetSequences moves the table's serial columns past the restored rows
+func resetSequences(ctx context.Context, tx *sqlx.Tx, table string) error {
+	var columns []struct {
+		Name     string `db:"name"`
+		Sequence string `db:"sequence"`
+	}
+	err := tx.SelectContext(ctx, &columns, `
+        SELECT attname AS name, pg_get_serial_sequence($1, attname) AS sequence
+        FROM pg_attribute
+        WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped
+          AND pg_get_serial_sequence($1, attname) IS NOT NULL
+    `, table)
+	if err != nil {
+		return fmt.Errorf("failed to find sequences of %s: %w", table, err)
+	}
+
+	for _, c := range columns {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`SELECT setval($1, COALESCE(MAX(%s), 0) + 1, false) FROM %s`, c.Name, table), c.Sequence)
+		if err != nil {
+			return fmt.Errorf("failed to reset %s: %w", c.Sequence, err)
+		}
+	}
+	return nil
+}
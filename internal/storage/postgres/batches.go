@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoLowStockAlert = errors.New("no low stock alert pending")
+	ErrNoTextureBatch  = errors.New("texture has no batches")
+)
+
+// TextureBatch is one delivery of material stored at a warehouse location
+type TextureBatch struct {
+	ID           int64     `db:"id"`
+	TextureID    string    `db:"texture_id"`
+	Supplier     string    `db:"supplier"`
+	Location     string    `db:"location"`
+	QuantityDM2  float64   `db:"quantity_dm2"`
+	RemainingDM2 float64   `db:"remaining_dm2"`
+	ReceivedAt   time.Time `db:"received_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// BatchAllocation tells production which batch (and shelf) an order is cut from
+type BatchAllocation struct {
+	BatchID    int64     `db:"batch_id"`
+	AreaDM2    float64   `db:"area_dm2"`
+	Location   string    `db:"location"`
+	Supplier   string    `db:"supplier"`
+	ReceivedAt time.Time `db:"received_at"`
+}
+
+// ReceiveTextureBatch registers a delivery and adds it to the texture stock
+func (s *PostgresStorage) ReceiveTextureBatch(ctx context.Context, batch TextureBatch) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := receiveBatch(ctx, tx, &batch)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit texture batch: %w", err)
+	}
+
+	s.invalidateTextureCache(ctx, batch.TextureID)
+	s.logger.Info("Texture batch received",
+		zap.Int64("batch_id", id),
+		zap.String("texture_id", batch.TextureID),
+		zap.String("location", batch.Location),
+		zap.Float64("quantity_dm2", batch.QuantityDM2))
+
+	return id, nil
+}
+
+// RestockTexture answers a low stock alert by receiving the last delivery
+// of the texture again: same quantity, supplier and location. Returns
+// ErrNoLowStockAlert when the alert was already answered and
+// ErrNoTextureBatch when the texture never had a batch to repeat.
+func (s *PostgresStorage) RestockTexture(ctx context.Context, textureID string) (*TextureBatch, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Clearing the alert first makes a second tap on the button a no-op
+	res, err := tx.ExecContext(ctx, `
+        UPDATE textures SET low_stock_alerted_at = NULL
+        WHERE id = $1 AND low_stock_alerted_at IS NOT NULL
+    `, textureID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear low stock alert: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNoLowStockAlert
+	}
+
+	var batch TextureBatch
+	err = tx.GetContext(ctx, &batch, `
+        SELECT texture_id::text, supplier, location, quantity_dm2
+        FROM texture_batches
+        WHERE texture_id = $1
+        ORDER BY received_at DESC, id DESC
+        LIMIT 1
+    `, textureID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoTextureBatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last texture batch: %w", err)
+	}
+
+	if batch.ID, err = receiveBatch(ctx, tx, &batch); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit restock: %w", err)
+	}
+
+	s.invalidateTextureCache(ctx, textureID)
+	s.logger.Info("Texture restocked",
+		zap.Int64("batch_id", batch.ID),
+		zap.String("texture_id", textureID),
+		zap.Float64("quantity_dm2", batch.QuantityDM2))
+
+	return &batch, nil
+}
+
+// receiveBatch saves the batch inside tx and adds it to the texture stock
+func receiveBatch(ctx context.Context, tx *sqlx.Tx, batch *TextureBatch) (int64, error) {
+	if batch.ReceivedAt.IsZero() {
+		batch.ReceivedAt = time.Now()
+	}
+
+	var id int64
+	err := tx.QueryRowContext(ctx, `
+        INSERT INTO texture_batches (texture_id, supplier, location, quantity_dm2, remaining_dm2, received_at)
+        VALUES ($1, $2, $3, $4, $4, $5)
+        RETURNING id
+    `, batch.TextureID, batch.Supplier, batch.Location, batch.QuantityDM2, batch.ReceivedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save texture batch: %w", err)
+	}
+
+	// Receiving a batch switches the texture to tracked stock
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE textures
+        SET stock_dm2 = COALESCE(stock_dm2, 0) + $2,
+            in_stock = TRUE,
+            updated_at = NOW()
+        WHERE id = $1
+    `, batch.TextureID, batch.QuantityDM2); err != nil {
+		return 0, fmt.Errorf("failed to add batch to stock: %w", err)
+	}
+	return id, nil
+}
+
+func (s *PostgresStorage) GetTextureBatches(ctx context.Context, textureID string) ([]TextureBatch, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, texture_id::text, supplier, location, quantity_dm2, remaining_dm2, received_at, created_at
+        FROM texture_batches
+        WHERE texture_id = $1 AND remaining_dm2 > 0
+        ORDER BY received_at, id
+    `
+
+	var batches []TextureBatch
+	if err := s.db.SelectContext(ctx, &batches, query, textureID); err != nil {
+		return nil, fmt.Errorf("failed to get texture batches: %w", err)
+	}
+	return batches, nil
+}
+
+// GetOrderAllocations returns the batches an order consumes
+func (s *PostgresStorage) GetOrderAllocations(ctx context.Context, orderID int64) ([]BatchAllocation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT a.batch_id, a.area_dm2, b.location, b.supplier, b.received_at
+        FROM order_batch_allocations a
+        JOIN texture_batches b ON b.id = a.batch_id
+        WHERE a.order_id = $1
+        ORDER BY b.received_at, b.id
+    `
+
+	var allocations []BatchAllocation
+	if err := s.db.SelectContext(ctx, &allocations, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order allocations: %w", err)
+	}
+	return allocations, nil
+}
+
+// allocateBatches consumes areaDM2 from the oldest batches first (FIFO).
+// Stock entered without batches is not traceable, so a shortfall is allowed.
+func allocateBatches(ctx context.Context, tx *sqlx.Tx, orderID int64, textureID string, areaDM2 float64) error {
+	var batches []TextureBatch
+	err := tx.SelectContext(ctx, &batches, `
+        SELECT id, remaining_dm2
+        FROM texture_batches
+        WHERE texture_id = $1 AND remaining_dm2 > 0
+        ORDER BY received_at, id
+        FOR UPDATE
+    `, textureID)
+	if err != nil {
+		return fmt.Errorf("failed to lock texture batches: %w", err)
+	}
+
+	// Counted in hundredths like the DECIMAL columns, so no allocation
+	// rounds to zero
+	left := int64(math.Round(areaDM2 * 100))
+	for _, batch := range batches {
+		if left <= 0 {
+			break
+		}
+
+		take := min(left, int64(math.Round(batch.RemainingDM2*100)))
+		if take <= 0 {
+			continue
+		}
+		area := float64(take) / 100
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE texture_batches SET remaining_dm2 = remaining_dm2 - $2 WHERE id = $1`,
+			batch.ID, area); err != nil {
+			return fmt.Errorf("failed to consume batch %d: %w", batch.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO order_batch_allocations (order_id, batch_id, area_dm2) VALUES ($1, $2, $3)`,
+			orderID, batch.ID, area); err != nil {
+			return fmt.Errorf("failed to record batch allocation: %w", err)
+		}
+		left -= take
+	}
+
+	return nil
+}
+
+// returnBatches puts the material of a cancelled order back into its batches
+func returnBatches(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE texture_batches b
+        SET remaining_dm2 = b.remaining_dm2 + a.area_dm2
+        FROM order_batch_allocations a
+        WHERE a.batch_id = b.id AND a.order_id = $1
+    `, orderID); err != nil {
+		return fmt.Errorf("failed to return batches: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM order_batch_allocations WHERE order_id = $1`, orderID); err != nil {
+		return fmt.Errorf("failed to clear batch allocations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type BroadcastStatus string
+
+const (
+	BroadcastDraft     BroadcastStatus = "draft"
+	BroadcastSending   BroadcastStatus = "sending"
+	BroadcastDone      BroadcastStatus = "done"
+	BroadcastCancelled BroadcastStatus = "cancelled"
+)
+
+// Delivery outcomes of one recipient
+const (
+	RecipientPending = "pending"
+	RecipientSent    = "sent"
+	RecipientFailed  = "failed"
+	// RecipientBlocked: the user blocked the bot
+	RecipientBlocked = "blocked"
+)
+
+// Broadcast segments. "status:<status>" selects users with an order in
+// that status.
+const (
+	SegmentAll = "all"
+	// SegmentActive: users who ordered in the last 30 days
+	SegmentActive       = "active"
+	SegmentStatusPrefix = "status:"
+)
+
+var (
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+	ErrInvalidSegment    = errors.New("invalid broadcast segment")
+)
+
+type BroadcastButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// BroadcastButtons are stored as a JSON array
+type BroadcastButtons []BroadcastButton
+
+func (b BroadcastButtons) Value() (driver.Value, error) {
+	if b == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(b)
+}
+
+func (b *BroadcastButtons) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*b = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into BroadcastButtons", src)
+	}
+	return json.Unmarshal(data, b)
+}
+
+// Broadcast is an announcement to a segment of users. The counters are
+// computed from its recipients.
+type Broadcast struct {
+	ID          int64            `db:"id"`
+	CreatedBy   int64            `db:"created_by"`
+	Text        string           `db:"text"`
+	PhotoFileID string           `db:"photo_file_id"`
+	Buttons     BroadcastButtons `db:"buttons"`
+	Segment     string           `db:"segment"`
+	Status      BroadcastStatus  `db:"status"`
+	CreatedAt   time.Time        `db:"created_at"`
+	StartedAt   *time.Time       `db:"started_at"`
+	FinishedAt  *time.Time       `db:"finished_at"`
+
+	Total   int `db:"total"`
+	Sent    int `db:"sent"`
+	Failed  int `db:"failed"`
+	Blocked int `db:"blocked"`
+}
+
+const broadcastColumns = `
+        b.id, b.created_by, b.text, b.photo_file_id, b.buttons, b.segment, b.status,
+        b.created_at, b.started_at, b.finished_at,
+        COUNT(r.user_id) AS total,
+        COUNT(r.user_id) FILTER (WHERE r.status = 'sent') AS sent,
+        COUNT(r.user_id) FILTER (WHERE r.status = 'failed') AS failed,
+        COUNT(r.user_id) FILTER (WHERE r.status = 'blocked') AS blocked
+`
+
+// ValidSegment checks a segment name, including the status of "status:<status>"
+func ValidSegment(segment string) error {
+	switch {
+	case segment == SegmentAll, segment == SegmentActive:
+		return nil
+	case strings.HasPrefix(segment, SegmentStatusPrefix):
+		if _, err := ParseOrderStatus(strings.TrimPrefix(segment, SegmentStatusPrefix)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSegment, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidSegment, segment)
+}
+
+// segmentQuery selects the user IDs of a segment; $2 is the order status
+func segmentQuery(segment string) (string, []any) {
+	switch {
+	case segment == SegmentActive:
+		return `SELECT DISTINCT user_id FROM orders WHERE created_at >= NOW() - INTERVAL '30 days'`, nil
+	case strings.HasPrefix(segment, SegmentStatusPrefix):
+		return `SELECT user_id FROM orders WHERE status = $2
+            UNION SELECT user_id FROM orders_archive WHERE status = $2`,
+			[]any{strings.TrimPrefix(segment, SegmentStatusPrefix)}
+	default:
+		return `SELECT user_id FROM users UNION SELECT user_id FROM orders
+            UNION SELECT user_id FROM orders_archive`, nil
+	}
+}
+
+// CreateBroadcast saves a draft and fixes its recipients. Users who
+// blocked the bot are left out unless they have ordered since.
+func (s *PostgresStorage) CreateBroadcast(ctx context.Context, b *Broadcast) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := ValidSegment(b.Segment); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insert = `
+        INSERT INTO broadcasts (created_by, text, photo_file_id, buttons, segment)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, status, created_at
+    `
+	if err := tx.QueryRowxContext(ctx, insert, b.CreatedBy, b.Text, b.PhotoFileID, b.Buttons, b.Segment).
+		Scan(&b.ID, &b.Status, &b.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create broadcast: %w", err)
+	}
+
+	segment, segmentArgs := segmentQuery(b.Segment)
+	recipients := `
+        INSERT INTO broadcast_recipients (broadcast_id, user_id)
+        SELECT $1, c.user_id
+        FROM (` + segment + `) c
+        LEFT JOIN users u ON u.user_id = c.user_id
+        WHERE u.bot_blocked_at IS NULL
+           OR EXISTS (SELECT 1 FROM orders o WHERE o.user_id = c.user_id AND o.created_at > u.bot_blocked_at)
+    `
+	res, err := tx.ExecContext(ctx, recipients, append([]any{b.ID}, segmentArgs...)...)
+	if err != nil {
+		return fmt.Errorf("failed to select broadcast recipients: %w", err)
+	}
+	total, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count broadcast recipients: %w", err)
+	}
+	b.Total = int(total)
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit broadcast: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) GetBroadcast(ctx context.Context, id int64) (*Broadcast, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + broadcastColumns + `
+        FROM broadcasts b
+        LEFT JOIN broadcast_recipients r ON r.broadcast_id = b.id
+        WHERE b.id = $1
+        GROUP BY b.id
+    `
+
+	var b Broadcast
+	err := s.db.GetContext(ctx, &b, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBroadcastNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+	return &b, nil
+}
+
+// ListBroadcasts returns the latest broadcasts first
+func (s *PostgresStorage) ListBroadcasts(ctx context.Context, limit int) ([]Broadcast, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + broadcastColumns + `
+        FROM broadcasts b
+        LEFT JOIN broadcast_recipients r ON r.broadcast_id = b.id
+        GROUP BY b.id
+        ORDER BY b.id DESC
+        LIMIT $1
+    `
+
+	var broadcasts []Broadcast
+	if err := s.db.SelectContext(ctx, &broadcasts, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
+// StartBroadcast queues a draft for sending
+func (s *PostgresStorage) StartBroadcast(ctx context.Context, id int64) error {
+	return s.setBroadcastStatus(ctx, id, BroadcastSending, BroadcastDraft)
+}
+
+// CancelBroadcast stops a draft or a broadcast being sent; recipients
+// already reached stay counted
+func (s *PostgresStorage) CancelBroadcast(ctx context.Context, id int64) error {
+	return s.setBroadcastStatus(ctx, id, BroadcastCancelled, BroadcastDraft, BroadcastSending)
+}
+
+// FinishBroadcast marks a broadcast as sent to everyone
+func (s *PostgresStorage) FinishBroadcast(ctx context.Context, id int64) error {
+	return s.setBroadcastStatus(ctx, id, BroadcastDone, BroadcastSending)
+}
+
+// setBroadcastStatus moves a broadcast from one of the given statuses.
+// ErrBroadcastNotFound also covers a broadcast in any other status.
+func (s *PostgresStorage) setBroadcastStatus(ctx context.Context, id int64, to BroadcastStatus, from ...BroadcastStatus) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	fromStatuses := make([]string, len(from))
+	for i, status := range from {
+		fromStatuses[i] = string(status)
+	}
+
+	const query = `
+        UPDATE broadcasts
+        SET status = $2,
+            started_at = CASE WHEN $2 = 'sending' THEN NOW() ELSE started_at END,
+            finished_at = CASE WHEN $2 IN ('done', 'cancelled') THEN NOW() ELSE finished_at END
+        WHERE id = $1 AND status = ANY($3)
+    `
+
+	res, err := s.db.ExecContext(ctx, query, id, string(to), fromStatuses)
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrBroadcastNotFound
+	}
+	return nil
+}
+
+// NextSendingBroadcast returns the oldest broadcast being sent, nil if none
+func (s *PostgresStorage) NextSendingBroadcast(ctx context.Context) (*Broadcast, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + broadcastColumns + `
+        FROM broadcasts b
+        LEFT JOIN broadcast_recipients r ON r.broadcast_id = b.id
+        WHERE b.status = 'sending'
+        GROUP BY b.id
+        ORDER BY b.started_at, b.id
+        LIMIT 1
+    `
+
+	var b Broadcast
+	err := s.db.GetContext(ctx, &b, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sending broadcast: %w", err)
+	}
+	return &b, nil
+}
+
+func (s *PostgresStorage) PendingBroadcastRecipients(ctx context.Context, id int64, limit int) ([]int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT user_id FROM broadcast_recipients
+        WHERE broadcast_id = $1 AND status = 'pending'
+        ORDER BY user_id
+        LIMIT $2
+    `
+
+	var userIDs []int64
+	if err := s.db.SelectContext(ctx, &userIDs, query, id, limit); err != nil {
+		return nil, fmt.Errorf("failed to get broadcast recipients: %w", err)
+	}
+	return userIDs, nil
+}
+
+// MarkBroadcastRecipient records the delivery outcome. A blocked bot is
+// also noted on the user so later broadcasts skip them.
+func (s *PostgresStorage) MarkBroadcastRecipient(ctx context.Context, id, userID int64, status, deliveryErr string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const query = `
+        UPDATE broadcast_recipients
+        SET status = $3, error = $4, sent_at = CASE WHEN $3 = 'sent' THEN NOW() END
+        WHERE broadcast_id = $1 AND user_id = $2
+    `
+	if _, err := tx.ExecContext(ctx, query, id, userID, status, deliveryErr); err != nil {
+		return fmt.Errorf("failed to mark broadcast recipient: %w", err)
+	}
+
+	if status == RecipientBlocked {
+		const blocked = `
+            INSERT INTO users (user_id, bot_blocked_at)
+            VALUES ($1, NOW())
+            ON CONFLICT (user_id)
+            DO UPDATE SET bot_blocked_at = NOW()
+        `
+		if _, err := tx.ExecContext(ctx, blocked, userID); err != nil {
+			return fmt.Errorf("failed to mark user as blocked: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit broadcast recipient: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Customer is what the shop knows of one customer across all their orders
+type Customer struct {
+	UserID int64 `db:"user_id"`
+	// Username is the Telegram @username without the @, empty when the
+	// customer has none or hasn't written since the table was created
+	Username     string       `db:"username"`
+	FirstSeenAt  time.Time    `db:"first_seen_at"`
+	AgreedAt     *time.Time   `db:"agreed_at"`
+	FirstOrderAt *time.Time   `db:"first_order_at"`
+	LastOrderAt  *time.Time   `db:"last_order_at"`
+	Orders       int          `db:"orders"`
+	TotalSpend   money.Amount `db:"total_spend"`
+}
+
+// CustomersRepository maintains the customers table. Its figures are
+// derived from users, orders and orders_archive and can always be rebuilt
+// from them with Sync.
+type CustomersRepository struct {
+	storage *PostgresStorage
+}
+
+func (s *PostgresStorage) Customers() *CustomersRepository {
+	return &CustomersRepository{storage: s}
+}
+
+// refreshCustomersQuery recomputes the customer $1, or everyone when $1 is
+// NULL. Users who never ordered are customers too, with no orders.
+const refreshCustomersQuery = `
+        WITH placed AS (
+            SELECT user_id, created_at, status, price
+            FROM orders
+            WHERE deleted_at IS NULL
+              AND ($1::bigint IS NULL OR user_id = $1)
+            UNION ALL
+            SELECT user_id, created_at, status, (data->'order'->>'price')::numeric
+            FROM orders_archive
+            WHERE $1::bigint IS NULL OR user_id = $1
+        ), totals AS (
+            SELECT user_id,
+                   MIN(created_at) AS first_order_at,
+                   MAX(created_at) AS last_order_at,
+                   COUNT(*) FILTER (WHERE status <> 'cancelled') AS orders,
+                   COALESCE(SUM(price) FILTER (WHERE status <> 'cancelled'), 0) AS total_spend
+            FROM placed
+            GROUP BY user_id
+        ), known AS (
+            SELECT user_id, created_at, agreed_at
+            FROM users
+            WHERE $1::bigint IS NULL OR user_id = $1
+        )
+        INSERT INTO customers (user_id, first_seen_at, agreed_at, first_order_at, last_order_at, orders, total_spend)
+        SELECT COALESCE(k.user_id, t.user_id),
+               COALESCE(LEAST(k.created_at, t.first_order_at), NOW()),
+               k.agreed_at,
+               t.first_order_at,
+               t.last_order_at,
+               COALESCE(t.orders, 0),
+               COALESCE(t.total_spend, 0)
+        FROM known k
+        FULL JOIN totals t ON t.user_id = k.user_id
+        ON CONFLICT (user_id) DO UPDATE SET
+            first_seen_at = LEAST(customers.first_seen_at, EXCLUDED.first_seen_at),
+            agreed_at = EXCLUDED.agreed_at,
+            first_order_at = EXCLUDED.first_order_at,
+            last_order_at = EXCLUDED.last_order_at,
+            orders = EXCLUDED.orders,
+            total_spend = EXCLUDED.total_spend,
+            updated_at = NOW()
+    `
+
+// Refresh recomputes one customer after their orders changed
+func (r *CustomersRepository) Refresh(ctx context.Context, userID int64) error {
+	ctx, cancel := r.storage.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.storage.db.ExecContext(ctx, refreshCustomersQuery, userID); err != nil {
+		return fmt.Errorf("failed to refresh customer %d: %w", userID, err)
+	}
+	return nil
+}
+
+// Sync recomputes every customer and returns how many rows it wrote
+func (r *CustomersRepository) Sync(ctx context.Context) (int64, error) {
+	ctx, cancel := r.storage.detach(ctx)
+	defer cancel()
+
+	res, err := r.storage.db.ExecContext(ctx, refreshCustomersQuery, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync customers: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync customers: %w", err)
+	}
+	return n, nil
+}
+
+// Touch records the customer's current Telegram username. Only users the
+// bot has a record of become customers; the row is written only when the
+// username changed.
+func (r *CustomersRepository) Touch(ctx context.Context, userID int64, username string) error {
+	ctx, cancel := r.storage.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.storage.db.ExecContext(ctx, `
+        INSERT INTO customers (user_id, username, first_seen_at, agreed_at)
+        SELECT user_id, NULLIF($2, ''), created_at, agreed_at
+        FROM users
+        WHERE user_id = $1
+        ON CONFLICT (user_id) DO UPDATE SET
+            username = EXCLUDED.username,
+            updated_at = NOW()
+        WHERE customers.username IS DISTINCT FROM EXCLUDED.username
+    `, userID, username)
+	if err != nil {
+		return fmt.Errorf("failed to record username of %d: %w", userID, err)
+	}
+	return nil
+}
+
+// Top returns the customers who spent the most, at most limit of them
+func (r *CustomersRepository) Top(ctx context.Context, limit int) ([]Customer, error) {
+	ctx, cancel := r.storage.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT user_id, COALESCE(username, '') AS username, first_seen_at, agreed_at,
+               first_order_at, last_order_at, orders, total_spend
+        FROM customers
+        WHERE orders > 0
+        ORDER BY total_spend DESC, last_order_at DESC
+        LIMIT $1
+    `
+	var customers []Customer
+	err := r.storage.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &customers, query, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top customers: %w", err)
+	}
+	return customers, nil
+}
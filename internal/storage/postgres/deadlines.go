@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"time"
+)
+
+// Deadline reminder kinds
+const (
+	ReminderDueSoon = "due_soon"
+	ReminderOverdue = "overdue"
+)
+
+// DeadlineReminder is a reminder claimed for sending
+type DeadlineReminder struct {
+	OrderID int64       `db:"order_id"`
+	Kind    string      `db:"kind"`
+	DueAt   time.Time   `db:"due_at"`
+	Status  OrderStatus `db:"status"`
+	IsRush  bool        `db:"is_rush"`
+}
+
+// Deadline is when production must finish the order: the manager's
+// deadline, or the date promised to the customer
+func (o Order) Deadline() *time.Time {
+	if o.DueAt != nil {
+		return o.DueAt
+	}
+	return o.ReadyBy
+}
+
+// SetOrderDueAt sets the production deadline; nil falls back to ready_by
+func (s *PostgresStorage) SetOrderDueAt(ctx context.Context, orderID int64, dueAt *time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE orders SET due_at = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, orderID, dueAt)
+	if err != nil {
+		return fmt.Errorf("failed to set order deadline: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errs.ErrOrderNotFound
+	}
+	return nil
+}
+
+// ClaimDeadlineReminders records and returns the reminders due: open
+// orders whose deadline is within warning, or past. Each kind is claimed
+// once per deadline, so concurrent watchers don't send it twice.
+func (s *PostgresStorage) ClaimDeadlineReminders(ctx context.Context, warning time.Duration) ([]DeadlineReminder, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        WITH due AS (
+            SELECT id, COALESCE(due_at, ready_by) AS deadline, status, is_rush
+            FROM orders
+            WHERE status IN ('new', 'processing')
+              AND deleted_at IS NULL
+              AND COALESCE(due_at, ready_by) <= NOW() + $1 * INTERVAL '1 second'
+        ), claimed AS (
+            INSERT INTO order_deadline_reminders (order_id, kind, due_at)
+            SELECT id, CASE WHEN deadline <= NOW() THEN 'overdue' ELSE 'due_soon' END, deadline
+            FROM due
+            ON CONFLICT DO NOTHING
+            RETURNING order_id, kind, due_at
+        )
+        SELECT c.order_id, c.kind, c.due_at, d.status, d.is_rush
+        FROM claimed c
+        JOIN due d ON d.id = c.order_id
+        ORDER BY c.due_at
+    `
+
+	var reminders []DeadlineReminder
+	if err := s.db.SelectContext(ctx, &reminders, query, warning.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim deadline reminders: %w", err)
+	}
+	return reminders, nil
+}
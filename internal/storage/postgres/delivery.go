@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/pkg/money"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DeliveryPickup is the method of orders without a delivery row
+const DeliveryPickup = "pickup"
+
+// OrderDelivery is how an order reaches the customer. Cost is the charge
+// included in the order price.
+type OrderDelivery struct {
+	OrderID int64        `db:"order_id"`
+	Method  string       `db:"method"`
+	Address string       `db:"address"`
+	Cost    money.Amount `db:"cost"`
+}
+
+// String describes the delivery for exports and staff messages, e.g.
+// "courier: Lenina 1, apt. 5"
+func (d OrderDelivery) String() string {
+	if d.Address == "" {
+		return d.Method
+	}
+	return d.Method + ": " + d.Address
+}
+
+// DeliveryOrPickup returns the delivery of the order, pickup when it has none
+func (o Order) DeliveryOrPickup() OrderDelivery {
+	if o.Delivery != nil {
+		return *o.Delivery
+	}
+	return OrderDelivery{OrderID: o.ID, Method: DeliveryPickup}
+}
+
+// GetOrderDelivery returns how the order is delivered, pickup when it was
+// placed without a delivery
+func (s *PostgresStorage) GetOrderDelivery(ctx context.Context, orderID int64) (OrderDelivery, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	deliveries, err := getDeliveriesByOrder(ctx, s.db, []int64{orderID})
+	if err != nil {
+		return OrderDelivery{}, err
+	}
+	if d, ok := deliveries[orderID]; ok {
+		return *d, nil
+	}
+	return OrderDelivery{OrderID: orderID, Method: DeliveryPickup}, nil
+}
+
+// getDeliveriesByOrder loads the deliveries of several orders with one query
+func getDeliveriesByOrder(ctx context.Context, db sqlx.QueryerContext, orderIDs []int64) (map[int64]*OrderDelivery, error) {
+	const query = `
+        SELECT order_id, method, address, cost
+        FROM delivery
+        WHERE order_id = ANY($1)
+    `
+
+	byOrder := make(map[int64]*OrderDelivery, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return byOrder, nil
+	}
+
+	var deliveries []OrderDelivery
+	if err := sqlx.SelectContext(ctx, db, &deliveries, query, orderIDs); err != nil {
+		return nil, fmt.Errorf("failed to get order deliveries: %w", err)
+	}
+	for i := range deliveries {
+		byOrder[deliveries[i].OrderID] = &deliveries[i]
+	}
+	return byOrder, nil
+}
+
+// attachDeliveries fills Delivery of the given orders
+func attachDeliveries(ctx context.Context, db sqlx.QueryerContext, orders []Order) error {
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+
+	deliveries, err := getDeliveriesByOrder(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		orders[i].Delivery = deliveries[orders[i].ID]
+	}
+	return nil
+}
+
+func insertDelivery(ctx context.Context, tx *sqlx.Tx, orderID int64, d OrderDelivery) error {
+	const query = `
+        INSERT INTO delivery (order_id, method, address, cost)
+        VALUES ($1, $2, $3, $4)
+    `
+
+	if _, err := tx.ExecContext(ctx, query, orderID, d.Method, d.Address, d.Cost); err != nil {
+		return fmt.Errorf("failed to save order delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// driverName is the pgx database/sql driver; sqlx binds $N placeholders for it
+const driverName = "pgx"
+
+// registerConnConfig prepares a DSN for the pgx driver. With a positive
+// cache capacity statements are prepared once per connection and reused;
+// 0 sends every query unprepared, as needed behind PgBouncer in
+// transaction mode.
+func registerConnConfig(dsn string, statementCache int) (string, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid database config: %w", err)
+	}
+
+	if statementCache > 0 {
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+		connConfig.StatementCacheCapacity = statementCache
+	} else {
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	return stdlib.RegisterConnConfig(connConfig), nil
+}
+
+// openDB opens a pool on a registered config. otelsql emits a span per
+// query as a child of the caller's span.
+func openDB(connStr string) (*sqlx.DB, error) {
+	sqlDB, err := otelsql.Open(driverName, connStr,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sqlx.NewDb(sqlDB, driverName), nil
+}
+
+// pgError returns the server error behind err, if there is one
+func pgError(err error) (*pgconn.PgError, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr, true
+	}
+	return nil, false
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	pgErr, ok := pgError(err)
+	return ok && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// StringArray scans a text[] column. Through database/sql pgx hands arrays
+// over in their text form, which pgtype parses.
+type StringArray []string
+
+func (a *StringArray) Scan(src any) error {
+	var text []byte
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		text = []byte(src)
+	case []byte:
+		text = src
+	default:
+		return fmt.Errorf("cannot scan %T into StringArray", src)
+	}
+
+	var values []string
+	if err := pgtype.NewMap().Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, text, &values); err != nil {
+		return fmt.Errorf("failed to parse text array: %w", err)
+	}
+	*a = values
+	return nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type OrderEventType string
+
+const (
+	EventDraftStarted  OrderEventType = "draft_started"
+	EventQuoteShown    OrderEventType = "quote_shown"
+	EventConfirmed     OrderEventType = "confirmed"
+	EventQuoteApproved OrderEventType = "quote_approved"
+	EventPaid          OrderEventType = "paid"
+	EventRefunded      OrderEventType = "refunded"
+	EventChargedBack   OrderEventType = "charged_back"
+	EventProduced      OrderEventType = "produced"
+	EventShipped       OrderEventType = "shipped"
+	EventStatusChanged OrderEventType = "status_changed"
+)
+
+// OrderEvent is a single immutable entry of the order lifecycle log.
+// OrderID is nil for events that happen before the order row exists
+// (draft started, quote shown).
+type OrderEvent struct {
+	ID        int64           `db:"id"`
+	OrderID   *int64          `db:"order_id"`
+	UserID    int64           `db:"user_id"`
+	Type      OrderEventType  `db:"event_type"`
+	Payload   json.RawMessage `db:"payload"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// AppendOrderEvent writes an event to the append-only order_events log
+func (s *PostgresStorage) AppendOrderEvent(ctx context.Context, orderID *int64, userID int64, eventType OrderEventType, payload any) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return appendOrderEvent(ctx, s.db, orderID, userID, eventType, payload)
+}
+
+func appendOrderEvent(ctx context.Context, db sqlx.ExecerContext, orderID *int64, userID int64, eventType OrderEventType, payload any) error {
+	const query = `
+        INSERT INTO order_events (order_id, user_id, event_type, payload)
+        VALUES ($1, $2, $3, $4)
+    `
+
+	data := []byte("{}")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal event payload: %w", err)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, query, orderID, userID, eventType, data); err != nil {
+		return fmt.Errorf("failed to append order event: %w", err)
+	}
+
+	return nil
+}
+
+// appendStatusChange logs a status change in the transaction that makes
+// it. Completing an order also logs that it was produced.
+func appendStatusChange(ctx context.Context, db sqlx.ExecerContext, orderID, userID int64, prev, status OrderStatus) error {
+	if prev == status {
+		return nil
+	}
+	if err := appendOrderEvent(ctx, db, &orderID, userID, EventStatusChanged, map[string]any{
+		"status":      status,
+		"prev_status": prev,
+	}); err != nil {
+		return err
+	}
+	if status == StatusCompleted {
+		return appendOrderEvent(ctx, db, &orderID, userID, EventProduced, nil)
+	}
+	return nil
+}
+
+// GetOrderEvents returns the full timeline of an order in the order it happened
+func (s *PostgresStorage) GetOrderEvents(ctx context.Context, orderID int64) ([]OrderEvent, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, order_id, user_id, event_type, payload, created_at
+        FROM order_events
+        WHERE order_id = $1
+        ORDER BY id
+    `
+
+	var events []OrderEvent
+	if err := s.db.SelectContext(ctx, &events, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+
+	return events, nil
+}
+
+// ReplayOrderEvents streams every event recorded after the given cursor to fn.
+// It is used to rebuild projections (statistics, caches) from scratch.
+func (s *PostgresStorage) ReplayOrderEvents(ctx context.Context, afterID int64, fn func(OrderEvent) error) error {
+	const operation = "storage.ReplayOrderEvents"
+
+	const query = `
+        SELECT id, order_id, user_id, event_type, payload, created_at
+        FROM order_events
+        WHERE id > $1
+        ORDER BY id
+    `
+
+	rows, err := s.db.QueryxContext(ctx, query, afterID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to query events: %w", operation, err)
+	}
+	defer rows.Close()
+
+	var replayed int
+	for rows.Next() {
+		var event OrderEvent
+		if err := rows.StructScan(&event); err != nil {
+			return fmt.Errorf("%s: failed to scan event: %w", operation, err)
+		}
+		if err := fn(event); err != nil {
+			return fmt.Errorf("%s: event %d: %w", operation, event.ID, err)
+		}
+		replayed++
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	s.logger.Info("Order events replayed",
+		zap.Int64("after_id", afterID),
+		zap.Int("count", replayed))
+	return nil
+}
+
+// RebuildOrderStatistics recomputes status counters from the event log,
+// for when the Redis cache has been lost and must be restored.
+func (s *PostgresStorage) RebuildOrderStatistics(ctx context.Context) (map[string]int, error) {
+	statuses := make(map[int64]OrderStatus)
+
+	err := s.ReplayOrderEvents(ctx, 0, func(event OrderEvent) error {
+		if event.OrderID == nil {
+			return nil
+		}
+
+		switch event.Type {
+		case EventConfirmed:
+			// Orders confirmed before the status was logged started as new
+			payload := struct {
+				Status OrderStatus `json:"status"`
+			}{Status: StatusNew}
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				return fmt.Errorf("bad confirmation payload: %w", err)
+			}
+			statuses[*event.OrderID] = payload.Status
+		case EventStatusChanged:
+			var payload struct {
+				Status OrderStatus `json:"status"`
+			}
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				return fmt.Errorf("bad status payload: %w", err)
+			}
+			statuses[*event.OrderID] = payload.Status
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, status := range statuses {
+		counts[string(status)]++
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExperimentResult is how one variant of an experiment did
+type ExperimentResult struct {
+	Experiment string    `db:"experiment"`
+	Variant    string    `db:"variant"`
+	Exposed    int       `db:"exposed"`
+	Converted  int       `db:"converted"`
+	Since      time.Time `db:"since"`
+}
+
+// RecordExposure notes that the user was shown the variant. Only the first
+// exposure counts: later ones keep the variant and time recorded then.
+func (s *PostgresStorage) RecordExposure(ctx context.Context, experiment string, userID int64, variant string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO experiment_exposures (experiment, user_id, variant)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (experiment, user_id) DO NOTHING
+    `
+	if _, err := s.db.ExecContext(ctx, query, experiment, userID, variant); err != nil {
+		return fmt.Errorf("failed to record experiment exposure: %w", err)
+	}
+	return nil
+}
+
+// RecordConversion credits the order to every experiment the user was
+// exposed to and hasn't converted in yet. Returns how many were credited.
+func (s *PostgresStorage) RecordConversion(ctx context.Context, userID, orderID int64) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE experiment_exposures
+        SET converted_at = NOW(), order_id = $2
+        WHERE user_id = $1 AND converted_at IS NULL
+    `
+	res, err := s.db.ExecContext(ctx, query, userID, orderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record experiment conversion: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record experiment conversion: %w", err)
+	}
+	return n, nil
+}
+
+// GetExperimentResults counts the exposed and converted users of every
+// variant ever shown, by experiment and variant
+func (s *PostgresStorage) GetExperimentResults(ctx context.Context) ([]ExperimentResult, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT experiment, variant,
+               COUNT(*) AS exposed,
+               COUNT(converted_at) AS converted,
+               MIN(exposed_at) AS since
+        FROM experiment_exposures
+        GROUP BY experiment, variant
+        ORDER BY experiment, variant
+    `
+	var results []ExperimentResult
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &results, query)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment results: %w", err)
+	}
+	return results, nil
+}
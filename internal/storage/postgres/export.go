@@ -0,0 +1,552 @@
+package postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/units"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// ExportOptions controls how orders are rendered into spreadsheets
+type ExportOptions struct {
+	Locale i18n.Locale
+
+	// Anonymize replaces user IDs with stable pseudonyms and drops contact
+	// columns, so the file can be shared with external contractors
+	Anonymize bool
+	// AnonymizationKey is the secret used to derive pseudonyms. Keeping it
+	// stable keeps pseudonyms comparable between exports.
+	AnonymizationKey string
+
+	// Since and Until limit the export to orders created in [Since, Until).
+	// Zero values leave the range open.
+	Since time.Time
+	Until time.Time
+
+	// Files adds the customer files and texture photos of each order:
+	// links, a preview and the texture picture. Nil leaves them out.
+	Files ExportFiles
+
+	// Policy decides which columns the recipient may see. The zero value
+	// shows them all.
+	Policy ColumnPolicy
+
+	// Template picks, orders and renames the columns and filters orders by
+	// status. Nil exports the full layout.
+	Template *ReportTemplate
+
+	// Progress, when set, is called as orders are written with the number
+	// done so far and the total
+	Progress func(done, total int)
+}
+
+// ColumnPolicy decides which columns of an export its recipient may see
+type ColumnPolicy int
+
+const (
+	// ColumnsAll is for admins: every column, costs and margins included
+	ColumnsAll ColumnPolicy = iota
+	// ColumnsStaff leaves out costs, commission, tax and profit, for
+	// production staff and managers
+	ColumnsStaff
+	// ColumnsCustomer is a customer's view of their own orders: no internal
+	// figures either
+	ColumnsCustomer
+)
+
+// showsInternal reports whether costs and margins may be shown
+func (p ColumnPolicy) showsInternal() bool {
+	return p == ColumnsAll
+}
+
+// allows reports whether the column may be shown under the policy
+func (p ColumnPolicy) allows(c exportColumn) bool {
+	return p.showsInternal() || !c.internal
+}
+
+// filter returns the columns the policy allows, in their order
+func (p ColumnPolicy) filter(columns []exportColumn) []exportColumn {
+	if p.showsInternal() {
+		return columns
+	}
+	allowed := make([]exportColumn, 0, len(columns))
+	for _, column := range columns {
+		if p.allows(column) {
+			allowed = append(allowed, column)
+		}
+	}
+	return allowed
+}
+
+type exportColumn struct {
+	key      string
+	personal bool
+	// internal columns expose costs and margins, see ColumnPolicy
+	internal bool
+	value    func(Order) any
+	// header replaces the translated title, set by report templates
+	header string
+}
+
+// name is how report templates refer to the column
+func (c exportColumn) name() string {
+	return strings.TrimPrefix(c.key, "export.")
+}
+
+func (c exportColumn) title(locale i18n.Locale) string {
+	if c.header != "" {
+		return c.header
+	}
+	return i18n.T(locale, c.key)
+}
+
+var orderColumns = []exportColumn{
+	{key: "export.id", value: func(o Order) any { return o.ID }},
+	{key: "export.user_id", value: func(o Order) any { return o.UserID }},
+	{key: "export.width", value: func(o Order) any { return o.WidthCM }},
+	{key: "export.height", value: func(o Order) any { return o.HeightCM }},
+	{key: "export.texture_id", value: func(o Order) any { return o.TextureID }},
+	{key: "export.texture_name", value: func(o Order) any { return o.TextureName }},
+	{key: "export.price", value: func(o Order) any { return o.Price.Float() }},
+	{key: "export.leather_cost", internal: true, value: func(o Order) any { return o.LeatherCost.Float() }},
+	{key: "export.process_cost", internal: true, value: func(o Order) any { return o.ProcessCost.Float() }},
+	{key: "export.total_cost", internal: true, value: func(o Order) any { return o.TotalCost.Float() }},
+	{key: "export.commission", internal: true, value: func(o Order) any { return o.Commission.Float() }},
+	{key: "export.tax", internal: true, value: func(o Order) any { return o.Tax.Float() }},
+	{key: "export.net_revenue", internal: true, value: func(o Order) any { return o.NetRevenue.Float() }},
+	{key: "export.profit", internal: true, value: func(o Order) any { return o.Profit.Float() }},
+	{key: "export.contact", personal: true, value: func(o Order) any { return o.Contact }},
+	{key: "export.status", value: func(o Order) any { return o.Status }},
+	{key: "export.created_at", value: func(o Order) any { return o.CreatedAt.Format("2006-01-02 15:04") }},
+	{key: "export.rush", value: func(o Order) any { return o.IsRush }},
+	// Appended last so spreadsheets built on the single-product layout keep working
+	{key: "export.items", value: func(o Order) any { return describeItems(o.LineItems(), units.CM) }},
+	{key: "export.delivery", value: func(o Order) any { return o.DeliveryOrPickup().Method }},
+	{key: "export.address", personal: true, value: func(o Order) any { return o.DeliveryOrPickup().Address }},
+	{key: "export.shipping", value: func(o Order) any { return o.DeliveryOrPickup().Cost.Float() }},
+	{key: "export.currency", value: func(o Order) any { return string(o.Currency) }},
+	{key: "export.code", value: func(o Order) any { return o.Code }},
+	{key: "export.gift_card", value: func(o Order) any { return o.GiftCardAmount.Float() }},
+	{key: "export.prepayment", value: func(o Order) any { return o.Prepayment.Float() }},
+	{key: "export.paid", value: func(o Order) any { return o.PaidAmount.Float() }},
+}
+
+// orderExportColumns returns the sheet layout for the given options
+func orderExportColumns(opts ExportOptions) []exportColumn {
+	layout := orderColumns
+	if opts.Template != nil {
+		layout = templateColumns(opts.Template, opts.Locale)
+	}
+	layout = opts.Policy.filter(layout)
+	if !opts.Anonymize {
+		return layout
+	}
+
+	columns := make([]exportColumn, 0, len(layout))
+	for _, column := range layout {
+		switch {
+		case column.personal:
+			continue
+		case column.key == "export.user_id":
+			key := opts.AnonymizationKey
+			column.key = "export.user_pseudonym"
+			column.header = ""
+			column.value = func(o Order) any { return Pseudonymize(key, o.UserID) }
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// templateColumns picks the columns of the template in its order with
+// its titles. Columns the sheet no longer has are skipped.
+func templateColumns(t *ReportTemplate, locale i18n.Locale) []exportColumn {
+	columns := make([]exportColumn, 0, len(t.Columns))
+	for _, name := range t.Columns {
+		i := slices.IndexFunc(orderColumns, func(c exportColumn) bool { return c.name() == name })
+		if i < 0 {
+			continue
+		}
+		column := orderColumns[i]
+		column.header, _ = t.Headers.Title(locale, name)
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// customerExportColumns is the layout of a customer's own order history:
+// what ColumnsCustomer allows without the user ID or order ID, sizes in the
+// customer's unit. The order code takes the place of the ID.
+func customerExportColumns(unit units.Unit) []exportColumn {
+	allowed := ColumnsCustomer.filter(orderColumns)
+	columns := make([]exportColumn, 0, len(allowed))
+	for _, column := range allowed {
+		switch column.key {
+		case "export.user_id", "export.code":
+			continue
+		case "export.id":
+			column.key = "export.code"
+			column.value = func(o Order) any { return o.Code }
+		}
+		if unit != units.CM {
+			switch column.key {
+			case "export.width":
+				column.key = "export.width_" + string(unit)
+				column.value = func(o Order) any { return unit.FromCM(o.WidthCM) }
+			case "export.height":
+				column.key = "export.height_" + string(unit)
+				column.value = func(o Order) any { return unit.FromCM(o.HeightCM) }
+			case "export.items":
+				column.value = func(o Order) any { return describeItems(o.LineItems(), unit) }
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// OrderExportHeaders returns the column titles of the full orders sheet in the given locale
+func OrderExportHeaders(locale i18n.Locale) []string {
+	headers := make([]string, len(orderColumns))
+	for i, column := range orderColumns {
+		headers[i] = i18n.T(locale, column.key)
+	}
+	return headers
+}
+
+// OrderExportRow renders the order as a row of the full orders sheet, in
+// the order of OrderExportHeaders
+func OrderExportRow(o Order) []any {
+	row := make([]any, len(orderColumns))
+	for i, column := range orderColumns {
+		row[i] = column.value(o)
+	}
+	return row
+}
+
+// Pseudonymize derives a stable, non-reversible identifier for a user, the
+// same in anonymized exports and published events
+func Pseudonymize(key string, userID int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strconv.FormatInt(userID, 10)))
+	return "u_" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// exportPageSize is how many orders ExportAllOrdersToExcel holds in memory
+// at a time
+const exportPageSize = 1000
+
+const ordersSheet = "Orders"
+
+// ExportAllOrdersToExcel writes reports/<filename>.xlsx. Orders are read
+// page by page and streamed to the file, so the size of the table doesn't
+// matter. Exports with Files are the exception: pictures can't be added to
+// a streamed sheet, that one is built in memory.
+func (s *PostgresStorage) ExportAllOrdersToExcel(ctx context.Context, filename string, opts ExportOptions) error {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	const operation = "storage.ExportAllOrdersToExcel"
+
+	if opts.Anonymize && opts.AnonymizationKey == "" {
+		return fmt.Errorf("%s: anonymization key is not configured", operation)
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	index, err := f.NewSheet(ordersSheet)
+	if err != nil {
+		return fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	if opts.Files != nil {
+		err = s.writeOrdersWithFiles(ctx, f, opts)
+	} else {
+		err = s.streamOrders(ctx, f, opts)
+	}
+	if err != nil {
+		s.logger.Error("Failed to export orders",
+			zap.Error(err),
+			zap.String("operation", operation))
+		return err
+	}
+
+	f.SetActiveSheet(index)
+
+	if err := os.MkdirAll("reports", 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	path := fmt.Sprintf("reports/%s.xlsx", filename)
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save Excel file: %w", err)
+	}
+
+	return nil
+}
+
+// streamOrders writes the orders sheet one page at a time. The stream
+// writer keeps rows in a temporary file once they outgrow its buffer.
+func (s *PostgresStorage) streamOrders(ctx context.Context, f *excelize.File, opts ExportOptions) error {
+	total, err := s.countExportOrders(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(ordersSheet)
+	if err != nil {
+		return fmt.Errorf("failed to create stream writer: %w", err)
+	}
+
+	columns := orderExportColumns(opts)
+	values := make([]any, len(columns))
+	for col, column := range columns {
+		values[col] = column.title(opts.Locale)
+	}
+	if err := sw.SetRow("A1", values); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	row := 1
+	err = s.eachExportPage(ctx, opts, func(orders []Order) error {
+		for _, order := range orders {
+			row++
+			for col, column := range columns {
+				values[col] = column.value(order)
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, row)
+			if err := sw.SetRow(cell, values); err != nil {
+				return fmt.Errorf("failed to write row: %w", err)
+			}
+			if opts.Progress != nil {
+				opts.Progress(row-1, max(total, row-1))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush sheet: %w", err)
+	}
+	return nil
+}
+
+// writeOrdersWithFiles builds the sheet with the customer files and
+// pictures in memory. These exports are for the production team and
+// limited to maxExportPictures pictures anyway.
+func (s *PostgresStorage) writeOrdersWithFiles(ctx context.Context, f *excelize.File, opts ExportOptions) error {
+	var orders []Order
+	err := s.eachExportPage(ctx, opts, func(page []Order) error {
+		orders = append(orders, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var loaded *exportFiles
+	err = s.read(ctx, func(db sqlx.QueryerContext) error {
+		var err error
+		loaded, err = loadExportFiles(ctx, db, orders)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	columns := orderExportColumns(opts)
+	for col, column := range columns {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(ordersSheet, cell, column.title(opts.Locale))
+	}
+	for row, order := range orders {
+		for col, column := range columns {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
+			f.SetCellValue(ordersSheet, cell, column.value(order))
+		}
+	}
+
+	s.writeExportFiles(ctx, f, ordersSheet, len(columns)+1, orders, loaded, opts)
+	return nil
+}
+
+func (s *PostgresStorage) countExportOrders(ctx context.Context, opts ExportOptions) (int, error) {
+	const query = `
+        SELECT COUNT(*)
+        FROM orders o
+        WHERE ($1::timestamp IS NULL OR o.created_at >= $1)
+          AND ($2::timestamp IS NULL OR o.created_at < $2)
+          AND (cardinality($3::text[]) = 0 OR o.status = ANY($3))
+    `
+
+	since, until := exportRange(opts)
+	var total int
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.GetContext(ctx, db, &total, query, since, until, exportStatuses(opts))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+	return total, nil
+}
+
+// eachExportPage calls fn with the orders of the export range, newest
+// first, exportPageSize at a time with their items and deliveries. Pages
+// continue after the last order seen rather than at an offset, so each
+// one costs the same however deep into the table it is.
+func (s *PostgresStorage) eachExportPage(ctx context.Context, opts ExportOptions, fn func([]Order) error) error {
+	const query = `
+        SELECT o.*, t.name as texture_name
+        FROM orders o
+        LEFT JOIN textures t ON o.texture_id = t.id
+        WHERE ($1::timestamp IS NULL OR o.created_at >= $1)
+          AND ($2::timestamp IS NULL OR o.created_at < $2)
+          AND (cardinality($3::text[]) = 0 OR o.status = ANY($3))
+          AND ($4::timestamp IS NULL OR (o.created_at, o.id) < ($4, $5))
+        ORDER BY o.created_at DESC, o.id DESC
+        LIMIT $6
+    `
+
+	since, until := exportRange(opts)
+	statuses := exportStatuses(opts)
+	var afterAt *time.Time
+	var afterID int64
+
+	for {
+		var orders []Order
+		err := s.read(ctx, func(db sqlx.QueryerContext) error {
+			orders = orders[:0]
+			if err := sqlx.SelectContext(ctx, db, &orders, query, since, until, statuses, afterAt, afterID, exportPageSize); err != nil {
+				return fmt.Errorf("failed to fetch orders: %w", err)
+			}
+			if err := attachItems(ctx, db, orders); err != nil {
+				return err
+			}
+			return attachDeliveries(ctx, db, orders)
+		})
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		if err := fn(orders); err != nil {
+			return err
+		}
+		if len(orders) < exportPageSize {
+			return nil
+		}
+
+		last := orders[len(orders)-1]
+		afterAt, afterID = &last.CreatedAt, last.ID
+	}
+}
+
+// exportRange turns the zero bounds of opts into NULLs for the queries
+func exportRange(opts ExportOptions) (since, until *time.Time) {
+	if !opts.Since.IsZero() {
+		since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		until = &opts.Until
+	}
+	return since, until
+}
+
+// exportStatuses is the status filter of the template, empty for all
+func exportStatuses(opts ExportOptions) []string {
+	if opts.Template == nil || len(opts.Template.Statuses) == 0 {
+		return []string{}
+	}
+	return opts.Template.Statuses
+}
+
+// ExportUserOrdersToExcel writes the order history of one customer and
+// returns the file path. The caller removes the file once it is sent.
+func (s *PostgresStorage) ExportUserOrdersToExcel(ctx context.Context, userID int64) (string, error) {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT o.*, COALESCE(t.name, '') AS texture_name
+        FROM orders o
+        LEFT JOIN textures t ON o.texture_id = t.id
+        WHERE o.user_id = $1
+        ORDER BY o.created_at DESC
+    `
+
+	var orders []Order
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		if err := sqlx.SelectContext(ctx, db, &orders, query, userID); err != nil {
+			return fmt.Errorf("failed to fetch user orders: %w", err)
+		}
+		if err := attachItems(ctx, db, orders); err != nil {
+			return err
+		}
+		if err := attachDeliveries(ctx, db, orders); err != nil {
+			return err
+		}
+		archived, err := getArchivedOrders(ctx, db, userID)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, archived...)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	locale, err := s.GetUserLocale(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get user locale for export", zap.Error(err))
+	}
+	unit, err := s.GetUserUnit(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get user unit for export", zap.Error(err))
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Sheet1"
+	columns := customerExportColumns(unit)
+	for col, column := range columns {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(sheet, cell, i18n.T(locale, column.key))
+	}
+	for row, order := range orders {
+		for col, column := range columns {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
+			f.SetCellValue(sheet, cell, column.value(order))
+		}
+	}
+
+	if err := os.MkdirAll("reports", 0755); err != nil {
+		return "", fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	path := fmt.Sprintf("reports/user_%d_%s.xlsx", userID, time.Now().Format("20060102_150405"))
+	if err := f.SaveAs(path); err != nil {
+		return "", fmt.Errorf("failed to save Excel file: %w", err)
+	}
+
+	return path, nil
+}
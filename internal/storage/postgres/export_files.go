@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// maxExportPictures caps the pictures embedded in one export; past it the
+// rows keep their links only, so a yearly export stays a file one can open
+const maxExportPictures = 300
+
+const (
+	pictureRowHeight   = 75
+	pictureColumnWidth = 16
+	filesColumnWidth   = 60
+)
+
+// ExportFiles fetches what the file columns of an orders export show
+type ExportFiles interface {
+	// Link returns a download link to the original of an attachment, ""
+	// for files kept by Telegram only
+	Link(a Attachment) string
+	// Picture returns an attachment as an image to embed with its
+	// extension, nil when it isn't an image or can't be fetched
+	Picture(ctx context.Context, a Attachment) ([]byte, string)
+	// TexturePhoto returns a photo of the texture gallery, nil when it
+	// can't be fetched
+	TexturePhoto(ctx context.Context, fileID string) ([]byte, string)
+}
+
+// exportFiles is what an export needs to fill its file columns
+type exportFiles struct {
+	attachments map[int64][]Attachment
+	// photos holds the first gallery photo of each texture
+	photos map[string]string
+}
+
+func loadExportFiles(ctx context.Context, db sqlx.QueryerContext, orders []Order) (*exportFiles, error) {
+	ids := make([]int64, 0, len(orders))
+	var textureIDs []string
+	seen := make(map[string]bool)
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+		if id := exportTexture(o); id != "" && !seen[id] {
+			seen[id] = true
+			textureIDs = append(textureIDs, id)
+		}
+	}
+
+	var attachments []Attachment
+	if err := sqlx.SelectContext(ctx, db, &attachments, `
+        SELECT id, user_id, order_id, tg_file_id, tg_unique_id, object_key,
+               content_type, size_bytes, sha256, transcript, created_at
+        FROM attachments
+        WHERE order_id = ANY($1)
+        ORDER BY id
+    `, ids); err != nil {
+		return nil, fmt.Errorf("failed to fetch order attachments: %w", err)
+	}
+
+	var photos []struct {
+		TextureID string `db:"texture_id"`
+		FileID    string `db:"file_id"`
+	}
+	if err := sqlx.SelectContext(ctx, db, &photos, `
+        SELECT DISTINCT ON (texture_id) texture_id::text AS texture_id, file_id
+        FROM texture_photos
+        WHERE texture_id::text = ANY($1)
+        ORDER BY texture_id, position, id
+    `, textureIDs); err != nil {
+		return nil, fmt.Errorf("failed to fetch texture photos: %w", err)
+	}
+
+	files := &exportFiles{
+		attachments: make(map[int64][]Attachment),
+		photos:      make(map[string]string, len(photos)),
+	}
+	for _, a := range attachments {
+		files.attachments[*a.OrderID] = append(files.attachments[*a.OrderID], a)
+	}
+	for _, p := range photos {
+		files.photos[p.TextureID] = p.FileID
+	}
+	return files, nil
+}
+
+// exportTexture is the texture shown for an order: the one of its first item
+func exportTexture(o Order) string {
+	if items := o.LineItems(); len(items) > 0 {
+		return items[0].TextureID
+	}
+	return o.TextureID
+}
+
+// writeExportFiles fills the file columns starting at col: links to the
+// customer files, a preview of the first image among them and a photo of
+// the texture
+func (s *PostgresStorage) writeExportFiles(ctx context.Context, f *excelize.File, sheet string, col int, orders []Order, loaded *exportFiles, opts ExportOptions) {
+	filesCol, _ := excelize.ColumnNumberToName(col)
+	lastCol, _ := excelize.ColumnNumberToName(col + 2)
+	previewCol, _ := excelize.ColumnNumberToName(col + 1)
+
+	for i, key := range []string{"export.files", "export.preview", "export.texture_photo"} {
+		cell, _ := excelize.CoordinatesToCellName(col+i, 1)
+		f.SetCellValue(sheet, cell, i18n.T(opts.Locale, key))
+	}
+	f.SetColWidth(sheet, filesCol, filesCol, filesColumnWidth)
+	f.SetColWidth(sheet, previewCol, lastCol, pictureColumnWidth)
+
+	wrap, err := f.NewStyle(&excelize.Style{Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"}})
+	if err != nil {
+		s.logger.Warn("Failed to create export style", zap.Error(err))
+	}
+
+	// Texture photos repeat across orders; each is fetched once
+	texturePictures := make(map[string][]byte)
+	textureExt := make(map[string]string)
+	pictures := 0
+
+	for i, order := range orders {
+		if opts.Progress != nil {
+			opts.Progress(i, len(orders))
+		}
+		row := i + 2
+		attachments := loaded.attachments[order.ID]
+
+		lines := make([]string, 0, len(attachments))
+		var firstLink string
+		for _, a := range attachments {
+			if link := opts.Files.Link(a); link != "" {
+				lines = append(lines, link)
+				if firstLink == "" {
+					firstLink = link
+				}
+				continue
+			}
+			lines = append(lines, i18n.T(opts.Locale, "export.file_telegram", a.ContentType))
+		}
+		cell, _ := excelize.CoordinatesToCellName(col, row)
+		f.SetCellValue(sheet, cell, strings.Join(lines, "\n"))
+		if wrap != 0 {
+			f.SetCellStyle(sheet, cell, cell, wrap)
+		}
+		if firstLink != "" {
+			f.SetCellHyperLink(sheet, cell, firstLink, "External")
+		}
+
+		if pictures >= maxExportPictures {
+			continue
+		}
+
+		embedded := false
+		for _, a := range attachments {
+			if !strings.HasPrefix(a.ContentType, "image/") {
+				continue
+			}
+			data, ext := opts.Files.Picture(ctx, a)
+			if data == nil {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			embedded = s.embedPicture(f, sheet, cell, data, ext, order.ID) || embedded
+			break
+		}
+
+		if fileID, ok := loaded.photos[exportTexture(order)]; ok {
+			if _, fetched := texturePictures[fileID]; !fetched {
+				texturePictures[fileID], textureExt[fileID] = opts.Files.TexturePhoto(ctx, fileID)
+			}
+			if data := texturePictures[fileID]; data != nil {
+				cell, _ := excelize.CoordinatesToCellName(col+2, row)
+				embedded = s.embedPicture(f, sheet, cell, data, textureExt[fileID], order.ID) || embedded
+			}
+		}
+
+		if embedded {
+			pictures++
+			f.SetRowHeight(sheet, row, pictureRowHeight)
+		}
+	}
+
+	if opts.Progress != nil {
+		opts.Progress(len(orders), len(orders))
+	}
+
+	if pictures >= maxExportPictures {
+		s.logger.Warn("Export picture limit reached, later rows have links only",
+			zap.Int("limit", maxExportPictures))
+	}
+}
+
+// embedPicture fits the image into the cell and reports whether it could
+func (s *PostgresStorage) embedPicture(f *excelize.File, sheet, cell string, data []byte, ext string, orderID int64) bool {
+	err := f.AddPictureFromBytes(sheet, cell, &excelize.Picture{
+		Extension: ext,
+		File:      data,
+		Format: &excelize.GraphicOptions{
+			AutoFit:         true,
+			LockAspectRatio: true,
+			Positioning:     "oneCell",
+		},
+	})
+	if err != nil {
+		s.logger.Warn("Failed to embed export picture", zap.Int64("order_id", orderID), zap.Error(err))
+		return false
+	}
+	return true
+}
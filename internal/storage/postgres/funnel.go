@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The ends of every flow in the funnel; the steps between are the dialog's
+const (
+	FunnelStarted = "started"
+	FunnelPlaced  = "placed"
+)
+
+// FunnelStep is how many users reached a step of a flow
+type FunnelStep struct {
+	Flow  string `db:"flow"`
+	Step  string `db:"step"`
+	Users int    `db:"users"`
+}
+
+// RecordDialogStep notes that the user reached a step of an order flow.
+// Starting one is also logged in order_events as a draft started.
+func (s *PostgresStorage) RecordDialogStep(ctx context.Context, userID int64, flow, step string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const query = `INSERT INTO events (user_id, flow, step) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, userID, flow, step); err != nil {
+		return fmt.Errorf("failed to record dialog step: %w", err)
+	}
+
+	// A new draft starts the order lifecycle, before the order exists
+	if step == FunnelStarted {
+		if err := appendOrderEvent(ctx, tx, nil, userID, EventDraftStarted, map[string]any{
+			"flow": flow,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit dialog step: %w", err)
+	}
+	return nil
+}
+
+// GetFunnel counts the users who reached each step within [from, to). The
+// steps of a flow come in funnel order: by users, most first, and by when
+// they were first reached for steps with as many.
+func (s *PostgresStorage) GetFunnel(ctx context.Context, from, to time.Time) ([]FunnelStep, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT flow, step, COUNT(DISTINCT user_id) AS users
+        FROM events
+        WHERE created_at >= $1 AND created_at < $2
+        GROUP BY flow, step
+        ORDER BY flow, users DESC, MIN(created_at)
+    `
+	var steps []FunnelStep
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &steps, query, from, to)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funnel: %w", err)
+	}
+	return steps, nil
+}
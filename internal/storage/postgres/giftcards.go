@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GiftCardStatus is where a card is in its life
+type GiftCardStatus string
+
+const (
+	// GiftCardRequested is a customer's request to buy a card, waiting for
+	// payment; it has no code yet
+	GiftCardRequested GiftCardStatus = "requested"
+	GiftCardActive    GiftCardStatus = "active"
+	GiftCardVoid      GiftCardStatus = "void"
+)
+
+var (
+	ErrGiftCardNotFound = errors.New("gift card not found")
+	ErrGiftCardExpired  = errors.New("gift card expired")
+	ErrGiftCardVoid     = errors.New("gift card voided")
+	ErrGiftCardEmpty    = errors.New("gift card has no balance left")
+	// ErrGiftCardBalance means the card was spent elsewhere after the quote
+	ErrGiftCardBalance = errors.New("gift card balance changed")
+	// ErrGiftCardNotRequested is returned when issuing a request that was
+	// already issued or voided
+	ErrGiftCardNotRequested = errors.New("gift card is not a pending request")
+)
+
+type GiftCard struct {
+	ID       int64          `db:"id"`
+	CodeHint string         `db:"code_hint"`
+	Amount   money.Amount   `db:"amount"`
+	Balance  money.Amount   `db:"balance"`
+	Currency money.Currency `db:"currency"`
+	Status   GiftCardStatus `db:"status"`
+	// BuyerID is the customer who requested the card, nil for cards
+	// issued by staff on their own
+	BuyerID   *int64     `db:"buyer_id"`
+	IssuedBy  *int64     `db:"issued_by"`
+	CreatedAt time.Time  `db:"created_at"`
+	IssuedAt  *time.Time `db:"issued_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	VoidedAt  *time.Time `db:"voided_at"`
+}
+
+const giftCardColumns = `id, COALESCE(code_hint, '') AS code_hint, amount, balance, currency, status,
+               buyer_id, issued_by, created_at, issued_at, expires_at, voided_at`
+
+// RequestGiftCard records a customer's wish to buy a card of the amount
+func (s *PostgresStorage) RequestGiftCard(ctx context.Context, buyerID int64, amount money.Amount, currency money.Currency) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO gift_cards (amount, balance, currency, buyer_id)
+        VALUES ($1, $1, $2, $3)
+        RETURNING id
+    `
+
+	var id int64
+	if err := s.db.QueryRowContext(ctx, query, amount, currency, buyerID).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to request gift card: %w", err)
+	}
+	return id, nil
+}
+
+// IssueGiftCard activates a requested card with the hash of its code
+func (s *PostgresStorage) IssueGiftCard(ctx context.Context, id int64, codeHash, codeHint string, issuedBy int64, expiresAt time.Time) (*GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        UPDATE gift_cards
+        SET status = 'active', code_hash = $2, code_hint = $3, issued_by = $4,
+            issued_at = NOW(), expires_at = $5
+        WHERE id = $1 AND status = 'requested'
+        RETURNING ` + giftCardColumns
+
+	var card GiftCard
+	err := s.db.GetContext(ctx, &card, query, id, codeHash, codeHint, issuedBy, expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGiftCardNotRequested
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue gift card: %w", err)
+	}
+	return &card, nil
+}
+
+// CreateGiftCard issues a card nobody requested, e.g. one sold at the
+// counter
+func (s *PostgresStorage) CreateGiftCard(ctx context.Context, amount money.Amount, currency money.Currency, codeHash, codeHint string, issuedBy int64, expiresAt time.Time) (*GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        INSERT INTO gift_cards (code_hash, code_hint, amount, balance, currency, status, issued_by, issued_at, expires_at)
+        VALUES ($1, $2, $3, $3, $4, 'active', $5, NOW(), $6)
+        RETURNING ` + giftCardColumns
+
+	var card GiftCard
+	if err := s.db.GetContext(ctx, &card, query, codeHash, codeHint, amount, currency, issuedBy, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create gift card: %w", err)
+	}
+	return &card, nil
+}
+
+// VoidGiftCard cancels a card or a request; its balance can no longer be
+// spent. Orders already paid with it keep their payment.
+func (s *PostgresStorage) VoidGiftCard(ctx context.Context, id int64) (*GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        UPDATE gift_cards
+        SET status = 'void', voided_at = NOW()
+        WHERE id = $1 AND status <> 'void'
+        RETURNING ` + giftCardColumns
+
+	var card GiftCard
+	err := s.db.GetContext(ctx, &card, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to void gift card: %w", err)
+	}
+	return &card, nil
+}
+
+func (s *PostgresStorage) GetGiftCard(ctx context.Context, id int64) (*GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var card GiftCard
+	err := s.db.GetContext(ctx, &card, `SELECT `+giftCardColumns+` FROM gift_cards WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+	return &card, nil
+}
+
+func (s *PostgresStorage) GetGiftCardByHash(ctx context.Context, codeHash string) (*GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var card GiftCard
+	err := s.db.GetContext(ctx, &card, `SELECT `+giftCardColumns+` FROM gift_cards WHERE code_hash = $1`, codeHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+	return &card, nil
+}
+
+// ListGiftCards returns the pending requests, oldest first, then the most
+// recent other cards
+func (s *PostgresStorage) ListGiftCards(ctx context.Context, limit int) ([]GiftCard, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        SELECT ` + giftCardColumns + `
+        FROM gift_cards
+        ORDER BY status = 'requested' DESC,
+                 CASE WHEN status = 'requested' THEN created_at END,
+                 created_at DESC
+        LIMIT $1
+    `
+
+	var cards []GiftCard
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &cards, query, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gift cards: %w", err)
+	}
+	return cards, nil
+}
+
+// redeemGiftCard takes the amount off the card for the order. The balance
+// is checked again under the row lock, so two orders can't spend it twice.
+func redeemGiftCard(ctx context.Context, tx *sqlx.Tx, cardID, orderID int64, amount money.Amount) error {
+	res, err := tx.ExecContext(ctx, `
+        UPDATE gift_cards
+        SET balance = balance - $2
+        WHERE id = $1 AND status = 'active' AND balance >= $2
+          AND (expires_at IS NULL OR expires_at > NOW())
+    `, cardID, amount)
+	if err != nil {
+		return fmt.Errorf("failed to redeem gift card: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to redeem gift card: %w", err)
+	} else if n == 0 {
+		return ErrGiftCardBalance
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO gift_card_redemptions (order_id, gift_card_id, amount)
+        VALUES ($1, $2, $3)
+    `, orderID, cardID, amount); err != nil {
+		return fmt.Errorf("failed to record gift card redemption: %w", err)
+	}
+	return nil
+}
+
+// refundGiftCard puts what a cancelled order took back on its card, even
+// one voided or expired since: the customer paid for it. It runs in the
+// transaction of the status change, see recordStatusChange; a redemption
+// is refunded once however often the order is cancelled.
+func refundGiftCard(ctx context.Context, tx sqlx.ExecerContext, orderID int64) error {
+	const query = `
+        WITH refunded AS (
+            UPDATE gift_card_redemptions
+            SET refunded_at = NOW()
+            WHERE order_id = $1 AND refunded_at IS NULL
+            RETURNING gift_card_id, amount
+        )
+        UPDATE gift_cards c
+        SET balance = c.balance + r.amount
+        FROM refunded r
+        WHERE c.id = r.gift_card_id
+    `
+	if _, err := tx.ExecContext(ctx, query, orderID); err != nil {
+		return fmt.Errorf("failed to refund gift card: %w", err)
+	}
+	return nil
+}
+
+// GetGiftCardRefund returns what the order put back on its gift card when
+// it was cancelled, 0 for orders not paid with a card or not refunded
+func (s *PostgresStorage) GetGiftCardRefund(ctx context.Context, orderID int64) (money.Amount, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var amount money.Amount
+	err := s.db.GetContext(ctx, &amount, `
+        SELECT amount FROM gift_card_redemptions
+        WHERE order_id = $1 AND refunded_at IS NOT NULL
+    `, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get gift card refund: %w", err)
+	}
+	return amount, nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+// UserOrderCounters are the per-user figures the fraud checks work with
+type UserOrderCounters struct {
+	Total           int `db:"total"`
+	Open            int `db:"open"`
+	RecentHighValue int `db:"recent_high_value"`
+}
+
+func (s *PostgresStorage) GetUserOrderCounters(ctx context.Context, userID int64, since time.Time, highValue float64) (*UserOrderCounters, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT
+            COUNT(*) AS total,
+            COUNT(*) FILTER (WHERE status IN ('new', 'processing', 'on_hold', 'awaiting_prepayment', 'pending_quote')) AS open,
+            COUNT(*) FILTER (WHERE created_at >= $2 AND price >= $3) AS recent_high_value
+        FROM orders
+        WHERE user_id = $1 AND deleted_at IS NULL
+    `
+
+	var counters UserOrderCounters
+	if err := s.db.GetContext(ctx, &counters, query, userID, since, highValue); err != nil {
+		return nil, fmt.Errorf("failed to get user order counters: %w", err)
+	}
+	return &counters, nil
+}
+
+// ResolveHold releases (approve) or cancels (reject) a held order and
+// returns its new status. A released order whose price a manager is to
+// approve waits for that, one with a deposit still to pay waits for it,
+// instead of going to production.
+func (s *PostgresStorage) ResolveHold(ctx context.Context, orderID, adminID int64, approve bool) (OrderStatus, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE order_holds SET resolved_by = $2, resolved_at = NOW(), approved = $3
+        WHERE order_id = $1 AND resolved_at IS NULL
+    `, orderID, adminID, approve)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve hold: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", fmt.Errorf("order %d has no open hold", orderID)
+	}
+
+	var held struct {
+		UserID     int64        `db:"user_id"`
+		Status     OrderStatus  `db:"status"`
+		Prepayment money.Amount `db:"prepayment"`
+		PaidAmount money.Amount `db:"paid_amount"`
+		QuoteDue   bool         `db:"quote_due"`
+	}
+	if err := tx.GetContext(ctx, &held, `
+        SELECT user_id, status, prepayment, paid_amount,
+               EXISTS (SELECT 1 FROM quote_reviews q WHERE q.order_id = o.id AND q.resolved_at IS NULL) AS quote_due
+        FROM orders o WHERE id = $1
+    `, orderID); err != nil {
+		return "", fmt.Errorf("failed to load held order: %w", err)
+	}
+
+	status := StatusCancelled
+	switch {
+	case approve && held.QuoteDue:
+		status = StatusPendingQuote
+	case approve && held.PaidAmount < held.Prepayment:
+		status = StatusAwaitingPrepayment
+	case approve:
+		status = StatusNew
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status = $2, updated_at = NOW() WHERE id = $1`, orderID, status); err != nil {
+		return "", fmt.Errorf("failed to release order: %w", err)
+	}
+	if err := recordStatusChange(ctx, tx, orderID, held.UserID, held.Status, status); err != nil {
+		return "", err
+	}
+
+	var textureIDs []string
+	switch status {
+	case StatusNew:
+		// Held orders were not announced yet
+		if err := enqueueOutbox(ctx, tx, OutboxOrderCreated, OrderOutboxPayload{
+			OrderID: orderID,
+			UserID:  held.UserID,
+			Status:  status,
+		}); err != nil {
+			return "", err
+		}
+	case StatusCancelled:
+		if textureIDs, err = releaseStock(ctx, tx, orderID); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit hold resolution: %w", err)
+	}
+
+	for _, textureID := range textureIDs {
+		s.invalidateTextureCache(ctx, textureID)
+	}
+	return status, nil
+}
@@ -0,0 +1,275 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+const importBatchSize = 500
+
+var contactPattern = regexp.MustCompile(`^\+[0-9]{10,15}$`)
+
+// ImportRowError describes why a spreadsheet row was skipped.
+// Row is the 1-based row number as shown in Excel.
+type ImportRowError struct {
+	Row int
+	Err string
+}
+
+type ImportReport struct {
+	Total    int
+	Imported int
+	Errors   []ImportRowError
+}
+
+// ImportOrdersFromExcel loads orders from a spreadsheet with the same layout as
+// ExportAllOrdersToExcel. Invalid rows are reported and skipped; valid rows are
+// inserted in batches inside a single transaction.
+func (s *PostgresStorage) ImportOrdersFromExcel(ctx context.Context, r io.Reader) (*ImportReport, error) {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	const operation = "storage.ImportOrdersFromExcel"
+
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open workbook: %w", operation, err)
+	}
+	defer f.Close()
+
+	sheet := "Orders"
+	if idx, _ := f.GetSheetIndex(sheet); idx < 0 {
+		sheet = f.GetSheetName(0)
+	}
+
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read rows: %w", operation, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: sheet %q is empty", operation, sheet)
+	}
+
+	columns, err := mapImportHeaders(rows[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	textureIDs, err := s.textureIDSet(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	report := &ImportReport{}
+	var valid []Order
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		if isBlankRow(row) {
+			continue
+		}
+		report.Total++
+
+		order, err := parseImportRow(row, columns)
+		if err == nil && !textureIDs[order.TextureID] {
+			err = fmt.Errorf("unknown texture %q", order.TextureID)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, Err: err.Error()})
+			continue
+		}
+
+		if order.Currency == "" {
+			order.Currency = s.currency
+		}
+		valid = append(valid, order)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	const query = `
+        INSERT INTO orders (
+            user_id, width_cm, height_cm, texture_id, price,
+            leather_cost, process_cost, total_cost, commission,
+            tax, net_revenue, profit, contact, status, created_at, is_rush, currency
+        ) VALUES (
+            :user_id, :width_cm, :height_cm, :texture_id, :price,
+            :leather_cost, :process_cost, :total_cost, :commission,
+            :tax, :net_revenue, :profit, :contact, :status, :created_at, :is_rush, :currency
+        )
+    `
+
+	for start := 0; start < len(valid); start += importBatchSize {
+		end := min(start+importBatchSize, len(valid))
+		if _, err := tx.NamedExecContext(ctx, query, valid[start:end]); err != nil {
+			return nil, fmt.Errorf("%s: failed to insert batch at row %d: %w", operation, start, periodLockError(err))
+		}
+	}
+
+	if err := backfillOrderItems(ctx, tx); err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit import: %w", operation, err)
+	}
+
+	report.Imported = len(valid)
+
+	s.logger.Info("Orders imported",
+		zap.Int("total", report.Total),
+		zap.Int("imported", report.Imported),
+		zap.Int("rejected", len(report.Errors)))
+
+	return report, nil
+}
+
+// mapImportHeaders resolves header titles in any supported locale to column keys
+func mapImportHeaders(header []string) (map[string]int, error) {
+	known := make(map[string]string)
+	for _, locale := range i18n.Supported() {
+		for _, column := range orderColumns {
+			known[strings.ToLower(i18n.T(locale, column.key))] = column.key
+		}
+	}
+
+	columns := make(map[string]int)
+	for i, title := range header {
+		if key, ok := known[strings.ToLower(strings.TrimSpace(title))]; ok {
+			columns[key] = i
+		}
+	}
+
+	for _, required := range []string{"export.user_id", "export.width", "export.height", "export.texture_id", "export.price", "export.contact"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", i18n.T(i18n.Default, required))
+		}
+	}
+
+	return columns, nil
+}
+
+func parseImportRow(row []string, columns map[string]int) (Order, error) {
+	cell := func(key string) string {
+		i, ok := columns[key]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var (
+		order Order
+		errs  []string
+	)
+
+	parseInt := func(key string) int64 {
+		v, err := strconv.ParseInt(cell(key), 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: not a number", key))
+		}
+		return v
+	}
+	parseMoney := func(key string) money.Amount {
+		raw := cell(key)
+		if raw == "" {
+			return 0
+		}
+		v, err := money.Parse(strings.ReplaceAll(raw, ",", "."))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: not a number", key))
+		}
+		return v
+	}
+
+	order.UserID = parseInt("export.user_id")
+	order.WidthCM = int(parseInt("export.width"))
+	order.HeightCM = int(parseInt("export.height"))
+	order.TextureID = cell("export.texture_id")
+	order.Price = parseMoney("export.price")
+	order.LeatherCost = parseMoney("export.leather_cost")
+	order.ProcessCost = parseMoney("export.process_cost")
+	order.TotalCost = parseMoney("export.total_cost")
+	order.Commission = parseMoney("export.commission")
+	order.Tax = parseMoney("export.tax")
+	order.NetRevenue = parseMoney("export.net_revenue")
+	order.Profit = parseMoney("export.profit")
+	order.Currency = money.Currency(strings.ToUpper(cell("export.currency")))
+	if order.Currency != "" && !order.Currency.Valid() {
+		errs = append(errs, fmt.Sprintf("unknown currency %q", order.Currency))
+	}
+	order.Contact = cell("export.contact")
+	order.IsRush, _ = strconv.ParseBool(cell("export.rush"))
+
+	order.Status = StatusCompleted
+	if raw := cell("export.status"); raw != "" {
+		status, err := ParseOrderStatus(raw)
+		if err != nil || status == StatusOnHold || status == StatusAwaitingPrepayment || status == StatusPendingQuote {
+			errs = append(errs, fmt.Sprintf("unknown status %q", raw))
+		}
+		order.Status = status
+	}
+
+	order.CreatedAt = time.Now()
+	if raw := cell("export.created_at"); raw != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04", raw, time.Local)
+		if err != nil {
+			errs = append(errs, "created_at: expected YYYY-MM-DD HH:MM")
+		}
+		order.CreatedAt = t
+	}
+
+	switch {
+	case order.UserID <= 0:
+		errs = append(errs, "user_id must be positive")
+	case order.WidthCM < 0 || order.WidthCM > 80:
+		errs = append(errs, "width must be within 0..80 cm")
+	case order.HeightCM < 0 || order.HeightCM > 50:
+		errs = append(errs, "height must be within 0..50 cm")
+	case order.Price <= 0:
+		errs = append(errs, "price must be positive")
+	case !contactPattern.MatchString(order.Contact):
+		errs = append(errs, "contact must be a phone number like +79991234567")
+	}
+
+	if len(errs) > 0 {
+		return Order{}, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return order, nil
+}
+
+func (s *PostgresStorage) textureIDSet(ctx context.Context) (map[string]bool, error) {
+	var ids []string
+	if err := s.db.SelectContext(ctx, &ids, `SELECT id::text FROM textures`); err != nil {
+		return nil, fmt.Errorf("failed to load texture ids: %w", err)
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
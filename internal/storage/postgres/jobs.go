@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+type Job struct {
+	ID          int64           `db:"id"`
+	Kind        string          `db:"kind"`
+	Payload     json.RawMessage `db:"payload"`
+	Status      JobStatus       `db:"status"`
+	Attempts    int             `db:"attempts"`
+	MaxAttempts int             `db:"max_attempts"`
+	LastError   string          `db:"last_error"`
+	RequestedBy int64           `db:"requested_by"`
+	RunAt       time.Time       `db:"run_at"`
+	CreatedAt   time.Time       `db:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at"`
+}
+
+var ErrJobNotFound = errors.New("job not found")
+
+func (s *PostgresStorage) EnqueueJob(ctx context.Context, kind string, payload any, requestedBy int64, maxAttempts int) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	const query = `
+        INSERT INTO jobs (kind, payload, requested_by, max_attempts)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+
+	var id int64
+	if err := s.db.QueryRowContext(ctx, query, kind, data, requestedBy, maxAttempts).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return id, nil
+}
+
+// EnqueueJobAt queues a job that isn't due before runAt
+func (s *PostgresStorage) EnqueueJobAt(ctx context.Context, kind string, payload any, runAt time.Time, maxAttempts int) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	const query = `
+        INSERT INTO jobs (kind, payload, requested_by, max_attempts, run_at)
+        VALUES ($1, $2, 0, $3, $4)
+        RETURNING id
+    `
+
+	var id int64
+	if err := s.db.QueryRowContext(ctx, query, kind, data, maxAttempts, runAt).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return id, nil
+}
+
+// ScheduleJob queues a job to run at runAt unless one of the same kind is
+// already queued or running, so every replica may schedule it
+func (s *PostgresStorage) ScheduleJob(ctx context.Context, kind string, runAt time.Time, maxAttempts int) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO jobs (kind, payload, requested_by, max_attempts, run_at)
+        SELECT $1, '{}', 0, $2, $3
+        WHERE NOT EXISTS (
+            SELECT 1 FROM jobs WHERE kind = $1 AND status IN ('pending', 'running')
+        )
+    `
+
+	res, err := s.db.ExecContext(ctx, query, kind, maxAttempts, runAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule job: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimNextJob marks the oldest due job as running and returns it.
+// SKIP LOCKED lets several workers poll the table concurrently.
+func (s *PostgresStorage) ClaimNextJob(ctx context.Context) (*Job, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE jobs SET status = 'running', attempts = attempts + 1, updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM jobs
+            WHERE status = 'pending' AND run_at <= NOW()
+            ORDER BY run_at, id
+            FOR UPDATE SKIP LOCKED
+            LIMIT 1
+        )
+        RETURNING id, kind, payload, status, attempts, max_attempts, last_error,
+                  requested_by, run_at, created_at, updated_at
+    `
+
+	var job Job
+	err := s.db.GetContext(ctx, &job, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (s *PostgresStorage) CompleteJob(ctx context.Context, jobID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE jobs SET status = 'done', last_error = '', updated_at = NOW() WHERE id = $1`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// FailJob records the error and either reschedules the job at retryAt or,
+// when retryAt is nil, marks it as permanently failed.
+func (s *PostgresStorage) FailJob(ctx context.Context, jobID int64, jobErr error, retryAt *time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	status, runAt := JobFailed, time.Now()
+	if retryAt != nil {
+		status, runAt = JobPending, *retryAt
+	}
+
+	const query = `
+        UPDATE jobs SET status = $2, last_error = $3, run_at = $4, updated_at = NOW()
+        WHERE id = $1
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, jobID, status, jobErr.Error(), runAt); err != nil {
+		return fmt.Errorf("failed to record job failure: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) GetJob(ctx context.Context, jobID int64) (*Job, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, kind, payload, status, attempts, max_attempts, last_error,
+               requested_by, run_at, created_at, updated_at
+        FROM jobs
+        WHERE id = $1
+    `
+
+	var job Job
+	err := s.db.GetContext(ctx, &job, query, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// RetryJob puts a failed job back into the queue with a fresh attempt budget
+func (s *PostgresStorage) RetryJob(ctx context.Context, jobID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE jobs SET status = 'pending', attempts = 0, run_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND status = 'failed'
+    `
+
+	res, err := s.db.ExecContext(ctx, query, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// ResetStuckJobs returns jobs left running by a crashed worker to the queue
+func (s *PostgresStorage) ResetStuckJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE jobs SET status = 'pending', updated_at = NOW()
+        WHERE status = 'running' AND updated_at < $1
+    `
+
+	res, err := s.db.ExecContext(ctx, query, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset stuck jobs: %w", err)
+	}
+	return res.RowsAffected()
+}
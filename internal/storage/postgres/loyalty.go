@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoyaltyBalance is what /bonus shows
+type LoyaltyBalance struct {
+	Points int `db:"points"`
+	// NextExpiry is when the earliest of the points expire and
+	// ExpiringPoints how many do then; nil without points
+	NextExpiry     *time.Time `db:"next_expiry"`
+	ExpiringPoints int        `db:"expiring_points"`
+}
+
+// ExpiredPoints are the points of one customer that just expired
+type ExpiredPoints struct {
+	UserID int64 `db:"user_id"`
+	Points int   `db:"points"`
+}
+
+// AccrueLoyaltyPoints credits a completed order with rate points per unit
+// of its price without delivery, valid until expiresAt. An order earns
+// once; points revoked when it was cancelled come back if it is completed
+// again. Returns the customer and the points, 0 when nothing was credited.
+func (s *PostgresStorage) AccrueLoyaltyPoints(ctx context.Context, orderID int64, rate float64, expiresAt time.Time) (int64, int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO loyalty_points (user_id, order_id, points, expires_at)
+        SELECT o.user_id, o.id, FLOOR((o.price - COALESCE(d.cost, 0)) * $2)::int, $3
+        FROM orders o
+        LEFT JOIN delivery d ON d.order_id = o.id
+        WHERE o.id = $1
+          AND FLOOR((o.price - COALESCE(d.cost, 0)) * $2) > 0
+        ON CONFLICT (order_id) DO UPDATE SET revoked_at = NULL
+        WHERE loyalty_points.revoked_at IS NOT NULL
+        RETURNING user_id, points
+    `
+
+	var userID int64
+	var points int
+	err := s.db.QueryRowContext(ctx, query, orderID, rate, expiresAt).Scan(&userID, &points)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to accrue loyalty points: %w", err)
+	}
+	return userID, points, nil
+}
+
+// RevokeLoyaltyPoints takes back the points of an order that was
+// cancelled after completion. Returns the points taken back.
+func (s *PostgresStorage) RevokeLoyaltyPoints(ctx context.Context, orderID int64) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE loyalty_points
+        SET revoked_at = NOW()
+        WHERE order_id = $1 AND revoked_at IS NULL AND expired_at IS NULL
+        RETURNING points
+    `
+
+	var points int
+	err := s.db.QueryRowContext(ctx, query, orderID).Scan(&points)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke loyalty points: %w", err)
+	}
+	return points, nil
+}
+
+func (s *PostgresStorage) GetLoyaltyBalance(ctx context.Context, userID int64) (*LoyaltyBalance, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        WITH active AS (
+            SELECT points, expires_at
+            FROM loyalty_points
+            WHERE user_id = $1 AND expired_at IS NULL AND revoked_at IS NULL
+        )
+        SELECT COALESCE(SUM(points), 0) AS points,
+               MIN(expires_at) AS next_expiry,
+               COALESCE(SUM(points) FILTER (
+                   WHERE expires_at::date = (SELECT MIN(expires_at)::date FROM active)
+               ), 0) AS expiring_points
+        FROM active
+    `
+
+	var balance LoyaltyBalance
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.GetContext(ctx, db, &balance, query, userID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty balance: %w", err)
+	}
+	return &balance, nil
+}
+
+// ExpireLoyaltyPoints marks the points due by now as expired and returns
+// them per customer
+func (s *PostgresStorage) ExpireLoyaltyPoints(ctx context.Context, now time.Time) ([]ExpiredPoints, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        WITH expired AS (
+            UPDATE loyalty_points
+            SET expired_at = NOW()
+            WHERE expires_at <= $1 AND expired_at IS NULL AND revoked_at IS NULL
+            RETURNING user_id, points
+        )
+        SELECT user_id, SUM(points) AS points
+        FROM expired
+        GROUP BY user_id
+    `
+
+	var expired []ExpiredPoints
+	if err := s.db.SelectContext(ctx, &expired, query, now); err != nil {
+		return nil, fmt.Errorf("failed to expire loyalty points: %w", err)
+	}
+	return expired, nil
+}
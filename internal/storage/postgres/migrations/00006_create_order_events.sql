@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE order_events (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   INTEGER,
+    user_id    BIGINT      NOT NULL,
+    event_type VARCHAR(32) NOT NULL CHECK (event_type IN (
+        'draft_started', 'quote_shown', 'confirmed', 'paid', 'produced', 'shipped', 'status_changed'
+    )),
+    payload    JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_order_events_order
+      FOREIGN KEY(order_id)
+      REFERENCES orders(id)
+      ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_order_events_order_id ON order_events (order_id, id);
+CREATE INDEX idx_order_events_user_id ON order_events (user_id);
+CREATE INDEX idx_order_events_created_at ON order_events (created_at);
+
+-- Events are append-only: forbid updates and deletes at the database level
+CREATE RULE order_events_no_update AS ON UPDATE TO order_events DO INSTEAD NOTHING;
+CREATE RULE order_events_no_delete AS ON DELETE TO order_events DO INSTEAD NOTHING;
+
+-- +goose Down
+DROP RULE IF EXISTS order_events_no_delete ON order_events;
+DROP RULE IF EXISTS order_events_no_update ON order_events;
+DROP INDEX IF EXISTS idx_order_events_created_at;
+DROP INDEX IF EXISTS idx_order_events_user_id;
+DROP INDEX IF EXISTS idx_order_events_order_id;
+DROP TABLE IF EXISTS order_events;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN is_rush BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orders ADD COLUMN rush_surcharge DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN ready_by TIMESTAMPTZ;
+
+CREATE INDEX idx_orders_is_rush ON orders (is_rush) WHERE is_rush = TRUE;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_is_rush;
+ALTER TABLE orders DROP COLUMN ready_by;
+ALTER TABLE orders DROP COLUMN rush_surcharge;
+ALTER TABLE orders DROP COLUMN is_rush;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN locale VARCHAR(8) NOT NULL DEFAULT 'ru';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN locale;
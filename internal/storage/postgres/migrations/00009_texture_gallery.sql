@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE textures ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE textures ADD COLUMN care_instructions TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE texture_photos (
+    id         BIGSERIAL PRIMARY KEY,
+    texture_id UUID         NOT NULL,
+    file_id    VARCHAR(255) NOT NULL,
+    caption    VARCHAR(1024) NOT NULL DEFAULT '',
+    position   INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_texture_photos_texture
+      FOREIGN KEY(texture_id)
+      REFERENCES textures(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_texture_photos_texture_id ON texture_photos (texture_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_texture_photos_texture_id;
+DROP TABLE IF EXISTS texture_photos;
+ALTER TABLE textures DROP COLUMN care_instructions;
+ALTER TABLE textures DROP COLUMN description;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE pricing_rules (
+    id              BIGSERIAL PRIMARY KEY,
+    commission_rate DECIMAL(6, 4) NOT NULL CHECK (commission_rate >= 0 AND commission_rate < 1),
+    tax_rate        DECIMAL(6, 4) NOT NULL CHECK (tax_rate >= 0 AND tax_rate < 1),
+    effective_from  TIMESTAMPTZ   NOT NULL,
+    created_by      BIGINT        NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pricing_rules_effective_from ON pricing_rules (effective_from DESC);
+
+-- Current hard-coded rates become the initial rule
+INSERT INTO pricing_rules (commission_rate, tax_rate, effective_from)
+VALUES (0.03, 0.06, '2000-01-01');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pricing_rules_effective_from;
+DROP TABLE IF EXISTS pricing_rules;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id           BIGSERIAL PRIMARY KEY,
+    kind         VARCHAR(64)  NOT NULL,
+    payload      JSONB        NOT NULL DEFAULT '{}'::jsonb,
+    status       VARCHAR(16)  NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    attempts     INTEGER      NOT NULL DEFAULT 0,
+    max_attempts INTEGER      NOT NULL DEFAULT 3,
+    last_error   TEXT         NOT NULL DEFAULT '',
+    requested_by BIGINT       NOT NULL DEFAULT 0,
+    run_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_pending ON jobs (run_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_jobs_pending;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE routing_rules (
+    id             BIGSERIAL PRIMARY KEY,
+    name           VARCHAR(100) NOT NULL,
+    priority       INTEGER      NOT NULL DEFAULT 100,
+    field          VARCHAR(32)  NOT NULL CHECK (field IN ('price', 'area_dm2', 'product', 'rush', 'quantity')),
+    operator       VARCHAR(4)   NOT NULL CHECK (operator IN ('=', '!=', '>', '>=', '<', '<=')),
+    value          VARCHAR(100) NOT NULL,
+    target_chat_id BIGINT       NOT NULL,
+    assignee_id    BIGINT,
+    active         BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_routing_rules_active ON routing_rules (priority, id) WHERE active = TRUE;
+
+ALTER TABLE orders ADD COLUMN assignee_id BIGINT;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN assignee_id;
+DROP INDEX IF EXISTS idx_routing_rules_active;
+DROP TABLE IF EXISTS routing_rules;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE dialog_messages (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    order_id   INTEGER,
+    direction  VARCHAR(3)  NOT NULL CHECK (direction IN ('in', 'out')),
+    step       VARCHAR(64) NOT NULL DEFAULT '',
+    text       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_dialog_messages_order
+      FOREIGN KEY(order_id)
+      REFERENCES orders(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_dialog_messages_order_id ON dialog_messages (order_id, id);
+CREATE INDEX idx_dialog_messages_unlinked ON dialog_messages (user_id, id) WHERE order_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_dialog_messages_unlinked;
+DROP INDEX IF EXISTS idx_dialog_messages_order_id;
+DROP TABLE IF EXISTS dialog_messages;
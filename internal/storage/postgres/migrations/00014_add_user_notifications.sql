@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN notifications_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN notifications_enabled;
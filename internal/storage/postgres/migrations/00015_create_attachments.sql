@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE attachments (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT       NOT NULL,
+    order_id       INTEGER,
+    tg_file_id     VARCHAR(255) NOT NULL,
+    tg_unique_id   VARCHAR(64)  NOT NULL,
+    object_key     VARCHAR(512) NOT NULL,
+    content_type   VARCHAR(128) NOT NULL DEFAULT 'application/octet-stream',
+    size_bytes     BIGINT       NOT NULL CHECK (size_bytes >= 0),
+    sha256         CHAR(64)     NOT NULL,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_attachments_order
+      FOREIGN KEY(order_id)
+      REFERENCES orders(id)
+      ON DELETE SET NULL
+);
+
+CREATE INDEX idx_attachments_order_id ON attachments (order_id);
+CREATE INDEX idx_attachments_user_id ON attachments (user_id);
+CREATE UNIQUE INDEX idx_attachments_unique_file ON attachments (user_id, tg_unique_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_attachments_unique_file;
+DROP INDEX IF EXISTS idx_attachments_user_id;
+DROP INDEX IF EXISTS idx_attachments_order_id;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold'));
+
+CREATE TABLE order_holds (
+    id          BIGSERIAL PRIMARY KEY,
+    order_id    INTEGER      NOT NULL,
+    reason      VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    resolved_by BIGINT,
+    resolved_at TIMESTAMPTZ,
+    approved    BOOLEAN,
+
+    CONSTRAINT fk_order_holds_order
+      FOREIGN KEY(order_id)
+      REFERENCES orders(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_order_holds_open ON order_holds (order_id) WHERE resolved_at IS NULL;
+CREATE INDEX idx_orders_user_created ON orders (user_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_user_created;
+DROP INDEX IF EXISTS idx_order_holds_open;
+DROP TABLE IF EXISTS order_holds;
+
+UPDATE orders SET status = 'new' WHERE status = 'on_hold';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled'));
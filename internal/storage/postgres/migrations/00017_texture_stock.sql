@@ -0,0 +1,8 @@
+-- +goose Up
+-- NULL stock means the texture is not tracked and never runs out
+ALTER TABLE textures ADD COLUMN stock_dm2 DECIMAL(12, 2) CHECK (stock_dm2 >= 0);
+ALTER TABLE orders ADD COLUMN reserved_dm2 DECIMAL(12, 2) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN reserved_dm2;
+ALTER TABLE textures DROP COLUMN stock_dm2;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE texture_batches (
+    id             BIGSERIAL PRIMARY KEY,
+    texture_id     UUID           NOT NULL,
+    supplier       VARCHAR(255)   NOT NULL DEFAULT '',
+    location       VARCHAR(64)    NOT NULL,
+    quantity_dm2   DECIMAL(12, 2) NOT NULL CHECK (quantity_dm2 > 0),
+    remaining_dm2  DECIMAL(12, 2) NOT NULL CHECK (remaining_dm2 >= 0),
+    received_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_texture_batches_texture
+      FOREIGN KEY(texture_id)
+      REFERENCES textures(id)
+      ON DELETE RESTRICT,
+    CONSTRAINT texture_batches_remaining_check CHECK (remaining_dm2 <= quantity_dm2)
+);
+
+CREATE INDEX idx_texture_batches_fifo ON texture_batches (texture_id, received_at, id) WHERE remaining_dm2 > 0;
+
+CREATE TABLE order_batch_allocations (
+    order_id  INTEGER        NOT NULL,
+    batch_id  BIGINT         NOT NULL,
+    area_dm2  DECIMAL(12, 2) NOT NULL CHECK (area_dm2 > 0),
+
+    PRIMARY KEY (order_id, batch_id),
+    CONSTRAINT fk_allocations_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_allocations_batch FOREIGN KEY(batch_id) REFERENCES texture_batches(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_order_batch_allocations_batch_id ON order_batch_allocations (batch_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_order_batch_allocations_batch_id;
+DROP TABLE IF EXISTS order_batch_allocations;
+DROP INDEX IF EXISTS idx_texture_batches_fifo;
+DROP TABLE IF EXISTS texture_batches;
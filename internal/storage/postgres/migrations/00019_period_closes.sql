@@ -0,0 +1,66 @@
+-- +goose Up
+CREATE TABLE period_closes (
+    id           BIGSERIAL PRIMARY KEY,
+    period_start TIMESTAMP   NOT NULL,
+    period_end   TIMESTAMP   NOT NULL,
+    snapshot     JSONB       NOT NULL,
+    signature    VARCHAR(64) NOT NULL,
+    closed_by    BIGINT      NOT NULL,
+    closed_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    unlocked_by  BIGINT,
+    unlocked_at  TIMESTAMPTZ,
+
+    CONSTRAINT period_closes_range_check CHECK (period_end > period_start)
+);
+
+-- A period can be closed again after an unlock; old snapshots stay for the audit trail
+CREATE UNIQUE INDEX idx_period_closes_active ON period_closes (period_start) WHERE unlocked_at IS NULL;
+
+-- Financial figures of orders in a closed period are frozen. Status changes
+-- are still allowed so production can finish late orders.
+-- +goose StatementBegin
+CREATE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR
+           (OLD.created_at, OLD.width_cm, OLD.height_cm, OLD.texture_id, OLD.price,
+            OLD.leather_cost, OLD.process_cost, OLD.total_cost, OLD.commission,
+            OLD.tax, OLD.net_revenue, OLD.profit, OLD.is_rush, OLD.rush_surcharge)
+           IS DISTINCT FROM
+           (NEW.created_at, NEW.width_cm, NEW.height_cm, NEW.texture_id, NEW.price,
+            NEW.leather_cost, NEW.process_cost, NEW.total_cost, NEW.commission,
+            NEW.tax, NEW.net_revenue, NEW.profit, NEW.is_rush, NEW.rush_surcharge)
+        THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_period_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION enforce_period_lock();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS orders_period_lock ON orders;
+DROP FUNCTION IF EXISTS enforce_period_lock();
+DROP INDEX IF EXISTS idx_period_closes_active;
+DROP TABLE IF EXISTS period_closes;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    kind            VARCHAR(64) NOT NULL,
+    payload         JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ,
+    failed_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_due ON outbox (next_attempt_at, id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_outbox_due;
+DROP TABLE IF EXISTS outbox;
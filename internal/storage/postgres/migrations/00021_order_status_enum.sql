@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+-- Fold spellings that may have slipped in on databases created without the constraint
+UPDATE orders SET status = LOWER(TRIM(status)) WHERE status <> LOWER(TRIM(status));
+UPDATE orders SET status = 'cancelled' WHERE status = 'canceled';
+
+-- Must stay in sync with postgres.OrderStatuses
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold'));
+
+-- +goose Down
+-- The constraint itself predates this migration (00016); the data cleanup is not reversible
+SELECT 1;
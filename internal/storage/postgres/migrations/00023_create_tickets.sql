@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE tickets (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT       NOT NULL,
+    subject           VARCHAR(255) NOT NULL DEFAULT '',
+    status            VARCHAR(16)  NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'answered', 'closed')),
+    topic_id          INTEGER,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    sla_due_at        TIMESTAMPTZ  NOT NULL,
+    sla_alerted_at    TIMESTAMPTZ,
+    first_response_at TIMESTAMPTZ,
+    closed_at         TIMESTAMPTZ,
+    closed_by         BIGINT
+);
+
+-- One open conversation per customer
+CREATE UNIQUE INDEX idx_tickets_user_active ON tickets (user_id) WHERE status <> 'closed';
+CREATE UNIQUE INDEX idx_tickets_topic_id ON tickets (topic_id) WHERE topic_id IS NOT NULL;
+CREATE INDEX idx_tickets_sla ON tickets (sla_due_at) WHERE first_response_at IS NULL AND status <> 'closed';
+
+CREATE TABLE ticket_messages (
+    id               BIGSERIAL PRIMARY KEY,
+    ticket_id        BIGINT      NOT NULL,
+    direction        VARCHAR(3)  NOT NULL CHECK (direction IN ('in', 'out')),
+    author_id        BIGINT      NOT NULL,
+    text             TEXT        NOT NULL DEFAULT '',
+    user_message_id  INTEGER     NOT NULL,
+    group_message_id INTEGER     NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_ticket_messages_ticket
+      FOREIGN KEY(ticket_id)
+      REFERENCES tickets(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_ticket_messages_ticket_id ON ticket_messages (ticket_id);
+CREATE INDEX idx_ticket_messages_group_message_id ON ticket_messages (group_message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ticket_messages_group_message_id;
+DROP INDEX IF EXISTS idx_ticket_messages_ticket_id;
+DROP TABLE IF EXISTS ticket_messages;
+DROP INDEX IF EXISTS idx_tickets_sla;
+DROP INDEX IF EXISTS idx_tickets_topic_id;
+DROP INDEX IF EXISTS idx_tickets_user_active;
+DROP TABLE IF EXISTS tickets;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- Every product is priced from a material: leather textures, vinyl films...
+ALTER TABLE textures
+ADD COLUMN service_type VARCHAR(20) NOT NULL DEFAULT 'leather',
+ADD CONSTRAINT textures_service_type_check CHECK (service_type IN ('leather', 'sticker'));
+
+ALTER TABLE orders
+ADD COLUMN service_type VARCHAR(20) NOT NULL DEFAULT 'leather',
+ADD COLUMN quantity     INTEGER     NOT NULL DEFAULT 1,
+ADD COLUMN options      JSONB       NOT NULL DEFAULT '{}',
+ADD CONSTRAINT orders_service_type_check CHECK (service_type IN ('leather', 'sticker')),
+ADD CONSTRAINT orders_quantity_check CHECK (quantity > 0);
+
+CREATE INDEX idx_textures_service_type ON textures (service_type) WHERE in_stock = TRUE;
+
+INSERT INTO textures (name, price_per_dm2, service_type) VALUES
+    ('Винил глянцевый', 3.50, 'sticker'),
+    ('Винил матовый', 3.80, 'sticker'),
+    ('Винил прозрачный', 4.20, 'sticker')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+DELETE FROM textures t
+WHERE t.service_type = 'sticker'
+  AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.texture_id = t.id);
+
+DROP INDEX IF EXISTS idx_textures_service_type;
+
+ALTER TABLE orders
+DROP CONSTRAINT IF EXISTS orders_quantity_check,
+DROP CONSTRAINT IF EXISTS orders_service_type_check,
+DROP COLUMN IF EXISTS options,
+DROP COLUMN IF EXISTS quantity,
+DROP COLUMN IF EXISTS service_type;
+
+ALTER TABLE textures
+DROP CONSTRAINT IF EXISTS textures_service_type_check,
+DROP COLUMN IF EXISTS service_type;
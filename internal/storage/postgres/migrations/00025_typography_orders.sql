@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE textures
+DROP CONSTRAINT textures_service_type_check,
+ADD CONSTRAINT textures_service_type_check CHECK (service_type IN ('leather', 'sticker', 'typography'));
+
+ALTER TABLE orders
+DROP CONSTRAINT orders_service_type_check,
+ADD CONSTRAINT orders_service_type_check CHECK (service_type IN ('leather', 'sticker', 'typography'));
+
+-- Products of the line a material suits; NULL means every one of them
+ALTER TABLE textures ADD COLUMN products TEXT[];
+
+INSERT INTO textures (name, price_per_dm2, service_type, products) VALUES
+    ('Мелованная 300 г/м²', 0.60, 'typography', '{business_card,flyer}'),
+    ('Дизайнерская 300 г/м²', 1.40, 'typography', '{business_card}'),
+    ('Мелованная 130 г/м²', 0.25, 'typography', '{flyer}'),
+    ('Баннерная ткань 440 г/м²', 0.90, 'typography', '{banner}')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+DELETE FROM textures t
+WHERE t.service_type = 'typography'
+  AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.texture_id = t.id);
+
+ALTER TABLE textures DROP COLUMN IF EXISTS products;
+
+ALTER TABLE orders
+DROP CONSTRAINT orders_service_type_check,
+ADD CONSTRAINT orders_service_type_check CHECK (service_type IN ('leather', 'sticker'));
+
+ALTER TABLE textures
+DROP CONSTRAINT textures_service_type_check,
+ADD CONSTRAINT textures_service_type_check CHECK (service_type IN ('leather', 'sticker'));
@@ -0,0 +1,40 @@
+-- +goose Up
+-- An order may combine several products, e.g. a leather piece and stickers.
+-- The orders row keeps the totals and, for older readers, the first item.
+CREATE TABLE order_items (
+    id             BIGSERIAL PRIMARY KEY,
+    order_id       INTEGER        NOT NULL,
+    position       SMALLINT       NOT NULL,
+    service_type   VARCHAR(20)    NOT NULL,
+    texture_id     UUID           NOT NULL,
+    width_cm       INTEGER        NOT NULL CHECK (width_cm > 0 AND width_cm <= 80),
+    height_cm      INTEGER        NOT NULL CHECK (height_cm > 0 AND height_cm <= 50),
+    quantity       INTEGER        NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    options        JSONB          NOT NULL DEFAULT '{}',
+    price          DECIMAL(10, 2) NOT NULL CHECK (price > 0),
+    leather_cost   DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    process_cost   DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total_cost     DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    rush_surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    reserved_dm2   DECIMAL(12, 2) NOT NULL DEFAULT 0,
+
+    CONSTRAINT fk_order_items_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_items_texture FOREIGN KEY(texture_id) REFERENCES textures(id) ON DELETE RESTRICT,
+    CONSTRAINT order_items_service_type_check CHECK (service_type IN ('leather', 'sticker', 'typography')),
+    CONSTRAINT order_items_position_unique UNIQUE (order_id, position)
+);
+
+CREATE INDEX idx_order_items_texture_id ON order_items (texture_id);
+
+-- Every existing order becomes a single-item order
+INSERT INTO order_items (
+    order_id, position, service_type, texture_id, width_cm, height_cm, quantity, options,
+    price, leather_cost, process_cost, total_cost, rush_surcharge, reserved_dm2
+)
+SELECT id, 1, service_type, texture_id, width_cm, height_cm, quantity, options,
+       price, leather_cost, process_cost, total_cost, rush_surcharge, reserved_dm2
+FROM orders;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_order_items_texture_id;
+DROP TABLE IF EXISTS order_items;
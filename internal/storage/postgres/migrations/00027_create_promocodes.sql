@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE promocodes (
+    id             BIGSERIAL PRIMARY KEY,
+    code           VARCHAR(32)    NOT NULL,
+    kind           VARCHAR(10)    NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    -- percent for 'percent' codes, rubles for 'fixed' ones
+    value          DECIMAL(10, 2) NOT NULL CHECK (value > 0),
+    -- NULL means unlimited
+    max_uses       INTEGER        CHECK (max_uses > 0),
+    per_user_limit INTEGER        NOT NULL DEFAULT 1 CHECK (per_user_limit > 0),
+    used_count     INTEGER        NOT NULL DEFAULT 0 CHECK (used_count >= 0),
+    expires_at     TIMESTAMPTZ,
+    created_by     BIGINT         NOT NULL,
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT promocodes_code_unique UNIQUE (code),
+    CONSTRAINT promocodes_percent_check CHECK (kind <> 'percent' OR value < 100)
+);
+
+CREATE TABLE promocode_redemptions (
+    promocode_id BIGINT         NOT NULL,
+    order_id     INTEGER        NOT NULL,
+    user_id      BIGINT         NOT NULL,
+    discount     DECIMAL(10, 2) NOT NULL,
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (promocode_id, order_id),
+    CONSTRAINT fk_redemptions_promocode FOREIGN KEY(promocode_id) REFERENCES promocodes(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_redemptions_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_promocode_redemptions_user ON promocode_redemptions (promocode_id, user_id);
+
+ALTER TABLE orders
+ADD COLUMN promocode_id BIGINT REFERENCES promocodes(id) ON DELETE RESTRICT,
+ADD COLUMN discount     DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (discount >= 0);
+
+-- +goose Down
+ALTER TABLE orders
+DROP COLUMN IF EXISTS discount,
+DROP COLUMN IF EXISTS promocode_id;
+
+DROP INDEX IF EXISTS idx_promocode_redemptions_user;
+DROP TABLE IF EXISTS promocode_redemptions;
+DROP TABLE IF EXISTS promocodes;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Codes are issued on first /referral and shared as t.me/<bot>?start=ref_<code>
+ALTER TABLE users
+ADD COLUMN referral_code VARCHAR(16),
+ADD CONSTRAINT users_referral_code_unique UNIQUE (referral_code);
+
+CREATE TABLE referrals (
+    -- A customer can be referred only once
+    referee_id  BIGINT PRIMARY KEY,
+    referrer_id BIGINT         NOT NULL,
+    created_at  TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    -- Filled when the referee's first order is completed
+    order_id    INTEGER,
+    bonus       DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (bonus >= 0),
+    credited_at TIMESTAMPTZ,
+
+    CONSTRAINT fk_referrals_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE SET NULL,
+    CONSTRAINT referrals_self_check CHECK (referee_id <> referrer_id)
+);
+
+CREATE INDEX idx_referrals_referrer_id ON referrals (referrer_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_referrals_referrer_id;
+DROP TABLE IF EXISTS referrals;
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_referral_code_unique,
+DROP COLUMN IF EXISTS referral_code;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- One row per change made by an admin command. before/after hold the
+-- changed record as JSON; either is NULL for creations and deletions.
+CREATE TABLE audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    admin_id   BIGINT      NOT NULL,
+    -- The command or callback data that made the change
+    action     TEXT        NOT NULL,
+    -- What was changed, as "<kind>:<id>", e.g. "order:42"
+    target     TEXT        NOT NULL,
+    before     JSONB,
+    after      JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at DESC);
+CREATE INDEX idx_audit_log_admin_id ON audit_log (admin_id, created_at DESC);
+CREATE INDEX idx_audit_log_target ON audit_log (target text_pattern_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_log_target;
+DROP INDEX IF EXISTS idx_audit_log_admin_id;
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP TABLE IF EXISTS audit_log;
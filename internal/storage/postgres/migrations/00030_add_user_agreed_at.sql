@@ -0,0 +1,9 @@
+-- +goose Up
+-- When the user accepted the terms; reported back to them by /mydata
+ALTER TABLE users ADD COLUMN agreed_at TIMESTAMPTZ;
+
+-- The exact moment was not kept before, updated_at is the closest record of it
+UPDATE users SET agreed_at = updated_at WHERE agreed_to_tpa;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN agreed_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Set when the number was confirmed with a code or shared via the
+-- Telegram contact button; cleared when the number changes
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN phone_verified_at;
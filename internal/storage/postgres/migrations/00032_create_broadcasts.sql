@@ -0,0 +1,44 @@
+-- +goose Up
+-- Set when Telegram answers 403 to a broadcast: the user blocked the bot.
+-- Later broadcasts skip them until they order again.
+ALTER TABLE users ADD COLUMN bot_blocked_at TIMESTAMPTZ;
+
+CREATE TABLE broadcasts (
+    id            BIGSERIAL PRIMARY KEY,
+    created_by    BIGINT      NOT NULL,
+    text          TEXT        NOT NULL DEFAULT '',
+    photo_file_id TEXT        NOT NULL DEFAULT '',
+    -- [{"text": ..., "url": ...}], one button per row
+    buttons       JSONB       NOT NULL DEFAULT '[]',
+    segment       TEXT        NOT NULL,
+    status        VARCHAR(16) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sending', 'done', 'cancelled')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ,
+
+    CONSTRAINT broadcasts_content_check CHECK (text <> '' OR photo_file_id <> '')
+);
+
+-- Recipients are fixed when the broadcast is composed, so a restart
+-- resumes with the ones still pending
+CREATE TABLE broadcast_recipients (
+    broadcast_id BIGINT      NOT NULL,
+    user_id      BIGINT      NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'blocked')),
+    error        TEXT        NOT NULL DEFAULT '',
+    sent_at      TIMESTAMPTZ,
+
+    PRIMARY KEY (broadcast_id, user_id),
+    CONSTRAINT fk_broadcast_recipients_broadcast
+      FOREIGN KEY(broadcast_id)
+      REFERENCES broadcasts(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_broadcast_recipients_pending ON broadcast_recipients (broadcast_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_broadcast_recipients_pending;
+DROP TABLE IF EXISTS broadcast_recipients;
+DROP TABLE IF EXISTS broadcasts;
+ALTER TABLE users DROP COLUMN IF EXISTS bot_blocked_at;
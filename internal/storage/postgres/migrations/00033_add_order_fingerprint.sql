@@ -0,0 +1,10 @@
+-- +goose Up
+-- Hash of what was ordered and the contact, to catch the same order placed
+-- twice in a row (see orders.Fingerprint)
+ALTER TABLE orders ADD COLUMN fingerprint TEXT;
+CREATE INDEX idx_orders_user_fingerprint ON orders (user_id, fingerprint, created_at DESC)
+    WHERE fingerprint IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_user_fingerprint;
+ALTER TABLE orders DROP COLUMN fingerprint;
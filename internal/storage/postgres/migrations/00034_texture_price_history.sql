@@ -0,0 +1,44 @@
+-- +goose Up
+-- Every price a texture has had: a row is valid from valid_from until the
+-- next row of the same texture. Filled by a trigger, so manual UPDATEs in
+-- psql are recorded as well.
+CREATE TABLE texture_price_history (
+    id            BIGSERIAL PRIMARY KEY,
+    texture_id    UUID           NOT NULL,
+    price_per_dm2 DECIMAL(10, 2) NOT NULL CHECK (price_per_dm2 > 0),
+    valid_from    TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_texture_price_history_texture
+      FOREIGN KEY(texture_id)
+      REFERENCES textures(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_texture_price_history_lookup ON texture_price_history (texture_id, valid_from DESC, id DESC);
+
+-- Earlier changes were not kept: the current price is the best we know
+-- since the texture was created
+INSERT INTO texture_price_history (texture_id, price_per_dm2, valid_from)
+SELECT id, price_per_dm2, created_at FROM textures;
+
+-- +goose StatementBegin
+CREATE FUNCTION record_texture_price() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.price_per_dm2 IS DISTINCT FROM OLD.price_per_dm2 THEN
+        INSERT INTO texture_price_history (texture_id, price_per_dm2, valid_from)
+        VALUES (NEW.id, NEW.price_per_dm2, CASE WHEN TG_OP = 'INSERT' THEN NEW.created_at ELSE NOW() END);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER textures_price_history
+    AFTER INSERT OR UPDATE OF price_per_dm2 ON textures
+    FOR EACH ROW EXECUTE FUNCTION record_texture_price();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS textures_price_history ON textures;
+DROP FUNCTION IF EXISTS record_texture_price();
+DROP INDEX IF EXISTS idx_texture_price_history_lookup;
+DROP TABLE IF EXISTS texture_price_history;
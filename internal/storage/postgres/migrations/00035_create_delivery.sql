@@ -0,0 +1,21 @@
+-- +goose Up
+-- How an order reaches the customer. Orders without a row, like the ones
+-- placed before delivery was offered, are picked up.
+CREATE TABLE delivery (
+    order_id   INTEGER        PRIMARY KEY,
+    method     VARCHAR(20)    NOT NULL,
+    address    TEXT           NOT NULL DEFAULT '',
+    -- The charge included in orders.price
+    cost       DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (cost >= 0),
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_delivery_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT delivery_method_check CHECK (method IN ('pickup', 'courier', 'post')),
+    CONSTRAINT delivery_address_check CHECK (method = 'pickup' OR address <> '')
+);
+
+CREATE INDEX idx_delivery_method ON delivery (method) WHERE method <> 'pickup';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_delivery_method;
+DROP TABLE IF EXISTS delivery;
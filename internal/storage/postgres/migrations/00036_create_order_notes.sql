@@ -0,0 +1,18 @@
+-- +goose Up
+-- Production notes left by staff on an order. The customer sees them on
+-- the order card, oldest first.
+CREATE TABLE order_notes (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   INTEGER     NOT NULL,
+    author_id  BIGINT      NOT NULL,
+    text       TEXT        NOT NULL CHECK (text <> ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_order_notes_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_order_notes_order_id ON order_notes (order_id, created_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_order_notes_order_id;
+DROP TABLE IF EXISTS order_notes;
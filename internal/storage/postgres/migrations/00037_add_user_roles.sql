@@ -0,0 +1,11 @@
+-- +goose Up
+-- Staff roles. ADMIN_IDS and PRODUCTION_IDS from the config still apply on
+-- top of these, so the bot can be bootstrapped before anyone is granted.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer'
+    CHECK (role IN ('customer', 'production', 'manager', 'admin'));
+
+CREATE INDEX idx_users_staff ON users (role) WHERE role <> 'customer';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_staff;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- due_at is the production deadline set by a manager. Orders without one
+-- are due when the customer was promised them (ready_by).
+ALTER TABLE orders ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX idx_orders_open_deadline ON orders ((COALESCE(due_at, ready_by)))
+    WHERE status IN ('new', 'processing');
+
+-- One row per reminder sent, so each is sent once per deadline: moving the
+-- deadline arms the reminders again
+CREATE TABLE order_deadline_reminders (
+    order_id INTEGER     NOT NULL,
+    kind     TEXT        NOT NULL CHECK (kind IN ('due_soon', 'overdue')),
+    due_at   TIMESTAMPTZ NOT NULL,
+    sent_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (order_id, kind, due_at),
+    CONSTRAINT fk_order_deadline_reminders_order FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_deadline_reminders;
+DROP INDEX IF EXISTS idx_orders_open_deadline;
+ALTER TABLE orders DROP COLUMN due_at;
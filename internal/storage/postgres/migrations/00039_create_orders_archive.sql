@@ -0,0 +1,197 @@
+-- +goose Up
+-- Finished orders of closed months move here so the orders table only
+-- holds what the bot still works with. The row, its items, delivery and
+-- notes are kept as one JSON document.
+CREATE TABLE orders_archive (
+    id          INTEGER     PRIMARY KEY,
+    user_id     BIGINT      NOT NULL,
+    status      VARCHAR(20) NOT NULL,
+    created_at  TIMESTAMP   NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    data        JSONB       NOT NULL
+);
+
+CREATE INDEX idx_orders_archive_user_id ON orders_archive (user_id, created_at);
+
+-- All-time statistics add these to the figures of the orders table
+CREATE TABLE orders_archive_totals (
+    status         VARCHAR(20)    PRIMARY KEY,
+    orders         INTEGER        NOT NULL DEFAULT 0,
+    revenue        DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    rush_orders    INTEGER        NOT NULL DEFAULT 0,
+    rush_revenue   DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    rush_surcharge DECIMAL(14, 2) NOT NULL DEFAULT 0
+);
+
+-- History that outlives the order keeps its order_id as a plain number
+ALTER TABLE order_events DROP CONSTRAINT fk_order_events_order;
+ALTER TABLE dialog_messages DROP CONSTRAINT fk_dialog_messages_order;
+ALTER TABLE attachments DROP CONSTRAINT fk_attachments_order;
+ALTER TABLE promocode_redemptions DROP CONSTRAINT fk_redemptions_order;
+ALTER TABLE order_batch_allocations DROP CONSTRAINT fk_allocations_order;
+ALTER TABLE referrals DROP CONSTRAINT fk_referrals_order;
+
+-- The archiver deletes orders of closed months, which the lock forbids to
+-- everyone else. It sets adtime.archiving for its own transaction only.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('adtime.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR
+           (OLD.created_at, OLD.width_cm, OLD.height_cm, OLD.texture_id, OLD.price,
+            OLD.leather_cost, OLD.process_cost, OLD.total_cost, OLD.commission,
+            OLD.tax, OLD.net_revenue, OLD.profit, OLD.is_rush, OLD.rush_surcharge)
+           IS DISTINCT FROM
+           (NEW.created_at, NEW.width_cm, NEW.height_cm, NEW.texture_id, NEW.price,
+            NEW.leather_cost, NEW.process_cost, NEW.total_cost, NEW.commission,
+            NEW.tax, NEW.net_revenue, NEW.profit, NEW.is_rush, NEW.rush_surcharge)
+        THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Archived orders go back into the orders table before the archive is
+-- dropped; the lock is bypassed for the inserts into closed months.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('adtime.archiving', true) = 'on' THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR
+           (OLD.created_at, OLD.width_cm, OLD.height_cm, OLD.texture_id, OLD.price,
+            OLD.leather_cost, OLD.process_cost, OLD.total_cost, OLD.commission,
+            OLD.tax, OLD.net_revenue, OLD.profit, OLD.is_rush, OLD.rush_surcharge)
+           IS DISTINCT FROM
+           (NEW.created_at, NEW.width_cm, NEW.height_cm, NEW.texture_id, NEW.price,
+            NEW.leather_cost, NEW.process_cost, NEW.total_cost, NEW.commission,
+            NEW.tax, NEW.net_revenue, NEW.profit, NEW.is_rush, NEW.rush_surcharge)
+        THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+SET adtime.archiving = 'on';
+
+INSERT INTO orders
+SELECT (jsonb_populate_record(NULL::orders, data->'order')).*
+FROM orders_archive;
+
+INSERT INTO order_items
+SELECT (jsonb_populate_record(NULL::order_items, item)).*
+FROM orders_archive, jsonb_array_elements(data->'items') AS item;
+
+INSERT INTO delivery
+SELECT (jsonb_populate_record(NULL::delivery, data->'delivery')).*
+FROM orders_archive
+WHERE jsonb_typeof(data->'delivery') = 'object';
+
+INSERT INTO order_notes
+SELECT (jsonb_populate_record(NULL::order_notes, note)).*
+FROM orders_archive, jsonb_array_elements(data->'notes') AS note;
+
+RESET adtime.archiving;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR
+           (OLD.created_at, OLD.width_cm, OLD.height_cm, OLD.texture_id, OLD.price,
+            OLD.leather_cost, OLD.process_cost, OLD.total_cost, OLD.commission,
+            OLD.tax, OLD.net_revenue, OLD.profit, OLD.is_rush, OLD.rush_surcharge)
+           IS DISTINCT FROM
+           (NEW.created_at, NEW.width_cm, NEW.height_cm, NEW.texture_id, NEW.price,
+            NEW.leather_cost, NEW.process_cost, NEW.total_cost, NEW.commission,
+            NEW.tax, NEW.net_revenue, NEW.profit, NEW.is_rush, NEW.rush_surcharge)
+        THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER TABLE referrals ADD CONSTRAINT fk_referrals_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE SET NULL;
+ALTER TABLE order_batch_allocations ADD CONSTRAINT fk_allocations_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE promocode_redemptions ADD CONSTRAINT fk_redemptions_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE attachments ADD CONSTRAINT fk_attachments_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE SET NULL;
+ALTER TABLE dialog_messages ADD CONSTRAINT fk_dialog_messages_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE order_events ADD CONSTRAINT fk_order_events_order
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE RESTRICT;
+
+DROP TABLE IF EXISTS orders_archive_totals;
+DROP INDEX IF EXISTS idx_orders_archive_user_id;
+DROP TABLE IF EXISTS orders_archive;
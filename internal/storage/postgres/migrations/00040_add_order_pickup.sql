@@ -0,0 +1,11 @@
+-- +goose Up
+-- Set when staff hand a ready order over at the counter, after scanning
+-- the customer's pickup code
+ALTER TABLE orders
+ADD COLUMN picked_up_at TIMESTAMPTZ,
+ADD COLUMN picked_up_by BIGINT;
+
+-- +goose Down
+ALTER TABLE orders
+DROP COLUMN picked_up_by,
+DROP COLUMN picked_up_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Amounts of an order are in its currency. Orders placed so far were in
+-- roubles.
+ALTER TABLE orders
+ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB',
+ADD CONSTRAINT orders_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN currency;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- Imported leather may be priced in the supplier's currency; quotes convert
+-- it to the order currency at the day's exchange rate.
+ALTER TABLE textures
+ADD COLUMN price_currency CHAR(3) NOT NULL DEFAULT 'RUB',
+ADD CONSTRAINT textures_price_currency_check CHECK (price_currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE texture_price_history
+ADD COLUMN price_currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Rates applied to material prices, e.g. {"EUR": 98.1234}: units of the
+-- order currency per unit of the material's. Empty when none was converted.
+ALTER TABLE orders
+ADD COLUMN exchange_rates JSONB NOT NULL DEFAULT '{}';
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_texture_price() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT'
+       OR NEW.price_per_dm2 IS DISTINCT FROM OLD.price_per_dm2
+       OR NEW.price_currency IS DISTINCT FROM OLD.price_currency THEN
+        INSERT INTO texture_price_history (texture_id, price_per_dm2, price_currency, valid_from)
+        VALUES (NEW.id, NEW.price_per_dm2, NEW.price_currency,
+                CASE WHEN TG_OP = 'INSERT' THEN NEW.created_at ELSE NOW() END);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS textures_price_history ON textures;
+CREATE TRIGGER textures_price_history
+    AFTER INSERT OR UPDATE OF price_per_dm2, price_currency ON textures
+    FOR EACH ROW EXECUTE FUNCTION record_texture_price();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS textures_price_history ON textures;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_texture_price() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.price_per_dm2 IS DISTINCT FROM OLD.price_per_dm2 THEN
+        INSERT INTO texture_price_history (texture_id, price_per_dm2, valid_from)
+        VALUES (NEW.id, NEW.price_per_dm2, CASE WHEN TG_OP = 'INSERT' THEN NEW.created_at ELSE NOW() END);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER textures_price_history
+    AFTER INSERT OR UPDATE OF price_per_dm2 ON textures
+    FOR EACH ROW EXECUTE FUNCTION record_texture_price();
+
+ALTER TABLE orders DROP COLUMN exchange_rates;
+ALTER TABLE texture_price_history DROP COLUMN price_currency;
+ALTER TABLE textures DROP COLUMN price_currency;
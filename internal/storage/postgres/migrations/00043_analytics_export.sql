@@ -0,0 +1,58 @@
+-- +goose Up
+-- Orders flattened with their texture, delivery, promo code and customer
+-- fields for BI queries, refreshed by the nightly analytics export.
+-- Customers are pseudonymous.
+CREATE SCHEMA IF NOT EXISTS analytics;
+
+CREATE TABLE analytics.orders (
+    id                    INTEGER        PRIMARY KEY,
+    customer              TEXT           NOT NULL,
+    status                VARCHAR(20)    NOT NULL,
+    service_type          VARCHAR(20)    NOT NULL,
+    is_rush               BOOLEAN        NOT NULL,
+    currency              CHAR(3)        NOT NULL,
+    -- What the customer pays: after the discount, shipping included
+    price                 DECIMAL(10, 2) NOT NULL,
+    discount              DECIMAL(10, 2) NOT NULL,
+    shipping              DECIMAL(10, 2) NOT NULL,
+    rush_surcharge        DECIMAL(10, 2) NOT NULL,
+    leather_cost          DECIMAL(10, 2) NOT NULL,
+    process_cost          DECIMAL(10, 2) NOT NULL,
+    total_cost            DECIMAL(10, 2) NOT NULL,
+    commission            DECIMAL(10, 2) NOT NULL,
+    tax                   DECIMAL(10, 2) NOT NULL,
+    net_revenue           DECIMAL(10, 2) NOT NULL,
+    profit                DECIMAL(10, 2) NOT NULL,
+    delivery_method       VARCHAR(20)    NOT NULL,
+    promo_code            VARCHAR(32)    NOT NULL,
+    texture_id            UUID           NOT NULL,
+    texture_name          VARCHAR(255)   NOT NULL,
+    items                 INTEGER        NOT NULL,
+    quantity              INTEGER        NOT NULL,
+    material_dm2          DECIMAL(12, 2) NOT NULL,
+    customer_locale       VARCHAR(8)     NOT NULL,
+    customer_since        TIMESTAMPTZ,
+    -- 1 for the customer's first order
+    customer_order_number INTEGER        NOT NULL,
+    created_at            TIMESTAMPTZ    NOT NULL,
+    updated_at            TIMESTAMPTZ    NOT NULL,
+    exported_at           TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_analytics_orders_created_at ON analytics.orders (created_at);
+
+-- How far each target has been exported: orders changed after
+-- (updated_at, order_id) are sent on the next run
+CREATE TABLE analytics_exports (
+    target      VARCHAR(20) PRIMARY KEY,
+    updated_at  TIMESTAMP   NOT NULL,
+    order_id    INTEGER     NOT NULL,
+    exported_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_orders_updated_at ON orders (updated_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_updated_at;
+DROP TABLE IF EXISTS analytics_exports;
+DROP SCHEMA IF EXISTS analytics CASCADE;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Order defaults a customer saved under /profile. The contact is the phone
+-- in users.
+CREATE TABLE user_preferences (
+    user_id           BIGINT      PRIMARY KEY,
+    -- '' until a default delivery is chosen
+    delivery_method   VARCHAR(20) NOT NULL DEFAULT '',
+    delivery_address  TEXT        NOT NULL DEFAULT '',
+    -- Listed first when a material is chosen
+    favorite_textures TEXT[]      NOT NULL DEFAULT '{}',
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT user_preferences_delivery_check
+        CHECK (delivery_method IN ('', 'pickup', 'courier', 'post')),
+    CONSTRAINT user_preferences_address_check
+        CHECK (delivery_method IN ('', 'pickup') OR delivery_address <> '')
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_preferences;
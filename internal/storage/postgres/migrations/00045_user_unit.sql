@@ -0,0 +1,8 @@
+-- +goose Up
+-- Unit the customer sees sizes in; NULL follows DEFAULT_UNIT. Orders keep
+-- centimetres.
+ALTER TABLE users ADD COLUMN unit VARCHAR(2)
+    CONSTRAINT users_unit_check CHECK (unit IN ('mm', 'cm', 'm'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS unit;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Abuse protection. A block is set by an admin and lasts until lifted; a
+-- ban is set automatically for flooding, and ban_count makes the next one
+-- longer. human_verified_at is when a first-time user passed the captcha.
+ALTER TABLE users
+    ADD COLUMN blocked_at TIMESTAMPTZ,
+    ADD COLUMN blocked_by BIGINT,
+    ADD COLUMN block_reason TEXT,
+    ADD COLUMN banned_until TIMESTAMPTZ,
+    ADD COLUMN ban_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN human_verified_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_blocked ON users (blocked_at) WHERE blocked_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_blocked;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS blocked_at,
+    DROP COLUMN IF EXISTS blocked_by,
+    DROP COLUMN IF EXISTS block_reason,
+    DROP COLUMN IF EXISTS banned_until,
+    DROP COLUMN IF EXISTS ban_count,
+    DROP COLUMN IF EXISTS human_verified_at;
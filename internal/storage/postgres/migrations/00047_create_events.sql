@@ -0,0 +1,15 @@
+-- +goose Up
+-- Dialog steps customers reached, for the conversion funnel. step is the
+-- dialog step, or "started" and "placed" at the ends of a flow.
+CREATE TABLE events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    flow TEXT NOT NULL,
+    step TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_events_created ON events (created_at, flow);
+
+-- +goose Down
+DROP TABLE IF EXISTS events;
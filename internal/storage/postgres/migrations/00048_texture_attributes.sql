@@ -0,0 +1,7 @@
+-- +goose Up
+-- Characteristics of a material shown side by side by /compare, e.g.
+-- {"Толщина": "1.2 мм", "Основа": "хлопок"}
+ALTER TABLE textures ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE textures DROP COLUMN IF EXISTS attributes;
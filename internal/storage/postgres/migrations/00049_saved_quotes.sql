@@ -0,0 +1,19 @@
+-- +goose Up
+-- Quotes customers saved for later. draft is the dialog draft to resume;
+-- its price holds for SAVED_QUOTE_VALIDITY from created_at.
+CREATE TABLE saved_quotes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    service_type VARCHAR(32) NOT NULL,
+    title TEXT NOT NULL,
+    draft JSONB NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_saved_quotes_user ON saved_quotes (user_id, expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS saved_quotes;
@@ -0,0 +1,76 @@
+-- +goose Up
+-- Customers know an order by its code, AT-<year>-<number> with the number
+-- starting over every year; the id stays internal. The trigger numbers
+-- every new order, the bot's, imported ones and those added in psql alike.
+CREATE TABLE order_code_counters (
+    year INT PRIMARY KEY,
+    last INT NOT NULL
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION format_order_code(year INT, number INT) RETURNS TEXT AS $$
+    SELECT 'AT-' || year || '-' || lpad(number::text, GREATEST(5, length(number::text)), '0');
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+ALTER TABLE orders ADD COLUMN code VARCHAR(32);
+
+-- Existing orders are numbered in the order they were placed, archived
+-- ones included so they keep their code when a month is opened again
+CREATE TEMPORARY TABLE order_code_backfill ON COMMIT DROP AS
+SELECT id,
+       EXTRACT(YEAR FROM created_at)::int AS year,
+       ROW_NUMBER() OVER (PARTITION BY EXTRACT(YEAR FROM created_at) ORDER BY created_at, id)::int AS number
+FROM (
+    SELECT id, created_at FROM orders
+    UNION ALL
+    SELECT id, created_at FROM orders_archive
+) placed;
+
+UPDATE orders o
+SET code = format_order_code(b.year, b.number)
+FROM order_code_backfill b
+WHERE o.id = b.id;
+
+UPDATE orders_archive a
+SET data = jsonb_set(data, '{order,code}', to_jsonb(format_order_code(b.year, b.number)))
+FROM order_code_backfill b
+WHERE a.id = b.id;
+
+INSERT INTO order_code_counters (year, last)
+SELECT year, MAX(number)
+FROM order_code_backfill
+GROUP BY year;
+
+-- +goose StatementBegin
+CREATE FUNCTION assign_order_code() RETURNS trigger AS $$
+DECLARE
+    order_year   INT := EXTRACT(YEAR FROM COALESCE(NEW.created_at, NOW()))::int;
+    order_number INT;
+BEGIN
+    IF NEW.code IS NULL THEN
+        -- the counter row lock keeps concurrent orders from sharing a number
+        INSERT INTO order_code_counters AS c (year, last) VALUES (order_year, 1)
+        ON CONFLICT (year) DO UPDATE SET last = c.last + 1
+        RETURNING c.last INTO order_number;
+        NEW.code := format_order_code(order_year, order_number);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_code
+    BEFORE INSERT ON orders
+    FOR EACH ROW EXECUTE FUNCTION assign_order_code();
+
+ALTER TABLE orders ALTER COLUMN code SET NOT NULL;
+CREATE UNIQUE INDEX idx_orders_code ON orders (code);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_code;
+DROP TRIGGER IF EXISTS orders_code ON orders;
+DROP FUNCTION IF EXISTS assign_order_code();
+ALTER TABLE orders DROP COLUMN IF EXISTS code;
+DROP FUNCTION IF EXISTS format_order_code(INT, INT);
+DROP TABLE IF EXISTS order_code_counters;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- One row per customer, derived from users, orders and the archive; the
+-- bot keeps it current and the sync at start, then daily, fills it and
+-- repairs any drift. Cancelled and deleted orders count toward neither
+-- orders nor total_spend.
+CREATE TABLE customers (
+    user_id        BIGINT         PRIMARY KEY,
+    username       VARCHAR(32),
+    first_seen_at  TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    agreed_at      TIMESTAMPTZ,
+    first_order_at TIMESTAMPTZ,
+    last_order_at  TIMESTAMPTZ,
+    orders         INTEGER        NOT NULL DEFAULT 0,
+    total_spend    DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    updated_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_customers_total_spend ON customers (total_spend DESC) WHERE orders > 0;
+
+-- +goose Down
+DROP TABLE IF EXISTS customers;
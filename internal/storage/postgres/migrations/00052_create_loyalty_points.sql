@@ -0,0 +1,24 @@
+-- +goose Up
+-- Points a customer earned, one row per completed order. The balance is
+-- the points neither expired nor revoked (their order cancelled after
+-- completion). order_id has no foreign key: points outlive archiving.
+CREATE TABLE loyalty_points (
+    id         SERIAL      PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    order_id   INTEGER     NOT NULL,
+    points     INTEGER     NOT NULL CHECK (points > 0),
+    earned_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    expired_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+
+    CONSTRAINT loyalty_points_order_unique UNIQUE (order_id)
+);
+
+CREATE INDEX idx_loyalty_points_user_id ON loyalty_points (user_id)
+    WHERE expired_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX idx_loyalty_points_expires_at ON loyalty_points (expires_at)
+    WHERE expired_at IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS loyalty_points;
@@ -0,0 +1,49 @@
+-- +goose Up
+-- A card starts as a customer's request to buy it, becomes active when a
+-- manager issues it after payment, and can be voided. Only the SHA-256 of
+-- the code is kept: the code is shown once, to whoever the card is for.
+CREATE TABLE gift_cards (
+    id         SERIAL         PRIMARY KEY,
+    code_hash  CHAR(64),
+    -- the last characters of the code, so staff can tell cards apart
+    code_hint  VARCHAR(4),
+    amount     DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    balance    DECIMAL(10, 2) NOT NULL CHECK (balance >= 0),
+    currency   CHAR(3)        NOT NULL,
+    status     VARCHAR(20)    NOT NULL DEFAULT 'requested',
+    buyer_id   BIGINT,
+    issued_by  BIGINT,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    issued_at  TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    voided_at  TIMESTAMPTZ,
+
+    CONSTRAINT gift_cards_code_hash_unique UNIQUE (code_hash),
+    CONSTRAINT gift_cards_status_check CHECK (status IN ('requested', 'active', 'void')),
+    CONSTRAINT gift_cards_balance_check CHECK (balance <= amount),
+    CONSTRAINT gift_cards_code_check CHECK (status <> 'active' OR code_hash IS NOT NULL)
+);
+
+CREATE INDEX idx_gift_cards_requested ON gift_cards (created_at) WHERE status = 'requested';
+
+-- What each order took off a card; refunded when the order is cancelled
+CREATE TABLE gift_card_redemptions (
+    order_id     INTEGER        PRIMARY KEY,
+    gift_card_id INTEGER        NOT NULL,
+    amount       DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    refunded_at  TIMESTAMPTZ,
+
+    CONSTRAINT fk_gift_card_redemptions_card FOREIGN KEY(gift_card_id) REFERENCES gift_cards(id)
+);
+
+CREATE INDEX idx_gift_card_redemptions_card ON gift_card_redemptions (gift_card_id);
+
+-- Part of price the customer paid with a gift card; price stays the
+-- order's full value
+ALTER TABLE orders ADD COLUMN gift_card_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS gift_card_amount;
+DROP TABLE IF EXISTS gift_card_redemptions;
+DROP TABLE IF EXISTS gift_cards;
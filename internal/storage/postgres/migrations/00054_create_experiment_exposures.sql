@@ -0,0 +1,20 @@
+-- +goose Up
+-- One row per customer and experiment: the variant they were first shown
+-- and the first order they placed after it. order_id has no foreign key:
+-- results outlive archiving.
+CREATE TABLE experiment_exposures (
+    experiment   VARCHAR(32) NOT NULL,
+    user_id      BIGINT      NOT NULL,
+    variant      VARCHAR(32) NOT NULL,
+    exposed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    converted_at TIMESTAMPTZ,
+    order_id     INTEGER,
+
+    PRIMARY KEY (experiment, user_id)
+);
+
+CREATE INDEX idx_experiment_exposures_user_id ON experiment_exposures (user_id)
+    WHERE converted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS experiment_exposures;
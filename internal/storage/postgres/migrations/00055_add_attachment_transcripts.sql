@@ -0,0 +1,8 @@
+-- +goose Up
+-- Voice messages of customers are kept as attachments; transcript is their
+-- text from the speech-to-text provider, NULL while transcription is off or
+-- when it failed
+ALTER TABLE attachments ADD COLUMN transcript TEXT;
+
+-- +goose Down
+ALTER TABLE attachments DROP COLUMN IF EXISTS transcript;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Set when admins were told the texture runs low; cleared once its stock is
+-- back above the threshold, so the next shortage is reported again
+ALTER TABLE textures ADD COLUMN low_stock_alerted_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE textures DROP COLUMN IF EXISTS low_stock_alerted_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Saved layouts of the orders export. columns are export column names in
+-- sheet order; headers replace their titles, per locale, with "" for all
+-- locales; statuses, when not empty, limit the export to those statuses.
+CREATE TABLE report_templates (
+    id         SERIAL       PRIMARY KEY,
+    name       VARCHAR(50)  NOT NULL,
+    columns    TEXT[]       NOT NULL,
+    headers    JSONB        NOT NULL DEFAULT '{}',
+    statuses   TEXT[]       NOT NULL DEFAULT '{}',
+    created_by BIGINT       NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT report_templates_name_unique UNIQUE (name),
+    CONSTRAINT report_templates_columns_check CHECK (cardinality(columns) > 0)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS report_templates;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- The price shown on an order summary. priced_at is the moment whose
+-- materials and rates it was quoted at; until expires_at the order is
+-- placed at that price, later it is priced again and the customer is shown
+-- the difference first. A customer keeps one per product line.
+CREATE TABLE price_quotes (
+    id           BIGSERIAL      PRIMARY KEY,
+    user_id      BIGINT         NOT NULL,
+    service_type VARCHAR(32)    NOT NULL,
+    breakdown    JSONB          NOT NULL,
+    price        DECIMAL(10, 2) NOT NULL,
+    currency     CHAR(3)        NOT NULL,
+    priced_at    TIMESTAMPTZ    NOT NULL,
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ    NOT NULL
+);
+
+CREATE INDEX idx_price_quotes_user ON price_quotes (user_id, service_type);
+
+-- +goose Down
+DROP TABLE IF EXISTS price_quotes;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- With PREPAYMENT_PERCENT set an order waits in awaiting_prepayment until
+-- the customer pays the deposit; then it becomes new and goes to production
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+-- Must stay in sync with postgres.OrderStatuses
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold', 'awaiting_prepayment'));
+
+-- prepayment is the deposit asked when the order was placed, paid_amount
+-- what the customer has paid so far; both stay 0 for orders without one
+ALTER TABLE orders ADD COLUMN prepayment DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN paid_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Payments received through Telegram invoices. The charge id makes a
+-- redelivered payment update count once.
+CREATE TABLE payments (
+    id                  BIGSERIAL      PRIMARY KEY,
+    order_id            INTEGER        NOT NULL,
+    user_id             BIGINT         NOT NULL,
+    amount              DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    currency            CHAR(3)        NOT NULL,
+    telegram_charge_id  VARCHAR(255)   NOT NULL,
+    provider_charge_id  VARCHAR(255)   NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT payments_telegram_charge_unique UNIQUE (telegram_charge_id)
+);
+
+CREATE INDEX idx_payments_order ON payments (order_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS payments;
+ALTER TABLE orders DROP COLUMN IF EXISTS paid_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS prepayment;
+
+UPDATE orders SET status = 'new' WHERE status = 'awaiting_prepayment';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold'));
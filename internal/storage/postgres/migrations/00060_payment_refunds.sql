@@ -0,0 +1,43 @@
+-- +goose Up
+-- The payments table becomes the ledger of all money moved for an order:
+-- payments in, refunds and chargebacks out. amount stays positive, kind
+-- gives the direction; orders.paid_amount is the net of the ledger.
+ALTER TABLE payments ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'payment';
+ALTER TABLE payments ADD CONSTRAINT payments_kind_check
+    CHECK (kind IN ('payment', 'refund', 'chargeback'));
+
+-- Refunds and chargebacks are entered by staff with the provider's
+-- reference, not delivered by Telegram
+ALTER TABLE payments ALTER COLUMN telegram_charge_id DROP NOT NULL;
+ALTER TABLE payments ADD COLUMN recorded_by BIGINT;
+ALTER TABLE payments ADD COLUMN note TEXT NOT NULL DEFAULT '';
+
+-- The same refund or chargeback entered twice counts once
+CREATE UNIQUE INDEX idx_payments_provider_reference ON payments (kind, provider_charge_id)
+    WHERE kind <> 'payment' AND provider_charge_id <> '';
+
+CREATE INDEX idx_payments_created_at ON payments (created_at);
+
+-- Refunds and chargebacks are logged with the order's events
+ALTER TABLE order_events DROP CONSTRAINT IF EXISTS order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN (
+    'draft_started', 'quote_shown', 'confirmed', 'paid', 'refunded', 'charged_back',
+    'produced', 'shipped', 'status_changed'
+));
+
+-- +goose Down
+-- order_events is append-only, the reversals logged stay: the narrower
+-- check is added without validating them
+ALTER TABLE order_events DROP CONSTRAINT IF EXISTS order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN (
+    'draft_started', 'quote_shown', 'confirmed', 'paid', 'produced', 'shipped', 'status_changed'
+)) NOT VALID;
+
+DROP INDEX IF EXISTS idx_payments_created_at;
+DROP INDEX IF EXISTS idx_payments_provider_reference;
+DELETE FROM payments WHERE kind <> 'payment';
+ALTER TABLE payments DROP COLUMN IF EXISTS note;
+ALTER TABLE payments DROP COLUMN IF EXISTS recorded_by;
+ALTER TABLE payments ALTER COLUMN telegram_charge_id SET NOT NULL;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_kind_check;
+ALTER TABLE payments DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- The regime the rule's tax rate applies under. Orders were taxed on the
+-- price until now, which is what existing rules keep.
+ALTER TABLE pricing_rules ADD COLUMN tax_regime VARCHAR(20) NOT NULL DEFAULT 'usn_income';
+ALTER TABLE pricing_rules ADD CONSTRAINT pricing_rules_tax_regime_check
+    CHECK (tax_regime IN ('usn_income', 'usn_profit', 'npd', 'vat'));
+
+-- +goose Down
+ALTER TABLE pricing_rules DROP CONSTRAINT IF EXISTS pricing_rules_tax_regime_check;
+ALTER TABLE pricing_rules DROP COLUMN IF EXISTS tax_regime;
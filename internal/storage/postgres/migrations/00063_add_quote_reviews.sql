@@ -0,0 +1,53 @@
+-- +goose Up
+-- Orders from QUOTE_APPROVAL_FROM or with custom requirements wait in
+-- pending_quote until a manager approves or adjusts the price
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+-- Must stay in sync with postgres.OrderStatuses
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold', 'awaiting_prepayment', 'pending_quote'));
+
+-- requirements are the customer's own wishes for the order, free text
+ALTER TABLE orders ADD COLUMN requirements TEXT NOT NULL DEFAULT '';
+
+-- calculated_price is what the calculator quoted, price what the manager
+-- approved; they differ when the price was adjusted
+CREATE TABLE quote_reviews (
+    id               BIGSERIAL      PRIMARY KEY,
+    order_id         INTEGER        NOT NULL,
+    reason           VARCHAR(255)   NOT NULL,
+    calculated_price DECIMAL(10, 2) NOT NULL,
+    price            DECIMAL(10, 2),
+    created_at       TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    resolved_by      BIGINT,
+    resolved_at      TIMESTAMPTZ,
+
+    CONSTRAINT fk_quote_reviews_order
+      FOREIGN KEY(order_id)
+      REFERENCES orders(id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX idx_quote_reviews_open ON quote_reviews (order_id) WHERE resolved_at IS NULL;
+
+ALTER TABLE order_events DROP CONSTRAINT IF EXISTS order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN (
+    'draft_started', 'quote_shown', 'confirmed', 'quote_approved', 'paid', 'refunded', 'charged_back',
+    'produced', 'shipped', 'status_changed'
+));
+
+-- +goose Down
+ALTER TABLE order_events DROP CONSTRAINT IF EXISTS order_events_event_type_check;
+ALTER TABLE order_events ADD CONSTRAINT order_events_event_type_check CHECK (event_type IN (
+    'draft_started', 'quote_shown', 'confirmed', 'paid', 'refunded', 'charged_back',
+    'produced', 'shipped', 'status_changed'
+)) NOT VALID;
+
+DROP INDEX IF EXISTS idx_quote_reviews_open;
+DROP TABLE IF EXISTS quote_reviews;
+ALTER TABLE orders DROP COLUMN IF EXISTS requirements;
+
+UPDATE orders SET status = 'new' WHERE status = 'pending_quote';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('new', 'processing', 'completed', 'cancelled', 'on_hold', 'awaiting_prepayment'));
@@ -0,0 +1,21 @@
+-- +goose Up
+-- A customer's rating of a completed order, asked for REVIEW_DELAY after
+-- completion. comment_due is set while the bot waits for the optional
+-- comment that follows the rating. There is no foreign key to orders:
+-- reviews stay when their orders are archived.
+CREATE TABLE reviews (
+    id          BIGSERIAL   PRIMARY KEY,
+    order_id    INTEGER     NOT NULL UNIQUE,
+    user_id     BIGINT      NOT NULL,
+    rating      SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment     TEXT        NOT NULL DEFAULT '',
+    comment_due BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reviews_comment_due ON reviews (user_id) WHERE comment_due;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reviews_comment_due;
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Comments are shown in /reviews once a manager approves them; ratings
+-- without a comment are never shown and skip moderation
+ALTER TABLE reviews
+    ADD COLUMN moderation   VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (moderation IN ('pending', 'approved', 'rejected')),
+    ADD COLUMN moderated_by BIGINT,
+    ADD COLUMN moderated_at TIMESTAMPTZ;
+
+CREATE INDEX idx_reviews_moderation_queue ON reviews (id)
+    WHERE moderation = 'pending' AND comment <> '' AND NOT comment_due;
+CREATE INDEX idx_reviews_published ON reviews (created_at DESC)
+    WHERE moderation = 'approved';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reviews_published;
+DROP INDEX IF EXISTS idx_reviews_moderation_queue;
+ALTER TABLE reviews
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderation;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The vinyl and paper materials were seeded without a picture; no picture
+-- is the empty string from now on, as the code reads it
+UPDATE textures SET image_url = '' WHERE image_url IS NULL;
+ALTER TABLE textures
+    ALTER COLUMN image_url SET DEFAULT '',
+    ALTER COLUMN image_url SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE textures
+    ALTER COLUMN image_url DROP NOT NULL,
+    ALTER COLUMN image_url DROP DEFAULT;
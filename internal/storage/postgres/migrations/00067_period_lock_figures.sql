@@ -0,0 +1,90 @@
+-- +goose Up
+-- The figures a closed period freezes are listed once, in
+-- order_locked_figures; the money columns added to orders later extend
+-- the list there instead of the trigger.
+-- +goose StatementBegin
+CREATE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('adtime.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR order_locked_figures(OLD) IS DISTINCT FROM order_locked_figures(NEW) THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION enforce_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('adtime.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) THEN
+        IF TG_OP = 'DELETE' OR
+           (OLD.created_at, OLD.width_cm, OLD.height_cm, OLD.texture_id, OLD.price,
+            OLD.leather_cost, OLD.process_cost, OLD.total_cost, OLD.commission,
+            OLD.tax, OLD.net_revenue, OLD.profit, OLD.is_rush, OLD.rush_surcharge)
+           IS DISTINCT FROM
+           (NEW.created_at, NEW.width_cm, NEW.height_cm, NEW.texture_id, NEW.price,
+            NEW.leather_cost, NEW.process_cost, NEW.total_cost, NEW.commission,
+            NEW.tax, NEW.net_revenue, NEW.profit, NEW.is_rush, NEW.rush_surcharge)
+        THEN
+            RAISE EXCEPTION 'order % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM period_closes
+        WHERE unlocked_at IS NULL
+          AND NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) AND (TG_OP = 'INSERT' OR NEW.created_at IS DISTINCT FROM OLD.created_at) THEN
+        RAISE EXCEPTION 'order date % belongs to a closed period', NEW.created_at USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP FUNCTION IF EXISTS order_locked_figures(orders);
@@ -0,0 +1,23 @@
+-- +goose Up
+-- A closed period freezes the promo code discount of its orders too
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge,
+        o.discount
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- A closed period freezes the part of its orders paid with a gift card too
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge,
+        o.discount, o.currency, o.exchange_rates, o.gift_card_amount
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge,
+        o.discount, o.currency, o.exchange_rates
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
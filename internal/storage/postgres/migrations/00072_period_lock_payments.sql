@@ -0,0 +1,62 @@
+-- +goose Up
+-- A closed period freezes the deposit asked and the amount paid of its
+-- orders, and with them the payments ledger of those orders: no payment,
+-- refund or chargeback can be entered, changed or removed until the period
+-- is unlocked.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge,
+        o.discount, o.currency, o.exchange_rates, o.gift_card_amount,
+        o.prepayment, o.paid_amount
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION enforce_payment_period_lock() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM orders o
+        JOIN period_closes p ON o.created_at >= p.period_start AND o.created_at < p.period_end
+        WHERE o.id = OLD.order_id AND p.unlocked_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'payment % belongs to a closed period', OLD.id USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM orders o
+        JOIN period_closes p ON o.created_at >= p.period_start AND o.created_at < p.period_end
+        WHERE o.id = NEW.order_id AND p.unlocked_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'order % belongs to a closed period', NEW.order_id USING ERRCODE = 'PC001';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER payments_period_lock
+    BEFORE INSERT OR UPDATE OR DELETE ON payments
+    FOR EACH ROW EXECUTE FUNCTION enforce_payment_period_lock();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS payments_period_lock ON payments;
+DROP FUNCTION IF EXISTS enforce_payment_period_lock();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION order_locked_figures(o orders) RETURNS jsonb AS $$
+    SELECT jsonb_build_array(
+        o.created_at, o.width_cm, o.height_cm, o.texture_id, o.price,
+        o.leather_cost, o.process_cost, o.total_cost, o.commission,
+        o.tax, o.net_revenue, o.profit, o.is_rush, o.rush_surcharge,
+        o.discount, o.currency, o.exchange_rates, o.gift_card_amount
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- DeleteUserData blanks the contact of a customer's orders and the address
+-- they were shipped to. Orders are still placed with both, see
+-- orders.Place.
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_contact_check;
+ALTER TABLE orders ADD CONSTRAINT orders_contact_check
+    CHECK (contact = '' OR contact ~ '^\+[0-9]{10,15}$');
+
+ALTER TABLE delivery DROP CONSTRAINT IF EXISTS delivery_address_check;
+
+-- +goose Down
+-- The erased rows stay: the checks are added without validating them
+ALTER TABLE delivery ADD CONSTRAINT delivery_address_check
+    CHECK (method = 'pickup' OR address <> '') NOT VALID;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_contact_check;
+ALTER TABLE orders ADD CONSTRAINT orders_contact_check
+    CHECK (contact ~ '^\+[0-9]{10,15}$') NOT VALID;
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"time"
+)
+
+// OrderNote is a production note left by staff on an order
+type OrderNote struct {
+	ID        int64     `db:"id"`
+	OrderID   int64     `db:"order_id"`
+	AuthorID  int64     `db:"author_id"`
+	Text      string    `db:"text"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AddOrderNote appends a note to the order. Returns errs.ErrOrderNotFound for
+// unknown and deleted orders.
+func (s *PostgresStorage) AddOrderNote(ctx context.Context, orderID, authorID int64, text string) (*OrderNote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO order_notes (order_id, author_id, text)
+        SELECT id, $2, $3
+        FROM orders
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, order_id, author_id, text, created_at
+    `
+
+	var note OrderNote
+	err := s.db.GetContext(ctx, &note, query, orderID, authorID, text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add order note: %w", err)
+	}
+	return &note, nil
+}
+
+// GetOrderNotes returns the notes of an order, oldest first
+func (s *PostgresStorage) GetOrderNotes(ctx context.Context, orderID int64) ([]OrderNote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, order_id, author_id, text, created_at
+        FROM order_notes
+        WHERE order_id = $1
+        ORDER BY created_at, id
+    `
+
+	var notes []OrderNote
+	if err := s.db.SelectContext(ctx, &notes, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order notes: %w", err)
+	}
+	return notes, nil
+}
+
+// LatestOrderNote returns the newest note of an order, nil when it has none
+func (s *PostgresStorage) LatestOrderNote(ctx context.Context, orderID int64) (*OrderNote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, order_id, author_id, text, created_at
+        FROM order_notes
+        WHERE order_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT 1
+    `
+
+	var note OrderNote
+	err := s.db.GetContext(ctx, &note, query, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest order note: %w", err)
+	}
+	return &note, nil
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/units"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OrderItem is one product of an order. Costs and the price are the item's
+// share of the order before the promo discount; commission and tax are kept
+// on the order only.
+type OrderItem struct {
+	ID          int64          `db:"id"`
+	OrderID     int64          `db:"order_id"`
+	Position    int            `db:"position"`
+	ServiceType ServiceType    `db:"service_type"`
+	TextureID   string         `db:"texture_id"`
+	TextureName string         `db:"texture_name"`
+	WidthCM     int            `db:"width_cm"`
+	HeightCM    int            `db:"height_cm"`
+	Quantity    int            `db:"quantity"`
+	Options     ProductOptions `db:"options"`
+
+	Price         money.Amount `db:"price"`
+	LeatherCost   money.Amount `db:"leather_cost"`
+	ProcessCost   money.Amount `db:"process_cost"`
+	TotalCost     money.Amount `db:"total_cost"`
+	RushSurcharge money.Amount `db:"rush_surcharge"`
+	ReservedDM2   float64      `db:"reserved_dm2"`
+}
+
+// MaterialDM2 is the material the item consumes
+func (i OrderItem) MaterialDM2() float64 {
+	return float64(i.WidthCM*i.HeightCM) / 100 * float64(max(i.Quantity, 1))
+}
+
+// String describes the item for exports and staff messages, e.g. "sticker 5×5 cm ×100"
+func (i OrderItem) String() string {
+	return i.Describe(units.CM)
+}
+
+// Describe is String with the size in the unit, e.g. "sticker 50×50 mm ×100"
+func (i OrderItem) Describe(unit units.Unit) string {
+	return fmt.Sprintf("%s %s×%s %s ×%d", i.ServiceType.OrLeather(),
+		unit.Number(i.WidthCM), unit.Number(i.HeightCM), unit, max(i.Quantity, 1))
+}
+
+// LineItems returns the products of the order. Orders built without Items,
+// or loaded without them, are a single product described by the order columns.
+func (o Order) LineItems() []OrderItem {
+	if len(o.Items) > 0 {
+		return o.Items
+	}
+	return []OrderItem{{
+		OrderID:       o.ID,
+		Position:      1,
+		ServiceType:   o.ServiceType.OrLeather(),
+		TextureID:     o.TextureID,
+		TextureName:   o.TextureName,
+		WidthCM:       o.WidthCM,
+		HeightCM:      o.HeightCM,
+		Quantity:      max(o.Quantity, 1),
+		Options:       o.Options,
+		Price:         o.Price + o.Discount,
+		LeatherCost:   o.LeatherCost,
+		ProcessCost:   o.ProcessCost,
+		TotalCost:     o.TotalCost,
+		RushSurcharge: o.RushSurcharge,
+		ReservedDM2:   o.ReservedDM2,
+	}}
+}
+
+// describeItems joins the items into one spreadsheet cell
+func describeItems(items []OrderItem, unit units.Unit) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = item.Describe(unit)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// GetOrderItems returns the products of an order in the order they were added
+func (s *PostgresStorage) GetOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	items, err := getItemsByOrder(ctx, s.db, []int64{orderID})
+	if err != nil {
+		return nil, err
+	}
+	return items[orderID], nil
+}
+
+// getItemsByOrder loads the items of several orders with one query
+func getItemsByOrder(ctx context.Context, db sqlx.QueryerContext, orderIDs []int64) (map[int64][]OrderItem, error) {
+	const query = `
+        SELECT i.id, i.order_id, i.position, i.service_type, i.texture_id::text,
+               COALESCE(t.name, '') AS texture_name, i.width_cm, i.height_cm, i.quantity,
+               i.options, i.price, i.leather_cost, i.process_cost, i.total_cost,
+               i.rush_surcharge, i.reserved_dm2
+        FROM order_items i
+        LEFT JOIN textures t ON t.id = i.texture_id
+        WHERE i.order_id = ANY($1)
+        ORDER BY i.order_id, i.position
+    `
+
+	byOrder := make(map[int64][]OrderItem, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return byOrder, nil
+	}
+
+	var items []OrderItem
+	if err := sqlx.SelectContext(ctx, db, &items, query, orderIDs); err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	for _, item := range items {
+		byOrder[item.OrderID] = append(byOrder[item.OrderID], item)
+	}
+	return byOrder, nil
+}
+
+// attachItems fills Items of the given orders
+func attachItems(ctx context.Context, db sqlx.QueryerContext, orders []Order) error {
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+
+	items, err := getItemsByOrder(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].ID]
+	}
+	return nil
+}
+
+func insertOrderItems(ctx context.Context, tx *sqlx.Tx, orderID int64, items []OrderItem) error {
+	const query = `
+        INSERT INTO order_items (
+            order_id, position, service_type, texture_id, width_cm, height_cm, quantity, options,
+            price, leather_cost, process_cost, total_cost, rush_surcharge, reserved_dm2
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+    `
+
+	for i, item := range items {
+		if _, err := tx.ExecContext(ctx, query,
+			orderID,
+			i+1,
+			item.ServiceType,
+			item.TextureID,
+			item.WidthCM,
+			item.HeightCM,
+			max(item.Quantity, 1),
+			item.Options,
+			item.Price,
+			item.LeatherCost,
+			item.ProcessCost,
+			item.TotalCost,
+			item.RushSurcharge,
+			item.ReservedDM2,
+		); err != nil {
+			return fmt.Errorf("failed to save order item %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// backfillOrderItems gives orders inserted without items (e.g. imported
+// history) a single item made of the order columns
+func backfillOrderItems(ctx context.Context, tx *sqlx.Tx) error {
+	const query = `
+        INSERT INTO order_items (
+            order_id, position, service_type, texture_id, width_cm, height_cm, quantity, options,
+            price, leather_cost, process_cost, total_cost, rush_surcharge, reserved_dm2
+        )
+        SELECT o.id, 1, o.service_type, o.texture_id, o.width_cm, o.height_cm, o.quantity, o.options,
+               o.price, o.leather_cost, o.process_cost, o.total_cost, o.rush_surcharge, o.reserved_dm2
+        FROM orders o
+        WHERE NOT EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)
+    `
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to backfill order items: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"strings"
+	"time"
+)
+
+// ErrDuplicateOrder is returned by SaveOrder with the ID of the order that
+// was already saved under the same idempotency key set on the order
+var ErrDuplicateOrder = errors.New("order already saved")
+
+const maxOrdersPage = 500
+
+// OrderFilter selects orders for integrations. Zero values are not applied.
+type OrderFilter struct {
+	Status       OrderStatus
+	UpdatedSince time.Time
+	// AfterID is the keyset cursor: the last ID of the previous page
+	AfterID int64
+	Limit   int
+}
+
+// ListOrders returns orders ordered by ID, at most maxOrdersPage per call
+func (s *PostgresStorage) ListOrders(ctx context.Context, filter OrderFilter) ([]Order, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT o.*, COALESCE(t.name, '') AS texture_name
+        FROM orders o
+        LEFT JOIN textures t ON o.texture_id = t.id
+        WHERE o.id > $1
+          AND ($2 = '' OR o.status = $2)
+          AND ($3::timestamp IS NULL OR o.updated_at >= $3)
+        ORDER BY o.id
+        LIMIT $4
+    `
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxOrdersPage {
+		limit = maxOrdersPage
+	}
+
+	var since *time.Time
+	if !filter.UpdatedSince.IsZero() {
+		since = &filter.UpdatedSince
+	}
+
+	var orders []Order
+	if err := s.db.SelectContext(ctx, &orders, query, filter.AfterID, string(filter.Status), since, limit); err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return orders, nil
+}
+
+// RecentOrderByFingerprint returns the latest order of the user with the
+// same fingerprint placed since the given time, nil if there is none.
+// Cancelled orders and the order saved under idempotencyKey (a redelivered
+// confirmation) don't count.
+func (s *PostgresStorage) RecentOrderByFingerprint(ctx context.Context, userID int64, fingerprint string, since time.Time, idempotencyKey string) (*Order, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT o.*, COALESCE(t.name, '') AS texture_name
+        FROM orders o
+        LEFT JOIN textures t ON o.texture_id = t.id
+        WHERE o.user_id = $1
+          AND o.fingerprint = $2
+          AND o.created_at >= $3
+          AND o.status <> 'cancelled'
+          AND o.deleted_at IS NULL
+          AND ($4::text = '' OR o.idempotency_key IS DISTINCT FROM $4::text)
+        ORDER BY o.created_at DESC
+        LIMIT 1
+    `
+
+	var order Order
+	err := s.db.GetContext(ctx, &order, query, userID, fingerprint, since, idempotencyKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repeated order: %w", err)
+	}
+	return &order, nil
+}
+
+func (s *PostgresStorage) orderByIdempotencyKey(ctx context.Context, key string) (int64, error) {
+	var id int64
+	err := s.db.GetContext(ctx, &id, `SELECT id FROM orders WHERE idempotency_key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+	return id, ErrDuplicateOrder
+}
+
+// GetOrderCode returns the code customers know the order by
+func (s *PostgresStorage) GetOrderCode(ctx context.Context, orderID int64) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var code string
+	err := s.db.GetContext(ctx, &code, `SELECT code FROM orders WHERE id = $1`, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get order code: %w", err)
+	}
+	return code, nil
+}
+
+// GetOrderIDByCode finds an order by its code, typed in any case
+func (s *PostgresStorage) GetOrderIDByCode(ctx context.Context, code string) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := s.db.GetContext(ctx, &id, `SELECT id FROM orders WHERE code = $1`, strings.ToUpper(strings.TrimSpace(code)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find order by code: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OutboxOrderCreated is written in the same transaction as a new order
+// (and again when a held order is approved)
+const OutboxOrderCreated = "order.created"
+
+// OutboxStatusChanged is written in the same transaction as every status
+// change, see recordStatusChange
+const OutboxStatusChanged = "order.status_changed"
+
+// OrderOutboxPayload identifies the order an outbox message is about.
+// Senders load the current order state at delivery time.
+type OrderOutboxPayload struct {
+	OrderID int64       `json:"order_id"`
+	UserID  int64       `json:"user_id"`
+	Status  OrderStatus `json:"status"`
+	// PrevStatus is set for status changes
+	PrevStatus OrderStatus `json:"prev_status,omitempty"`
+}
+
+// OutboxMessage is a side effect waiting for at-least-once delivery
+type OutboxMessage struct {
+	ID            int64           `db:"id"`
+	Kind          string          `db:"kind"`
+	Payload       json.RawMessage `db:"payload"`
+	Attempts      int             `db:"attempts"`
+	LastError     string          `db:"last_error"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+// EnqueueOutbox schedules a message outside of any business transaction
+func (s *PostgresStorage) EnqueueOutbox(ctx context.Context, kind string, payload any) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return enqueueOutbox(ctx, s.db, kind, payload)
+}
+
+func enqueueOutbox(ctx context.Context, db sqlx.ExecerContext, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx,
+		`INSERT INTO outbox (kind, payload) VALUES ($1, $2)`, kind, data); err != nil {
+		return fmt.Errorf("failed to enqueue outbox message: %w", err)
+	}
+	return nil
+}
+
+// recordStatusChange logs a status change in order_events and queues its
+// outbox message, both in the transaction that makes the change, so the
+// change can't commit without them. A cancellation puts the gift card
+// money of the order back in the same transaction.
+func recordStatusChange(ctx context.Context, tx sqlx.ExecerContext, orderID, userID int64, prev, status OrderStatus) error {
+	if prev == status {
+		return nil
+	}
+	if err := appendStatusChange(ctx, tx, orderID, userID, prev, status); err != nil {
+		return err
+	}
+	if status == StatusCancelled {
+		if err := refundGiftCard(ctx, tx, orderID); err != nil {
+			return err
+		}
+	}
+	return enqueueOutbox(ctx, tx, OutboxStatusChanged, OrderOutboxPayload{
+		OrderID:    orderID,
+		UserID:     userID,
+		Status:     status,
+		PrevStatus: prev,
+	})
+}
+
+// ClaimOutbox leases up to limit due messages. A message whose dispatcher
+// dies mid-delivery becomes due again once the lease expires.
+func (s *PostgresStorage) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE outbox
+        SET attempts = attempts + 1,
+            next_attempt_at = NOW() + $2::interval
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+            ORDER BY next_attempt_at, id
+            FOR UPDATE SKIP LOCKED
+            LIMIT $1
+        )
+        RETURNING id, kind, payload, attempts, last_error, next_attempt_at, created_at
+    `
+
+	var messages []OutboxMessage
+	if err := s.db.SelectContext(ctx, &messages, query, limit, lease.String()); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (s *PostgresStorage) MarkOutboxDelivered(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE outbox SET delivered_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message delivered: %w", err)
+	}
+	return nil
+}
+
+// FanOutOutbox replaces a message with one message per destination kind,
+// so each destination is retried on its own
+func (s *PostgresStorage) FanOutOutbox(ctx context.Context, msg OutboxMessage, kinds []string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, kind := range kinds {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO outbox (kind, payload) VALUES ($1, $2)`, kind, []byte(msg.Payload)); err != nil {
+			return fmt.Errorf("failed to fan out outbox message: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE outbox SET delivered_at = NOW() WHERE id = $1`, msg.ID); err != nil {
+		return fmt.Errorf("failed to mark outbox message delivered: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fan-out: %w", err)
+	}
+	return nil
+}
+
+// RetryOutbox records a failed attempt. A nil retryAt gives up on the message.
+func (s *PostgresStorage) RetryOutbox(ctx context.Context, id int64, deliveryErr error, retryAt *time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE outbox
+        SET last_error = $2,
+            next_attempt_at = COALESCE($3, next_attempt_at),
+            failed_at = CASE WHEN $3::timestamptz IS NULL THEN NOW() END
+        WHERE id = $1
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, id, deliveryErr.Error(), retryAt); err != nil {
+		return fmt.Errorf("failed to reschedule outbox message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrPaymentRecorded means the payment was counted before: Telegram
+	// may deliver the same successful payment again, staff may enter the
+	// same refund twice
+	ErrPaymentRecorded = errors.New("payment already recorded")
+	// ErrRefundExceedsPaid refuses to take back more than the order holds
+	ErrRefundExceedsPaid = errors.New("refund exceeds the amount paid")
+)
+
+// PaymentKind is the direction of a payments row
+type PaymentKind string
+
+const (
+	// PaymentCharge is money the customer paid through an invoice
+	PaymentCharge PaymentKind = "payment"
+	// PaymentRefund is money the workshop returned at the provider
+	PaymentRefund PaymentKind = "refund"
+	// PaymentChargeback is money the customer's bank took back
+	PaymentChargeback PaymentKind = "chargeback"
+)
+
+// Payment is a row of the payments ledger: money paid for an order, or
+// returned by a refund or chargeback. Amount is always positive.
+type Payment struct {
+	ID       int64          `db:"id"`
+	OrderID  int64          `db:"order_id"`
+	UserID   int64          `db:"user_id"`
+	Kind     PaymentKind    `db:"kind"`
+	Amount   money.Amount   `db:"amount"`
+	Currency money.Currency `db:"currency"`
+	// TelegramChargeID identifies an invoice payment; refunds and
+	// chargebacks have none. ProviderChargeID is the provider's reference:
+	// of the payment, the refund or the dispute.
+	TelegramChargeID *string `db:"telegram_charge_id"`
+	ProviderChargeID string  `db:"provider_charge_id"`
+	// RecordedBy is the staff member who entered a refund or chargeback
+	RecordedBy *int64    `db:"recorded_by"`
+	Note       string    `db:"note"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// RecordPayment adds the payment to the order's paid amount. When it
+// covers the deposit of an order awaiting prepayment the order becomes new
+// and is announced to production, as a released hold is; cleared reports
+// that. An order of a closed period takes no payments,
+// ErrPeriodClosed.
+func (s *PostgresStorage) RecordPayment(ctx context.Context, p Payment) (cleared bool, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if p.TelegramChargeID == nil {
+		return false, errors.New("invoice payment without a telegram charge id")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var paymentID int64
+	err = tx.GetContext(ctx, &paymentID, `
+        INSERT INTO payments (order_id, user_id, kind, amount, currency, telegram_charge_id, provider_charge_id)
+        VALUES ($1, $2, 'payment', $3, $4, $5, $6)
+        ON CONFLICT (telegram_charge_id) DO NOTHING
+        RETURNING id
+    `, p.OrderID, p.UserID, p.Amount, p.Currency, p.TelegramChargeID, p.ProviderChargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%w: %s", ErrPaymentRecorded, *p.TelegramChargeID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record payment: %w", periodLockError(err))
+	}
+
+	var order struct {
+		Status     OrderStatus  `db:"status"`
+		Prepayment money.Amount `db:"prepayment"`
+		PaidAmount money.Amount `db:"paid_amount"`
+	}
+	err = tx.GetContext(ctx, &order, `
+        UPDATE orders SET paid_amount = paid_amount + $2, updated_at = NOW()
+        WHERE id = $1
+        RETURNING status, prepayment, paid_amount
+    `, p.OrderID, p.Amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to add payment to order: %w", err)
+	}
+
+	if err := appendOrderEvent(ctx, tx, &p.OrderID, p.UserID, EventPaid, map[string]any{
+		"amount":      p.Amount,
+		"currency":    p.Currency,
+		"paid_amount": order.PaidAmount,
+		"prepayment":  order.Prepayment,
+	}); err != nil {
+		return false, err
+	}
+
+	cleared = order.Status == StatusAwaitingPrepayment && order.PaidAmount >= order.Prepayment
+	if cleared {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE orders SET status = $2, updated_at = NOW() WHERE id = $1`, p.OrderID, StatusNew); err != nil {
+			return false, fmt.Errorf("failed to release order: %w", err)
+		}
+		if err := recordStatusChange(ctx, tx, p.OrderID, p.UserID, order.Status, StatusNew); err != nil {
+			return false, err
+		}
+		// Orders awaiting the deposit were not announced yet
+		if err := enqueueOutbox(ctx, tx, OutboxOrderCreated, OrderOutboxPayload{
+			OrderID: p.OrderID,
+			UserID:  p.UserID,
+			Status:  StatusNew,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit payment: %w", err)
+	}
+	return cleared, nil
+}
+
+// RecordReversal enters a refund or chargeback and takes it off the
+// order's paid amount; ErrPeriodClosed while the order's period is closed.
+// The order's status is left to staff.
+func (s *PostgresStorage) RecordReversal(ctx context.Context, p Payment) (*Payment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	event := EventRefunded
+	switch p.Kind {
+	case PaymentRefund:
+	case PaymentChargeback:
+		event = EventChargedBack
+	default:
+		return nil, fmt.Errorf("not a refund or chargeback: %q", p.Kind)
+	}
+	if p.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %s", money.ErrInvalid, p.Amount)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var order struct {
+		UserID     int64          `db:"user_id"`
+		Currency   money.Currency `db:"currency"`
+		PaidAmount money.Amount   `db:"paid_amount"`
+	}
+	err = tx.GetContext(ctx, &order,
+		`SELECT user_id, currency, paid_amount FROM orders WHERE id = $1 FOR UPDATE`, p.OrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+	if p.Amount > order.PaidAmount {
+		return nil, fmt.Errorf("%w: %s of %s paid", ErrRefundExceedsPaid, p.Amount, order.PaidAmount)
+	}
+
+	var saved Payment
+	err = tx.GetContext(ctx, &saved, `
+        INSERT INTO payments (order_id, user_id, kind, amount, currency, provider_charge_id, recorded_by, note)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING *
+    `, p.OrderID, order.UserID, p.Kind, p.Amount, order.Currency, p.ProviderChargeID, p.RecordedBy, p.Note)
+	if isUniqueViolation(err, "idx_payments_provider_reference") {
+		return nil, fmt.Errorf("%w: %s %s", ErrPaymentRecorded, p.Kind, p.ProviderChargeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", p.Kind, periodLockError(err))
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE orders SET paid_amount = paid_amount - $2, updated_at = NOW() WHERE id = $1`,
+		p.OrderID, p.Amount); err != nil {
+		return nil, fmt.Errorf("failed to take %s off order: %w", p.Kind, err)
+	}
+
+	if err := appendOrderEvent(ctx, tx, &p.OrderID, order.UserID, event, map[string]any{
+		"amount":      p.Amount,
+		"reference":   p.ProviderChargeID,
+		"paid_amount": order.PaidAmount - p.Amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit %s: %w", p.Kind, err)
+	}
+	return &saved, nil
+}
+
+// GetOrderPayments returns the ledger of the order, oldest first
+func (s *PostgresStorage) GetOrderPayments(ctx context.Context, orderID int64) ([]Payment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var payments []Payment
+	err := s.db.SelectContext(ctx, &payments,
+		`SELECT * FROM payments WHERE order_id = $1 ORDER BY created_at, id`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order payments: %w", err)
+	}
+	return payments, nil
+}
+
+// PaymentTotal sums the rows of one kind
+type PaymentTotal struct {
+	Kind   PaymentKind  `db:"kind"`
+	Count  int          `db:"count"`
+	Amount money.Amount `db:"amount"`
+}
+
+// GetPaymentTotals sums the ledger by kind over [since, until)
+func (s *PostgresStorage) GetPaymentTotals(ctx context.Context, since, until time.Time) ([]PaymentTotal, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var totals []PaymentTotal
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &totals, `
+            SELECT kind, COUNT(*) AS count, SUM(amount) AS amount
+            FROM payments
+            WHERE created_at >= $1 AND created_at < $2
+            GROUP BY kind
+            ORDER BY kind
+        `, since, until)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment totals: %w", err)
+	}
+	return totals, nil
+}
+
+// Problems PaymentDiscrepancy can show
+const (
+	// DiscrepancyLedger: the order's paid amount is not the net of its ledger
+	DiscrepancyLedger = "ledger"
+	// DiscrepancyOverpaid: the order got more than its total
+	DiscrepancyOverpaid = "overpaid"
+	// DiscrepancyOverRefunded: more went back than was paid
+	DiscrepancyOverRefunded = "over_refunded"
+	// DiscrepancyRefundDue: a cancelled order still holds the customer's money
+	DiscrepancyRefundDue = "refund_due"
+)
+
+// PaymentDiscrepancy is an order whose payments don't add up
+type PaymentDiscrepancy struct {
+	OrderID int64       `db:"order_id"`
+	Code    string      `db:"code"`
+	Status  OrderStatus `db:"status"`
+	// Payable is the order total less the gift card, see Order.Payable
+	Payable    money.Amount `db:"payable"`
+	PaidAmount money.Amount `db:"paid_amount"`
+	// Paid, Refunded and ChargedBack sum the ledger by kind
+	Paid        money.Amount `db:"paid"`
+	Refunded    money.Amount `db:"refunded"`
+	ChargedBack money.Amount `db:"charged_back"`
+}
+
+// Net is what the ledger says the workshop holds for the order
+func (d PaymentDiscrepancy) Net() money.Amount {
+	return d.Paid - d.Refunded - d.ChargedBack
+}
+
+// Problems lists what is wrong with the order, see the Discrepancy constants
+func (d PaymentDiscrepancy) Problems() []string {
+	var problems []string
+	if d.PaidAmount != d.Net() {
+		problems = append(problems, DiscrepancyLedger)
+	}
+	if d.Net() > d.Payable {
+		problems = append(problems, DiscrepancyOverpaid)
+	}
+	if d.Net() < 0 {
+		problems = append(problems, DiscrepancyOverRefunded)
+	}
+	if d.Status == StatusCancelled && d.Net() > 0 {
+		problems = append(problems, DiscrepancyRefundDue)
+	}
+	return problems
+}
+
+// GetPaymentDiscrepancies reconciles the ledger against the orders and
+// returns the ones with a problem, oldest first. Archived orders are left
+// out.
+func (s *PostgresStorage) GetPaymentDiscrepancies(ctx context.Context) ([]PaymentDiscrepancy, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// The conditions mirror PaymentDiscrepancy.Problems
+	const query = `
+        WITH ledger AS (
+            SELECT order_id,
+                   COALESCE(SUM(amount) FILTER (WHERE kind = 'payment'), 0) AS paid,
+                   COALESCE(SUM(amount) FILTER (WHERE kind = 'refund'), 0) AS refunded,
+                   COALESCE(SUM(amount) FILTER (WHERE kind = 'chargeback'), 0) AS charged_back
+            FROM payments
+            GROUP BY order_id
+        ), balances AS (
+            SELECT o.id AS order_id, o.code, o.status,
+                   o.price - o.gift_card_amount AS payable,
+                   o.paid_amount,
+                   COALESCE(l.paid, 0) AS paid,
+                   COALESCE(l.refunded, 0) AS refunded,
+                   COALESCE(l.charged_back, 0) AS charged_back
+            FROM orders o
+            LEFT JOIN ledger l ON l.order_id = o.id
+            WHERE l.order_id IS NOT NULL OR o.paid_amount <> 0
+        )
+        SELECT * FROM balances
+        WHERE paid_amount <> paid - refunded - charged_back
+           OR paid - refunded - charged_back > payable
+           OR paid - refunded - charged_back < 0
+           OR (status = 'cancelled' AND paid - refunded - charged_back > 0)
+        ORDER BY order_id
+    `
+
+	var discrepancies []PaymentDiscrepancy
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &discrepancies, query)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile payments: %w", err)
+	}
+	return discrepancies, nil
+}
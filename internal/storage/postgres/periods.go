@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPeriodClosed    = errors.New("period is closed")
+	ErrPeriodNotClosed = errors.New("period is not closed")
+)
+
+// periodLockCode is the SQLSTATE raised by the orders_period_lock and
+// payments_period_lock triggers
+const periodLockCode = "PC001"
+
+// PeriodSnapshot holds the financial figures frozen at month close
+type PeriodSnapshot struct {
+	PeriodStart   time.Time      `json:"period_start"`
+	PeriodEnd     time.Time      `json:"period_end"`
+	Orders        int            `json:"orders" db:"orders"`
+	Revenue       float64        `json:"revenue" db:"revenue"`
+	LeatherCost   float64        `json:"leather_cost" db:"leather_cost"`
+	ProcessCost   float64        `json:"process_cost" db:"process_cost"`
+	TotalCost     float64        `json:"total_cost" db:"total_cost"`
+	Commission    float64        `json:"commission" db:"commission"`
+	Tax           float64        `json:"tax" db:"tax"`
+	NetRevenue    float64        `json:"net_revenue" db:"net_revenue"`
+	Profit        float64        `json:"profit" db:"profit"`
+	RushSurcharge float64        `json:"rush_surcharge" db:"rush_surcharge"`
+	ByStatus      map[string]int `json:"by_status"`
+}
+
+// PeriodClose is a signed month-close record. While UnlockedAt is nil the
+// orders of the period cannot change their financial figures.
+type PeriodClose struct {
+	ID          int64           `db:"id"`
+	PeriodStart time.Time       `db:"period_start"`
+	PeriodEnd   time.Time       `db:"period_end"`
+	Snapshot    json.RawMessage `db:"snapshot"`
+	Signature   string          `db:"signature"`
+	ClosedBy    int64           `db:"closed_by"`
+	ClosedAt    time.Time       `db:"closed_at"`
+	UnlockedBy  *int64          `db:"unlocked_by"`
+	UnlockedAt  *time.Time      `db:"unlocked_at"`
+}
+
+// MonthBounds returns the first instant of the month and of the next one
+func MonthBounds(month time.Time) (time.Time, time.Time) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// SignSnapshot returns the HMAC-SHA256 of a snapshot, so the accountant can
+// check that the figures were not altered after the close
+func SignSnapshot(key string, snapshot []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(snapshot)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether the stored snapshot still matches its signature
+func (c *PeriodClose) VerifySignature(key string) bool {
+	expected := SignSnapshot(key, c.Snapshot)
+	return hmac.Equal([]byte(expected), []byte(c.Signature))
+}
+
+// ClosePeriod freezes the figures of the month containing the given time.
+// Orders are locked for writes while the snapshot is taken, so nothing can
+// slip in between the totals and the lock.
+func (s *PostgresStorage) ClosePeriod(ctx context.Context, month time.Time, adminID int64, signingKey string) (*PeriodClose, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const operation = "storage.ClosePeriod"
+
+	if signingKey == "" {
+		return nil, fmt.Errorf("%s: signing key is not configured", operation)
+	}
+
+	start, end := MonthBounds(month)
+	if end.After(time.Now()) {
+		return nil, fmt.Errorf("%s: month %s is not over yet", operation, start.Format("2006-01"))
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE orders IN SHARE MODE`); err != nil {
+		return nil, fmt.Errorf("%s: failed to lock orders: %w", operation, err)
+	}
+
+	snapshot := PeriodSnapshot{PeriodStart: start, PeriodEnd: end}
+	err = tx.GetContext(ctx, &snapshot, `
+        SELECT COUNT(*) AS orders,
+               COALESCE(SUM(price), 0) AS revenue,
+               COALESCE(SUM(leather_cost), 0) AS leather_cost,
+               COALESCE(SUM(process_cost), 0) AS process_cost,
+               COALESCE(SUM(total_cost), 0) AS total_cost,
+               COALESCE(SUM(commission), 0) AS commission,
+               COALESCE(SUM(tax), 0) AS tax,
+               COALESCE(SUM(net_revenue), 0) AS net_revenue,
+               COALESCE(SUM(profit), 0) AS profit,
+               COALESCE(SUM(rush_surcharge), 0) AS rush_surcharge
+        FROM orders
+        WHERE created_at >= $1 AND created_at < $2
+          AND status <> 'cancelled'
+    `, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to calculate totals: %w", operation, err)
+	}
+
+	var statuses []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	err = tx.SelectContext(ctx, &statuses, `
+        SELECT status, COUNT(*) AS count
+        FROM orders
+        WHERE created_at >= $1 AND created_at < $2
+        GROUP BY status
+    `, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to count statuses: %w", operation, err)
+	}
+	snapshot.ByStatus = make(map[string]int, len(statuses))
+	for _, row := range statuses {
+		snapshot.ByStatus[row.Status] = row.Count
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to marshal snapshot: %w", operation, err)
+	}
+
+	closing := PeriodClose{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Snapshot:    data,
+		Signature:   SignSnapshot(signingKey, data),
+		ClosedBy:    adminID,
+	}
+
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO period_closes (period_start, period_end, snapshot, signature, closed_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, closed_at
+    `, start, end, []byte(data), closing.Signature, adminID).Scan(&closing.ID, &closing.ClosedAt)
+	if err != nil {
+		if pgErr, ok := pgError(err); ok && pgErr.Code == "23505" {
+			return nil, ErrPeriodClosed
+		}
+		return nil, fmt.Errorf("%s: failed to save period close: %w", operation, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", operation, err)
+	}
+
+	s.logger.Info("Period closed",
+		zap.Time("period_start", start),
+		zap.Int64("admin_id", adminID),
+		zap.Int("orders", snapshot.Orders),
+		zap.Float64("revenue", snapshot.Revenue))
+
+	return &closing, nil
+}
+
+// UnlockPeriod lifts the lock of a closed month so its orders can be corrected.
+// The snapshot is kept; closing the month again produces a new one. Months
+// whose orders were archived stay closed.
+func (s *PostgresStorage) UnlockPeriod(ctx context.Context, month time.Time, adminID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start, end := MonthBounds(month)
+
+	var archived bool
+	err := s.db.GetContext(ctx, &archived, `
+        SELECT EXISTS (SELECT 1 FROM orders_archive WHERE created_at >= $1 AND created_at < $2)
+    `, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to check archive: %w", err)
+	}
+	if archived {
+		return ErrPeriodArchived
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+        UPDATE period_closes
+        SET unlocked_by = $2, unlocked_at = NOW()
+        WHERE period_start = $1 AND unlocked_at IS NULL
+    `, start, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to unlock period: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPeriodNotClosed
+	}
+
+	s.logger.Warn("Period unlocked",
+		zap.Time("period_start", start),
+		zap.Int64("admin_id", adminID))
+	return nil
+}
+
+// GetPeriodClose returns the active close of the month containing the given time
+func (s *PostgresStorage) GetPeriodClose(ctx context.Context, month time.Time) (*PeriodClose, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start, _ := MonthBounds(month)
+
+	var closing PeriodClose
+	err := s.db.GetContext(ctx, &closing, `
+        SELECT id, period_start, period_end, snapshot, signature,
+               closed_by, closed_at, unlocked_by, unlocked_at
+        FROM period_closes
+        WHERE period_start = $1 AND unlocked_at IS NULL
+    `, start)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodNotClosed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get period close: %w", err)
+	}
+	return &closing, nil
+}
+
+// periodLockError turns the trigger exception into ErrPeriodClosed
+func periodLockError(err error) error {
+	if pgErr, ok := pgError(err); ok && pgErr.Code == periodLockCode {
+		return fmt.Errorf("%w: %s", ErrPeriodClosed, pgErr.Message)
+	}
+	return err
+}
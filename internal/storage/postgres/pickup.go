@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+)
+
+var (
+	ErrOrderNotReady   = errors.New("order is not ready")
+	ErrAlreadyPickedUp = errors.New("order is already picked up")
+)
+
+// MarkPickedUp records that staff handed the order over, and logs it as
+// shipped. Only completed orders can be picked up, and only once.
+func (s *PostgresStorage) MarkPickedUp(ctx context.Context, orderID, staffID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	var status OrderStatus
+	var marked bool
+	err = tx.QueryRowContext(ctx, `
+        WITH marked AS (
+            UPDATE orders
+            SET picked_up_at = NOW(), picked_up_by = $2, updated_at = NOW()
+            WHERE id = $1 AND status = 'completed' AND picked_up_at IS NULL
+            RETURNING id
+        )
+        SELECT o.user_id, o.status, EXISTS (SELECT 1 FROM marked)
+        FROM orders o
+        WHERE o.id = $1
+    `, orderID, staffID).Scan(&userID, &status, &marked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark order picked up: %w", err)
+	}
+
+	switch {
+	case !marked && status != StatusCompleted:
+		return ErrOrderNotReady
+	case !marked:
+		return ErrAlreadyPickedUp
+	}
+
+	if err := appendOrderEvent(ctx, tx, &orderID, userID, EventShipped, map[string]any{
+		"picked_up_by": staffID,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pickup: %w", err)
+	}
+	return nil
+}
@@ -1,73 +1,255 @@
 package postgres
 
 import (
-	"adtime-bot/internal/config"
-	"adtime-bot/pkg/redis"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/pkg/money"
+	"s1ntez/pkg/phone"
+	"s1ntez/pkg/redis"
+	"s1ntez/pkg/units"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
 type PostgresStorage struct {
-	db     *sqlx.DB
-	redis  *redis.Client
-	logger *zap.Logger
+	db *sqlx.DB
+	// replica serves heavy reads when configured; see read
+	replica   *sqlx.DB
+	replicaUp atomic.Bool
+	redis     *redis.Client
+	logger    *zap.Logger
+
+	queryTimeout  time.Duration
+	exportTimeout time.Duration
+	// currency is given to saved and imported orders that name none
+	currency money.Currency
+	// unit is shown to customers who haven't picked their own
+	unit units.Unit
 }
 
 func (s *PostgresStorage) GetUserOrders(ctx context.Context, userID int64) ([]Order, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	const query = `
-        SELECT id, width_cm, height_cm, price, status, created_at 
+        SELECT id, code, width_cm, height_cm, price, status, created_at 
         FROM orders 
         WHERE user_id = $1 AND deleted_at IS NULL
         ORDER BY created_at DESC`
 
 	var orders []Order
-	err := s.db.SelectContext(ctx, &orders, query, userID)
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &orders, query, userID)
+	})
 	return orders, err
 }
 
+// userDataErasure is a statement of DeleteUserData with the columns of
+// personal data it erases, as table.column
+type userDataErasure struct {
+	columns []string
+	query   string
+}
+
+// userDataErasures run in this order for the customer $1: orders are
+// soft-deleted first so the customer's figures are recomputed without
+// them. Order rows, ratings and tickets stay for the books and the
+// statistics; what the customer wrote or gave to reach them goes.
+var userDataErasures = []userDataErasure{
+	{
+		columns: []string{"orders.contact", "orders.requirements", "orders.fingerprint"},
+		query: `UPDATE orders SET deleted_at = COALESCE(deleted_at, NOW()),
+                   contact = '', requirements = '', fingerprint = NULL
+                WHERE user_id = $1`,
+	},
+	{
+		// archived orders keep the row as a JSON document
+		columns: []string{"orders_archive.data"},
+		query: `UPDATE orders_archive
+                SET data = jsonb_set(jsonb_set(data, '{order,contact}', '""'), '{order,requirements}', '""')
+                WHERE user_id = $1`,
+	},
+	{
+		columns: []string{"orders_archive.data"},
+		query: `UPDATE orders_archive SET data = jsonb_set(data, '{delivery,address}', '""')
+                WHERE user_id = $1 AND jsonb_typeof(data->'delivery') = 'object'`,
+	},
+	{
+		columns: []string{"delivery.address"},
+		query:   `UPDATE delivery SET address = '' WHERE order_id IN (SELECT id FROM orders WHERE user_id = $1)`,
+	},
+	{
+		columns: []string{"user_preferences.delivery_method", "user_preferences.delivery_address"},
+		query:   `UPDATE user_preferences SET delivery_method = '', delivery_address = '', updated_at = NOW() WHERE user_id = $1`,
+	},
+	{
+		columns: []string{"users.phone_number", "users.phone_verified_at"},
+		query:   `UPDATE users SET phone_number = NULL, phone_verified_at = NULL, updated_at = NOW() WHERE user_id = $1`,
+	},
+	{
+		columns: []string{"dialog_messages.text"},
+		query:   `DELETE FROM dialog_messages WHERE user_id = $1`,
+	},
+	{
+		columns: []string{"ticket_messages.text"},
+		query:   `UPDATE ticket_messages SET text = '' WHERE ticket_id IN (SELECT id FROM tickets WHERE user_id = $1)`,
+	},
+	{
+		columns: []string{"tickets.subject"},
+		query:   `UPDATE tickets SET subject = '' WHERE user_id = $1`,
+	},
+	{
+		columns: []string{"attachments.transcript"},
+		query:   `UPDATE attachments SET transcript = NULL WHERE user_id = $1`,
+	},
+	{
+		// ratings stay in the averages, the customer's words don't
+		columns: []string{"reviews.comment"},
+		query:   `UPDATE reviews SET comment = '', comment_due = FALSE WHERE user_id = $1`,
+	},
+	{
+		// the deleted orders leave the customer's figures
+		query: refreshCustomersQuery,
+	},
+	{
+		columns: []string{"customers.username"},
+		query:   `UPDATE customers SET username = NULL WHERE user_id = $1`,
+	},
+}
+
+// DeleteUserData soft-deletes the customer's orders and erases their
+// personal data everywhere it is kept, in one transaction; see
+// userDataErasures
 func (s *PostgresStorage) DeleteUserData(ctx context.Context, chatID int64) error {
-	// Soft delete с timestamp
-	_, err := s.db.ExecContext(ctx,
-		"UPDATE orders SET deleted_at = NOW() WHERE user_id = $1", chatID)
-	return err
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, erasure := range userDataErasures {
+		if _, err := tx.ExecContext(ctx, erasure.query, chatID); err != nil {
+			return fmt.Errorf("failed to erase user data %v: %w", erasure.columns, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user data erasure: %w", err)
+	}
+	return nil
 }
 
 type Texture struct {
 	ID          string  `db:"id"`
 	Name        string  `db:"name"`
 	PricePerDM2 float64 `db:"price_per_dm2"`
-	ImageURL    string  `db:"image_url"`
-	InStock     bool    `db:"in_stock"`
+	// PriceCurrency is the currency of PricePerDM2; quotes convert it to
+	// the order currency. Empty in entries cached before it was added.
+	PriceCurrency money.Currency `db:"price_currency"`
+	ImageURL      string         `db:"image_url"`
+	InStock       bool           `db:"in_stock"`
+
+	ServiceType ServiceType `db:"service_type"`
+	// Products limits the material to some products of its line
+	Products StringArray `db:"products" json:",omitempty"`
 }
 
+// textureColumns selects a Texture. Products must be read wherever a
+// texture is cached or checked with Suits.
+const textureColumns = `id::text, name, price_per_dm2, price_currency, image_url, in_stock,
+        service_type, products`
+
 type Order struct {
-	ID          int64     `db:"id"`
-	UserID      int64     `db:"user_id"`
-	WidthCM     int       `db:"width_cm"`
-	HeightCM    int       `db:"height_cm"`
-	TextureID   string    `db:"texture_id"`
-	TextureName string    `db:"texture_name"`
-	Price       float64   `db:"price"`
-	LeatherCost float64   `db:"leather_cost"`
-	ProcessCost float64   `db:"process_cost"`
-	TotalCost   float64   `db:"total_cost"`
-	Commission  float64   `db:"commission"`
-	Tax         float64   `db:"tax"`
-	NetRevenue  float64   `db:"net_revenue"`
-	Profit      float64   `db:"profit"`
-	Contact     string    `db:"contact"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID int64 `db:"id"`
+	// Code is the number customers know the order by, e.g. AT-2024-00123;
+	// ID never leaves the workshop
+	Code        string         `db:"code"`
+	UserID      int64          `db:"user_id"`
+	WidthCM     int            `db:"width_cm"`
+	HeightCM    int            `db:"height_cm"`
+	TextureID   string         `db:"texture_id"`
+	TextureName string         `db:"texture_name"`
+	Price       money.Amount   `db:"price"`
+	LeatherCost money.Amount   `db:"leather_cost"`
+	ProcessCost money.Amount   `db:"process_cost"`
+	TotalCost   money.Amount   `db:"total_cost"`
+	Commission  money.Amount   `db:"commission"`
+	Tax         money.Amount   `db:"tax"`
+	NetRevenue  money.Amount   `db:"net_revenue"`
+	Profit      money.Amount   `db:"profit"`
+	Currency    money.Currency `db:"currency"`
+	// ExchangeRates are the rates material prices were converted with
+	ExchangeRates ExchangeRates `db:"exchange_rates"`
+	Contact       string        `db:"contact"`
+	Status        OrderStatus   `db:"status"`
+	CreatedAt     time.Time     `db:"created_at"`
+	UpdatedAt     time.Time     `db:"updated_at"`
+
+	IsRush        bool         `db:"is_rush"`
+	RushSurcharge money.Amount `db:"rush_surcharge"`
+	ReadyBy       *time.Time   `db:"ready_by"`
+	// DueAt is the production deadline set by a manager; see Deadline
+	DueAt *time.Time `db:"due_at"`
+	// PickedUpAt is when staff handed the order over; see MarkPickedUp
+	PickedUpAt *time.Time `db:"picked_up_at"`
+	PickedUpBy *int64     `db:"picked_up_by"`
+
+	AssigneeID  *int64  `db:"assignee_id"`
+	ReservedDM2 float64 `db:"reserved_dm2"`
+
+	// IdempotencyKey makes a redelivered confirmation return the existing
+	// order instead of creating a second one
+	IdempotencyKey *string `db:"idempotency_key"`
+	// Fingerprint identifies the ordered products and contact, so a repeat
+	// of the same order can be spotted
+	Fingerprint *string `db:"fingerprint"`
+
+	ServiceType ServiceType    `db:"service_type"`
+	Quantity    int            `db:"quantity"`
+	Options     ProductOptions `db:"options"`
+
+	// Discount is what the promo code took off Price, which is final
+	PromoCodeID *int64       `db:"promocode_id"`
+	Discount    money.Amount `db:"discount"`
+	// GiftCardAmount is the part of Price paid with a gift card, taken off
+	// the card GiftCardID when the order is saved; see Payable
+	GiftCardID     *int64       `db:"-"`
+	GiftCardAmount money.Amount `db:"gift_card_amount"`
+	// Prepayment is the deposit due before production, PaidAmount what
+	// the customer has paid through invoices; see RecordPayment
+	Prepayment money.Amount `db:"prepayment"`
+	PaidAmount money.Amount `db:"paid_amount"`
+	// Requirements are the customer's own wishes for the order; an order
+	// with them waits for a manager to price it, see ApproveQuote
+	Requirements string `db:"requirements"`
+	// HoldReason, for an order saved on hold, is recorded with it as the
+	// open hold an admin resolves; see ResolveHold
+	HoldReason string `db:"-"`
+	// QuoteReason, when set, opens the quote review of the order as it is
+	// saved, for its price to be approved; see ApproveQuote
+	QuoteReason string `db:"-"`
+
+	// AttachmentIDs are uploaded files (e.g. a sticker preview) to link to
+	// the order when it is saved
+	AttachmentIDs []int64 `db:"-"`
+
+	// Items are the products of the order. The columns above hold the
+	// totals and repeat the first item for single-product readers.
+	Items []OrderItem `db:"-"`
+	// Delivery is saved with the order; nil means pickup
+	Delivery *OrderDelivery `db:"-"`
 }
 
 type OrderStatistics struct {
@@ -80,6 +262,10 @@ type OrderStatistics struct {
 	MonthOrders  int
 	MonthRevenue float64
 	StatusCounts map[string]int
+
+	RushOrders    int
+	RushRevenue   float64
+	RushSurcharge float64
 }
 
 type PriceFormula struct {
@@ -101,8 +287,12 @@ func NewPostgresStorage(ctx context.Context, cfg config.Config, redisClient *red
 		cfg.Database.Name,
 	)
 
+	connStr, err := registerConnConfig(connStr, cfg.Database.StatementCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
 	var db *sqlx.DB
-	var err error
 
 	retryPolicy := backoff.NewExponentialBackOff()
 	retryPolicy.MaxElapsedTime = 2 * time.Minute
@@ -112,8 +302,8 @@ func NewPostgresStorage(ctx context.Context, cfg config.Config, redisClient *red
 
 	err = backoff.RetryNotify(
 		func() error {
-			db, err = sqlx.ConnectContext(ctx, "postgres", connStr)
-			if err != nil {
+			var err error
+			if db, err = openDB(connStr); err != nil {
 				return fmt.Errorf("connect: %w", err)
 			}
 
@@ -141,16 +331,32 @@ func NewPostgresStorage(ctx context.Context, cfg config.Config, redisClient *red
 	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
 
 	logger.Info("Successfully connected to PostgreSQL")
-	return &PostgresStorage{
-		db:     db,
-		redis:  redisClient,
-		logger: logger,
-	}, nil
+	storage := &PostgresStorage{
+		db:            db,
+		redis:         redisClient,
+		logger:        logger,
+		queryTimeout:  cfg.Database.QueryTimeout,
+		exportTimeout: cfg.Database.ExportTimeout,
+		currency:      cfg.Currency,
+		unit:          cfg.Unit,
+	}
+
+	if cfg.Database.ReplicaDSN != "" {
+		if err := storage.openReplica(ctx, cfg.Database.ReplicaDSN, cfg.Database.StatementCacheSize,
+			cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: failed to open read replica: %w", operation, err)
+		}
+	}
+
+	return storage, nil
 }
 
 func (s *PostgresStorage) GetTextureByID(ctx context.Context, textureID string) (*Texture, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	cacheKey := fmt.Sprintf("texture:%s", textureID)
+	cacheKey := textureCacheKey(textureID)
 
 	// Try Redis first
 	cached, err := s.redis.Get(ctx, cacheKey)
@@ -171,8 +377,8 @@ func (s *PostgresStorage) GetTextureByID(ctx context.Context, textureID string)
 
 	// Fall back to Postgres
 	const query = `
-        SELECT id::text, name, price_per_dm2, image_url, in_stock 
-        FROM textures 
+        SELECT ` + textureColumns + `
+        FROM textures
         WHERE id = $1
     `
 
@@ -180,26 +386,29 @@ func (s *PostgresStorage) GetTextureByID(ctx context.Context, textureID string)
 	err = s.db.GetContext(ctx, &texture, query, textureID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("texture not found: %w", err)
+			return nil, fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
 		}
 		return nil, fmt.Errorf("failed to get texture: %w", err)
 	}
 
 	// Validate price from database
 	if texture.PricePerDM2 <= 0 {
-		return nil, fmt.Errorf("invalid price for texture %s: %.2f", textureID, texture.PricePerDM2)
+		return nil, fmt.Errorf("%w for texture %s: %.2f", errs.ErrInvalidPrice, textureID, texture.PricePerDM2)
 	}
 
 	// Cache the validated result
 	if data, err := json.Marshal(texture); err == nil {
-		s.redis.Set(ctx, cacheKey, data, 24*time.Hour)
+		s.redis.Set(ctx, cacheKey, data, textureCacheTTL)
 	}
 
 	return &texture, nil
 }
 
 func (s *PostgresStorage) GetAvailableTextures(ctx context.Context) ([]Texture, error) {
-	const query = `SELECT id::text, name, price_per_dm2, image_url FROM textures WHERE in_stock = TRUE`
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `SELECT ` + textureColumns + ` FROM textures WHERE in_stock = TRUE`
 
 	var textures []Texture
 	err := s.db.SelectContext(ctx, &textures, query)
@@ -210,18 +419,74 @@ func (s *PostgresStorage) GetAvailableTextures(ctx context.Context) ([]Texture,
 	return textures, nil
 }
 
-func (s *PostgresStorage) SaveOrder(ctx context.Context, order Order) (int64, error) {
+// SaveOrder stores a new order with its items, delivery and reservations
+// and sets its ID and public code
+func (s *PostgresStorage) SaveOrder(ctx context.Context, order *Order) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	const query = `
         INSERT INTO orders (
             user_id, width_cm, height_cm, texture_id, price,
             leather_cost, process_cost, total_cost, commission,
-            tax, net_revenue, profit, contact, status, created_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-        RETURNING id
+            tax, net_revenue, profit, contact, status, created_at,
+            is_rush, rush_surcharge, ready_by, reserved_dm2, idempotency_key,
+            service_type, quantity, options, promocode_id, discount, fingerprint, currency,
+            exchange_rates, gift_card_amount, prepayment, requirements
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)
+        RETURNING id, code
     `
 
+	if order.Currency == "" {
+		order.Currency = s.currency
+	}
+
+	if order.IdempotencyKey != nil {
+		if id, err := s.orderByIdempotencyKey(ctx, *order.IdempotencyKey); err != nil || id != 0 {
+			order.ID = id
+			return err
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Reserve material first: the texture row lock serializes concurrent orders.
+	// Items of the same texture are reserved together.
+	items := order.LineItems()
+	needed := make(map[string]float64)
+	var textureIDs []string
+	for _, item := range items {
+		if _, ok := needed[item.TextureID]; !ok {
+			textureIDs = append(textureIDs, item.TextureID)
+		}
+		needed[item.TextureID] += item.MaterialDM2()
+	}
+
+	var reserved float64
+	tracked := make(map[string]float64)
+	for _, textureID := range textureIDs {
+		area, err := reserveStock(ctx, tx, textureID, needed[textureID])
+		if err != nil {
+			return err
+		}
+		if area > 0 {
+			tracked[textureID] = area
+			reserved += area
+		}
+	}
+	for i := range items {
+		items[i].ReservedDM2 = 0
+		if tracked[items[i].TextureID] > 0 {
+			items[i].ReservedDM2 = items[i].MaterialDM2()
+		}
+	}
+
 	var orderID int64
-	err := s.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		order.UserID,
 		order.WidthCM,
 		order.HeightCM,
@@ -237,19 +502,128 @@ func (s *PostgresStorage) SaveOrder(ctx context.Context, order Order) (int64, er
 		order.Contact,
 		order.Status,
 		order.CreatedAt,
-	).Scan(&orderID)
+		order.IsRush,
+		order.RushSurcharge,
+		order.ReadyBy,
+		reserved,
+		order.IdempotencyKey,
+		order.ServiceType,
+		max(order.Quantity, 1),
+		order.Options,
+		order.PromoCodeID,
+		order.Discount,
+		order.Fingerprint,
+		order.Currency,
+		order.ExchangeRates,
+		order.GiftCardAmount,
+		order.Prepayment,
+		order.Requirements,
+	).Scan(&orderID, &order.Code)
 
 	if err != nil {
-		return 0, fmt.Errorf("failed to save order: %w", err)
+		// A concurrent delivery of the same confirmation won the race
+		if order.IdempotencyKey != nil && isUniqueViolation(err, "idx_orders_idempotency_key") {
+			tx.Rollback()
+			order.ID, err = s.orderByIdempotencyKey(ctx, *order.IdempotencyKey)
+			return err
+		}
+		return fmt.Errorf("failed to save order: %w", err)
 	}
 
-	// Invalidate statistics cache
-	s.redis.Del(ctx, "order_stats")
+	if err := insertOrderItems(ctx, tx, orderID, items); err != nil {
+		return err
+	}
 
-	return orderID, nil
+	if order.Delivery != nil {
+		if err := insertDelivery(ctx, tx, orderID, *order.Delivery); err != nil {
+			return err
+		}
+	}
+
+	if order.Status == StatusOnHold {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO order_holds (order_id, reason) VALUES ($1, $2)`, orderID, order.HoldReason); err != nil {
+			return fmt.Errorf("failed to record hold: %w", err)
+		}
+	}
+	if order.QuoteReason != "" {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO quote_reviews (order_id, reason, calculated_price) VALUES ($1, $2, $3)`,
+			orderID, order.QuoteReason, order.Price); err != nil {
+			return fmt.Errorf("failed to request quote review: %w", err)
+		}
+	}
+
+	if order.PromoCodeID != nil {
+		if err := redeemPromoCode(ctx, tx, *order.PromoCodeID, orderID, order.UserID, order.Discount); err != nil {
+			return err
+		}
+	}
+
+	if order.GiftCardID != nil && order.GiftCardAmount > 0 {
+		if err := redeemGiftCard(ctx, tx, *order.GiftCardID, orderID, order.GiftCardAmount); err != nil {
+			return err
+		}
+	}
+
+	for _, textureID := range textureIDs {
+		if area := tracked[textureID]; area > 0 {
+			if err := allocateBatches(ctx, tx, orderID, textureID, area); err != nil {
+				return err
+			}
+		}
+	}
+
+	// External notifications are delivered from the outbox once this commits
+	if err := enqueueOutbox(ctx, tx, OutboxOrderCreated, OrderOutboxPayload{
+		OrderID: orderID,
+		UserID:  order.UserID,
+		Status:  order.Status,
+	}); err != nil {
+		return err
+	}
+
+	// Record the lifecycle event in the same transaction as the order itself
+	if err := appendOrderEvent(ctx, tx, &orderID, order.UserID, EventConfirmed, map[string]any{
+		"service_type": order.ServiceType,
+		"texture_id":   order.TextureID,
+		"width_cm":     order.WidthCM,
+		"height_cm":    order.HeightCM,
+		"quantity":     max(order.Quantity, 1),
+		"options":      order.Options,
+		"items":        len(items),
+		"price":        order.Price,
+		"discount":     order.Discount,
+		"rush":         order.IsRush,
+		"status":       order.Status,
+	}); err != nil {
+		return err
+	}
+
+	if err := linkAttachments(ctx, tx, orderID, order.UserID, order.AttachmentIDs); err != nil {
+		return err
+	}
+
+	// Keep the conversation that led to the order for dispute handling
+	if err := linkDialogMessages(ctx, tx, order.UserID, orderID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
+	}
+
+	for textureID := range tracked {
+		s.invalidateTextureCache(ctx, textureID)
+	}
+
+	order.ID = orderID
+	return nil
 }
 
-func (s *PostgresStorage) ExportOrderToExcel(ctx context.Context, order Order) (string, error) {
+// ExportOrderToExcel writes the production ticket of the order. Its cost
+// breakdown is left out unless the policy shows internal figures.
+func (s *PostgresStorage) ExportOrderToExcel(ctx context.Context, order Order, policy ColumnPolicy) (string, error) {
 	f := excelize.NewFile()
 	defer f.Close()
 
@@ -275,25 +649,60 @@ func (s *PostgresStorage) ExportOrderToExcel(ctx context.Context, order Order) (
 	f.SetCellValue("Order", "B5", fmt.Sprintf("%.1f dm²", area))
 
 	// Set pricing info
-	f.SetCellValue("Order", "A7", "Price Components")
-	f.SetCellValue("Order", "A8", "Leather Cost")
-	f.SetCellValue("Order", "B8", order.LeatherCost)
-	f.SetCellValue("Order", "A9", "Processing Cost")
-	f.SetCellValue("Order", "B9", order.ProcessCost)
-	f.SetCellValue("Order", "A10", "Total Cost")
-	f.SetCellValue("Order", "B10", order.TotalCost)
-	f.SetCellValue("Order", "A11", "Commission")
-	f.SetCellValue("Order", "B11", order.Commission)
-	f.SetCellValue("Order", "A12", "Tax")
-	f.SetCellValue("Order", "B12", order.Tax)
+	if policy.showsInternal() {
+		f.SetCellValue("Order", "A7", "Price Components")
+		f.SetCellValue("Order", "A8", "Leather Cost")
+		f.SetCellValue("Order", "B8", order.LeatherCost)
+		f.SetCellValue("Order", "A9", "Processing Cost")
+		f.SetCellValue("Order", "B9", order.ProcessCost)
+		f.SetCellValue("Order", "A10", "Total Cost")
+		f.SetCellValue("Order", "B10", order.TotalCost)
+		f.SetCellValue("Order", "A11", "Commission")
+		f.SetCellValue("Order", "B11", order.Commission)
+		f.SetCellValue("Order", "A12", "Tax")
+		f.SetCellValue("Order", "B12", order.Tax)
+	}
 	f.SetCellValue("Order", "A13", "Final Price")
 	f.SetCellValue("Order", "B13", order.Price)
 
+	// Rush orders are flagged at the top of the production ticket
+	if order.IsRush {
+		f.SetCellValue("Order", "D1", "СРОЧНО / RUSH")
+		f.SetCellValue("Order", "A14", "Rush Surcharge")
+		f.SetCellValue("Order", "B14", order.RushSurcharge)
+		if order.ReadyBy != nil {
+			f.SetCellValue("Order", "A15", "Ready By")
+			f.SetCellValue("Order", "B15", order.ReadyBy.Format("2006-01-02 15:04"))
+		}
+	}
+
 	// Formatting
 	style, _ := f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{Bold: true},
 	})
-	f.SetCellStyle("Order", "A1", "A13", style)
+	// Where workers fetch the material from
+	if allocations, err := s.GetOrderAllocations(ctx, order.ID); err != nil {
+		s.logger.Warn("Failed to load batch allocations for ticket",
+			zap.Int64("order_id", order.ID),
+			zap.Error(err))
+	} else if len(allocations) > 0 {
+		f.SetCellValue("Order", "A17", "Material")
+		for i, a := range allocations {
+			row := 18 + i
+			f.SetCellValue("Order", fmt.Sprintf("A%d", row), a.Location)
+			f.SetCellValue("Order", fmt.Sprintf("B%d", row), fmt.Sprintf("%.2f dm²", a.AreaDM2))
+			f.SetCellValue("Order", fmt.Sprintf("C%d", row), fmt.Sprintf("batch #%d, %s, %s",
+				a.BatchID, a.Supplier, a.ReceivedAt.Format("2006-01-02")))
+		}
+	}
+
+	f.SetCellStyle("Order", "A1", "A17", style)
+	if order.IsRush {
+		rushStyle, _ := f.NewStyle(&excelize.Style{
+			Font: &excelize.Font{Bold: true, Color: "FF0000", Size: 14},
+		})
+		f.SetCellStyle("Order", "D1", "D1", rushStyle)
+	}
 
 	f.SetActiveSheet(index)
 
@@ -314,100 +723,34 @@ func (s *PostgresStorage) ExportOrderToExcel(ctx context.Context, order Order) (
 	return filepath, nil
 }
 
-func (s *PostgresStorage) ExportAllOrdersToExcel(ctx context.Context, filename string) error {
-	const operation = "storage.ExportAllOrdersToExcel"
+// SaveUserAgreement records that the user accepted the terms. The phone is
+// stored in E.164; a changed number loses its verification.
+func (s *PostgresStorage) SaveUserAgreement(ctx context.Context, userID int64, rawPhone string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	// Получаем все заказы из БД
-	const query = `
-        SELECT o.*, t.name as texture_name 
-        FROM orders o
-        LEFT JOIN textures t ON o.texture_id = t.id
-        ORDER BY o.created_at DESC
-    `
-
-	var orders []Order
-	if err := s.db.SelectContext(ctx, &orders, query); err != nil {
-		s.logger.Error("Failed to fetch orders for export",
-			zap.Error(err),
-			zap.String("operation", operation))
-		return fmt.Errorf("failed to fetch orders: %w", err)
-	}
-
-	f := excelize.NewFile()
-	defer f.Close()
-
-	index, err := f.NewSheet("Orders")
+	number, err := phone.Normalize(rawPhone)
 	if err != nil {
-		return fmt.Errorf("failed to create sheet: %w", err)
-	}
-
-	// Заголовки
-	headers := []string{
-		"ID", "User ID", "Width (cm)", "Height (cm)", "Texture ID",
-		"Texture Name", "Price", "Leather Cost", "Process Cost",
-		"Total Cost", "Commission", "Tax", "Net Revenue", "Profit",
-		"Contact", "Status", "Created At",
-	}
-	for col, header := range headers {
-		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
-		f.SetCellValue("Orders", cell, header)
-	}
-
-	// Данные
-	for row, order := range orders {
-		data := []interface{}{
-			order.ID,
-			order.UserID,
-			order.WidthCM,
-			order.HeightCM,
-			order.TextureID,
-			order.TextureName,
-			order.Price,
-			order.LeatherCost,
-			order.ProcessCost,
-			order.TotalCost,
-			order.Commission,
-			order.Tax,
-			order.NetRevenue,
-			order.Profit,
-			order.Contact,
-			order.Status,
-			order.CreatedAt.Format("2006-01-02 15:04"),
-		}
-		for col, value := range data {
-			cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
-			f.SetCellValue("Orders", cell, value)
-		}
-	}
-
-	f.SetActiveSheet(index)
-
-	// Создаем папку если не существует
-	if err := os.MkdirAll("reports", 0755); err != nil {
-		return fmt.Errorf("failed to create reports directory: %w", err)
+		return fmt.Errorf("failed to save user agreement: %w", err)
 	}
 
-	// Сохраняем в один файл
-	filepath := fmt.Sprintf("reports/%s.xlsx", filename)
-	if err := f.SaveAs(filepath); err != nil {
-		return fmt.Errorf("failed to save Excel file: %w", err)
-	}
-
-	return nil
-}
-
-func (s *PostgresStorage) SaveUserAgreement(ctx context.Context, userID int64, phone string) error {
 	const query = `
-        INSERT INTO users (user_id, agreed_to_tpa, phone_number)
-        VALUES ($1, TRUE, $2)
+        INSERT INTO users (user_id, agreed_to_tpa, agreed_at, phone_number)
+        VALUES ($1, TRUE, NOW(), $2)
         ON CONFLICT (user_id) 
-        DO UPDATE SET agreed_to_tpa = TRUE, phone_number = $2
+        DO UPDATE SET agreed_to_tpa = TRUE,
+                      agreed_at = COALESCE(users.agreed_at, NOW()),
+                      phone_verified_at = CASE WHEN users.phone_number = $2 THEN users.phone_verified_at END,
+                      phone_number = $2
     `
-	_, err := s.db.ExecContext(ctx, query, userID, phone)
+	_, err = s.db.ExecContext(ctx, query, userID, number)
 	return err
 }
 
 func (s *PostgresStorage) GetUserAgreement(ctx context.Context, userID int64) (bool, string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	const query = `
 		SELECT agreed_to_tpa, phone_number 
 		FROM users 
@@ -423,102 +766,107 @@ func (s *PostgresStorage) GetUserAgreement(ctx context.Context, userID int64) (b
 	return agreed, phone, err
 }
 
-func (s *PostgresStorage) UpdateOrderStatus(ctx context.Context, orderID int64, status string) error {
-	// Get all orders
-	const query = `
-		SELECT * 
-		FROM orders 
-		ORDER BY created_at 
-		DESC
-	`
-
-	var orders []Order
-	if err := s.db.SelectContext(ctx, &orders, query); err != nil {
-		return fmt.Errorf("failed to fetch orders: %w", err)
+// UpdateOrderStatus writes the status, logs the change in order_events and
+// queues its outbox message in one transaction, and returns the status the
+// order had. An order awaiting prepayment or its price can only be
+// cancelled: ErrPrepaymentDue and ErrQuotePending are checked on the locked
+// row, so a payment or an approval landing meanwhile is not overwritten.
+// The in-process reactions (notifications, reports) belong to
+// orders.ChangeStatus.
+func (s *PostgresStorage) UpdateOrderStatus(ctx context.Context, orderID int64, status OrderStatus) (OrderStatus, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Create or open file
-	filename := "reports/current_orders.xlsx"
-	f := excelize.NewFile()
-
-	if _, err := os.Stat(filename); err == nil {
-		f, err = excelize.OpenFile(filename)
-		if err != nil {
-			return fmt.Errorf("failed to open existing file: %w", err)
-		}
-		// Clear existing data if needed
-		if err := f.DeleteSheet("Orders"); err != nil {
-			return fmt.Errorf("failed to clear old sheet: %w", err)
-		}
+	var prev StatusChange
+	err = tx.GetContext(ctx, &prev,
+		`SELECT id AS order_id, user_id, status AS prev_status FROM orders WHERE id = $1 FOR UPDATE`, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errs.ErrOrderNotFound
 	}
-
-	// Create fresh sheet
-	index, err := f.NewSheet("Orders")
 	if err != nil {
-		return fmt.Errorf("failed to create sheet: %w", err)
-	}
-
-	// Заголовки
-	headers := []string{
-		"ID", "User ID", "Width (cm)", "Height (cm)", "Texture ID",
-		"Texture Name", "Price", "Leather Cost", "Process Cost",
-		"Total Cost", "Commission", "Tax", "Net Revenue", "Profit",
-		"Contact", "Status", "Created At",
-	}
-	for col, header := range headers {
-		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
-		f.SetCellValue("Orders", cell, header)
-	}
-
-	// Данные
-	for row, order := range orders {
-		data := []interface{}{
-			order.ID,
-			order.UserID,
-			order.WidthCM,
-			order.HeightCM,
-			order.TextureID,
-			order.TextureName,
-			order.Price,
-			order.LeatherCost,
-			order.ProcessCost,
-			order.TotalCost,
-			order.Commission,
-			order.Tax,
-			order.NetRevenue,
-			order.Profit,
-			order.Contact,
-			order.Status,
-			order.CreatedAt.Format("2006-01-02 15:04"),
-		}
-		for col, value := range data {
-			cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
-			f.SetCellValue("Orders", cell, value)
+		return "", fmt.Errorf("failed to load order status: %w", err)
+	}
+	if status != StatusCancelled {
+		switch prev.PrevStatus {
+		case StatusAwaitingPrepayment:
+			return "", fmt.Errorf("%w: order %d", ErrPrepaymentDue, orderID)
+		case StatusPendingQuote:
+			return "", fmt.Errorf("%w: order %d", ErrQuotePending, orderID)
 		}
 	}
 
-	f.SetActiveSheet(index)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status = $2, updated_at = NOW() WHERE id = $1`, orderID, status); err != nil {
+		return "", fmt.Errorf("failed to update order status: %w", err)
+	}
+	if err := recordStatusChange(ctx, tx, orderID, prev.UserID, prev.PrevStatus, status); err != nil {
+		return "", err
+	}
 
-	// Создаем папку если не существует
-	if err := os.MkdirAll("reports", 0755); err != nil {
-		return fmt.Errorf("failed to create reports directory: %w", err)
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit order status: %w", err)
 	}
+	return prev.PrevStatus, nil
+}
 
-	// Сохраняем в один файл
-	filepath := fmt.Sprintf("reports/%s.xlsx", filename)
-	if err := f.SaveAs(filepath); err != nil {
-		return fmt.Errorf("failed to save Excel file: %w", err)
+// UpdateOrdersStatus sets the status of many orders in one statement and
+// logs and queues every change with it, as UpdateOrderStatus does.
+// Orders that are missing or already in the status are skipped, as are
+// orders awaiting prepayment or their price unless they are cancelled; the
+// result lists the ones that changed with the status they had before.
+func (s *PostgresStorage) UpdateOrdersStatus(ctx context.Context, ids []int64, status OrderStatus) ([]StatusChange, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll("reports", 0755); err != nil {
-		return fmt.Errorf("failed to create reports directory: %w", err)
+	const query = `
+        UPDATE orders o
+        SET status = $2, updated_at = NOW()
+        FROM (
+            SELECT id, status FROM orders
+            WHERE id = ANY($1) AND status <> $2
+              AND (status NOT IN ('awaiting_prepayment', 'pending_quote') OR $2 = 'cancelled')
+            FOR UPDATE
+        ) prev
+        WHERE o.id = prev.id
+        RETURNING o.id AS order_id, o.user_id, prev.status AS prev_status
+    `
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return f.SaveAs(filename)
+	var changes []StatusChange
+	if err := tx.SelectContext(ctx, &changes, query, ids, status); err != nil {
+		return nil, fmt.Errorf("failed to update orders status: %w", err)
+	}
+	for _, change := range changes {
+		if err := recordStatusChange(ctx, tx, change.OrderID, change.UserID, change.PrevStatus, status); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit orders status: %w", err)
+	}
+	return changes, nil
 }
 
 func (s *PostgresStorage) Close() error {
+	if s.replica != nil {
+		s.replica.Close()
+	}
 	if s.db == nil {
 		return nil
 	}
@@ -526,35 +874,54 @@ func (s *PostgresStorage) Close() error {
 }
 
 func (s *PostgresStorage) GetOrderByID(ctx context.Context, orderID int64) (*Order, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	const query = `SELECT * FROM orders WHERE id = $1`
 	var order Order
 	err := s.db.GetContext(ctx, &order, query, orderID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("order not found")
+			return nil, errs.ErrOrderNotFound
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
+
+	if order.Items, err = s.GetOrderItems(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.GetOrderDelivery(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.Delivery = &delivery
 	return &order, nil
 }
 
+// GetOrderStatistics computes the statistics from the orders table and the
+// archive totals. The bot serves live counters from Redis and uses this to
+// reconcile them.
 func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatistics, error) {
-	cacheKey := "order_stats"
-
-	// Try Redis first
-	if cached, err := s.redis.Get(ctx, cacheKey); err == nil {
-		var stats OrderStatistics
-		if err := json.Unmarshal(cached, &stats); err == nil {
-			return &stats, nil
-		}
-	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var stats *OrderStatistics
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		var err error
+		stats, err = orderStatistics(ctx, db)
+		return err
+	})
+	return stats, err
+}
 
+func orderStatistics(ctx context.Context, db sqlx.QueryerContext) (*OrderStatistics, error) {
 	stats := &OrderStatistics{
 		StatusCounts: make(map[string]int),
 	}
 
 	// Get total orders and revenue
-	err := s.db.QueryRowContext(ctx, `
+	err := db.QueryRowxContext(ctx, `
         SELECT 
             COUNT(*) as total_orders,
             COALESCE(SUM(price), 0) as total_revenue
@@ -565,7 +932,7 @@ func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatist
 	}
 
 	// Get today's stats
-	err = s.db.QueryRowContext(ctx, `
+	err = db.QueryRowxContext(ctx, `
         SELECT 
             COUNT(*) as count,
             COALESCE(SUM(price), 0) as revenue
@@ -577,7 +944,7 @@ func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatist
 	}
 
 	// Get week's stats
-	err = s.db.QueryRowContext(ctx, `
+	err = db.QueryRowxContext(ctx, `
         SELECT 
             COUNT(*) as count,
             COALESCE(SUM(price), 0) as revenue
@@ -589,7 +956,7 @@ func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatist
 	}
 
 	// Get month's stats
-	err = s.db.QueryRowContext(ctx, `
+	err = db.QueryRowxContext(ctx, `
         SELECT 
             COUNT(*) as count,
             COALESCE(SUM(price), 0) as revenue
@@ -607,7 +974,7 @@ func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatist
 	}
 
 	var statusCounts []statusCount
-	err = s.db.SelectContext(ctx, &statusCounts, `
+	err = sqlx.SelectContext(ctx, db, &statusCounts, `
         SELECT status, COUNT(*) as count
         FROM orders
         GROUP BY status
@@ -620,37 +987,63 @@ func (s *PostgresStorage) GetOrderStatistics(ctx context.Context) (*OrderStatist
 		stats.StatusCounts[sc.Status] = sc.Count
 	}
 
-	// Cache the result
-	if data, err := json.Marshal(stats); err == nil {
-		s.redis.Set(ctx, cacheKey, data, 1*time.Hour)
+	// Rush orders are tracked separately to see how often customers pay for speed
+	err = db.QueryRowxContext(ctx, `
+        SELECT 
+            COUNT(*) as count,
+            COALESCE(SUM(price), 0) as revenue,
+            COALESCE(SUM(rush_surcharge), 0) as surcharge
+        FROM orders
+        WHERE is_rush = TRUE
+    `).Scan(&stats.RushOrders, &stats.RushRevenue, &stats.RushSurcharge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rush stats: %w", err)
+	}
+
+	if err := addArchiveTotals(ctx, db, stats); err != nil {
+		return nil, err
 	}
 
 	return stats, nil
 }
 
-func (s *PostgresStorage) CheckRateLimit(ctx context.Context, userID int64, action string, limit int64, window time.Duration) (bool, error) {
+// CheckRateLimit counts one more use of the action and returns
+// errs.ErrRateLimited once the user went over limit within the window
+func (s *PostgresStorage) CheckRateLimit(ctx context.Context, userID int64, action string, limit int64, window time.Duration) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	key := fmt.Sprintf("ratelimit:%d:%s", userID, action)
 
 	count, err := s.redis.Incr(ctx, key)
 	if err != nil {
-		return false, fmt.Errorf("failed to increment rate limit counter: %w", err)
+		return fmt.Errorf("failed to increment rate limit counter: %w", err)
 	}
 
 	// Set expiry if this is the first increment
 	if count == 1 {
 		if _, err := s.redis.Expire(ctx, key, window); err != nil {
-			return false, fmt.Errorf("failed to set rate limit window: %w", err)
+			return fmt.Errorf("failed to set rate limit window: %w", err)
 		}
 	}
 
-	return count > limit, nil
+	if count > limit {
+		return errs.ErrRateLimited
+	}
+	return nil
 }
 
 func (s *PostgresStorage) GetTextureByName(ctx context.Context, name string) (*Texture, error) {
-	const query = `SELECT id::text, name, price_per_dm2 FROM textures WHERE name = $1`
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `SELECT id::text, name, price_per_dm2, price_currency FROM textures WHERE LOWER(name) = LOWER($1) AND service_type = 'leather'`
 
 	var texture Texture
 	err := s.db.GetContext(ctx, &texture, query, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %q", errs.ErrTextureNotFound, name)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get texture: %w", err)
 	}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// personalDataColumns is every column that holds a customer's personal
+// data. A migration adding one must add it here and to userDataErasures.
+var personalDataColumns = []string{
+	"attachments.transcript",
+	"customers.username",
+	"delivery.address",
+	"dialog_messages.text",
+	"orders.contact",
+	"orders.fingerprint",
+	"orders.requirements",
+	"orders_archive.data",
+	"reviews.comment",
+	"ticket_messages.text",
+	"tickets.subject",
+	"user_preferences.delivery_address",
+	"user_preferences.delivery_method",
+	"users.phone_number",
+	"users.phone_verified_at",
+}
+
+func TestUserDataErasures(t *testing.T) {
+	var erased []string
+	for _, erasure := range userDataErasures {
+		for _, column := range erasure.columns {
+			table, name, ok := strings.Cut(column, ".")
+			if !ok {
+				t.Errorf("column %q is not table.column", column)
+				continue
+			}
+			if !regexp.MustCompile(`\b` + table + `\b`).MatchString(erasure.query) {
+				t.Errorf("query erasing %s does not touch %s", column, table)
+			}
+			// a deleted row takes every column with it
+			if !strings.HasPrefix(erasure.query, "DELETE") && !regexp.MustCompile(`\b`+name+`\b`).MatchString(erasure.query) {
+				t.Errorf("query erasing %s does not set %s", column, name)
+			}
+			erased = append(erased, column)
+		}
+	}
+	slices.Sort(erased)
+	erased = slices.Compact(erased)
+
+	for _, column := range personalDataColumns {
+		if !slices.Contains(erased, column) {
+			t.Errorf("%s is not erased", column)
+		}
+	}
+	for _, column := range erased {
+		if !slices.Contains(personalDataColumns, column) {
+			t.Errorf("%s is erased but not listed as personal data", column)
+		}
+	}
+}
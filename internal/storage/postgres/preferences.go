@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Preferences are the order defaults a customer saved under /profile
+type Preferences struct {
+	UserID int64 `db:"user_id" json:"-"`
+	// DeliveryMethod is empty until a default delivery is chosen
+	DeliveryMethod  string `db:"delivery_method" json:"delivery_method"`
+	DeliveryAddress string `db:"delivery_address" json:"delivery_address"`
+	// FavoriteTextures are listed first when a material is chosen
+	FavoriteTextures StringArray `db:"favorite_textures" json:"favorite_textures"`
+	UpdatedAt        time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+// GetPreferences returns the saved defaults of a user; empty ones when
+// nothing was saved
+func (s *PostgresStorage) GetPreferences(ctx context.Context, userID int64) (Preferences, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var prefs Preferences
+	err := s.db.GetContext(ctx, &prefs, `
+        SELECT user_id, delivery_method, delivery_address, favorite_textures, updated_at
+        FROM user_preferences
+        WHERE user_id = $1
+    `, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preferences{UserID: userID}, nil
+	}
+	if err != nil {
+		return Preferences{}, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SaveDeliveryPreference sets the default delivery; the address is kept
+// only for methods that need one
+func (s *PostgresStorage) SaveDeliveryPreference(ctx context.Context, userID int64, method, address string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if method == DeliveryPickup {
+		address = ""
+	}
+
+	const query = `
+        INSERT INTO user_preferences (user_id, delivery_method, delivery_address)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET delivery_method = $2, delivery_address = $3, updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, method, address); err != nil {
+		return fmt.Errorf("failed to save delivery preference: %w", err)
+	}
+	return nil
+}
+
+// ToggleFavoriteTexture adds the texture to the favorites or removes it
+// when it is there; favorite reports which happened
+func (s *PostgresStorage) ToggleFavoriteTexture(ctx context.Context, userID int64, textureID string) (favorite bool, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO user_preferences (user_id, favorite_textures)
+        VALUES ($1, ARRAY[$2::text])
+        ON CONFLICT (user_id) DO UPDATE
+        SET favorite_textures = CASE
+                WHEN $2 = ANY(user_preferences.favorite_textures)
+                THEN array_remove(user_preferences.favorite_textures, $2)
+                ELSE array_append(user_preferences.favorite_textures, $2)
+            END,
+            updated_at = NOW()
+        RETURNING $2 = ANY(favorite_textures)
+    `
+
+	if err := s.db.QueryRowContext(ctx, query, userID, textureID).Scan(&favorite); err != nil {
+		return false, fmt.Errorf("failed to toggle favorite texture: %w", err)
+	}
+	return favorite, nil
+}
+
+// ClearPreferences forgets the saved delivery and favorites
+func (s *PostgresStorage) ClearPreferences(ctx context.Context, userID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_preferences WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+var ErrPriceQuoteNotFound = errors.New("price quote not found")
+
+// PriceQuote is the price a customer was shown on the order summary
+type PriceQuote struct {
+	ID          int64       `db:"id"`
+	UserID      int64       `db:"user_id"`
+	ServiceType ServiceType `db:"service_type"`
+	// Breakdown is the quoted pricing.Breakdown as JSON
+	Breakdown json.RawMessage `db:"breakdown"`
+	Price     money.Amount    `db:"price"`
+	Currency  money.Currency  `db:"currency"`
+	// PricedAt is the moment whose materials and rates were quoted
+	PricedAt  time.Time `db:"priced_at"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Expired reports whether the quoted price no longer holds at now
+func (q PriceQuote) Expired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// SavePriceQuote keeps the quote and returns its ID. It replaces the
+// customer's previous quote of the product line: only the latest summary
+// can be confirmed.
+func (s *PostgresStorage) SavePriceQuote(ctx context.Context, q PriceQuote) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        DELETE FROM price_quotes WHERE user_id = $1 AND service_type = $2
+    `, q.UserID, q.ServiceType); err != nil {
+		return 0, fmt.Errorf("failed to drop previous price quote: %w", err)
+	}
+
+	const query = `
+        INSERT INTO price_quotes (user_id, service_type, breakdown, price, currency, priced_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		q.UserID, q.ServiceType, []byte(q.Breakdown), q.Price, q.Currency, q.PricedAt, q.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save price quote: %w", err)
+	}
+
+	if err := appendOrderEvent(ctx, tx, nil, q.UserID, EventQuoteShown, map[string]any{
+		"quote_id":     id,
+		"service_type": q.ServiceType,
+		"price":        q.Price,
+		"currency":     q.Currency,
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit price quote: %w", err)
+	}
+	return id, nil
+}
+
+// GetPriceQuote returns a quote of the customer, expired or not
+func (s *PostgresStorage) GetPriceQuote(ctx context.Context, userID, id int64) (*PriceQuote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, user_id, service_type, breakdown, price, currency, priced_at, created_at, expires_at
+        FROM price_quotes
+        WHERE id = $1 AND user_id = $2
+    `
+	var q PriceQuote
+	err := s.db.GetContext(ctx, &q, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPriceQuoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price quote: %w", err)
+	}
+	return &q, nil
+}
+
+// DeletePriceQuote removes the quote an order was placed from
+func (s *PostgresStorage) DeletePriceQuote(ctx context.Context, userID, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM price_quotes WHERE id = $1 AND user_id = $2`, id, userID); err != nil {
+		return fmt.Errorf("failed to delete price quote: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const pricingRuleCacheKey = "pricing_rule:active"
+
+// PricingRule holds commission and tax rates valid from EffectiveFrom
+// until the next rule takes effect
+type PricingRule struct {
+	ID             int64   `db:"id"`
+	CommissionRate float64 `db:"commission_rate"`
+	TaxRate        float64 `db:"tax_rate"`
+	// TaxRegime names the pricing.Regime TaxRate applies under
+	TaxRegime     string    `db:"tax_regime"`
+	EffectiveFrom time.Time `db:"effective_from"`
+	CreatedBy     int64     `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+var ErrNoPricingRule = errors.New("no pricing rule in effect")
+
+// GetActivePricingRule returns the rule in effect at the given moment.
+// The rule active right now is cached in Redis until the next rule starts.
+func (s *PostgresStorage) GetActivePricingRule(ctx context.Context, at time.Time) (*PricingRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	useCache := !at.Before(now.Add(-time.Minute))
+
+	if useCache {
+		if cached, err := s.redis.Get(ctx, pricingRuleCacheKey); err == nil {
+			var rule PricingRule
+			if err := json.Unmarshal(cached, &rule); err == nil && !rule.EffectiveFrom.After(at) {
+				return &rule, nil
+			}
+		}
+	}
+
+	const query = `
+        SELECT id, commission_rate, tax_rate, tax_regime, effective_from, created_by, created_at
+        FROM pricing_rules
+        WHERE effective_from <= $1
+        ORDER BY effective_from DESC, id DESC
+        LIMIT 1
+    `
+
+	var rule PricingRule
+	err := s.db.GetContext(ctx, &rule, query, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoPricingRule
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing rule: %w", err)
+	}
+
+	if useCache {
+		// Don't keep the cached rule past the start of a scheduled one
+		ttl := time.Hour
+		var next sql.NullTime
+		err := s.db.QueryRowContext(ctx,
+			`SELECT MIN(effective_from) FROM pricing_rules WHERE effective_from > $1`, at,
+		).Scan(&next)
+		if err == nil && next.Valid && next.Time.Sub(now) < ttl {
+			ttl = next.Time.Sub(now)
+		}
+
+		if data, err := json.Marshal(rule); err == nil && ttl > 0 {
+			s.redis.Set(ctx, pricingRuleCacheKey, data, ttl)
+		}
+	}
+
+	return &rule, nil
+}
+
+// CreatePricingRule schedules new rates starting from rule.EffectiveFrom
+func (s *PostgresStorage) CreatePricingRule(ctx context.Context, rule PricingRule) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO pricing_rules (commission_rate, tax_rate, tax_regime, effective_from, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		rule.CommissionRate,
+		rule.TaxRate,
+		rule.TaxRegime,
+		rule.EffectiveFrom,
+		rule.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create pricing rule: %w", err)
+	}
+
+	s.redis.Del(ctx, pricingRuleCacheKey)
+
+	return id, nil
+}
+
+// ListPricingRules returns the most recent rules, newest first
+func (s *PostgresStorage) ListPricingRules(ctx context.Context, limit int) ([]PricingRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, commission_rate, tax_rate, tax_regime, effective_from, created_by, created_at
+        FROM pricing_rules
+        ORDER BY effective_from DESC, id DESC
+        LIMIT $1
+    `
+
+	var rules []PricingRule
+	if err := s.db.SelectContext(ctx, &rules, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list pricing rules: %w", err)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"slices"
+	"time"
+)
+
+// ServiceType is the product line of an order and of the materials it is
+// made from. The *_service_type_check constraints accept exactly these values.
+type ServiceType string
+
+const (
+	ServiceLeather    ServiceType = "leather"
+	ServiceSticker    ServiceType = "sticker"
+	ServiceTypography ServiceType = "typography"
+)
+
+var ErrInvalidServiceType = errors.New("invalid service type")
+
+func (t ServiceType) Valid() bool {
+	switch t {
+	case ServiceLeather, ServiceSticker, ServiceTypography:
+		return true
+	}
+	return false
+}
+
+func (t ServiceType) String() string {
+	return string(t)
+}
+
+// Value stores the zero value as leather, which is what every order was
+// before other products existed
+func (t ServiceType) Value() (driver.Value, error) {
+	if t == "" {
+		return string(ServiceLeather), nil
+	}
+	if !t.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidServiceType, string(t))
+	}
+	return string(t), nil
+}
+
+// OrLeather treats the zero value, e.g. from a texture cached before product
+// lines existed, as leather
+func (t ServiceType) OrLeather() ServiceType {
+	if t == "" {
+		return ServiceLeather
+	}
+	return t
+}
+
+// ProductOptions are the product-specific choices of an order, such as the
+// lamination of stickers. Stored as a JSON object.
+type ProductOptions map[string]string
+
+func (o ProductOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(o)
+}
+
+func (o *ProductOptions) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*o = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into ProductOptions", src)
+	}
+	return json.Unmarshal(data, o)
+}
+
+// ExchangeRates maps a currency to the price of one unit of it in the order
+// currency
+type ExchangeRates map[money.Currency]float64
+
+func (r ExchangeRates) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *ExchangeRates) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*r = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into ExchangeRates", src)
+	}
+	return json.Unmarshal(data, r)
+}
+
+// MaterialDM2 is the material the order consumes: the piece area times the
+// number of pieces, over all items
+func (o Order) MaterialDM2() float64 {
+	var total float64
+	for _, item := range o.LineItems() {
+		total += item.MaterialDM2()
+	}
+	return total
+}
+
+// Total is what the customer pays, in the order currency
+func (o Order) Total() money.Money {
+	return money.New(o.Price, o.Currency)
+}
+
+// Payable is what is left for the customer to pay after the gift card
+func (o Order) Payable() money.Amount {
+	return o.Price - o.GiftCardAmount
+}
+
+// Suits reports whether the material can be used for a product of its line
+func (t Texture) Suits(product string) bool {
+	return len(t.Products) == 0 || slices.Contains(t.Products, product)
+}
+
+// GetMaterials lists the in-stock materials of a product line, cheapest first
+func (s *PostgresStorage) GetMaterials(ctx context.Context, serviceType ServiceType) ([]Texture, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT ` + textureColumns + `
+        FROM textures
+        WHERE in_stock = TRUE AND service_type = $1
+        ORDER BY price_per_dm2, name
+    `
+
+	var materials []Texture
+	if err := s.db.SelectContext(ctx, &materials, query, serviceType); err != nil {
+		return nil, fmt.Errorf("failed to get %s materials: %w", serviceType, err)
+	}
+	return materials, nil
+}
+
+// materialsCacheTTL only bounds what a missed invalidation, like a manual
+// UPDATE in psql, can leave behind: texture changes drop the lists
+const materialsCacheTTL = 10 * time.Minute
+
+func materialsCacheKey(serviceType ServiceType) string {
+	return fmt.Sprintf("materials:%s", serviceType)
+}
+
+// GetCachedMaterials is GetMaterials served from Redis, for lookups that
+// run on every keystroke such as inline quotes. Reservations and order
+// dialogs keep reading GetMaterials.
+func (s *PostgresStorage) GetCachedMaterials(ctx context.Context, serviceType ServiceType) ([]Texture, error) {
+	key := materialsCacheKey(serviceType)
+	if cached, err := s.redis.Get(ctx, key); err == nil {
+		var materials []Texture
+		if err := json.Unmarshal(cached, &materials); err == nil {
+			return materials, nil
+		}
+	}
+
+	materials, err := s.GetMaterials(ctx, serviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(materials); err == nil {
+		s.redis.Set(ctx, key, data, materialsCacheTTL)
+	}
+	return materials, nil
+}
+
+func (s *PostgresStorage) dropMaterialsCache(ctx context.Context) {
+	s.redis.Del(ctx,
+		materialsCacheKey(ServiceLeather),
+		materialsCacheKey(ServiceSticker),
+		materialsCacheKey(ServiceTypography))
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PromoKind is how a promo code discounts the order
+type PromoKind string
+
+const (
+	// PromoPercent takes Value percent off the price
+	PromoPercent PromoKind = "percent"
+	// PromoFixed takes Value rubles off the price
+	PromoFixed PromoKind = "fixed"
+)
+
+var (
+	ErrPromoNotFound  = errors.New("promo code not found")
+	ErrPromoExists    = errors.New("promo code already exists")
+	ErrPromoExpired   = errors.New("promo code expired")
+	ErrPromoExhausted = errors.New("promo code usage limit reached")
+	ErrPromoUserLimit = errors.New("promo code already used by this customer")
+)
+
+type PromoCode struct {
+	ID    int64     `db:"id"`
+	Code  string    `db:"code"`
+	Kind  PromoKind `db:"kind"`
+	Value float64   `db:"value"`
+	// MaxUses is the limit over all customers, nil for unlimited
+	MaxUses      *int       `db:"max_uses"`
+	PerUserLimit int        `db:"per_user_limit"`
+	UsedCount    int        `db:"used_count"`
+	ExpiresAt    *time.Time `db:"expires_at"`
+	CreatedBy    int64      `db:"created_by"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+const promoColumns = `id, code, kind, value, max_uses, per_user_limit, used_count, expires_at, created_by, created_at`
+
+// CreatePromoCode saves a new code; codes are unique
+func (s *PostgresStorage) CreatePromoCode(ctx context.Context, code PromoCode) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO promocodes (code, kind, value, max_uses, per_user_limit, expires_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		code.Code,
+		code.Kind,
+		code.Value,
+		code.MaxUses,
+		max(code.PerUserLimit, 1),
+		code.ExpiresAt,
+		code.CreatedBy,
+	).Scan(&id)
+	if isUniqueViolation(err, "promocodes_code_unique") {
+		return 0, ErrPromoExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create promo code: %w", err)
+	}
+	return id, nil
+}
+
+func (s *PostgresStorage) GetPromoCode(ctx context.Context, code string) (*PromoCode, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var promo PromoCode
+	err := s.db.GetContext(ctx, &promo, `SELECT `+promoColumns+` FROM promocodes WHERE code = $1`, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPromoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	return &promo, nil
+}
+
+// ListPromoCodes returns the most recent codes, newest first
+func (s *PostgresStorage) ListPromoCodes(ctx context.Context, limit int) ([]PromoCode, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var codes []PromoCode
+	err := s.db.SelectContext(ctx, &codes,
+		`SELECT `+promoColumns+` FROM promocodes ORDER BY created_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+	return codes, nil
+}
+
+// CountPromoRedemptions returns how many orders of the customer used the code
+func (s *PostgresStorage) CountPromoRedemptions(ctx context.Context, promoID, userID int64) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := s.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM promocode_redemptions WHERE promocode_id = $1 AND user_id = $2`, promoID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count promo redemptions: %w", err)
+	}
+	return count, nil
+}
+
+// redeemPromoCode counts the use of a code by an order inside tx. The limits
+// were checked at quote time; the row lock taken here rechecks them against
+// concurrent orders.
+func redeemPromoCode(ctx context.Context, tx *sqlx.Tx, promoID, orderID, userID int64, discount money.Amount) error {
+	var perUserLimit int
+	err := tx.QueryRowContext(ctx, `
+        UPDATE promocodes
+        SET used_count = used_count + 1
+        WHERE id = $1
+          AND (max_uses IS NULL OR used_count < max_uses)
+          AND (expires_at IS NULL OR expires_at > NOW())
+        RETURNING per_user_limit
+    `, promoID).Scan(&perUserLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPromoExhausted
+	}
+	if err != nil {
+		return fmt.Errorf("failed to redeem promo code: %w", err)
+	}
+
+	var used int
+	if err := tx.GetContext(ctx, &used,
+		`SELECT COUNT(*) FROM promocode_redemptions WHERE promocode_id = $1 AND user_id = $2`,
+		promoID, userID); err != nil {
+		return fmt.Errorf("failed to count promo redemptions: %w", err)
+	}
+	if used >= perUserLimit {
+		return ErrPromoUserLimit
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO promocode_redemptions (promocode_id, order_id, user_id, discount)
+        VALUES ($1, $2, $3, $4)
+    `, promoID, orderID, userID, discount); err != nil {
+		return fmt.Errorf("failed to record promo redemption: %w", err)
+	}
+	return nil
+}
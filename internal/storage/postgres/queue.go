@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetProductionQueue returns the orders the workshop has to make: confirmed
+// and in production, the nearest deadline first. Held and finished orders
+// are left out.
+func (s *PostgresStorage) GetProductionQueue(ctx context.Context, limit int) ([]Order, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT *
+        FROM orders
+        WHERE status IN ('new', 'processing') AND deleted_at IS NULL
+        ORDER BY COALESCE(due_at, ready_by) NULLS LAST, created_at
+        LIMIT $1
+    `
+
+	var orders []Order
+	if err := s.db.SelectContext(ctx, &orders, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to get production queue: %w", err)
+	}
+	if err := attachItems(ctx, s.db, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
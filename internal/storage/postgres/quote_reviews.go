@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+// ErrQuoteNotPending means the order doesn't wait for its price: it was
+// approved already, cancelled, or is still on hold
+var ErrQuoteNotPending = errors.New("order is not pending a quote")
+
+// QuoteReview is a manager's review of the price of an order
+type QuoteReview struct {
+	ID      int64  `db:"id"`
+	OrderID int64  `db:"order_id"`
+	Reason  string `db:"reason"`
+	// CalculatedPrice is the calculator's quote, Price the one the manager
+	// approved, nil until then
+	CalculatedPrice money.Amount  `db:"calculated_price"`
+	Price           *money.Amount `db:"price"`
+	CreatedAt       time.Time     `db:"created_at"`
+	ResolvedBy      *int64        `db:"resolved_by"`
+	ResolvedAt      *time.Time    `db:"resolved_at"`
+}
+
+// QuotePrice is the approved price with the figures derived from it
+type QuotePrice struct {
+	Price      money.Amount
+	Commission money.Amount
+	Tax        money.Amount
+	NetRevenue money.Amount
+	Profit     money.Amount
+	Prepayment money.Amount
+}
+
+// GetOpenQuoteReview returns the review of an order still waiting for it
+func (s *PostgresStorage) GetOpenQuoteReview(ctx context.Context, orderID int64) (*QuoteReview, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, order_id, reason, calculated_price, price, created_at, resolved_by, resolved_at
+        FROM quote_reviews
+        WHERE order_id = $1 AND resolved_at IS NULL
+        ORDER BY id DESC
+        LIMIT 1
+    `
+
+	var review QuoteReview
+	err := s.db.GetContext(ctx, &review, query, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: order %d", ErrQuoteNotPending, orderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote review: %w", err)
+	}
+	return &review, nil
+}
+
+// ApproveQuote sets the price of an order pending a quote and releases it:
+// to production, announced as a released hold is, or to await the deposit
+// of the new price. Returns the new status.
+func (s *PostgresStorage) ApproveQuote(ctx context.Context, orderID, managerID int64, p QuotePrice) (OrderStatus, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var order struct {
+		UserID     int64        `db:"user_id"`
+		Status     OrderStatus  `db:"status"`
+		Price      money.Amount `db:"price"`
+		PaidAmount money.Amount `db:"paid_amount"`
+	}
+	err = tx.GetContext(ctx, &order,
+		`SELECT user_id, status, price, paid_amount FROM orders WHERE id = $1 FOR UPDATE`, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load order: %w", err)
+	}
+	if order.Status != StatusPendingQuote {
+		return "", fmt.Errorf("%w: order %d is %s", ErrQuoteNotPending, orderID, order.Status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE quote_reviews SET price = $2, resolved_by = $3, resolved_at = NOW()
+        WHERE order_id = $1 AND resolved_at IS NULL
+    `, orderID, p.Price, managerID); err != nil {
+		return "", fmt.Errorf("failed to resolve quote review: %w", err)
+	}
+
+	status := StatusNew
+	if order.PaidAmount < p.Prepayment {
+		status = StatusAwaitingPrepayment
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE orders
+        SET price = $2, commission = $3, tax = $4, net_revenue = $5, profit = $6,
+            prepayment = $7, status = $8, updated_at = NOW()
+        WHERE id = $1
+    `, orderID, p.Price, p.Commission, p.Tax, p.NetRevenue, p.Profit, p.Prepayment, status); err != nil {
+		return "", fmt.Errorf("failed to approve quote: %w", err)
+	}
+
+	if err := appendOrderEvent(ctx, tx, &orderID, order.UserID, EventQuoteApproved, map[string]any{
+		"calculated":  order.Price,
+		"price":       p.Price,
+		"approved_by": managerID,
+	}); err != nil {
+		return "", err
+	}
+	if err := recordStatusChange(ctx, tx, orderID, order.UserID, order.Status, status); err != nil {
+		return "", err
+	}
+
+	if status == StatusNew {
+		// Orders pending a quote were not announced yet
+		if err := enqueueOutbox(ctx, tx, OutboxOrderCreated, OrderOutboxPayload{
+			OrderID: orderID,
+			UserID:  order.UserID,
+			Status:  status,
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit quote approval: %w", err)
+	}
+	return status, nil
+}
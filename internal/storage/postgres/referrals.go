@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrReferralCodeNotFound = errors.New("referral code not found")
+	ErrReferralCodeTaken    = errors.New("referral code already taken")
+)
+
+// ReferralStats summarizes the customers a user brought in
+type ReferralStats struct {
+	Invited   int     `db:"invited"`
+	Converted int     `db:"converted"`
+	Balance   float64 `db:"balance"`
+}
+
+// EnsureReferralCode stores candidate as the user's code unless one is
+// already issued, and returns the code in effect
+func (s *PostgresStorage) EnsureReferralCode(ctx context.Context, userID int64, candidate string) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, referral_code)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET referral_code = COALESCE(users.referral_code, $2), updated_at = NOW()
+        RETURNING referral_code
+    `
+
+	var code string
+	err := s.db.QueryRowContext(ctx, query, userID, candidate).Scan(&code)
+	if isUniqueViolation(err, "users_referral_code_unique") {
+		return "", ErrReferralCodeTaken
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save referral code: %w", err)
+	}
+	return code, nil
+}
+
+func (s *PostgresStorage) GetReferrerByCode(ctx context.Context, code string) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var userID int64
+	err := s.db.GetContext(ctx, &userID, `SELECT user_id FROM users WHERE referral_code = $1`, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrReferralCodeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find referral code: %w", err)
+	}
+	return userID, nil
+}
+
+// RecordReferral links a new customer to the one who invited them. Customers
+// who already ordered or were already referred are left as they are; the
+// result reports whether the referral was recorded.
+func (s *PostgresStorage) RecordReferral(ctx context.Context, refereeID, referrerID int64) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO referrals (referee_id, referrer_id)
+        SELECT $1, $2
+        WHERE NOT EXISTS (SELECT 1 FROM orders WHERE user_id = $1)
+          AND NOT EXISTS (SELECT 1 FROM orders_archive WHERE user_id = $1)
+        ON CONFLICT (referee_id) DO NOTHING
+    `
+
+	res, err := s.db.ExecContext(ctx, query, refereeID, referrerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record referral: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record referral: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CreditReferral pays the referrer of the customer for their first completed
+// order. It credits at most once and returns the referrer when it did.
+func (s *PostgresStorage) CreditReferral(ctx context.Context, refereeID, orderID int64, bonus float64) (int64, bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE referrals
+        SET order_id = $2, bonus = $3, credited_at = NOW()
+        WHERE referee_id = $1 AND credited_at IS NULL
+        RETURNING referrer_id
+    `
+
+	var referrerID int64
+	err := s.db.QueryRowContext(ctx, query, refereeID, orderID, bonus).Scan(&referrerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to credit referral: %w", err)
+	}
+	return referrerID, true, nil
+}
+
+func (s *PostgresStorage) GetReferralStats(ctx context.Context, referrerID int64) (*ReferralStats, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT
+            COUNT(*) AS invited,
+            COUNT(*) FILTER (WHERE credited_at IS NOT NULL) AS converted,
+            COALESCE(SUM(bonus), 0) AS balance
+        FROM referrals
+        WHERE referrer_id = $1
+    `
+
+	var stats ReferralStats
+	if err := s.db.GetContext(ctx, &stats, query, referrerID); err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// openReplica opens the read replica pool. An unreachable replica is not
+// fatal: it starts marked down and WatchReplica brings it back.
+func (s *PostgresStorage) openReplica(ctx context.Context, dsn string, statementCache, maxOpen, maxIdle int) error {
+	connStr, err := registerConnConfig(dsn, statementCache)
+	if err != nil {
+		return err
+	}
+
+	if s.replica, err = openDB(connStr); err != nil {
+		return err
+	}
+	s.replica.SetMaxOpenConns(maxOpen)
+	s.replica.SetMaxIdleConns(maxIdle)
+
+	if err := s.replica.PingContext(ctx); err != nil {
+		s.logger.Warn("Read replica is unreachable, reading from primary", zap.Error(err))
+		return nil
+	}
+
+	s.replicaUp.Store(true)
+	s.logger.Info("Successfully connected to PostgreSQL read replica")
+	return nil
+}
+
+// read runs a read-only query on the replica when it is up. If the replica
+// can't be reached the query is retried on the primary and the replica is
+// left alone until WatchReplica sees it healthy again. Queries must not
+// depend on writes made just before them: the replica may lag.
+func (s *PostgresStorage) read(ctx context.Context, query func(db sqlx.QueryerContext) error) error {
+	if s.replica != nil && s.replicaUp.Load() {
+		err := query(s.replica)
+		if !isConnectionError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if s.replicaUp.CompareAndSwap(true, false) {
+			s.logger.Warn("Read replica failed, falling back to primary", zap.Error(err))
+		}
+	}
+
+	return query(s.db)
+}
+
+// WatchReplica pings the replica every interval and routes reads back to it
+// once it answers
+func (s *PostgresStorage) WatchReplica(ctx context.Context, interval time.Duration) {
+	if s.replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval/2)
+		err := s.replica.PingContext(pingCtx)
+		cancel()
+
+		switch {
+		case err == nil && s.replicaUp.CompareAndSwap(false, true):
+			s.logger.Info("Read replica is back, reading from it again")
+		case err != nil && s.replicaUp.CompareAndSwap(true, false):
+			s.logger.Warn("Read replica is down, reading from primary", zap.Error(err))
+		}
+	}
+}
+
+// isConnectionError tells failures of the server or the network from
+// errors of the query itself, which would fail on the primary as well
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	if pgErr, ok := pgError(err); ok {
+		// 08: connection exception; 57P: server shutting down or starting up
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	return false
+}
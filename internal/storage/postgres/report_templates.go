@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/internal/i18n"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrReportTemplateNotFound = errors.New("report template not found")
+	ErrUnknownExportColumn    = errors.New("unknown export column")
+)
+
+// ReportTemplate is a saved layout of the orders export: which columns in
+// which order, their titles and the orders it covers
+type ReportTemplate struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+	// Columns are names from ExportColumnNames, in sheet order
+	Columns StringArray `db:"columns"`
+	// Headers replace column titles, see ReportHeaders
+	Headers ReportHeaders `db:"headers"`
+	// Statuses limits the export to orders in these statuses; empty takes all
+	Statuses  StringArray `db:"statuses"`
+	CreatedBy int64       `db:"created_by"`
+	CreatedAt time.Time   `db:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at"`
+}
+
+// ReportHeaders maps a locale to the titles of the columns it renames.
+// Titles under the "" locale apply to every locale without its own.
+type ReportHeaders map[i18n.Locale]map[string]string
+
+// Title returns the title of the column in the locale, if the template
+// renames it
+func (h ReportHeaders) Title(locale i18n.Locale, column string) (string, bool) {
+	if title, ok := h[locale][column]; ok {
+		return title, true
+	}
+	title, ok := h[""][column]
+	return title, ok
+}
+
+func (h *ReportHeaders) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("cannot scan %T into ReportHeaders", src)
+	}
+	return json.Unmarshal(data, h)
+}
+
+func (h ReportHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+// ExportColumnNames returns the names templates pick columns by, in the
+// order of the full orders sheet
+func ExportColumnNames() []string {
+	names := make([]string, len(orderColumns))
+	for i, column := range orderColumns {
+		names[i] = column.name()
+	}
+	return names
+}
+
+// SaveReportTemplate creates the template or replaces the one with the
+// same name
+func (s *PostgresStorage) SaveReportTemplate(ctx context.Context, t ReportTemplate) (*ReportTemplate, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if len(t.Columns) == 0 {
+		return nil, fmt.Errorf("%w: template has no columns", ErrUnknownExportColumn)
+	}
+	names := ExportColumnNames()
+	for _, column := range t.Columns {
+		if !slices.Contains(names, column) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownExportColumn, column)
+		}
+	}
+	for _, titles := range t.Headers {
+		for column := range titles {
+			if !slices.Contains(t.Columns, column) {
+				return nil, fmt.Errorf("%w: %s is renamed but not exported", ErrUnknownExportColumn, column)
+			}
+		}
+	}
+	for _, status := range t.Statuses {
+		if !OrderStatus(status).Valid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, status)
+		}
+	}
+	if t.Statuses == nil {
+		t.Statuses = StringArray{}
+	}
+
+	const query = `
+        INSERT INTO report_templates (name, columns, headers, statuses, created_by)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (name) DO UPDATE
+        SET columns = EXCLUDED.columns,
+            headers = EXCLUDED.headers,
+            statuses = EXCLUDED.statuses,
+            updated_at = NOW()
+        RETURNING *
+    `
+
+	var saved ReportTemplate
+	err := s.db.GetContext(ctx, &saved, query,
+		strings.TrimSpace(t.Name), []string(t.Columns), t.Headers, []string(t.Statuses), t.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save report template: %w", err)
+	}
+	return &saved, nil
+}
+
+// GetReportTemplate returns the template with the given name
+func (s *PostgresStorage) GetReportTemplate(ctx context.Context, name string) (*ReportTemplate, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var t ReportTemplate
+	err := s.db.GetContext(ctx, &t, `SELECT * FROM report_templates WHERE name = $1`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrReportTemplateNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report template: %w", err)
+	}
+	return &t, nil
+}
+
+// ListReportTemplates returns the templates by name
+func (s *PostgresStorage) ListReportTemplates(ctx context.Context) ([]ReportTemplate, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var templates []ReportTemplate
+	if err := s.db.SelectContext(ctx, &templates, `SELECT * FROM report_templates ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("failed to list report templates: %w", err)
+	}
+	return templates, nil
+}
+
+// DeleteReportTemplate removes the template with the given name
+func (s *PostgresStorage) DeleteReportTemplate(ctx context.Context, name string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM report_templates WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete report template: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrReportTemplateNotFound, name)
+	}
+	return nil
+}
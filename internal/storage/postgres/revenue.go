@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RevenueBucket string
+
+const (
+	BucketDay  RevenueBucket = "day"
+	BucketWeek RevenueBucket = "week"
+)
+
+// RevenuePoint is the revenue of one day or week
+type RevenuePoint struct {
+	Period  time.Time `db:"period"`
+	Orders  int       `db:"orders"`
+	Revenue float64   `db:"revenue"`
+}
+
+// GetRevenueSeries returns revenue grouped by day or week since the given time.
+// Empty periods are included with zero values so charts have no gaps.
+func (s *PostgresStorage) GetRevenueSeries(ctx context.Context, bucket RevenueBucket, since time.Time) ([]RevenuePoint, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	step := "1 day"
+	if bucket == BucketWeek {
+		step = "1 week"
+	}
+
+	const query = `
+        SELECT p.period,
+               COUNT(o.id) AS orders,
+               COALESCE(SUM(o.price), 0) AS revenue
+        FROM generate_series(
+                 date_trunc($1, $2::timestamptz),
+                 date_trunc($1, NOW()),
+                 $3::interval
+             ) AS p(period)
+        LEFT JOIN orders o
+               ON date_trunc($1, o.created_at) = p.period
+              AND o.status <> 'cancelled'
+        GROUP BY p.period
+        ORDER BY p.period
+    `
+
+	var points []RevenuePoint
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &points, query, string(bucket), since, step)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue series: %w", err)
+	}
+	return points, nil
+}
+
+// GetDailyOrderTotals counts every order, cancelled ones included, per day of
+// creation since the given day. Unlike GetRevenueSeries it matches the
+// windows of GetOrderStatistics and skips empty days.
+func (s *PostgresStorage) GetDailyOrderTotals(ctx context.Context, since time.Time) ([]RevenuePoint, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT created_at::date::timestamptz AS period,
+               COUNT(*) AS orders,
+               COALESCE(SUM(price), 0) AS revenue
+        FROM orders
+        WHERE created_at >= $1::date
+        GROUP BY 1
+        ORDER BY 1
+    `
+
+	var points []RevenuePoint
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &points, query, since)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily order totals: %w", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrReviewExists means the order was rated already
+	ErrReviewExists = errors.New("order already reviewed")
+	// ErrReviewNotFound means there is no review waiting for a comment, or
+	// no completed order of the customer to rate
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrReviewModerated means another manager approved or rejected the
+	// review first
+	ErrReviewModerated = errors.New("review already moderated")
+)
+
+// ReviewModeration is whether a review's comment may be shown in /reviews
+type ReviewModeration string
+
+const (
+	ReviewPending  ReviewModeration = "pending"
+	ReviewApproved ReviewModeration = "approved"
+	ReviewRejected ReviewModeration = "rejected"
+)
+
+const reviewColumns = `
+        id, order_id, user_id, rating, comment, comment_due, moderation,
+        moderated_by, moderated_at, created_at, updated_at
+`
+
+// Review is a customer's rating of a completed order
+type Review struct {
+	ID      int64  `db:"id" json:"id"`
+	OrderID int64  `db:"order_id" json:"order_id"`
+	UserID  int64  `db:"user_id" json:"user_id"`
+	Rating  int    `db:"rating" json:"rating"`
+	Comment string `db:"comment" json:"comment"`
+	// CommentDue is set while the bot waits for the comment
+	CommentDue  bool             `db:"comment_due" json:"-"`
+	Moderation  ReviewModeration `db:"moderation" json:"moderation"`
+	ModeratedBy *int64           `db:"moderated_by" json:"-"`
+	ModeratedAt *time.Time       `db:"moderated_at" json:"-"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time        `db:"updated_at" json:"updated_at"`
+}
+
+// ReviewSummary is the average rating of all reviews and of those of the
+// period asked for
+type ReviewSummary struct {
+	Count         int     `db:"count"`
+	Average       float64 `db:"average"`
+	PeriodCount   int     `db:"period_count"`
+	PeriodAverage float64 `db:"period_average"`
+}
+
+// HasReview reports whether the order was rated
+func (s *PostgresStorage) HasReview(ctx context.Context, orderID int64) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM reviews WHERE order_id = $1)`, orderID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check review: %w", err)
+	}
+	return exists, nil
+}
+
+// SaveRating records the rating of a completed order of the user and waits
+// for its comment; the comment another review still waited for is given
+// up. An order is rated once.
+func (s *PostgresStorage) SaveRating(ctx context.Context, orderID, userID int64, rating int) (*Review, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE reviews SET comment_due = FALSE, updated_at = NOW()
+        WHERE user_id = $1 AND comment_due
+    `, userID); err != nil {
+		return nil, fmt.Errorf("failed to close pending comments: %w", err)
+	}
+
+	var review Review
+	err = tx.GetContext(ctx, &review, `
+        INSERT INTO reviews (order_id, user_id, rating)
+        SELECT id, user_id, $3 FROM orders
+        WHERE id = $1 AND user_id = $2 AND status = 'completed'
+        ON CONFLICT (order_id) DO NOTHING
+        RETURNING `+reviewColumns, orderID, userID, rating)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM reviews WHERE order_id = $1)`, orderID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to check review: %w", err)
+		}
+		if exists {
+			return nil, ErrReviewExists
+		}
+		return nil, fmt.Errorf("%w: order %d", ErrReviewNotFound, orderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save rating: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rating: %w", err)
+	}
+	return &review, nil
+}
+
+// GetReviewAwaitingComment returns the review of the user rated since the
+// given time whose comment the bot waits for
+func (s *PostgresStorage) GetReviewAwaitingComment(ctx context.Context, userID int64, since time.Time) (*Review, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var review Review
+	err := s.db.GetContext(ctx, &review, `
+        SELECT `+reviewColumns+`
+        FROM reviews
+        WHERE user_id = $1 AND comment_due AND created_at >= $2
+        ORDER BY id DESC
+        LIMIT 1
+    `, userID, since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	return &review, nil
+}
+
+// SetReviewComment stores the comment of an order's review and stops
+// waiting for it; an empty comment just stops waiting
+func (s *PostgresStorage) SetReviewComment(ctx context.Context, orderID, userID int64, comment string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+        UPDATE reviews SET comment = $3, comment_due = FALSE, updated_at = NOW()
+        WHERE order_id = $1 AND user_id = $2 AND comment_due
+    `, orderID, userID, comment)
+	if err != nil {
+		return fmt.Errorf("failed to save review comment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+// GetReviewSummary averages the ratings of all reviews and of those left
+// since the given time
+func (s *PostgresStorage) GetReviewSummary(ctx context.Context, since time.Time) (*ReviewSummary, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT COUNT(*) AS count,
+               COALESCE(AVG(rating), 0) AS average,
+               COUNT(*) FILTER (WHERE created_at >= $1) AS period_count,
+               COALESCE(AVG(rating) FILTER (WHERE created_at >= $1), 0) AS period_average
+        FROM reviews
+    `
+
+	var summary ReviewSummary
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.GetContext(ctx, db, &summary, query, since)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// GetPublishedReviews returns a page of the approved reviews, newest
+// first. It asks for one more than limit so the caller knows whether a
+// next page exists.
+func (s *PostgresStorage) GetPublishedReviews(ctx context.Context, offset, limit int) ([]Review, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        SELECT ` + reviewColumns + `
+        FROM reviews
+        WHERE moderation = 'approved' AND comment <> ''
+        ORDER BY created_at DESC, id DESC
+        OFFSET $1
+        LIMIT $2
+    `
+
+	var reviews []Review
+	err := s.read(ctx, func(db sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, db, &reviews, query, offset, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get published reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetNextReviewToModerate returns the oldest comment waiting for a
+// manager and how many wait in all; ErrReviewNotFound when none does
+func (s *PostgresStorage) GetNextReviewToModerate(ctx context.Context) (*Review, int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var row struct {
+		Review
+		Queued int `db:"queued"`
+	}
+	err := s.db.GetContext(ctx, &row, `
+        SELECT `+reviewColumns+`, COUNT(*) OVER () AS queued
+        FROM reviews
+        WHERE moderation = 'pending' AND comment <> '' AND NOT comment_due
+        ORDER BY id
+        LIMIT 1
+    `)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get review to moderate: %w", err)
+	}
+	return &row.Review, row.Queued, nil
+}
+
+// ModerateReview approves a review's comment for /reviews or rejects it
+func (s *PostgresStorage) ModerateReview(ctx context.Context, reviewID, moderatorID int64, approve bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	verdict := ReviewRejected
+	if approve {
+		verdict = ReviewApproved
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+        UPDATE reviews
+        SET moderation = $2, moderated_by = $3, moderated_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND moderation = 'pending'
+    `, reviewID, verdict, moderatorID)
+	if err != nil {
+		return fmt.Errorf("failed to moderate review: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: review %d", ErrReviewModerated, reviewID)
+	}
+	return nil
+}
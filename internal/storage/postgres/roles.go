@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// StaffMember is a user with a role other than customer
+type StaffMember struct {
+	UserID int64  `db:"user_id"`
+	Role   string `db:"role"`
+}
+
+// GetUserRole returns the stored role of a user; "" for unknown users
+func (s *PostgresStorage) GetUserRole(ctx context.Context, userID int64) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var role string
+	err := s.db.QueryRowContext(ctx, `SELECT role FROM users WHERE user_id = $1`, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+// SetUserRole stores the role of a user. Users who never wrote to the bot
+// get a row, so staff can be granted before their first message.
+func (s *PostgresStorage) SetUserRole(ctx context.Context, userID int64, role string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, role)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET role = $2, updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, role); err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	return nil
+}
+
+// ListStaff returns the users with a role other than customer
+func (s *PostgresStorage) ListStaff(ctx context.Context) ([]StaffMember, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT user_id, role
+        FROM users
+        WHERE role <> 'customer'
+        ORDER BY role, user_id
+    `
+
+	var staff []StaffMember
+	if err := s.db.SelectContext(ctx, &staff, query); err != nil {
+		return nil, fmt.Errorf("failed to list staff: %w", err)
+	}
+	return staff, nil
+}
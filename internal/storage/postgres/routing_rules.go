@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const routingRulesCacheKey = "routing_rules"
+
+type RoutingRule struct {
+	ID           int64     `db:"id"`
+	Name         string    `db:"name"`
+	Priority     int       `db:"priority"`
+	Field        string    `db:"field"`
+	Operator     string    `db:"operator"`
+	Value        string    `db:"value"`
+	TargetChatID int64     `db:"target_chat_id"`
+	AssigneeID   *int64    `db:"assignee_id"`
+	Active       bool      `db:"active"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// GetRoutingRules returns active rules in evaluation order
+func (s *PostgresStorage) GetRoutingRules(ctx context.Context) ([]RoutingRule, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if cached, err := s.redis.Get(ctx, routingRulesCacheKey); err == nil {
+		var rules []RoutingRule
+		if err := json.Unmarshal(cached, &rules); err == nil {
+			return rules, nil
+		}
+	}
+
+	const query = `
+        SELECT id, name, priority, field, operator, value, target_chat_id, assignee_id, active, created_at
+        FROM routing_rules
+        WHERE active = TRUE
+        ORDER BY priority, id
+    `
+
+	var rules []RoutingRule
+	if err := s.db.SelectContext(ctx, &rules, query); err != nil {
+		return nil, fmt.Errorf("failed to get routing rules: %w", err)
+	}
+
+	if data, err := json.Marshal(rules); err == nil {
+		s.redis.Set(ctx, routingRulesCacheKey, data, time.Hour)
+	}
+
+	return rules, nil
+}
+
+func (s *PostgresStorage) CreateRoutingRule(ctx context.Context, rule RoutingRule) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO routing_rules (name, priority, field, operator, value, target_chat_id, assignee_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		rule.Name,
+		rule.Priority,
+		rule.Field,
+		rule.Operator,
+		rule.Value,
+		rule.TargetChatID,
+		rule.AssigneeID,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create routing rule: %w", err)
+	}
+
+	s.redis.Del(ctx, routingRulesCacheKey)
+	return id, nil
+}
+
+func (s *PostgresStorage) DeactivateRoutingRule(ctx context.Context, ruleID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `UPDATE routing_rules SET active = FALSE WHERE id = $1`, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate routing rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("routing rule %d not found", ruleID)
+	}
+
+	s.redis.Del(ctx, routingRulesCacheKey)
+	return nil
+}
+
+// AssignOrder records the manager responsible for an order
+func (s *PostgresStorage) AssignOrder(ctx context.Context, orderID, assigneeID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE orders SET assignee_id = $2, updated_at = NOW() WHERE id = $1`, orderID, assigneeID)
+	if err != nil {
+		return fmt.Errorf("failed to assign order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+var (
+	ErrSavedQuoteNotFound = errors.New("saved quote not found")
+	ErrTooManySavedQuotes = errors.New("too many saved quotes")
+)
+
+// SavedQuote is a quote a customer kept to order later
+type SavedQuote struct {
+	ID          int64       `db:"id"`
+	UserID      int64       `db:"user_id"`
+	ServiceType ServiceType `db:"service_type"`
+	// Title describes the products, in the customer's language at saving
+	Title string `db:"title"`
+	// Draft is the dialog draft the order resumes from
+	Draft     json.RawMessage `db:"draft"`
+	Price     money.Amount    `db:"price"`
+	Currency  money.Currency  `db:"currency"`
+	CreatedAt time.Time       `db:"created_at"`
+	ExpiresAt time.Time       `db:"expires_at"`
+}
+
+// SaveQuote keeps the quote until ttl passes. A customer keeps at most max
+// quotes at once; saving more is ErrTooManySavedQuotes.
+func (s *PostgresStorage) SaveQuote(ctx context.Context, q SavedQuote, ttl time.Duration, max int) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO saved_quotes (user_id, service_type, title, draft, price, currency, expires_at)
+        SELECT $1, $2, $3, $4, $5, $6, NOW() + make_interval(secs => $7)
+        WHERE (SELECT COUNT(*) FROM saved_quotes WHERE user_id = $1 AND expires_at > NOW()) < $8
+        RETURNING id
+    `
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		q.UserID, q.ServiceType, q.Title, []byte(q.Draft), q.Price, q.Currency, ttl.Seconds(), max,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTooManySavedQuotes
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to save quote: %w", err)
+	}
+	return id, nil
+}
+
+// ListSavedQuotes returns the customer's quotes that haven't expired, the
+// most recent first. Expired ones are removed on the way.
+func (s *PostgresStorage) ListSavedQuotes(ctx context.Context, userID int64) ([]SavedQuote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `
+        DELETE FROM saved_quotes WHERE user_id = $1 AND expires_at <= NOW()
+    `, userID); err != nil {
+		return nil, fmt.Errorf("failed to drop expired quotes: %w", err)
+	}
+
+	const query = `
+        SELECT id, user_id, service_type, title, draft, price, currency, created_at, expires_at
+        FROM saved_quotes
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+	var quotes []SavedQuote
+	if err := s.db.SelectContext(ctx, &quotes, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list saved quotes: %w", err)
+	}
+	return quotes, nil
+}
+
+// GetSavedQuote returns a quote of the customer that hasn't expired
+func (s *PostgresStorage) GetSavedQuote(ctx context.Context, userID, id int64) (*SavedQuote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, user_id, service_type, title, draft, price, currency, created_at, expires_at
+        FROM saved_quotes
+        WHERE id = $1 AND user_id = $2 AND expires_at > NOW()
+    `
+	var q SavedQuote
+	err := s.db.GetContext(ctx, &q, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedQuoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved quote: %w", err)
+	}
+	return &q, nil
+}
+
+// DeleteSavedQuote removes a quote of the customer, once ordered or no
+// longer wanted
+func (s *PostgresStorage) DeleteSavedQuote(ctx context.Context, userID, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM saved_quotes WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved quote: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSavedQuoteNotFound
+	}
+	return nil
+}
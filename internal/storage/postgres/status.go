@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OrderStatus is the lifecycle state of an order. The orders_status_check
+// constraint accepts exactly these values.
+type OrderStatus string
+
+const (
+	StatusNew        OrderStatus = "new"
+	StatusProcessing OrderStatus = "processing"
+	StatusCompleted  OrderStatus = "completed"
+	StatusCancelled  OrderStatus = "cancelled"
+	// StatusOnHold is set by the fraud checks only
+	StatusOnHold OrderStatus = "on_hold"
+	// StatusAwaitingPrepayment waits for the deposit; paying it moves the
+	// order to new, see RecordPayment
+	StatusAwaitingPrepayment OrderStatus = "awaiting_prepayment"
+	// StatusPendingQuote waits for a manager to approve the price; see
+	// ApproveQuote
+	StatusPendingQuote OrderStatus = "pending_quote"
+)
+
+var (
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	// ErrPrepaymentDue refuses to move an order to production before its
+	// deposit is paid; it can only be cancelled meanwhile
+	ErrPrepaymentDue = errors.New("prepayment is not paid yet")
+	// ErrQuotePending does the same before a manager approved the price
+	ErrQuotePending = errors.New("quote is not approved yet")
+)
+
+// StatusChange is an order moved by a bulk status update
+type StatusChange struct {
+	OrderID    int64       `db:"order_id"`
+	UserID     int64       `db:"user_id"`
+	PrevStatus OrderStatus `db:"prev_status"`
+}
+
+// OrderStatuses lists every valid status
+var OrderStatuses = []OrderStatus{StatusNew, StatusProcessing, StatusCompleted, StatusCancelled, StatusOnHold, StatusAwaitingPrepayment, StatusPendingQuote}
+
+// ParseOrderStatus accepts user input such as "Cancelled" or "canceled"
+func ParseOrderStatus(raw string) (OrderStatus, error) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	if normalized == "canceled" {
+		normalized = string(StatusCancelled)
+	}
+
+	status := OrderStatus(normalized)
+	if !status.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrderStatus, raw)
+	}
+	return status, nil
+}
+
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case StatusNew, StatusProcessing, StatusCompleted, StatusCancelled, StatusOnHold, StatusAwaitingPrepayment, StatusPendingQuote:
+		return true
+	}
+	return false
+}
+
+// Open reports whether the order still needs work
+func (s OrderStatus) Open() bool {
+	return s == StatusNew || s == StatusProcessing || s == StatusOnHold || s == StatusAwaitingPrepayment ||
+		s == StatusPendingQuote
+}
+
+func (s OrderStatus) String() string {
+	return string(s)
+}
+
+// Value refuses to write an unknown status instead of leaving it to the constraint
+func (s OrderStatus) Value() (driver.Value, error) {
+	if !s.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, string(s))
+	}
+	return string(s), nil
+}
+
+func (s *OrderStatus) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		return fmt.Errorf("%w: NULL", ErrInvalidOrderStatus)
+	default:
+		return fmt.Errorf("cannot scan %T into OrderStatus", src)
+	}
+
+	status := OrderStatus(raw)
+	if !status.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidOrderStatus, raw)
+	}
+	*s = status
+	return nil
+}
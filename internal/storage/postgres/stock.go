@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var ErrInsufficientStock = errors.New("insufficient texture stock")
+
+// reserveStock takes areaDM2 from the texture inside tx. The row lock keeps
+// concurrent orders for the same texture from overselling. Returns the
+// reserved area, which is zero for textures without stock tracking.
+func reserveStock(ctx context.Context, tx *sqlx.Tx, textureID string, areaDM2 float64) (float64, error) {
+	var stock sql.NullFloat64
+	err := tx.QueryRowContext(ctx,
+		`SELECT stock_dm2 FROM textures WHERE id = $1 FOR UPDATE`, textureID).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock texture stock: %w", err)
+	}
+
+	if !stock.Valid {
+		return 0, nil
+	}
+	if stock.Float64 < areaDM2 {
+		return 0, fmt.Errorf("%w: %.2f dm² left, %.2f dm² requested", ErrInsufficientStock, stock.Float64, areaDM2)
+	}
+
+	const query = `
+        UPDATE textures
+        SET stock_dm2 = stock_dm2 - $2,
+            in_stock = (stock_dm2 - $2) > 0,
+            updated_at = NOW()
+        WHERE id = $1
+    `
+	if _, err := tx.ExecContext(ctx, query, textureID, areaDM2); err != nil {
+		return 0, fmt.Errorf("failed to reserve texture stock: %w", err)
+	}
+
+	return areaDM2, nil
+}
+
+// releaseStock returns the area reserved by an order's items to their
+// textures and reports the texture IDs. It is idempotent: reservations are
+// zeroed once released.
+func releaseStock(ctx context.Context, tx *sqlx.Tx, orderID int64) ([]string, error) {
+	var locked int64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT id FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to lock order reservation: %w", err)
+	}
+
+	type reservation struct {
+		TextureID string  `db:"texture_id"`
+		Reserved  float64 `db:"reserved"`
+	}
+	var reservations []reservation
+	err := tx.SelectContext(ctx, &reservations, `
+        SELECT texture_id::text AS texture_id, SUM(reserved_dm2) AS reserved
+        FROM order_items
+        WHERE order_id = $1 AND reserved_dm2 > 0
+        GROUP BY texture_id
+    `, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order reservations: %w", err)
+	}
+
+	if len(reservations) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE order_items SET reserved_dm2 = 0 WHERE order_id = $1`, orderID); err != nil {
+		return nil, fmt.Errorf("failed to clear item reservations: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET reserved_dm2 = 0 WHERE id = $1`, orderID); err != nil {
+		return nil, fmt.Errorf("failed to clear reservation: %w", err)
+	}
+
+	const query = `
+        UPDATE textures
+        SET stock_dm2 = stock_dm2 + $2,
+            in_stock = TRUE,
+            updated_at = NOW()
+        WHERE id = $1 AND stock_dm2 IS NOT NULL
+    `
+	textureIDs := make([]string, 0, len(reservations))
+	for _, r := range reservations {
+		if _, err := tx.ExecContext(ctx, query, r.TextureID, r.Reserved); err != nil {
+			return nil, fmt.Errorf("failed to release texture stock: %w", err)
+		}
+		textureIDs = append(textureIDs, r.TextureID)
+	}
+
+	if err := returnBatches(ctx, tx, orderID); err != nil {
+		return nil, err
+	}
+
+	return textureIDs, nil
+}
+
+// ReleaseOrderStock returns the reserved area of a cancelled order to stock
+func (s *PostgresStorage) ReleaseOrderStock(ctx context.Context, orderID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	textureIDs, err := releaseStock(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit stock release: %w", err)
+	}
+
+	for _, textureID := range textureIDs {
+		s.invalidateTextureCache(ctx, textureID)
+	}
+	return nil
+}
+
+// SetTextureStock sets the remaining area; nil disables stock tracking
+func (s *PostgresStorage) SetTextureStock(ctx context.Context, textureID string, stockDM2 *float64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE textures
+        SET stock_dm2 = $2,
+            in_stock = COALESCE($2 > 0, TRUE),
+            updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + textureColumns + `
+    `
+
+	var texture Texture
+	err := s.db.GetContext(ctx, &texture, query, textureID, stockDM2)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set texture stock: %w", err)
+	}
+
+	s.cacheTexture(ctx, texture)
+	s.logger.Info("Texture stock updated",
+		zap.String("texture_id", textureID),
+		zap.Any("stock_dm2", stockDM2))
+	return nil
+}
+
+// LowStock is a tracked texture whose remaining area fell below the alert
+// threshold, with its last delivery when it had one
+type LowStock struct {
+	TextureID    string     `db:"texture_id"`
+	Name         string     `db:"name"`
+	StockDM2     float64    `db:"stock_dm2"`
+	LastBatchDM2 *float64   `db:"last_batch_dm2"`
+	LastBatchAt  *time.Time `db:"last_batch_at"`
+}
+
+// ClaimLowStockAlerts returns the tracked textures with less than
+// thresholdDM2 left that admins weren't told about yet, and marks them as
+// told. Textures back at or above the threshold are rearmed first.
+func (s *PostgresStorage) ClaimLowStockAlerts(ctx context.Context, thresholdDM2 float64) ([]LowStock, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `
+        UPDATE textures SET low_stock_alerted_at = NULL
+        WHERE low_stock_alerted_at IS NOT NULL
+          AND (stock_dm2 IS NULL OR stock_dm2 >= $1)
+    `, thresholdDM2); err != nil {
+		return nil, fmt.Errorf("failed to rearm low stock alerts: %w", err)
+	}
+
+	const query = `
+        WITH claimed AS (
+            UPDATE textures SET low_stock_alerted_at = NOW()
+            WHERE stock_dm2 < $1 AND low_stock_alerted_at IS NULL
+            RETURNING id, name, stock_dm2
+        )
+        SELECT c.id::text AS texture_id, c.name, c.stock_dm2,
+               b.quantity_dm2 AS last_batch_dm2, b.received_at AS last_batch_at
+        FROM claimed c
+        LEFT JOIN LATERAL (
+            SELECT quantity_dm2, received_at
+            FROM texture_batches
+            WHERE texture_id = c.id
+            ORDER BY received_at DESC, id DESC
+            LIMIT 1
+        ) b ON TRUE
+        ORDER BY c.stock_dm2, c.name
+    `
+
+	var textures []LowStock
+	if err := s.db.SelectContext(ctx, &textures, query, thresholdDM2); err != nil {
+		return nil, fmt.Errorf("failed to claim low stock alerts: %w", err)
+	}
+	return textures, nil
+}
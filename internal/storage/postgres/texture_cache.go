@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/pkg/money"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const textureCacheTTL = 24 * time.Hour
+
+func textureCacheKey(textureID string) string {
+	return fmt.Sprintf("texture:%s", textureID)
+}
+
+// cacheTexture writes the fresh row straight into Redis so readers never
+// see the previous price after an admin change
+func (s *PostgresStorage) cacheTexture(ctx context.Context, texture Texture) {
+	data, err := json.Marshal(texture)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, textureCacheKey(texture.ID), data, textureCacheTTL); err != nil {
+		s.logger.Warn("Failed to write texture cache, dropping key",
+			zap.String("texture_id", texture.ID),
+			zap.Error(err))
+		s.redis.Del(ctx, textureCacheKey(texture.ID))
+	}
+	s.redis.Del(ctx, fmt.Sprintf("texture_details:%s", texture.ID))
+	s.dropMaterialsCache(ctx)
+}
+
+// UpdateTexturePrice changes price_per_dm2 and refreshes the cache in place.
+// An empty currency keeps the one the texture is priced in.
+func (s *PostgresStorage) UpdateTexturePrice(ctx context.Context, textureID string, price float64, currency money.Currency) (*Texture, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if price <= 0 {
+		return nil, fmt.Errorf("%w for texture %s: %.2f", errs.ErrInvalidPrice, textureID, price)
+	}
+
+	const query = `
+        UPDATE textures
+        SET price_per_dm2 = $2,
+            price_currency = COALESCE(NULLIF($3, ''), price_currency),
+            updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + textureColumns + `
+    `
+
+	var texture Texture
+	err := s.db.GetContext(ctx, &texture, query, textureID, price, string(currency))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update texture price: %w", err)
+	}
+
+	s.cacheTexture(ctx, texture)
+	return &texture, nil
+}
+
+// SetTextureInStock toggles availability and refreshes the cache in place
+func (s *PostgresStorage) SetTextureInStock(ctx context.Context, textureID string, inStock bool) (*Texture, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE textures SET in_stock = $2, updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + textureColumns + `
+    `
+
+	var texture Texture
+	err := s.db.GetContext(ctx, &texture, query, textureID, inStock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update texture stock flag: %w", err)
+	}
+
+	s.cacheTexture(ctx, texture)
+	return &texture, nil
+}
+
+// VerifyTextureCache compares a checksum of every cached texture with the
+// database row and force-refreshes entries that drifted (e.g. after a manual
+// UPDATE in psql). Returns the number of refreshed entries.
+func (s *PostgresStorage) VerifyTextureCache(ctx context.Context) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `SELECT ` + textureColumns + ` FROM textures`
+
+	var textures []Texture
+	if err := s.db.SelectContext(ctx, &textures, query); err != nil {
+		return 0, fmt.Errorf("failed to load textures: %w", err)
+	}
+
+	var refreshed int
+	for _, texture := range textures {
+		cached, err := s.redis.Get(ctx, textureCacheKey(texture.ID))
+		if err != nil {
+			// Not cached - nothing can be stale
+			continue
+		}
+
+		fresh, err := json.Marshal(texture)
+		if err != nil {
+			continue
+		}
+
+		if checksum(cached) == checksum(fresh) {
+			continue
+		}
+
+		var stale Texture
+		_ = json.Unmarshal(cached, &stale)
+		s.logger.Warn("Stale texture cache detected",
+			zap.String("texture_id", texture.ID),
+			zap.Float64("cached_price", stale.PricePerDM2),
+			zap.Float64("db_price", texture.PricePerDM2),
+			zap.Bool("cached_in_stock", stale.InStock),
+			zap.Bool("db_in_stock", texture.InStock))
+
+		s.cacheTexture(ctx, texture)
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// WatchTextureCache runs VerifyTextureCache every interval until ctx is done
+func (s *PostgresStorage) WatchTextureCache(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.redis.Degraded() {
+				continue
+			}
+			if _, err := s.VerifyTextureCache(ctx); err != nil {
+				s.logger.Error("Texture cache verification failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func checksum(data []byte) [32]byte {
+	return sha256.Sum256(bytes.TrimSpace(data))
+}
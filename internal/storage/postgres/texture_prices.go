@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+// TexturePrice is a price of a texture valid from ValidFrom until the next
+// change
+type TexturePrice struct {
+	ID            int64          `db:"id"`
+	TextureID     string         `db:"texture_id"`
+	PricePerDM2   float64        `db:"price_per_dm2"`
+	PriceCurrency money.Currency `db:"price_currency"`
+	ValidFrom     time.Time      `db:"valid_from"`
+}
+
+var ErrNoTexturePrice = errors.New("texture had no price at that time")
+
+// GetTexturePriceAt returns the price the texture had at the given moment,
+// e.g. the creation time of an order being audited or of a saved quote
+func (s *PostgresStorage) GetTexturePriceAt(ctx context.Context, textureID string, at time.Time) (TexturePrice, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, texture_id::text, price_per_dm2, price_currency, valid_from
+        FROM texture_price_history
+        WHERE texture_id = $1 AND valid_from <= $2
+        ORDER BY valid_from DESC, id DESC
+        LIMIT 1
+    `
+
+	var price TexturePrice
+	err := s.db.GetContext(ctx, &price, query, textureID, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TexturePrice{}, ErrNoTexturePrice
+	}
+	if err != nil {
+		return TexturePrice{}, fmt.Errorf("failed to get texture price: %w", err)
+	}
+	return price, nil
+}
+
+// TexturePriceHistory lists the price changes of a texture, newest first
+func (s *PostgresStorage) TexturePriceHistory(ctx context.Context, textureID string) ([]TexturePrice, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, texture_id::text, price_per_dm2, price_currency, valid_from
+        FROM texture_price_history
+        WHERE texture_id = $1
+        ORDER BY valid_from DESC, id DESC
+    `
+
+	var prices []TexturePrice
+	if err := s.db.SelectContext(ctx, &prices, query, textureID); err != nil {
+		return nil, fmt.Errorf("failed to get texture price history: %w", err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"s1ntez/internal/storage/errs"
+	"time"
+)
+
+// Telegram accepts at most 10 items in a media group
+const maxGalleryPhotos = 10
+
+type TexturePhoto struct {
+	ID        int64     `db:"id"`
+	TextureID string    `db:"texture_id"`
+	FileID    string    `db:"file_id"`
+	Caption   string    `db:"caption"`
+	Position  int       `db:"position"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// TextureDetails is the "More info" card of a texture
+type TextureDetails struct {
+	Texture
+	Description      string `db:"description"`
+	CareInstructions string `db:"care_instructions"`
+	// StockDM2 is the area left, nil when stock isn't tracked
+	StockDM2   *float64          `db:"stock_dm2"`
+	Attributes TextureAttributes `db:"attributes"`
+	Photos     []TexturePhoto    `db:"-"`
+}
+
+// TextureAttributes are the characteristics of a material by name
+type TextureAttributes map[string]string
+
+func (a TextureAttributes) Value() (driver.Value, error) {
+	return ProductOptions(a).Value()
+}
+
+func (a *TextureAttributes) Scan(src any) error {
+	return (*ProductOptions)(a).Scan(src)
+}
+
+func (s *PostgresStorage) GetTextureDetails(ctx context.Context, textureID string) (*TextureDetails, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	cacheKey := fmt.Sprintf("texture_details:%s", textureID)
+
+	if cached, err := s.redis.Get(ctx, cacheKey); err == nil {
+		var details TextureDetails
+		if err := json.Unmarshal(cached, &details); err == nil {
+			return &details, nil
+		}
+	}
+
+	const query = `
+        SELECT ` + textureColumns + `,
+               description, care_instructions, stock_dm2, attributes
+        FROM textures
+        WHERE id = $1
+    `
+
+	var details TextureDetails
+	err := s.db.GetContext(ctx, &details, query, textureID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get texture details: %w", err)
+	}
+
+	const photosQuery = `
+        SELECT id, texture_id::text, file_id, caption, position, created_at
+        FROM texture_photos
+        WHERE texture_id = $1
+        ORDER BY position, id
+        LIMIT $2
+    `
+
+	if err := s.db.SelectContext(ctx, &details.Photos, photosQuery, textureID, maxGalleryPhotos); err != nil {
+		return nil, fmt.Errorf("failed to get texture photos: %w", err)
+	}
+
+	if data, err := json.Marshal(details); err == nil {
+		s.redis.Set(ctx, cacheKey, data, 24*time.Hour)
+	}
+
+	return &details, nil
+}
+
+// UpdateTextureContent replaces the description and care instructions of a texture
+func (s *PostgresStorage) UpdateTextureContent(ctx context.Context, textureID, description, care string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE textures
+        SET description = $2, care_instructions = $3, updated_at = NOW()
+        WHERE id = $1
+    `
+
+	res, err := s.db.ExecContext(ctx, query, textureID, description, care)
+	if err != nil {
+		return fmt.Errorf("failed to update texture content: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+
+	s.invalidateTextureCache(ctx, textureID)
+	return nil
+}
+
+// UpdateTextureAttributes replaces the characteristics of a texture
+func (s *PostgresStorage) UpdateTextureAttributes(ctx context.Context, textureID string, attributes TextureAttributes) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        UPDATE textures
+        SET attributes = $2, updated_at = NOW()
+        WHERE id = $1
+    `
+
+	res, err := s.db.ExecContext(ctx, query, textureID, attributes)
+	if err != nil {
+		return fmt.Errorf("failed to update texture attributes: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", errs.ErrTextureNotFound, textureID)
+	}
+
+	s.invalidateTextureCache(ctx, textureID)
+	return nil
+}
+
+// AddTexturePhoto appends a Telegram photo to the end of the texture gallery
+func (s *PostgresStorage) AddTexturePhoto(ctx context.Context, textureID, fileID, caption string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO texture_photos (texture_id, file_id, caption, position)
+        SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0)
+        FROM texture_photos
+        WHERE texture_id = $1
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, textureID, fileID, caption); err != nil {
+		return fmt.Errorf("failed to add texture photo: %w", err)
+	}
+
+	s.invalidateTextureCache(ctx, textureID)
+	return nil
+}
+
+// ClearTexturePhotos removes the whole gallery of a texture
+func (s *PostgresStorage) ClearTexturePhotos(ctx context.Context, textureID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM texture_photos WHERE texture_id = $1`, textureID); err != nil {
+		return fmt.Errorf("failed to clear texture photos: %w", err)
+	}
+
+	s.invalidateTextureCache(ctx, textureID)
+	return nil
+}
+
+func (s *PostgresStorage) invalidateTextureCache(ctx context.Context, textureID string) {
+	s.redis.Del(ctx, textureCacheKey(textureID))
+	s.redis.Del(ctx, fmt.Sprintf("texture_details:%s", textureID))
+	s.dropMaterialsCache(ctx)
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TicketStatus string
+
+const (
+	TicketOpen TicketStatus = "open"
+	// TicketAnswered waits for the customer
+	TicketAnswered TicketStatus = "answered"
+	TicketClosed   TicketStatus = "closed"
+)
+
+var (
+	ErrTicketNotFound = errors.New("ticket not found")
+	ErrTicketExists   = errors.New("user already has an open ticket")
+)
+
+// Ticket is a support conversation. The staff side of it lives in a forum
+// topic of the support group.
+type Ticket struct {
+	ID              int64        `db:"id"`
+	UserID          int64        `db:"user_id"`
+	Subject         string       `db:"subject"`
+	Status          TicketStatus `db:"status"`
+	TopicID         *int         `db:"topic_id"`
+	CreatedAt       time.Time    `db:"created_at"`
+	UpdatedAt       time.Time    `db:"updated_at"`
+	SLADueAt        time.Time    `db:"sla_due_at"`
+	SLAAlertedAt    *time.Time   `db:"sla_alerted_at"`
+	FirstResponseAt *time.Time   `db:"first_response_at"`
+	ClosedAt        *time.Time   `db:"closed_at"`
+	ClosedBy        *int64       `db:"closed_by"`
+}
+
+type TicketMessage struct {
+	ID             int64            `db:"id"`
+	TicketID       int64            `db:"ticket_id"`
+	Direction      MessageDirection `db:"direction"`
+	AuthorID       int64            `db:"author_id"`
+	Text           string           `db:"text"`
+	UserMessageID  int              `db:"user_message_id"`
+	GroupMessageID int              `db:"group_message_id"`
+	CreatedAt      time.Time        `db:"created_at"`
+}
+
+const ticketColumns = `
+        id, user_id, subject, status, topic_id, created_at, updated_at,
+        sla_due_at, sla_alerted_at, first_response_at, closed_at, closed_by
+`
+
+func (s *PostgresStorage) CreateTicket(ctx context.Context, userID int64, subject string, slaDueAt time.Time) (*Ticket, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        INSERT INTO tickets (user_id, subject, sla_due_at)
+        VALUES ($1, $2, $3)
+        RETURNING ` + ticketColumns
+
+	var ticket Ticket
+	if err := s.db.GetContext(ctx, &ticket, query, userID, subject, slaDueAt); err != nil {
+		if isUniqueViolation(err, "idx_tickets_user_active") {
+			return nil, ErrTicketExists
+		}
+		return nil, fmt.Errorf("failed to create ticket: %w", err)
+	}
+	return &ticket, nil
+}
+
+func (s *PostgresStorage) SetTicketTopic(ctx context.Context, ticketID int64, topicID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE tickets SET topic_id = $2, updated_at = NOW() WHERE id = $1`, ticketID, topicID); err != nil {
+		return fmt.Errorf("failed to set ticket topic: %w", err)
+	}
+	return nil
+}
+
+// GetActiveTicket returns the user's ticket that is not closed yet
+func (s *PostgresStorage) GetActiveTicket(ctx context.Context, userID int64) (*Ticket, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getTicket(ctx, `WHERE user_id = $1 AND status <> 'closed'`, userID)
+}
+
+func (s *PostgresStorage) GetTicket(ctx context.Context, ticketID int64) (*Ticket, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getTicket(ctx, `WHERE id = $1`, ticketID)
+}
+
+// GetTicketByGroupMessage finds the ticket a staff message refers to: either
+// the topic itself or a relayed customer message that staff replied to
+func (s *PostgresStorage) GetTicketByGroupMessage(ctx context.Context, messageID int) (*Ticket, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getTicket(ctx, `
+        WHERE topic_id = $1
+           OR id = (SELECT ticket_id FROM ticket_messages WHERE group_message_id = $1 LIMIT 1)
+        ORDER BY id DESC
+        LIMIT 1`, messageID)
+}
+
+func (s *PostgresStorage) getTicket(ctx context.Context, where string, args ...any) (*Ticket, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, `SELECT `+ticketColumns+` FROM tickets `+where, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return &ticket, nil
+}
+
+// AddTicketMessage stores a relayed message and moves the ticket to the side
+// that has to answer next. The first staff message stamps first_response_at.
+func (s *PostgresStorage) AddTicketMessage(ctx context.Context, msg TicketMessage) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO ticket_messages (ticket_id, direction, author_id, text, user_message_id, group_message_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, msg.TicketID, msg.Direction, msg.AuthorID, msg.Text, msg.UserMessageID, msg.GroupMessageID); err != nil {
+		return fmt.Errorf("failed to save ticket message: %w", err)
+	}
+
+	status := TicketOpen
+	if msg.Direction == DirectionOut {
+		status = TicketAnswered
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE tickets
+        SET status = $2,
+            updated_at = NOW(),
+            first_response_at = CASE WHEN $2 = 'answered' THEN COALESCE(first_response_at, NOW()) ELSE first_response_at END
+        WHERE id = $1 AND status <> 'closed'
+    `, msg.TicketID, status); err != nil {
+		return fmt.Errorf("failed to update ticket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ticket message: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) CloseTicket(ctx context.Context, ticketID, closedBy int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+        UPDATE tickets
+        SET status = 'closed', closed_at = NOW(), closed_by = $2, updated_at = NOW()
+        WHERE id = $1 AND status <> 'closed'
+    `, ticketID, closedBy)
+	if err != nil {
+		return fmt.Errorf("failed to close ticket: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTicketNotFound
+	}
+	return nil
+}
+
+// ClaimOverdueTickets returns tickets past their first-response deadline
+// that staff were not reminded about yet, and marks them as reminded
+func (s *PostgresStorage) ClaimOverdueTickets(ctx context.Context) ([]Ticket, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+        UPDATE tickets SET sla_alerted_at = NOW()
+        WHERE first_response_at IS NULL
+          AND status <> 'closed'
+          AND sla_alerted_at IS NULL
+          AND sla_due_at < NOW()
+        RETURNING ` + ticketColumns
+
+	var tickets []Ticket
+	if err := s.db.SelectContext(ctx, &tickets, query); err != nil {
+		return nil, fmt.Errorf("failed to claim overdue tickets: %w", err)
+	}
+	return tickets, nil
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+)
+
+// withTimeout bounds one storage operation by the configured query timeout.
+// An earlier deadline of the caller still wins.
+func (s *PostgresStorage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// detach runs a long operation (export, import) on its own deadline. The
+// caller's cancellation is ignored, so a customer leaving a dialog or an
+// update timing out doesn't abort a spreadsheet half written; tracing
+// values are kept.
+func (s *PostgresStorage) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if s.exportTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.exportTimeout)
+}
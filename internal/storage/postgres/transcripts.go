@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MessageDirection string
+
+const (
+	DirectionIn  MessageDirection = "in"
+	DirectionOut MessageDirection = "out"
+)
+
+// DialogMessage is one line of the order conversation kept for disputes
+type DialogMessage struct {
+	ID        int64            `db:"id"`
+	UserID    int64            `db:"user_id"`
+	OrderID   *int64           `db:"order_id"`
+	Direction MessageDirection `db:"direction"`
+	Step      string           `db:"step"`
+	Text      string           `db:"text"`
+	CreatedAt time.Time        `db:"created_at"`
+}
+
+// RecordDialogMessage stores a user input or bot confirmation made while an
+// order is being drafted. It is linked to the order once the order is saved.
+func (s *PostgresStorage) RecordDialogMessage(ctx context.Context, userID int64, direction MessageDirection, step, text string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO dialog_messages (user_id, direction, step, text)
+        VALUES ($1, $2, $3, $4)
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, direction, step, text); err != nil {
+		return fmt.Errorf("failed to record dialog message: %w", err)
+	}
+	return nil
+}
+
+// linkDialogMessages attaches the user's pending draft conversation to an order
+func linkDialogMessages(ctx context.Context, db sqlx.ExecerContext, userID, orderID int64) error {
+	const query = `
+        UPDATE dialog_messages SET order_id = $2
+        WHERE user_id = $1 AND order_id IS NULL
+    `
+
+	if _, err := db.ExecContext(ctx, query, userID, orderID); err != nil {
+		return fmt.Errorf("failed to link dialog messages: %w", err)
+	}
+	return nil
+}
+
+// DiscardDialogMessages drops a draft conversation that never became an order
+func (s *PostgresStorage) DiscardDialogMessages(ctx context.Context, userID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM dialog_messages WHERE user_id = $1 AND order_id IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to discard dialog messages: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) GetOrderTranscript(ctx context.Context, orderID int64) ([]DialogMessage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        SELECT id, user_id, order_id, direction, step, text, created_at
+        FROM dialog_messages
+        WHERE order_id = $1
+        ORDER BY id
+    `
+
+	var messages []DialogMessage
+	if err := s.db.SelectContext(ctx, &messages, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order transcript: %w", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UserProfile is the users row of a customer
+type UserProfile struct {
+	UserID               int64      `db:"user_id" json:"user_id"`
+	AgreedToTerms        bool       `db:"agreed_to_tpa" json:"agreed_to_terms"`
+	AgreedAt             *time.Time `db:"agreed_at" json:"agreed_at"`
+	Phone                *string    `db:"phone_number" json:"phone"`
+	PhoneVerifiedAt      *time.Time `db:"phone_verified_at" json:"phone_verified_at"`
+	Locale               string     `db:"locale" json:"locale"`
+	NotificationsEnabled bool       `db:"notifications_enabled" json:"notifications_enabled"`
+	ReferralCode         *string    `db:"referral_code" json:"referral_code"`
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// UserData is everything stored in Postgres about one user, for the
+// data subject access request (/mydata). Orders include soft-deleted and
+// archived ones: they are still kept.
+type UserData struct {
+	Profile        *UserProfile    `json:"profile"`
+	Preferences    *Preferences    `json:"preferences"`
+	Orders         []Order         `json:"orders"`
+	Attachments    []Attachment    `json:"attachments"`
+	Tickets        []Ticket        `json:"support_tickets"`
+	TicketMessages []TicketMessage `json:"support_messages"`
+	Reviews        []Review        `json:"reviews"`
+	ReferredBy     *int64          `json:"referred_by"`
+}
+
+// GetUserData collects the stored data of a user from the primary, so a
+// change made just before the request is included
+func (s *PostgresStorage) GetUserData(ctx context.Context, userID int64) (*UserData, error) {
+	ctx, cancel := s.detach(ctx)
+	defer cancel()
+
+	data := &UserData{}
+
+	var profile UserProfile
+	err := s.db.GetContext(ctx, &profile, `
+        SELECT user_id, agreed_to_tpa, agreed_at, phone_number, phone_verified_at, locale,
+               notifications_enabled, referral_code, created_at, updated_at
+        FROM users
+        WHERE user_id = $1
+    `, userID)
+	switch {
+	case err == nil:
+		data.Profile = &profile
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	var prefs Preferences
+	err = s.db.GetContext(ctx, &prefs, `
+        SELECT user_id, delivery_method, delivery_address, favorite_textures, updated_at
+        FROM user_preferences
+        WHERE user_id = $1
+    `, userID)
+	switch {
+	case err == nil:
+		data.Preferences = &prefs
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &data.Orders,
+		`SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user orders: %w", err)
+	}
+	if err := attachItems(ctx, s.db, data.Orders); err != nil {
+		return nil, err
+	}
+	archived, err := getArchivedOrders(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	data.Orders = append(data.Orders, archived...)
+
+	if err := s.db.SelectContext(ctx, &data.Attachments, `
+        SELECT id, user_id, order_id, tg_file_id, tg_unique_id, object_key,
+               content_type, size_bytes, sha256, transcript, created_at
+        FROM attachments
+        WHERE user_id = $1
+        ORDER BY id
+    `, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user attachments: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &data.Tickets,
+		`SELECT `+ticketColumns+` FROM tickets WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user tickets: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &data.TicketMessages, `
+        SELECT m.id, m.ticket_id, m.direction, m.author_id, m.text,
+               m.user_message_id, m.group_message_id, m.created_at
+        FROM ticket_messages m
+        JOIN tickets t ON t.id = m.ticket_id
+        WHERE t.user_id = $1
+        ORDER BY m.id
+    `, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user ticket messages: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &data.Reviews,
+		`SELECT `+reviewColumns+` FROM reviews WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user reviews: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT referrer_id FROM referrals WHERE referee_id = $1`, userID).Scan(&data.ReferredBy)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user referral: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/units"
+)
+
+const localeCacheTTL = 24 * time.Hour
+
+// GetUserLocale returns the stored interface language of a user,
+// falling back to the default locale for unknown users.
+func (s *PostgresStorage) GetUserLocale(ctx context.Context, userID int64) (i18n.Locale, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	cacheKey := fmt.Sprintf("user_locale:%d", userID)
+
+	// Locale is read on every message, so keep it in Redis
+	if cached, err := s.redis.Get(ctx, cacheKey); err == nil {
+		return i18n.Parse(string(cached)), nil
+	}
+
+	var locale string
+	err := s.db.QueryRowContext(ctx, `SELECT locale FROM users WHERE user_id = $1`, userID).Scan(&locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return i18n.Default, nil
+	}
+	if err != nil {
+		return i18n.Default, fmt.Errorf("failed to get user locale: %w", err)
+	}
+
+	s.redis.Set(ctx, cacheKey, []byte(locale), localeCacheTTL)
+
+	return i18n.Parse(locale), nil
+}
+
+// SetUserLocale stores the interface language chosen by the user
+func (s *PostgresStorage) SetUserLocale(ctx context.Context, userID int64, locale i18n.Locale) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, locale)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET locale = $2, updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, string(locale)); err != nil {
+		return fmt.Errorf("failed to set user locale: %w", err)
+	}
+
+	s.redis.Del(ctx, fmt.Sprintf("user_locale:%d", userID))
+
+	return nil
+}
+
+// GetUserUnit returns the unit the user sees sizes in, DEFAULT_UNIT until
+// they pick one
+func (s *PostgresStorage) GetUserUnit(ctx context.Context, userID int64) (units.Unit, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var unit sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT unit FROM users WHERE user_id = $1`, userID).Scan(&unit)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !unit.Valid) {
+		return s.unit, nil
+	}
+	if err != nil {
+		return s.unit, fmt.Errorf("failed to get user unit: %w", err)
+	}
+	return units.Unit(unit.String), nil
+}
+
+// SetUserUnit stores the unit chosen by the user
+func (s *PostgresStorage) SetUserUnit(ctx context.Context, userID int64, unit units.Unit) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, unit)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET unit = $2, updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, string(unit)); err != nil {
+		return fmt.Errorf("failed to set user unit: %w", err)
+	}
+	return nil
+}
+
+// NotificationsEnabled reports whether the user wants order status messages.
+// Users without a row are opted in.
+func (s *PostgresStorage) NotificationsEnabled(ctx context.Context, userID int64) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var enabled bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT notifications_enabled FROM users WHERE user_id = $1`, userID).Scan(&enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	return enabled, nil
+}
+
+func (s *PostgresStorage) SetNotificationsEnabled(ctx context.Context, userID int64, enabled bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, notifications_enabled)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET notifications_enabled = $2, updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, enabled); err != nil {
+		return fmt.Errorf("failed to update notification settings: %w", err)
+	}
+	return nil
+}
+
+// MarkBotBlocked flags the user as unreachable: Telegram answered that
+// they blocked the bot. Group chats, whose IDs are negative, are ignored.
+func (s *PostgresStorage) MarkBotBlocked(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, bot_blocked_at)
+        VALUES ($1, NOW())
+        ON CONFLICT (user_id)
+        DO UPDATE SET bot_blocked_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to mark user as blocked: %w", err)
+	}
+	return nil
+}
+
+// GetUserPhone returns the stored phone of a user and whether it has been
+// verified; "" when there is none
+func (s *PostgresStorage) GetUserPhone(ctx context.Context, userID int64) (string, bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var number sql.NullString
+	var verified bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT phone_number, phone_verified_at IS NOT NULL FROM users WHERE user_id = $1`, userID).
+		Scan(&number, &verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get user phone: %w", err)
+	}
+	return number.String, verified, nil
+}
+
+// SaveUserPhone stores a phone in E.164. verified marks it as confirmed;
+// an unverified number that didn't change keeps its earlier verification.
+func (s *PostgresStorage) SaveUserPhone(ctx context.Context, userID int64, number string, verified bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const query = `
+        INSERT INTO users (user_id, phone_number, phone_verified_at)
+        VALUES ($1, $2, CASE WHEN $3 THEN NOW() END)
+        ON CONFLICT (user_id)
+        DO UPDATE SET phone_verified_at = CASE
+                          WHEN $3 THEN NOW()
+                          WHEN users.phone_number = $2 THEN users.phone_verified_at
+                      END,
+                      phone_number = $2,
+                      updated_at = NOW()
+    `
+
+	if _, err := s.db.ExecContext(ctx, query, userID, number, verified); err != nil {
+		return fmt.Errorf("failed to save user phone: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Captcha is a question a first-time user has to answer
+type Captcha struct {
+	Answer   int `json:"answer"`
+	Attempts int `json:"attempts"`
+}
+
+func buildFloodKey(userID int64) string {
+	return fmt.Sprintf("flood:%d", userID)
+}
+
+func buildStrikesKey(userID int64) string {
+	return fmt.Sprintf("strikes:%d", userID)
+}
+
+func buildCaptchaKey(userID int64) string {
+	return fmt.Sprintf("captcha:%d", userID)
+}
+
+// CountUpdate counts an update of the user within window. over is true
+// for every update beyond limit; strikes is set only the first time in a
+// window, to how many windows the user went over within strikeWindow.
+func (s *Storage) CountUpdate(ctx context.Context, userID int64, limit int64, window, strikeWindow time.Duration) (over bool, strikes int64, err error) {
+	count, err := s.client.Incr(ctx, buildFloodKey(userID))
+	if err != nil {
+		return false, 0, fmt.Errorf("count update: %w", err)
+	}
+	if count == 1 {
+		if _, err := s.client.Expire(ctx, buildFloodKey(userID), window); err != nil {
+			return false, 0, fmt.Errorf("set flood window: %w", err)
+		}
+	}
+	if count <= limit {
+		return false, 0, nil
+	}
+	if count > limit+1 {
+		return true, 0, nil
+	}
+
+	strikes, err = s.client.Incr(ctx, buildStrikesKey(userID))
+	if err != nil {
+		return true, 0, fmt.Errorf("count strike: %w", err)
+	}
+	if strikes == 1 {
+		if _, err := s.client.Expire(ctx, buildStrikesKey(userID), strikeWindow); err != nil {
+			return true, strikes, fmt.Errorf("set strike window: %w", err)
+		}
+	}
+	return true, strikes, nil
+}
+
+// ResetStrikes starts the count over, once a ban was given for them
+func (s *Storage) ResetStrikes(ctx context.Context, userID int64) error {
+	return s.client.Del(ctx, buildStrikesKey(userID), buildFloodKey(userID))
+}
+
+func (s *Storage) SetCaptcha(ctx context.Context, userID int64, captcha Captcha, ttl time.Duration) error {
+	data, err := json.Marshal(captcha)
+	if err != nil {
+		return fmt.Errorf("marshal captcha: %w", err)
+	}
+	return s.client.Set(ctx, buildCaptchaKey(userID), data, ttl)
+}
+
+// GetCaptcha returns the pending question, nil when there is none or it
+// expired
+func (s *Storage) GetCaptcha(ctx context.Context, userID int64) (*Captcha, error) {
+	data, err := s.client.Get(ctx, buildCaptchaKey(userID))
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get captcha: %w", err)
+	}
+
+	var captcha Captcha
+	if err := json.Unmarshal(data, &captcha); err != nil {
+		return nil, fmt.Errorf("unmarshal captcha: %w", err)
+	}
+	return &captcha, nil
+}
+
+func (s *Storage) DropCaptcha(ctx context.Context, userID int64) error {
+	return s.client.Del(ctx, buildCaptchaKey(userID))
+}
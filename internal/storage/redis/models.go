@@ -1,5 +1,10 @@
 package redis
 
+import (
+	"s1ntez/pkg/money"
+	"time"
+)
+
 type UserState struct {
 	Step     string    `json:"step"`
 	Userdata *UserData `json:"user_data,omitempty"`
@@ -13,12 +18,44 @@ type Order struct {
 	Typography *Typography `json:"typography,omitempty"`
 	Sticker    *Stickers   `json:"sticker,omitempty"`
 
+	// срочный заказ с наценкой, переключается кнопкой на итоге заказа
+	Rush *bool `json:"rush,omitempty"`
+	// промокод, проверяется при каждом расчёте
+	PromoCode *string `json:"promo_code,omitempty"`
+	// код подарочной карты, оплачивает заказ в пределах остатка
+	GiftCard *string `json:"gift_card,omitempty"`
+	// голосовые клиента по ходу диалога (attachments.id), уходят мастеру
+	// вместе с заказом
+	VoiceNotes []int64 `json:"voice_notes,omitempty"`
+
 	Price    *string   `json:"price,omitempty"`
 	Delivery *Delivery `json:"delivery,omitempty"`
+
+	// повтор прошлого заказа: его номер, код для клиента и цена без
+	// промокода для сравнения
+	RepeatOf    *int64        `json:"repeat_of,omitempty"`
+	RepeatCode  *string       `json:"repeat_code,omitempty"`
+	RepeatPrice *money.Amount `json:"repeat_price,omitempty"`
+
+	// правка поля из сводки: после ответа вернуться к сводке
+	Editing *bool `json:"editing,omitempty"`
+
+	// заказ из сохранённого расчёта: его номер и момент, по ценам
+	// которого считать, пока расчёт действует
+	SavedQuote *int64     `json:"saved_quote,omitempty"`
+	PricedAt   *time.Time `json:"priced_at,omitempty"`
+
+	// цена последней показанной сводки (price_quotes.id): заказ
+	// оформляется по ней, пока она действует
+	QuoteID *int64 `json:"quote_id,omitempty"`
 }
 
 type Delivery struct {
 	Date *string `json:"date,omitempty"`
+	// самовывоз, курьер или почта
+	Method *string `json:"method,omitempty"`
+	// адрес для курьера и почты
+	Address *string `json:"address,omitempty"`
 }
 
 type UserData struct {
@@ -31,10 +68,34 @@ type Leather struct {
 	// размер
 	WidthCM  *int `json:"width_cm,omitempty"`
 	HeightCM *int `json:"height_cm,omitempty"`
+	// фото изделия, которое обтянуть (attachments.id), альбомом до 10 штук
+	PhotoIDs []int64 `json:"photo_ids,omitempty"`
 }
 
 type Typography struct {
+	// визитки, листовки, баннер
+	Product *string `json:"product,omitempty"`
+	Format  *string `json:"format,omitempty"`
+	// размер баннера, если формат свой
+	WidthCM  *int `json:"width_cm,omitempty"`
+	HeightCM *int `json:"height_cm,omitempty"`
+	// бумага
+	MaterialID *string `json:"material_id,omitempty"`
+	Sides      *int    `json:"sides,omitempty"`
+	Quantity   *int    `json:"quantity,omitempty"`
+	// загруженный файл для печати (attachments.id)
+	LayoutID *int64 `json:"layout_id,omitempty"`
 }
 
 type Stickers struct {
+	// виниловая плёнка
+	MaterialID *string `json:"material_id,omitempty"`
+	// размер одной наклейки
+	WidthCM  *int `json:"width_cm,omitempty"`
+	HeightCM *int `json:"height_cm,omitempty"`
+	// тираж
+	Quantity   *int    `json:"quantity,omitempty"`
+	Lamination *string `json:"lamination,omitempty"`
+	// загруженный макет (attachments.id)
+	PreviewID *int64 `json:"preview_id,omitempty"`
 }
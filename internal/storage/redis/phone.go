@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PhoneCode is a verification code waiting to be entered. Only the hash of
+// the code is kept.
+type PhoneCode struct {
+	Phone    string `json:"phone"`
+	CodeHash string `json:"code_hash"`
+	Attempts int    `json:"attempts"`
+}
+
+func buildPhoneCodeKey(userID int64) string {
+	return fmt.Sprintf("phone_code:%d", userID)
+}
+
+func (s *Storage) SetPhoneCode(ctx context.Context, userID int64, code PhoneCode, ttl time.Duration) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("marshal phone code: %w", err)
+	}
+	return s.client.Set(ctx, buildPhoneCodeKey(userID), data, ttl)
+}
+
+// GetPhoneCode returns the pending code, nil when there is none or it expired
+func (s *Storage) GetPhoneCode(ctx context.Context, userID int64) (*PhoneCode, error) {
+	data, err := s.client.Get(ctx, buildPhoneCodeKey(userID))
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get phone code: %w", err)
+	}
+
+	var code PhoneCode
+	if err := json.Unmarshal(data, &code); err != nil {
+		return nil, fmt.Errorf("unmarshal phone code: %w", err)
+	}
+	return &code, nil
+}
+
+// UpdatePhoneCode saves a changed attempt count without extending the code's life
+func (s *Storage) UpdatePhoneCode(ctx context.Context, userID int64, code PhoneCode) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("marshal phone code: %w", err)
+	}
+	// XX: a code that expired meanwhile stays gone
+	err = s.client.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.SetArgs(ctx, buildPhoneCodeKey(userID), data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err()
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("update phone code: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DropPhoneCode(ctx context.Context, userID int64) error {
+	return s.client.Del(ctx, buildPhoneCodeKey(userID))
+}
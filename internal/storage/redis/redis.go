@@ -5,32 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	redisclient "s1ntez/pkg/redis"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 const stateTTL = 24 * time.Hour
 
+// Storage keeps dialog state and counters. Calls go through the shared
+// client, so they fail fast with redisclient.ErrUnavailable while Redis is
+// down.
 type Storage struct {
-	client *redis.Client
+	client *redisclient.Client
 }
 
-// New creates a new Redis client
-func New(addr, password string, db int) *Storage {
+func New(client *redisclient.Client) *Storage {
 	return &Storage{
-		client: redis.NewClient(&redis.Options{
-			Addr:         addr,
-			Password:     password,
-			DB:           db,
-			PoolSize:     100, // Increase connection pool size
-			MinIdleConns: 10,  // Keep minimum connections ready
-		}),
-	}
-}
-
-// Close closes the Redis connection
-func (s *Storage) Close() {
-	if s.client != nil {
-		_ = s.client.Close()
+		client: client,
 	}
 }
 
@@ -40,11 +32,11 @@ func (s *Storage) SetUserDialogState(ctx context.Context, chatId int64, state *U
 		return fmt.Errorf("marshal state: %w", err)
 	}
 
-	return s.client.Set(ctx, buildStateKey(chatId), data, stateTTL).Err()
+	return s.client.Set(ctx, buildStateKey(chatId), data, stateTTL)
 }
 
 func (s *Storage) GetUserDialogState(ctx context.Context, chatID int64) (*UserState, error) {
-	data, err := s.client.Get(ctx, buildStateKey(chatID)).Bytes()
+	data, err := s.client.Get(ctx, buildStateKey(chatID))
 	if errors.Is(err, redis.Nil) {
 		return &UserState{}, nil
 	}
@@ -60,7 +52,24 @@ func (s *Storage) GetUserDialogState(ctx context.Context, chatID int64) (*UserSt
 }
 
 func (s *Storage) DropUserDialogState(ctx context.Context, chatID int64) error {
-	return s.client.Del(ctx, buildStateKey(chatID)).Err()
+	return s.client.Del(ctx, buildStateKey(chatID))
+}
+
+// ClaimUpdate marks a Telegram update as taken. It returns false when the
+// update was already claimed, i.e. Telegram delivered it again.
+func (s *Storage) ClaimUpdate(ctx context.Context, updateID int, ttl time.Duration) (bool, error) {
+	// Not retried: if the reply got lost, a retry would find the key taken
+	// and drop the update
+	var claimed bool
+	err := s.client.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		var err error
+		claimed, err = rdb.SetNX(ctx, fmt.Sprintf("update:%d", updateID), 1, ttl).Result()
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("claim update: %w", err)
+	}
+	return claimed, nil
 }
 
 func buildStateKey(chatId int64) string {
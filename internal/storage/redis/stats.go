@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	statsTotalsKey   = "stats:orders"
+	statsStatusesKey = "stats:statuses"
+	statsDayPrefix   = "stats:day:"
+	statsDayFormat   = "2006-01-02"
+	// day buckets outlive the longest window the statistics show
+	statsDayTTL = 32 * 24 * time.Hour
+)
+
+// OrderTally is what a single order adds to the statistics
+type OrderTally struct {
+	CreatedAt     time.Time
+	Status        string
+	Price         float64
+	Rush          bool
+	RushSurcharge float64
+}
+
+// DayTotals are the orders created on one day
+type DayTotals struct {
+	Orders  int64
+	Revenue float64
+}
+
+// OrderStats are the live order counters. Days is keyed by date
+// (YYYY-MM-DD); days without orders may be missing.
+type OrderStats struct {
+	Orders        int64
+	Revenue       float64
+	RushOrders    int64
+	RushRevenue   float64
+	RushSurcharge float64
+	Statuses      map[string]int64
+	Days          map[string]DayTotals
+}
+
+// DayKey formats the date of t the way day buckets are named
+func DayKey(t time.Time) string {
+	return t.Format(statsDayFormat)
+}
+
+// CountOrder adds a new order to the counters in one transaction. It is
+// not retried: a lost reply would count the order twice.
+func (s *Storage) CountOrder(ctx context.Context, t OrderTally) error {
+	dayKey := statsDayPrefix + DayKey(t.CreatedAt)
+
+	err := s.client.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HIncrBy(ctx, statsTotalsKey, "orders", 1)
+			pipe.HIncrByFloat(ctx, statsTotalsKey, "revenue", t.Price)
+			if t.Rush {
+				pipe.HIncrBy(ctx, statsTotalsKey, "rush_orders", 1)
+				pipe.HIncrByFloat(ctx, statsTotalsKey, "rush_revenue", t.Price)
+				pipe.HIncrByFloat(ctx, statsTotalsKey, "rush_surcharge", t.RushSurcharge)
+			}
+			pipe.HIncrBy(ctx, statsStatusesKey, t.Status, 1)
+			pipe.HIncrBy(ctx, dayKey, "orders", 1)
+			pipe.HIncrByFloat(ctx, dayKey, "revenue", t.Price)
+			pipe.Expire(ctx, dayKey, statsDayTTL)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("count order: %w", err)
+	}
+	return nil
+}
+
+// MoveOrderStatus moves one order from one status counter to another
+func (s *Storage) MoveOrderStatus(ctx context.Context, from, to string) error {
+	err := s.client.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HIncrBy(ctx, statsStatusesKey, from, -1)
+			pipe.HIncrBy(ctx, statsStatusesKey, to, 1)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("move order status: %w", err)
+	}
+	return nil
+}
+
+// GetOrderStats reads the counters with the given day buckets. ok is false
+// when the counters were never filled, e.g. after Redis lost its data.
+func (s *Storage) GetOrderStats(ctx context.Context, days []time.Time) (stats *OrderStats, ok bool, err error) {
+	var (
+		totalsCmd   *redis.MapStringStringCmd
+		statusesCmd *redis.MapStringStringCmd
+		dayCmds     map[string]*redis.MapStringStringCmd
+	)
+	err = s.client.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		pipe := rdb.Pipeline()
+		totalsCmd = pipe.HGetAll(ctx, statsTotalsKey)
+		statusesCmd = pipe.HGetAll(ctx, statsStatusesKey)
+		dayCmds = make(map[string]*redis.MapStringStringCmd, len(days))
+		for _, day := range days {
+			dayCmds[DayKey(day)] = pipe.HGetAll(ctx, statsDayPrefix+DayKey(day))
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, fmt.Errorf("get order stats: %w", err)
+	}
+
+	totals := totalsCmd.Val()
+	if len(totals) == 0 {
+		return nil, false, nil
+	}
+
+	stats = &OrderStats{
+		Orders:        parseInt(totals["orders"]),
+		Revenue:       parseFloat(totals["revenue"]),
+		RushOrders:    parseInt(totals["rush_orders"]),
+		RushRevenue:   parseFloat(totals["rush_revenue"]),
+		RushSurcharge: parseFloat(totals["rush_surcharge"]),
+		Statuses:      make(map[string]int64),
+		Days:          make(map[string]DayTotals, len(days)),
+	}
+	for status, count := range statusesCmd.Val() {
+		stats.Statuses[status] = parseInt(count)
+	}
+	for key, cmd := range dayCmds {
+		fields := cmd.Val()
+		stats.Days[key] = DayTotals{
+			Orders:  parseInt(fields["orders"]),
+			Revenue: parseFloat(fields["revenue"]),
+		}
+	}
+	return stats, true, nil
+}
+
+// ReplaceOrderStats overwrites the counters with freshly computed ones
+func (s *Storage) ReplaceOrderStats(ctx context.Context, stats OrderStats) error {
+	err := s.client.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, statsTotalsKey, statsStatusesKey)
+			pipe.HSet(ctx, statsTotalsKey,
+				"orders", stats.Orders,
+				"revenue", stats.Revenue,
+				"rush_orders", stats.RushOrders,
+				"rush_revenue", stats.RushRevenue,
+				"rush_surcharge", stats.RushSurcharge,
+			)
+			for status, count := range stats.Statuses {
+				pipe.HSet(ctx, statsStatusesKey, status, count)
+			}
+			for day, totals := range stats.Days {
+				key := statsDayPrefix + day
+				pipe.HSet(ctx, key, "orders", totals.Orders, "revenue", totals.Revenue)
+				pipe.Expire(ctx, key, statsDayTTL)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("replace order stats: %w", err)
+	}
+	return nil
+}
+
+func parseInt(raw string) int64 {
+	n, _ := strconv.ParseInt(raw, 10, 64)
+	return n
+}
+
+func parseFloat(raw string) float64 {
+	f, _ := strconv.ParseFloat(raw, 64)
+	return f
+}
@@ -0,0 +1,171 @@
+package support
+
+import (
+	"context"
+	"errors"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler wires the service to Telegram:
+//
+//	/support [subject]  — customer opens a ticket
+//	/support close      — customer closes it
+//	/closeticket        — staff closes the ticket of the current topic
+//
+// Plain messages are relayed both ways while a ticket is active.
+type Handler struct {
+	service *Service
+	redis   *redis.Storage
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, redis *redis.Storage, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		redis:   redis,
+		logger:  logger.Named("support"),
+	}
+}
+
+func (h *Handler) Handle(ctx context.Context, update tgbotapi.Update) error {
+	msg := update.Message
+	if msg.Command() == "closeticket" {
+		return h.closeFromGroup(ctx, msg)
+	}
+	if !msg.Chat.IsPrivate() {
+		return nil
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+
+	if !h.service.Enabled() {
+		return h.send(msg.Chat.ID, i18n.T(locale, "support.unavailable"))
+	}
+
+	subject := strings.TrimSpace(msg.CommandArguments())
+	if strings.EqualFold(subject, "close") {
+		ticket, err := h.service.storage.GetActiveTicket(ctx, msg.From.ID)
+		if errors.Is(err, postgres.ErrTicketNotFound) {
+			return h.send(msg.Chat.ID, i18n.T(locale, "support.none"))
+		}
+		if err != nil {
+			return err
+		}
+		if err := h.service.Close(ctx, ticket, msg.From.ID); err != nil {
+			return err
+		}
+		return h.send(msg.Chat.ID, i18n.T(locale, "support.closed", ticket.ID))
+	}
+
+	ticket, err := h.service.Open(ctx, msg, subject)
+	if errors.Is(err, postgres.ErrTicketExists) {
+		return h.send(msg.Chat.ID, i18n.T(locale, "support.already_open"))
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.send(msg.Chat.ID, i18n.T(locale, "support.opened", ticket.ID))
+}
+
+// HandleMessage relays non-command messages that belong to a ticket
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if !h.service.Enabled() || msg.From == nil || msg.From.IsBot {
+		return false, nil
+	}
+
+	if msg.Chat.ID == h.service.cfg.Support.GroupID {
+		return h.relayFromGroup(ctx, msg)
+	}
+	if !msg.Chat.IsPrivate() {
+		return false, nil
+	}
+
+	// Messages typed during an order dialog belong to the dialog
+	state, err := h.redis.GetUserDialogState(ctx, msg.Chat.ID)
+	if err != nil {
+		return false, err
+	}
+	if state.Step != "" {
+		return false, nil
+	}
+
+	ticket, err := h.service.storage.GetActiveTicket(ctx, msg.From.ID)
+	if errors.Is(err, postgres.ErrTicketNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, h.service.RelayFromCustomer(ctx, ticket, msg)
+}
+
+func (h *Handler) relayFromGroup(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	ticket, err := h.ticketOf(ctx, msg)
+	if errors.Is(err, postgres.ErrTicketNotFound) {
+		// Staff chatting among themselves
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	if ticket.Status == postgres.TicketClosed {
+		return true, nil
+	}
+
+	return true, h.service.RelayFromStaff(ctx, ticket, msg)
+}
+
+func (h *Handler) closeFromGroup(ctx context.Context, msg *tgbotapi.Message) error {
+	if !h.service.Enabled() || msg.Chat.ID != h.service.cfg.Support.GroupID {
+		return nil
+	}
+
+	ticket, err := h.ticketOf(ctx, msg)
+	if errors.Is(err, postgres.ErrTicketNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Close(ctx, ticket, msg.From.ID); err != nil {
+		if errors.Is(err, postgres.ErrTicketNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, ticket.UserID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	return h.send(ticket.UserID, i18n.T(locale, "support.closed_by_staff", ticket.ID))
+}
+
+// ticketOf resolves the ticket a group message belongs to. Inside a forum
+// topic Telegram sets reply_to_message to the topic's service message, whose
+// ID is the thread ID; otherwise staff reply to a relayed customer message.
+func (h *Handler) ticketOf(ctx context.Context, msg *tgbotapi.Message) (*postgres.Ticket, error) {
+	if msg.ReplyToMessage == nil {
+		return nil, postgres.ErrTicketNotFound
+	}
+	return h.service.storage.GetTicketByGroupMessage(ctx, msg.ReplyToMessage.MessageID)
+}
+
+func (h *Handler) send(chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := h.service.botAPI.Send(msg)
+	return err
+}
@@ -0,0 +1,310 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/transcribe"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Service relays support conversations between customers and the staff
+// group. Each ticket is a forum topic there; staff answer inside the topic
+// (or by replying to a relayed message) and the bot copies the answer back.
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	// voices keeps customer voice messages with their transcript
+	voices *transcribe.Service
+	logger *zap.Logger
+	cfg    *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, voices *transcribe.Service, logger *zap.Logger, cfg *config.Config) *Service {
+	return &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		voices:  voices,
+		logger:  logger.Named("support"),
+		cfg:     cfg,
+	}
+}
+
+// Enabled reports whether a staff group is configured
+func (s *Service) Enabled() bool {
+	return s.cfg.Support.GroupID != 0
+}
+
+// Open creates a ticket and its topic. The subject, if any, becomes the
+// first customer message.
+func (s *Service) Open(ctx context.Context, msg *tgbotapi.Message, subject string) (*postgres.Ticket, error) {
+	ticket, err := s.storage.CreateTicket(ctx, msg.From.ID, subject, time.Now().Add(s.cfg.Support.ResponseSLA))
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("#%d %s", ticket.ID, displayName(msg.From))
+	if topicID, err := s.createTopic(name); err != nil {
+		// Plain groups still work through replies to relayed messages
+		s.logger.Warn("Failed to create ticket topic",
+			zap.Int64("ticket_id", ticket.ID),
+			zap.Error(err))
+	} else {
+		ticket.TopicID = &topicID
+		if err := s.storage.SetTicketTopic(ctx, ticket.ID, topicID); err != nil {
+			return nil, err
+		}
+	}
+
+	header := fmt.Sprintf("🎫 <b>Обращение #%d</b>\nКлиент: %s (<code>%d</code>)\nОтветить до: %s\n\nОтвечайте в этой теме. Закрыть: /closeticket",
+		ticket.ID, html.EscapeString(displayName(msg.From)), msg.From.ID,
+		ticket.SLADueAt.Format("02.01 15:04"))
+	if _, err := s.sendToGroup(ticket, header, 0); err != nil {
+		return nil, err
+	}
+
+	if subject != "" {
+		if err := s.RelayFromCustomer(ctx, ticket, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("Support ticket opened",
+		zap.Int64("ticket_id", ticket.ID),
+		zap.Int64("user_id", msg.From.ID))
+	return ticket, nil
+}
+
+// RelayFromCustomer copies a customer message into the ticket topic. A
+// voice message is followed by its transcript, which the history keeps as
+// its text.
+func (s *Service) RelayFromCustomer(ctx context.Context, ticket *postgres.Ticket, msg *tgbotapi.Message) error {
+	groupMessageID, err := s.copyMessage(s.cfg.Support.GroupID, topicOf(ticket), msg.Chat.ID, msg.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to relay customer message: %w", err)
+	}
+
+	text := messageText(msg)
+	if msg.Voice != nil {
+		text = s.voiceNote(ctx, ticket, msg, groupMessageID)
+	}
+
+	return s.storage.AddTicketMessage(ctx, postgres.TicketMessage{
+		TicketID:       ticket.ID,
+		Direction:      postgres.DirectionIn,
+		AuthorID:       msg.From.ID,
+		Text:           text,
+		UserMessageID:  msg.MessageID,
+		GroupMessageID: groupMessageID,
+	})
+}
+
+// RelayFromStaff copies a staff answer to the customer
+func (s *Service) RelayFromStaff(ctx context.Context, ticket *postgres.Ticket, msg *tgbotapi.Message) error {
+	userMessageID, err := s.copyMessage(ticket.UserID, 0, msg.Chat.ID, msg.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to relay staff answer: %w", err)
+	}
+
+	return s.storage.AddTicketMessage(ctx, postgres.TicketMessage{
+		TicketID:       ticket.ID,
+		Direction:      postgres.DirectionOut,
+		AuthorID:       msg.From.ID,
+		Text:           messageText(msg),
+		UserMessageID:  userMessageID,
+		GroupMessageID: msg.MessageID,
+	})
+}
+
+// Close finishes a ticket and closes its topic
+func (s *Service) Close(ctx context.Context, ticket *postgres.Ticket, closedBy int64) error {
+	if err := s.storage.CloseTicket(ctx, ticket.ID, closedBy); err != nil {
+		return err
+	}
+
+	who := "сотрудником"
+	if closedBy == ticket.UserID {
+		who = "клиентом"
+	}
+	if _, err := s.sendToGroup(ticket, fmt.Sprintf("✅ Обращение #%d закрыто %s", ticket.ID, who), 0); err != nil {
+		s.logger.Warn("Failed to announce ticket close", zap.Error(err))
+	}
+
+	if ticket.TopicID != nil {
+		params := tgbotapi.Params{}
+		params.AddNonZero64("chat_id", s.cfg.Support.GroupID)
+		params.AddNonZero("message_thread_id", *ticket.TopicID)
+		if _, err := s.botAPI.MakeRequest("closeForumTopic", params); err != nil {
+			s.logger.Warn("Failed to close ticket topic", zap.Error(err))
+		}
+	}
+
+	s.logger.Info("Support ticket closed",
+		zap.Int64("ticket_id", ticket.ID),
+		zap.Int64("closed_by", closedBy))
+	return nil
+}
+
+// WatchSLA reminds staff about tickets left without a first answer
+func (s *Service) WatchSLA(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Support.SLACheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		tickets, err := s.storage.ClaimOverdueTickets(ctx)
+		if err != nil {
+			s.logger.Error("Failed to check ticket SLA", zap.Error(err))
+			continue
+		}
+
+		for i := range tickets {
+			ticket := &tickets[i]
+			text := fmt.Sprintf("⏰ Обращение #%d ждёт ответа с %s — срок ответа истёк",
+				ticket.ID, ticket.CreatedAt.Format("02.01 15:04"))
+			if _, err := s.sendToGroup(ticket, text, 0); err != nil {
+				s.logger.Error("Failed to send SLA reminder",
+					zap.Int64("ticket_id", ticket.ID),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// voiceNote keeps the customer's voice message and answers the relayed copy
+// with its transcript. Returns the text for the ticket history.
+func (s *Service) voiceNote(ctx context.Context, ticket *postgres.Ticket, msg *tgbotapi.Message, groupMessageID int) string {
+	id, transcript, err := s.voices.Note(ctx, msg)
+	if err != nil {
+		s.logger.Warn("Failed to keep voice message",
+			zap.Int64("ticket_id", ticket.ID),
+			zap.Error(err))
+		return "[voice]"
+	}
+	if transcript == "" {
+		return fmt.Sprintf("[voice #%d]", id)
+	}
+
+	if _, err := s.sendToGroup(ticket, "🎙 "+html.EscapeString(transcript), groupMessageID); err != nil {
+		s.logger.Warn("Failed to send voice transcript", zap.Error(err))
+	}
+	return fmt.Sprintf("[voice #%d] %s", id, transcript)
+}
+
+func (s *Service) createTopic(name string) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", s.cfg.Support.GroupID)
+	params.AddNonEmpty("name", truncate(name, 128))
+
+	resp, err := s.botAPI.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &topic); err != nil {
+		return 0, fmt.Errorf("bad createForumTopic response: %w", err)
+	}
+	if topic.MessageThreadID == 0 {
+		return 0, errors.New("createForumTopic returned no thread id")
+	}
+	return topic.MessageThreadID, nil
+}
+
+// sendToGroup posts text in the ticket topic, as a reply to replyTo unless
+// it is 0
+func (s *Service) sendToGroup(ticket *postgres.Ticket, text string, replyTo int) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", s.cfg.Support.GroupID)
+	params.AddNonZero("message_thread_id", topicOf(ticket))
+	params.AddNonZero("reply_to_message_id", replyTo)
+	params.AddNonEmpty("text", text)
+	params.AddNonEmpty("parse_mode", tgbotapi.ModeHTML)
+
+	resp, err := s.botAPI.MakeRequest("sendMessage", params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send to support group: %w", err)
+	}
+
+	var sent tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		return 0, fmt.Errorf("bad sendMessage response: %w", err)
+	}
+	return sent.MessageID, nil
+}
+
+// copyMessage copies any kind of message (text, photo, voice...) and
+// returns the ID of the copy. threadID 0 means no topic.
+func (s *Service) copyMessage(toChat int64, threadID int, fromChat int64, messageID int) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", toChat)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonZero64("from_chat_id", fromChat)
+	params.AddNonZero("message_id", messageID)
+
+	resp, err := s.botAPI.MakeRequest("copyMessage", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var copied tgbotapi.MessageID
+	if err := json.Unmarshal(resp.Result, &copied); err != nil {
+		return 0, fmt.Errorf("bad copyMessage response: %w", err)
+	}
+	return copied.MessageID, nil
+}
+
+func topicOf(ticket *postgres.Ticket) int {
+	if ticket.TopicID == nil {
+		return 0
+	}
+	return *ticket.TopicID
+}
+
+func displayName(user *tgbotapi.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.UserName != "" {
+		name += " @" + user.UserName
+	}
+	return strings.TrimSpace(name)
+}
+
+// messageText is what gets stored in the ticket history
+func messageText(msg *tgbotapi.Message) string {
+	switch {
+	case msg.Text != "":
+		return msg.Text
+	case msg.Caption != "":
+		return msg.Caption
+	case len(msg.Photo) > 0:
+		return "[photo]"
+	case msg.Document != nil:
+		return "[document] " + msg.Document.FileName
+	case msg.Voice != nil:
+		return "[voice]"
+	}
+	return "[attachment]"
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,76 @@
+package templates
+
+import (
+	"s1ntez/internal/i18n"
+	"s1ntez/pkg/money"
+	"time"
+)
+
+// OrderCard announces a new order in the chat it was routed to
+type OrderCard struct {
+	OrderID int64
+	// Code is what the customer calls the order by
+	Code    string
+	Rush    bool
+	Product string
+	Price   money.Amount
+	// GiftCard is the part of Price paid with a gift card, ToPay the rest
+	GiftCard money.Amount
+	ToPay    money.Amount
+	Contact  string
+	Quantity int
+	WidthCM  int
+	HeightCM int
+	Options  map[string]string
+	// Delivery is the name of the delivery method, in Russian
+	Delivery     string
+	DeliveryCost money.Amount
+	Address      string
+	// Rule is the routing rule that picked the chat, "" for the default
+	Rule string
+}
+
+func (OrderCard) template() string { return "order_card" }
+
+// DeadlineReminder warns production of an order due soon or overdue
+type DeadlineReminder struct {
+	OrderID int64
+	Rush    bool
+	Overdue bool
+	DueAt   time.Time
+	// Left is the time until the deadline, or past it when overdue
+	Left    time.Duration
+	Started bool
+}
+
+func (DeadlineReminder) template() string { return "deadline_reminder" }
+
+// JobFailed tells admins a background job ran out of attempts
+type JobFailed struct {
+	JobID    int64
+	Kind     string
+	Attempts int
+	Error    string
+}
+
+func (JobFailed) template() string { return "job_failed" }
+
+// TextureCard describes a material to a customer, after its photos
+type TextureCard struct {
+	Locale           i18n.Locale
+	Name             string
+	PricePerDM2      float64
+	CurrencySymbol   string
+	Description      string
+	CareInstructions string
+}
+
+func (TextureCard) template() string { return "texture_card" }
+
+// TextureComparison sets materials side by side; Table is preformatted
+type TextureComparison struct {
+	Locale i18n.Locale
+	Table  string
+}
+
+func (TextureComparison) template() string { return "texture_comparison" }
@@ -0,0 +1,148 @@
+// Package templates renders the composed bot messages from the templates
+// next to this file, one per message, each filled from its own data type
+// in messages.go.
+//
+// The file name picks the parse mode: name.html.tmpl is Telegram HTML and
+// escapes every value, name.md.tmpl is MarkdownV2 where values go through
+// md. The catalog strings of internal/i18n are read with t.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"s1ntez/internal/i18n"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+//go:embed *.tmpl
+var files embed.FS
+
+// Data is the content of one message; its type names the template
+type Data interface {
+	template() string
+}
+
+// Message is a rendered text with the parse mode Telegram must read it in
+type Message struct {
+	Text      string
+	ParseMode string
+}
+
+// To addresses the message to a chat
+func (m Message) To(chatID int64) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, m.Text)
+	msg.ParseMode = m.ParseMode
+	return msg
+}
+
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+type parsed struct {
+	tmpl      executor
+	parseMode string
+}
+
+// registry is built when the package loads, so a broken template stops the
+// bot at start and not when its message is first sent
+var registry = mustParse()
+
+// Render fills the template of the message
+func Render(data Data) (Message, error) {
+	name := data.template()
+	p, ok := registry[name]
+	if !ok {
+		return Message{}, fmt.Errorf("template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+	return Message{Text: strings.TrimSpace(buf.String()), ParseMode: p.parseMode}, nil
+}
+
+func mustParse() map[string]parsed {
+	names, err := fs.Glob(files, "*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	registry := make(map[string]parsed, len(names))
+	for _, file := range names {
+		source, err := fs.ReadFile(files, file)
+		if err != nil {
+			panic(err)
+		}
+
+		name := strings.TrimSuffix(file, ".tmpl")
+		switch {
+		case strings.HasSuffix(name, ".html"):
+			tmpl := htmltemplate.Must(htmltemplate.New(file).Funcs(htmlFuncs).Parse(string(source)))
+			registry[strings.TrimSuffix(name, ".html")] = parsed{tmpl: tmpl, parseMode: tgbotapi.ModeHTML}
+		case strings.HasSuffix(name, ".md"):
+			tmpl := texttemplate.Must(texttemplate.New(file).Funcs(markdownFuncs).Parse(string(source)))
+			registry[strings.TrimSuffix(name, ".md")] = parsed{tmpl: tmpl, parseMode: tgbotapi.ModeMarkdownV2}
+		default:
+			panic(fmt.Sprintf("template %s: the name must end in .html.tmpl or .md.tmpl", file))
+		}
+	}
+	return registry
+}
+
+var commonFuncs = map[string]any{
+	"date":  func(t time.Time) string { return t.Format("02.01.2006") },
+	"stamp": func(t time.Time) string { return t.Format("02.01 15:04") },
+	"hours": hours,
+}
+
+var htmlFuncs = merge(commonFuncs, htmltemplate.FuncMap{
+	// Catalog strings may carry markup of their own; the arguments put
+	// into them are escaped
+	"t": func(locale i18n.Locale, key string, args ...any) htmltemplate.HTML {
+		for i, arg := range args {
+			if s, ok := arg.(string); ok {
+				args[i] = htmltemplate.HTMLEscapeString(s)
+			}
+		}
+		return htmltemplate.HTML(i18n.T(locale, key, args...))
+	},
+})
+
+var markdownFuncs = merge(commonFuncs, texttemplate.FuncMap{
+	"t": func(locale i18n.Locale, key string, args ...any) string {
+		return tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, i18n.T(locale, key, args...))
+	},
+	"md": func(v any) string {
+		return tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, fmt.Sprint(v))
+	},
+})
+
+func merge[M ~map[string]any](common map[string]any, funcs M) M {
+	for name, fn := range common {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// hours renders a duration the way staff read it: "5 ч", "1 ч 30 мин"
+func hours(d time.Duration) string {
+	d = max(d, 0).Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%d мин", m)
+	case m == 0:
+		return fmt.Sprintf("%d ч", h)
+	}
+	return fmt.Sprintf("%d ч %d мин", h, m)
+}
@@ -0,0 +1,70 @@
+package transcribe
+
+import (
+	"context"
+	"html"
+	"s1ntez/internal/i18n"
+	"s1ntez/internal/storage/redis"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Handler takes the voice messages customers send during an order dialog.
+// They are kept in the draft and attached to the order once it is placed,
+// so production gets them with their transcript; the dialog stays at its
+// step. Voice messages outside a dialog are left to the other handlers.
+type Handler struct {
+	service *Service
+	redis   *redis.Storage
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, redis *redis.Storage, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		redis:   redis,
+		logger:  logger.Named("transcribe"),
+	}
+}
+
+func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) (bool, error) {
+	if msg.Voice == nil || msg.From == nil || !msg.Chat.IsPrivate() {
+		return false, nil
+	}
+
+	state, err := h.redis.GetUserDialogState(ctx, msg.Chat.ID)
+	if err != nil {
+		return false, err
+	}
+	if state.Step == "" || state.Order == nil {
+		return false, nil
+	}
+
+	id, transcript, err := h.service.Note(ctx, msg)
+	if err != nil {
+		return true, err
+	}
+	if !slices.Contains(state.Order.VoiceNotes, id) {
+		state.Order.VoiceNotes = append(state.Order.VoiceNotes, id)
+	}
+	if err := h.redis.SetUserDialogState(ctx, msg.Chat.ID, state); err != nil {
+		return true, err
+	}
+
+	locale, err := h.service.storage.GetUserLocale(ctx, msg.From.ID)
+	if err != nil {
+		h.logger.Warn("Failed to get user locale", zap.Error(err))
+	}
+	text := i18n.T(locale, "voice.noted_file")
+	if transcript != "" {
+		text = i18n.T(locale, "voice.noted", html.EscapeString(transcript))
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeHTML
+	reply.ReplyToMessageID = msg.MessageID
+	_, err = h.service.botAPI.Send(reply)
+	return true, err
+}
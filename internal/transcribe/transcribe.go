@@ -0,0 +1,102 @@
+// Package transcribe keeps the voice messages customers send in support
+// tickets and order dialogs, with their text from a speech-to-text provider
+// so staff can read them instead of listening. Without a provider voice
+// messages are kept as audio only.
+package transcribe
+
+import (
+	"context"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/uploads"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxVoiceBytes is what a bot can download from Telegram
+const maxVoiceBytes = 20 << 20
+
+// ContentTypes are the formats Telegram voice messages come in
+var ContentTypes = []string{"audio/ogg", "audio/mpeg", "audio/mp4"}
+
+// Service saves voice messages as attachments of the customer, with their
+// transcript
+type Service struct {
+	storage *postgres.PostgresStorage
+	botAPI  *tgbotapi.BotAPI
+	// provider is nil when transcription is off
+	provider Provider
+	logger   *zap.Logger
+	cfg      *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, botAPI *tgbotapi.BotAPI, logger *zap.Logger, cfg *config.Config) *Service {
+	s := &Service{
+		storage: storage,
+		botAPI:  botAPI,
+		logger:  logger.Named("transcribe"),
+		cfg:     cfg,
+	}
+
+	t := cfg.Transcription
+	switch t.Provider {
+	case "whisper":
+		s.provider = newWhisper(t.URL, t.APIKey, t.Model, t.Language, t.Timeout)
+	}
+	return s
+}
+
+// Note saves the voice message of msg as a loose attachment of its sender
+// and returns its ID and transcript. The transcript is "" when
+// transcription is off, the message is too long or the provider failed:
+// the audio is kept all the same.
+func (s *Service) Note(ctx context.Context, msg *tgbotapi.Message) (int64, string, error) {
+	if msg.Voice == nil {
+		return 0, "", uploads.ErrNoFile
+	}
+	file, err := uploads.Download(ctx, s.botAPI, msg, maxVoiceBytes)
+	if err != nil {
+		return 0, "", err
+	}
+
+	file.Transcript = s.transcribe(ctx, msg.Voice, file)
+	id, err := uploads.Save(ctx, s.storage, nil, msg.From.ID, *file, ContentTypes)
+	if err != nil {
+		return 0, "", err
+	}
+	return id, file.Transcript, nil
+}
+
+func (s *Service) transcribe(ctx context.Context, voice *tgbotapi.Voice, file *uploads.File) string {
+	if s.provider == nil {
+		return ""
+	}
+	if time.Duration(voice.Duration)*time.Second > s.cfg.Transcription.MaxDuration {
+		s.logger.Info("Voice message too long to transcribe",
+			zap.String("file_id", voice.FileID),
+			zap.Int("seconds", voice.Duration))
+		return ""
+	}
+
+	text, err := s.provider.Transcribe(ctx, file.Data, fileName(file.ContentType))
+	if err != nil {
+		s.logger.Warn("Failed to transcribe voice message",
+			zap.String("file_id", voice.FileID),
+			zap.Error(err))
+		return ""
+	}
+	return text
+}
+
+// fileName tells the provider the audio format by its extension
+func fileName(contentType string) string {
+	switch contentType {
+	case "audio/mpeg":
+		return "voice.mp3"
+	case "audio/mp4":
+		return "voice.m4a"
+	}
+	return "voice.ogg"
+}
@@ -0,0 +1,98 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider turns recorded speech into text
+type Provider interface {
+	Transcribe(ctx context.Context, audio []byte, name string) (string, error)
+}
+
+// whisper calls an OpenAI-compatible /audio/transcriptions endpoint: the
+// OpenAI API itself or a self-hosted Whisper server speaking the same API
+type whisper struct {
+	url      string
+	apiKey   string
+	model    string
+	language string
+	client   *http.Client
+}
+
+func newWhisper(url, apiKey, model, language string, timeout time.Duration) *whisper {
+	return &whisper{
+		url:      url,
+		apiKey:   apiKey,
+		model:    model,
+		language: language,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *whisper) Transcribe(ctx context.Context, audio []byte, name string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	fields := map[string]string{
+		"model":           w.model,
+		"language":        w.language,
+		"response_format": "json",
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(key, value); err != nil {
+			return "", err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whisper request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Text  string `json:"text"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("bad whisper response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return "", fmt.Errorf("whisper: %s (%s)", result.Error.Message, resp.Status)
+		}
+		return "", fmt.Errorf("whisper: %s", resp.Status)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,84 @@
+package uploads
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxAlbum is the most photos Telegram puts in one album
+const MaxAlbum = 10
+
+// AlbumWait is how long an album stays open after its last part came in.
+// Telegram sends the photos of an album as separate messages sharing a
+// media group ID, with nothing marking the last one.
+const AlbumWait = 1500 * time.Millisecond
+
+// Albums gathers the messages of media groups, so a customer's album is
+// handled once as a whole instead of photo by photo
+type Albums struct {
+	wait time.Duration
+
+	mu   sync.Mutex
+	open map[string]*album
+}
+
+type album struct {
+	messages []*tgbotapi.Message
+	timer    *time.Timer
+	// closed is set once done ran; a Reset racing the timer fires it again
+	closed bool
+}
+
+func NewAlbums(wait time.Duration) *Albums {
+	if wait <= 0 {
+		wait = AlbumWait
+	}
+	return &Albums{
+		wait: wait,
+		open: make(map[string]*album),
+	}
+}
+
+// Add collects msg into its album. Once no further part came in for the
+// wait, done gets every message of the album in the order they were sent.
+// A message outside an album is passed to done at once, alone. done runs
+// on a timer goroutine, so it must not use the context of the update.
+func (a *Albums) Add(msg *tgbotapi.Message, done func([]*tgbotapi.Message)) {
+	if msg.MediaGroupID == "" {
+		done([]*tgbotapi.Message{msg})
+		return
+	}
+
+	// Media group IDs are only unique within a chat
+	key := strconv.FormatInt(msg.Chat.ID, 10) + ":" + msg.MediaGroupID
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if pending, ok := a.open[key]; ok {
+		pending.messages = append(pending.messages, msg)
+		pending.timer.Reset(a.wait)
+		return
+	}
+
+	pending := &album{messages: []*tgbotapi.Message{msg}}
+	pending.timer = time.AfterFunc(a.wait, func() {
+		a.mu.Lock()
+		if pending.closed {
+			a.mu.Unlock()
+			return
+		}
+		pending.closed = true
+		delete(a.open, key)
+		messages := pending.messages
+		a.mu.Unlock()
+
+		slices.SortFunc(messages, func(x, y *tgbotapi.Message) int { return x.MessageID - y.MessageID })
+		done(messages)
+	})
+	a.open[key] = pending
+}
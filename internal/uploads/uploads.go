@@ -0,0 +1,197 @@
+package uploads
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/pkg/objectstore"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	ErrNoFile     = errors.New("message has no file")
+	ErrTooLarge   = errors.New("file is too large")
+	ErrFileFormat = errors.New("unsupported file format")
+)
+
+var client = &http.Client{Timeout: time.Minute}
+
+// extensionTypes resolves documents Telegram sends without a usable MIME type
+var extensionTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".ai":   "application/illustrator",
+	".eps":  "application/postscript",
+	".ps":   "application/postscript",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".webp": "image/webp",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+}
+
+// signatures lists what content sniffing may report for a declared type.
+// Illustrator files are saved either PDF-compatible or as PostScript.
+var signatures = map[string][]string{
+	"application/pdf":         {"application/pdf"},
+	"application/illustrator": {"application/pdf", "application/postscript"},
+	"application/postscript":  {"application/postscript"},
+	"image/png":               {"image/png"},
+	"image/jpeg":              {"image/jpeg"},
+	"image/webp":              {"image/webp"},
+}
+
+// File is a photo, document or voice message sent by a customer. Data is
+// downloaded to check and fingerprint it; documents are also copied to the
+// object store when one is configured.
+type File struct {
+	FileID   string
+	UniqueID string
+	// Kind is postgres.TelegramPhoto, TelegramDocument or TelegramVoice
+	Kind        string
+	Name        string
+	ContentType string
+	Data        []byte
+	// Transcript is the text of a voice message, set before Save
+	Transcript string
+}
+
+// Download fetches the photo, document or voice message of a message. Files over maxBytes
+// are refused before downloading when Telegram reports their size.
+func Download(ctx context.Context, botAPI *tgbotapi.BotAPI, msg *tgbotapi.Message, maxBytes int64) (*File, error) {
+	var file File
+	switch {
+	case len(msg.Photo) > 0:
+		// The last size is the largest one
+		photo := msg.Photo[len(msg.Photo)-1]
+		file = File{
+			FileID:      photo.FileID,
+			UniqueID:    photo.FileUniqueID,
+			Kind:        postgres.TelegramPhoto,
+			ContentType: "image/jpeg",
+		}
+	case msg.Document != nil:
+		if int64(msg.Document.FileSize) > maxBytes {
+			return nil, ErrTooLarge
+		}
+		file = File{
+			FileID:      msg.Document.FileID,
+			UniqueID:    msg.Document.FileUniqueID,
+			Kind:        postgres.TelegramDocument,
+			Name:        msg.Document.FileName,
+			ContentType: msg.Document.MimeType,
+		}
+	case msg.Voice != nil:
+		if int64(msg.Voice.FileSize) > maxBytes {
+			return nil, ErrTooLarge
+		}
+		file = File{
+			FileID:      msg.Voice.FileID,
+			UniqueID:    msg.Voice.FileUniqueID,
+			Kind:        postgres.TelegramVoice,
+			ContentType: cmp.Or(msg.Voice.MimeType, "audio/ogg"),
+		}
+	default:
+		return nil, ErrNoFile
+	}
+
+	url, err := botAPI.GetFileDirectURL(file.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build download request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	file.Data, err = io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(file.Data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	sniffed, _, _ := strings.Cut(http.DetectContentType(file.Data), ";")
+	if file.ContentType == "" || file.ContentType == "application/octet-stream" {
+		file.ContentType = extensionTypes[strings.ToLower(path.Ext(file.Name))]
+	}
+	if file.ContentType == "" {
+		file.ContentType = sniffed
+	}
+
+	// A renamed file must not pass for a print format it isn't
+	if expected, ok := signatures[file.ContentType]; ok && !slices.Contains(expected, sniffed) {
+		return nil, fmt.Errorf("%w: %s content declared as %s", ErrFileFormat, sniffed, file.ContentType)
+	}
+	return &file, nil
+}
+
+// Save records the file as an attachment of the user, not linked to an
+// order yet. Files of other types than allowed are refused. With a store,
+// documents are uploaded to it; photos are Telegram-compressed previews and
+// stay referenced by file ID, as do voice messages. store may be nil.
+func Save(ctx context.Context, storage *postgres.PostgresStorage, store *objectstore.Client, userID int64, file File, allowed []string) (int64, error) {
+	if !slices.Contains(allowed, file.ContentType) {
+		return 0, fmt.Errorf("%w: %s", ErrFileFormat, file.ContentType)
+	}
+
+	sum := sha256.Sum256(file.Data)
+	digest := hex.EncodeToString(sum[:])
+
+	key := postgres.TelegramObjectKey(file.Kind, file.UniqueID)
+	if store != nil && file.Kind == postgres.TelegramDocument {
+		key = ObjectKey(userID, digest, file.Name)
+		if err := store.Put(ctx, key, bytes.NewReader(file.Data), int64(len(file.Data)), file.ContentType); err != nil {
+			return 0, fmt.Errorf("failed to upload file: %w", err)
+		}
+	}
+
+	a := postgres.Attachment{
+		UserID:      userID,
+		FileID:      file.FileID,
+		UniqueID:    file.UniqueID,
+		ObjectKey:   key,
+		ContentType: file.ContentType,
+		SizeBytes:   int64(len(file.Data)),
+		SHA256:      digest,
+	}
+	if file.Transcript != "" {
+		a.Transcript = &file.Transcript
+	}
+	return storage.SaveAttachment(ctx, a)
+}
+
+// ObjectKey names a customer file in the object store. Keys are content
+// addressed, so re-uploading the same file overwrites it in place.
+func ObjectKey(userID int64, digest, name string) string {
+	key := "uploads/" + strconv.FormatInt(userID, 10) + "/" + digest
+	if ext := strings.ToLower(path.Ext(name)); extensionTypes[ext] != "" {
+		key += ext
+	}
+	return key
+}
@@ -0,0 +1,118 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sender delivers a verification code to a phone number in E.164
+type Sender interface {
+	SendCode(ctx context.Context, number, code string, ttl time.Duration) error
+}
+
+const senderTimeout = 10 * time.Second
+
+// smsRu sends codes by SMS through sms.ru
+type smsRu struct {
+	apiID  string
+	client *http.Client
+}
+
+func newSMSRu(apiID string) *smsRu {
+	return &smsRu{apiID: apiID, client: &http.Client{Timeout: senderTimeout}}
+}
+
+func (s *smsRu) SendCode(ctx context.Context, number, code string, _ time.Duration) error {
+	to := strings.TrimPrefix(number, "+")
+	form := url.Values{
+		"api_id": {s.apiID},
+		"to":     {to},
+		"msg":    {fmt.Sprintf("Код подтверждения: %s", code)},
+		"json":   {"1"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://sms.ru/sms/send", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms.ru request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	type status struct {
+		Status     string `json:"status"`
+		StatusText string `json:"status_text"`
+	}
+	var result struct {
+		status
+		SMS map[string]status `json:"sms"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("bad sms.ru response (%s): %w", resp.Status, err)
+	}
+
+	if result.Status != "OK" {
+		return fmt.Errorf("sms.ru: %s", result.StatusText)
+	}
+	if sms, ok := result.SMS[to]; ok && sms.Status != "OK" {
+		return fmt.Errorf("sms.ru: %s", sms.StatusText)
+	}
+	return nil
+}
+
+// gateway sends codes as Telegram messages to the number through the
+// Telegram Gateway API, which is cheaper than SMS
+type gateway struct {
+	token  string
+	client *http.Client
+}
+
+func newGateway(token string) *gateway {
+	return &gateway{token: token, client: &http.Client{Timeout: senderTimeout}}
+}
+
+func (g *gateway) SendCode(ctx context.Context, number, code string, ttl time.Duration) error {
+	form := url.Values{
+		"phone_number": {number},
+		"code":         {code},
+		// the API accepts 30 s to 1 day
+		"ttl": {strconv.Itoa(int(min(max(ttl, 30*time.Second), 24*time.Hour).Seconds()))},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		"https://gatewayapi.telegram.org/sendVerificationMessage", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("bad telegram gateway response (%s): %w", resp.Status, err)
+	}
+	if !result.OK {
+		return errors.New("telegram gateway: " + result.Error)
+	}
+	return nil
+}
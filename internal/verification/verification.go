@@ -0,0 +1,181 @@
+package verification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"s1ntez/internal/config"
+	"s1ntez/internal/storage/errs"
+	"s1ntez/internal/storage/postgres"
+	"s1ntez/internal/storage/redis"
+	"s1ntez/pkg/phone"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoCode means no code is pending: it expired or was never sent
+	ErrNoCode          = errors.New("no verification code pending")
+	ErrWrongCode       = errors.New("wrong verification code")
+	ErrTooManyAttempts = errors.New("too many wrong verification codes")
+	ErrTooManyCodes    = errors.New("too many verification codes requested")
+)
+
+const codeDigits = 6
+
+// Service keeps the customer's phone number for orders. Typed numbers are
+// confirmed with a one-time code when PHONE_VERIFICATION is on; numbers
+// shared with the Telegram contact button are trusted as they are.
+type Service struct {
+	storage *postgres.PostgresStorage
+	codes   *redis.Storage
+	// sender is nil when verification is off
+	sender Sender
+	logger *zap.Logger
+	cfg    *config.Config
+}
+
+func New(storage *postgres.PostgresStorage, codes *redis.Storage, logger *zap.Logger, cfg *config.Config) *Service {
+	s := &Service{
+		storage: storage,
+		codes:   codes,
+		logger:  logger.Named("verification"),
+		cfg:     cfg,
+	}
+
+	switch cfg.Phone.Verification {
+	case "sms":
+		s.sender = newSMSRu(cfg.Phone.SMSRuAPIID)
+	case "telegram":
+		s.sender = newGateway(cfg.Phone.GatewayToken)
+	}
+	return s
+}
+
+// Known returns the saved number when an order can use it without asking,
+// "" otherwise
+func (s *Service) Known(ctx context.Context, userID int64) (string, error) {
+	number, verified, err := s.storage.GetUserPhone(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if s.sender != nil && !verified {
+		return "", nil
+	}
+	return number, nil
+}
+
+// Shared saves the number of the user's own Telegram contact. Telegram has
+// confirmed it belongs to the account, so no code is needed.
+func (s *Service) Shared(ctx context.Context, userID int64, raw string) (string, error) {
+	number, err := phone.Normalize(raw)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.SaveUserPhone(ctx, userID, number, true); err != nil {
+		return "", err
+	}
+	return number, nil
+}
+
+// Submit takes a typed number. With verification on a code is sent to it
+// and needsCode is set; the number is saved once Confirm accepts the code.
+func (s *Service) Submit(ctx context.Context, userID int64, raw string) (number string, needsCode bool, err error) {
+	number, err = phone.Normalize(raw)
+	if err != nil {
+		return "", false, err
+	}
+
+	if s.sender == nil {
+		return number, false, s.storage.SaveUserPhone(ctx, userID, number, false)
+	}
+
+	// The number on file was confirmed before
+	current, verified, err := s.storage.GetUserPhone(ctx, userID)
+	if err != nil {
+		return "", false, err
+	}
+	if verified && current == number {
+		return number, false, nil
+	}
+
+	switch err := s.storage.CheckRateLimit(ctx, userID, "phone_code", s.cfg.Phone.CodesPerHour, time.Hour); {
+	case errors.Is(err, errs.ErrRateLimited):
+		return "", false, ErrTooManyCodes
+	case err != nil:
+		s.logger.Warn("Rate limit check failed", zap.Error(err))
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return "", false, err
+	}
+
+	pending := redis.PhoneCode{Phone: number, CodeHash: hashCode(userID, code)}
+	if err := s.codes.SetPhoneCode(ctx, userID, pending, s.cfg.Phone.CodeTTL); err != nil {
+		return "", false, err
+	}
+
+	if err := s.sender.SendCode(ctx, number, code, s.cfg.Phone.CodeTTL); err != nil {
+		if dropErr := s.codes.DropPhoneCode(ctx, userID); dropErr != nil {
+			s.logger.Warn("Failed to drop unsent phone code", zap.Error(dropErr))
+		}
+		return "", false, fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	s.logger.Info("Verification code sent", zap.Int64("user_id", userID))
+	return number, true, nil
+}
+
+// Confirm checks the entered code and saves the number as verified
+func (s *Service) Confirm(ctx context.Context, userID int64, code string) (string, error) {
+	pending, err := s.codes.GetPhoneCode(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if pending == nil {
+		return "", ErrNoCode
+	}
+
+	if !hmac.Equal([]byte(hashCode(userID, code)), []byte(pending.CodeHash)) {
+		pending.Attempts++
+		if pending.Attempts >= s.cfg.Phone.MaxAttempts {
+			if err := s.codes.DropPhoneCode(ctx, userID); err != nil {
+				return "", err
+			}
+			return "", ErrTooManyAttempts
+		}
+		if err := s.codes.UpdatePhoneCode(ctx, userID, *pending); err != nil {
+			return "", err
+		}
+		return "", ErrWrongCode
+	}
+
+	if err := s.codes.DropPhoneCode(ctx, userID); err != nil {
+		s.logger.Warn("Failed to drop used phone code", zap.Error(err))
+	}
+	if err := s.storage.SaveUserPhone(ctx, userID, pending.Phone, true); err != nil {
+		return "", err
+	}
+	return pending.Phone, nil
+}
+
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
+
+// hashCode keeps the code out of Redis in plain text
+func hashCode(userID int64, code string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s", userID, code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,229 @@
+// Package money keeps amounts in minor units (kopecks, cents), so sums are
+// exact and every rate is rounded once, half away from zero
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid amount")
+
+// All supported currencies have two decimal places
+const (
+	scale = 100
+	// rateScale is the precision rates are applied with: 0.035 of a price,
+	// 12.5 dm² of a price per dm²
+	rateScale = 1_000_000
+)
+
+// Amount is a sum in minor units of the order currency
+type Amount int64
+
+// FromFloat converts a float as it is written, so 1.005 becomes 1.01, not
+// the 1.00 its binary value would round to. For config values and rates.
+func FromFloat(v float64) Amount {
+	a, err := Parse(strconv.FormatFloat(v, 'f', -1, 64))
+	if err != nil {
+		// NaN and infinities never reach prices
+		return 0
+	}
+	return a
+}
+
+// Parse reads a decimal like "1234.5" or "-0.75"; digits past the minor
+// unit are rounded
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	for _, part := range []string{whole, frac} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+	}
+
+	units, err := strconv.ParseInt("0"+whole, 10, 64)
+	if err != nil || units > math.MaxInt64/scale {
+		return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	frac += "000"
+	minor, _ := strconv.ParseInt(frac[:2], 10, 64)
+	if frac[2] >= '5' {
+		minor++
+	}
+
+	a := Amount(units*scale + minor)
+	if negative {
+		a = -a
+	}
+	return a, nil
+}
+
+// MulRate multiplies by a rate given to six decimals, rounding once
+func (a Amount) MulRate(rate float64) Amount {
+	r := int64(math.Round(rate * rateScale))
+	return Amount(divRound(int64(a)*r, rateScale))
+}
+
+// divRound divides rounding half away from zero
+func divRound(n, d int64) int64 {
+	q, rem := n/d, n%d
+	if 2*abs(rem) >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Float is for charts and spreadsheets, which work in floats anyway
+func (a Amount) Float() float64 {
+	return float64(a) / scale
+}
+
+// String formats the amount with two decimals, e.g. "1234.50"
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, int64(a/scale), int64(a%scale))
+}
+
+// Format makes %.2f and %v print the exact amount, so message templates
+// written for float prices keep working
+func (a Amount) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'f', 'v', 's':
+		if prec, ok := f.Precision(); ok && prec < 2 && verb == 'f' {
+			fmt.Fprintf(f, "%.*f", prec, a.Float())
+			return
+		}
+		s := a.String()
+		if prec, ok := f.Precision(); ok && prec > 2 {
+			s += strings.Repeat("0", prec-2)
+		}
+		if width, ok := f.Width(); ok && len(s) < width {
+			s = strings.Repeat(" ", width-len(s)) + s
+		}
+		fmt.Fprint(f, s)
+	case 'd':
+		fmt.Fprintf(f, "%d", int64(a))
+	default:
+		fmt.Fprintf(f, "%%!%c(money.Amount=%s)", verb, a.String())
+	}
+}
+
+// MarshalJSON writes a number with two decimals, as the float fields did
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Scan reads NUMERIC columns without going through float64
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case string:
+		parsed, err := Parse(v)
+		*a = parsed
+		return err
+	case []byte:
+		parsed, err := Parse(string(v))
+		*a = parsed
+		return err
+	case int64:
+		*a = Amount(v * scale)
+		return nil
+	case float64:
+		*a = FromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalid, src)
+}
+
+// Value stores the amount as a decimal string for NUMERIC columns
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Currency is an ISO 4217 code such as RUB
+type Currency string
+
+const RUB Currency = "RUB"
+
+// Valid checks the code has the ISO 4217 shape: three capital letters
+func (c Currency) Valid() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Symbol is what customers see after an amount; codes without a common
+// sign are shown as is
+func (c Currency) Symbol() string {
+	switch c {
+	case RUB:
+		return "₽"
+	case "USD":
+		return "$"
+	case "EUR":
+		return "€"
+	case "KZT":
+		return "₸"
+	}
+	return string(c)
+}
+
+// Money is an amount together with its currency
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func New(amount Amount, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// String formats the sum for people, e.g. "1234.50 ₽"
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency.Symbol()
+}
@@ -0,0 +1,227 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "1234.5", want: 123450},
+		{in: "-0.75", want: -75},
+		{in: " 12 ", want: 1200},
+		{in: "+3.1", want: 310},
+		{in: ".5", want: 50},
+		{in: "5.", want: 500},
+		{in: "1.005", want: 101},
+		{in: "1.004", want: 100},
+		{in: "-1.005", want: -101},
+		{in: "0.999", want: 100},
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1e5", wantErr: true},
+		{in: "92233720368547759", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalid", tt.in, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want Amount
+	}{
+		{in: 0, want: 0},
+		{in: 25, want: 2500},
+		{in: 31.25, want: 3125},
+		// As written, not as the binary value 1.00499999...
+		{in: 1.005, want: 101},
+		{in: -2.5, want: -250},
+		{in: 0.001, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.in), func(t *testing.T) {
+			if got := FromFloat(tt.in); got != tt.want {
+				t.Errorf("FromFloat(%v) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		rate   float64
+		want   Amount
+	}{
+		{name: "whole", amount: 10000, rate: 0.03, want: 300},
+		{name: "half rounds up", amount: 5, rate: 0.5, want: 3},
+		{name: "negative half rounds down", amount: -5, rate: 0.5, want: -3},
+		{name: "below half", amount: 14063, rate: 0.03, want: 422},
+		{name: "markup", amount: 5625, rate: 2.5, want: 14063},
+		{name: "area", amount: 2500, rate: 12.5, want: 31250},
+		{name: "zero rate", amount: 12345, rate: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.MulRate(tt.rate); got != tt.want {
+				t.Errorf("%d.MulRate(%v) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		amount Amount
+		want   string
+	}{
+		{format: "%v", amount: 123450, want: "1234.50"},
+		{format: "%s", amount: -75, want: "-0.75"},
+		{format: "%.2f", amount: 5, want: "0.05"},
+		{format: "%.0f", amount: 123456, want: "1235"},
+		{format: "%.3f", amount: 123456, want: "1234.560"},
+		{format: "%8.2f", amount: 1250, want: "   12.50"},
+		{format: "%d", amount: 1250, want: "1250"},
+		{format: "%x", amount: 1250, want: "%!x(money.Amount=12.50)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, tt.amount); got != tt.want {
+				t.Errorf("Sprintf(%q, %d) = %q, want %q", tt.format, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Amount
+	}{
+		{name: "number", in: `12.5`, want: 1250},
+		{name: "string", in: `"0.75"`, want: 75},
+		{name: "null keeps zero", in: `null`, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+			}
+
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var back Amount
+			if err := json.Unmarshal(data, &back); err != nil || back != got {
+				t.Errorf("round trip of %d through %s = %d, %v", got, data, back, err)
+			}
+		})
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Amount
+		wantErr bool
+	}{
+		{name: "nil", src: nil, want: 0},
+		{name: "string", src: "12.30", want: 1230},
+		{name: "bytes", src: []byte("0.5"), want: 50},
+		{name: "int64", src: int64(7), want: 700},
+		{name: "float64", src: 1.1, want: 110},
+		{name: "bad string", src: "twelve", wantErr: true},
+		{name: "unsupported", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Scan(%v) error = %v, want ErrInvalid", tt.src, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) unexpected error: %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	tests := []struct {
+		code       Currency
+		wantValid  bool
+		wantSymbol string
+	}{
+		{code: RUB, wantValid: true, wantSymbol: "₽"},
+		{code: "USD", wantValid: true, wantSymbol: "$"},
+		{code: "EUR", wantValid: true, wantSymbol: "€"},
+		{code: "KZT", wantValid: true, wantSymbol: "₸"},
+		{code: "GBP", wantValid: true, wantSymbol: "GBP"},
+		{code: "rub", wantValid: false, wantSymbol: "rub"},
+		{code: "RUBL", wantValid: false, wantSymbol: "RUBL"},
+		{code: "", wantValid: false, wantSymbol: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			if got := tt.code.Valid(); got != tt.wantValid {
+				t.Errorf("Valid() = %v, want %v", got, tt.wantValid)
+			}
+			if got := tt.code.Symbol(); got != tt.wantSymbol {
+				t.Errorf("Symbol() = %q, want %q", got, tt.wantSymbol)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	if got, want := New(123450, RUB).String(), "1234.50 ₽"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
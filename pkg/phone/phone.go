@@ -0,0 +1,60 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid phone number")
+
+// E.164 allows up to 15 digits; the orders contact constraint requires
+// at least 10
+const (
+	minDigits = 10
+	maxDigits = 15
+)
+
+// Normalize converts a phone number as people type it to E.164
+// (+79991234567). Numbers without a country code are taken as Russian:
+// "8 (999) 123-45-67", "7 999 1234567" and "999 123 45 67" all become
+// +79991234567.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	international := false
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		international, raw = true, raw[1:]
+	case strings.HasPrefix(raw, "00"):
+		international, raw = true, raw[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ', r == '-', r == '(', r == ')', r == '.', r == '\u00a0':
+			// separators
+		default:
+			return "", ErrInvalid
+		}
+	}
+	number := digits.String()
+
+	if !international {
+		switch {
+		case len(number) == 11 && (number[0] == '8' || number[0] == '7'):
+			number = "7" + number[1:]
+		case len(number) == 10 && number[0] == '9':
+			number = "7" + number
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	if len(number) < minDigits || len(number) > maxDigits || number[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,48 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "8 (999) 123-45-67", want: "+79991234567"},
+		{raw: "7 999 1234567", want: "+79991234567"},
+		{raw: "999 123 45 67", want: "+79991234567"},
+		{raw: "+7 999 123.45.67", want: "+79991234567"},
+		{raw: " +79991234567 ", want: "+79991234567"},
+		{raw: "8 999 1234567", want: "+79991234567"},
+		{raw: "+1 202 555 0100", want: "+12025550100"},
+		{raw: "0044 20 7946 0958", want: "+442079460958"},
+		{raw: "", wantErr: true},
+		{raw: "12345", wantErr: true},
+		{raw: "+123", wantErr: true},
+		{raw: "1234567890", wantErr: true},
+		{raw: "8999123456a", wantErr: true},
+		{raw: "+0123456789012", wantErr: true},
+		{raw: "+1234567890123456", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Normalize(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Normalize(%q) = %q, %v, want ErrInvalid", tt.raw, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize(%q) unexpected error: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
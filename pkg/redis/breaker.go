@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker. After threshold failures in a row it
+// opens: calls fail right away instead of waiting on a dead server. While
+// open, one call per cooldown is let through to probe whether Redis is back.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	lastProbe time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a call may go to Redis
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if time.Since(b.lastProbe) < b.cooldown {
+		return false
+	}
+	b.lastProbe = time.Now()
+	return true
+}
+
+// success closes the breaker; closed is true when it was open
+func (b *breaker) success() (closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	closed = b.open
+	b.failures = 0
+	b.open = false
+	return closed
+}
+
+// failure counts a failed call; opened is true when it opened the breaker
+func (b *breaker) failure() (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.open || b.failures < b.threshold {
+		return false
+	}
+	b.open = true
+	b.lastProbe = time.Now()
+	return true
+}
+
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
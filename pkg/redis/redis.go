@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnavailable is returned without calling Redis while the breaker is open
+var ErrUnavailable = errors.New("redis unavailable")
+
+// maxPendingDeletes bounds the invalidations kept while Redis is down
+const maxPendingDeletes = 10000
+
+// Nil is the reply for a missing key
+const Nil = redis.Nil
+
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Retries is how many times a failed idempotent call is repeated,
+	// RetryBackoff the pause before the first repeat; it doubles each time
+	Retries      int
+	RetryBackoff time.Duration
+	// BreakerThreshold failures in a row open the breaker; while open, a
+	// probe is let through once per BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// OnStateChange, if set, is called when the client enters or leaves
+	// the degraded mode
+	OnStateChange func(degraded bool)
+}
+
+// Client is a Redis client that keeps the bot going when Redis is down.
+// Idempotent calls are retried; after repeated failures a circuit breaker
+// switches the client to the degraded mode, where calls fail fast with
+// ErrUnavailable and callers fall back to Postgres.
+type Client struct {
+	rdb     *redis.Client
+	cfg     Config
+	breaker *breaker
+
+	// cache invalidations that failed while Redis was down; they are
+	// replayed when it is back so no stale entry outlives the outage
+	mu             sync.Mutex
+	pendingDeletes map[string]struct{}
+}
+
+func New(cfg Config) *Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     100, // Increase connection pool size
+		MinIdleConns: 10,  // Keep minimum connections ready
+		// retries are ours: only idempotent calls may be repeated
+		MaxRetries: -1,
+	})
+
+	// Trace every Redis command as a child of the caller's span
+	_ = redisotel.InstrumentTracing(rdb)
+
+	return &Client{
+		rdb:            rdb,
+		cfg:            cfg,
+		breaker:        newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		pendingDeletes: make(map[string]struct{}),
+	}
+}
+
+// Close closes the Redis connection
+func (c *Client) Close() {
+	if c.rdb != nil {
+		_ = c.rdb.Close()
+	}
+}
+
+// Degraded reports whether Redis is considered down
+func (c *Client) Degraded() bool {
+	return c.breaker.isOpen()
+}
+
+// Do runs fn once, guarded by the breaker. Use it for calls that must not
+// run twice, such as counters and SETNX.
+func (c *Client) Do(ctx context.Context, fn func(ctx context.Context, rdb *redis.Client) error) error {
+	return c.call(ctx, 0, fn)
+}
+
+// Retry runs fn guarded by the breaker, repeating it on connection
+// failures. fn must be idempotent.
+func (c *Client) Retry(ctx context.Context, fn func(ctx context.Context, rdb *redis.Client) error) error {
+	return c.call(ctx, c.cfg.Retries, fn)
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := c.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		var err error
+		data, err = rdb.Get(ctx, key).Bytes()
+		return err
+	})
+	return data, err
+}
+
+func (c *Client) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Set(ctx, key, value, ttl).Err()
+	})
+}
+
+// Del deletes keys. Keys that could not be deleted because Redis is down
+// are deleted once it is back.
+func (c *Client) Del(ctx context.Context, keys ...string) error {
+	err := c.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Del(ctx, keys...).Err()
+	})
+	if errors.Is(err, ErrUnavailable) || connectionFailure(err) {
+		c.mu.Lock()
+		for _, key := range keys {
+			if len(c.pendingDeletes) < maxPendingDeletes {
+				c.pendingDeletes[key] = struct{}{}
+			}
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Incr is not retried: a lost reply would count twice
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		var err error
+		n, err = rdb.Incr(ctx, key).Result()
+		return err
+	})
+	return n, err
+}
+
+func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := c.Retry(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		var err error
+		ok, err = rdb.Expire(ctx, key, ttl).Result()
+		return err
+	})
+	return ok, err
+}
+
+func (c *Client) call(ctx context.Context, retries int, fn func(ctx context.Context, rdb *redis.Client) error) error {
+	if !c.breaker.allow() {
+		return ErrUnavailable
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn(ctx, c.rdb)
+		if ctx.Err() != nil {
+			// the caller gave up; that says nothing about Redis
+			return err
+		}
+		if !connectionFailure(err) {
+			if c.breaker.success() {
+				c.stateChanged(false)
+				go c.replayDeletes()
+			}
+			return err
+		}
+		if attempt >= retries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(c.cfg.RetryBackoff << attempt):
+		}
+	}
+
+	if c.breaker.failure() {
+		c.stateChanged(true)
+	}
+	return err
+}
+
+func (c *Client) replayDeletes() {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.pendingDeletes))
+	for key := range c.pendingDeletes {
+		keys = append(keys, key)
+	}
+	c.pendingDeletes = make(map[string]struct{})
+	c.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// Del puts back whatever fails again
+	_ = c.Del(ctx, keys...)
+}
+
+func (c *Client) stateChanged(degraded bool) {
+	if c.cfg.OnStateChange != nil {
+		c.cfg.OnStateChange(degraded)
+	}
+}
+
+// connectionFailure tells a Redis outage from answers of a working server,
+// such as a missing key or an error reply
+func connectionFailure(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	var reply redis.Error
+	return !errors.As(err, &reply)
+}
@@ -0,0 +1,267 @@
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiBase      = "https://sheets.googleapis.com/v4/spreadsheets/"
+	scope        = "https://www.googleapis.com/auth/spreadsheets"
+	defaultToken = "https://oauth2.googleapis.com/token"
+	// tokens live an hour; renew a little early
+	tokenLifetime = time.Hour
+	tokenMargin   = 2 * time.Minute
+)
+
+type Config struct {
+	// Credentials is the JSON key of a Google service account. The
+	// spreadsheet must be shared with its client_email.
+	Credentials   []byte
+	SpreadsheetID string
+	Timeout       time.Duration
+}
+
+// Client reads and writes cell values of one spreadsheet through the
+// Sheets API v4, authenticated as a service account
+type Client struct {
+	spreadsheetID string
+	email         string
+	key           *rsa.PrivateKey
+	tokenURI      string
+	client        *http.Client
+	now           func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.SpreadsheetID == "" {
+		return nil, errors.New("spreadsheet ID is required")
+	}
+
+	var account struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(cfg.Credentials, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("service account key has no client_email or private_key")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account private key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not RSA")
+	}
+
+	if account.TokenURI == "" {
+		account.TokenURI = defaultToken
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	return &Client{
+		spreadsheetID: cfg.SpreadsheetID,
+		email:         account.ClientEmail,
+		key:           key,
+		tokenURI:      account.TokenURI,
+		client:        &http.Client{Timeout: cfg.Timeout},
+		now:           time.Now,
+	}, nil
+}
+
+// Get returns the formatted values of the range, e.g. "Orders!A:A".
+// Trailing empty rows and cells are left out.
+func (c *Client) Get(ctx context.Context, rng string) ([][]string, error) {
+	var resp struct {
+		Values [][]string `json:"values"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.valuesURL(rng, "", nil), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rng, err)
+	}
+	return resp.Values, nil
+}
+
+// Append adds the rows after the last row of the table in the range and
+// returns the range they were written to, e.g. "Orders!A15:T15"
+func (c *Client) Append(ctx context.Context, rng string, rows [][]any) (string, error) {
+	body := map[string]any{"values": rows}
+	var resp struct {
+		Updates struct {
+			UpdatedRange string `json:"updatedRange"`
+		} `json:"updates"`
+	}
+	query := url.Values{"valueInputOption": {"USER_ENTERED"}, "insertDataOption": {"INSERT_ROWS"}}
+	if err := c.do(ctx, http.MethodPost, c.valuesURL(rng, ":append", query), body, &resp); err != nil {
+		return "", fmt.Errorf("failed to append to %s: %w", rng, err)
+	}
+	return resp.Updates.UpdatedRange, nil
+}
+
+// Update overwrites the cells of the range with the rows
+func (c *Client) Update(ctx context.Context, rng string, rows [][]any) error {
+	body := map[string]any{"values": rows}
+	query := url.Values{"valueInputOption": {"USER_ENTERED"}}
+	if err := c.do(ctx, http.MethodPut, c.valuesURL(rng, "", query), body, nil); err != nil {
+		return fmt.Errorf("failed to update %s: %w", rng, err)
+	}
+	return nil
+}
+
+// valuesURL addresses a range. Writes use USER_ENTERED, so numbers and
+// dates are parsed as if typed into the sheet.
+func (c *Client) valuesURL(rng, action string, query url.Values) string {
+	endpoint := apiBase + url.PathEscape(c.spreadsheetID) + "/values/" + url.PathEscape(rng) + action
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode == http.StatusUnauthorized {
+			c.dropToken()
+		}
+		return fmt.Errorf("sheets API responded %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns a cached OAuth token, exchanging a signed JWT for a
+// new one when it is about to expire
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.token != "" && now.Before(c.tokenExpiry.Add(-tokenMargin)) {
+		return c.token, nil
+	}
+
+	assertion, err := c.assertion(now)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("failed to get access token: %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiry = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+func (c *Client) dropToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// assertion is the RS256-signed JWT a service account authenticates with
+func (c *Client) assertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.email,
+		"scope": scope,
+		"aud":   c.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenLifetime).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
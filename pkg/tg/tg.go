@@ -0,0 +1,207 @@
+// Package tg sends Telegram messages without losing them to the usual API
+// trouble: it waits out flood limits (429 with retry_after), backs off on
+// server errors, splits texts longer than Telegram takes into several
+// messages and reports chats that blocked the bot.
+package tg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// MaxMessageLength is the longest text Telegram accepts in one message, in
+// UTF-16 code units: an emoji outside the BMP takes two
+const MaxMessageLength = 4096
+
+// ErrBlocked means the chat can't be written to: the user blocked the bot
+// or deleted their account. Retrying won't help.
+var ErrBlocked = errors.New("bot was blocked by the user")
+
+type Options struct {
+	// MaxAttempts per message, counting flood waits; 4 by default
+	MaxAttempts int
+	// BaseDelay is the first backoff after a server or network error,
+	// doubled on every next one up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// OnBlocked is told about chats that answered ErrBlocked, e.g. to flag
+	// the user as unreachable
+	OnBlocked func(ctx context.Context, chatID int64) error
+}
+
+// Sender wraps the bot API with retries
+type Sender struct {
+	api    *tgbotapi.BotAPI
+	opts   Options
+	logger *zap.Logger
+}
+
+func New(api *tgbotapi.BotAPI, logger *zap.Logger, opts Options) *Sender {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 4
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	return &Sender{
+		api:    api,
+		opts:   opts,
+		logger: logger.Named("tg"),
+	}
+}
+
+// Send delivers the message. A text over MaxMessageLength goes out in
+// parts, the keyboard with the last one; the last part is returned.
+func (s *Sender) Send(ctx context.Context, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	parts := Split(msg.Text, MaxMessageLength)
+	var sent tgbotapi.Message
+	for i, text := range parts {
+		part := msg
+		part.Text = text
+		if i < len(parts)-1 {
+			part.ReplyMarkup = nil
+		}
+		var err error
+		if sent, err = s.Do(ctx, msg.ChatID, part); err != nil {
+			if i > 0 {
+				return sent, fmt.Errorf("message cut short after part %d of %d: %w", i, len(parts), err)
+			}
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// Do sends anything the bot API sends, with the retries of Send but no
+// splitting. chatID is the recipient, for ErrBlocked and the logs.
+func (s *Sender) Do(ctx context.Context, chatID int64, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var lastErr error
+	backoff := s.opts.BaseDelay
+
+	for attempt := 1; attempt <= s.opts.MaxAttempts; attempt++ {
+		sent, err := s.api.Send(c)
+		if err == nil {
+			return sent, nil
+		}
+		lastErr = err
+
+		delay := backoff
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) {
+			switch {
+			case apiErr.RetryAfter > 0:
+				// the flood wait doesn't grow the backoff
+				delay = time.Duration(apiErr.RetryAfter) * time.Second
+			case apiErr.Code == 403 && isBlocked(apiErr.Message):
+				s.blocked(ctx, chatID)
+				return sent, fmt.Errorf("chat %d: %w: %s", chatID, ErrBlocked, apiErr.Message)
+			case apiErr.Code < 500:
+				// a bad request stays bad
+				return sent, err
+			default:
+				backoff = min(backoff*2, s.opts.MaxDelay)
+			}
+		} else {
+			backoff = min(backoff*2, s.opts.MaxDelay)
+		}
+		if attempt == s.opts.MaxAttempts {
+			break
+		}
+
+		s.logger.Warn("Telegram send failed, retrying",
+			zap.Int64("chat_id", chatID),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return tgbotapi.Message{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return tgbotapi.Message{}, fmt.Errorf("not delivered to chat %d after %d attempts: %w", chatID, s.opts.MaxAttempts, lastErr)
+}
+
+// isBlocked tells the 403s of a chat gone for good from the other ones,
+// such as a bot kicked from a group or not allowed to start a conversation
+func isBlocked(description string) bool {
+	switch strings.TrimPrefix(description, "Forbidden: ") {
+	case "bot was blocked by the user", "user is deactivated":
+		return true
+	}
+	return false
+}
+
+func (s *Sender) blocked(ctx context.Context, chatID int64) {
+	if s.opts.OnBlocked == nil {
+		return
+	}
+	if err := s.opts.OnBlocked(ctx, chatID); err != nil {
+		s.logger.Warn("Failed to flag unreachable chat", zap.Int64("chat_id", chatID), zap.Error(err))
+	}
+}
+
+// Split cuts text into parts of at most limit UTF-16 code units, as
+// Telegram counts them, at paragraph breaks where it can, then at line
+// breaks, then at spaces. HTML tags opened on one line should close on it,
+// or a part may not parse.
+func Split(text string, limit int) []string {
+	var parts []string
+	for length(text) > limit {
+		cut := prefix(text, limit)
+		at := -1
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(cut, sep); i > 0 {
+				at = i
+				break
+			}
+		}
+		if at < 0 {
+			parts = append(parts, cut)
+			text = text[len(cut):]
+			continue
+		}
+		parts = append(parts, strings.TrimRight(cut[:at], " \n"))
+		text = strings.TrimLeft(text[at:], " \n")
+	}
+	return append(parts, text)
+}
+
+// length is the length of s in UTF-16 code units
+func length(s string) int {
+	n := 0
+	for _, r := range s {
+		n += max(utf16.RuneLen(r), 1)
+	}
+	return n
+}
+
+// prefix is the longest start of s within n UTF-16 code units; a character
+// taking two is not cut in half. It is never empty, so Split moves on even
+// with a limit of one.
+func prefix(s string, n int) string {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		units := max(utf16.RuneLen(r), 1)
+		if units > n && i > 0 {
+			break
+		}
+		n -= units
+		i += size
+	}
+	return s[:i]
+}
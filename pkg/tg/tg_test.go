@@ -0,0 +1,89 @@
+package tg
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{name: "short text", text: "hello", limit: 10, want: []string{"hello"}},
+		{name: "text at the limit", text: "hello", limit: 5, want: []string{"hello"}},
+		{name: "paragraph break first", text: "one two\n\nthree", limit: 10, want: []string{"one two", "three"}},
+		{name: "line break", text: "one\ntwo three", limit: 10, want: []string{"one", "two three"}},
+		{name: "space", text: "one two three", limit: 10, want: []string{"one two", "three"}},
+		{name: "no break", text: "abcdefghij", limit: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "cyrillic counts one unit a letter", text: "привет мир", limit: 8, want: []string{"привет", "мир"}},
+		{name: "emoji counts two units", text: "😀😀😀", limit: 4, want: []string{"😀😀", "😀"}},
+		{name: "surrogate pair is not cut", text: "ab😀", limit: 3, want: []string{"ab", "😀"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.text, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Split(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMaxMessageLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantParts int
+	}{
+		{name: "ascii at the limit", text: strings.Repeat("a", MaxMessageLength), wantParts: 1},
+		{name: "ascii over the limit", text: strings.Repeat("a", MaxMessageLength+1), wantParts: 2},
+		{name: "cyrillic at the limit", text: strings.Repeat("ж", MaxMessageLength), wantParts: 1},
+		// 4096 runes but 8192 code units
+		{name: "emoji at the rune limit", text: strings.Repeat("😀", MaxMessageLength), wantParts: 2},
+		{name: "emoji at the unit limit", text: strings.Repeat("😀", MaxMessageLength/2), wantParts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := Split(tt.text, MaxMessageLength)
+			if len(parts) != tt.wantParts {
+				t.Fatalf("got %d parts, want %d", len(parts), tt.wantParts)
+			}
+			for i, part := range parts {
+				if n := length(part); n > MaxMessageLength {
+					t.Errorf("part %d is %d code units long", i, n)
+				}
+			}
+			if joined := strings.Join(parts, ""); joined != tt.text {
+				t.Error("parts don't add up to the text")
+			}
+		})
+	}
+}
+
+func TestIsBlocked(t *testing.T) {
+	tests := []struct {
+		description string
+		want        bool
+	}{
+		{description: "Forbidden: bot was blocked by the user", want: true},
+		{description: "Forbidden: user is deactivated", want: true},
+		{description: "Forbidden: bot was kicked from the group chat", want: false},
+		{description: "Forbidden: bot can't initiate conversation with a user", want: false},
+		{description: "Forbidden: bot is not a member of the channel chat", want: false},
+		{description: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := isBlocked(tt.description); got != tt.want {
+				t.Errorf("isBlocked(%q) = %v, want %v", tt.description, got, tt.want)
+			}
+		})
+	}
+}
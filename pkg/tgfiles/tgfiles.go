@@ -0,0 +1,182 @@
+package tgfiles
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTooLarge          = errors.New("file exceeds size limit")
+	ErrResumeUnsupported = errors.New("server does not support resuming downloads")
+)
+
+// Uploader is the destination of a download. Put must consume r until EOF;
+// the reader is fed straight from the Telegram response.
+type Uploader interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+}
+
+// Result describes a stored file
+type Result struct {
+	Key          string
+	FileID       string
+	FileUniqueID string
+	Size         int64
+	SHA256       string
+	ContentType  string
+}
+
+type Options struct {
+	MaxSize     int64
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Timeout     time.Duration
+}
+
+// Downloader streams Telegram files into object storage with retries.
+// Interrupted transfers resume with an HTTP Range request instead of
+// starting over, and the content is hashed on the fly.
+type Downloader struct {
+	api    *tgbotapi.BotAPI
+	client *http.Client
+	opts   Options
+	logger *zap.Logger
+}
+
+func New(api *tgbotapi.BotAPI, logger *zap.Logger, opts Options) *Downloader {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+
+	return &Downloader{
+		api:    api,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Download fetches the Telegram file and streams it to dst under key
+func (d *Downloader) Download(ctx context.Context, fileID, key, contentType string, dst Uploader) (*Result, error) {
+	file, err := d.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	size := int64(file.FileSize)
+	if d.opts.MaxSize > 0 && size > d.opts.MaxSize {
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrTooLarge, size, d.opts.MaxSize)
+	}
+
+	url := file.Link(d.api.Token)
+	hash := sha256.New()
+	pr, pw := io.Pipe()
+
+	// The producer writes into the pipe while the uploader reads from it,
+	// so the file is never held in memory as a whole
+	go func() {
+		written, err := d.fetch(ctx, url, io.MultiWriter(pw, hash))
+		if err == nil && size > 0 && written != size {
+			err = fmt.Errorf("short download: got %d of %d bytes", written, size)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if err := dst.Put(ctx, key, pr, size, contentType); err != nil {
+		pr.CloseWithError(err)
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return &Result{
+		Key:          key,
+		FileID:       file.FileID,
+		FileUniqueID: file.FileUniqueID,
+		Size:         size,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		ContentType:  contentType,
+	}, nil
+}
+
+// fetch copies the file to w, resuming from the last written byte on failure
+func (d *Downloader) fetch(ctx context.Context, url string, w io.Writer) (int64, error) {
+	var offset int64
+	var lastErr error
+
+	for attempt := 1; attempt <= d.opts.MaxAttempts; attempt++ {
+		n, err := d.fetchFrom(ctx, url, offset, w)
+		offset += n
+		if err == nil {
+			return offset, nil
+		}
+		if errors.Is(err, ErrTooLarge) || errors.Is(err, ErrResumeUnsupported) || errors.Is(err, io.ErrClosedPipe) || ctx.Err() != nil {
+			return offset, err
+		}
+		lastErr = err
+
+		d.logger.Warn("File download interrupted, resuming",
+			zap.Int("attempt", attempt),
+			zap.Int64("offset", offset),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return offset, ctx.Err()
+		case <-time.After(d.opts.RetryDelay * time.Duration(attempt)):
+		}
+	}
+
+	return offset, fmt.Errorf("download failed after %d attempts: %w", d.opts.MaxAttempts, lastErr)
+}
+
+func (d *Downloader) fetchFrom(ctx context.Context, url string, offset int64, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case offset == 0 && resp.StatusCode == http.StatusOK:
+	case offset > 0 && resp.StatusCode == http.StatusOK:
+		// Server ignored Range: the already streamed prefix can't be taken back
+		return 0, ErrResumeUnsupported
+	default:
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if d.opts.MaxSize > 0 {
+		// One extra byte tells "exactly at the limit" from "over the limit"
+		body = io.LimitReader(resp.Body, d.opts.MaxSize-offset+1)
+	}
+
+	n, err := io.Copy(w, body)
+	if d.opts.MaxSize > 0 && offset+n > d.opts.MaxSize {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,122 @@
+// Package units converts sizes between the units customers type and the
+// whole centimetres orders are stored, cut and priced in
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Unit is a length unit sizes can be entered and shown in
+type Unit string
+
+const (
+	MM Unit = "mm"
+	CM Unit = "cm"
+	M  Unit = "m"
+)
+
+// All lists the units in the order they are offered
+var All = []Unit{MM, CM, M}
+
+// perCM is how many of the unit make a centimetre
+var perCM = map[Unit]float64{MM: 10, CM: 1, M: 0.01}
+
+// suffixes are the spellings accepted after a number, latin and cyrillic
+var suffixes = map[string]Unit{
+	"mm": MM, "мм": MM,
+	"cm": CM, "см": CM,
+	"m": M, "м": M,
+}
+
+func (u Unit) Valid() bool {
+	_, ok := perCM[u]
+	return ok
+}
+
+// Parse reads a unit name such as "mm" or "см"
+func Parse(s string) (Unit, bool) {
+	u, ok := suffixes[strings.ToLower(strings.TrimSpace(s))]
+	return u, ok
+}
+
+// FromCM converts centimetres to the unit. The float error of the
+// factor is rounded off, so 70 cm is 0.7 m, not 0.7000000000000001.
+func (u Unit) FromCM(cm int) float64 {
+	return math.Round(float64(cm)*perCM[u]*10_000) / 10_000
+}
+
+// Number formats centimetres in the unit without trailing zeros, e.g.
+// "0.5" for 50 cm in metres
+func (u Unit) Number(cm int) string {
+	return strconv.FormatFloat(u.FromCM(cm), 'f', -1, 64)
+}
+
+// Example formats a size the way it is typed in the unit, e.g. "50x50"
+// for 5×5 cm in millimetres
+func (u Unit) Example(widthCM, heightCM int) string {
+	return u.Number(widthCM) + "x" + u.Number(heightCM)
+}
+
+// ParseLength reads a length like "50", "0.5m" or "120 мм"; a number
+// without a unit is in def. The result is rounded up to whole centimetres,
+// the precision sizes are cut with.
+func ParseLength(raw string, def Unit) (int, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	end := strings.IndexFunc(raw, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	number, suffix := raw, ""
+	if end >= 0 {
+		number, suffix = raw[:end], strings.TrimSpace(raw[end:])
+	}
+
+	unit := def
+	if suffix != "" {
+		var ok bool
+		if unit, ok = suffixes[suffix]; !ok {
+			return 0, false
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil || value <= 0 || !unit.Valid() {
+		return 0, false
+	}
+	// Allow for float error, so 0.07 m stays 7 cm
+	cm := math.Ceil(value/perCM[unit] - 1e-9)
+	if cm > math.MaxInt32 {
+		return 0, false
+	}
+	return int(cm), true
+}
+
+// ParseSize reads "WxH" with latin, cyrillic, × or * separators. A unit
+// after the height applies to both sides, so "50x70mm" and "50mm x 70mm"
+// are the same size.
+func ParseSize(raw string, def Unit) (widthCM, heightCM int, ok bool) {
+	raw = strings.NewReplacer("х", "x", "Х", "x", "X", "x", "×", "x", "*", "x").Replace(strings.TrimSpace(raw))
+	w, h, found := strings.Cut(raw, "x")
+	if !found {
+		return 0, 0, false
+	}
+
+	// The height's unit is the default of a bare width
+	heightUnit := def
+	if i := strings.IndexFunc(h, unicode.IsLetter); i >= 0 {
+		var known bool
+		if heightUnit, known = Parse(h[i:]); !known {
+			return 0, 0, false
+		}
+	}
+
+	if widthCM, ok = ParseLength(w, heightUnit); !ok {
+		return 0, 0, false
+	}
+	if heightCM, ok = ParseLength(h, def); !ok {
+		return 0, 0, false
+	}
+	return widthCM, heightCM, true
+}
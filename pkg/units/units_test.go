@@ -0,0 +1,120 @@
+package units
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   Unit
+		wantOK bool
+	}{
+		{in: "mm", want: MM, wantOK: true},
+		{in: "см", want: CM, wantOK: true},
+		{in: " СМ ", want: CM, wantOK: true},
+		{in: "M", want: M, wantOK: true},
+		{in: "км", wantOK: false},
+		{in: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := Parse(tt.in)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Parse(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		raw    string
+		def    Unit
+		want   int
+		wantOK bool
+	}{
+		{raw: "50", def: CM, want: 50, wantOK: true},
+		{raw: "50", def: MM, want: 5, wantOK: true},
+		{raw: "0.5m", def: CM, want: 50, wantOK: true},
+		{raw: "120 мм", def: CM, want: 12, wantOK: true},
+		{raw: "0,07 м", def: CM, want: 7, wantOK: true},
+		{raw: "0.07m", def: CM, want: 7, wantOK: true},
+		// Rounded up to whole centimetres
+		{raw: "125mm", def: CM, want: 13, wantOK: true},
+		{raw: "0.1", def: CM, want: 1, wantOK: true},
+		{raw: "", def: CM, wantOK: false},
+		{raw: "0", def: CM, wantOK: false},
+		{raw: "-5", def: CM, wantOK: false},
+		{raw: "5 km", def: CM, wantOK: false},
+		{raw: "five", def: CM, wantOK: false},
+		{raw: "5", def: Unit("in"), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := ParseLength(tt.raw, tt.def)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseLength(%q, %s) = %d, %v, want %d, %v", tt.raw, tt.def, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		raw        string
+		def        Unit
+		wantWidth  int
+		wantHeight int
+		wantOK     bool
+	}{
+		{raw: "50x70", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "50х70", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "50Х70", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "50×70", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "50*70", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "500x700", def: MM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		// The unit after the height applies to a bare width
+		{raw: "500x700mm", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "50mm x 70mm", def: CM, wantWidth: 5, wantHeight: 7, wantOK: true},
+		{raw: "0.5x0.7 m", def: CM, wantWidth: 50, wantHeight: 70, wantOK: true},
+		{raw: "1mx50", def: CM, wantWidth: 100, wantHeight: 50, wantOK: true},
+		{raw: "50", def: CM, wantOK: false},
+		{raw: "50xabc", def: CM, wantOK: false},
+		{raw: "x70", def: CM, wantOK: false},
+		{raw: "0x70", def: CM, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			width, height, ok := ParseSize(tt.raw, tt.def)
+			if ok != tt.wantOK || width != tt.wantWidth || height != tt.wantHeight {
+				t.Errorf("ParseSize(%q, %s) = %d, %d, %v, want %d, %d, %v",
+					tt.raw, tt.def, width, height, ok, tt.wantWidth, tt.wantHeight, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "centimetres", got: CM.Number(7), want: "7"},
+		{name: "millimetres", got: MM.Number(7), want: "70"},
+		{name: "metres", got: M.Number(50), want: "0.5"},
+		{name: "example in millimetres", got: MM.Example(5, 5), want: "50x50"},
+		{name: "example in metres", got: M.Example(150, 70), want: "1.5x0.7"},
+		{name: "metres without float error", got: M.Number(70), want: "0.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}